@@ -8,8 +8,8 @@ import (
 	"strings"
 	"syscall"
 
-	"github.com/obra/packnplay/pkg/container"
 	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/runner"
 	"github.com/spf13/cobra"
 )
 
@@ -44,15 +44,15 @@ var attachCmd = &cobra.Command{
 			return fmt.Errorf("--worktree flag is required for attach")
 		}
 
-		// Generate container name
-		containerName := container.GenerateContainerName(workDir, worktreeName)
-
 		// Initialize Docker client
 		dockerClient, err := docker.NewClient(false)
 		if err != nil {
 			return fmt.Errorf("failed to initialize docker: %w", err)
 		}
 
+		// Generate container name
+		containerName := runner.ContainerName(dockerClient, workDir, worktreeName)
+
 		// Check if container is running
 		output, err := dockerClient.Run("ps", "--filter", fmt.Sprintf("name=%s", containerName), "--format", "{{.Names}}")
 		if err != nil {
@@ -63,22 +63,27 @@ var attachCmd = &cobra.Command{
 			return fmt.Errorf("no running container found for worktree '%s'", worktreeName)
 		}
 
-		// Execute docker exec with interactive shell
-		cmdPath, err := exec.LookPath(dockerClient.Command())
-		if err != nil {
-			return fmt.Errorf("failed to find docker command: %w", err)
-		}
+		return attachContainer(dockerClient, containerName)
+	},
+}
 
-		argv := []string{
-			filepath.Base(cmdPath),
-			"exec",
-			"-it",
-			containerName,
-			"/bin/bash",
-		}
+// attachContainer replaces packnplay with an interactive shell in a running container
+func attachContainer(dockerClient *docker.Client, containerName string) error {
+	cmdPath, err := exec.LookPath(dockerClient.Command())
+	if err != nil {
+		return fmt.Errorf("failed to find docker command: %w", err)
+	}
 
-		return syscall.Exec(cmdPath, argv, os.Environ())
-	},
+	argv := []string{
+		filepath.Base(cmdPath),
+		"exec",
+		"-it",
+		containerName,
+	}
+	// Runtimes without a detached TTY keep a tmux or screen session to join instead
+	argv = append(argv, runner.AttachCommand(dockerClient.Capabilities().DetachedTTY)...)
+
+	return syscall.Exec(cmdPath, argv, os.Environ())
 }
 
 func init() {
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/obra/packnplay/pkg/cache"
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cacheMaxSize     string
+	cacheClearGlobal bool
+	cacheClearPath   string
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the shared download cache and dependency caches",
+	Long: `Manage the cache shared by all containers for large downloads such as Playwright
+browsers and model weights. Enable it with "shared_cache": {"enabled": true} in the config
+file or --shared-cache on run; it is mounted at ` + cache.ContainerPath + `.
+
+Dependency caches are volumes mounted over package manager caches such as ~/.npm, per
+project or shared. Enable them with "dependency_caches": {"enabled": true} or
+--dependency-caches on run.`,
+}
+
+var cacheInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Show shared cache location and size",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := cache.HostDir()
+		if err != nil {
+			return err
+		}
+		size, err := cache.Usage(dir)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Host directory: %s\n", dir)
+		fmt.Printf("Container path: %s\n", cache.ContainerPath)
+		fmt.Printf("Size:           %s\n", cache.FormatSize(size))
+
+		if cfg, err := config.LoadWithoutRuntimeCheck(); err == nil {
+			status := "disabled"
+			if cfg.SharedCache.Enabled {
+				status = "enabled"
+			}
+			fmt.Printf("Status:         %s\n", status)
+			if cfg.SharedCache.MaxSize != "" {
+				fmt.Printf("Max size:       %s\n", cfg.SharedCache.MaxSize)
+			}
+		}
+
+		// Dependency caches live in volumes, so listing them needs the runtime
+		dockerClient, err := docker.NewClient(false)
+		if err != nil {
+			return nil
+		}
+		volumes, err := dependencyCacheVolumes(dockerClient)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\nDependency cache volumes: %d\n", len(volumes))
+		for _, volume := range volumes {
+			fmt.Printf("  %s\n", volume.name)
+		}
+		return nil
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear [cache...]",
+	Short: "Remove a project's dependency cache volumes",
+	Long: `Remove the dependency cache volumes of the project in the current directory (or
+--path), or with --global the ones all projects share. Name caches (npm, go-mod, ...) to
+remove only those. Volumes a container still uses are kept; stop it first.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workDir := cacheClearPath
+		if workDir == "" {
+			var err error
+			if workDir, err = os.Getwd(); err != nil {
+				return fmt.Errorf("failed to get working directory: %w", err)
+			}
+		}
+		workDir, err := filepath.Abs(workDir)
+		if err != nil {
+			return fmt.Errorf("failed to resolve path: %w", err)
+		}
+
+		dockerClient, err := docker.NewClient(false)
+		if err != nil {
+			return fmt.Errorf("failed to initialize docker: %w", err)
+		}
+		volumes, err := dependencyCacheVolumes(dockerClient)
+		if err != nil {
+			return err
+		}
+
+		prefix := cache.DependencyVolumePrefix(cacheClearGlobal, workDir)
+		wanted := make(map[string]bool)
+		for _, name := range args {
+			wanted[name] = true
+		}
+		removed := 0
+		for _, volume := range volumes {
+			if volume.name != prefix+"-"+volume.cache || (len(wanted) > 0 && !wanted[volume.cache]) {
+				continue
+			}
+			if output, err := dockerClient.Run("volume", "rm", volume.name); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to remove %s: %s\n", volume.name, strings.TrimSpace(output))
+				continue
+			}
+			removed++
+		}
+		fmt.Printf("Removed %d dependency cache volumes\n", removed)
+		return nil
+	},
+}
+
+// dependencyCacheVolume is a dependency cache volume and the cache it holds
+type dependencyCacheVolume struct {
+	name  string
+	cache string
+}
+
+// dependencyCacheVolumes lists the dependency cache volumes of every project
+func dependencyCacheVolumes(dockerClient *docker.Client) ([]dependencyCacheVolume, error) {
+	output, err := dockerClient.Run("volume", "ls", "--filter", "label="+cache.DependencyCacheLabel,
+		"--format", fmt.Sprintf("{{.Name}}\t{{.Label %q}}", cache.DependencyCacheLabel))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes: %w", err)
+	}
+	var volumes []dependencyCacheVolume
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if name, cacheName, ok := strings.Cut(line, "\t"); ok {
+			volumes = append(volumes, dependencyCacheVolume{name: name, cache: cacheName})
+		}
+	}
+	return volumes, nil
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove least recently used files from the shared cache",
+	Long: `Remove least recently used files until the shared cache fits in --max-size
+(default: shared_cache.max_size from the config file, or 0 to empty it).
+Files modified in the last few minutes are kept since a container may be writing them.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		maxSize := cacheMaxSize
+		if !cmd.Flags().Changed("max-size") {
+			if cfg, err := config.LoadWithoutRuntimeCheck(); err == nil && cfg.SharedCache.MaxSize != "" {
+				maxSize = cfg.SharedCache.MaxSize
+			}
+		}
+		maxBytes, err := cache.ParseSize(maxSize)
+		if err != nil {
+			return err
+		}
+
+		dir, err := cache.HostDir()
+		if err != nil {
+			return err
+		}
+		result, err := cache.Prune(dir, maxBytes, time.Now())
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Removed %d entries (%s), shared cache is now %s\n", result.Removed, cache.FormatSize(result.Freed), cache.FormatSize(result.Size))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheInfoCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+	cachePruneCmd.Flags().StringVar(&cacheMaxSize, "max-size", "0", "Target size, e.g. 10GB")
+	cacheClearCmd.Flags().BoolVar(&cacheClearGlobal, "global", false, "Remove the caches shared by all projects instead")
+	cacheClearCmd.Flags().StringVar(&cacheClearPath, "path", "", "Project directory (default: current directory)")
+}
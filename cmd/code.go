@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/runner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	codePath       string
+	codeWorktree   string
+	codeNoWorktree bool
+	codeRuntime    string
+	codeInsiders   bool
+	codePrint      bool
+	codeVerbose    bool
+)
+
+var codeCmd = &cobra.Command{
+	Use:   "code",
+	Short: "Open VS Code attached to the running container",
+	Long: `Open VS Code attached to the container already running for the current worktree,
+with the project folder open, using the Dev Containers extension's "Attach to
+Running Container". The extension must be installed.
+
+--print prints the vscode-remote:// folder URI instead, for opening it some other way.`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Runtime: flag > config (detected when neither is set)
+		runtime := codeRuntime
+		if runtime == "" {
+			if cfg, err := config.LoadWithoutRuntimeCheck(); err == nil {
+				runtime = cfg.ContainerRuntime
+			}
+		}
+
+		uri, err := runner.Code(&runner.CodeConfig{
+			Path:       codePath,
+			Worktree:   codeWorktree,
+			NoWorktree: codeNoWorktree,
+			Runtime:    runtime,
+			Insiders:   codeInsiders,
+			PrintOnly:  codePrint,
+			Verbose:    codeVerbose,
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			if uri != "" {
+				fmt.Fprintf(os.Stderr, "Folder URI: %s\n", uri)
+			}
+			os.Exit(1)
+		}
+		if codePrint {
+			fmt.Println(uri)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(codeCmd)
+
+	codeCmd.Flags().StringVar(&codePath, "path", "", "Project path (default: pwd)")
+	codeCmd.Flags().StringVar(&codeWorktree, "worktree", "", "Worktree name (default: current branch)")
+	codeCmd.Flags().BoolVar(&codeNoWorktree, "no-worktree", false, "Use the container started with --no-worktree")
+	codeCmd.Flags().StringVar(&codeRuntime, "runtime", "", "Container runtime to use (docker/podman/container)")
+	codeCmd.Flags().BoolVar(&codeInsiders, "insiders", false, "Open VS Code Insiders")
+	codeCmd.Flags().BoolVar(&codePrint, "print", false, "Print the folder URI instead of opening VS Code")
+	codeCmd.Flags().BoolVar(&codeVerbose, "verbose", false, "Show all docker commands")
+}
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configShowPath      string
+	configShowEffective bool
+	configListJSON      bool
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect, edit or convert packnplay configuration",
+	Long: `Inspect packnplay configuration, edit it from scripts, or convert its file format.
+Use packnplay configure to edit it interactively.
+
+Settings are addressed by dotted keys made of their names in the config file, e.g.
+default_container.image, env_configs.work.env_vars or labels.team.
+
+Settings come from three layers, each overriding the one before:
+  1. The global config (~/.config/packnplay/config.json)
+  2. The project's .packnplay.json, at the root of its git repository
+  3. Command-line flags`,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the global config, or the settings a project's runs start from",
+	Long: `Print the global config file as JSON.
+
+With --effective, print the settings packnplay run uses for the project after layering
+its .packnplay.json over the global config: image, default command, env, credentials,
+ports, mounts, groups, labels, ulimits, resource limits and timeout. Flags passed to
+packnplay run override these.`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		globalPath := config.GetConfigPath()
+		cfg, err := config.LoadExistingOrEmpty(globalPath)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		if !configShowEffective {
+			return printJSON(cmd.OutOrStdout(), cfg)
+		}
+
+		projectDir, err := findProjectDir(configShowPath)
+		if err != nil {
+			return err
+		}
+		projectCfg, err := config.LoadProjectConfig(projectDir)
+		if err != nil {
+			return err
+		}
+		// Shown the way packnplay run would use it, without asking about trust
+		projectCfg, _ = checkWorkspaceTrust(cfg, projectDir, projectCfg, nil, cmd.ErrOrStderr(), false)
+		eff, err := config.Layer(cfg, projectCfg, projectDir)
+		if err != nil {
+			return err
+		}
+
+		printConfigSources(cmd.ErrOrStderr(), globalPath, config.GetProjectConfigPath(projectDir))
+		return printJSON(cmd.OutOrStdout(), eff)
+	},
+}
+
+var configConvertCmd = &cobra.Command{
+	Use:   "convert <json|yaml|toml>",
+	Short: "Rewrite the global config file in another format",
+	Long: `Rewrite the global config file as config.json, config.yaml or config.toml. Every key
+is carried over, including ones this version of packnplay doesn't know. The old file is
+kept with a .bak suffix.
+
+packnplay reads whichever of config.json, config.yaml, config.yml and config.toml it
+finds first, in that order.`,
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format := args[0]
+		if format == "yml" {
+			format = config.FormatYAML
+		}
+		if !config.ValidFormat(format) {
+			return fmt.Errorf("unknown format '%s' (valid: json, yaml, toml)", args[0])
+		}
+
+		path := config.GetConfigPath()
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("no config file to convert at %s (run packnplay configure to create one)", path)
+		}
+		target, err := config.ConvertConfigFile(path, format)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Converted %s to %s (the old file is kept as %s.bak)\n", path, target, filepath.Base(path))
+		return nil
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print one setting from the global config",
+	Long: `Print the setting at key from the global config. Strings are printed as they are,
+other values as JSON. Fails when a map entry such as labels.team isn't set.`,
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadExistingOrEmpty(config.GetConfigPath())
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		value, ok, err := config.GetKey(cfg, args[0])
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%s is not set", args[0])
+		}
+		_, err = fmt.Fprintln(cmd.OutOrStdout(), formatConfigValue(value))
+		return err
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Change one setting in the global config",
+	Long: `Change the setting at key in the global config, keeping the rest of the file as it
+is. Lists of strings take comma-separated values or a JSON array; objects take JSON.
+
+Examples:
+  packnplay config set default_container.image ghcr.io/acme/agent:latest
+  packnplay config set default_env_vars ANTHROPIC_API_KEY,OPENAI_API_KEY
+  packnplay config set default_credentials.gh true
+  packnplay config set labels.team platform`,
+	Args:          cobra.ExactArgs(2),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return config.SetKey(config.GetConfigPath(), args[0], args[1])
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:           "unset <key>",
+	Short:         "Remove one setting from the global config, restoring its default",
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return config.UnsetKey(config.GetConfigPath(), args[0])
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Print every setting in the global config as key=value",
+	Long: `Print every setting in the global config with its dotted key, one per line. Empty
+settings are left out. With --json, print them as one JSON object keyed by dotted key.`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadExistingOrEmpty(config.GetConfigPath())
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		keys, err := config.ListKeys(cfg)
+		if err != nil {
+			return err
+		}
+		if configListJSON {
+			return printJSON(cmd.OutOrStdout(), keys)
+		}
+		for _, key := range config.SortedKeys(keys) {
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s=%s\n", key, formatConfigValue(keys[key]))
+		}
+		return nil
+	},
+}
+
+// formatConfigValue renders a setting for config get and list: strings as they are, other
+// values as compact JSON
+func formatConfigValue(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// printConfigSources notes which config files were layered, and which don't exist
+func printConfigSources(w io.Writer, paths ...string) {
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			_, _ = fmt.Fprintf(w, "# %s (not found)\n", path)
+		} else {
+			_, _ = fmt.Fprintf(w, "# %s\n", path)
+		}
+	}
+}
+
+// printJSON writes v as indented JSON
+func printJSON(w io.Writer, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configConvertCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configListCmd)
+
+	configShowCmd.Flags().StringVar(&configShowPath, "path", "", "Project path (default: pwd)")
+	configShowCmd.Flags().BoolVar(&configShowEffective, "effective", false, "Show the project's settings with .packnplay.json layered over the global config")
+	configListCmd.Flags().BoolVar(&configListJSON, "json", false, "Print the settings as a JSON object")
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/git"
+	"github.com/obra/packnplay/pkg/runner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configDoctorPath string
+	configDoctorFix  bool
+)
+
+var configDoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Find containers, worktrees and config left by older versions, and migrate them",
+	Long: `Check for what older packnplay versions left behind that doesn't match the current
+naming scheme and config format:
+  - containers named without the project hash (packnplay-<project>-<worktree>)
+  - worktrees of the project created next to it instead of in ~/.local/share/packnplay/worktrees
+  - the deprecated default_image setting, and config keys packnplay doesn't read
+
+With --fix, migrate what can be migrated without risk: stopped containers are renamed
+(along with their ~/.claude sandbox and logs), worktrees no running container uses are
+moved with git worktree move, and default_image becomes default_container.image when
+that keeps the same image. Everything else is reported with what to do by hand.
+Exits non-zero while anything is left to migrate.`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workDir := configDoctorPath
+		if workDir == "" {
+			var err error
+			workDir, err = os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get working directory: %w", err)
+			}
+		}
+		workDir, err := filepath.Abs(workDir)
+		if err != nil {
+			return fmt.Errorf("failed to resolve path: %w", err)
+		}
+
+		migrations, err := findMigrations(workDir, cmd.ErrOrStderr())
+		if err != nil {
+			return err
+		}
+		if len(migrations) == 0 {
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), "Nothing to migrate")
+			return nil
+		}
+
+		left := applyMigrations(cmd.OutOrStdout(), migrations, configDoctorFix)
+		if left == 0 {
+			return nil
+		}
+		fixable := 0
+		for _, m := range migrations {
+			if m.Apply != nil {
+				fixable++
+			}
+		}
+		if !configDoctorFix && fixable > 0 {
+			return fmt.Errorf("%d to migrate; packnplay config doctor --fix migrates %d of them", left, fixable)
+		}
+		return fmt.Errorf("%d left to migrate by hand", left)
+	},
+}
+
+// findMigrations collects the migrations of the config files, the containers and the
+// worktrees of the repository at workDir. Containers and worktrees are skipped, with a
+// warning, when there is no container runtime to ask which are running.
+func findMigrations(workDir string, warnings io.Writer) ([]config.Migration, error) {
+	migrations, err := config.ConfigMigrations(config.GetConfigPath())
+	if err != nil {
+		return nil, err
+	}
+
+	repoDir, repoErr := git.GetRepoRoot(workDir)
+	if repoErr == nil {
+		projectMigrations, err := config.ProjectConfigMigrations(repoDir)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, projectMigrations...)
+	}
+
+	cfg, _ := config.LoadExistingOrEmpty(config.GetConfigPath())
+	runtime := ""
+	if cfg != nil {
+		runtime = cfg.ContainerRuntime
+	}
+	dockerClient, err := docker.NewClientWithRuntime(runtime, false)
+	if err != nil {
+		_, _ = fmt.Fprintf(warnings, "Warning: not checking containers and worktrees: %v\n", err)
+		return migrations, nil
+	}
+
+	containerMigrations, err := runner.ContainerMigrations(dockerClient)
+	if err != nil {
+		return nil, err
+	}
+	migrations = append(migrations, containerMigrations...)
+
+	if repoErr == nil {
+		worktreeMigrations, err := runner.WorktreeMigrations(dockerClient, repoDir)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, worktreeMigrations...)
+	}
+	return migrations, nil
+}
+
+// applyMigrations reports each migration, applying the ones that can be when fix is set,
+// and returns how many are left
+func applyMigrations(w io.Writer, migrations []config.Migration, fix bool) int {
+	left := 0
+	for _, m := range migrations {
+		_, _ = fmt.Fprintf(w, "%s: %s\n", m.Subject, m.Problem)
+		if !fix || m.Apply == nil {
+			_, _ = fmt.Fprintf(w, "  Fix: %s\n", m.Fix)
+			left++
+			continue
+		}
+		if err := m.Apply(); err != nil {
+			_, _ = fmt.Fprintf(w, "  Failed to %s: %v\n", m.Fix, err)
+			left++
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "  Fixed: %s\n", m.Fix)
+	}
+	return left
+}
+
+func init() {
+	configCmd.AddCommand(configDoctorCmd)
+	configDoctorCmd.Flags().StringVar(&configDoctorPath, "path", "", "Project path for the worktree and .packnplay.json checks (default: pwd)")
+	configDoctorCmd.Flags().BoolVar(&configDoctorFix, "fix", false, "Migrate what can be migrated without risk")
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/config"
+)
+
+func TestApplyMigrations(t *testing.T) {
+	applied := 0
+	migrations := []config.Migration{
+		{Subject: "packnplay-app-main", Problem: "has an old name", Fix: "rename it to packnplay-app-f227ecb4-main", Apply: func() error { applied++; return nil }},
+		{Subject: "packnplay-app-fix", Problem: "has an old name", Fix: "stop it first"},
+		{Subject: "/src/app-x", Problem: "is an old worktree", Fix: "move it", Apply: func() error { return errors.New("locked") }},
+	}
+
+	var out bytes.Buffer
+	if left := applyMigrations(&out, migrations, false); left != 3 || applied != 0 {
+		t.Errorf("without fix: left = %d, applied = %d, want 3 and 0", left, applied)
+	}
+
+	out.Reset()
+	if left := applyMigrations(&out, migrations, true); left != 2 || applied != 1 {
+		t.Errorf("with fix: left = %d, applied = %d, want 2 and 1", left, applied)
+	}
+	for _, want := range []string{"Fixed: rename it", "Fix: stop it first", "Failed to move it: locked"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/config"
+)
+
+func TestConfigShowEffective(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	globalPath := config.GetConfigPath()
+	if err := os.MkdirAll(filepath.Dir(globalPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// The project is trusted, so its default command applies
+	projectDir := t.TempDir()
+	trusted, _ := json.Marshal([]string{projectDir})
+	if err := os.WriteFile(globalPath, []byte(`{"default_image": "ubuntu:24.04", "timeout": "2h", "default_resources": {"cpus": "2"}, "trusted_workspaces": `+string(trusted)+`}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(config.GetProjectConfigPath(projectDir), []byte(`{"timeout": "30m", "command": "npm test", "resources": {"memory": "4g"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	configShowPath, configShowEffective = projectDir, true
+	defer func() { configShowPath, configShowEffective = "", false }()
+
+	var out, errOut bytes.Buffer
+	configShowCmd.SetOut(&out)
+	configShowCmd.SetErr(&errOut)
+	defer configShowCmd.SetOut(nil)
+	defer configShowCmd.SetErr(nil)
+	if err := configShowCmd.RunE(configShowCmd, nil); err != nil {
+		t.Fatalf("config show --effective: %v", err)
+	}
+
+	var eff struct {
+		Image     string
+		Command   string
+		Timeout   string
+		Resources config.ResourcesConfig
+	}
+	if err := json.Unmarshal(out.Bytes(), &eff); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+	}
+	if eff.Image != "ubuntu:24.04" || eff.Command != "npm test" || eff.Timeout != "30m" {
+		t.Errorf("effective config = %+v", eff)
+	}
+	if eff.Resources != (config.ResourcesConfig{CPUs: "2", Memory: "4g"}) {
+		t.Errorf("effective resources = %+v", eff.Resources)
+	}
+	if want := "# " + globalPath + "\n# " + config.GetProjectConfigPath(projectDir) + "\n"; errOut.String() != want {
+		t.Errorf("sources = %q, want %q", errOut.String(), want)
+	}
+}
+
+func TestConfigConvert(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	globalPath := config.GetConfigPath()
+	if err := os.MkdirAll(filepath.Dir(globalPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(globalPath, []byte(`{"container_runtime": "docker", "default_resources": {"memory": "4g"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	configConvertCmd.SetOut(&out)
+	defer configConvertCmd.SetOut(nil)
+	if err := configConvertCmd.RunE(configConvertCmd, []string{"yml"}); err != nil {
+		t.Fatalf("config convert: %v", err)
+	}
+
+	if got := config.GetConfigPath(); filepath.Base(got) != "config.yaml" {
+		t.Fatalf("GetConfigPath() after convert = %q", got)
+	}
+	cfg, err := config.LoadConfigFromFile(config.GetConfigPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ContainerRuntime != "docker" || cfg.DefaultResources.Memory != "4g" {
+		t.Errorf("converted config = %+v", cfg)
+	}
+
+	if err := configConvertCmd.RunE(configConvertCmd, []string{"ini"}); err == nil {
+		t.Error("unknown format should be rejected")
+	}
+}
+
+func TestConfigSetGetList(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if err := configSetCmd.RunE(configSetCmd, []string{"default_container.image", "foo:latest"}); err != nil {
+		t.Fatalf("config set: %v", err)
+	}
+	if err := configSetCmd.RunE(configSetCmd, []string{"default_credentials.gh", "true"}); err != nil {
+		t.Fatalf("config set: %v", err)
+	}
+	if err := configSetCmd.RunE(configSetCmd, []string{"default_container.tag", "x"}); err == nil {
+		t.Error("config set of an unknown key should fail")
+	}
+
+	var out bytes.Buffer
+	configGetCmd.SetOut(&out)
+	defer configGetCmd.SetOut(nil)
+	if err := configGetCmd.RunE(configGetCmd, []string{"default_container.image"}); err != nil {
+		t.Fatalf("config get: %v", err)
+	}
+	if out.String() != "foo:latest\n" {
+		t.Errorf("config get = %q", out.String())
+	}
+
+	out.Reset()
+	configListCmd.SetOut(&out)
+	defer configListCmd.SetOut(nil)
+	if err := configListCmd.RunE(configListCmd, nil); err != nil {
+		t.Fatalf("config list: %v", err)
+	}
+	for _, want := range []string{"default_container.image=foo:latest\n", "default_credentials.gh=true\n"} {
+		if !bytes.Contains(out.Bytes(), []byte(want)) {
+			t.Errorf("config list missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	configListJSON = true
+	defer func() { configListJSON = false }()
+	if err := configListCmd.RunE(configListCmd, nil); err != nil {
+		t.Fatalf("config list --json: %v", err)
+	}
+	var keys map[string]any
+	if err := json.Unmarshal(out.Bytes(), &keys); err != nil {
+		t.Fatalf("config list --json is not JSON: %v", err)
+	}
+	if keys["default_credentials.gh"] != true {
+		t.Errorf("config list --json = %v", keys)
+	}
+
+	if err := configUnsetCmd.RunE(configUnsetCmd, []string{"default_container.image"}); err != nil {
+		t.Fatalf("config unset: %v", err)
+	}
+	if err := configGetCmd.RunE(configGetCmd, []string{"labels.team"}); err == nil {
+		t.Error("config get of an unset label should fail")
+	}
+}
@@ -2,12 +2,20 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/git"
 	"github.com/spf13/cobra"
 )
 
-var configureVerbose bool
+var (
+	configureVerbose bool
+	configureProject bool
+	configureSection string
+	configureNoTUI   bool
+)
 
 var configureCmd = &cobra.Command{
 	Use:   "configure",
@@ -21,16 +29,34 @@ Shows all configuration options in a logical flow:
   1. Container runtime selection
   2. Default credential mounting preferences
   3. Default container image and update settings
+  4. Environment profiles (env_configs) used with --config
 
 This command preserves all existing configuration values not displayed
 in the interactive forms, ensuring manual edits and advanced settings
-are never lost during configuration updates.`,
+are never lost during configuration updates.
+
+Use --section to edit a single part of the configuration:
+  runtime, credentials, container, env
+
+With --project, edits the repo-local .packnplay.json instead (image, mounts,
+ports and credential overrides for this project only).
+
+Plain text prompts are used instead of the full-screen editor with --no-tui,
+on dumb terminals, or when input/output is not a terminal.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runInteractiveConfigure(configureVerbose)
+		config.DisableTUI = configureNoTUI
+
+		if configureProject {
+			if configureSection != "" {
+				return fmt.Errorf("--section cannot be combined with --project")
+			}
+			return runProjectConfigure(configureVerbose)
+		}
+		return runInteractiveConfigure(configureSection, configureVerbose)
 	},
 }
 
-func runInteractiveConfigure(verbose bool) error {
+func runInteractiveConfigure(section string, verbose bool) error {
 	configPath := config.GetConfigPath()
 
 	if verbose {
@@ -43,8 +69,55 @@ func runInteractiveConfigure(verbose bool) error {
 		return fmt.Errorf("failed to load existing config: %w", err)
 	}
 
-	// Run complete configuration flow
-	return configureAll(existingConfig, configPath, verbose)
+	switch section {
+	case "":
+		// Run complete configuration flow
+		return configureAll(existingConfig, configPath, verbose)
+	case "runtime":
+		return configureRuntime(existingConfig, configPath, verbose)
+	case "credentials":
+		return configureCredentials(existingConfig, configPath, verbose)
+	case "container":
+		return configureContainer(existingConfig, configPath, verbose)
+	case "env":
+		return configureEnv(existingConfig, configPath, verbose)
+	}
+	return fmt.Errorf("unknown section '%s' (valid: runtime, credentials, container, env)", section)
+}
+
+func runProjectConfigure(verbose bool) error {
+	projectDir, err := findProjectDir("")
+	if err != nil {
+		return err
+	}
+
+	if verbose {
+		fmt.Printf("Editing project config: %s\n", config.GetProjectConfigPath(projectDir))
+	}
+
+	// Global config supplies the inherited values shown in the modal
+	globalConfig, err := config.LoadExistingOrEmpty(config.GetConfigPath())
+	if err != nil {
+		return fmt.Errorf("failed to load existing config: %w", err)
+	}
+
+	return config.RunProjectConfiguration(globalConfig, projectDir, verbose)
+}
+
+// findProjectDir returns the repo root containing dir (default: pwd), or dir itself outside git
+func findProjectDir(dir string) (string, error) {
+	if dir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get working directory: %w", err)
+		}
+		dir = cwd
+	}
+
+	if root, err := git.GetRepoRoot(dir); err == nil {
+		return root, nil
+	}
+	return filepath.Abs(dir)
 }
 
 // configureAll implements the complete configuration flow
@@ -52,7 +125,30 @@ func configureAll(existing *config.Config, configPath string, verbose bool) erro
 	return config.RunInteractiveConfiguration(existing, configPath, verbose)
 }
 
+// configureRuntime edits only the container runtime
+func configureRuntime(existing *config.Config, configPath string, verbose bool) error {
+	return config.RunSectionConfiguration(existing, configPath, "runtime", verbose)
+}
+
+// configureCredentials edits only the default credential mounts
+func configureCredentials(existing *config.Config, configPath string, verbose bool) error {
+	return config.RunSectionConfiguration(existing, configPath, "credentials", verbose)
+}
+
+// configureContainer edits only the default container image and update settings
+func configureContainer(existing *config.Config, configPath string, verbose bool) error {
+	return config.RunSectionConfiguration(existing, configPath, "container", verbose)
+}
+
+// configureEnv edits only the environment profiles
+func configureEnv(existing *config.Config, configPath string, verbose bool) error {
+	return config.RunSectionConfiguration(existing, configPath, "env", verbose)
+}
+
 func init() {
 	rootCmd.AddCommand(configureCmd)
 	configureCmd.Flags().BoolVarP(&configureVerbose, "verbose", "v", false, "Show detailed output")
+	configureCmd.Flags().StringVar(&configureSection, "section", "", "Edit a single section: runtime, credentials, container, env")
+	configureCmd.Flags().BoolVar(&configureNoTUI, "no-tui", false, "Use plain text prompts instead of the full-screen editor")
+	configureCmd.Flags().BoolVar(&configureProject, "project", false, "Edit the project's .packnplay.json instead of the global config")
 }
\ No newline at end of file
@@ -34,10 +34,10 @@ func TestConfigureCommand(t *testing.T) {
 func TestConfigureCommandFlags(t *testing.T) {
 	// Test configure command flags (simplified)
 
-	// Should NOT have section flag (simplified)
+	// Should have section flag for editing a single section
 	flag := configureCmd.Flags().Lookup("section")
-	if flag != nil {
-		t.Error("configure command should not have --section flag (simplified design)")
+	if flag == nil {
+		t.Error("configure command should have --section flag")
 	}
 
 	// Should have verbose flag
@@ -45,6 +45,21 @@ func TestConfigureCommandFlags(t *testing.T) {
 	if flag == nil {
 		t.Error("configure command should have --verbose flag")
 	}
+
+	// Should have project flag for editing .packnplay.json
+	flag = configureCmd.Flags().Lookup("project")
+	if flag == nil {
+		t.Error("configure command should have --project flag")
+	}
+}
+
+func TestConfigureUnknownSection(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	err := runInteractiveConfigure("bogus", false)
+	if err == nil || !strings.Contains(err.Error(), "unknown section") {
+		t.Errorf("runInteractiveConfigure(bogus) error = %v, want unknown section", err)
+	}
 }
 
 // Helper function
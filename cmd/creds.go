@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/diagnostics"
+	"github.com/obra/packnplay/pkg/runner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	credsTestPath     string
+	credsTestRuntime  string
+	credsTestHostOnly bool
+	credsTestVerbose  bool
+)
+
+// credsCheck tests one kind of credential: the same script runs on the host and in a
+// throwaway container started with only that credential enabled
+type credsCheck struct {
+	kind         string
+	tool         string // command the script needs
+	script       string // exits 0 when the credential works
+	enable       func(creds *config.Credentials, defaults config.Credentials)
+	hostFix      string // how to fix the credential on the host
+	containerFix string // what to look at when it works on the host but not in the container
+}
+
+// sshGitHubScript succeeds when GitHub accepts the ssh key; ssh -T exits 1 even then. The
+// host key isn't checked or recorded, so the test leaves known_hosts alone, and BatchMode
+// keeps ssh from prompting.
+const sshGitHubScript = `out=$(ssh -T -o BatchMode=yes -o UserKnownHostsFile=/dev/null -o StrictHostKeyChecking=no -o LogLevel=ERROR git@github.com 2>&1); status=$?
+echo "$out"
+[ $status -eq 127 ] && exit 127
+case "$out" in *"successfully authenticated"*) exit 0 ;; esac
+exit 1`
+
+var credsChecks = []credsCheck{
+	{
+		kind:         "aws",
+		tool:         "aws",
+		script:       "aws sts get-caller-identity",
+		enable:       func(c *config.Credentials, _ config.Credentials) { c.AWS = true },
+		hostFix:      "log in on the host (aws sso login, or check AWS_PROFILE and its credential_process)",
+		containerFix: "run with --aws-creds --verbose to see what is mounted and injected; --strict-creds shows what couldn't be",
+	},
+	{
+		kind:         "gh",
+		tool:         "gh",
+		script:       "gh auth status",
+		enable:       func(c *config.Credentials, _ config.Credentials) { c.GH = true },
+		hostFix:      "gh auth login",
+		containerFix: "run with --gh-creds --verbose; on macOS the token is copied from the keychain after the container starts",
+	},
+	{
+		kind:         "claude",
+		tool:         "sh",
+		script:       `test -s "$HOME/.claude/.credentials.json" || test -n "$ANTHROPIC_API_KEY" || { command -v security >/dev/null && security find-generic-password -s "Claude Code-credentials" >/dev/null 2>&1; }`,
+		enable:       func(*config.Credentials, config.Credentials) {},
+		hostFix:      "log in with claude on the host, or set ANTHROPIC_API_KEY",
+		containerFix: "check that ANTHROPIC_API_KEY is in default_env_vars, or log in once inside a container",
+	},
+	{
+		kind:         "npm",
+		tool:         "npm",
+		script:       "npm whoami",
+		enable:       func(c *config.Credentials, _ config.Credentials) { c.NPM = true },
+		hostFix:      "npm login",
+		containerFix: "run with --npm-creds --verbose; ~/.npmrc must be a file the container can read",
+	},
+	{
+		kind:   "ssh",
+		tool:   "ssh",
+		script: sshGitHubScript,
+		enable: func(c *config.Credentials, defaults config.Credentials) {
+			// Test the way runs get ssh access: the forwarded agent when that's configured
+			c.SSHAgent = defaults.SSHAgent
+			c.SSH = !defaults.SSHAgent
+		},
+		hostFix:      "add a key GitHub knows to ~/.ssh or the ssh-agent (ssh-add)",
+		containerFix: "run with --ssh-creds or --ssh-agent and --verbose; agent forwarding needs Linux or Docker Desktop",
+	},
+}
+
+var credsCmd = &cobra.Command{
+	Use:   "creds",
+	Short: "Troubleshoot credentials",
+}
+
+var credsTestCmd = &cobra.Command{
+	Use:   "test [aws|gh|claude|npm|ssh]...",
+	Short: "Check that credentials work on the host and inside a container",
+	Long: `Check credentials link by link: first on the host (aws sts get-caller-identity,
+gh auth status, npm whoami, ssh -T git@github.com, the Claude login), then inside a
+throwaway container of the current project started with only that credential enabled.
+A failure on the host means the credential itself is broken; one only in the container
+means it isn't reaching the container. All kinds are tested when none are given.
+Exits non-zero if any check fails.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		checks, err := selectCredsChecks(args)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.LoadWithoutRuntimeCheck()
+		if err != nil {
+			cfg = &config.Config{}
+		}
+		runtime := credsTestRuntime
+		if runtime == "" {
+			runtime = cfg.ContainerRuntime
+		}
+
+		var reports []diagnostics.Report
+		for _, check := range checks {
+			hostOutput, hostCode := runHostCheck(check)
+			host := hostResult(check, hostCode, hostOutput)
+			reports = append(reports, diagnostics.Report{Check: check.kind + " on host", Result: host})
+
+			var inContainer diagnostics.Result
+			switch {
+			case credsTestHostOnly:
+				inContainer = diagnostics.Result{Status: diagnostics.Skip, Message: "--host-only"}
+			case host.Status == diagnostics.Fail:
+				inContainer = diagnostics.Result{Status: diagnostics.Skip, Message: "fix the host first"}
+			default:
+				fmt.Fprintf(os.Stderr, "Checking %s in a throwaway container...\n", check.kind)
+				inContainer = containerResult(check, runContainerCheck(check, cfg, runtime))
+			}
+			reports = append(reports, diagnostics.Report{Check: check.kind + " in container", Result: inContainer})
+		}
+
+		fmt.Println()
+		printReports(os.Stdout, reports)
+		if diagnostics.Failed(reports) {
+			return fmt.Errorf("some credential checks failed")
+		}
+		return nil
+	},
+}
+
+// selectCredsChecks returns the checks for kinds, or all of them when none are given
+func selectCredsChecks(kinds []string) ([]credsCheck, error) {
+	if len(kinds) == 0 {
+		return credsChecks, nil
+	}
+	var selected []credsCheck
+	for _, kind := range kinds {
+		found := false
+		for _, check := range credsChecks {
+			if check.kind == kind {
+				selected = append(selected, check)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown credential '%s' (valid: aws, gh, claude, npm, ssh)", kind)
+		}
+	}
+	return selected, nil
+}
+
+// runHostCheck runs a check's script on the host, returning its output and exit code
+func runHostCheck(check credsCheck) (string, int) {
+	output, err := exec.Command("sh", "-c", check.script).CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(output), exitErr.ExitCode()
+	}
+	if err != nil {
+		return err.Error(), 127
+	}
+	return string(output), 0
+}
+
+// runContainerCheck runs a check's script as the command of a --rm container of the
+// project, with only the checked credential enabled. Its output goes to the terminal.
+func runContainerCheck(check credsCheck, cfg *config.Config, runtime string) error {
+	var creds config.Credentials
+	check.enable(&creds, cfg.DefaultCredentials)
+	return runner.Run(&runner.RunConfig{
+		Path:           credsTestPath,
+		NoWorktree:     true,
+		Verbose:        credsTestVerbose,
+		Runtime:        runtime,
+		DefaultImage:   cfg.GetDefaultImage(),
+		Command:        []string{"sh", "-c", check.script},
+		Credentials:    creds,
+		DefaultEnvVars: cfg.DefaultEnvVars,
+		AWSProfile:     os.Getenv("AWS_PROFILE"),
+		ClaudeSync:     cfg.ClaudeSync,
+		Ephemeral:      true,
+		Summary:        config.SummaryOff,
+		SkipPreflight:  true,
+	})
+}
+
+// hostResult interprets the exit code of a check run on the host
+func hostResult(check credsCheck, code int, output string) diagnostics.Result {
+	switch code {
+	case 0:
+		return diagnostics.Result{Status: diagnostics.OK, Message: "works"}
+	case 127:
+		return diagnostics.Result{Status: diagnostics.Fail, Message: check.tool + " is not installed on the host", Fix: "install " + check.tool}
+	}
+	message := "doesn't work"
+	if line := diagnostics.FirstLine(output, nil); line != "" {
+		message += ": " + line
+	}
+	return diagnostics.Result{Status: diagnostics.Fail, Message: message, Fix: check.hostFix}
+}
+
+// containerResult interprets how a check run in a container ended
+func containerResult(check credsCheck, err error) diagnostics.Result {
+	var exitErr *runner.ExitError
+	switch {
+	case err == nil:
+		return diagnostics.Result{Status: diagnostics.OK, Message: "works"}
+	case errors.As(err, &exitErr) && exitErr.Code == 127:
+		return diagnostics.Result{Status: diagnostics.Fail, Message: "the image has no " + check.tool + " command", Fix: "install it in the devcontainer, or use the full default image (--image-variant=full)"}
+	case errors.As(err, &exitErr):
+		return diagnostics.Result{Status: diagnostics.Fail, Message: "works on the host but not in the container", Fix: check.containerFix}
+	}
+	return diagnostics.Result{Status: diagnostics.Fail, Message: "couldn't start a container: " + diagnostics.FirstLine("", err), Fix: "packnplay doctor"}
+}
+
+func init() {
+	rootCmd.AddCommand(credsCmd)
+	credsCmd.AddCommand(credsTestCmd)
+	credsTestCmd.Flags().StringVar(&credsTestPath, "path", "", "Project whose container to test in (default: pwd)")
+	credsTestCmd.Flags().StringVar(&credsTestRuntime, "runtime", "", "Container runtime to use (docker/podman/container)")
+	credsTestCmd.Flags().BoolVar(&credsTestHostOnly, "host-only", false, "Only check the host, without starting a container")
+	credsTestCmd.Flags().BoolVarP(&credsTestVerbose, "verbose", "v", false, "Show how the container is set up")
+}
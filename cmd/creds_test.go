@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/diagnostics"
+	"github.com/obra/packnplay/pkg/runner"
+)
+
+func TestSelectCredsChecks(t *testing.T) {
+	all, err := selectCredsChecks(nil)
+	if err != nil || len(all) != len(credsChecks) {
+		t.Fatalf("selectCredsChecks(nil) = %d checks, %v; want all %d", len(all), err, len(credsChecks))
+	}
+
+	selected, err := selectCredsChecks([]string{"ssh", "aws"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(selected) != 2 || selected[0].kind != "ssh" || selected[1].kind != "aws" {
+		t.Errorf("selectCredsChecks() = %v, want ssh then aws", selected)
+	}
+
+	if _, err := selectCredsChecks([]string{"vault"}); err == nil {
+		t.Error("selectCredsChecks() accepted an unknown kind")
+	}
+}
+
+func TestCredsCheckEnable(t *testing.T) {
+	ssh, _ := selectCredsChecks([]string{"ssh"})
+
+	var creds config.Credentials
+	ssh[0].enable(&creds, config.Credentials{})
+	if !creds.SSH || creds.SSHAgent {
+		t.Errorf("ssh check without agent forwarding enabled %+v, want the mounted keys", creds)
+	}
+
+	creds = config.Credentials{}
+	ssh[0].enable(&creds, config.Credentials{SSHAgent: true})
+	if creds.SSH || !creds.SSHAgent {
+		t.Errorf("ssh check with agent forwarding enabled %+v, want the agent", creds)
+	}
+}
+
+func TestRunHostCheck(t *testing.T) {
+	tests := []struct {
+		script     string
+		wantCode   int
+		wantOutput string
+	}{
+		{"echo ok", 0, "ok\n"},
+		{"echo denied >&2; exit 1", 1, "denied\n"},
+		{"packnplay-no-such-command", 127, ""},
+	}
+	for _, tt := range tests {
+		output, code := runHostCheck(credsCheck{script: tt.script})
+		if code != tt.wantCode {
+			t.Errorf("runHostCheck(%q) code = %d, want %d", tt.script, code, tt.wantCode)
+		}
+		if tt.wantOutput != "" && output != tt.wantOutput {
+			t.Errorf("runHostCheck(%q) output = %q, want %q", tt.script, output, tt.wantOutput)
+		}
+	}
+}
+
+func TestSSHGitHubScriptLeavesKnownHosts(t *testing.T) {
+	bin := t.TempDir()
+	argsFile := filepath.Join(bin, "args")
+	fakeSSH := "#!/bin/sh\necho \"$@\" > " + argsFile + "\necho \"Hi dev! You've successfully authenticated\" >&2\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(bin, "ssh"), []byte(fakeSSH), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	if _, code := runHostCheck(credsCheck{script: sshGitHubScript}); code != 0 {
+		t.Errorf("ssh check code = %d, want 0", code)
+	}
+	args, _ := os.ReadFile(argsFile)
+	for _, want := range []string{"BatchMode=yes", "UserKnownHostsFile=/dev/null"} {
+		if !strings.Contains(string(args), want) {
+			t.Errorf("ssh args = %q, missing %s", args, want)
+		}
+	}
+	if strings.Contains(string(args), "accept-new") {
+		t.Errorf("ssh args = %q, would record the host key", args)
+	}
+}
+
+func TestHostResult(t *testing.T) {
+	check := credsCheck{tool: "gh", hostFix: "gh auth login"}
+
+	if got := hostResult(check, 0, ""); got.Status != diagnostics.OK {
+		t.Errorf("hostResult(0) = %+v, want ok", got)
+	}
+	if got := hostResult(check, 127, ""); got.Status != diagnostics.Fail || got.Message != "gh is not installed on the host" {
+		t.Errorf("hostResult(127) = %+v", got)
+	}
+	got := hostResult(check, 1, "\nYou are not logged into any GitHub hosts.\nRun gh auth login\n")
+	if got.Status != diagnostics.Fail || got.Message != "doesn't work: You are not logged into any GitHub hosts." || got.Fix != "gh auth login" {
+		t.Errorf("hostResult(1) = %+v", got)
+	}
+}
+
+func TestContainerResult(t *testing.T) {
+	check := credsCheck{tool: "aws", containerFix: "run with --aws-creds --verbose"}
+
+	tests := []struct {
+		name    string
+		err     error
+		status  diagnostics.Status
+		message string
+	}{
+		{"works", nil, diagnostics.OK, "works"},
+		{"tool missing", &runner.ExitError{Code: 127}, diagnostics.Fail, "the image has no aws command"},
+		{"not reaching the container", &runner.ExitError{Code: 254}, diagnostics.Fail, "works on the host but not in the container"},
+		{"no container", errors.New("failed to initialize container runtime\ndetails"), diagnostics.Fail, "couldn't start a container: failed to initialize container runtime"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := containerResult(check, tt.err)
+			if got.Status != tt.status || got.Message != tt.message {
+				t.Errorf("containerResult() = %+v, want %v %q", got, tt.status, tt.message)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/obra/packnplay/pkg/api"
+	"github.com/obra/packnplay/pkg/devproxy"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/runner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	daemonSocket      string
+	daemonProxy       bool
+	daemonProxyListen string
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Serve the local control API",
+	Long: `Serve a local control API on a unix socket so editor extensions and dashboards can
+list, start, stop and exec into containers without invoking the CLI for each action.
+
+Clients authenticate with the token stored in ~/.local/share/packnplay/api-token.
+
+With --proxy it also runs a reverse proxy that routes http://<worktree>.<project>.localhost
+to the lowest port that worktree's container publishes, and
+http://<port>.<worktree>.<project>.localhost to a given container port, so several
+worktrees of the same app can serve side by side.
+
+While it runs, it also follows container events: when a packnplay container is removed
+with the runtime's CLI instead of packnplay stop, its sidecars, services and agent links
+are removed too. Volumes holding its copy of the workspace are kept, since they may hold
+changes that were never exported.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dockerClient, err := docker.NewClient(false)
+		if err != nil {
+			return fmt.Errorf("failed to initialize docker: %w", err)
+		}
+		executable, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to get executable path: %w", err)
+		}
+
+		token, err := api.LoadOrCreateToken(api.TokenPath())
+		if err != nil {
+			return err
+		}
+
+		socketPath := daemonSocket
+		if socketPath == "" {
+			socketPath = api.SocketPath()
+		}
+		listener, err := api.Listen(socketPath)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = os.Remove(socketPath) }()
+
+		backend := &daemonBackend{dockerClient: dockerClient, executable: executable}
+		server := &http.Server{Handler: api.NewHandler(backend, token), ReadHeaderTimeout: 10 * time.Second}
+		servers := []*http.Server{server}
+
+		if daemonProxy {
+			proxyListener, err := net.Listen("tcp", daemonProxyListen)
+			if err != nil {
+				return fmt.Errorf("failed to listen on %s for the proxy: %w", daemonProxyListen, err)
+			}
+			proxyServer := &http.Server{Handler: devproxy.New(backend.proxyTargets), ReadHeaderTimeout: 10 * time.Second}
+			servers = append(servers, proxyServer)
+			go func() {
+				if err := proxyServer.Serve(proxyListener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					fmt.Fprintf(os.Stderr, "Warning: proxy stopped: %v\n", err)
+				}
+			}()
+			fmt.Fprintf(os.Stderr, "Proxying http://<worktree>.<project>.localhost:%s to containers\n", portOf(proxyListener.Addr()))
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		// Clean up after containers removed with docker rm rather than packnplay stop
+		if dockerClient.Capabilities().Events {
+			watcher := &containerWatcher{
+				docker:  dockerClient,
+				cleanup: func(name string) { runner.RemoveContainerSidecars(dockerClient, name) },
+				log:     os.Stderr,
+			}
+			go watcher.run(ctx)
+		}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			for _, server := range servers {
+				_ = server.Shutdown(shutdownCtx)
+			}
+		}()
+
+		fmt.Fprintf(os.Stderr, "Listening on %s (token in %s)\n", socketPath, api.TokenPath())
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("control API failed: %w", err)
+		}
+		return nil
+	},
+}
+
+// daemonBackend carries out control API requests with the docker CLI and packnplay itself
+type daemonBackend struct {
+	dockerClient *docker.Client
+	executable   string
+}
+
+func (b *daemonBackend) List() ([]api.Container, error) {
+	output, err := b.dockerClient.Run("ps", "--filter", "label=managed-by=packnplay", "--format", "{{json .}}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	var containers []api.Container
+	for _, line := range splitLines(output) {
+		if line == "" {
+			continue
+		}
+		var info ContainerInfo
+		if err := json.Unmarshal([]byte(line), &info); err != nil {
+			continue
+		}
+		project, worktree, hostPath, _ := parseLabelsWithLaunchInfo(info.Labels)
+		containers = append(containers, api.Container{
+			Name:     info.Names,
+			Status:   info.Status,
+			Project:  project,
+			Worktree: worktree,
+			HostPath: hostPath,
+		})
+	}
+	return containers, nil
+}
+
+// Start runs "packnplay run ... true", which starts the container (and worktree) the same
+// way the CLI would and leaves it running
+func (b *daemonBackend) Start(req api.StartRequest) (*api.CommandResult, error) {
+	args := []string{"run", "--path", req.Path}
+	if req.NoWorktree {
+		args = append(args, "--no-worktree")
+	} else if req.Worktree != "" {
+		args = append(args, "--worktree", req.Worktree)
+	}
+	args = append(args, "true")
+
+	cmd := exec.Command(b.executable, args...)
+	cmd.Dir = req.Path
+	return commandResult(cmd)
+}
+
+func (b *daemonBackend) Stop(name string, discard bool) error {
+	if err := b.requireManaged(name); err != nil {
+		return err
+	}
+	if !discard {
+		if err := runner.CheckExported(b.dockerClient, name); err != nil {
+			return fmt.Errorf("%w: %v; stop it with ?discard=true to drop them", api.ErrConflict, err)
+		}
+	}
+	// It may have gone away since it was listed
+	if err := stopContainer(b.dockerClient, name, true); errors.Is(err, docker.ErrNoSuchContainer) {
+		return fmt.Errorf("%w: %s", api.ErrNotFound, name)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (b *daemonBackend) Exec(name string, req api.ExecRequest) (*api.CommandResult, error) {
+	if err := b.requireManaged(name); err != nil {
+		return nil, err
+	}
+	args := append([]string{"exec", name}, req.Command...)
+	return commandResult(exec.Command(b.dockerClient.Command(), args...))
+}
+
+// proxyTargets lists managed containers with the TCP ports they publish, for the proxy
+func (b *daemonBackend) proxyTargets() ([]devproxy.Target, error) {
+	output, err := b.dockerClient.Run("ps", "--filter", "label=managed-by=packnplay", "--format", "{{json .}}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	var targets []devproxy.Target
+	for _, info := range parseContainerInfos(output) {
+		project, worktree := parseLabels(info.Labels)
+		target := devproxy.Target{Container: info.Names, Project: project, Worktree: worktree}
+		for _, p := range lookupPorts(b.dockerClient, info) {
+			port, protocol, _ := strings.Cut(p.ContainerPort, "/")
+			containerPort, err := strconv.Atoi(port)
+			if err != nil || protocol != "tcp" {
+				continue
+			}
+			target.Ports = append(target.Ports, devproxy.Port{ContainerPort: containerPort, HostAddr: dialAddr(p)})
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// dialAddr is where the host reaches a published port; wildcard bindings are reached on
+// the loopback address
+func dialAddr(p publishedPort) string {
+	switch p.HostIP {
+	case "", "0.0.0.0":
+		return net.JoinHostPort("127.0.0.1", p.HostPort)
+	case "::":
+		return net.JoinHostPort("::1", p.HostPort)
+	}
+	return net.JoinHostPort(p.HostIP, p.HostPort)
+}
+
+// portOf returns the port of a listener address
+func portOf(addr net.Addr) string {
+	_, port, _ := net.SplitHostPort(addr.String())
+	return port
+}
+
+// requireManaged keeps API clients away from containers packnplay didn't start
+func (b *daemonBackend) requireManaged(name string) error {
+	containers, err := b.List()
+	if err != nil {
+		return err
+	}
+	for _, c := range containers {
+		if c.Name == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", api.ErrNotFound, name)
+}
+
+// commandResult runs cmd and reports its combined output and exit status
+func commandResult(cmd *exec.Cmd) (*api.CommandResult, error) {
+	output, err := cmd.CombinedOutput()
+	result := &api.CommandResult{Output: string(output)}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		result.ExitCode = exitErr.ExitCode()
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %s: %w", cmd.Path, err)
+	}
+	return result, nil
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+
+	daemonCmd.Flags().StringVar(&daemonSocket, "socket", "", "Socket path (default: $XDG_RUNTIME_DIR/packnplay/api.sock)")
+	daemonCmd.Flags().BoolVar(&daemonProxy, "proxy", false, "Also route http://<worktree>.<project>.localhost to containers' published ports")
+	daemonCmd.Flags().StringVar(&daemonProxyListen, "proxy-listen", devproxy.DefaultListen, "Address the proxy listens on")
+}
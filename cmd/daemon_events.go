@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/obra/packnplay/pkg/credentials"
+	"github.com/obra/packnplay/pkg/dind"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/egress"
+	"github.com/obra/packnplay/pkg/runner"
+	"github.com/obra/packnplay/pkg/services"
+	"github.com/obra/packnplay/pkg/sshserver"
+)
+
+// sidecarLabels mark what packnplay starts next to a container with that container's name
+var sidecarLabels = []string{egress.ForLabel, dind.ForLabel, services.ForLabel, sshserver.ForLabel}
+
+// reconnectDelay is how long the watcher waits before subscribing again after the event
+// stream ends, as it does when the daemon restarts
+const reconnectDelay = 5 * time.Second
+
+// eventSource is the part of *docker.Client the watcher uses
+type eventSource interface {
+	Run(args ...string) (string, error)
+	Events(ctx context.Context, filters map[string][]string, handle func(docker.Event)) error
+}
+
+// containerWatcher keeps packnplay's state in step with containers removed with the
+// runtime's CLI (docker rm -f) instead of packnplay stop, which would otherwise leave
+// their sidecars, networks and agent links behind. Volumes holding a copy of the
+// workspace are never removed, since they may have the only copy of the agent's work.
+type containerWatcher struct {
+	docker  eventSource
+	cleanup func(containerName string) // runner.RemoveContainerSidecars; replaced in tests
+	log     io.Writer
+}
+
+// run reconciles once, then follows container events until ctx is done. Whenever the
+// stream is lost it reconciles again, since events may have been missed meanwhile.
+func (w *containerWatcher) run(ctx context.Context) {
+	filters := map[string][]string{"type": {"container"}, "label": {"managed-by=packnplay"}}
+	for {
+		w.reconcile()
+		err := w.docker.Events(ctx, filters, w.handle)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(w.log, "Warning: lost container events: %v; retrying in %s\n", err, reconnectDelay)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// handle cleans up after a managed container once it is gone. A container that only
+// stopped keeps its sidecars, so docker start brings it back as it was.
+func (w *containerWatcher) handle(event docker.Event) {
+	name := event.Actor.Attributes["name"]
+	if event.Action != "destroy" || name == "" {
+		return
+	}
+	// packnplay removes containers itself when restarting or recreating them, and by the
+	// time the event arrives the next run may have started its own
+	if w.replaced(name, event.Time()) {
+		return
+	}
+	w.cleanup(name)
+	fmt.Fprintf(w.log, "%s was removed; cleaned up its sidecars and local state\n", name)
+	if event.Actor.Attributes[credentials.FilesLabel] != "" {
+		fmt.Fprintf(w.log, "  Credential files it used may be left over; packnplay prune removes unused ones\n")
+	}
+	w.noteSyncVolumes(name)
+}
+
+// replaced reports whether a container named name exists again, or has sidecars created
+// since one by that name was destroyed. When docker can't tell, it is assumed to have.
+func (w *containerWatcher) replaced(name string, destroyed time.Time) bool {
+	output, err := w.docker.Run("ps", "-a", "--filter", "name=^"+name+"$", "--format", "{{.Names}}")
+	if err != nil || strings.TrimSpace(output) != "" {
+		return true
+	}
+	// CreatedAt is to the second, so one from the second it was destroyed in counts as newer
+	destroyed = destroyed.Truncate(time.Second)
+	for _, label := range sidecarLabels {
+		output, err := w.docker.Run("ps", "-a", "--filter", "label="+label+"="+name, "--format", "{{.CreatedAt}}")
+		if err != nil {
+			return true
+		}
+		for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+			if line == "" {
+				continue
+			}
+			created, err := parseCreatedAt(line)
+			if err != nil || !created.Before(destroyed) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// noteSyncVolumes points out the volumes left holding name's copy of the workspace
+func (w *containerWatcher) noteSyncVolumes(name string) {
+	output, err := w.docker.Run("volume", "ls", "-q", "--filter", "label="+runner.SyncVolumeLabel+"="+name)
+	if err != nil || strings.TrimSpace(output) == "" {
+		return
+	}
+	fmt.Fprintf(w.log, "  Its copy of the workspace is kept in volumes %s; docker volume rm them once it isn't needed\n", strings.Join(strings.Fields(output), ", "))
+}
+
+// orphanGrace spares sidecars started so recently that the run starting them may not have
+// created its container yet
+const orphanGrace = 10 * time.Minute
+
+// createdAtLayout is the CreatedAt format of docker ps
+const createdAtLayout = "2006-01-02 15:04:05 -0700 MST"
+
+// createdAtLayouts are the CreatedAt formats of the runtimes' ps. Podman prints Go's
+// time.String, with fractional seconds (which time.Parse accepts without them in the
+// layout) and, in zones without an abbreviation, the offset twice.
+var createdAtLayouts = []string{createdAtLayout, "2006-01-02 15:04:05 -0700 -0700", time.RFC3339Nano}
+
+// parseCreatedAt parses the CreatedAt of a ps listing
+func parseCreatedAt(value string) (time.Time, error) {
+	// A monotonic clock reading ("m=+0.5") may trail Go's time.String
+	value, _, _ = strings.Cut(strings.TrimSpace(value), " m=")
+	var err error
+	for _, layout := range createdAtLayouts {
+		var created time.Time
+		if created, err = time.Parse(layout, value); err == nil {
+			return created, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// reconcile cleans up after containers removed while nothing was watching: sidecars
+// whose container no longer exists
+func (w *containerWatcher) reconcile() {
+	output, err := w.docker.Run("ps", "-a", "--filter", "label=managed-by=packnplay", "--format", "{{.Names}}")
+	if err != nil {
+		fmt.Fprintf(w.log, "Warning: failed to list containers: %v\n", err)
+		return
+	}
+	existing := make(map[string]bool)
+	for _, name := range strings.Fields(output) {
+		existing[name] = true
+	}
+
+	orphaned := make(map[string]bool)
+	for _, label := range sidecarLabels {
+		output, err := w.docker.Run("ps", "-a", "--filter", "label="+label, "--format", fmt.Sprintf("{{.Label %q}}\t{{.CreatedAt}}", label))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(output, "\n") {
+			name, createdAt, _ := strings.Cut(strings.TrimSpace(line), "\t")
+			created, err := parseCreatedAt(createdAt)
+			if name == "" || existing[name] || err != nil || time.Since(created) < orphanGrace {
+				continue
+			}
+			orphaned[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(orphaned))
+	for name := range orphaned {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		w.cleanup(name)
+		fmt.Fprintf(w.log, "%s no longer exists; cleaned up its sidecars and local state\n", name)
+		w.noteSyncVolumes(name)
+	}
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/obra/packnplay/pkg/docker"
+)
+
+type fakeEventSource struct {
+	ps     map[string]string // ps and volume ls output by the filter, without label=
+	events []docker.Event
+}
+
+func (f *fakeEventSource) Run(args ...string) (string, error) {
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == "--filter" {
+			return f.ps[strings.TrimPrefix(args[i+1], "label=")], nil
+		}
+	}
+	return "", errors.New("unexpected command")
+}
+
+func (f *fakeEventSource) Events(ctx context.Context, filters map[string][]string, handle func(docker.Event)) error {
+	for _, event := range f.events {
+		handle(event)
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func event(action, name string, attributes map[string]string) docker.Event {
+	var e docker.Event
+	e.Type, e.Action = "container", action
+	e.TimeNano = time.Now().UnixNano()
+	e.Actor.Attributes = map[string]string{"name": name}
+	for key, value := range attributes {
+		e.Actor.Attributes[key] = value
+	}
+	return e
+}
+
+func TestContainerWatcherHandle(t *testing.T) {
+	old := time.Now().Add(-time.Hour).Format(createdAtLayout)
+	later := time.Now().Add(time.Minute).Format(createdAtLayout)
+	source := &fakeEventSource{ps: map[string]string{
+		"name=^packnplay-app-recreated$":              "packnplay-app-recreated\n",
+		"packnplay-egress-for=packnplay-app-feature":  old + "\n",
+		"packnplay-egress-for=packnplay-app-restart":  later + "\n",
+		"packnplay-sync-volume=packnplay-app-feature": "packnplay-app-feature-workspace\n",
+	}}
+	var cleaned []string
+	var log bytes.Buffer
+	w := &containerWatcher{docker: source, cleanup: func(name string) { cleaned = append(cleaned, name) }, log: &log}
+
+	w.handle(event("die", "packnplay-app-main", nil))
+	w.handle(event("destroy", "packnplay-app-recreated", nil))
+	w.handle(event("destroy", "packnplay-app-restart", nil))
+	w.handle(event("destroy", "packnplay-app-feature", map[string]string{"packnplay-credential-files": "/creds/gh"}))
+	if want := []string{"packnplay-app-feature"}; !reflect.DeepEqual(cleaned, want) {
+		t.Errorf("cleaned up %q, want only the removed container %q", cleaned, want)
+	}
+	if !strings.Contains(log.String(), "packnplay prune") {
+		t.Errorf("log = %q, want a hint about its credential files", log.String())
+	}
+	if !strings.Contains(log.String(), "packnplay-app-feature-workspace") {
+		t.Errorf("log = %q, want the kept workspace volume pointed out", log.String())
+	}
+}
+
+func TestContainerWatcherReconcile(t *testing.T) {
+	old := time.Now().Add(-time.Hour).Format(createdAtLayout)
+	recent := time.Now().Format(createdAtLayout)
+	source := &fakeEventSource{ps: map[string]string{
+		"managed-by=packnplay": "packnplay-app-main\n",
+		"packnplay-egress-for": "packnplay-app-main\t" + old + "\npacknplay-app-gone\t" + old + "\n",
+		"packnplay-dind-for":   "packnplay-app-gone\t" + old + "\npacknplay-app-starting\t" + recent + "\n",
+	}}
+	var cleaned []string
+	w := &containerWatcher{docker: source, cleanup: func(name string) { cleaned = append(cleaned, name) }, log: &bytes.Buffer{}}
+
+	w.reconcile()
+	if want := []string{"packnplay-app-gone"}; !reflect.DeepEqual(cleaned, want) {
+		t.Errorf("cleaned up %q, want %q", cleaned, want)
+	}
+}
+
+func TestParseCreatedAt(t *testing.T) {
+	want := time.Date(2026, 3, 1, 12, 0, 3, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value string
+		want  time.Time
+	}{
+		{"docker", "2026-03-01 12:00:03 +0000 UTC", want},
+		{"podman", "2026-03-01 12:00:03.123456789 +0000 UTC", want.Add(123456789)},
+		{"podman without a zone abbreviation", "2026-03-01 17:30:03.5 +0530 +0530", want.Add(500 * time.Millisecond)},
+		{"podman with a monotonic reading", "2026-03-01 12:00:03.25 +0000 UTC m=+0.004", want.Add(250 * time.Millisecond)},
+		{"inspect", "2026-03-01T12:00:03.000000001Z", want.Add(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCreatedAt(tt.value)
+			if err != nil || !got.Equal(tt.want) {
+				t.Errorf("parseCreatedAt(%q) = %v, %v; want %v", tt.value, got, err, tt.want)
+			}
+		})
+	}
+	if _, err := parseCreatedAt("3 hours ago"); err == nil {
+		t.Error("parseCreatedAt() accepted a relative time")
+	}
+}
+
+func TestContainerWatcherReconcilePodman(t *testing.T) {
+	// Podman's CreatedAt has fractional seconds, and an offset for the zone name in
+	// zones without an abbreviation
+	old := time.Now().Add(-time.Hour).In(time.FixedZone("", 5*3600+1800)).Format("2006-01-02 15:04:05.999999999 -0700 -0700")
+	source := &fakeEventSource{ps: map[string]string{
+		"managed-by=packnplay": "",
+		"packnplay-egress-for": "packnplay-app-gone\t" + old + "\n",
+	}}
+	var cleaned []string
+	w := &containerWatcher{docker: source, cleanup: func(name string) { cleaned = append(cleaned, name) }, log: &bytes.Buffer{}}
+
+	w.reconcile()
+	if want := []string{"packnplay-app-gone"}; !reflect.DeepEqual(cleaned, want) {
+		t.Errorf("cleaned up %q, want %q", cleaned, want)
+	}
+}
+
+func TestContainerWatcherRun(t *testing.T) {
+	source := &fakeEventSource{
+		ps:     map[string]string{},
+		events: []docker.Event{event("destroy", "packnplay-app-main", nil)},
+	}
+	cleaned := make(chan string, 1)
+	w := &containerWatcher{docker: source, cleanup: func(name string) { cleaned <- name }, log: &bytes.Buffer{}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.run(ctx)
+		close(done)
+	}()
+	if got := <-cleaned; got != "packnplay-app-main" {
+		t.Errorf("cleaned up %q", got)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("run() didn't return when its context was done")
+	}
+}
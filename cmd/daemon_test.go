@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestCommandResult(t *testing.T) {
+	result, err := commandResult(exec.Command("sh", "-c", "echo out; echo err >&2; exit 7"))
+	if err != nil {
+		t.Fatalf("commandResult() error = %v", err)
+	}
+	if result.ExitCode != 7 {
+		t.Errorf("ExitCode = %d, want 7", result.ExitCode)
+	}
+	if result.Output != "out\nerr\n" {
+		t.Errorf("Output = %q, want combined stdout and stderr", result.Output)
+	}
+
+	if _, err := commandResult(exec.Command("/nonexistent/packnplay")); err == nil {
+		t.Error("commandResult() succeeded for a missing binary")
+	}
+}
+
+func TestDialAddr(t *testing.T) {
+	tests := []struct {
+		port publishedPort
+		want string
+	}{
+		{publishedPort{HostIP: "0.0.0.0", HostPort: "49153"}, "127.0.0.1:49153"},
+		{publishedPort{HostIP: "::", HostPort: "49153"}, "[::1]:49153"},
+		{publishedPort{HostIP: "127.0.0.1", HostPort: "3000"}, "127.0.0.1:3000"},
+		{publishedPort{HostIP: "::1", HostPort: "3000"}, "[::1]:3000"},
+	}
+	for _, tt := range tests {
+		if got := dialAddr(tt.port); got != tt.want {
+			t.Errorf("dialAddr(%+v) = %q, want %q", tt.port, got, tt.want)
+		}
+	}
+}
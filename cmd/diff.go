@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/git"
+	"github.com/obra/packnplay/pkg/runner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffPath       string
+	diffWorktree   string
+	diffNoWorktree bool
+	diffRuntime    string
+	diffStat       bool
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [container_name] [flags]",
+	Short: "Show the changes in a --copy-workspace container",
+	Long: `Show the changes made in a container started with --copy-workspace, or on a remote
+daemon the workspace is synced to, as a patch for the workspace it was copied from.
+Changes count from when the workspace was copied, or last exported, committed in the
+container or not. Files git ignores are left out.
+
+Without a name, the container for the current directory and branch is used, as run would
+pick it. packnplay export applies the changes.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		copied, err := findCopiedWorkspace(args, diffPath, diffWorktree, diffNoWorktree, diffRuntime)
+		if err != nil {
+			return err
+		}
+		patch, err := copied.Diff()
+		if err != nil {
+			return err
+		}
+		if len(patch) == 0 {
+			fmt.Fprintf(os.Stderr, "No changes in %s\n", copied.Name)
+			return nil
+		}
+		if diffStat {
+			stat, err := git.PatchStat(copied.Source, patch)
+			if err != nil {
+				return err
+			}
+			fmt.Print(stat)
+			return nil
+		}
+		_, err = os.Stdout.Write(patch)
+		return err
+	},
+}
+
+// findCopiedWorkspace returns the --copy-workspace container named in args, or else the one
+// for the directory and worktree
+func findCopiedWorkspace(args []string, path, worktree string, noWorktree bool, runtime string) (*runner.CopiedWorkspace, error) {
+	// Runtime: flag > config (detected when neither is set)
+	if runtime == "" {
+		if cfg, err := config.LoadWithoutRuntimeCheck(); err == nil {
+			runtime = cfg.ContainerRuntime
+		}
+	}
+	if len(args) == 0 {
+		return runner.FindCopiedWorkspace(path, worktree, noWorktree, runtime, false)
+	}
+	dockerClient, err := docker.NewClientWithRuntime(runtime, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize container runtime: %w", err)
+	}
+	return runner.CopiedWorkspaceOf(dockerClient, args[0])
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVar(&diffPath, "path", "", "Project path (default: pwd)")
+	diffCmd.Flags().StringVar(&diffWorktree, "worktree", "", "Worktree name (default: current branch)")
+	diffCmd.Flags().BoolVar(&diffNoWorktree, "no-worktree", false, "Use the container started with --no-worktree")
+	diffCmd.Flags().StringVar(&diffRuntime, "runtime", "", "Container runtime to use (docker/podman/container)")
+	diffCmd.Flags().BoolVar(&diffStat, "stat", false, "Show a summary of the changed files instead of the patch")
+}
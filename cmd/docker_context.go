@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/obra/packnplay/pkg/config"
+)
+
+// rootContext is --context: the docker context every command talks to
+var rootContext string
+
+// selectDockerContext decides which docker context this invocation uses. --context wins
+// over everything, including DOCKER_HOST, which is then cleared. Otherwise DOCKER_HOST and
+// DOCKER_CONTEXT are left to the docker CLI, and the docker_context config field only
+// applies when neither is set. It returns "" to leave the environment alone.
+func selectDockerContext(flag, configured string, getenv func(string) string) (name string, clearHost bool) {
+	if flag != "" {
+		return flag, getenv("DOCKER_HOST") != ""
+	}
+	if getenv("DOCKER_HOST") != "" || getenv("DOCKER_CONTEXT") != "" {
+		return "", false
+	}
+	return configured, false
+}
+
+// applyDockerContext points the docker CLI, and the Engine API client, at the selected context
+func applyDockerContext() {
+	configured := ""
+	if rootContext == "" {
+		if cfg, err := config.LoadWithoutRuntimeCheck(); err == nil {
+			configured = cfg.DockerContext
+		}
+	}
+	name, clearHost := selectDockerContext(rootContext, configured, os.Getenv)
+	if clearHost {
+		_ = os.Unsetenv("DOCKER_HOST")
+	}
+	if name != "" {
+		_ = os.Setenv("DOCKER_CONTEXT", name)
+	}
+}
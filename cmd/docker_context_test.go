@@ -0,0 +1,30 @@
+package cmd
+
+import "testing"
+
+func TestSelectDockerContext(t *testing.T) {
+	tests := []struct {
+		name       string
+		flag       string
+		configured string
+		env        map[string]string
+		want       string
+		wantClear  bool
+	}{
+		{"nothing set", "", "", nil, "", false},
+		{"config", "", "build", nil, "build", false},
+		{"DOCKER_HOST beats config", "", "build", map[string]string{"DOCKER_HOST": "tcp://x:2376"}, "", false},
+		{"DOCKER_CONTEXT beats config", "", "build", map[string]string{"DOCKER_CONTEXT": "other"}, "", false},
+		{"flag beats everything", "gpu", "build", map[string]string{"DOCKER_CONTEXT": "other"}, "gpu", false},
+		{"flag clears DOCKER_HOST", "gpu", "", map[string]string{"DOCKER_HOST": "tcp://x:2376"}, "gpu", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			got, clear := selectDockerContext(tt.flag, tt.configured, getenv)
+			if got != tt.want || clear != tt.wantClear {
+				t.Errorf("selectDockerContext() = %q, %v, want %q, %v", got, clear, tt.want, tt.wantClear)
+			}
+		})
+	}
+}
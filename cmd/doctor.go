@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/obra/packnplay/pkg/diagnostics"
+	"github.com/spf13/cobra"
+)
+
+var doctorPath string
+
+var doctorCmd = &cobra.Command{
+	Use:     "doctor",
+	Aliases: []string{"status"},
+	Short:   "Check the host setup and suggest fixes",
+	Long: `Check the container runtime, daemon, container clock, config file, default
+credentials, default image, git worktrees and leftover containers, printing a fix for
+each problem.
+Exits non-zero if any check fails; warnings don't affect the exit status.`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workDir := doctorPath
+		if workDir == "" {
+			var err error
+			workDir, err = os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get working directory: %w", err)
+			}
+		}
+		workDir, err := filepath.Abs(workDir)
+		if err != nil {
+			return fmt.Errorf("failed to resolve path: %w", err)
+		}
+
+		env := diagnostics.NewEnvironment(workDir)
+		reports := diagnostics.Run(diagnostics.DefaultChecks(), env)
+		printReports(os.Stdout, reports)
+
+		if diagnostics.Failed(reports) {
+			return fmt.Errorf("some checks failed")
+		}
+		return nil
+	},
+}
+
+// printReports writes one line per check, with the fix indented below problems
+func printReports(w io.Writer, reports []diagnostics.Report) {
+	width := 0
+	for _, report := range reports {
+		if len(report.Check) > width {
+			width = len(report.Check)
+		}
+	}
+
+	for _, report := range reports {
+		_, _ = fmt.Fprintf(w, "[%-4s] %-*s  %s\n", report.Status, width, report.Check, report.Message)
+		if report.Fix != "" && (report.Status == diagnostics.Warn || report.Status == diagnostics.Fail) {
+			_, _ = fmt.Fprintf(w, "       %*s  Fix: %s\n", width, "", report.Fix)
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().StringVar(&doctorPath, "path", "", "Project path for the worktree check (default: pwd)")
+}
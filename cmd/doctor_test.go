@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/diagnostics"
+)
+
+func TestPrintReports(t *testing.T) {
+	reports := []diagnostics.Report{
+		{Check: "daemon", Result: diagnostics.Result{Status: diagnostics.OK, Message: "server version 27.1.0"}},
+		{Check: "default image", Result: diagnostics.Result{Status: diagnostics.Warn, Message: "not pulled", Fix: "packnplay refresh-container"}},
+		{Check: "worktrees", Result: diagnostics.Result{Status: diagnostics.Skip, Message: "not in a git repository", Fix: "ignored"}},
+	}
+
+	var buf bytes.Buffer
+	printReports(&buf, reports)
+
+	want := "[ok  ] daemon         server version 27.1.0\n" +
+		"[warn] default image  not pulled\n" +
+		"                      Fix: packnplay refresh-container\n" +
+		"[skip] worktrees      not in a git repository\n"
+	if buf.String() != want {
+		t.Errorf("printReports() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/runner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	execPath       string
+	execWorktree   string
+	execNoWorktree bool
+	execRuntime    string
+	execVerbose    bool
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec [flags] [--] command...",
+	Short: "Run a command in the running container",
+	Long: `Run a one-off command in the container already running for the current worktree,
+e.g. "packnplay exec -- npm test". Unlike run, exec never starts a container and
+exits with the command's exit status.`,
+	Args:          cobra.MinimumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadWithoutRuntimeCheck()
+		if err != nil {
+			cfg = &config.Config{}
+		}
+		// Runtime: flag > config (detected when neither is set)
+		runtime := execRuntime
+		if runtime == "" {
+			runtime = cfg.ContainerRuntime
+		}
+		projectDir, err := findProjectDir(execPath)
+		if err != nil {
+			return err
+		}
+
+		err = runner.Exec(&runner.ExecConfig{
+			Path:       execPath,
+			Worktree:   execWorktree,
+			NoWorktree: execNoWorktree,
+			Runtime:    runtime,
+			Command:    args,
+			Verbose:    execVerbose,
+			// exec doesn't ask: an untrusted workspace stays untrusted
+			UntrustedWorkspace: workspaceUntrusted(cfg, projectDir),
+		})
+		if err != nil {
+			var exitErr *runner.ExitError
+			if errors.As(err, &exitErr) {
+				os.Exit(exitErr.Code)
+			}
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+
+	// Everything after the first positional arg belongs to the command
+	execCmd.Flags().SetInterspersed(false)
+
+	execCmd.Flags().StringVar(&execPath, "path", "", "Project path (default: pwd)")
+	execCmd.Flags().StringVar(&execWorktree, "worktree", "", "Worktree name (default: current branch)")
+	execCmd.Flags().BoolVar(&execNoWorktree, "no-worktree", false, "Use the container started with --no-worktree")
+	execCmd.Flags().StringVar(&execRuntime, "runtime", "", "Container runtime to use (docker/podman/container)")
+	execCmd.Flags().BoolVar(&execVerbose, "verbose", false, "Show all docker commands")
+}
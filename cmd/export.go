@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/obra/packnplay/pkg/runner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportPath       string
+	exportWorktree   string
+	exportNoWorktree bool
+	exportRuntime    string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [container_name] [flags]",
+	Short: "Apply the changes in a --copy-workspace container to the workspace",
+	Long: `Apply the changes made in a container started with --copy-workspace, or on a remote
+daemon the workspace is synced to, as shown by packnplay diff, to the workspace it was
+copied from. They are applied as uncommitted changes; commits made in the container
+aren't carried over as commits.
+
+Nothing is applied when any change doesn't fit the workspace as it is now. Once applied,
+changes aren't exported again, so export can be run as often as needed.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		copied, err := findCopiedWorkspace(args, exportPath, exportWorktree, exportNoWorktree, exportRuntime)
+		if err != nil {
+			return err
+		}
+		stat, err := copied.Export()
+		if errors.Is(err, runner.ErrNothingToExport) {
+			fmt.Printf("No changes in %s to export\n", copied.Name)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Print(stat)
+		fmt.Printf("Applied the changes in %s to %s\n", copied.Name, copied.Source)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringVar(&exportPath, "path", "", "Project path (default: pwd)")
+	exportCmd.Flags().StringVar(&exportWorktree, "worktree", "", "Worktree name (default: current branch)")
+	exportCmd.Flags().BoolVar(&exportNoWorktree, "no-worktree", false, "Use the container started with --no-worktree")
+	exportCmd.Flags().StringVar(&exportRuntime, "runtime", "", "Container runtime to use (docker/podman/container)")
+}
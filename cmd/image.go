@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/obra/packnplay/pkg/cache"
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/imageinfo"
+	"github.com/spf13/cobra"
+)
+
+var imageVerbose bool
+
+var imageCmd = &cobra.Command{
+	Use:   "image",
+	Short: "Inspect container images",
+}
+
+var imageInfoCmd = &cobra.Command{
+	Use:   "info [image]",
+	Short: "Show where an image came from",
+	Long: `Show the labels, creation metadata, base image and any SBOM and provenance
+attestations of an image (default: the configured default image), so you can audit
+what you are about to mount credentials into. The image must be pulled; attestations
+are read from its registry with docker buildx.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		runtime := ""
+		image := ""
+		if cfg, err := config.LoadWithoutRuntimeCheck(); err == nil {
+			runtime = cfg.ContainerRuntime
+			image = cfg.GetDefaultImage()
+		} else {
+			image = (&config.Config{}).GetDefaultImage()
+		}
+		if len(args) > 0 {
+			image = args[0]
+		}
+
+		dockerClient, err := docker.NewClientWithRuntime(runtime, imageVerbose)
+		if err != nil {
+			return fmt.Errorf("failed to initialize container runtime: %w", err)
+		}
+
+		info, err := imageinfo.Inspect(dockerClient, image)
+		if err != nil {
+			return err
+		}
+
+		printImageInfo(os.Stdout, info, time.Now())
+		return nil
+	},
+}
+
+// printImageInfo writes a human-readable audit summary of an image
+func printImageInfo(w io.Writer, info *imageinfo.Info, now time.Time) {
+	_, _ = fmt.Fprintf(w, "Image:       %s\n", info.Reference)
+	_, _ = fmt.Fprintf(w, "ID:          %s\n", info.ID)
+	for _, digest := range info.RepoDigests {
+		_, _ = fmt.Fprintf(w, "Digest:      %s\n", digest)
+	}
+	if !info.Created.IsZero() {
+		_, _ = fmt.Fprintf(w, "Created:     %s (%s ago)\n", info.Created.Local().Format(time.RFC3339), formatAge(now.Sub(info.Created)))
+	}
+	if info.Author != "" {
+		_, _ = fmt.Fprintf(w, "Author:      %s\n", info.Author)
+	}
+	if info.Platform != "" {
+		_, _ = fmt.Fprintf(w, "Platform:    %s\n", info.Platform)
+	}
+	_, _ = fmt.Fprintf(w, "Size:        %s\n", cache.FormatSize(info.Size))
+
+	base := info.BaseImage
+	if base == "" {
+		base = "unknown"
+	} else if info.BaseDigest != "" {
+		base += " (" + info.BaseDigest + ")"
+	}
+	_, _ = fmt.Fprintf(w, "Base image:  %s\n", base)
+
+	_, _ = fmt.Fprintln(w, "\nLabels:")
+	if len(info.Labels) == 0 {
+		_, _ = fmt.Fprintln(w, "  (none)")
+	}
+	for _, key := range info.SortedLabels() {
+		_, _ = fmt.Fprintf(w, "  %s=%s\n", key, info.Labels[key])
+	}
+
+	_, _ = fmt.Fprintln(w, "\nSBOM:")
+	switch {
+	case info.AttestationError != nil:
+		_, _ = fmt.Fprintf(w, "  unavailable: %v\n", info.AttestationError)
+	case len(info.SBOMs) == 0:
+		_, _ = fmt.Fprintln(w, "  none attached")
+	}
+	for _, sbom := range info.SBOMs {
+		line := fmt.Sprintf("%s, %d packages", sbom.Version, sbom.Packages)
+		if len(sbom.Creators) > 0 {
+			line += " (" + strings.Join(sbom.Creators, ", ") + ")"
+		}
+		_, _ = fmt.Fprintf(w, "  %s%s\n", platformPrefix(sbom.Platform), line)
+	}
+
+	_, _ = fmt.Fprintln(w, "\nProvenance:")
+	switch {
+	case info.AttestationError != nil:
+		_, _ = fmt.Fprintf(w, "  unavailable: %v\n", info.AttestationError)
+	case len(info.Provenance) == 0:
+		_, _ = fmt.Fprintln(w, "  none attached")
+	}
+	for _, p := range info.Provenance {
+		_, _ = fmt.Fprintf(w, "  %sbuilder %s\n", platformPrefix(p.Platform), valueOr(p.BuilderID, "unknown"))
+		if p.Source != "" {
+			_, _ = fmt.Fprintf(w, "    source:  %s\n", p.Source)
+		}
+		if p.Started != "" {
+			_, _ = fmt.Fprintf(w, "    started: %s\n", p.Started)
+		}
+		for _, m := range p.Materials {
+			_, _ = fmt.Fprintf(w, "    input:   %s\n", m)
+		}
+	}
+}
+
+func platformPrefix(platform string) string {
+	if platform == "" {
+		return ""
+	}
+	return platform + ": "
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// formatAge renders a duration in the largest whole unit
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%d minutes", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%d hours", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%d days", int(d.Hours()/24))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(imageCmd)
+	imageCmd.AddCommand(imageInfoCmd)
+	imageInfoCmd.Flags().BoolVarP(&imageVerbose, "verbose", "v", false, "Show all docker commands")
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/obra/packnplay/pkg/imageinfo"
+)
+
+func TestPrintImageInfo(t *testing.T) {
+	now := time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC)
+	info := &imageinfo.Info{
+		Reference:   "ghcr.io/obra/packnplay-default:latest",
+		ID:          "sha256:abc",
+		RepoDigests: []string{"ghcr.io/obra/packnplay-default@sha256:def"},
+		Created:     now.Add(-72 * time.Hour),
+		Platform:    "linux/amd64",
+		Size:        2048,
+		Labels:      map[string]string{"b": "2", "a": "1"},
+		BaseImage:   "ubuntu:24.04",
+		SBOMs:       []imageinfo.SBOM{{Platform: "linux/amd64", Version: "SPDX-2.3", Packages: 12}},
+		Provenance: []imageinfo.Provenance{{
+			BuilderID: "builder-1",
+			Source:    "https://github.com/obra/packnplay.git",
+			Materials: []string{"pkg:docker/ubuntu@24.04"},
+		}},
+	}
+
+	var buf bytes.Buffer
+	printImageInfo(&buf, info, now)
+	out := buf.String()
+
+	for _, want := range []string{
+		"Digest:      ghcr.io/obra/packnplay-default@sha256:def\n",
+		"(3 days ago)",
+		"Size:        2.0 KB\n",
+		"Base image:  ubuntu:24.04\n",
+		"Labels:\n  a=1\n  b=2\n",
+		"  linux/amd64: SPDX-2.3, 12 packages\n",
+		"  builder builder-1\n    source:  https://github.com/obra/packnplay.git\n    input:   pkg:docker/ubuntu@24.04\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrintImageInfoWithoutAttestations(t *testing.T) {
+	info := &imageinfo.Info{
+		Reference:        "local/image:dev",
+		AttestationError: errors.New("no such manifest"),
+	}
+
+	var buf bytes.Buffer
+	printImageInfo(&buf, info, time.Now())
+	out := buf.String()
+
+	for _, want := range []string{
+		"Base image:  unknown\n",
+		"Labels:\n  (none)\n",
+		"SBOM:\n  unavailable: no such manifest\n",
+		"Provenance:\n  unavailable: no such manifest\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/toolchain"
+	"github.com/spf13/cobra"
+)
+
+var (
+	initPath  string
+	initAuto  bool
+	initForce bool
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create a .devcontainer/devcontainer.json for the project",
+	Long: `Write a .devcontainer/devcontainer.json that pins the project's image.
+
+By default it uses your configured default image. With --auto, the project's manifests
+(go.mod, package.json, pyproject.toml, Cargo.toml, ...) are detected and the smallest
+variant of the packnplay default image with those toolchains is chosen.`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectPath := initPath
+		if projectPath == "" {
+			var err error
+			projectPath, err = os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get working directory: %w", err)
+			}
+		}
+		projectPath, err := filepath.Abs(projectPath)
+		if err != nil {
+			return fmt.Errorf("failed to resolve path: %w", err)
+		}
+
+		configPath := filepath.Join(projectPath, ".devcontainer", "devcontainer.json")
+		if _, err := os.Stat(configPath); err == nil && !initForce {
+			return fmt.Errorf("%s already exists (use --force to overwrite)", configPath)
+		}
+
+		image := config.ImageForVariant("full")
+		if cfg, err := config.LoadWithoutRuntimeCheck(); err == nil {
+			image = cfg.GetDefaultImage()
+		}
+
+		var detected []toolchain.Toolchain
+		if initAuto {
+			detected = toolchain.Detect(projectPath)
+			if len(detected) == 0 {
+				fmt.Println("No toolchain manifests found; using the default image")
+			} else {
+				image = config.ImageForVariant(toolchain.BestVariant(detected))
+				fmt.Printf("Detected %s\n", toolchain.Names(detected))
+			}
+		}
+
+		if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+			return fmt.Errorf("failed to create .devcontainer: %w", err)
+		}
+		if err := os.WriteFile(configPath, initDevcontainerJSON(filepath.Base(projectPath), image, detected), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", configPath, err)
+		}
+		fmt.Printf("Wrote %s using %s\n", configPath, image)
+		return nil
+	},
+}
+
+// initDevcontainerJSON renders a devcontainer.json for name and image, noting the
+// detected toolchains in a comment
+func initDevcontainerJSON(name, image string, detected []toolchain.Toolchain) []byte {
+	quote := func(s string) string {
+		data, _ := json.Marshal(s)
+		return string(data)
+	}
+
+	var b strings.Builder
+	b.WriteString("{\n")
+	if len(detected) > 0 {
+		var found []string
+		for _, tc := range detected {
+			found = append(found, fmt.Sprintf("%s (%s)", tc.Name, tc.Manifest))
+		}
+		fmt.Fprintf(&b, "  // Detected by packnplay init --auto: %s\n", strings.Join(found, ", "))
+	}
+	fmt.Fprintf(&b, "  \"name\": %s,\n", quote(name))
+	fmt.Fprintf(&b, "  \"image\": %s\n", quote(image))
+	b.WriteString("}\n")
+	return []byte(b.String())
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+
+	initCmd.Flags().StringVar(&initPath, "path", "", "Project path (default: pwd)")
+	initCmd.Flags().BoolVar(&initAuto, "auto", false, "Pick the image from the toolchains the project's manifests need")
+	initCmd.Flags().BoolVarP(&initForce, "force", "f", false, "Overwrite an existing devcontainer.json")
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/obra/packnplay/pkg/devcontainer"
+	"github.com/obra/packnplay/pkg/toolchain"
+)
+
+func TestInitDevcontainerJSON(t *testing.T) {
+	got := string(initDevcontainerJSON("my \"app\"", "ghcr.io/obra/packnplay-default:go", []toolchain.Toolchain{
+		{Name: "go", Manifest: "go.mod"},
+		{Name: "node", Manifest: "package.json"},
+	}))
+
+	want := `{
+  // Detected by packnplay init --auto: go (go.mod), node (package.json)
+  "name": "my \"app\"",
+  "image": "ghcr.io/obra/packnplay-default:go"
+}
+`
+	if got != want {
+		t.Errorf("initDevcontainerJSON() =\n%s\nwant\n%s", got, want)
+	}
+
+	// What init writes must lint clean
+	findings, err := devcontainer.Lint([]byte(got))
+	if err != nil || len(findings) != 0 {
+		t.Errorf("Lint() = %+v, %v", findings, err)
+	}
+}
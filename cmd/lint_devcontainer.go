@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/obra/packnplay/pkg/devcontainer"
+	"github.com/spf13/cobra"
+)
+
+var lintDevcontainerPath string
+
+var lintDevcontainerCmd = &cobra.Command{
+	Use:   "lint-devcontainer",
+	Short: "Check devcontainer.json for fields packnplay ignores or can't use",
+	Long: `Validate the project's .devcontainer/devcontainer.json against the dev container
+spec and the subset of it packnplay supports.
+
+Each finding is one of:
+  error    packnplay can't use the file as written (wrong type, bad port, no image)
+  ignored  a valid spec field packnplay doesn't act on, so the container behaves
+           differently than it does in VS Code
+  unknown  not a devcontainer.json field at all, often a typo
+
+Exits non-zero if there are errors.`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectPath := lintDevcontainerPath
+		if projectPath == "" {
+			var err error
+			projectPath, err = os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get working directory: %w", err)
+			}
+		}
+
+		configPath := filepath.Join(projectPath, ".devcontainer", "devcontainer.json")
+		data, err := os.ReadFile(configPath)
+		if os.IsNotExist(err) {
+			fmt.Printf("No %s; packnplay uses the default image\n", configPath)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", configPath, err)
+		}
+
+		findings, err := devcontainer.Lint(data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", configPath, err)
+			return err
+		}
+
+		if printLintFindings(os.Stdout, configPath, findings) {
+			return fmt.Errorf("devcontainer.json has errors")
+		}
+		return nil
+	},
+}
+
+// printLintFindings writes one line per finding and a summary, and reports whether
+// any finding is an error
+func printLintFindings(w io.Writer, configPath string, findings []devcontainer.Finding) bool {
+	if len(findings) == 0 {
+		_, _ = fmt.Fprintf(w, "%s: packnplay supports everything in it\n", configPath)
+		return false
+	}
+
+	counts := make(map[devcontainer.Severity]int)
+	_, _ = fmt.Fprintf(w, "%s:\n", configPath)
+	for _, f := range findings {
+		counts[f.Severity]++
+		_, _ = fmt.Fprintf(w, "  %-8s %s: %s\n", f.Severity, f.Field, f.Message)
+	}
+	_, _ = fmt.Fprintf(w, "%d errors, %d ignored, %d unknown\n",
+		counts[devcontainer.SeverityError], counts[devcontainer.SeverityIgnored], counts[devcontainer.SeverityUnknown])
+
+	return counts[devcontainer.SeverityError] > 0
+}
+
+func init() {
+	rootCmd.AddCommand(lintDevcontainerCmd)
+	lintDevcontainerCmd.Flags().StringVar(&lintDevcontainerPath, "path", "", "Project path (default: pwd)")
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/devcontainer"
+)
+
+func TestPrintLintFindings(t *testing.T) {
+	var buf bytes.Buffer
+	hasErrors := printLintFindings(&buf, "devcontainer.json", []devcontainer.Finding{
+		{Field: "features", Severity: devcontainer.SeverityIgnored, Message: "features are not installed"},
+		{Field: "image", Severity: devcontainer.SeverityError, Message: "expected string, got number"},
+	})
+
+	want := "devcontainer.json:\n" +
+		"  ignored  features: features are not installed\n" +
+		"  error    image: expected string, got number\n" +
+		"1 errors, 1 ignored, 0 unknown\n"
+	if buf.String() != want {
+		t.Errorf("printLintFindings() =\n%s\nwant\n%s", buf.String(), want)
+	}
+	if !hasErrors {
+		t.Error("printLintFindings() = false, want true with an error finding")
+	}
+
+	buf.Reset()
+	if printLintFindings(&buf, "devcontainer.json", nil) {
+		t.Error("printLintFindings() = true with no findings")
+	}
+	if buf.String() != "devcontainer.json: packnplay supports everything in it\n" {
+		t.Errorf("printLintFindings() = %q", buf.String())
+	}
+}
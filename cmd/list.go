@@ -1,27 +1,34 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"text/tabwriter"
+	"time"
 
 	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/git"
 	"github.com/spf13/cobra"
 )
 
-var listVerbose bool
+var (
+	listVerbose     bool
+	listAllContexts bool
+	listGit         bool
+	listInteractive bool
+)
 
-type ContainerInfo struct {
-	Names  string `json:"Names"`
-	Status string `json:"Status"`
-	Labels string `json:"Labels"`
-}
+// ContainerInfo is a line of ps output, decoded the same for Docker and Podman
+type ContainerInfo = docker.PSEntry
 
 var listCmd = &cobra.Command{
-	Use:   "list",
-	Short: "List all packnplay-managed containers",
-	Long:  `Display all running containers managed by packnplay.`,
+	Use:     "list",
+	Aliases: []string{"ps"},
+	Short:   "List all packnplay-managed containers",
+	Long: `Display all running containers managed by packnplay.
+
+With --interactive, pick one of them to attach to, stop, show logs of, or copy the
+command that reconnects to it.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Initialize Docker client
 		dockerClient, err := docker.NewClient(false)
@@ -29,6 +36,13 @@ var listCmd = &cobra.Command{
 			return fmt.Errorf("failed to initialize docker: %w", err)
 		}
 
+		if listAllContexts {
+			return runListAllContexts(dockerClient)
+		}
+		if listInteractive {
+			return runContainerPicker(dockerClient)
+		}
+
 		// Get all packnplay-managed containers
 		output, err := dockerClient.Run(
 			"ps",
@@ -45,23 +59,20 @@ var listCmd = &cobra.Command{
 		}
 
 		// Docker outputs one JSON object per line
-		lines := splitLines(output)
+		infos := parseContainerInfos(output)
+
+		var gitStates map[string]worktreeGit
+		if listGit {
+			gitStates = collectWorktreeGit(hostPathsOf(infos), git.GetWorktreeStatus)
+		}
+		now := time.Now()
 
 		if listVerbose {
 			// Verbose mode: use block format for better readability
-			for i, line := range lines {
-				if line == "" {
-					continue
-				}
-
-				var info ContainerInfo
-				if err := json.Unmarshal([]byte(line), &info); err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: failed to parse container info: %v\n", err)
-					continue
-				}
-
+			for i, info := range infos {
 				// Parse labels with launch info support
 				project, worktree, hostPath, launchCommand := parseLabelsWithLaunchInfo(info.Labels)
+				gitDesc := describeWorktreeGit(gitStates, hostPath, now)
 
 				// Handle backward compatibility
 				if hostPath == "" {
@@ -81,38 +92,40 @@ var listCmd = &cobra.Command{
 				if launchCommand != "" {
 					fmt.Printf("  Commandline: %s\n", launchCommand)
 				}
+				if gitDesc != "" {
+					fmt.Printf("  Git: %s\n", gitDesc)
+				}
 			}
 		} else {
 			// Normal mode: use tabular format
 			w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-			_, _ = fmt.Fprintln(w, "CONTAINER\tSTATUS\tPROJECT\tWORKTREE\tHOST PATH")
-
-			for _, line := range lines {
-				if line == "" {
-					continue
-				}
-
-				var info ContainerInfo
-				if err := json.Unmarshal([]byte(line), &info); err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: failed to parse container info: %v\n", err)
-					continue
-				}
+			header := "CONTAINER\tSTATUS\tPROJECT\tWORKTREE\tHOST PATH"
+			if listGit {
+				header += "\t" + gitColumnsHeader
+			}
+			_, _ = fmt.Fprintln(w, header)
 
+			for _, info := range infos {
 				// Parse labels with launch info support
 				project, worktree, hostPath, _ := parseLabelsWithLaunchInfo(info.Labels)
+				gitCells := gitColumns(gitStates, hostPath, now)
 
 				// Handle backward compatibility
 				if hostPath == "" {
 					hostPath = "N/A"
 				}
 
-				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				row := fmt.Sprintf("%s\t%s\t%s\t%s\t%s",
 					info.Names,
 					info.Status,
 					project,
 					worktree,
 					hostPath,
 				)
+				if listGit {
+					row += "\t" + gitCells
+				}
+				_, _ = fmt.Fprintln(w, row)
 			}
 
 			return w.Flush()
@@ -202,4 +215,7 @@ func splitByEquals(s string) []string {
 func init() {
 	rootCmd.AddCommand(listCmd)
 	listCmd.Flags().BoolVarP(&listVerbose, "verbose", "v", false, "Show detailed launch information")
+	listCmd.Flags().BoolVar(&listAllContexts, "all-contexts", false, "List containers across all docker contexts")
+	listCmd.Flags().BoolVar(&listGit, "git", false, "Show each worktree's commits ahead of and behind its base branch, uncommitted files, and last commit age")
+	listCmd.Flags().BoolVarP(&listInteractive, "interactive", "i", false, "Pick a container to attach to, stop, show logs of, or copy the reconnect command for")
 }
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/git"
+)
+
+// contextContainers holds the packnplay containers found in one docker context
+type contextContainers struct {
+	Context    string
+	Containers []ContainerInfo
+	Err        error
+}
+
+// dockerContexts returns the names of the configured docker contexts
+func dockerContexts(dockerClient *docker.Client) ([]string, error) {
+	output, err := dockerClient.Run("context", "ls", "--format", "{{.Name}}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list docker contexts: %w: %s", err, strings.TrimSpace(output))
+	}
+	return parseContextNames(output), nil
+}
+
+// parseContextNames parses `docker context ls --format {{.Name}}` output.
+// Older docker versions mark the current context with a trailing " *".
+func parseContextNames(output string) []string {
+	var names []string
+	for _, line := range splitLines(output) {
+		name := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), "*"))
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseContainerInfos parses docker ps JSON lines, warning about lines it can't read
+func parseContainerInfos(output string) []ContainerInfo {
+	var infos []ContainerInfo
+	for _, line := range splitLines(output) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var info ContainerInfo
+		if err := json.Unmarshal([]byte(line), &info); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to parse container info: %v\n", err)
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// listContextContainers queries every context concurrently. Results keep the
+// order of contexts so the table is stable between runs.
+func listContextContainers(contexts []string, ps func(context string) (string, error)) []contextContainers {
+	results := make([]contextContainers, len(contexts))
+	var wg sync.WaitGroup
+	for i, name := range contexts {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			results[i].Context = name
+			output, err := ps(name)
+			if err != nil {
+				results[i].Err = fmt.Errorf("%w: %s", err, strings.TrimSpace(output))
+				return
+			}
+			results[i].Containers = parseContainerInfos(output)
+		}(i, name)
+	}
+	wg.Wait()
+	return results
+}
+
+// printContextsTable writes the aggregated containers with a CONTEXT column, and the
+// list --git columns when gitStates is not nil
+func printContextsTable(out io.Writer, results []contextContainers, gitStates map[string]worktreeGit) error {
+	now := time.Now()
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	header := "CONTEXT\tCONTAINER\tSTATUS\tPROJECT\tWORKTREE\tHOST PATH"
+	if gitStates != nil {
+		header += "\t" + gitColumnsHeader
+	}
+	_, _ = fmt.Fprintln(w, header)
+	for _, result := range results {
+		for _, info := range result.Containers {
+			project, worktree, hostPath, _ := parseLabelsWithLaunchInfo(info.Labels)
+			gitCells := gitColumns(gitStates, hostPath, now)
+			if hostPath == "" {
+				hostPath = "N/A"
+			}
+			row := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s",
+				result.Context,
+				info.Names,
+				info.Status,
+				project,
+				worktree,
+				hostPath,
+			)
+			if gitStates != nil {
+				row += "\t" + gitCells
+			}
+			_, _ = fmt.Fprintln(w, row)
+		}
+	}
+	return w.Flush()
+}
+
+// printContextsVerbose writes the aggregated containers in block format
+func printContextsVerbose(out io.Writer, results []contextContainers, gitStates map[string]worktreeGit) {
+	now := time.Now()
+	first := true
+	for _, result := range results {
+		for _, info := range result.Containers {
+			project, worktree, hostPath, launchCommand := parseLabelsWithLaunchInfo(info.Labels)
+			gitDesc := describeWorktreeGit(gitStates, hostPath, now)
+			if hostPath == "" {
+				hostPath = "N/A"
+			}
+			if !first {
+				_, _ = fmt.Fprintln(out)
+			}
+			first = false
+
+			_, _ = fmt.Fprintf(out, "Container: %s\n", info.Names)
+			_, _ = fmt.Fprintf(out, "  Context: %s\n", result.Context)
+			_, _ = fmt.Fprintf(out, "  Status: %s\n", info.Status)
+			_, _ = fmt.Fprintf(out, "  Project: %s\n", project)
+			_, _ = fmt.Fprintf(out, "  Worktree: %s\n", worktree)
+			_, _ = fmt.Fprintf(out, "  Host Path: %s\n", hostPath)
+			if launchCommand != "" {
+				_, _ = fmt.Fprintf(out, "  Commandline: %s\n", launchCommand)
+			}
+			if gitDesc != "" {
+				_, _ = fmt.Fprintf(out, "  Git: %s\n", gitDesc)
+			}
+		}
+	}
+}
+
+// runListAllContexts lists packnplay containers across every docker context.
+// Unreachable contexts are reported as warnings so one offline remote doesn't
+// hide the rest.
+func runListAllContexts(dockerClient *docker.Client) error {
+	contexts, err := dockerContexts(dockerClient)
+	if err != nil {
+		return err
+	}
+
+	results := listContextContainers(contexts, func(context string) (string, error) {
+		return dockerClient.Run(
+			"--context", context,
+			"ps",
+			"--filter", "label=managed-by=packnplay",
+			"--format", "{{json .}}",
+		)
+	})
+
+	total := 0
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: context %s unavailable: %v\n", result.Context, result.Err)
+		}
+		total += len(result.Containers)
+	}
+
+	if total == 0 {
+		fmt.Println("No packnplay-managed containers running in any context")
+		return nil
+	}
+
+	var gitStates map[string]worktreeGit
+	if listGit {
+		var infos []ContainerInfo
+		for _, result := range results {
+			infos = append(infos, result.Containers...)
+		}
+		gitStates = collectWorktreeGit(hostPathsOf(infos), git.GetWorktreeStatus)
+	}
+
+	if listVerbose {
+		printContextsVerbose(os.Stdout, results, gitStates)
+		return nil
+	}
+	return printContextsTable(os.Stdout, results, gitStates)
+}
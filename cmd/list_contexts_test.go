@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseContextNames(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{
+			name:   "plain names",
+			output: "default\nbuild-01\n",
+			want:   []string{"default", "build-01"},
+		},
+		{
+			name:   "current context marker",
+			output: "default *\nremote\n",
+			want:   []string{"default", "remote"},
+		},
+		{
+			name:   "blank lines",
+			output: "\ndefault\n\n",
+			want:   []string{"default"},
+		},
+		{
+			name:   "empty",
+			output: "",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseContextNames(tt.output)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseContextNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListContextContainers(t *testing.T) {
+	outputs := map[string]string{
+		"default": `{"Names":"packnplay-app-main","Status":"Up 2 hours","Labels":"managed-by=packnplay,packnplay-project=app,packnplay-worktree=main"}` + "\n",
+		"remote":  `{"Names":"packnplay-api-dev","Status":"Up 5 minutes","Labels":"managed-by=packnplay,packnplay-project=api,packnplay-worktree=dev,packnplay-host-path=/srv/api"}` + "\n",
+	}
+	ps := func(context string) (string, error) {
+		if context == "offline" {
+			return "cannot connect to the Docker daemon", errors.New("exit status 1")
+		}
+		return outputs[context], nil
+	}
+
+	results := listContextContainers([]string{"default", "offline", "remote"}, ps)
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+
+	for i, want := range []string{"default", "offline", "remote"} {
+		if results[i].Context != want {
+			t.Errorf("results[%d].Context = %q, want %q", i, results[i].Context, want)
+		}
+	}
+	if results[1].Err == nil || !strings.Contains(results[1].Err.Error(), "cannot connect") {
+		t.Errorf("offline context error = %v, want daemon output included", results[1].Err)
+	}
+	if len(results[0].Containers) != 1 || len(results[2].Containers) != 1 {
+		t.Fatalf("unexpected containers: %+v", results)
+	}
+
+	var buf bytes.Buffer
+	if err := printContextsTable(&buf, results, nil); err != nil {
+		t.Fatalf("printContextsTable() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("table has %d lines, want 3:\n%s", len(lines), buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "CONTEXT") {
+		t.Errorf("header = %q, want CONTEXT first", lines[0])
+	}
+	if fields := strings.Fields(lines[1]); fields[0] != "default" || fields[1] != "packnplay-app-main" || fields[len(fields)-1] != "N/A" {
+		t.Errorf("row = %q, want default context with N/A host path", lines[1])
+	}
+	if fields := strings.Fields(lines[2]); fields[0] != "remote" || fields[len(fields)-1] != "/srv/api" {
+		t.Errorf("row = %q, want remote context with host path", lines[2])
+	}
+}
+
+func TestPrintContextsVerbose(t *testing.T) {
+	results := []contextContainers{
+		{Context: "default", Containers: []ContainerInfo{{Names: "a", Status: "Up", Labels: "packnplay-project=p"}}},
+		{Context: "remote", Containers: []ContainerInfo{{Names: "b", Status: "Up"}}},
+	}
+
+	var buf bytes.Buffer
+	printContextsVerbose(&buf, results, nil)
+	out := buf.String()
+
+	for _, want := range []string{"Container: a\n  Context: default\n", "\n\nContainer: b\n  Context: remote\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
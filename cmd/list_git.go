@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/obra/packnplay/pkg/git"
+)
+
+// gitColumnsHeader is the header of the columns list --git adds to the table
+const gitColumnsHeader = "BASE\tAHEAD\tBEHIND\tDIRTY\tLAST COMMIT"
+
+// worktreeGit is the git status of one container's host path
+type worktreeGit struct {
+	Status *git.WorktreeStatus
+	Err    error
+}
+
+// hostPathsOf returns the host paths of containers, skipping ones without the label
+func hostPathsOf(infos []ContainerInfo) []string {
+	var paths []string
+	for _, info := range infos {
+		if _, _, hostPath, _ := parseLabelsWithLaunchInfo(info.Labels); hostPath != "" {
+			paths = append(paths, hostPath)
+		}
+	}
+	return paths
+}
+
+// collectWorktreeGit reads the git status of every path concurrently, so a long list
+// doesn't wait on each checkout in turn. Paths that aren't directories on this machine,
+// such as the clones of remote-mode containers, are left out.
+func collectWorktreeGit(paths []string, status func(path string) (*git.WorktreeStatus, error)) map[string]worktreeGit {
+	var local []string
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		if fi, err := os.Stat(path); err != nil || !fi.IsDir() || seen[path] {
+			continue
+		}
+		seen[path] = true
+		local = append(local, path)
+	}
+
+	states := make([]worktreeGit, len(local))
+	var wg sync.WaitGroup
+	for i, path := range local {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			s, err := status(path)
+			states[i] = worktreeGit{Status: s, Err: err}
+		}(i, path)
+	}
+	wg.Wait()
+
+	results := make(map[string]worktreeGit, len(local))
+	for i, path := range local {
+		results[path] = states[i]
+	}
+	return results
+}
+
+// gitColumns renders the list --git cells for hostPath. Checkouts that couldn't be read,
+// or aren't git repositories, show dashes.
+func gitColumns(states map[string]worktreeGit, hostPath string, now time.Time) string {
+	state, ok := states[hostPath]
+	if !ok || state.Err != nil {
+		return "-\t-\t-\t-\t-"
+	}
+	s := state.Status
+	base, ahead, behind := "-", "-", "-"
+	if s.Base != "" {
+		base, ahead, behind = s.Base, strconv.Itoa(s.Ahead), strconv.Itoa(s.Behind)
+	}
+	return fmt.Sprintf("%s\t%s\t%s\t%d\t%s", base, ahead, behind, s.Dirty, lastCommitAge(s, now))
+}
+
+// describeWorktreeGit renders the git status of hostPath for list --git --verbose, or ""
+// when there's none
+func describeWorktreeGit(states map[string]worktreeGit, hostPath string, now time.Time) string {
+	state, ok := states[hostPath]
+	if !ok {
+		return ""
+	}
+	if state.Err != nil {
+		return "not a git checkout"
+	}
+	s := state.Status
+	desc := fmt.Sprintf("%d dirty files, last commit %s", s.Dirty, lastCommitAge(s, now))
+	if s.Base != "" {
+		desc = fmt.Sprintf("%d ahead, %d behind %s, %s", s.Ahead, s.Behind, s.Base, desc)
+	}
+	return desc
+}
+
+// lastCommitAge renders how long ago the checkout's last commit was made
+func lastCommitAge(s *git.WorktreeStatus, now time.Time) string {
+	if s.LastCommit.IsZero() {
+		return "never"
+	}
+	return formatAge(now.Sub(s.LastCommit)) + " ago"
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/obra/packnplay/pkg/git"
+)
+
+func TestCollectWorktreeGit(t *testing.T) {
+	repo := t.TempDir()
+	plain := t.TempDir()
+	missing := filepath.Join(t.TempDir(), "gone")
+
+	var called []string
+	states := collectWorktreeGit([]string{repo, plain, repo, missing}, func(path string) (*git.WorktreeStatus, error) {
+		if path == plain {
+			return nil, errors.New("not a git repository")
+		}
+		return &git.WorktreeStatus{Branch: "feature"}, nil
+	})
+	for path := range states {
+		called = append(called, path)
+	}
+	sort.Strings(called)
+
+	want := []string{plain, repo}
+	sort.Strings(want)
+	if len(called) != len(want) || called[0] != want[0] || called[1] != want[1] {
+		t.Fatalf("collectWorktreeGit() read %q, want %q", called, want)
+	}
+	if states[repo].Status == nil || states[plain].Err == nil {
+		t.Errorf("collectWorktreeGit() = %+v", states)
+	}
+}
+
+func TestGitColumns(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	states := map[string]worktreeGit{
+		"/work/feature": {Status: &git.WorktreeStatus{Base: "origin/main", Ahead: 3, Behind: 1, Dirty: 2, LastCommit: now.Add(-5 * time.Hour)}},
+		"/work/solo":    {Status: &git.WorktreeStatus{Dirty: 1}},
+		"/work/plain":   {Err: errors.New("not a git repository")},
+	}
+
+	tests := []struct {
+		hostPath    string
+		wantColumns string
+		wantDesc    string
+	}{
+		{"/work/feature", "origin/main\t3\t1\t2\t5 hours ago", "3 ahead, 1 behind origin/main, 2 dirty files, last commit 5 hours ago"},
+		{"/work/solo", "-\t-\t-\t1\tnever", "1 dirty files, last commit never"},
+		{"/work/plain", "-\t-\t-\t-\t-", "not a git checkout"},
+		{"/elsewhere", "-\t-\t-\t-\t-", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.hostPath, func(t *testing.T) {
+			if got := gitColumns(states, tt.hostPath, now); got != tt.wantColumns {
+				t.Errorf("gitColumns() = %q, want %q", got, tt.wantColumns)
+			}
+			if got := describeWorktreeGit(states, tt.hostPath, now); got != tt.wantDesc {
+				t.Errorf("describeWorktreeGit() = %q, want %q", got, tt.wantDesc)
+			}
+		})
+	}
+}
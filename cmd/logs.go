@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/runner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logsPath       string
+	logsWorktree   string
+	logsNoWorktree bool
+	logsRuntime    string
+	logsFollow     bool
+	logsSince      string
+	logsAll        bool
+	logsCaptured   bool
+	logsVerbose    bool
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [flags]",
+	Short: "Show container output",
+	Long: `Show the stdout and stderr of the container for the current directory and branch.
+With --all, logs from every packnplay-managed container are interleaved, each line
+prefixed with its container name.
+
+With --captured, show the session output saved by log capture (log_capture in the
+config, or packnplay run --capture-logs) instead. The captured log is also shown when the
+container is gone.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if logsAll && (logsPath != "" || logsWorktree != "" || logsNoWorktree) {
+			return fmt.Errorf("--all cannot be combined with --path, --worktree or --no-worktree")
+		}
+		if logsCaptured && (logsAll || logsFollow || logsSince != "") {
+			return fmt.Errorf("--captured cannot be combined with --all, --follow or --since")
+		}
+
+		// Runtime: flag > config (detected when neither is set)
+		runtime := logsRuntime
+		if runtime == "" {
+			if cfg, err := config.LoadWithoutRuntimeCheck(); err == nil {
+				runtime = cfg.ContainerRuntime
+			}
+		}
+
+		return runner.Logs(&runner.LogsConfig{
+			Path:       logsPath,
+			Worktree:   logsWorktree,
+			NoWorktree: logsNoWorktree,
+			Runtime:    runtime,
+			All:        logsAll,
+			Follow:     logsFollow,
+			Since:      logsSince,
+			Captured:   logsCaptured,
+			Verbose:    logsVerbose,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+
+	logsCmd.Flags().StringVar(&logsPath, "path", "", "Project path (default: pwd)")
+	logsCmd.Flags().StringVar(&logsWorktree, "worktree", "", "Worktree name (default: current branch)")
+	logsCmd.Flags().BoolVar(&logsNoWorktree, "no-worktree", false, "Use the container started with --no-worktree")
+	logsCmd.Flags().StringVar(&logsRuntime, "runtime", "", "Container runtime to use (docker/podman/container)")
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Keep streaming new output")
+	logsCmd.Flags().StringVar(&logsSince, "since", "", "Only show logs since a timestamp or relative time (e.g. 10m, 2025-01-02T15:04:05)")
+	logsCmd.Flags().BoolVar(&logsAll, "all", false, "Interleave logs from all packnplay-managed containers")
+	logsCmd.Flags().BoolVar(&logsCaptured, "captured", false, "Show the session output saved by log capture")
+	logsCmd.Flags().BoolVar(&logsVerbose, "verbose", false, "Show all docker commands")
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var notificationsCmd = &cobra.Command{
+	Use:   "notifications",
+	Short: "Manage notifications about new versions of the default image",
+	Long: `packnplay checks for a newer default image every default_container.check_frequency_hours
+and notifies about each new version once per that interval. Settings in the config file:
+  "notifications": {
+    "quiet": true,                      never check or notify (also the case when CI is set)
+    "quiet_hours": "22:00-08:00",       no checks or notifications in this local time window
+    "frequency_hours": {"<image>": 168} hours between checks for an image (0: every run)
+  }`,
+}
+
+var notificationsResetCmd = &cobra.Command{
+	Use:   "reset [image]",
+	Short: "Forget past notifications so the next run checks and notifies again",
+	Long: `Forget which new versions packnplay already notified about, for one image or all of
+them, and when it last checked, so the next run checks for updates again. What
+refresh-container recorded about pulled images is kept.`,
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		image := ""
+		if len(args) == 1 {
+			image = args[0]
+		}
+		cleared, err := config.ResetVersionNotifications(config.GetVersionTrackingPath(), image)
+		if err != nil {
+			return fmt.Errorf("failed to reset notifications: %w", err)
+		}
+		switch {
+		case image != "" && cleared == 0:
+			fmt.Fprintf(cmd.OutOrStdout(), "No notifications about %s to forget; the next run checks for updates again\n", image)
+		case image != "":
+			fmt.Fprintf(cmd.OutOrStdout(), "Forgot notifications about %s; the next run checks for updates again\n", image)
+		default:
+			fmt.Fprintf(cmd.OutOrStdout(), "Forgot %d notifications; the next run checks for updates again\n", cleared)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(notificationsCmd)
+	notificationsCmd.AddCommand(notificationsResetCmd)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/muesli/termenv"
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/runner"
+)
+
+// runContainerPicker shows the running packnplay containers full screen and does what
+// the user picks: attach, stop (then shows the rest), show logs, or copy the command
+// that reconnects to a container
+func runContainerPicker(dockerClient *docker.Client) error {
+	for {
+		output, err := dockerClient.Run("ps", "--filter", "label=managed-by=packnplay", "--format", "{{json .}}")
+		if err != nil {
+			return fmt.Errorf("failed to list containers: %w", err)
+		}
+		entries := containerEntries(parseContainerInfos(output))
+
+		entry, action, err := config.PickContainer(entries)
+		if err != nil {
+			return fmt.Errorf("container picker failed: %w", err)
+		}
+
+		switch action {
+		case config.ContainerActionAttach:
+			return attachContainer(dockerClient, entry.Name)
+		case config.ContainerActionStop:
+			if err := stopContainer(dockerClient, entry.Name, false); err != nil {
+				return err
+			}
+		case config.ContainerActionLogs:
+			return runner.Logs(&runner.LogsConfig{Container: entry.Name, Runtime: dockerClient.Command()})
+		case config.ContainerActionCopy:
+			command := reconnectCommand(entry)
+			// OSC 52 reaches the local clipboard through ssh and tmux too
+			termenv.Copy(command)
+			fmt.Printf("Copied to the clipboard: %s\n", command)
+			return nil
+		default:
+			return nil
+		}
+	}
+}
+
+// containerEntries turns ps output into the picker's entries
+func containerEntries(infos []ContainerInfo) []config.ContainerEntry {
+	entries := make([]config.ContainerEntry, 0, len(infos))
+	for _, info := range infos {
+		project, worktree, hostPath, _ := parseLabelsWithLaunchInfo(info.Labels)
+		entries = append(entries, config.ContainerEntry{
+			Name:     info.Names,
+			Project:  project,
+			Worktree: worktree,
+			Status:   info.Status,
+			HostPath: hostPath,
+		})
+	}
+	return entries
+}
+
+// reconnectCommand returns the shell command that attaches to the container again from
+// another terminal
+func reconnectCommand(entry config.ContainerEntry) string {
+	if entry.HostPath == "" || entry.Worktree == "" {
+		return fmt.Sprintf("docker exec -it %s /bin/bash", entry.Name)
+	}
+	return fmt.Sprintf("cd %s && packnplay attach --worktree=%s", shellQuote(entry.HostPath), shellQuote(entry.Worktree))
+}
+
+// shellQuote quotes s for sh when it has anything but safe characters
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:@") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/obra/packnplay/pkg/config"
+)
+
+func TestContainerEntries(t *testing.T) {
+	infos := parseContainerInfos(`{"Names":"packnplay-app-f227ecb4-main","Status":"Up 2 hours","Labels":"managed-by=packnplay,packnplay-project=app,packnplay-worktree=main,packnplay-host-path=/src/app"}`)
+	entries := containerEntries(infos)
+	want := config.ContainerEntry{Name: "packnplay-app-f227ecb4-main", Project: "app", Worktree: "main", Status: "Up 2 hours", HostPath: "/src/app"}
+	if len(entries) != 1 || entries[0] != want {
+		t.Errorf("containerEntries() = %+v, want %+v", entries, want)
+	}
+}
+
+func TestReconnectCommand(t *testing.T) {
+	tests := []struct {
+		entry config.ContainerEntry
+		want  string
+	}{
+		{config.ContainerEntry{Name: "packnplay-app-f227ecb4-main", Worktree: "main", HostPath: "/src/app"}, "cd /src/app && packnplay attach --worktree=main"},
+		{config.ContainerEntry{Name: "packnplay-app-f227ecb4-fix", Worktree: "fix/login", HostPath: "/home/me/My Projects/app"}, "cd '/home/me/My Projects/app' && packnplay attach --worktree=fix/login"},
+		{config.ContainerEntry{Name: "packnplay-old-main"}, "docker exec -it packnplay-old-main /bin/bash"},
+	}
+	for _, tt := range tests {
+		if got := reconnectCommand(tt.entry); got != tt.want {
+			t.Errorf("reconnectCommand(%+v) = %q, want %q", tt.entry, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/obra/packnplay/pkg/container"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/runner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	portsPath string
+	portsAll  bool
+)
+
+var portsCmd = &cobra.Command{
+	Use:   "ports [container_name]",
+	Short: "Show published ports with their URLs",
+	Long: `Show the host ports the current project's running containers publish, with a
+localhost URL for each TCP port. This covers -p mappings, including host ports picked at
+start for -p 0:PORT and for ports that were already in use, and devcontainer.json
+forwardPorts.
+
+Pass a container name to show only that container, or --all for every packnplay container.`,
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if portsAll && (len(args) > 0 || portsPath != "") {
+			return fmt.Errorf("--all cannot be combined with a container name or --path")
+		}
+
+		dockerClient, err := docker.NewClient(false)
+		if err != nil {
+			return fmt.Errorf("failed to initialize docker: %w", err)
+		}
+
+		psArgs := []string{"ps", "--filter", "label=managed-by=packnplay"}
+		what := "for this project"
+		var project map[string]bool // the project's containers by name, nil for any
+		switch {
+		case len(args) > 0:
+			psArgs = append(psArgs, "--filter", "name=^"+args[0]+"$")
+			what = "named " + args[0]
+		case !portsAll:
+			workDir := portsPath
+			if workDir == "" {
+				if workDir, err = os.Getwd(); err != nil {
+					return fmt.Errorf("failed to get working directory: %w", err)
+				}
+			}
+			if workDir, err = filepath.Abs(workDir); err != nil {
+				return fmt.Errorf("failed to resolve path: %w", err)
+			}
+			// Other projects can have the same directory name, so they are told apart
+			// by the project path hash
+			containers, err := runner.ProjectContainers(dockerClient, workDir)
+			if err != nil {
+				return err
+			}
+			project = make(map[string]bool)
+			for _, c := range containers {
+				project[c.Name] = true
+			}
+			psArgs = append(psArgs, "--filter", "label=packnplay-project="+filepath.Base(workDir))
+		}
+		output, err := dockerClient.Run(append(psArgs, "--format", "{{json .}}")...)
+		if err != nil {
+			return fmt.Errorf("failed to list containers: %w", err)
+		}
+
+		var infos []ContainerInfo
+		for _, info := range parseContainerInfos(output) {
+			if project == nil || project[info.Names] {
+				infos = append(infos, info)
+			}
+		}
+		if len(infos) == 0 {
+			if portsAll {
+				return fmt.Errorf("no packnplay-managed containers running")
+			}
+			return fmt.Errorf("no running packnplay container %s", what)
+		}
+
+		var all []containerPorts
+		for _, info := range infos {
+			all = append(all, containerPorts{Name: info.Names, Ports: lookupPorts(dockerClient, info)})
+		}
+		return printPorts(os.Stdout, all)
+	},
+}
+
+// publishedPort is one container port published on a host address
+type publishedPort struct {
+	ContainerPort string // with its protocol, e.g. 3000/tcp
+	HostIP        string
+	HostPort      string
+}
+
+// containerPorts are the published ports of one container
+type containerPorts struct {
+	Name  string
+	Ports []publishedPort
+}
+
+// lookupPorts asks the runtime which ports a container publishes, falling back to the
+// mappings recorded in its labels when the runtime can't say
+func lookupPorts(dockerClient *docker.Client, info ContainerInfo) []publishedPort {
+	if output, err := dockerClient.Run("port", info.Names); err == nil {
+		return parseDockerPort(output)
+	}
+	return portsFromLabel(labelValue(info.Labels, container.PortsLabel))
+}
+
+// parseDockerPort parses docker port output ("3000/tcp -> 0.0.0.0:49153"). The same
+// port bound on both the IPv4 and IPv6 wildcard addresses is listed once.
+func parseDockerPort(output string) []publishedPort {
+	var ports []publishedPort
+	seen := make(map[string]bool)
+	for _, line := range splitLines(output) {
+		containerPort, hostAddr, ok := strings.Cut(strings.TrimSpace(line), " -> ")
+		if !ok {
+			continue
+		}
+		host, port, err := net.SplitHostPort(hostAddr)
+		if err != nil {
+			continue
+		}
+		if host == "::" {
+			host = "0.0.0.0"
+		}
+		key := containerPort + " " + host + " " + port
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		ports = append(ports, publishedPort{ContainerPort: containerPort, HostIP: host, HostPort: port})
+	}
+	return ports
+}
+
+// portsFromLabel parses the space-separated [hostIP:]hostPort:containerPort[/protocol]
+// mappings recorded in container.PortsLabel
+func portsFromLabel(label string) []publishedPort {
+	var ports []publishedPort
+	for _, mapping := range strings.Fields(label) {
+		spec, protocol, _ := strings.Cut(mapping, "/")
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		i := strings.LastIndex(spec, ":")
+		if i < 0 {
+			continue
+		}
+		host, containerPort := spec[:i], spec[i+1:]
+		hostIP, hostPort := "0.0.0.0", host
+		if j := strings.LastIndex(host, ":"); j >= 0 {
+			hostIP, hostPort = strings.Trim(host[:j], "[]"), host[j+1:]
+		}
+		ports = append(ports, publishedPort{ContainerPort: containerPort + "/" + protocol, HostIP: hostIP, HostPort: hostPort})
+	}
+	return ports
+}
+
+// URL returns where a browser on the host reaches a TCP port, or "" for other protocols
+func (p publishedPort) URL() string {
+	if !strings.HasSuffix(p.ContainerPort, "/tcp") {
+		return ""
+	}
+	switch p.HostIP {
+	case "", "0.0.0.0", "::", "127.0.0.1", "::1":
+		return "http://localhost:" + p.HostPort
+	}
+	return "http://" + net.JoinHostPort(p.HostIP, p.HostPort)
+}
+
+// printPorts writes one row per published port, noting containers that publish none
+func printPorts(w io.Writer, all []containerPorts) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "CONTAINER\tPORT\tHOST\tURL")
+	for _, c := range all {
+		if len(c.Ports) == 0 {
+			_, _ = fmt.Fprintf(tw, "%s\t-\t-\t(no published ports)\n", c.Name)
+			continue
+		}
+		for _, p := range c.Ports {
+			url := p.URL()
+			if url == "" {
+				url = "-"
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.Name, p.ContainerPort, net.JoinHostPort(p.HostIP, p.HostPort), url)
+		}
+	}
+	return tw.Flush()
+}
+
+// labelValue returns the value of key in a docker ps labels string
+func labelValue(labels, key string) string {
+	for _, pair := range splitByComma(labels) {
+		kv := splitByEquals(pair)
+		if len(kv) == 2 && kv[0] == key {
+			return kv[1]
+		}
+	}
+	return ""
+}
+
+func init() {
+	rootCmd.AddCommand(portsCmd)
+
+	portsCmd.Flags().StringVar(&portsPath, "path", "", "Project path (default: pwd)")
+	portsCmd.Flags().BoolVar(&portsAll, "all", false, "Show ports of all packnplay-managed containers")
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDockerPort(t *testing.T) {
+	output := `3000/tcp -> 0.0.0.0:49153
+3000/tcp -> [::]:49153
+53/udp -> 127.0.0.1:5353
+8080/tcp -> [::1]:18080
+`
+	want := []publishedPort{
+		{ContainerPort: "3000/tcp", HostIP: "0.0.0.0", HostPort: "49153"},
+		{ContainerPort: "53/udp", HostIP: "127.0.0.1", HostPort: "5353"},
+		{ContainerPort: "8080/tcp", HostIP: "::1", HostPort: "18080"},
+	}
+	if got := parseDockerPort(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDockerPort() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPortsFromLabel(t *testing.T) {
+	labels := "managed-by=packnplay,packnplay-ports=49153:3000 127.0.0.1:5353:53/udp [::1]:5432:5432/tcp,packnplay-project=web"
+	want := []publishedPort{
+		{ContainerPort: "3000/tcp", HostIP: "0.0.0.0", HostPort: "49153"},
+		{ContainerPort: "53/udp", HostIP: "127.0.0.1", HostPort: "5353"},
+		{ContainerPort: "5432/tcp", HostIP: "::1", HostPort: "5432"},
+	}
+	if got := portsFromLabel(labelValue(labels, "packnplay-ports")); !reflect.DeepEqual(got, want) {
+		t.Errorf("portsFromLabel() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPublishedPortURL(t *testing.T) {
+	tests := []struct {
+		port publishedPort
+		want string
+	}{
+		{publishedPort{ContainerPort: "3000/tcp", HostIP: "0.0.0.0", HostPort: "49153"}, "http://localhost:49153"},
+		{publishedPort{ContainerPort: "3000/tcp", HostIP: "::1", HostPort: "3000"}, "http://localhost:3000"},
+		{publishedPort{ContainerPort: "3000/tcp", HostIP: "192.168.1.5", HostPort: "8080"}, "http://192.168.1.5:8080"},
+		{publishedPort{ContainerPort: "3000/tcp", HostIP: "fd00::5", HostPort: "8080"}, "http://[fd00::5]:8080"},
+		{publishedPort{ContainerPort: "53/udp", HostIP: "127.0.0.1", HostPort: "5353"}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.port.URL(); got != tt.want {
+			t.Errorf("URL(%+v) = %q, want %q", tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestPrintPorts(t *testing.T) {
+	all := []containerPorts{
+		{Name: "packnplay-web-main", Ports: []publishedPort{
+			{ContainerPort: "3000/tcp", HostIP: "0.0.0.0", HostPort: "49153"},
+			{ContainerPort: "53/udp", HostIP: "127.0.0.1", HostPort: "5353"},
+		}},
+		{Name: "packnplay-web-feature"},
+	}
+
+	var out bytes.Buffer
+	if err := printPorts(&out, all); err != nil {
+		t.Fatalf("printPorts() error = %v", err)
+	}
+	for _, want := range []string{
+		"packnplay-web-main      3000/tcp   0.0.0.0:49153    http://localhost:49153",
+		"packnplay-web-main      53/udp     127.0.0.1:5353   -",
+		"packnplay-web-feature   -          -                (no published ports)",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
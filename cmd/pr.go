@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/obra/packnplay/pkg/git"
+	"github.com/spf13/cobra"
+)
+
+var (
+	prPath     string
+	prWorktree string
+	prRemote   string
+	prBase     string
+	prTitle    string
+	prBody     string
+	prDraft    bool
+	prWeb      bool
+)
+
+var prCmd = &cobra.Command{
+	Use:   "pr [flags]",
+	Short: "Push a worktree's branch and open a pull request for it",
+	Long: `Push the branch of a worktree (by default the current branch's) and open a pull
+request for it with the GitHub CLI, gh, on the host. gh uses the same login that
+--gh-creds gives containers.
+
+The title and body are filled in from the branch's commits unless --title is given. If
+the branch already has an open pull request, the branch is pushed to update it.
+Uncommitted changes in the worktree are not included.`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workDir := prPath
+		if workDir == "" {
+			var err error
+			workDir, err = os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get working directory: %w", err)
+			}
+		}
+		workDir, err := filepath.Abs(workDir)
+		if err != nil {
+			return fmt.Errorf("failed to resolve path: %w", err)
+		}
+		if !git.IsGitRepo(workDir) {
+			return fmt.Errorf("%s is not a git repository", workDir)
+		}
+
+		branch := prWorktree
+		if branch == "" {
+			if branch, err = git.GetCurrentBranch(workDir); err != nil || branch == "" {
+				return fmt.Errorf("not on a branch; pass --worktree")
+			}
+		}
+		worktreePath, err := git.FindWorktree(workDir, branch)
+		if err != nil {
+			return fmt.Errorf("no worktree has branch '%s' checked out\n\nStart one with: packnplay run --worktree=%s <command>", branch, branch)
+		}
+
+		if _, err := exec.LookPath("gh"); err != nil {
+			return fmt.Errorf("gh not found; install the GitHub CLI (https://cli.github.com) and run gh auth login")
+		}
+
+		if changed, err := git.ChangedFiles(worktreePath); err == nil && len(changed) > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %d uncommitted changes in %s are not part of the pull request\n", len(changed), worktreePath)
+		}
+
+		if err := git.Push(worktreePath, prRemote, branch); err != nil {
+			return err
+		}
+
+		if url := existingPullRequest(worktreePath, branch); url != "" {
+			fmt.Printf("Updated pull request %s\n", url)
+			return nil
+		}
+
+		gh := exec.Command("gh", prCreateArgs(branch)...)
+		gh.Dir = worktreePath
+		gh.Stdin, gh.Stdout, gh.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := gh.Run(); err != nil {
+			return fmt.Errorf("gh pr create failed: %w", err)
+		}
+		return nil
+	},
+}
+
+// prCreateArgs returns the gh arguments that open a pull request for branch
+func prCreateArgs(branch string) []string {
+	args := []string{"pr", "create", "--head", branch}
+	if prBase != "" {
+		args = append(args, "--base", prBase)
+	}
+	if prTitle != "" {
+		args = append(args, "--title", prTitle, "--body", prBody)
+	} else {
+		args = append(args, "--fill")
+	}
+	if prDraft {
+		args = append(args, "--draft")
+	}
+	if prWeb {
+		args = append(args, "--web")
+	}
+	return args
+}
+
+// existingPullRequest returns the URL of branch's open pull request, or "" if it has none
+func existingPullRequest(dir, branch string) string {
+	gh := exec.Command("gh", "pr", "view", branch, "--json", "url,state", "--jq", `select(.state == "OPEN") | .url`)
+	gh.Dir = dir
+	output, err := gh.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func init() {
+	rootCmd.AddCommand(prCmd)
+
+	prCmd.Flags().StringVar(&prPath, "path", "", "Project path (default: pwd)")
+	prCmd.Flags().StringVar(&prWorktree, "worktree", "", "Worktree (branch) to open the pull request for (default: current branch)")
+	prCmd.Flags().StringVar(&prRemote, "remote", "origin", "Remote to push the branch to")
+	prCmd.Flags().StringVar(&prBase, "base", "", "Branch to merge into (default: the repository's default branch)")
+	prCmd.Flags().StringVar(&prTitle, "title", "", "Pull request title (default: filled in from the commits)")
+	prCmd.Flags().StringVar(&prBody, "body", "", "Pull request body, used with --title")
+	prCmd.Flags().BoolVar(&prDraft, "draft", false, "Open the pull request as a draft")
+	prCmd.Flags().BoolVar(&prWeb, "web", false, "Finish creating the pull request in the browser")
+}
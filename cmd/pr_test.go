@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPRCreateArgs(t *testing.T) {
+	tests := []struct {
+		name  string
+		base  string
+		title string
+		body  string
+		draft bool
+		want  []string
+	}{
+		{
+			name: "filled from commits",
+			want: []string{"pr", "create", "--head", "fix-auth", "--fill"},
+		},
+		{
+			name:  "explicit title",
+			base:  "develop",
+			title: "Fix auth",
+			body:  "Closes #12",
+			draft: true,
+			want:  []string{"pr", "create", "--head", "fix-auth", "--base", "develop", "--title", "Fix auth", "--body", "Closes #12", "--draft"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prBase, prTitle, prBody, prDraft = tt.base, tt.title, tt.body, tt.draft
+			defer func() { prBase, prTitle, prBody, prDraft = "", "", "", false }()
+
+			if got := prCreateArgs("fix-auth"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("prCreateArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/credentials"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/git"
+	"github.com/obra/packnplay/pkg/prune"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneDryRun   bool
+	pruneForce    bool
+	pruneImageAge time.Duration
+	pruneVerbose  bool
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove orphaned containers, stale worktrees, old images and unused credential files",
+	Long: `Find what packnplay left behind and remove it:
+
+  - containers that were stopped but never removed, or whose mounted worktree or
+    project directory has been deleted
+  - directories under the worktrees root that git no longer tracks
+  - devcontainer images superseded by a newer build, or unused and older than --image-age
+  - files in packnplay's credential directories that no remaining container uses
+    (the shared Claude login is kept), and any whose mode isn't 0600, which are restricted
+
+Everything that will be removed is listed first. Prune asks for confirmation unless
+--force is given, and --dry-run only lists.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		runtime := ""
+		if cfg, err := config.LoadWithoutRuntimeCheck(); err == nil {
+			runtime = cfg.ContainerRuntime
+		}
+		dockerClient, err := docker.NewClientWithRuntime(runtime, pruneVerbose)
+		if err != nil {
+			return fmt.Errorf("failed to initialize container runtime: %w", err)
+		}
+
+		root, err := git.WorktreesRoot()
+		if err != nil {
+			return fmt.Errorf("failed to find worktrees directory: %w", err)
+		}
+
+		credentialDirs, err := credentials.FileDirs()
+		if err != nil {
+			return fmt.Errorf("failed to find credentials directory: %w", err)
+		}
+
+		// Bind mount sources of a remote daemon's containers live on the remote host
+		checkMounts := !docker.IsRemoteHost(docker.DaemonHost())
+
+		inv, err := prune.Collect(dockerClient, root, credentialDirs, checkMounts)
+		if err != nil {
+			return err
+		}
+		candidates := prune.Plan(inv, prune.Options{ImageAge: pruneImageAge, Now: time.Now()})
+
+		if len(candidates) == 0 {
+			fmt.Println("Nothing to prune")
+			return nil
+		}
+
+		prune.Print(os.Stdout, candidates)
+		if pruneDryRun {
+			return nil
+		}
+
+		if !pruneForce {
+			if !term.IsTerminal(os.Stdin.Fd()) {
+				return fmt.Errorf("not removing anything without confirmation; use --force")
+			}
+			fmt.Printf("\nRemove these %d items? [y/N]: ", len(candidates))
+			answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+				fmt.Println("Nothing removed")
+				return nil
+			}
+		}
+
+		return prune.Apply(dockerClient, candidates, os.Stdout)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+
+	pruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "List what would be removed without removing it")
+	pruneCmd.Flags().BoolVarP(&pruneForce, "force", "f", false, "Remove without asking for confirmation")
+	pruneCmd.Flags().DurationVar(&pruneImageAge, "image-age", 30*24*time.Hour, "Also remove unused devcontainer images built longer ago than this (0 = keep them)")
+	pruneCmd.Flags().BoolVar(&pruneVerbose, "verbose", false, "Show all docker commands")
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/obra/packnplay/pkg/inflight"
+	"github.com/obra/packnplay/pkg/runner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	recoverClean   bool
+	recoverVerbose bool
+)
+
+var recoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "Clean up after runs that were interrupted while setting up",
+	Long: `packnplay records worktrees it is creating and containers it is starting until
+they are ready. If packnplay is killed halfway (a crash, Ctrl-C, a closed terminal),
+the record stays behind, and recover finds it.
+
+On a terminal, recover asks about each interrupted operation: clean it up (remove the
+worktree, keeping its branch, or the container), resume it (keep a worktree git
+finished adding, for the next run to use), or skip it for now. packnplay run asks the
+same questions before starting. --clean cleans everything up without asking.`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ops, err := inflight.Interrupted()
+		if err != nil {
+			return err
+		}
+		if len(ops) == 0 {
+			fmt.Println("Nothing to recover")
+			return nil
+		}
+
+		if recoverClean {
+			var failed int
+			for i := range ops {
+				if err := runner.CleanUpOperation(&ops[i], recoverVerbose); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+					failed++
+					continue
+				}
+				fmt.Printf("Cleaned up %s\n", ops[i].Target)
+			}
+			if failed > 0 {
+				return fmt.Errorf("failed to clean up %d of %d interrupted operations", failed, len(ops))
+			}
+			return nil
+		}
+
+		if !term.IsTerminal(os.Stdin.Fd()) {
+			for _, op := range ops {
+				fmt.Printf("Interrupted while %s\n", op.Describe())
+			}
+			return fmt.Errorf("no terminal to ask on; use --clean to clean everything up")
+		}
+		runner.RecoverInterrupted(recoverVerbose)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(recoverCmd)
+
+	recoverCmd.Flags().BoolVar(&recoverClean, "clean", false, "Clean up every interrupted operation without asking")
+	recoverCmd.Flags().BoolVar(&recoverVerbose, "verbose", false, "Show all docker commands")
+}
@@ -2,18 +2,32 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
 
 	"github.com/obra/packnplay/pkg/config"
 	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/imageinfo"
+	"github.com/obra/packnplay/pkg/registry"
 	"github.com/spf13/cobra"
 )
 
-var refreshVerbose bool
+var (
+	refreshVerbose bool
+	refreshForce   bool
+)
 
 var refreshCmd = &cobra.Command{
 	Use:   "refresh-container",
 	Short: "Pull latest version of default container image",
-	Long:  `Force pull the latest version of the configured default container image to get updated tools and dependencies.`,
+	Long: `Pull the latest version of the configured default container image to get updated tools and dependencies.
+
+The image's tag is resolved in its registry first, to report which architecture and
+digest this host will use. When the image for this host's platform hasn't changed,
+nothing is pulled, even if the multi-architecture index the tag points at has moved
+(because another architecture was rebuilt). Use --force to pull anyway.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Load config to get the configured default image
 		cfg, err := config.Load()
@@ -28,6 +42,32 @@ var refreshCmd = &cobra.Command{
 			return fmt.Errorf("failed to initialize docker: %w", err)
 		}
 
+		trackingPath := config.GetVersionTrackingPath()
+		tracking, err := config.LoadVersionTracking(trackingPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			tracking = &config.VersionTrackingData{
+				Notifications: make(map[string]config.VersionNotification),
+				Pulls:         make(map[string]config.ImagePull),
+			}
+		}
+
+		platform := hostPlatform(dockerClient)
+		res, err := registry.NewClient().Resolve(defaultImage, platform)
+		if err != nil {
+			// Private registries and offline hosts can still pull through the runtime
+			fmt.Fprintf(os.Stderr, "Warning: failed to resolve %s in its registry, pulling without checking: %v\n", defaultImage, err)
+			res = nil
+		} else {
+			fmt.Print(describeResolution(res))
+		}
+
+		local, _ := imageinfo.InspectLocal(dockerClient, defaultImage)
+		if res != nil && !refreshForce && platformUpToDate(local, res, tracking.Pulls[defaultImage]) {
+			fmt.Printf("Default container is already up to date for %s\n", res.Platform)
+			return nil
+		}
+
 		if refreshVerbose {
 			fmt.Printf("Pulling latest version of %s...\n", defaultImage)
 		}
@@ -37,6 +77,23 @@ var refreshCmd = &cobra.Command{
 			return fmt.Errorf("failed to pull image %s: %w\nDocker output:\n%s", defaultImage, err, output)
 		}
 
+		if res != nil {
+			if pulled, err := imageinfo.InspectLocal(dockerClient, defaultImage); err == nil {
+				err := config.UpdateVersionTracking(trackingPath, func(tracking *config.VersionTrackingData) {
+					tracking.Pulls[defaultImage] = config.ImagePull{
+						Platform:    res.Platform.String(),
+						Digest:      res.Digest,
+						IndexDigest: res.IndexDigest,
+						ImageID:     pulled.ID,
+						PulledAt:    time.Now(),
+					}
+				})
+				if err != nil && refreshVerbose {
+					fmt.Fprintf(os.Stderr, "Warning: failed to save tracking data: %v\n", err)
+				}
+			}
+		}
+
 		if refreshVerbose {
 			fmt.Printf("Successfully updated %s\n", defaultImage)
 		} else {
@@ -47,7 +104,59 @@ var refreshCmd = &cobra.Command{
 	},
 }
 
+// hostPlatform returns the platform the runtime pulls images for, which on macOS and
+// Windows is its Linux VM's rather than the host's
+func hostPlatform(dockerClient *docker.Client) registry.Platform {
+	format := "{{.OSType}}/{{.Architecture}}"
+	if dockerClient.IsPodman() {
+		format = "{{.Host.OS}}/{{.Host.Arch}}"
+	}
+	if dockerClient.Capabilities().DaemonInfo {
+		if output, err := dockerClient.Run("info", "--format", format); err == nil {
+			if platform, err := registry.ParsePlatform(output); err == nil {
+				return platform
+			}
+		}
+	}
+	platform, _ := registry.ParsePlatform("linux/" + runtime.GOARCH)
+	return platform
+}
+
+// describeResolution reports which image a tag resolves to for this host's platform
+func describeResolution(res *registry.Resolution) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s for %s: %s\n", res.Reference, res.Platform, res.Digest)
+	if res.MultiPlatform() {
+		names := make([]string, 0, len(res.Platforms))
+		for _, p := range res.Platforms {
+			names = append(names, p.String())
+		}
+		fmt.Fprintf(&b, "  multi-architecture index %s (%s)\n", res.IndexDigest, strings.Join(names, ", "))
+	}
+	return b.String()
+}
+
+// platformUpToDate reports whether the local image already is the one res resolves to.
+// The docker image store names an image by its config digest and keeps the digest it
+// was pulled by; the containerd store and Podman keep only the index digest, so the
+// record of the last pull covers an index that moved while this platform's image didn't.
+func platformUpToDate(local *imageinfo.Info, res *registry.Resolution, last config.ImagePull) bool {
+	if local == nil {
+		return false
+	}
+	if local.ID == res.ConfigDigest || local.ID == res.Digest {
+		return true
+	}
+	for _, repoDigest := range local.RepoDigests {
+		if _, digest, _ := strings.Cut(repoDigest, "@"); digest == res.IndexDigest || digest == res.Digest {
+			return true
+		}
+	}
+	return last.ImageID == local.ID && last.Digest == res.Digest && last.Platform == res.Platform.String()
+}
+
 func init() {
 	rootCmd.AddCommand(refreshCmd)
 	refreshCmd.Flags().BoolVarP(&refreshVerbose, "verbose", "v", false, "Show detailed output")
-}
\ No newline at end of file
+	refreshCmd.Flags().BoolVar(&refreshForce, "force", false, "Pull even when this platform's image hasn't changed")
+}
@@ -2,6 +2,10 @@ package cmd
 
 import (
 	"testing"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/imageinfo"
+	"github.com/obra/packnplay/pkg/registry"
 )
 
 func TestRefreshCommand(t *testing.T) {
@@ -27,4 +31,50 @@ func TestRefreshCommandFlags(t *testing.T) {
 	if flag == nil {
 		t.Error("refresh command should have --verbose flag")
 	}
-}
\ No newline at end of file
+}
+
+func TestPlatformUpToDate(t *testing.T) {
+	res := &registry.Resolution{
+		IndexDigest:  "sha256:newindex",
+		Digest:       "sha256:arm",
+		ConfigDigest: "sha256:armconfig",
+		Platform:     registry.Platform{OS: "linux", Architecture: "arm64"},
+	}
+	pulled := config.ImagePull{Platform: "linux/arm64", Digest: "sha256:arm", ImageID: "sha256:oldindex"}
+
+	tests := []struct {
+		name  string
+		local *imageinfo.Info
+		last  config.ImagePull
+		want  bool
+	}{
+		{"not pulled", nil, config.ImagePull{}, false},
+		{"image store id is the config digest", &imageinfo.Info{ID: "sha256:armconfig"}, config.ImagePull{}, true},
+		{"index unchanged", &imageinfo.Info{ID: "sha256:x", RepoDigests: []string{"ghcr.io/obra/img@sha256:newindex"}}, config.ImagePull{}, true},
+		{"index moved, platform image recorded as unchanged", &imageinfo.Info{ID: "sha256:oldindex", RepoDigests: []string{"ghcr.io/obra/img@sha256:oldindex"}}, pulled, true},
+		{"index moved, nothing recorded", &imageinfo.Info{ID: "sha256:oldindex", RepoDigests: []string{"ghcr.io/obra/img@sha256:oldindex"}}, config.ImagePull{}, false},
+		{"platform image changed", &imageinfo.Info{ID: "sha256:oldindex"}, config.ImagePull{Platform: "linux/arm64", Digest: "sha256:oldarm", ImageID: "sha256:oldindex"}, false},
+		{"image replaced since the pull", &imageinfo.Info{ID: "sha256:other"}, pulled, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := platformUpToDate(tt.local, res, tt.last); got != tt.want {
+				t.Errorf("platformUpToDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribeResolution(t *testing.T) {
+	res := &registry.Resolution{
+		Reference:   "ghcr.io/obra/img:latest",
+		IndexDigest: "sha256:index",
+		Digest:      "sha256:arm",
+		Platform:    registry.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"},
+		Platforms:   []registry.Platform{{OS: "linux", Architecture: "amd64"}, {OS: "linux", Architecture: "arm64", Variant: "v8"}},
+	}
+	want := "ghcr.io/obra/img:latest for linux/arm64/v8: sha256:arm\n  multi-architecture index sha256:index (linux/amd64, linux/arm64/v8)\n"
+	if got := describeResolution(res); got != want {
+		t.Errorf("describeResolution() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/remote"
+	"github.com/obra/packnplay/pkg/runner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	restartPull    bool
+	restartDiscard bool
+)
+
+var restartCmd = &cobra.Command{
+	Use:   "restart [flags] [command...]",
+	Short: "Recreate the container for the current worktree",
+	Long: `Stop and remove the container for the current worktree, then start a new one with
+mounts, env vars and credentials set up afresh from the current config, and run the
+command in it. Use it after changing the config or credentials.
+
+The worktree is kept, and so is the image unless --pull is given. restart takes the
+same flags as run. Without a command, the project's default "command" is run, or a
+shell when there is none.
+
+Like stop, it refuses to remove a copied workspace with changes that weren't exported,
+unless --discard is given.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if runRemove {
+			return fmt.Errorf("--rm can't be used with restart")
+		}
+
+		runtime := runRuntime
+		if runtime == "" {
+			if cfg, err := config.LoadWithoutRuntimeCheck(); err == nil {
+				runtime = cfg.ContainerRuntime
+			}
+		}
+		// A remote host's containers are found through its daemon, as run will
+		remoteHost, runtime, err := resolveRemoteHost(runHost, docker.DaemonHost(), runtime)
+		if err != nil {
+			return err
+		}
+		if remoteHost != "" {
+			_ = os.Setenv("DOCKER_HOST", remote.Host{Target: remoteHost}.DockerHost())
+		}
+
+		existing, err := runner.FindContainer(runPath, runWorktree, runNoWorktree, runtime, runVerbose)
+		if err != nil {
+			return err
+		}
+
+		if restartPull {
+			if err := existing.PullImage(runVerbose); err != nil {
+				return err
+			}
+		}
+		if err := stopContainer(existing.Client, existing.Name, restartDiscard); err != nil {
+			return err
+		}
+
+		runFallbackCommand = runner.DefaultShellCommand
+		return runCmd.RunE(cmd, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(restartCmd)
+	restartCmd.Flags().BoolVar(&restartPull, "pull", false, "Pull the container's image before recreating it")
+	restartCmd.Flags().BoolVar(&restartDiscard, "discard", false, "Recreate a copied workspace even with changes that weren't exported")
+	// run's flags are added in its init
+}
@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/charmbracelet/x/term"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/events"
+	"github.com/obra/packnplay/pkg/ui"
 	"github.com/spf13/cobra"
 )
 
@@ -17,17 +21,49 @@ Configuration:
   Config file: ~/.config/packnplay/config.json
   Credentials: ~/.local/share/packnplay/credentials/
   Worktrees:   ~/.local/share/packnplay/worktrees/
+  Cache:       ~/.cache/packnplay/shared/ (shared downloads, when enabled)
 
 Default container: ghcr.io/obra/packnplay-default:latest
   Includes: Node.js, Claude Code, OpenAI Codex, Google Gemini, GitHub CLI,
             GitHub Copilot, Qwen Code, Cursor CLI, Sourcegraph Amp
 
-Supported AI agents: claude, codex, gemini, copilot, qwen, cursor, amp, deepseek`,
+Supported AI agents: claude, codex, gemini, copilot, qwen, cursor, amp, deepseek
+
+Run without a command on a terminal to pick a running container to attach to, stop,
+show logs of, or copy the reconnect command for.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !term.IsTerminal(os.Stdin.Fd()) || !term.IsTerminal(os.Stdout.Fd()) {
+			return cmd.Help()
+		}
+		dockerClient, err := docker.NewClient(false)
+		if err != nil {
+			return fmt.Errorf("failed to initialize docker: %w", err)
+		}
+		return runContainerPicker(dockerClient)
+	},
+}
+
+// rootPlain is --plain: no color and ASCII-only symbols, for logs and limited terminals
+var rootPlain bool
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&rootPlain, "plain", false, "Plain output: no color, and ASCII instead of Unicode symbols (NO_COLOR turns off color only)")
+	rootCmd.PersistentFlags().StringVar(&rootContext, "context", "", "Docker context to use (overrides DOCKER_HOST, DOCKER_CONTEXT and docker_context in config)")
+	cobra.OnInitialize(func() { ui.Setup(rootPlain, os.Getenv) }, applyDockerContext)
 }
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
+		runEvents.Emit(events.Event{Event: events.Error, Error: err.Error()})
 		fmt.Fprintln(os.Stderr, err)
+		printDockerHint(err)
 		os.Exit(1)
 	}
 }
+
+// printDockerHint tells the user what to do about a daemon error err wraps, if any
+func printDockerHint(err error) {
+	if hint := docker.Hint(err); hint != "" {
+		fmt.Fprintf(os.Stderr, "Hint: %s\n", hint)
+	}
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -9,39 +10,95 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/obra/packnplay/pkg/cache"
 	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/events"
+	"github.com/obra/packnplay/pkg/preflight"
+	"github.com/obra/packnplay/pkg/remote"
 	"github.com/obra/packnplay/pkg/runner"
+	"github.com/obra/packnplay/pkg/secrets"
 	"github.com/spf13/cobra"
 )
 
 var (
-	runPath         string
-	runWorktree     string
-	runNoWorktree   bool
-	runEnv          []string
-	runVerbose      bool
-	runRuntime      string
-	runConfig       string
-	runReconnect    bool
-	runPublishPorts []string
+	runPath             string
+	runWorktree         string
+	runNoWorktree       bool
+	runEnv              []string
+	runVerbose          bool
+	runRuntime          string
+	runConfig           string
+	runReconnect        bool
+	runPublishPorts     []string
+	runClaudeSync       string
+	runImageVariant     string
+	runAWSProfile       string
+	runCredProviders    []string
+	runGroupAdd         []string
+	runSharedCache      bool
+	runDependencyCaches bool
+	runNoForwardPorts   bool
+	runUlimits          []string
+	runResources        config.ResourcesConfig
+	runManagedExec      bool
+	runRecord           string
+	runTimeout          string
+	runStopOnTimeout    bool
+	runSummary          string
+	runNoNetwork        bool
+	runAllowDomains     []string
+	runDocker           string
+	runAutoCommit       bool
+	runStrictCreds      bool
+	runSELinuxLabels    bool
+	runScopedCreds      bool
+	runBuildSecrets     []string
+	runBuildSSH         []string
+	runCacheFrom        []string
+	runCacheTo          string
+	runCaptureLogs      bool
+	runJSONEvents       bool
+	runHost             string
+	runRemove           bool
+	runCopyWorkspace    bool
+	runDryRun           bool
+	runEvents           *events.Writer // set when --json-events is on; nil discards events
+	runFallbackCommand  []string       // run when there is no command and no project default (restart opens a shell)
 	// Credential flags
-	runGitCreds *bool
-	runSSHCreds *bool
-	runGHCreds  *bool
-	runGPGCreds *bool
-	runNPMCreds *bool
-	runAWSCreds *bool
-	runAllCreds bool
+	runGitCreds   *bool
+	runSSHCreds   *bool
+	runSSHAgent   *bool
+	runGHCreds    *bool
+	runGPGCreds   *bool
+	runGPGAgent   *bool
+	runNPMCreds   *bool
+	runAWSCreds   *bool
+	runGCPCreds   *bool
+	runAzureCreds *bool
+	runAzureToken bool
+	runKubeCreds  *bool
+	runAllCreds   bool
 )
 
 var runCmd = &cobra.Command{
 	Use:   "run [flags] [command...]",
 	Short: "Run command in container",
-	Long:  `Start a container and execute the specified command inside it.`,
-	Args:  cobra.MinimumNArgs(1),
+	Long: `Start a container and execute the specified command inside it.
+
+Several commands can run one after another in the same container by separating them
+with --then, e.g. "packnplay run npm ci --then npm test". Each step is timed and the
+run stops at the first step that fails.
+
+Without a command, the "command" from the project's .packnplay.json is run.`,
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		// Look for fd 3 before packnplay opens files of its own
+		if runJSONEvents {
+			runEvents = events.Open()
+		}
+
 		// Ensure credential watcher is running (auto-managed daemon)
 		if err := ensureCredentialWatcher(); err != nil {
 			return fmt.Errorf("failed to start credential watcher: %w", err)
@@ -75,8 +132,35 @@ var runCmd = &cobra.Command{
 			}
 		}
 
+		// Project config (.packnplay.json) overrides global settings; flags override both
+		projectDir, err := findProjectDir(runPath)
+		if err != nil {
+			return err
+		}
+		projectCfg, err := config.LoadProjectConfig(projectDir)
+		if err != nil {
+			return err
+		}
+		// A cloned repo's settings only reach past the sandbox once the user trusts it
+		projectCfg, untrustedWorkspace := confirmWorkspaceTrust(cfg, projectDir, projectCfg)
+		eff, err := config.Layer(cfg, projectCfg, projectDir)
+		if err != nil {
+			return err
+		}
+
+		// With no command on the command line, run the project's default one
+		if len(args) == 0 {
+			args = eff.Command
+			if len(args) == 0 {
+				args = runFallbackCommand
+			}
+			if len(args) == 0 {
+				return fmt.Errorf("no command given and no default \"command\" in %s", config.GetProjectConfigPath(projectDir))
+			}
+		}
+
 		// Determine which credentials to use (flags override config)
-		creds := cfg.DefaultCredentials
+		creds := eff.Credentials
 
 		// Check if flags were explicitly set
 		if cmd.Flags().Changed("git-creds") {
@@ -85,25 +169,104 @@ var runCmd = &cobra.Command{
 		if cmd.Flags().Changed("ssh-creds") {
 			creds.SSH = *runSSHCreds
 		}
+		if cmd.Flags().Changed("ssh-agent") {
+			creds.SSHAgent = *runSSHAgent
+		}
 		if cmd.Flags().Changed("gh-creds") {
 			creds.GH = *runGHCreds
 		}
 		if cmd.Flags().Changed("gpg-creds") {
 			creds.GPG = *runGPGCreds
 		}
+		if cmd.Flags().Changed("gpg-agent") {
+			creds.GPGAgent = *runGPGAgent
+		}
 		if cmd.Flags().Changed("npm-creds") {
 			creds.NPM = *runNPMCreds
 		}
 		if cmd.Flags().Changed("aws-creds") {
 			creds.AWS = *runAWSCreds
 		}
+		// Picking an AWS profile implies AWS credentials
+		if runAWSProfile != "" && !cmd.Flags().Changed("aws-creds") {
+			creds.AWS = true
+		}
+		if cmd.Flags().Changed("gcp-creds") {
+			creds.GCP = *runGCPCreds
+		}
+		if cmd.Flags().Changed("azure-creds") {
+			creds.Azure = *runAzureCreds
+		}
+		// Injecting a token implies Azure credentials
+		if runAzureToken && !cmd.Flags().Changed("azure-creds") {
+			creds.Azure = true
+		}
+		if cmd.Flags().Changed("kube-creds") {
+			creds.Kube = *runKubeCreds
+		}
 		if runAllCreds {
 			creds.Git = true
 			creds.SSH = true
+			creds.SSHAgent = true
 			creds.GH = true
 			creds.GPG = true
+			creds.GPGAgent = true
 			creds.NPM = true
 			creds.AWS = true
+			creds.GCP = true
+			creds.Azure = true
+			creds.Kube = true
+		}
+
+		// Shared download cache (flag > config)
+		sharedCache := cfg.SharedCache.Enabled
+		if cmd.Flags().Changed("shared-cache") {
+			sharedCache = runSharedCache
+		}
+		var sharedCacheMax int64
+		if sharedCache && cfg.SharedCache.MaxSize != "" {
+			sharedCacheMax, err = cache.ParseSize(cfg.SharedCache.MaxSize)
+			if err != nil {
+				return fmt.Errorf("invalid shared_cache.max_size in config: %w", err)
+			}
+		}
+
+		// Package manager cache volumes (flag > project > config)
+		dependencyCaches := eff.DependencyCaches
+		if cmd.Flags().Changed("dependency-caches") {
+			dependencyCaches.Enabled = runDependencyCaches
+		}
+		if err := dependencyCaches.Validate(); err != nil {
+			return fmt.Errorf("invalid dependency_caches setting: %w", err)
+		}
+
+		// Resource limits: global config, then project, then flags (docker keeps the last per resource)
+		ulimits := append(append([]string{}, eff.Ulimits...), runUlimits...)
+		for _, ulimit := range ulimits {
+			if err := preflight.ValidateUlimit(ulimit); err != nil {
+				return err
+			}
+		}
+
+		// CPU, memory and process limits (flags > project > config)
+		resources := eff.Resources.Apply(runResources)
+		if err := resources.Validate(); err != nil {
+			return err
+		}
+
+		// Command time limit (flag > project > config)
+		timeout, err := resolveTimeout(runTimeout, eff.Timeout)
+		if err != nil {
+			return err
+		}
+
+		credentialProviders, err := enabledCredentialProviders(cfg.CredentialProviders, runCredProviders)
+		if err != nil {
+			return err
+		}
+
+		if !config.ValidUntrustedImagePolicy(cfg.UntrustedImagePolicy) {
+			return fmt.Errorf("invalid untrusted_image_policy '%s' in config (valid: warn, prompt, refuse)", cfg.UntrustedImagePolicy)
 		}
 
 		// Determine which runtime to use (flag > config > detect)
@@ -112,6 +275,48 @@ var runCmd = &cobra.Command{
 			runtime = cfg.ContainerRuntime
 		}
 
+		// Remote mode: the docker CLI reaches the remote daemon over ssh
+		remoteHost, runtime, err := resolveRemoteHost(runHost, docker.DaemonHost(), runtime)
+		if err != nil {
+			return err
+		}
+		if remoteHost != "" {
+			_ = os.Setenv("DOCKER_HOST", remote.Host{Target: remoteHost}.DockerHost())
+		}
+		// Other remote daemons (tcp) get a copy of the workspace in volumes instead
+		remoteDaemon := remoteDaemonHost(remoteHost, runtime, docker.DaemonHost())
+
+		command, then, err := splitSteps(args)
+		if err != nil {
+			return err
+		}
+		if len(then) > 0 && runRemove {
+			return fmt.Errorf("--then can't be used with --rm")
+		}
+		if len(then) > 0 && runRecord != "" {
+			return fmt.Errorf("--then can't be used with --record")
+		}
+
+		// A --rm container is never reconnected to, and remote runs copy files into a running container
+		if runRemove && runReconnect {
+			return fmt.Errorf("--rm and --reconnect can't be used together")
+		}
+		if runRemove && (remoteHost != "" || remoteDaemon != "") {
+			return fmt.Errorf("--rm is not supported with a remote host")
+		}
+		// The copy's changes are exported to the local workspace before the container goes
+		if runCopyWorkspace && runRemove {
+			return fmt.Errorf("--copy-workspace can't be used with --rm: the changes would be removed before they could be exported")
+		}
+		if runCopyWorkspace && remoteHost != "" {
+			return fmt.Errorf("--copy-workspace is not supported with a remote host")
+		}
+		if remoteHost != "" {
+			if err := checkRemoteHostSupport(remoteHost, eff.Mounts, sharedCache); err != nil {
+				return err
+			}
+		}
+
 		// Apply environment configuration if specified
 		var configEnv []string
 		if runConfig != "" {
@@ -122,6 +327,97 @@ var runCmd = &cobra.Command{
 			}
 		}
 
+		// Determine how ~/.claude is shared (flag > config > full)
+		claudeSync := cfg.ClaudeSync
+		if runClaudeSync != "" {
+			claudeSync = runClaudeSync
+		}
+		if !config.ValidClaudeSyncMode(claudeSync) {
+			return fmt.Errorf("invalid claude sync mode '%s' (valid: full, copy, selective)", claudeSync)
+		}
+
+		// Network isolation (flags > config > open); --allow-domain adds to the configured allowlist
+		network := cfg.Network
+		if runNoNetwork {
+			if len(runAllowDomains) > 0 {
+				return fmt.Errorf("--no-network and --allow-domain cannot be combined")
+			}
+			network.Mode = config.NetworkNone
+		} else if len(runAllowDomains) > 0 {
+			network.Mode = config.NetworkAllowlist
+			network.Allow = append(append([]string{}, network.Allow...), runAllowDomains...)
+		}
+		if err := network.Validate(); err != nil {
+			return fmt.Errorf("invalid network settings: %w", err)
+		}
+
+		// Docker access (flag > project > global; unset defers to devcontainer features)
+		dockerAccess := cfg.Docker
+		dockerAccess.Mode = eff.Docker
+		if runDocker != "" {
+			dockerAccess.Mode = runDocker
+		}
+		if err := dockerAccess.Validate(); err != nil {
+			return fmt.Errorf("invalid docker setting: %w", err)
+		}
+
+		// Check the commit message template now rather than when the run is over
+		autoCommit := runAutoCommit || cfg.AutoCommit
+		if autoCommit {
+			if _, err := config.ParseAutoCommitMessage(cfg.AutoCommitMessage); err != nil {
+				return err
+			}
+		}
+
+		// Save session output to the container's log file (flag or config)
+		logCapture := cfg.LogCapture
+		logCapture.Enabled = logCapture.Enabled || runCaptureLogs
+		if logCapture.Enabled {
+			if err := logCapture.Validate(); err != nil {
+				return fmt.Errorf("invalid log_capture settings: %w", err)
+			}
+		}
+
+		// Mint short-lived gh and AWS tokens instead of passing the host's (flag or config)
+		var scopedCreds *config.ScopedCredentialsConfig
+		if runScopedCreds || cfg.ScopedCredentials.Enabled {
+			if err := cfg.ScopedCredentials.Validate(); err != nil {
+				return fmt.Errorf("invalid scoped_credentials settings: %w", err)
+			}
+			scopedCreds = &cfg.ScopedCredentials
+		}
+
+		// Registry cache for devcontainer builds (flags > project > global)
+		buildCache := eff.BuildCache
+		if cmd.Flags().Changed("cache-from") {
+			buildCache.From = runCacheFrom
+		}
+		if cmd.Flags().Changed("cache-to") {
+			buildCache.To = runCacheTo
+		}
+
+		// Determine the exit summary for managed runs (flag > config > short)
+		summary := cfg.Summary
+		if runSummary != "" {
+			summary = runSummary
+		}
+		if !config.ValidSummaryMode(summary) {
+			return fmt.Errorf("invalid summary '%s' (valid: off, short, full)", summary)
+		}
+
+		// Select a default image variant (flag > config)
+		if runImageVariant != "" {
+			if !config.ValidImageVariant(runImageVariant) {
+				return fmt.Errorf("invalid image variant '%s' (valid: %s)", runImageVariant, strings.Join(config.DefaultImageVariants, ", "))
+			}
+			cfg.DefaultContainer.Variant = runImageVariant
+			eff.Image = cfg.GetDefaultImage()
+		}
+
+		if !config.ValidClaudeJSONSyncMode(cfg.ClaudeJSONSync) {
+			return fmt.Errorf("invalid claude_json_sync '%s' in config (valid: off, host, container)", cfg.ClaudeJSONSync)
+		}
+
 		// Determine host path for labels
 		hostPath := runPath
 		if hostPath == "" {
@@ -141,25 +437,81 @@ var runCmd = &cobra.Command{
 		launchCommand := strings.Join(os.Args, " ")
 
 		runConfig := &runner.RunConfig{
-			Path:           runPath,
-			Worktree:       runWorktree,
-			NoWorktree:     runNoWorktree,
-			Env:            append(runEnv, configEnv...), // Merge user env vars with config env vars
-			Verbose:        runVerbose,
-			Runtime:        runtime,
-			Reconnect:      runReconnect,
-			DefaultImage:   cfg.DefaultImage,
-			Command:        args,
-			Credentials:    creds,
-			DefaultEnvVars: cfg.DefaultEnvVars,
-			PublishPorts:   runPublishPorts,
-			HostPath:       hostPath,
-			LaunchCommand:  launchCommand,
+			Path:                runPath,
+			Worktree:            runWorktree,
+			NoWorktree:          runNoWorktree,
+			Env:                 append(append(withoutSecretReferences(eff.Env, config.GetProjectConfigPath(projectDir)), runEnv...), configEnv...), // Project env, then user and profile env vars
+			Verbose:             runVerbose,
+			Runtime:             runtime,
+			Reconnect:           runReconnect,
+			DefaultImage:        eff.Image,
+			Command:             command,
+			Then:                then,
+			Credentials:         creds,
+			DefaultEnvVars:      cfg.DefaultEnvVars,
+			PublishPorts:        append(append([]string{}, eff.PublishPorts...), runPublishPorts...),
+			Mounts:              eff.Mounts,
+			AWSProfile:          runAWSProfile,
+			AzureToken:          runAzureToken,
+			CredentialProviders: credentialProviders,
+			GroupAdd:            append(append([]string{}, eff.GroupAdd...), runGroupAdd...),
+			Labels:              eff.Labels,
+			SharedCache:         sharedCache,
+			SharedCacheMax:      sharedCacheMax,
+			DependencyCaches:    dependencyCaches,
+			NoForwardPorts:      runNoForwardPorts,
+			Ulimits:             ulimits,
+			Resources:           resources,
+			SkipPreflight:       cfg.SkipPreflight,
+			ManagedExec:         runManagedExec,
+			RecordFile:          runRecord,
+			Timeout:             timeout,
+			StopOnTimeout:       runStopOnTimeout || cfg.StopOnTimeout,
+			Events:              runEvents,
+			RemoteHost:          remoteHost,
+			RemoteDaemon:        remoteDaemon,
+			CopyWorkspace:       runCopyWorkspace,
+			Ephemeral:           runRemove,
+			DryRun:              runDryRun,
+			Summary:             summary,
+			Network:             network,
+			Docker:              dockerAccess,
+			Services:            eff.Services,
+			UntrustedWorkspace:  untrustedWorkspace,
+			AutoCommit:          autoCommit,
+			StrictCreds:         runStrictCreds || cfg.StrictCreds,
+			SELinuxLabelDisable: runSELinuxLabels || cfg.SELinuxLabelDisable,
+			ScopedCreds:         scopedCreds,
+			BuildSecrets:        runBuildSecrets,
+			BuildSSH:            runBuildSSH,
+			BuildCache:          buildCache,
+			AutoCommitMessage:   cfg.AutoCommitMessage,
+			LogCapture:          logCapture,
+			// The user's own default image is trusted; a project's image is not
+			TrustedImages:        append(append([]string{}, cfg.TrustedImages...), cfg.GetDefaultImage()),
+			UntrustedImagePolicy: cfg.UntrustedImagePolicy,
+			HostPath:             hostPath,
+			LaunchCommand:        launchCommand,
+			ClaudeSync:           claudeSync,
+			ClaudeJSONSync:       cfg.ClaudeJSONSync,
+			MCPServers:           cfg.MCPServers,
+		}
+
+		// Offer to clean up after an earlier run that was killed halfway through setting up
+		if !runDryRun {
+			runner.RecoverInterrupted(runVerbose)
 		}
 
 		if err := runner.Run(runConfig); err != nil {
+			// The command itself failed; its output already explains why
+			var exitErr *runner.ExitError
+			if errors.As(err, &exitErr) {
+				os.Exit(exitErr.Code)
+			}
+			runEvents.Emit(events.Event{Event: events.Error, Error: err.Error()})
 			// Print error without extra formatting since our error messages are already well-formatted
 			fmt.Fprintln(os.Stderr, err.Error())
+			printDockerHint(err)
 			// Return non-nil error to set exit code, but silence Cobra error handling
 			os.Exit(1)
 		}
@@ -182,17 +534,64 @@ func init() {
 	runCmd.Flags().StringArrayVarP(&runPublishPorts, "publish", "p", []string{}, "Publish container port(s) to host (format: [hostIP:]hostPort:containerPort[/protocol])")
 	runCmd.Flags().StringVar(&runRuntime, "runtime", "", "Container runtime to use (docker/podman/container)")
 	runCmd.Flags().StringVar(&runConfig, "config", "", "API config profile (anthropic, z.ai, anthropic-work, claude-personal)")
+	runCmd.Flags().StringVar(&runClaudeSync, "claude-sync", "", "How ~/.claude is shared: full (read-write), copy (isolated copy), selective (settings read-only)")
+	runCmd.Flags().StringVar(&runImageVariant, "image-variant", "", "Default image variant when no devcontainer is found (full, slim, python, go)")
+	runCmd.Flags().StringArrayVar(&runGroupAdd, "group-add", []string{}, "Add the container user to a supplemental group (name or GID, repeatable)")
+	runCmd.Flags().StringVar(&runRecord, "record", "", "Record the session to an asciicast file (replay with asciinema play)")
+	runCmd.Flags().StringVar(&runTimeout, "timeout", "", "Stop the command after this long (e.g. 30m, 2h; 0 disables a configured timeout)")
+	runCmd.Flags().BoolVar(&runStopOnTimeout, "stop-on-timeout", false, "Also stop the container when --timeout is exceeded")
+	runCmd.Flags().StringVar(&runSummary, "summary", "", "What to print when a managed run exits: off, short (default), or full (also lists changed files)")
+	runCmd.Flags().BoolVar(&runAutoCommit, "auto-commit", false, "Commit the worktree's changes to its branch when the command exits")
+	runCmd.Flags().BoolVar(&runCaptureLogs, "capture-logs", false, "Save the session's output to ~/.local/share/packnplay/logs/<container>.log")
+	runCmd.Flags().BoolVar(&runJSONEvents, "json-events", false, "Write lifecycle events as JSON lines to fd 3 if open, otherwise stdout")
+	runCmd.Flags().BoolVar(&runManagedExec, "managed-exec", false, "Run docker exec as a child process instead of replacing packnplay")
+	_ = runCmd.Flags().MarkHidden("managed-exec")
+	runCmd.Flags().StringArrayVar(&runUlimits, "ulimit", []string{}, "Container ulimit (name=soft[:hard], e.g. nofile=65536:65536, repeatable)")
+	runCmd.Flags().StringVar(&runResources.CPUs, "cpus", "", "Number of CPUs the container may use (e.g. 2 or 1.5)")
+	runCmd.Flags().StringVar(&runResources.Memory, "memory", "", "Memory limit (e.g. 4g or 512m)")
+	runCmd.Flags().StringVar(&runResources.MemorySwap, "memory-swap", "", "Memory plus swap limit (e.g. 8g, or -1 for unlimited swap)")
+	runCmd.Flags().Int64Var(&runResources.PidsLimit, "pids-limit", 0, "Maximum number of processes in the container (-1 for unlimited)")
+	runCmd.Flags().BoolVar(&runNoNetwork, "no-network", false, "Run the container without any network access")
+	runCmd.Flags().StringArrayVar(&runAllowDomains, "allow-domain", []string{}, "Only allow network access to this domain, through an egress proxy (*.example.com for subdomains, repeatable)")
+	runCmd.Flags().StringVar(&runDocker, "docker", "", "Give the container a docker daemon: dind (rootless docker-in-docker sidecar, the default for a bare --docker), socket (mount the host's daemon socket), or off")
+	runCmd.Flags().Lookup("docker").NoOptDefVal = "dind"
+	runCmd.Flags().BoolVar(&runNoForwardPorts, "no-forward-ports", false, "Don't publish devcontainer.json forwardPorts to the host")
+	runCmd.Flags().BoolVar(&runSharedCache, "shared-cache", false, "Mount the cross-project download cache at "+cache.ContainerPath)
+	runCmd.Flags().BoolVar(&runDependencyCaches, "dependency-caches", false, "Mount named volumes over package manager caches (~/.npm, ~/.cache/go-build, ...)")
+	runCmd.Flags().StringVar(&runHost, "host", "", "Run on a remote docker host over SSH (user@server)")
+	runCmd.Flags().BoolVar(&runStrictCreds, "strict-creds", false, "Fail instead of warning when a requested credential can't be provided")
+	runCmd.Flags().BoolVar(&runSELinuxLabels, "selinux-label-disable", false, "Turn off SELinux label separation so the container can read credentials packnplay won't relabel")
+	runCmd.Flags().StringArrayVar(&runBuildSecrets, "build-secret", nil, "Pass a secret to the devcontainer image build (id=name,src=path or id=name,env=VAR), for RUN --mount=type=secret")
+	runCmd.Flags().StringArrayVar(&runBuildSSH, "build-ssh", nil, "Pass an SSH agent or keys to the devcontainer image build (default, or id=path), for RUN --mount=type=ssh")
+	runCmd.Flags().StringArrayVar(&runCacheFrom, "cache-from", nil, "Use a registry cache for the devcontainer image build (e.g. type=registry,ref=ghcr.io/team/app:buildcache)")
+	runCmd.Flags().StringVar(&runCacheTo, "cache-to", "", "Export the devcontainer image build's cache (e.g. type=registry,ref=ghcr.io/team/app:buildcache,mode=max)")
+	runCmd.Flags().BoolVar(&runScopedCreds, "scoped-creds", false, "Pass short-lived gh and AWS tokens minted on the host instead of long-lived credentials")
 	runCmd.Flags().BoolVar(&runReconnect, "reconnect", false, "Reconnect to existing container instead of failing")
+	runCmd.Flags().BoolVar(&runRemove, "rm", false, "Run in a throwaway container that is removed when the command exits")
+	runCmd.Flags().BoolVar(&runCopyWorkspace, "copy-workspace", false, "Work on a copy of the workspace in a volume instead of the host files; packnplay export applies the changes")
+	runCmd.Flags().BoolVar(&runDryRun, "dry-run", false, "Print the docker commands (secrets redacted) instead of running them")
 	runCmd.Flags().BoolVar(&runVerbose, "verbose", false, "Show all docker/git commands")
 
 	// Credential flags (use pointers so we can detect if they were explicitly set)
 	runGitCreds = runCmd.Flags().Bool("git-creds", false, "Mount git config (~/.gitconfig)")
 	runSSHCreds = runCmd.Flags().Bool("ssh-creds", false, "Mount SSH keys (~/.ssh)")
+	runSSHAgent = runCmd.Flags().Bool("ssh-agent", false, "Forward the host ssh-agent instead of mounting keys")
 	runGHCreds = runCmd.Flags().Bool("gh-creds", false, "Mount GitHub CLI credentials")
 	runGPGCreds = runCmd.Flags().Bool("gpg-creds", false, "Mount GPG credentials for commit signing")
+	runGPGAgent = runCmd.Flags().Bool("gpg-agent", false, "Forward the host gpg-agent for commit signing instead of mounting ~/.gnupg")
 	runNPMCreds = runCmd.Flags().Bool("npm-creds", false, "Mount npm credentials")
 	runAWSCreds = runCmd.Flags().Bool("aws-creds", false, "Mount AWS credentials")
+	runCmd.Flags().StringVar(&runAWSProfile, "aws-profile", "", "AWS profile to use for credentials (overrides AWS_PROFILE, implies --aws-creds)")
+	runGCPCreds = runCmd.Flags().Bool("gcp-creds", false, "Mount Google Cloud credentials (~/.config/gcloud)")
+	runAzureCreds = runCmd.Flags().Bool("azure-creds", false, "Mount Azure CLI credentials (~/.azure)")
+	runCmd.Flags().BoolVar(&runAzureToken, "azure-token", false, "Also inject a short-lived access token from the host's az login (implies --azure-creds)")
+	runKubeCreds = runCmd.Flags().Bool("kube-creds", false, "Mount the kubeconfig read-only, resolving exec auth plugins on the host")
+	runCmd.Flags().StringSliceVar(&runCredProviders, "cred-provider", []string{}, "Enable a credential provider defined in config (repeatable)")
 	runCmd.Flags().BoolVar(&runAllCreds, "all-creds", false, "Mount all available credentials")
+
+	// restart takes the same flags, so the new container can be set up differently
+	restartCmd.Flags().SetInterspersed(false)
+	restartCmd.Flags().AddFlagSet(runCmd.Flags())
 }
 
 // ensureCredentialWatcher starts the credential sync daemon if not already running
@@ -229,6 +628,126 @@ func isWatcherRunning() bool {
 	return err == nil
 }
 
+// enabledCredentialProviders returns the configured credential providers to use for this
+// run: those enabled in config plus any requested by name
+func enabledCredentialProviders(configured map[string]config.CredentialProviderConfig, requested []string) (map[string]config.CredentialProviderConfig, error) {
+	enabled := make(map[string]config.CredentialProviderConfig)
+	for name, provider := range configured {
+		if err := config.ValidateCredentialProviderName(name); err != nil {
+			return nil, err
+		}
+		if provider.Enabled {
+			enabled[name] = provider
+		}
+	}
+	for _, name := range requested {
+		provider, exists := configured[name]
+		if !exists {
+			return nil, fmt.Errorf("credential provider '%s' not found in config file", name)
+		}
+		enabled[name] = provider
+	}
+	return enabled, nil
+}
+
+// stepSeparator separates the commands of a multi-step run
+const stepSeparator = "--then"
+
+// splitSteps splits the run arguments into the first command and the commands to run
+// after it, separated by --then
+func splitSteps(args []string) ([]string, [][]string, error) {
+	var steps [][]string
+	step := []string{}
+	for _, arg := range args {
+		if arg == stepSeparator {
+			steps = append(steps, step)
+			step = []string{}
+			continue
+		}
+		step = append(step, arg)
+	}
+	steps = append(steps, step)
+
+	for _, step := range steps {
+		if len(step) == 0 {
+			return nil, nil, fmt.Errorf("empty command around %s", stepSeparator)
+		}
+	}
+	return steps[0], steps[1:], nil
+}
+
+// remoteDaemonHost returns the daemon address when docker talks to a remote daemon
+// that isn't reached over ssh, so host paths can't be bind mounted
+func remoteDaemonHost(remoteHost, runtime, daemonHost string) string {
+	if remoteHost != "" || (runtime != "" && runtime != "docker") || !docker.IsRemoteHost(daemonHost) {
+		return ""
+	}
+	return daemonHost
+}
+
+// resolveRemoteHost returns the ssh target for remote mode (--host, or an ssh:// DOCKER_HOST)
+// and the runtime to use. Remote mode relies on the docker CLI's ssh support.
+func resolveRemoteHost(flag, dockerHost, runtime string) (string, string, error) {
+	target := flag
+	if target == "" {
+		target = remote.TargetFromDockerHost(dockerHost)
+	}
+	if target == "" {
+		return "", runtime, nil
+	}
+
+	if err := remote.ValidateTarget(target); err != nil {
+		return "", "", err
+	}
+	if runtime != "" && runtime != "docker" {
+		return "", "", fmt.Errorf("remote hosts require the docker runtime (configured: %s)", runtime)
+	}
+	return target, "docker", nil
+}
+
+// checkRemoteHostSupport rejects settings that bind mount local directories, which the
+// remote host doesn't have, rather than running without them
+func checkRemoteHostSupport(remoteHost string, mounts []string, sharedCache bool) error {
+	if len(mounts) > 0 {
+		return fmt.Errorf("the project's mounts (%s) can't be used on remote host %s: the paths are local", strings.Join(mounts, ", "), remoteHost)
+	}
+	if sharedCache {
+		return fmt.Errorf("the shared cache can't be used on remote host %s: it is a local directory; pass --shared-cache=false", remoteHost)
+	}
+	return nil
+}
+
+// resolveTimeout returns the first timeout that is set, in precedence order. "0" disables
+// the timeout without falling through to lower-precedence values.
+func resolveTimeout(values ...string) (time.Duration, error) {
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			return 0, fmt.Errorf("invalid timeout '%s' (use a duration like 30m or 2h)", value)
+		}
+		return timeout, nil
+	}
+	return 0, nil
+}
+
+// withoutSecretReferences drops the entries of env, read from a repository's source file,
+// whose value refers to a secret manager. Only the user's own config and flags may fetch
+// secrets; a cloned repository could otherwise ask for anything in their vaults.
+func withoutSecretReferences(env []string, source string) []string {
+	kept := make([]string, 0, len(env))
+	for _, entry := range env {
+		if key, value, _ := strings.Cut(entry, "="); secrets.IsReference(value) {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring %s from %s: secret references are only resolved from your own config and --env\n", key, source)
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept
+}
+
 // applyEnvConfig processes environment configuration and returns env var array
 func applyEnvConfig(envConfig config.EnvConfig) []string {
 	var envVars []string
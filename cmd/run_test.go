@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"os"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/obra/packnplay/pkg/config"
 )
@@ -146,4 +148,188 @@ func TestApplyEnvConfig(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestRunAWSProfileFlag(t *testing.T) {
+	runAWSProfile = ""
+	defer func() { runAWSProfile = "" }()
+
+	if err := runCmd.ParseFlags([]string{"--aws-profile", "staging"}); err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+	if runAWSProfile != "staging" {
+		t.Errorf("runAWSProfile = %q, want %q", runAWSProfile, "staging")
+	}
+}
+
+func TestEnabledCredentialProviders(t *testing.T) {
+	configured := map[string]config.CredentialProviderConfig{
+		"vault":   {Command: "vault-creds", Enabled: true},
+		"onepass": {Command: "op-creds"},
+	}
+
+	enabled, err := enabledCredentialProviders(configured, nil)
+	if err != nil {
+		t.Fatalf("enabledCredentialProviders() error = %v", err)
+	}
+	if _, ok := enabled["vault"]; !ok || len(enabled) != 1 {
+		t.Errorf("enabled = %v, want only vault", enabled)
+	}
+
+	enabled, err = enabledCredentialProviders(configured, []string{"onepass"})
+	if err != nil {
+		t.Fatalf("enabledCredentialProviders() error = %v", err)
+	}
+	if len(enabled) != 2 {
+		t.Errorf("enabled = %v, want vault and onepass", enabled)
+	}
+
+	if _, err := enabledCredentialProviders(configured, []string{"missing"}); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+
+	configured["kube"] = config.CredentialProviderConfig{Command: "kube-creds"}
+	if _, err := enabledCredentialProviders(configured, nil); err == nil || !strings.Contains(err.Error(), "built-in") {
+		t.Errorf("enabledCredentialProviders() error = %v, want the kube provider refused", err)
+	}
+}
+
+func TestResolveTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"none set", []string{"", "", ""}, 0, false},
+		{"flag wins", []string{"30m", "1h", "2h"}, 30 * time.Minute, false},
+		{"project over global", []string{"", "1h", "2h"}, time.Hour, false},
+		{"global only", []string{"", "", "2h"}, 2 * time.Hour, false},
+		{"zero disables", []string{"0", "", "2h"}, 0, false},
+		{"invalid", []string{"soon"}, 0, true},
+		{"negative", []string{"-5m"}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveTimeout(tt.values...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveTimeout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitSteps(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCmd  []string
+		wantThen [][]string
+		wantErr  bool
+	}{
+		{"single command", []string{"npm", "test"}, []string{"npm", "test"}, nil, false},
+		{"steps", []string{"npm", "ci", "--then", "npm", "test", "--then", "claude"}, []string{"npm", "ci"}, [][]string{{"npm", "test"}, {"claude"}}, false},
+		{"quoted separator stays", []string{"sh", "-c", "echo --then"}, []string{"sh", "-c", "echo --then"}, nil, false},
+		{"trailing separator", []string{"npm", "ci", "--then"}, nil, nil, true},
+		{"leading separator", []string{"--then", "npm", "ci"}, nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, then, err := splitSteps(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitSteps() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(cmd, tt.wantCmd) || len(then) != len(tt.wantThen) || (len(then) > 0 && !reflect.DeepEqual(then, tt.wantThen)) {
+				t.Errorf("splitSteps() = %q, %q, want %q, %q", cmd, then, tt.wantCmd, tt.wantThen)
+			}
+		})
+	}
+}
+
+func TestResolveRemoteHost(t *testing.T) {
+	tests := []struct {
+		name        string
+		flag        string
+		dockerHost  string
+		runtime     string
+		wantHost    string
+		wantRuntime string
+		wantErr     bool
+	}{
+		{"local", "", "", "podman", "", "podman", false},
+		{"tcp docker host stays local", "", "tcp://10.0.0.5:2376", "", "", "", false},
+		{"flag", "dev@build-01", "", "", "dev@build-01", "docker", false},
+		{"ssh docker host", "", "ssh://dev@build-01", "docker", "dev@build-01", "docker", false},
+		{"flag wins over docker host", "ci@build-02", "ssh://dev@build-01", "", "ci@build-02", "docker", false},
+		{"podman unsupported", "dev@build-01", "", "podman", "", "", true},
+		{"invalid target", "-oProxyCommand=x", "", "", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, runtime, err := resolveRemoteHost(tt.flag, tt.dockerHost, tt.runtime)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveRemoteHost() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if host != tt.wantHost || runtime != tt.wantRuntime {
+				t.Errorf("resolveRemoteHost() = %q, %q; want %q, %q", host, runtime, tt.wantHost, tt.wantRuntime)
+			}
+		})
+	}
+}
+
+func TestCheckRemoteHostSupport(t *testing.T) {
+	if err := checkRemoteHostSupport("dev@build-01", nil, false); err != nil {
+		t.Errorf("checkRemoteHostSupport() error = %v, want none", err)
+	}
+	if err := checkRemoteHostSupport("dev@build-01", []string{"/data:/data:ro"}, false); err == nil || !strings.Contains(err.Error(), "/data:/data:ro") {
+		t.Errorf("checkRemoteHostSupport(mounts) error = %v, want the mounts named", err)
+	}
+	if err := checkRemoteHostSupport("dev@build-01", nil, true); err == nil {
+		t.Error("checkRemoteHostSupport(shared cache) should fail")
+	}
+}
+
+func TestRemoteDaemonHost(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteHost string
+		runtime    string
+		daemonHost string
+		want       string
+	}{
+		{"local socket", "", "", "unix:///var/run/docker.sock", ""},
+		{"loopback tcp", "", "docker", "tcp://127.0.0.1:2375", ""},
+		{"remote tcp", "", "", "tcp://10.0.0.5:2376", "tcp://10.0.0.5:2376"},
+		{"ssh host clones the repo instead", "dev@build-01", "docker", "ssh://dev@build-01", ""},
+		{"podman", "", "podman", "tcp://10.0.0.5:2376", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := remoteDaemonHost(tt.remoteHost, tt.runtime, tt.daemonHost); got != tt.want {
+				t.Errorf("remoteDaemonHost() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithoutSecretReferences(t *testing.T) {
+	env := []string{
+		"NODE_ENV=test",
+		"ANTHROPIC_API_KEY=op://Private/Anthropic/credential",
+		"DEPLOY_TOKEN=vault://secret/deploy#token",
+		"DEBUG",
+	}
+	got := withoutSecretReferences(env, ".packnplay.json")
+	if want := []string{"NODE_ENV=test", "DEBUG"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("withoutSecretReferences() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/runner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	shellPath       string
+	shellWorktree   string
+	shellNoWorktree bool
+	shellRuntime    string
+	shellVerbose    bool
+)
+
+var shellCmd = &cobra.Command{
+	Use:   "shell [flags]",
+	Short: "Open a shell in the container for the current directory",
+	Long: `Open an interactive shell in the container for the current directory and branch,
+starting the container first if it isn't running. The shell is the container user's
+login shell, falling back to bash and then sh.`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Runtime: flag > config (detected when neither is set)
+		runtime := shellRuntime
+		if runtime == "" {
+			if cfg, err := config.LoadWithoutRuntimeCheck(); err == nil {
+				runtime = cfg.ContainerRuntime
+			}
+		}
+
+		err := runner.Exec(&runner.ExecConfig{
+			Path:       shellPath,
+			Worktree:   shellWorktree,
+			NoWorktree: shellNoWorktree,
+			Runtime:    runtime,
+			Command:    runner.DefaultShellCommand,
+			Verbose:    shellVerbose,
+		})
+		if errors.Is(err, runner.ErrContainerNotRunning) {
+			// Start it the way run would, with all configured credentials and mounts
+			executable, exeErr := os.Executable()
+			if exeErr != nil {
+				return fmt.Errorf("failed to get executable path: %w", exeErr)
+			}
+			if shellVerbose {
+				fmt.Fprintf(os.Stderr, "No running container, starting one\n")
+			}
+			err = syscall.Exec(executable, shellRunArgs(), os.Environ())
+		}
+		if err != nil {
+			var exitErr *runner.ExitError
+			if errors.As(err, &exitErr) {
+				os.Exit(exitErr.Code)
+			}
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+// shellRunArgs returns the "packnplay run" invocation that starts the container with a shell
+func shellRunArgs() []string {
+	args := []string{"packnplay", "run"}
+	if shellPath != "" {
+		args = append(args, "--path", shellPath)
+	}
+	if shellWorktree != "" {
+		args = append(args, "--worktree", shellWorktree)
+	}
+	if shellNoWorktree {
+		args = append(args, "--no-worktree")
+	}
+	if shellRuntime != "" {
+		args = append(args, "--runtime", shellRuntime)
+	}
+	if shellVerbose {
+		args = append(args, "--verbose")
+	}
+	return append(args, runner.DefaultShellCommand...)
+}
+
+func init() {
+	rootCmd.AddCommand(shellCmd)
+
+	shellCmd.Flags().StringVar(&shellPath, "path", "", "Project path (default: pwd)")
+	shellCmd.Flags().StringVar(&shellWorktree, "worktree", "", "Worktree name (default: current branch)")
+	shellCmd.Flags().BoolVar(&shellNoWorktree, "no-worktree", false, "Use the current directory without a worktree")
+	shellCmd.Flags().StringVar(&shellRuntime, "runtime", "", "Container runtime to use (docker/podman/container)")
+	shellCmd.Flags().BoolVar(&shellVerbose, "verbose", false, "Show all docker commands")
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/runner"
+)
+
+func TestShellRunArgs(t *testing.T) {
+	defer func() {
+		shellPath, shellWorktree, shellNoWorktree, shellRuntime = "", "", false, ""
+	}()
+
+	if got := shellRunArgs(); !reflect.DeepEqual(got, append([]string{"packnplay", "run"}, runner.DefaultShellCommand...)) {
+		t.Errorf("shellRunArgs() = %v", got)
+	}
+
+	shellPath, shellWorktree, shellRuntime = "/src/app", "feature", "podman"
+	want := append([]string{"packnplay", "run", "--path", "/src/app", "--worktree", "feature", "--runtime", "podman"}, runner.DefaultShellCommand...)
+	if got := shellRunArgs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("shellRunArgs() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/runner"
+	"github.com/obra/packnplay/pkg/sshserver"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sshServerPath       string
+	sshServerWorktree   string
+	sshServerNoWorktree bool
+	sshServerRuntime    string
+	sshServerPort       int
+	sshServerStop       bool
+	sshServerVerbose    bool
+)
+
+var sshServerCmd = &cobra.Command{
+	Use:   "ssh-server",
+	Short: "Start an SSH server in the running container for editor remote attach",
+	Long: `Start an SSH server in the container already running for the current worktree, so
+VS Code Remote-SSH, JetBrains Gateway or plain ssh can connect into the sandbox.
+
+OpenSSH (or dropbear, when the image has it) is installed if the image has neither.
+The server only accepts a key generated for this container, and is published on a
+port of 127.0.0.1. The command prints a Host block to add to ~/.ssh/config; running
+it again replaces the keys, and "packnplay stop" or --stop removes them.`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Runtime: flag > config (detected when neither is set)
+		runtime := sshServerRuntime
+		if runtime == "" {
+			if cfg, err := config.LoadWithoutRuntimeCheck(); err == nil {
+				runtime = cfg.ContainerRuntime
+			}
+		}
+
+		endpoint, err := runner.SSHServer(&runner.SSHServerConfig{
+			Path:       sshServerPath,
+			Worktree:   sshServerWorktree,
+			NoWorktree: sshServerNoWorktree,
+			Runtime:    runtime,
+			Port:       sshServerPort,
+			Stop:       sshServerStop,
+			Verbose:    sshServerVerbose,
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		if endpoint == nil {
+			fmt.Println("SSH server stopped")
+			return nil
+		}
+		printSSHEndpoint(endpoint)
+		return nil
+	},
+}
+
+// printSSHEndpoint shows the Host block and how to connect with it
+func printSSHEndpoint(e *sshserver.Endpoint) {
+	fmt.Printf("SSH server (%s) listening on 127.0.0.1:%d\n\n", e.Server, e.Port)
+	fmt.Printf("Add this to ~/.ssh/config:\n\n%s\n", e.HostBlock())
+	fmt.Printf("Then connect with:\n")
+	fmt.Printf("  ssh %s\n", e.Alias)
+	fmt.Printf("  code --remote ssh-remote+%s %s    (VS Code Remote-SSH)\n", e.Alias, e.Dir)
+	fmt.Printf("  JetBrains Gateway: New Connection, host %s, project %s\n", e.Alias, e.Dir)
+}
+
+func init() {
+	rootCmd.AddCommand(sshServerCmd)
+
+	sshServerCmd.Flags().StringVar(&sshServerPath, "path", "", "Project path (default: pwd)")
+	sshServerCmd.Flags().StringVar(&sshServerWorktree, "worktree", "", "Worktree name (default: current branch)")
+	sshServerCmd.Flags().BoolVar(&sshServerNoWorktree, "no-worktree", false, "Use the container started with --no-worktree")
+	sshServerCmd.Flags().StringVar(&sshServerRuntime, "runtime", "", "Container runtime to use (docker/podman/container)")
+	sshServerCmd.Flags().IntVar(&sshServerPort, "port", 0, "Host port to publish the server on (default: a free port)")
+	sshServerCmd.Flags().BoolVar(&sshServerStop, "stop", false, "Stop the SSH server and remove its keys")
+	sshServerCmd.Flags().BoolVar(&sshServerVerbose, "verbose", false, "Show all docker commands")
+}
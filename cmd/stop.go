@@ -1,14 +1,13 @@
 package cmd
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
-	"github.com/obra/packnplay/pkg/container"
 	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/runner"
 	"github.com/spf13/cobra"
 )
 
@@ -16,12 +15,22 @@ var (
 	stopPath     string
 	stopWorktree string
 	stopAll      bool
+	stopDiscard  bool
 )
 
 var stopCmd = &cobra.Command{
 	Use:   "stop [container_name] [flags]",
 	Short: "Stop container",
-	Long:  `Stop the container by name, or for the specified project/worktree.`,
+	Long: `Stop the container by name, or for the specified project/worktree.
+
+Without a name or --worktree, the container for the current branch is stopped, as
+run would pick it. When the branch has none, the project's running containers are
+listed to pick from, even when there is only one; without a terminal, the list is an
+error instead.
+
+A container started with --copy-workspace, or on a remote daemon the workspace is synced
+to, isn't stopped while its copy has changes packnplay export hasn't applied, since
+removing it removes them. --discard removes it anyway.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Initialize Docker client
 		dockerClient, err := docker.NewClient(false)
@@ -37,7 +46,7 @@ var stopCmd = &cobra.Command{
 		// If container name provided as argument, use that
 		if len(args) > 0 {
 			containerName := args[0]
-			return stopContainer(dockerClient, containerName)
+			return stopContainer(dockerClient, containerName, stopDiscard)
 		}
 
 		// Otherwise, use worktree-based approach
@@ -56,64 +65,81 @@ var stopCmd = &cobra.Command{
 			return fmt.Errorf("failed to resolve path: %w", err)
 		}
 
-		// Determine worktree name
-		worktreeName := stopWorktree
-		if worktreeName == "" {
-			return fmt.Errorf("container name or --worktree flag is required for stop (or use --all)")
+		if stopWorktree != "" {
+			return stopContainer(dockerClient, runner.ContainerName(dockerClient, workDir, stopWorktree), stopDiscard)
 		}
 
-		// Generate container name
-		containerName := container.GenerateContainerName(workDir, worktreeName)
+		// Without --worktree, the current branch's container, as run would use
+		existing, findErr := runner.FindContainer(workDir, "", false, "", false)
+		if findErr == nil {
+			return stopContainer(existing.Client, existing.Name, stopDiscard)
+		}
 
-		// Stop and remove container
-		return stopContainer(dockerClient, containerName)
+		// Otherwise one of the project's containers: detached HEAD, or a container
+		// started with --worktree from the main checkout
+		containers, err := runner.ProjectContainers(dockerClient, workDir)
+		if err != nil {
+			return err
+		}
+		names := make([]string, 0, len(containers))
+		for _, c := range containers {
+			names = append(names, c.Name)
+		}
+		containerName, err := runner.PickContainer(names)
+		if errors.Is(err, runner.ErrNoContainer) {
+			return findErr
+		}
+		if err != nil {
+			return err
+		}
+		return stopContainer(dockerClient, containerName, stopDiscard)
 	},
 }
 
-func stopContainer(dockerClient *docker.Client, containerName string) error {
+// stopContainer stops and removes containerName with what packnplay keeps for it. Unless
+// discard is set, a copy of the workspace with unexported changes keeps it running.
+func stopContainer(dockerClient *docker.Client, containerName string, discard bool) error {
+	// The copy of a --copy-workspace container goes with it
+	if !discard {
+		if err := runner.CheckExported(dockerClient, containerName); err != nil {
+			return fmt.Errorf("%w\nPass --discard to remove it anyway", err)
+		}
+	}
 	fmt.Printf("Stopping container %s...\n", containerName)
-	_, err := dockerClient.Run("stop", containerName)
-	if err != nil {
+	if err := dockerClient.StopContainer(containerName, -1); err != nil {
 		return fmt.Errorf("failed to stop container: %w", err)
 	}
 
-	_, err = dockerClient.Run("rm", containerName)
-	if err != nil {
+	if err := dockerClient.RemoveContainer(containerName, false, false); err != nil {
 		return fmt.Errorf("failed to remove container: %w", err)
 	}
 
+	runner.RemoveContainerState(dockerClient, containerName)
+
 	fmt.Printf("Container %s stopped and removed\n", containerName)
 	return nil
 }
 
 func stopAllContainers(dockerClient *docker.Client) error {
 	// Get all packnplay-managed containers
-	output, err := dockerClient.Run("ps", "--filter", "label=managed-by=packnplay", "--format", "{{json .}}")
+	containers, err := dockerClient.ListContainers(map[string][]string{"label": {"managed-by=packnplay"}}, false)
 	if err != nil {
 		return fmt.Errorf("failed to list containers: %w", err)
 	}
 
-	if strings.TrimSpace(output) == "" {
+	if len(containers) == 0 {
 		fmt.Println("No packnplay-managed containers running")
 		return nil
 	}
 
-	// Parse container names
-	lines := strings.Split(strings.TrimSpace(output), "\n")
 	var containerNames []string
-	for _, line := range lines {
-		var info struct {
-			Names string `json:"Names"`
-		}
-		if err := json.Unmarshal([]byte(line), &info); err != nil {
-			continue
-		}
-		containerNames = append(containerNames, info.Names)
+	for _, c := range containers {
+		containerNames = append(containerNames, c.Name)
 	}
 
 	// Stop each container
 	for _, name := range containerNames {
-		if err := stopContainer(dockerClient, name); err != nil {
+		if err := stopContainer(dockerClient, name, stopDiscard); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 		}
 	}
@@ -128,4 +154,5 @@ func init() {
 	stopCmd.Flags().StringVar(&stopPath, "path", "", "Project path (default: pwd)")
 	stopCmd.Flags().StringVar(&stopWorktree, "worktree", "", "Worktree name")
 	stopCmd.Flags().BoolVar(&stopAll, "all", false, "Stop all packnplay-managed containers")
+	stopCmd.Flags().BoolVar(&stopDiscard, "discard", false, "Remove copied workspaces even with changes that weren't exported")
 }
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/obra/packnplay/pkg/runner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	syncWorkspaceRuntime string
+	syncWorkspaceUser    string
+)
+
+var syncWorkspaceCmd = &cobra.Command{
+	Use:    "sync-workspace <container> <source> <target>",
+	Short:  "Copy local workspace changes into a container on a remote daemon",
+	Long:   `Background process that copies new and changed files in a local workspace into its container on a remote daemon, until the container stops.`,
+	Hidden: true, // Hide from help - internal command
+	Args:   cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runner.SyncWorkspace(syncWorkspaceRuntime, args[0], syncWorkspaceUser, args[1], args[2])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(syncWorkspaceCmd)
+	syncWorkspaceCmd.Flags().StringVar(&syncWorkspaceRuntime, "runtime", "docker", "Container runtime")
+	syncWorkspaceCmd.Flags().StringVar(&syncWorkspaceUser, "user", "", "User to write files as in the container")
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var taskCmd = &cobra.Command{
+	Use:   "task [flags] [name] [args...]",
+	Short: "Run a task defined in the project's .packnplay.json",
+	Long: `Run a named task from the "tasks" section of the project's .packnplay.json in its
+container, like packnplay run would: same flags, worktree and container. The task's
+env, workdir and credentials are applied on top of the flags, and any extra arguments
+are appended to its command.
+
+With no name, the project's tasks are listed.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectDir, err := findProjectDir(runPath)
+		if err != nil {
+			return err
+		}
+		projectCfg, err := config.LoadProjectConfig(projectDir)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			printTasks(cmd.OutOrStdout(), projectCfg.Tasks)
+			return nil
+		}
+
+		// Tasks are the repo's commands, so they need a trusted workspace
+		cfg, err := config.LoadExistingOrEmpty(config.GetConfigPath())
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if _, untrusted := confirmWorkspaceTrust(cfg, projectDir, projectCfg); untrusted {
+			return fmt.Errorf("not running tasks of an untrusted workspace")
+		}
+
+		name := args[0]
+		task, ok := projectCfg.Tasks[name]
+		if !ok {
+			return fmt.Errorf("no task '%s' in %s (run packnplay task to list them)", name, config.GetProjectConfigPath(projectDir))
+		}
+		if err := task.Validate(); err != nil {
+			return fmt.Errorf("task '%s': %w", name, err)
+		}
+
+		for _, cred := range task.Credentials {
+			switch cred {
+			case "git", "ssh", "gh", "gpg", "npm", "aws", "gcp", "azure", "kube":
+				if err := cmd.Flags().Set(cred+"-creds", "true"); err != nil {
+					return err
+				}
+			default:
+				runCredProviders = append(runCredProviders, cred)
+			}
+		}
+		runEnv = append(runEnv, withoutSecretReferences(taskEnv(task), config.GetProjectConfigPath(projectDir))...)
+
+		return runCmd.RunE(cmd, taskCommand(task, args[1:]))
+	},
+}
+
+// taskEnv returns the task's environment as sorted KEY=value pairs
+func taskEnv(task config.Task) []string {
+	var env []string
+	for key, value := range task.Env {
+		env = append(env, key+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// taskCommand returns the task's command with extra arguments appended, changing to its
+// workdir first. A shell string gets them as "$@" at its end, so they stay separate words.
+// The container starts in the mounted project root, so a relative cd works for worktrees too.
+func taskCommand(task config.Task, extra []string) []string {
+	command := append(append([]string{}, task.Command...), extra...)
+	if script, ok := config.ShellScript(task.Command); ok && len(extra) > 0 {
+		command = append([]string{"sh", "-c", strings.TrimRight(script, " \t\n;") + ` "$@"`, "sh"}, extra...)
+	}
+	if task.Workdir != "" {
+		command = append([]string{"sh", "-c", `cd "$0" && exec "$@"`, task.Workdir}, command...)
+	}
+	return command
+}
+
+// printTasks lists tasks by name with their descriptions
+func printTasks(w io.Writer, tasks map[string]config.Task) {
+	if len(tasks) == 0 {
+		_, _ = fmt.Fprintf(w, "No tasks defined; add a \"tasks\" section to %s\n", config.ProjectConfigFile)
+		return
+	}
+
+	names := make([]string, 0, len(tasks))
+	width := 0
+	for name := range tasks {
+		names = append(names, name)
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		line := fmt.Sprintf("%-*s  %s", width, name, tasks[name].Description)
+		_, _ = fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(taskCmd)
+
+	// Everything after the task name belongs to the task, and run's flags apply as-is
+	taskCmd.Flags().SetInterspersed(false)
+	taskCmd.Flags().AddFlagSet(runCmd.Flags())
+}
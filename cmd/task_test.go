@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/config"
+)
+
+func TestTaskCommand(t *testing.T) {
+	tests := []struct {
+		name  string
+		task  config.Task
+		extra []string
+		want  []string
+	}{
+		{
+			name:  "extra args appended",
+			task:  config.Task{Command: []string{"go", "test", "./..."}},
+			extra: []string{"-run", "TestFoo"},
+			want:  []string{"go", "test", "./...", "-run", "TestFoo"},
+		},
+		{
+			name:  "extra args passed to a shell string",
+			task:  config.Task{Command: []string{"sh", "-c", "npm run lint;\n"}},
+			extra: []string{"--fix", "src/a b.ts"},
+			want:  []string{"sh", "-c", `npm run lint "$@"`, "sh", "--fix", "src/a b.ts"},
+		},
+		{
+			name: "shell string without extra args",
+			task: config.Task{Command: []string{"sh", "-c", "make && make test"}},
+			want: []string{"sh", "-c", "make && make test"},
+		},
+		{
+			name: "workdir",
+			task: config.Task{Command: []string{"npm", "test"}, Workdir: "web"},
+			want: []string{"sh", "-c", `cd "$0" && exec "$@"`, "web", "npm", "test"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := taskCommand(tt.task, tt.extra)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("taskCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// The task's own command isn't modified
+	task := config.Task{Command: make([]string, 1, 4)}
+	task.Command[0] = "make"
+	_ = taskCommand(task, []string{"all"})
+	if got := task.Command[:cap(task.Command)][1]; got != "" {
+		t.Errorf("taskCommand() wrote %q into the task's command", got)
+	}
+}
+
+func TestTaskEnv(t *testing.T) {
+	got := taskEnv(config.Task{Env: map[string]string{"B": "2", "A": "1"}})
+	if want := []string{"A=1", "B=2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("taskEnv() = %v, want %v", got, want)
+	}
+}
+
+func TestPrintTasks(t *testing.T) {
+	var buf bytes.Buffer
+	printTasks(&buf, map[string]config.Task{
+		"test":      {Description: "Run the tests"},
+		"typecheck": {},
+	})
+	want := "test       Run the tests\ntypecheck\n"
+	if buf.String() != want {
+		t.Errorf("printTasks() =\n%q\nwant\n%q", buf.String(), want)
+	}
+
+	buf.Reset()
+	printTasks(&buf, nil)
+	if buf.String() != "No tasks defined; add a \"tasks\" section to .packnplay.json\n" {
+		t.Errorf("printTasks(nil) = %q", buf.String())
+	}
+}
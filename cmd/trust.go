@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/devcontainer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	trustPath   string
+	trustRevoke bool
+	trustList   bool
+)
+
+var trustCmd = &cobra.Command{
+	Use:   "trust",
+	Short: "Trust the current repository's packnplay settings",
+	Long: `Trust the current repository, so packnplay uses the settings in its .packnplay.json and
+devcontainer.json that reach past the sandbox: host mounts, credentials, host env vars,
+docker access, the default command and tasks.
+
+packnplay asks the first time it runs in a repository that has such settings. Until the
+repository is trusted they are ignored. Trusted repositories are kept in
+trusted_workspaces in the config file.`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configPath := config.GetConfigPath()
+		if trustList {
+			cfg, err := config.LoadExistingOrEmpty(configPath)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			if len(cfg.TrustedWorkspaces) == 0 {
+				fmt.Println("No trusted workspaces")
+			}
+			for _, dir := range cfg.TrustedWorkspaces {
+				fmt.Println(dir)
+			}
+			return nil
+		}
+
+		projectDir, err := findProjectDir(trustPath)
+		if err != nil {
+			return err
+		}
+		if trustRevoke {
+			if err := config.UntrustWorkspace(configPath, projectDir); err != nil {
+				return err
+			}
+			fmt.Printf("No longer trusting %s\n", projectDir)
+			return nil
+		}
+
+		if err := config.TrustWorkspace(configPath, projectDir); err != nil {
+			return err
+		}
+		fmt.Printf("Trusted %s\n", projectDir)
+		return nil
+	},
+}
+
+// workspaceTrustRisks lists what the repository in projectDir configures that reaches
+// past the sandbox or cfg's limits
+func workspaceTrustRisks(cfg *config.Config, projectDir string, projectCfg *config.ProjectConfig) []string {
+	risks := projectCfg.TrustRisks(projectDir, cfg)
+	if devConfig, err := devcontainer.ParseConfig(projectDir); err == nil && devConfig != nil {
+		if feature := devConfig.DockerFeature(); feature != "" {
+			risks = append(risks, "asks for docker access with the devcontainer "+feature+" feature")
+		}
+		if names := devConfig.LocalEnvRefs(); len(names) > 0 {
+			risks = append(risks, "reads host env vars in devcontainer.json: "+strings.Join(names, ", "))
+		}
+		var outside []string
+		for _, path := range devConfig.BuildPaths(projectDir) {
+			if !config.PathInProject(path, projectDir) {
+				outside = append(outside, path)
+			}
+		}
+		if len(outside) > 0 {
+			risks = append(risks, "builds its image from outside the repository: "+strings.Join(outside, ", "))
+		}
+	}
+	return risks
+}
+
+// workspaceUntrusted reports whether the repository in projectDir has settings reaching
+// past the sandbox that the user hasn't trusted, without asking
+func workspaceUntrusted(cfg *config.Config, projectDir string) bool {
+	if cfg.WorkspaceTrusted(projectDir) {
+		return false
+	}
+	projectCfg, err := config.LoadProjectConfig(projectDir)
+	if err != nil {
+		return true
+	}
+	return len(workspaceTrustRisks(cfg, projectDir, projectCfg)) > 0
+}
+
+// checkWorkspaceTrust returns the project config to run with. A repository that isn't
+// trusted and has settings reaching past the sandbox is asked about once; when the user
+// declines, or there is no terminal to ask on, those settings are left out and untrusted
+// is true.
+func checkWorkspaceTrust(cfg *config.Config, projectDir string, projectCfg *config.ProjectConfig, in io.Reader, out io.Writer, interactive bool) (*config.ProjectConfig, bool) {
+	if cfg.WorkspaceTrusted(projectDir) {
+		return projectCfg, false
+	}
+	risks := workspaceTrustRisks(cfg, projectDir, projectCfg)
+	if len(risks) == 0 {
+		return projectCfg, false
+	}
+
+	_, _ = fmt.Fprintf(out, "%s is not a trusted workspace. Its configuration:\n", projectDir)
+	for _, risk := range risks {
+		_, _ = fmt.Fprintf(out, "  - %s\n", risk)
+	}
+	if interactive {
+		_, _ = fmt.Fprint(out, "Trust it and use these settings? [y/N]: ")
+		answer, _ := bufio.NewReader(in).ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			if err := config.TrustWorkspace(config.GetConfigPath(), projectDir); err != nil {
+				_, _ = fmt.Fprintf(out, "Warning: failed to remember the trust decision: %v\n", err)
+			}
+			return projectCfg, false
+		}
+	}
+	_, _ = fmt.Fprintln(out, "Ignoring these settings; trust the workspace with: packnplay trust")
+	return projectCfg.Restricted(projectDir, cfg), true
+}
+
+// confirmWorkspaceTrust checks the workspace's trust using the terminal
+func confirmWorkspaceTrust(cfg *config.Config, projectDir string, projectCfg *config.ProjectConfig) (*config.ProjectConfig, bool) {
+	return checkWorkspaceTrust(cfg, projectDir, projectCfg, os.Stdin, os.Stderr, term.IsTerminal(os.Stdin.Fd()))
+}
+
+func init() {
+	rootCmd.AddCommand(trustCmd)
+
+	trustCmd.Flags().StringVar(&trustPath, "path", "", "Repository to trust (default: current directory)")
+	trustCmd.Flags().BoolVar(&trustRevoke, "revoke", false, "Stop trusting the repository")
+	trustCmd.Flags().BoolVar(&trustList, "list", false, "List trusted repositories")
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/config"
+)
+
+func TestCheckWorkspaceTrust(t *testing.T) {
+	yes := true
+	risky := &config.ProjectConfig{
+		Credentials: &config.CredentialOverrides{AWS: &yes},
+		Command:     config.CommandLine{"claude"},
+	}
+
+	tests := []struct {
+		name          string
+		trusted       bool
+		project       *config.ProjectConfig
+		interactive   bool
+		answer        string
+		wantUntrusted bool
+		wantSaved     bool
+		wantOutput    string
+	}{
+		{name: "nothing risky", project: &config.ProjectConfig{Env: []string{"CI=1"}}},
+		{name: "already trusted", trusted: true, project: risky},
+		{name: "accepted", project: risky, interactive: true, answer: "y\n", wantSaved: true, wantOutput: "turns on credentials: aws"},
+		{name: "declined", project: risky, interactive: true, answer: "n\n", wantUntrusted: true, wantOutput: "Ignoring these settings"},
+		{name: "no terminal", project: risky, wantUntrusted: true, wantOutput: "sets the default command: claude"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+			projectDir := t.TempDir()
+			cfg := &config.Config{}
+			if tt.trusted {
+				cfg.TrustedWorkspaces = []string{projectDir}
+			}
+
+			var out bytes.Buffer
+			got, untrusted := checkWorkspaceTrust(cfg, projectDir, tt.project, strings.NewReader(tt.answer), &out, tt.interactive)
+			if untrusted != tt.wantUntrusted {
+				t.Errorf("untrusted = %v, want %v", untrusted, tt.wantUntrusted)
+			}
+			if untrusted && (got.Command != nil || got.Credentials.AWS != nil) {
+				t.Errorf("untrusted workspace kept its settings: %+v", got)
+			}
+			if !untrusted && got != tt.project {
+				t.Errorf("trusted workspace got %+v, want the project config", got)
+			}
+			if !strings.Contains(out.String(), tt.wantOutput) {
+				t.Errorf("output %q doesn't contain %q", out.String(), tt.wantOutput)
+			}
+
+			saved, err := config.LoadExistingOrEmpty(config.GetConfigPath())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if saved.WorkspaceTrusted(projectDir) != tt.wantSaved {
+				t.Errorf("trust saved = %v, want %v", !tt.wantSaved, tt.wantSaved)
+			}
+		})
+	}
+}
+
+func TestWorkspaceTrustRisksDevcontainerFeature(t *testing.T) {
+	projectDir := t.TempDir()
+	devDir := filepath.Join(projectDir, ".devcontainer")
+	if err := os.MkdirAll(devDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	devJSON := `{
+		// JSONC is fine
+		"image": "mcr.microsoft.com/devcontainers/base:ubuntu",
+		"features": {"ghcr.io/devcontainers/features/docker-outside-of-docker:1": {}},
+	}`
+	if err := os.WriteFile(filepath.Join(devDir, "devcontainer.json"), []byte(devJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	risks := workspaceTrustRisks(&config.Config{}, projectDir, &config.ProjectConfig{})
+	if len(risks) != 1 || !strings.Contains(risks[0], "docker-outside-of-docker") {
+		t.Errorf("workspaceTrustRisks() = %q, want the docker feature", risks)
+	}
+}
+
+func TestWorkspaceTrustRisksLocalEnv(t *testing.T) {
+	projectDir := t.TempDir()
+	devDir := filepath.Join(projectDir, ".devcontainer")
+	if err := os.MkdirAll(devDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	devJSON := `{
+		"image": "mcr.microsoft.com/devcontainers/base:ubuntu",
+		"containerEnv": {"TOKEN": "${localEnv:GITHUB_TOKEN}"},
+		"build": {"args": {"NPM_TOKEN": "${localEnv:NPM_TOKEN:none}"}}
+	}`
+	if err := os.WriteFile(filepath.Join(devDir, "devcontainer.json"), []byte(devJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	risks := workspaceTrustRisks(&config.Config{}, projectDir, &config.ProjectConfig{})
+	if len(risks) != 1 || !strings.Contains(risks[0], "GITHUB_TOKEN, NPM_TOKEN") {
+		t.Errorf("workspaceTrustRisks() = %q, want the host env vars", risks)
+	}
+}
+
+func TestWorkspaceTrustRisksBuildContext(t *testing.T) {
+	projectDir := t.TempDir()
+	devDir := filepath.Join(projectDir, ".devcontainer")
+	if err := os.MkdirAll(devDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(devJSON string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(devDir, "devcontainer.json"), []byte(devJSON), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{"build": {"dockerfile": "Dockerfile", "context": ".."}}`)
+	if risks := workspaceTrustRisks(&config.Config{}, projectDir, &config.ProjectConfig{}); risks != nil {
+		t.Errorf("workspaceTrustRisks() with the repository as context = %q", risks)
+	}
+
+	write(`{"build": {"dockerfile": "Dockerfile", "context": "../../.."}}`)
+	risks := workspaceTrustRisks(&config.Config{}, projectDir, &config.ProjectConfig{})
+	if len(risks) != 1 || !strings.Contains(risks[0], "builds its image from outside the repository") {
+		t.Errorf("workspaceTrustRisks() = %q, want the build context", risks)
+	}
+}
@@ -0,0 +1,240 @@
+// Package api serves a local control API for managing packnplay containers over a unix
+// socket, for editor extensions and dashboards that shouldn't shell out to the CLI for
+// every action.
+//
+// Endpoints (JSON bodies, all requiring "Authorization: Bearer <token>"):
+//
+//	GET  /v1/containers               list managed containers
+//	POST /v1/containers               start a container: {"path", "worktree", "no_worktree"}
+//	POST /v1/containers/{name}/stop   stop and remove a container; ?discard=true drops
+//	                                  changes a copied workspace hasn't exported
+//	POST /v1/containers/{name}/exec   run a command: {"command": [...]}
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Container is a managed container as reported by the API
+type Container struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Project  string `json:"project"`
+	Worktree string `json:"worktree"`
+	HostPath string `json:"host_path,omitempty"`
+}
+
+// StartRequest asks for the container of a project directory and worktree to be started
+type StartRequest struct {
+	Path       string `json:"path"`
+	Worktree   string `json:"worktree,omitempty"`
+	NoWorktree bool   `json:"no_worktree,omitempty"`
+}
+
+// ExecRequest runs a non-interactive command in a container
+type ExecRequest struct {
+	Command []string `json:"command"`
+}
+
+// CommandResult is the outcome of a command run for a request
+type CommandResult struct {
+	ExitCode int    `json:"exit_code"`
+	Output   string `json:"output"`
+}
+
+// Backend carries out API requests
+type Backend interface {
+	List() ([]Container, error)
+	Start(req StartRequest) (*CommandResult, error)
+	Stop(name string, discard bool) error
+	Exec(name string, req ExecRequest) (*CommandResult, error)
+}
+
+// ErrNotFound is returned by a Backend for containers packnplay doesn't manage
+var ErrNotFound = errors.New("container not found")
+
+// ErrConflict is returned by a Backend for requests that would lose work, such as
+// stopping a container whose copy of the workspace has unexported changes
+var ErrConflict = errors.New("conflict")
+
+// errorResponse is the body of every non-2xx response
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewHandler returns the API handler. Requests without the bearer token are rejected.
+func NewHandler(backend Backend, token string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /v1/containers", func(w http.ResponseWriter, r *http.Request) {
+		containers, err := backend.List()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if containers == nil {
+			containers = []Container{}
+		}
+		writeJSON(w, http.StatusOK, map[string][]Container{"containers": containers})
+	})
+
+	mux.HandleFunc("POST /v1/containers", func(w http.ResponseWriter, r *http.Request) {
+		var req StartRequest
+		if !readJSON(w, r, &req) {
+			return
+		}
+		if req.Path == "" || !filepath.IsAbs(req.Path) {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "path must be an absolute project path"})
+			return
+		}
+		result, err := backend.Start(req)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	})
+
+	mux.HandleFunc("POST /v1/containers/{name}/stop", func(w http.ResponseWriter, r *http.Request) {
+		if err := backend.Stop(r.PathValue("name"), r.URL.Query().Get("discard") == "true"); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("POST /v1/containers/{name}/exec", func(w http.ResponseWriter, r *http.Request) {
+		var req ExecRequest
+		if !readJSON(w, r, &req) {
+			return
+		}
+		if len(req.Command) == 0 {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "command is required"})
+			return
+		}
+		result, err := backend.Exec(r.PathValue("name"), req)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	})
+
+	return requireToken(token, mux)
+}
+
+// requireToken rejects requests that don't carry the bearer token
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "missing or invalid token"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(v); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("invalid request body: %v", err)})
+		return false
+	}
+	return true
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, ErrNotFound) {
+		status = http.StatusNotFound
+	} else if errors.Is(err, ErrConflict) {
+		status = http.StatusConflict
+	}
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// SocketPath returns where the daemon listens: $XDG_RUNTIME_DIR/packnplay/api.sock, or
+// the packnplay data directory when there is no runtime directory
+func SocketPath() string {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "packnplay", "api.sock")
+	}
+	return filepath.Join(dataDir(), "api.sock")
+}
+
+// TokenPath returns the file holding the API token clients must present
+func TokenPath() string {
+	return filepath.Join(dataDir(), "api-token")
+}
+
+func dataDir() string {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		homeDir, _ := os.UserHomeDir()
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	return filepath.Join(dataHome, "packnplay")
+}
+
+// LoadOrCreateToken reads the API token at path, generating one (mode 0600) on first use
+func LoadOrCreateToken(path string) (string, error) {
+	if data, err := os.ReadFile(path); err == nil {
+		if token := strings.TrimSpace(string(data)); token != "" {
+			return token, nil
+		}
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate API token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("failed to create token directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("failed to write API token: %w", err)
+	}
+	return token, nil
+}
+
+// Listen opens the unix socket at path, readable only by the current user. A stale socket
+// left by a daemon that exited is replaced; a live one is an error.
+func Listen(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	if conn, err := net.Dial("unix", path); err == nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("another packnplay daemon is already listening on %s", path)
+	}
+	_ = os.Remove(path)
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	return listener, nil
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type fakeBackend struct {
+	containers []Container
+	started    []StartRequest
+	stopped    []string
+}
+
+func (f *fakeBackend) List() ([]Container, error) { return f.containers, nil }
+
+func (f *fakeBackend) Start(req StartRequest) (*CommandResult, error) {
+	f.started = append(f.started, req)
+	return &CommandResult{Output: "started"}, nil
+}
+
+func (f *fakeBackend) Stop(name string, discard bool) error {
+	if name == "packnplay-app-copy" && !discard {
+		return fmt.Errorf("%w: %s has unexported changes", ErrConflict, name)
+	}
+	if name != "packnplay-app-main" && name != "packnplay-app-copy" {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	f.stopped = append(f.stopped, name)
+	return nil
+}
+
+func (f *fakeBackend) Exec(name string, req ExecRequest) (*CommandResult, error) {
+	return &CommandResult{ExitCode: 3, Output: strings.Join(req.Command, " ")}, nil
+}
+
+// serve starts the API on a unix socket and returns an HTTP client that talks to it
+func serve(t *testing.T, backend Backend, token string) *http.Client {
+	t.Helper()
+	socketPath := filepath.Join(t.TempDir(), "api.sock")
+	listener, err := Listen(socketPath)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	server := &http.Server{Handler: NewHandler(backend, token)}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	info, err := os.Stat(socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("socket mode = %o, want 0600", info.Mode().Perm())
+	}
+
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		},
+	}}
+}
+
+func request(t *testing.T, client *http.Client, method, path, token, body string) (*http.Response, map[string]interface{}) {
+	t.Helper()
+	req, err := http.NewRequest(method, "http://packnplay"+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	var decoded map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&decoded)
+	return resp, decoded
+}
+
+func TestHandler(t *testing.T) {
+	backend := &fakeBackend{containers: []Container{{Name: "packnplay-app-main", Status: "Up 5 minutes", Project: "app", Worktree: "main"}}}
+	client := serve(t, backend, "secret")
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"missing token", "GET", "/v1/containers", "", "", http.StatusUnauthorized, "missing or invalid token"},
+		{"wrong token", "GET", "/v1/containers", "nope", "", http.StatusUnauthorized, "missing or invalid token"},
+		{"list", "GET", "/v1/containers", "secret", "", http.StatusOK, "packnplay-app-main"},
+		{"start", "POST", "/v1/containers", "secret", `{"path": "/src/app", "worktree": "feature"}`, http.StatusOK, "started"},
+		{"start relative path", "POST", "/v1/containers", "secret", `{"path": "app"}`, http.StatusBadRequest, "absolute"},
+		{"start bad body", "POST", "/v1/containers", "secret", `{`, http.StatusBadRequest, "invalid request body"},
+		{"stop", "POST", "/v1/containers/packnplay-app-main/stop", "secret", "", http.StatusNoContent, ""},
+		{"stop unknown", "POST", "/v1/containers/postgres/stop", "secret", "", http.StatusNotFound, "container not found"},
+		{"stop with unexported changes", "POST", "/v1/containers/packnplay-app-copy/stop", "secret", "", http.StatusConflict, "unexported changes"},
+		{"stop discarding changes", "POST", "/v1/containers/packnplay-app-copy/stop?discard=true", "secret", "", http.StatusNoContent, ""},
+		{"exec", "POST", "/v1/containers/packnplay-app-main/exec", "secret", `{"command": ["npm", "test"]}`, http.StatusOK, "npm test"},
+		{"exec without command", "POST", "/v1/containers/packnplay-app-main/exec", "secret", `{}`, http.StatusBadRequest, "command is required"},
+		{"wrong method", "DELETE", "/v1/containers", "secret", "", http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := request(t, client, tt.method, tt.path, tt.token, tt.body)
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %v)", resp.StatusCode, tt.wantStatus, body)
+			}
+			if encoded, _ := json.Marshal(body); tt.wantBody != "" && !strings.Contains(string(encoded), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %q", encoded, tt.wantBody)
+			}
+		})
+	}
+
+	if len(backend.started) != 1 || backend.started[0].Worktree != "feature" {
+		t.Errorf("started = %+v, want one start for worktree feature", backend.started)
+	}
+	if len(backend.stopped) != 2 {
+		t.Errorf("stopped = %v, want two stops", backend.stopped)
+	}
+}
+
+func TestExecReportsExitCode(t *testing.T) {
+	client := serve(t, &fakeBackend{}, "secret")
+	_, body := request(t, client, "POST", "/v1/containers/packnplay-app-main/exec", "secret", `{"command": ["false"]}`)
+	if body["exit_code"] != 3.0 {
+		t.Errorf("exit_code = %v, want 3", body["exit_code"])
+	}
+}
+
+func TestListenRejectsRunningDaemon(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "api.sock")
+	listener, err := Listen(socketPath)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer listener.Close()
+
+	if _, err := Listen(socketPath); err == nil {
+		t.Error("Listen() succeeded while another daemon is listening")
+	}
+}
+
+func TestListenReplacesStaleSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "api.sock")
+	if err := os.WriteFile(socketPath, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := Listen(socketPath)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	_ = listener.Close()
+}
+
+func TestLoadOrCreateToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "packnplay", "api-token")
+
+	token, err := LoadOrCreateToken(path)
+	if err != nil {
+		t.Fatalf("LoadOrCreateToken() error = %v", err)
+	}
+	if len(token) != 64 {
+		t.Errorf("token length = %d, want 64 hex chars", len(token))
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("token file mode = %o, want 0600", info.Mode().Perm())
+	}
+
+	again, err := LoadOrCreateToken(path)
+	if err != nil || again != token {
+		t.Errorf("LoadOrCreateToken() = %q, %v; want the existing token", again, err)
+	}
+}
+
+func TestSocketPath(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	if got := SocketPath(); got != "/run/user/1000/packnplay/api.sock" {
+		t.Errorf("SocketPath() = %q", got)
+	}
+
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	t.Setenv("XDG_DATA_HOME", "/data")
+	if got := SocketPath(); got != "/data/packnplay/api.sock" {
+		t.Errorf("SocketPath() without runtime dir = %q", got)
+	}
+	if got := TokenPath(); got != "/data/packnplay/api-token" {
+		t.Errorf("TokenPath() = %q", got)
+	}
+}
@@ -0,0 +1,188 @@
+package aws
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// configFile holds the sections of an AWS config file, keyed by name
+type configFile struct {
+	profiles    map[string]map[string]string
+	ssoSessions map[string]map[string]string
+}
+
+// parseConfigFile reads the [profile name], [default] and [sso-session name] sections of
+// an AWS config file. Indented sub-keys (e.g. under "s3 =") are flattened into their section.
+func parseConfigFile(configPath string) (*configFile, error) {
+	file, err := os.Open(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open AWS config at %s: %w", configPath, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	cfg := &configFile{
+		profiles:    make(map[string]map[string]string),
+		ssoSessions: make(map[string]map[string]string),
+	}
+
+	scanner := bufio.NewScanner(file)
+	var current map[string]string
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// Skip empty lines and comments
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		// Section header: [profile name], [default] or [sso-session name]
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			header := strings.TrimSpace(strings.Trim(line, "[]")) // Handle extra whitespace
+			sections := cfg.profiles
+			name := header
+			if strings.HasPrefix(header, "profile ") {
+				name = strings.TrimSpace(strings.TrimPrefix(header, "profile "))
+			} else if strings.HasPrefix(header, "sso-session ") {
+				sections = cfg.ssoSessions
+				name = strings.TrimSpace(strings.TrimPrefix(header, "sso-session "))
+			}
+
+			if sections[name] == nil {
+				sections[name] = make(map[string]string)
+			}
+			current = sections[name]
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		current[strings.TrimSpace(key)] = strings.TrimSpace(stripInlineComment(value))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading AWS config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// stripInlineComment drops a trailing # or ; comment from value. Like the AWS CLI, only one
+// preceded by whitespace starts a comment, so URLs like https://x.awsapps.com/start#/ are kept.
+func stripInlineComment(value string) string {
+	for i := 1; i < len(value); i++ {
+		if (value[i] == '#' || value[i] == ';') && (value[i-1] == ' ' || value[i-1] == '\t') {
+			return value[:i]
+		}
+	}
+	return value
+}
+
+// SSOProfile describes a profile whose credentials come from AWS IAM Identity Center (SSO)
+type SSOProfile struct {
+	Profile  string
+	Session  string // sso-session name; empty for legacy profiles with sso_start_url inline
+	StartURL string
+	Region   string
+}
+
+// LoginCommand returns the host command that refreshes the SSO token for this profile
+func (p SSOProfile) LoginCommand() string {
+	return fmt.Sprintf("aws sso login --profile %s", p.Profile)
+}
+
+// TokenCachePath returns where the AWS CLI caches this profile's SSO token: the SHA-1 of
+// the sso-session name, or of the start URL for legacy profiles
+func (p SSOProfile) TokenCachePath(homeDir string) string {
+	key := p.Session
+	if key == "" {
+		key = p.StartURL
+	}
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(homeDir, ".aws", "sso", "cache", hex.EncodeToString(sum[:])+".json")
+}
+
+// HasValidToken reports whether a cached SSO token exists and has not expired at now
+func (p SSOProfile) HasValidToken(homeDir string, now time.Time) bool {
+	data, err := os.ReadFile(p.TokenCachePath(homeDir))
+	if err != nil {
+		return false
+	}
+
+	var token struct {
+		AccessToken string `json:"accessToken"`
+		ExpiresAt   string `json:"expiresAt"`
+	}
+	if err := json.Unmarshal(data, &token); err != nil || token.AccessToken == "" {
+		return false
+	}
+
+	// Older CLI versions write "2006-01-02T15:04:05UTC" instead of RFC 3339
+	expiresAt, err := time.Parse(time.RFC3339, token.ExpiresAt)
+	if err != nil {
+		expiresAt, err = time.Parse("2006-01-02T15:04:05UTC", token.ExpiresAt)
+		if err != nil {
+			return false
+		}
+	}
+	return now.Before(expiresAt)
+}
+
+// SSOProfileError reports that a profile uses SSO instead of credential_process
+type SSOProfileError struct {
+	SSO SSOProfile
+}
+
+func (e *SSOProfileError) Error() string {
+	source := "sso_start_url"
+	if e.SSO.Session != "" {
+		source = fmt.Sprintf("sso-session '%s'", e.SSO.Session)
+	}
+	return fmt.Sprintf("profile '%s' uses AWS SSO (%s) and has no credential_process; run '%s' on the host to sign in",
+		e.SSO.Profile, source, e.SSO.LoginCommand())
+}
+
+// ssoProfile resolves the SSO settings for a profile, following its sso_session reference
+func (c *configFile) ssoProfile(profile string) (SSOProfile, bool, error) {
+	settings := c.profiles[profile]
+
+	if session := settings["sso_session"]; session != "" {
+		sessionSettings, ok := c.ssoSessions[session]
+		if !ok {
+			return SSOProfile{}, false, fmt.Errorf("profile '%s' references sso-session '%s', which is not defined", profile, session)
+		}
+		region := sessionSettings["sso_region"]
+		if region == "" {
+			region = settings["sso_region"]
+		}
+		return SSOProfile{
+			Profile:  profile,
+			Session:  session,
+			StartURL: sessionSettings["sso_start_url"],
+			Region:   region,
+		}, true, nil
+	}
+
+	if startURL := settings["sso_start_url"]; startURL != "" {
+		return SSOProfile{
+			Profile:  profile,
+			StartURL: startURL,
+			Region:   settings["sso_region"],
+		}, true, nil
+	}
+
+	return SSOProfile{}, false, nil
+}
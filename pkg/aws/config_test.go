@@ -0,0 +1,186 @@
+package aws
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const ssoConfig = `[sso-session corp]
+sso_start_url = https://corp.awsapps.com/start
+sso_region = us-east-1
+sso_registration_scopes = sso:account:access
+
+[profile dev]
+sso_session = corp
+sso_account_id = 111111111111
+sso_role_name = Developer
+region = eu-west-1
+
+[profile legacy]
+sso_start_url = https://legacy.awsapps.com/start
+sso_region = us-west-2
+sso_account_id = 222222222222
+
+[profile broken]
+sso_session = missing
+
+[profile processed]
+credential_process = granted credential-process --profile processed
+sso_session = corp
+`
+
+func writeAWSConfig(t *testing.T, content string) {
+	t.Helper()
+	configPath := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	t.Setenv("AWS_CONFIG_FILE", configPath)
+}
+
+func TestParseAWSConfigSSO(t *testing.T) {
+	writeAWSConfig(t, ssoConfig)
+
+	tests := []struct {
+		name        string
+		profile     string
+		want        SSOProfile
+		errContains string
+	}{
+		{
+			name:    "sso-session reference",
+			profile: "dev",
+			want:    SSOProfile{Profile: "dev", Session: "corp", StartURL: "https://corp.awsapps.com/start", Region: "us-east-1"},
+		},
+		{
+			name:    "legacy inline sso_start_url",
+			profile: "legacy",
+			want:    SSOProfile{Profile: "legacy", StartURL: "https://legacy.awsapps.com/start", Region: "us-west-2"},
+		},
+		{
+			name:        "undefined sso-session",
+			profile:     "broken",
+			errContains: "sso-session 'missing', which is not defined",
+		},
+		{
+			name:        "sso-session is not a profile",
+			profile:     "sso-session corp",
+			errContains: "not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAWSConfig(tt.profile)
+			if err == nil {
+				t.Fatalf("ParseAWSConfig(%q) expected error", tt.profile)
+			}
+
+			if tt.errContains != "" {
+				if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("ParseAWSConfig() error = %v, want error containing %q", err, tt.errContains)
+				}
+				return
+			}
+
+			var ssoErr *SSOProfileError
+			if !errors.As(err, &ssoErr) {
+				t.Fatalf("ParseAWSConfig() error = %v, want *SSOProfileError", err)
+			}
+			if ssoErr.SSO != tt.want {
+				t.Errorf("SSO = %+v, want %+v", ssoErr.SSO, tt.want)
+			}
+			if !strings.Contains(err.Error(), "aws sso login --profile "+tt.profile) {
+				t.Errorf("error %q should include the login command", err)
+			}
+		})
+	}
+}
+
+func TestParseConfigFileInlineComments(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config")
+	content := `[profile hashed]
+sso_start_url = https://corp.awsapps.com/start#/
+sso_region = us-east-1 # where the portal lives
+sso_account_id = 333333333333	;account
+sso_role_name = Dev;Ops
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := parseConfigFile(configPath)
+	if err != nil {
+		t.Fatalf("parseConfigFile() error = %v", err)
+	}
+	want := map[string]string{
+		"sso_start_url":  "https://corp.awsapps.com/start#/",
+		"sso_region":     "us-east-1",
+		"sso_account_id": "333333333333",
+		"sso_role_name":  "Dev;Ops",
+	}
+	for key, value := range want {
+		if got := cfg.profiles["hashed"][key]; got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestParseAWSConfigPrefersCredentialProcess(t *testing.T) {
+	writeAWSConfig(t, ssoConfig)
+
+	got, err := ParseAWSConfig("processed")
+	if err != nil {
+		t.Fatalf("ParseAWSConfig() unexpected error = %v", err)
+	}
+	if got != "granted credential-process --profile processed" {
+		t.Errorf("ParseAWSConfig() = %q", got)
+	}
+}
+
+func TestSSOProfileHasValidToken(t *testing.T) {
+	homeDir := t.TempDir()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	profile := SSOProfile{Profile: "dev", Session: "corp", StartURL: "https://corp.awsapps.com/start"}
+
+	cachePath := profile.TokenCachePath(homeDir)
+	// sha1("corp")
+	if filepath.Base(cachePath) != "ee0bfd2552fbd840c02cc48b6e823320543c450f.json" {
+		t.Errorf("TokenCachePath() = %s", cachePath)
+	}
+
+	if profile.HasValidToken(homeDir, now) {
+		t.Error("HasValidToken() = true with no cached token")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  bool
+	}{
+		{"valid", `{"accessToken":"abc","expiresAt":"2025-06-01T13:00:00Z"}`, true},
+		{"expired", `{"accessToken":"abc","expiresAt":"2025-06-01T11:00:00Z"}`, false},
+		{"legacy timestamp", `{"accessToken":"abc","expiresAt":"2025-06-01T13:00:00UTC"}`, true},
+		{"no access token", `{"expiresAt":"2025-06-01T13:00:00Z"}`, false},
+		{"malformed", `not json`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(cachePath, []byte(tt.token), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if got := profile.HasValidToken(homeDir, now); got != tt.want {
+				t.Errorf("HasValidToken() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
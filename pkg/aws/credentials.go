@@ -1,13 +1,11 @@
 package aws
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 	"time"
 )
@@ -63,83 +61,37 @@ func GetCredentialsFromProcess(credentialProcess string) (*AWSCredentials, error
 	return &creds, nil
 }
 
-// ParseAWSConfig parses AWS config file and returns credential_process for a profile
+// ParseAWSConfig parses AWS config file and returns credential_process for a profile.
+// SSO profiles (sso_session or legacy sso_start_url) return an *SSOProfileError, since
+// their credentials come from the SSO token cache rather than a process.
 func ParseAWSConfig(profile string) (string, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
-	}
-
 	// Check for AWS_CONFIG_FILE override
-	configPath := os.Getenv("AWS_CONFIG_FILE")
-	if configPath == "" {
-		configPath = filepath.Join(homeDir, ".aws", "config")
-	}
-
-	file, err := os.Open(configPath)
+	configPath, err := ConfigFilePath()
 	if err != nil {
-		return "", fmt.Errorf("failed to open AWS config at %s: %w", configPath, err)
-	}
-	defer func() { _ = file.Close() }()
-
-	scanner := bufio.NewScanner(file)
-	var currentProfile string
-	var credentialProcess string
-	var profileFound bool
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
-			continue
-		}
-
-		// Check for profile section
-		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
-			// Extract profile name from [profile name] or [default]
-			profileLine := strings.Trim(line, "[]")
-			profileLine = strings.TrimSpace(profileLine) // Handle extra whitespace
-			if strings.HasPrefix(profileLine, "profile ") {
-				currentProfile = strings.TrimSpace(strings.TrimPrefix(profileLine, "profile "))
-			} else {
-				currentProfile = profileLine
-			}
-			if currentProfile == profile {
-				profileFound = true
-			}
-			continue
-		}
-
-		// Check for credential_process in current profile
-		if currentProfile == profile {
-			if strings.HasPrefix(line, "credential_process") {
-				parts := strings.SplitN(line, "=", 2)
-				if len(parts) == 2 {
-					value := strings.TrimSpace(parts[1])
-					// Strip inline comments (# or ;)
-					if idx := strings.IndexAny(value, "#;"); idx > 0 {
-						value = strings.TrimSpace(value[:idx])
-					}
-					credentialProcess = value
-				}
-			}
-		}
+		return "", err
 	}
 
-	if err := scanner.Err(); err != nil {
-		return "", fmt.Errorf("error reading AWS config: %w", err)
+	cfg, err := parseConfigFile(configPath)
+	if err != nil {
+		return "", err
 	}
 
+	settings, profileFound := cfg.profiles[profile]
 	if !profileFound {
 		return "", fmt.Errorf("profile '%s' not found in %s", profile, configPath)
 	}
 
-	if credentialProcess == "" {
-		return "", fmt.Errorf("profile '%s' exists but has no credential_process configured", profile)
+	if credentialProcess := settings["credential_process"]; credentialProcess != "" {
+		return credentialProcess, nil
+	}
+
+	if sso, ok, err := cfg.ssoProfile(profile); err != nil {
+		return "", err
+	} else if ok {
+		return "", &SSOProfileError{SSO: sso}
 	}
 
-	return credentialProcess, nil
+	return "", fmt.Errorf("profile '%s' exists but has no credential_process configured", profile)
 }
 
 // GetAWSEnvVars returns all AWS_* environment variables, excluding problematic ones
@@ -0,0 +1,96 @@
+package aws
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// relocatedFiles maps AWS file env vars to the name used for them inside the container
+var relocatedFiles = []struct {
+	envVar string
+	name   string
+}{
+	{"AWS_CONFIG_FILE", "config"},
+	{"AWS_SHARED_CREDENTIALS_FILE", "credentials"},
+}
+
+// RelocatedDir is where AWS files from outside ~/.aws are mounted, relative to the container home
+const RelocatedDir = ".aws-packnplay"
+
+// FileMount maps an AWS file configured via environment variable into the container
+type FileMount struct {
+	EnvVar        string // AWS_CONFIG_FILE or AWS_SHARED_CREDENTIALS_FILE
+	HostPath      string // resolved path on the host
+	ContainerPath string // value the env var should have inside the container
+	NeedsMount    bool   // false when the file is already visible through the ~/.aws mount
+}
+
+// ConfigFilePath returns the host AWS config path, honoring AWS_CONFIG_FILE
+func ConfigFilePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	if configPath := os.Getenv("AWS_CONFIG_FILE"); configPath != "" {
+		return expandHome(configPath, homeDir), nil
+	}
+	return filepath.Join(homeDir, ".aws", "config"), nil
+}
+
+// RelocatedFileMounts resolves AWS_CONFIG_FILE and AWS_SHARED_CREDENTIALS_FILE for use in
+// a container whose home is containerHome. Files inside ~/.aws are remapped to the ~/.aws
+// mount; files elsewhere get their own mount under RelocatedDir. Missing files are skipped
+// and reported so the caller can drop the env var instead of passing a dangling host path.
+func RelocatedFileMounts(homeDir, containerHome string) (mounts []FileMount, missing []string) {
+	awsDir := filepath.Join(homeDir, ".aws")
+
+	for _, file := range relocatedFiles {
+		value := os.Getenv(file.envVar)
+		if value == "" {
+			continue
+		}
+
+		hostPath, err := filepath.Abs(expandHome(value, homeDir))
+		if err != nil || !fileExists(hostPath) {
+			missing = append(missing, file.envVar)
+			continue
+		}
+
+		if rel, err := filepath.Rel(awsDir, hostPath); err == nil && !strings.HasPrefix(rel, "..") {
+			mounts = append(mounts, FileMount{
+				EnvVar:        file.envVar,
+				HostPath:      hostPath,
+				ContainerPath: filepath.Join(containerHome, ".aws", rel),
+			})
+			continue
+		}
+
+		mounts = append(mounts, FileMount{
+			EnvVar:        file.envVar,
+			HostPath:      hostPath,
+			ContainerPath: filepath.Join(containerHome, RelocatedDir, file.name),
+			NeedsMount:    true,
+		})
+	}
+
+	return mounts, missing
+}
+
+// expandHome replaces a leading ~ with homeDir
+func expandHome(path, homeDir string) string {
+	if path == "~" {
+		return homeDir
+	}
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(homeDir, path[2:])
+	}
+	return path
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,66 @@
+package aws
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRelocatedFileMounts(t *testing.T) {
+	homeDir := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(homeDir, ".aws"), 0755)
+	_ = os.WriteFile(filepath.Join(homeDir, ".aws", "work-config"), []byte("[default]\n"), 0644)
+
+	outside := filepath.Join(t.TempDir(), "creds")
+	_ = os.WriteFile(outside, []byte("[default]\n"), 0600)
+
+	t.Setenv("AWS_CONFIG_FILE", "~/.aws/work-config")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", outside)
+
+	mounts, missing := RelocatedFileMounts(homeDir, "/home/vscode")
+	if len(missing) != 0 {
+		t.Errorf("missing = %v, want none", missing)
+	}
+	if len(mounts) != 2 {
+		t.Fatalf("RelocatedFileMounts() = %+v, want 2 mounts", mounts)
+	}
+
+	// Inside ~/.aws: remapped through the existing ~/.aws mount
+	if mounts[0].EnvVar != "AWS_CONFIG_FILE" || mounts[0].NeedsMount || mounts[0].ContainerPath != "/home/vscode/.aws/work-config" {
+		t.Errorf("config mount = %+v", mounts[0])
+	}
+
+	// Outside ~/.aws: mounted separately
+	if mounts[1].EnvVar != "AWS_SHARED_CREDENTIALS_FILE" || !mounts[1].NeedsMount || mounts[1].HostPath != outside ||
+		mounts[1].ContainerPath != "/home/vscode/.aws-packnplay/credentials" {
+		t.Errorf("credentials mount = %+v", mounts[1])
+	}
+}
+
+func TestRelocatedFileMountsMissing(t *testing.T) {
+	t.Setenv("AWS_CONFIG_FILE", "/nonexistent/aws/config")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "")
+
+	mounts, missing := RelocatedFileMounts(t.TempDir(), "/home/vscode")
+	if len(mounts) != 0 {
+		t.Errorf("mounts = %+v, want none", mounts)
+	}
+	if len(missing) != 1 || missing[0] != "AWS_CONFIG_FILE" {
+		t.Errorf("missing = %v, want [AWS_CONFIG_FILE]", missing)
+	}
+}
+
+func TestConfigFilePath(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	t.Setenv("AWS_CONFIG_FILE", "")
+	if got, _ := ConfigFilePath(); got != filepath.Join(homeDir, ".aws", "config") {
+		t.Errorf("ConfigFilePath() = %v, want default ~/.aws/config", got)
+	}
+
+	t.Setenv("AWS_CONFIG_FILE", "~/custom/aws.conf")
+	if got, _ := ConfigFilePath(); got != filepath.Join(homeDir, "custom", "aws.conf") {
+		t.Errorf("ConfigFilePath() = %v, want ~ expanded", got)
+	}
+}
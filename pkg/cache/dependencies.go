@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/obra/packnplay/pkg/container"
+)
+
+// DependencyCacheLabel marks a dependency cache volume with the cache's name
+const DependencyCacheLabel = "packnplay-dependency-cache"
+
+// dependencyVolumePrefix starts the name of every dependency cache volume
+const dependencyVolumePrefix = "packnplay-cache"
+
+// DependencyCaches are the package manager caches packnplay knows, by name, with where
+// each lives relative to the container user's home
+var DependencyCaches = map[string]string{
+	"npm":      ".npm",
+	"yarn":     ".cache/yarn",
+	"pnpm":     ".local/share/pnpm/store",
+	"pip":      ".cache/pip",
+	"go-build": ".cache/go-build",
+	"go-mod":   "go/pkg/mod",
+	"cargo":    ".cargo/registry",
+	"maven":    ".m2/repository",
+	"gradle":   ".gradle/caches",
+}
+
+// DependencyCache is a cache volume and where it is mounted
+type DependencyCache struct {
+	Name   string
+	Volume string
+	Target string // absolute path in the container
+}
+
+// Mount returns the cache in docker -v syntax
+func (d DependencyCache) Mount() string {
+	return d.Volume + ":" + d.Target
+}
+
+// DependencyCacheNames returns the built-in caches, sorted
+func DependencyCacheNames() []string {
+	names := make([]string, 0, len(DependencyCaches))
+	for name := range DependencyCaches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DependencyVolumePrefix returns how the volumes of a project's caches are named: after
+// the project for the project scope, shared by all projects for the global one
+func DependencyVolumePrefix(global bool, projectPath string) string {
+	if global {
+		return dependencyVolumePrefix
+	}
+	return fmt.Sprintf("%s-%s-%s", dependencyVolumePrefix, filepath.Base(projectPath), container.ProjectHash(projectPath))
+}
+
+// ResolveDependencyCaches returns the caches to mount for the entries (built-in names, or
+// name:path with ~ for home), or every built-in one without entries. Volumes are named
+// volumePrefix-name.
+func ResolveDependencyCaches(entries []string, home, volumePrefix string) ([]DependencyCache, error) {
+	if len(entries) == 0 {
+		entries = DependencyCacheNames()
+	}
+
+	var caches []DependencyCache
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		name, target, custom := strings.Cut(entry, ":")
+		if !custom {
+			relative, ok := DependencyCaches[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown dependency cache '%s' (use %s, or name:path)", name, strings.Join(DependencyCacheNames(), ", "))
+			}
+			target = path.Join(home, relative)
+		} else if target == "~" || strings.HasPrefix(target, "~/") {
+			target = path.Join(home, strings.TrimPrefix(target, "~"))
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		caches = append(caches, DependencyCache{Name: name, Volume: volumePrefix + "-" + name, Target: target})
+	}
+	return caches, nil
+}
+
+// CreatedParents returns the directories between home and the caches' targets. The
+// runtime creates the ones the image lacks, owned by root, when it mounts the caches, so
+// they need handing to the container user. home itself is left out.
+func CreatedParents(caches []DependencyCache, home string) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, c := range caches {
+		if !strings.HasPrefix(c.Target, home+"/") {
+			continue
+		}
+		for dir := path.Dir(c.Target); dir != home && dir != "/" && !seen[dir]; dir = path.Dir(dir) {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
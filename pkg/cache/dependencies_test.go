@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveDependencyCaches(t *testing.T) {
+	caches, err := ResolveDependencyCaches([]string{"npm", "go-build", "bundler:~/.bundle/cache", "npm", "ccache:/var/cache/ccache"}, "/home/vscode", "packnplay-cache-app-1a2b3c4d")
+	if err != nil {
+		t.Fatalf("ResolveDependencyCaches() error = %v", err)
+	}
+	want := []DependencyCache{
+		{Name: "npm", Volume: "packnplay-cache-app-1a2b3c4d-npm", Target: "/home/vscode/.npm"},
+		{Name: "go-build", Volume: "packnplay-cache-app-1a2b3c4d-go-build", Target: "/home/vscode/.cache/go-build"},
+		{Name: "bundler", Volume: "packnplay-cache-app-1a2b3c4d-bundler", Target: "/home/vscode/.bundle/cache"},
+		{Name: "ccache", Volume: "packnplay-cache-app-1a2b3c4d-ccache", Target: "/var/cache/ccache"},
+	}
+	if !reflect.DeepEqual(caches, want) {
+		t.Errorf("ResolveDependencyCaches() =\n%+v\nwant\n%+v", caches, want)
+	}
+	if got := caches[0].Mount(); got != "packnplay-cache-app-1a2b3c4d-npm:/home/vscode/.npm" {
+		t.Errorf("Mount() = %q", got)
+	}
+
+	// Without entries, every built-in cache
+	all, err := ResolveDependencyCaches(nil, "/home/vscode", "packnplay-cache")
+	if err != nil || len(all) != len(DependencyCaches) {
+		t.Errorf("ResolveDependencyCaches(nil) = %d caches, %v", len(all), err)
+	}
+
+	if _, err := ResolveDependencyCaches([]string{"composer"}, "/home/vscode", "packnplay-cache"); err == nil || !strings.Contains(err.Error(), "npm") {
+		t.Errorf("ResolveDependencyCaches() of an unknown cache error = %v, want one listing the built-ins", err)
+	}
+}
+
+func TestDependencyVolumePrefix(t *testing.T) {
+	if got := DependencyVolumePrefix(true, "/src/app"); got != "packnplay-cache" {
+		t.Errorf("DependencyVolumePrefix(global) = %q", got)
+	}
+	project := DependencyVolumePrefix(false, "/src/app")
+	if !strings.HasPrefix(project, "packnplay-cache-app-") || project == DependencyVolumePrefix(false, "/work/app") {
+		t.Errorf("DependencyVolumePrefix(project) = %q, want one per project path", project)
+	}
+}
+
+func TestCreatedParents(t *testing.T) {
+	caches := []DependencyCache{
+		{Target: "/home/vscode/.npm"},
+		{Target: "/home/vscode/.cache/go-build"},
+		{Target: "/home/vscode/.cache/pip"},
+		{Target: "/home/vscode/go/pkg/mod"},
+		{Target: "/var/cache/ccache"},
+	}
+	want := []string{"/home/vscode/.cache", "/home/vscode/go", "/home/vscode/go/pkg"}
+	if got := CreatedParents(caches, "/home/vscode"); !reflect.DeepEqual(got, want) {
+		t.Errorf("CreatedParents() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,243 @@
+package cache
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContainerPath is where the shared cache is mounted in every container
+const ContainerPath = "/var/cache/packnplay"
+
+// recentWindow protects files written this recently from pruning, since another
+// sandbox may still be downloading them
+const recentWindow = 10 * time.Minute
+
+// toolDirs points common download-heavy tools at the shared cache, relative to ContainerPath
+var toolDirs = map[string]string{
+	"PLAYWRIGHT_BROWSERS_PATH": "ms-playwright",
+	"PUPPETEER_CACHE_DIR":      "puppeteer",
+	"HF_HOME":                  "huggingface",
+	"TORCH_HOME":               "torch",
+}
+
+// HostDir returns the host directory backing the shared cache, creating it if needed
+func HostDir() (string, error) {
+	cacheDir := os.Getenv("XDG_CACHE_HOME")
+	if cacheDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		cacheDir = filepath.Join(homeDir, ".cache")
+	}
+
+	sharedDir := filepath.Join(cacheDir, "packnplay", "shared")
+	if err := os.MkdirAll(sharedDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create shared cache directory: %w", err)
+	}
+	return sharedDir, nil
+}
+
+// EnvArgs returns KEY=value pairs, sorted, that direct tools to the shared cache
+// inside the container
+func EnvArgs() []string {
+	args := []string{"PACKNPLAY_SHARED_CACHE=" + ContainerPath}
+	for key, dir := range toolDirs {
+		args = append(args, fmt.Sprintf("%s=%s/%s", key, ContainerPath, dir))
+	}
+	sort.Strings(args)
+	return args
+}
+
+// ParseSize parses sizes like "20GB", "512MB" or a plain byte count
+func ParseSize(size string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+	}
+
+	multiplier := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size '%s' (examples: 20GB, 512MB)", size)
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
+// FormatSize renders a byte count for humans, e.g. "1.5 GB"
+func FormatSize(bytes int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	value := float64(bytes)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
+
+// cacheEntry is something the cache holds that is only useful whole, so it is pruned
+// whole: a top-level file or directory, or one inside a tool's directory (a browser
+// build, a model)
+type cacheEntry struct {
+	path     string
+	size     int64
+	lastUsed time.Time // latest access or modification of any file in it
+}
+
+// listEntries returns the entries in dir
+func listEntries(dir string) ([]cacheEntry, error) {
+	toolDir := make(map[string]bool, len(toolDirs))
+	for _, name := range toolDirs {
+		toolDir[name] = true
+	}
+
+	children, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan shared cache: %w", err)
+	}
+	var paths []string
+	for _, child := range children {
+		path := filepath.Join(dir, child.Name())
+		if !child.IsDir() || !toolDir[child.Name()] {
+			paths = append(paths, path)
+			continue
+		}
+		inner, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan shared cache: %w", err)
+		}
+		for _, entry := range inner {
+			paths = append(paths, filepath.Join(path, entry.Name()))
+		}
+	}
+
+	entries := make([]cacheEntry, 0, len(paths))
+	for _, path := range paths {
+		entry, err := statEntry(path)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// statEntry adds up the files under path and finds when any of them was last used
+func statEntry(path string) (cacheEntry, error) {
+	entry := cacheEntry{path: path}
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil // removed while walking
+			}
+			return err
+		}
+		// Directories change whenever they are listed or filled, so only files tell
+		// when an entry was used
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil // removed while walking
+		}
+		used := info.ModTime()
+		if accessed := accessTime(info); accessed.After(used) {
+			used = accessed
+		}
+		if used.After(entry.lastUsed) {
+			entry.lastUsed = used
+		}
+		if d.Type().IsRegular() {
+			entry.size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return entry, fmt.Errorf("failed to scan shared cache: %w", err)
+	}
+	// Without any files, an entry counts from when it was made
+	if entry.lastUsed.IsZero() {
+		if info, err := os.Stat(path); err == nil {
+			entry.lastUsed = info.ModTime()
+		}
+	}
+	return entry, nil
+}
+
+// Usage returns the total size of the files in dir
+func Usage(dir string) (int64, error) {
+	entries, err := listEntries(dir)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, e := range entries {
+		total += e.size
+	}
+	return total, nil
+}
+
+// PruneResult summarizes a prune
+type PruneResult struct {
+	Removed int   // entries deleted
+	Freed   int64 // bytes freed
+	Size    int64 // cache size after pruning
+}
+
+// Prune deletes the least recently used entries in dir until it fits in maxBytes. An
+// entry counts as used when any file in it was read or written. Entries used in the last
+// few minutes are kept, so the result may stay over the cap.
+func Prune(dir string, maxBytes int64, now time.Time) (*PruneResult, error) {
+	entries, err := listEntries(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PruneResult{}
+	for _, e := range entries {
+		result.Size += e.size
+	}
+	if result.Size <= maxBytes {
+		return result, nil
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].lastUsed.Before(entries[j].lastUsed) })
+	for _, e := range entries {
+		if result.Size <= maxBytes {
+			break
+		}
+		if now.Sub(e.lastUsed) < recentWindow {
+			continue
+		}
+		if err := os.RemoveAll(e.path); err != nil {
+			continue
+		}
+		result.Removed++
+		result.Freed += e.size
+		result.Size -= e.size
+	}
+	return result, nil
+}
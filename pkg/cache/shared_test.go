@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{"20GB", 20 << 30, false},
+		{"512mb", 512 << 20, false},
+		{"1.5G", 3 << 29, false},
+		{"100", 100, false},
+		{"0", 0, false},
+		{"lots", 0, true},
+		{"-1GB", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSize(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSize(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSize(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	if got := FormatSize(512); got != "512 B" {
+		t.Errorf("FormatSize(512) = %q", got)
+	}
+	if got := FormatSize(3 << 29); got != "1.5 GB" {
+		t.Errorf("FormatSize(1.5GB) = %q", got)
+	}
+}
+
+func TestHostDir(t *testing.T) {
+	cacheHome := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+
+	dir, err := HostDir()
+	if err != nil {
+		t.Fatalf("HostDir() error = %v", err)
+	}
+	if dir != filepath.Join(cacheHome, "packnplay", "shared") {
+		t.Errorf("HostDir() = %s", dir)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("HostDir() should create the directory: %v", err)
+	}
+}
+
+func TestEnvArgs(t *testing.T) {
+	args := EnvArgs()
+	joined := strings.Join(args, "\n")
+	for _, want := range []string{
+		"PACKNPLAY_SHARED_CACHE=/var/cache/packnplay",
+		"PLAYWRIGHT_BROWSERS_PATH=/var/cache/packnplay/ms-playwright",
+		"HF_HOME=/var/cache/packnplay/huggingface",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("EnvArgs() missing %s, got %v", want, args)
+		}
+	}
+	for i := 1; i < len(args); i++ {
+		if args[i-1] > args[i] {
+			t.Errorf("EnvArgs() not sorted: %v", args)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	writeFile := func(rel string, size int, modified, accessed time.Duration) string {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-accessed), now.Add(-modified)); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	oldest := writeFile("ms-playwright/chromium-1100/chrome", 300, 72*time.Hour, 72*time.Hour)
+	oldestPart := writeFile("ms-playwright/chromium-1100/lib.so", 100, 72*time.Hour, 72*time.Hour)
+	older := writeFile("huggingface/model.bin", 300, 48*time.Hour, 48*time.Hour)
+	recent := writeFile("huggingface/new.bin", 200, 24*time.Hour, 24*time.Hour)
+	// Downloaded long ago but read by every run: still in use
+	reused := writeFile("ms-playwright/firefox-900/firefox", 100, 96*time.Hour, time.Hour)
+	inFlight := writeFile("torch/partial.bin", 500, time.Minute, time.Minute)
+
+	// Under the cap: nothing happens
+	result, err := Prune(dir, 10000, now)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if result.Removed != 0 || result.Size != 1500 {
+		t.Errorf("Prune() under cap = %+v, want no removals and size 1500", result)
+	}
+
+	// Over the cap: least recently used entries go first, whole
+	result, err = Prune(dir, 800, now)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if result.Removed != 2 || result.Freed != 700 || result.Size != 800 {
+		t.Errorf("Prune() = %+v, want 2 entries / 700 bytes removed", result)
+	}
+	for _, path := range []string{oldest, oldestPart, older} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s should have been pruned", path)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ms-playwright", "chromium-1100")); !os.IsNotExist(err) {
+		t.Error("a pruned entry should be removed whole")
+	}
+	if _, err := os.Stat(reused); err != nil {
+		t.Error("an old download that is still read should be kept")
+	}
+
+	// Entries being written right now are never pruned
+	result, err = Prune(dir, 0, now)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	for _, path := range []string{recent, reused} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s past the protection window should be pruned", path)
+		}
+	}
+	if _, err := os.Stat(inFlight); err != nil {
+		t.Error("in-flight file should be kept")
+	}
+	if result.Size != 500 {
+		t.Errorf("Size = %d, want 500 (in-flight file kept)", result.Size)
+	}
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// DefaultAutoCommitMessage is the commit message template used when auto_commit_message
+// isn't set
+const DefaultAutoCommitMessage = "packnplay: {{.Command}}\n\nCommitted by packnplay --auto-commit at {{.Time}} (exit status {{.ExitCode}})."
+
+// AutoCommitData is what an auto_commit_message template can refer to
+type AutoCommitData struct {
+	Command  string // the command that ran, space-separated
+	Time     string // when the command exited, RFC 3339
+	ExitCode int    // the command's exit status
+	Branch   string // the branch being committed to
+}
+
+// ParseAutoCommitMessage parses an auto_commit_message template, or the default one when
+// tmpl is empty
+func ParseAutoCommitMessage(tmpl string) (*template.Template, error) {
+	if tmpl == "" {
+		tmpl = DefaultAutoCommitMessage
+	}
+	t, err := template.New("auto_commit_message").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auto_commit_message: %w", err)
+	}
+	// Catch references to fields that don't exist now rather than after a long run
+	if err := t.Execute(&strings.Builder{}, AutoCommitData{}); err != nil {
+		return nil, fmt.Errorf("invalid auto_commit_message: %w", err)
+	}
+	return t, nil
+}
+
+// AutoCommitMessage renders the commit message for data from tmpl (the default when empty)
+func AutoCommitMessage(tmpl string, data AutoCommitData) (string, error) {
+	t, err := ParseAutoCommitMessage(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("invalid auto_commit_message: %w", err)
+	}
+	return b.String(), nil
+}
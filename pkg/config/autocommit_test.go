@@ -0,0 +1,54 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAutoCommitMessage(t *testing.T) {
+	data := AutoCommitData{Command: "claude -p fix the tests", Time: "2026-10-16T09:30:00Z", ExitCode: 0, Branch: "feature"}
+
+	tests := []struct {
+		name    string
+		tmpl    string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "default",
+			want: "packnplay: claude -p fix the tests\n\nCommitted by packnplay --auto-commit at 2026-10-16T09:30:00Z (exit status 0).",
+		},
+		{
+			name: "custom",
+			tmpl: "wip({{.Branch}}): {{.Command}}",
+			want: "wip(feature): claude -p fix the tests",
+		},
+		{
+			name:    "unknown field",
+			tmpl:    "{{.Prompt}}",
+			wantErr: "invalid auto_commit_message",
+		},
+		{
+			name:    "syntax error",
+			tmpl:    "{{.Command",
+			wantErr: "invalid auto_commit_message",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AutoCommitMessage(tt.tmpl, data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("AutoCommitMessage() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AutoCommitMessage() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("AutoCommitMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
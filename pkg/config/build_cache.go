@@ -0,0 +1,9 @@
+package config
+
+// BuildCacheConfig shares the layer cache of devcontainer image builds through a
+// registry, so a fresh machine or CI runner reuses a team's earlier builds instead of
+// starting from scratch. Values are passed to docker build as they are.
+type BuildCacheConfig struct {
+	From []string `json:"from,omitempty"` // --cache-from sources, e.g. "type=registry,ref=ghcr.io/team/app:buildcache"
+	To   string   `json:"to,omitempty"`   // --cache-to destination, e.g. "type=registry,ref=ghcr.io/team/app:buildcache,mode=max"
+}
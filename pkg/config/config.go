@@ -6,22 +6,118 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
-	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/obra/packnplay/pkg/registry"
+	"github.com/obra/packnplay/pkg/ui"
 )
 
 // Config represents packnplay's configuration
 type Config struct {
-	ContainerRuntime   string                   `json:"container_runtime"` // docker, podman, or container
-	DefaultImage       string                   `json:"default_image"`     // deprecated: use DefaultContainer.Image
-	DefaultCredentials Credentials              `json:"default_credentials"`
-	DefaultEnvVars     []string                 `json:"default_env_vars"` // API keys to always proxy
-	EnvConfigs         map[string]EnvConfig     `json:"env_configs"`
-	DefaultContainer   DefaultContainerConfig   `json:"default_container"`
+	ContainerRuntime     string                              `json:"container_runtime"`        // docker, podman, or container
+	DockerContext        string                              `json:"docker_context,omitempty"` // docker context to use when DOCKER_HOST and DOCKER_CONTEXT aren't set
+	DefaultImage         string                              `json:"default_image"`            // deprecated: use DefaultContainer.Image
+	DefaultCredentials   Credentials                         `json:"default_credentials"`
+	DefaultEnvVars       []string                            `json:"default_env_vars"` // API keys to always proxy
+	EnvConfigs           map[string]EnvConfig                `json:"env_configs"`
+	DefaultContainer     DefaultContainerConfig              `json:"default_container"`
+	ClaudeSync           string                              `json:"claude_sync,omitempty"`            // full, copy, or selective (default: full)
+	ClaudeJSONSync       string                              `json:"claude_json_sync,omitempty"`       // off, host, or container (conflict winner)
+	MCPServers           map[string]MCPServerMapping         `json:"mcp_servers,omitempty"`            // rewrites for host MCP servers, "*" matches all
+	CredentialProviders  map[string]CredentialProviderConfig `json:"credential_providers,omitempty"`   // user-defined credential helper scripts
+	GroupAdd             []string                            `json:"group_add,omitempty"`              // supplemental groups for the container user (e.g. video)
+	Labels               map[string]string                   `json:"labels,omitempty"`                 // extra labels on every container (e.g. team, cost-center)
+	SharedCache          SharedCacheConfig                   `json:"shared_cache,omitzero"`            // cache directory shared by all containers
+	DependencyCaches     DependencyCachesConfig              `json:"dependency_caches,omitzero"`       // named volumes over package manager caches (~/.npm, ~/.cargo/registry)
+	Ulimits              []string                            `json:"ulimits,omitempty"`                // docker --ulimit values, e.g. nofile=65536:65536
+	DefaultResources     ResourcesConfig                     `json:"default_resources,omitzero"`       // CPU, memory, swap and process limits for every container
+	SkipPreflight        bool                                `json:"skip_preflight,omitempty"`         // don't warn about host limits (inotify) before starting
+	Timeout              string                              `json:"timeout,omitempty"`                // default limit on how long the command may run, e.g. "2h"
+	StopOnTimeout        bool                                `json:"stop_on_timeout,omitempty"`        // also stop the container when the timeout is hit
+	Summary              string                              `json:"summary,omitempty"`                // off, short, or full: what to print when a managed run exits (default: short)
+	Network              NetworkConfig                       `json:"network,omitzero"`                 // open, none, or allowlist egress for every container
+	Docker               DockerConfig                        `json:"docker,omitzero"`                  // docker access for every container: off, socket, or dind
+	BuildCache           BuildCacheConfig                    `json:"build_cache,omitzero"`             // registry cache for devcontainer image builds
+	AutoCommit           bool                                `json:"auto_commit,omitempty"`            // commit worktree changes when a run's command exits
+	AutoCommitMessage    string                              `json:"auto_commit_message,omitempty"`    // text/template for auto-commit messages
+	TrustedImages        []string                            `json:"trusted_images,omitempty"`         // image patterns trusted with secret credentials (ssh, gh, gpg, npm, aws, gcp, azure, kube)
+	UntrustedImagePolicy string                              `json:"untrusted_image_policy,omitempty"` // warn, prompt, or refuse for other images (default: prompt on a terminal, else warn)
+	StrictCreds          bool                                `json:"strict_creds,omitempty"`           // abort startup when a requested credential can't be provided
+	SELinuxLabelDisable  bool                                `json:"selinux_label_disable,omitempty"`  // turn off SELinux label separation for mounts packnplay won't relabel
+	ScopedCredentials    ScopedCredentialsConfig             `json:"scoped_credentials,omitzero"`      // short-lived gh and AWS tokens minted per run (--scoped-creds)
+	TrustedWorkspaces    []string                            `json:"trusted_workspaces,omitempty"`     // repo roots whose .packnplay.json may reach past the sandbox
+	LogCapture           LogCaptureConfig                    `json:"log_capture,omitzero"`             // save session output to rotated log files on the host
+	Notifications        NotificationsConfig                 `json:"notifications,omitzero"`           // quiet settings and per-image frequencies for new version notices
+}
+
+// CredentialProviderConfig defines a user credential helper. The command runs on the host
+// and must print {"env": {"KEY": "value"}}; the env vars are passed to the container.
+type CredentialProviderConfig struct {
+	Command    string `json:"command"`
+	RefreshTTL string `json:"refresh_ttl,omitempty"` // how long the credentials stay valid, e.g. "1h"
+	Enabled    bool   `json:"enabled,omitempty"`     // use on every run, not only with --cred-provider
+}
+
+// builtinCredentialNames are the credentials packnplay provides itself. A provider with
+// one of these names would be mistaken for the built-in one when credentials are
+// refreshed and when images are checked for trust.
+var builtinCredentialNames = []string{"git", "ssh", "ssh-agent", "gh", "gpg", "gpg-agent", "npm", "aws", "gcp", "azure", "kube"}
+
+// ValidateCredentialProviderName returns an error when name is taken by a built-in credential
+func ValidateCredentialProviderName(name string) error {
+	if slices.Contains(builtinCredentialNames, name) {
+		return fmt.Errorf("credential provider '%s' has the name of a built-in credential, rename it", name)
+	}
+	return nil
+}
+
+// MCPServerMapping rewrites an MCP server definition in the container's .claude.json copy.
+// Host-side commands and localhost URLs usually don't work inside the container.
+type MCPServerMapping struct {
+	Disabled         bool              `json:"disabled,omitempty"`           // drop the server from the container copy
+	Command          string            `json:"command,omitempty"`            // replacement stdio command inside the container
+	Args             []string          `json:"args,omitempty"`               // replacement stdio args
+	Env              map[string]string `json:"env,omitempty"`                // extra env vars for the server
+	URL              string            `json:"url,omitempty"`                // replacement http/sse URL
+	ForwardHostPorts bool              `json:"forward_host_ports,omitempty"` // point localhost URLs at the host gateway
+}
+
+// Claude settings sync modes control how ~/.claude is exposed to containers
+const (
+	ClaudeSyncFull      = "full"      // mount ~/.claude read-write (host-global changes allowed)
+	ClaudeSyncCopy      = "copy"      // copy ~/.claude into a per-container directory on start
+	ClaudeSyncSelective = "selective" // settings read-only, everything else per-container
+)
+
+// .claude.json sync strategies. When enabled, .claude.json is re-synced on every
+// reconnect/exec and the strategy decides which side wins when both changed.
+const (
+	ClaudeJSONSyncOff       = "off"       // copy once at container start (default)
+	ClaudeJSONSyncHost      = "host"      // sync changes, host wins conflicts
+	ClaudeJSONSyncContainer = "container" // sync changes, container wins conflicts
+)
+
+// ValidClaudeSyncMode reports whether mode is a known Claude sync mode
+func ValidClaudeSyncMode(mode string) bool {
+	switch mode {
+	case "", ClaudeSyncFull, ClaudeSyncCopy, ClaudeSyncSelective:
+		return true
+	}
+	return false
+}
+
+// ValidClaudeJSONSyncMode reports whether mode is a known .claude.json sync strategy
+func ValidClaudeJSONSyncMode(mode string) bool {
+	switch mode {
+	case "", ClaudeJSONSyncOff, ClaudeJSONSyncHost, ClaudeJSONSyncContainer:
+		return true
+	}
+	return false
 }
 
 // DefaultContainerConfig configures the default container and update behavior
@@ -30,6 +126,44 @@ type DefaultContainerConfig struct {
 	CheckForUpdates     bool   `json:"check_for_updates"`     // whether to check for new versions
 	AutoPullUpdates     bool   `json:"auto_pull_updates"`     // whether to auto-pull new versions
 	CheckFrequencyHours int    `json:"check_frequency_hours"` // how often to check for updates
+	Variant             string `json:"variant,omitempty"`     // default image variant: full, slim, python, go
+}
+
+// SharedCacheConfig configures the cache shared across projects for large downloads
+type SharedCacheConfig struct {
+	Enabled bool   `json:"enabled"`            // mount the shared cache into every container
+	MaxSize string `json:"max_size,omitempty"` // prune least recently used files above this size, e.g. "20GB"
+}
+
+// DefaultImageRepository is where the packnplay default image and its variants are published
+const DefaultImageRepository = "ghcr.io/obra/packnplay-default"
+
+// DefaultImageVariants lists the published variants of the default image
+var DefaultImageVariants = []string{"full", "slim", "python", "go"}
+
+// ImageForVariant returns the default image reference for a variant ("full" is the latest tag)
+func ImageForVariant(variant string) string {
+	if variant == "" || variant == "full" {
+		return DefaultImageRepository + ":latest"
+	}
+	return DefaultImageRepository + ":" + variant
+}
+
+// ValidImageVariant reports whether variant is a known default image variant
+func ValidImageVariant(variant string) bool {
+	for _, v := range DefaultImageVariants {
+		if v == variant {
+			return true
+		}
+	}
+	return variant == ""
+}
+
+// variantSelectsImage reports whether a variant may pick the tag for image: when it is
+// unset, or the default image without a tag or at latest, which is what packnplay writes.
+// A custom image, another tag or a digest was pinned on purpose and is kept.
+func variantSelectsImage(image string) bool {
+	return image == "" || image == DefaultImageRepository || image == DefaultImageRepository+":latest"
 }
 
 // EnvConfig defines environment variables for different setups (API configs, etc.)
@@ -41,16 +175,26 @@ type EnvConfig struct {
 
 // Credentials specifies which credentials to mount
 type Credentials struct {
-	Git bool `json:"git"` // ~/.gitconfig
-	SSH bool `json:"ssh"` // ~/.ssh keys
-	GH  bool `json:"gh"`  // GitHub CLI credentials
-	GPG bool `json:"gpg"` // GPG keys for commit signing
-	NPM bool `json:"npm"` // npm credentials
-	AWS bool `json:"aws"` // AWS credentials
+	Git   bool `json:"git"`   // ~/.gitconfig
+	SSH   bool `json:"ssh"`   // ~/.ssh keys
+	GH    bool `json:"gh"`    // GitHub CLI credentials
+	GPG   bool `json:"gpg"`   // GPG keys for commit signing
+	NPM   bool `json:"npm"`   // npm credentials
+	AWS   bool `json:"aws"`   // AWS credentials
+	GCP   bool `json:"gcp"`   // Google Cloud credentials
+	Azure bool `json:"azure"` // Azure CLI credentials
+	Kube  bool `json:"kube"`  // Kubernetes kubeconfig
+
+	SSHAgent bool `json:"ssh_agent"` // Forward the host ssh-agent instead of mounting keys
+	GPGAgent bool `json:"gpg_agent"` // Forward the host gpg-agent instead of mounting ~/.gnupg
 }
 
 // GetDefaultImage returns the configured default image or fallback
 func (c *Config) GetDefaultImage() string {
+	// A variant selects a tag of the default image unless an image is pinned
+	if c.DefaultContainer.Variant != "" && variantSelectsImage(c.DefaultContainer.Image) {
+		return ImageForVariant(c.DefaultContainer.Variant)
+	}
 	if c.DefaultContainer.Image != "" {
 		return c.DefaultContainer.Image
 	}
@@ -76,6 +220,17 @@ func GetDefaultContainerConfig() DefaultContainerConfig {
 type VersionTrackingData struct {
 	LastCheck     time.Time                      `json:"last_check"`
 	Notifications map[string]VersionNotification `json:"notifications"`
+	Pulls         map[string]ImagePull           `json:"pulls,omitempty"`
+}
+
+// ImagePull records which platform image refresh-container pulled for a tag, since the
+// local image store only keeps the digest of the multi-platform index it came from
+type ImagePull struct {
+	Platform    string    `json:"platform"`
+	Digest      string    `json:"digest"`       // the platform's manifest
+	IndexDigest string    `json:"index_digest"` // what the tag pointed at
+	ImageID     string    `json:"image_id"`     // the local image it became
+	PulledAt    time.Time `json:"pulled_at"`
 }
 
 // VersionNotification tracks when we notified about a specific image version
@@ -95,21 +250,68 @@ func GetVersionTrackingPath() string {
 	return filepath.Join(configHome, "packnplay", "version-tracking.json")
 }
 
-// SaveVersionTracking saves notification history to disk
+// SaveVersionTracking saves notification history to disk, replacing what is there.
+// Use UpdateVersionTracking to change it when other packnplay runs may be doing the same.
 func SaveVersionTracking(data *VersionTrackingData, filePath string) error {
-	// Ensure directory exists
-	dir := filepath.Dir(filePath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
-	}
-
-	// Write data
 	jsonData, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal tracking data: %w", err)
 	}
 
-	return os.WriteFile(filePath, jsonData, 0644)
+	return WriteFileAtomic(filePath, jsonData, 0644)
+}
+
+// UpdateVersionTracking applies update to the notification history on disk while holding
+// its lock. update gets what is on disk at that moment, not what was loaded earlier, so
+// the notifications and pulls other packnplay runs recorded in the meantime are kept.
+func UpdateVersionTracking(filePath string, update func(*VersionTrackingData)) error {
+	return rewriteVersionTracking(filePath, func(tracking *VersionTrackingData) {
+		lastCheck := tracking.LastCheck
+		update(tracking)
+		// Never move the last check back when a slower run finishes after a newer one
+		if lastCheck.After(tracking.LastCheck) {
+			tracking.LastCheck = lastCheck
+		}
+	})
+}
+
+// rewriteVersionTracking applies update to the notification history on disk while
+// holding its lock, saving whatever update leaves
+func rewriteVersionTracking(filePath string, update func(*VersionTrackingData)) error {
+	return withFileLock(filePath, func() error {
+		tracking, err := LoadVersionTracking(filePath)
+		if err != nil {
+			// A file another version left unreadable is rewritten rather than kept stuck
+			tracking = &VersionTrackingData{
+				Notifications: make(map[string]VersionNotification),
+				Pulls:         make(map[string]ImagePull),
+			}
+		}
+		update(tracking)
+		return SaveVersionTracking(tracking, filePath)
+	})
+}
+
+// ResetVersionNotifications forgets the notifications about image, or about every image
+// when it is empty, and when the last update check was, so the next run checks again and
+// notifies about versions it already did. Records of refresh-container pulls are kept.
+// It returns how many notifications were forgotten.
+func ResetVersionNotifications(filePath, image string) (int, error) {
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return 0, nil
+	}
+	cleared := 0
+	// Clearing the last check moves it back, which UpdateVersionTracking wouldn't allow
+	err := rewriteVersionTracking(filePath, func(tracking *VersionTrackingData) {
+		for name := range tracking.Notifications {
+			if image == "" || name == image {
+				delete(tracking.Notifications, name)
+				cleared++
+			}
+		}
+		tracking.LastCheck = time.Time{}
+	})
+	return cleared, err
 }
 
 // LoadVersionTracking loads notification history from disk
@@ -119,6 +321,7 @@ func LoadVersionTracking(filePath string) (*VersionTrackingData, error) {
 		// Return empty tracking data
 		return &VersionTrackingData{
 			Notifications: make(map[string]VersionNotification),
+			Pulls:         make(map[string]ImagePull),
 		}, nil
 	}
 
@@ -136,6 +339,9 @@ func LoadVersionTracking(filePath string) (*VersionTrackingData, error) {
 	if tracking.Notifications == nil {
 		tracking.Notifications = make(map[string]VersionNotification)
 	}
+	if tracking.Pulls == nil {
+		tracking.Pulls = make(map[string]ImagePull)
+	}
 
 	return &tracking, nil
 }
@@ -167,21 +373,111 @@ func ShouldCheckForUpdates(config DefaultContainerConfig, lastCheck time.Time) b
 	return shouldCheckForUpdates(config, lastCheck)
 }
 
-// ConfigUpdates represents partial config updates that preserve unshown settings
+// ConfigUpdates represents partial config updates that preserve unshown settings.
+// Every field is a pointer so a UI that shows only some settings can't clobber the rest.
 type ConfigUpdates struct {
-	ContainerRuntime   *string      `json:"container_runtime,omitempty"`
-	DefaultCredentials *Credentials `json:"default_credentials,omitempty"`
-	DefaultContainer   *DefaultContainerConfig `json:"default_container,omitempty"`
+	ContainerRuntime   *string                  `json:"container_runtime,omitempty"`
+	DefaultCredentials *CredentialOverrides     `json:"default_credentials,omitempty"`
+	DefaultContainer   *DefaultContainerUpdates `json:"default_container,omitempty"`
+	EnvConfigs         *map[string]EnvConfig    `json:"env_configs,omitempty"`
+}
+
+// DefaultContainerUpdates updates individual default container settings; nil means keep
+type DefaultContainerUpdates struct {
+	Image               *string `json:"image,omitempty"`
+	CheckForUpdates     *bool   `json:"check_for_updates,omitempty"`
+	AutoPullUpdates     *bool   `json:"auto_pull_updates,omitempty"`
+	CheckFrequencyHours *int    `json:"check_frequency_hours,omitempty"`
+	Variant             *string `json:"variant,omitempty"`
 }
 
+// Apply returns container with the updates applied
+func (u *DefaultContainerUpdates) Apply(container DefaultContainerConfig) DefaultContainerConfig {
+	if u == nil {
+		return container
+	}
+	if u.Image != nil {
+		container.Image = *u.Image
+	}
+	if u.CheckForUpdates != nil {
+		container.CheckForUpdates = *u.CheckForUpdates
+	}
+	if u.AutoPullUpdates != nil {
+		container.AutoPullUpdates = *u.AutoPullUpdates
+	}
+	if u.CheckFrequencyHours != nil {
+		container.CheckFrequencyHours = *u.CheckFrequencyHours
+	}
+	if u.Variant != nil {
+		container.Variant = *u.Variant
+	}
+	return container
+}
+
+// setFieldUpdate records a single UI field value in updates, leaving other fields untouched
+func setFieldUpdate(updates *ConfigUpdates, name string, value interface{}) {
+	creds := func() *CredentialOverrides {
+		if updates.DefaultCredentials == nil {
+			updates.DefaultCredentials = &CredentialOverrides{}
+		}
+		return updates.DefaultCredentials
+	}
+	container := func() *DefaultContainerUpdates {
+		if updates.DefaultContainer == nil {
+			updates.DefaultContainer = &DefaultContainerUpdates{}
+		}
+		return updates.DefaultContainer
+	}
+
+	switch name {
+	case "runtime":
+		updates.ContainerRuntime = stringPtr(value.(string))
+	case "ssh":
+		creds().SSH = boolPtr(value.(bool))
+	case "ssh-agent":
+		creds().SSHAgent = boolPtr(value.(bool))
+	case "github":
+		creds().GH = boolPtr(value.(bool))
+	case "gpg":
+		creds().GPG = boolPtr(value.(bool))
+	case "gpg-agent":
+		creds().GPGAgent = boolPtr(value.(bool))
+	case "npm":
+		creds().NPM = boolPtr(value.(bool))
+	case "aws":
+		creds().AWS = boolPtr(value.(bool))
+	case "gcp":
+		creds().GCP = boolPtr(value.(bool))
+	case "azure":
+		creds().Azure = boolPtr(value.(bool))
+	case "kube":
+		creds().Kube = boolPtr(value.(bool))
+	case "container-image":
+		container().Image = stringPtr(value.(string))
+	case "check-updates":
+		container().CheckForUpdates = boolPtr(value.(bool))
+	case "auto-pull":
+		container().AutoPullUpdates = boolPtr(value.(bool))
+	case "check-frequency":
+		hours := parseFrequencyFromDisplay(value.(string))
+		container().CheckFrequencyHours = &hours
+	case "image-variant":
+		container().Variant = stringPtr(value.(string))
+	}
+}
+
+func boolPtr(v bool) *bool       { return &v }
+func stringPtr(v string) *string { return &v }
+
 // LoadExistingOrEmpty loads config from file or returns empty config if file doesn't exist
 func LoadExistingOrEmpty(configPath string) (*Config, error) {
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		// Return empty config with defaults
+		// Return empty config with defaults (.gitconfig is always copied unless disabled)
 		return &Config{
-			DefaultContainer: GetDefaultContainerConfig(),
-			DefaultEnvVars:   []string{},
-			EnvConfigs:       make(map[string]EnvConfig),
+			DefaultCredentials: Credentials{Git: true},
+			DefaultContainer:   GetDefaultContainerConfig(),
+			DefaultEnvVars:     []string{},
+			EnvConfigs:         make(map[string]EnvConfig),
 		}, nil
 	}
 
@@ -190,7 +486,7 @@ func LoadExistingOrEmpty(configPath string) (*Config, error) {
 
 // LoadConfigFromFile loads config from specified file
 func LoadConfigFromFile(configPath string) (*Config, error) {
-	data, err := os.ReadFile(configPath)
+	data, err := ReadConfigFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
@@ -211,21 +507,24 @@ func UpdateConfigSafely(configPath string, updates ConfigUpdates) error {
 		return fmt.Errorf("failed to load existing config: %w", err)
 	}
 
-	// Apply updates only to specified fields
+	applyConfigUpdates(cfg, updates)
+
+	// Save updated config
+	return SaveConfig(cfg, configPath)
+}
+
+// applyConfigUpdates applies only the specified fields of updates to cfg
+func applyConfigUpdates(cfg *Config, updates ConfigUpdates) {
 	if updates.ContainerRuntime != nil {
 		cfg.ContainerRuntime = *updates.ContainerRuntime
 	}
 
-	if updates.DefaultCredentials != nil {
-		cfg.DefaultCredentials = *updates.DefaultCredentials
-	}
+	cfg.DefaultCredentials = updates.DefaultCredentials.Apply(cfg.DefaultCredentials)
+	cfg.DefaultContainer = updates.DefaultContainer.Apply(cfg.DefaultContainer)
 
-	if updates.DefaultContainer != nil {
-		cfg.DefaultContainer = *updates.DefaultContainer
+	if updates.EnvConfigs != nil {
+		cfg.EnvConfigs = *updates.EnvConfigs
 	}
-
-	// Save updated config
-	return SaveConfig(cfg, configPath)
 }
 
 // applyCredentialUpdates applies credential updates to config, preserving other settings
@@ -244,13 +543,13 @@ func SaveConfig(cfg *Config, configPath string) error {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	// Marshal and save
+	// Marshal and save in the file's format
 	data, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	return os.WriteFile(configPath, data, 0644)
+	return WriteConfigFile(configPath, data)
 }
 
 // TabbedConfigModel represents a tabbed configuration interface
@@ -318,6 +617,13 @@ func createTabbedConfig(existing *Config) *TabbedConfigModel {
 					description: "Mount ~/.ssh (read-only) for SSH authentication",
 					value:       existing.DefaultCredentials.SSH,
 				},
+				{
+					name:        "ssh-agent",
+					fieldType:   "toggle",
+					title:       "SSH agent",
+					description: "Forward the host ssh-agent so keys never enter the container",
+					value:       existing.DefaultCredentials.SSHAgent,
+				},
 				{
 					name:        "github",
 					fieldType:   "toggle",
@@ -332,6 +638,13 @@ func createTabbedConfig(existing *Config) *TabbedConfigModel {
 					description: "Mount ~/.gnupg (read-only) for commit signing",
 					value:       existing.DefaultCredentials.GPG,
 				},
+				{
+					name:        "gpg-agent",
+					fieldType:   "toggle",
+					title:       "GPG agent",
+					description: "Forward the host gpg-agent so signing works without the secret keys",
+					value:       existing.DefaultCredentials.GPGAgent,
+				},
 				{
 					name:        "npm",
 					fieldType:   "toggle",
@@ -346,6 +659,27 @@ func createTabbedConfig(existing *Config) *TabbedConfigModel {
 					description: "Mount ~/.aws and AWS environment variables",
 					value:       existing.DefaultCredentials.AWS,
 				},
+				{
+					name:        "gcp",
+					fieldType:   "toggle",
+					title:       "Google Cloud credentials",
+					description: "Mount ~/.config/gcloud and Google Cloud environment variables",
+					value:       existing.DefaultCredentials.GCP,
+				},
+				{
+					name:        "azure",
+					fieldType:   "toggle",
+					title:       "Azure credentials",
+					description: "Mount ~/.azure and Azure environment variables",
+					value:       existing.DefaultCredentials.Azure,
+				},
+				{
+					name:        "kube",
+					fieldType:   "toggle",
+					title:       "Kubernetes config",
+					description: "Mount ~/.kube/config read-only, resolving exec auth plugins on the host",
+					value:       existing.DefaultCredentials.Kube,
+				},
 			},
 		},
 		{
@@ -481,56 +815,15 @@ func runTabbedConfig(existing *Config, configPath string, verbose bool) error {
 
 // applyTabbedConfigUpdates applies tabbed config changes safely
 func applyTabbedConfigUpdates(model *TabbedConfigModel, configPath string) error {
-	runtime := ""
-	creds := Credentials{Git: true}
-	var containerConfig *DefaultContainerConfig
+	var updates ConfigUpdates
 
 	// Extract values from all tabs
 	for _, tab := range model.tabs {
 		for _, field := range tab.fields {
-			switch field.name {
-			case "runtime":
-				runtime = field.value.(string)
-			case "ssh":
-				creds.SSH = field.value.(bool)
-			case "github":
-				creds.GH = field.value.(bool)
-			case "gpg":
-				creds.GPG = field.value.(bool)
-			case "npm":
-				creds.NPM = field.value.(bool)
-			case "aws":
-				creds.AWS = field.value.(bool)
-			case "container-image":
-				if containerConfig == nil {
-					containerConfig = &DefaultContainerConfig{}
-				}
-				containerConfig.Image = field.value.(string)
-			case "check-updates":
-				if containerConfig == nil {
-					containerConfig = &DefaultContainerConfig{}
-				}
-				containerConfig.CheckForUpdates = field.value.(bool)
-			case "auto-pull":
-				if containerConfig == nil {
-					containerConfig = &DefaultContainerConfig{}
-				}
-				containerConfig.AutoPullUpdates = field.value.(bool)
-			case "check-frequency":
-				if containerConfig == nil {
-					containerConfig = &DefaultContainerConfig{}
-				}
-				containerConfig.CheckFrequencyHours = parseFrequencyFromDisplay(field.value.(string))
-			}
+			setFieldUpdate(&updates, field.name, field.value)
 		}
 	}
 
-	updates := ConfigUpdates{
-		ContainerRuntime:   &runtime,
-		DefaultCredentials: &creds,
-		DefaultContainer:   containerConfig,
-	}
-
 	return UpdateConfigSafely(configPath, updates)
 }
 
@@ -541,10 +834,18 @@ type SettingsModal struct {
 	sections       []SettingsSection
 	currentSection int
 	currentField   int
-	buttonFocused  bool   // Are we focused on buttons (not fields)?
-	currentButton  int    // Which button is focused (0=save, 1=cancel)
-	textInput      textinput.Model // For text field editing
-	textEditing    bool   // Are we in text editing mode?
+	buttonFocused  bool                 // Are we focused on buttons (not fields)?
+	currentButton  int                  // Which button is focused (0=save, 1=cancel)
+	textInput      textinput.Model      // For text field editing
+	textEditing    bool                 // Are we in text editing mode?
+	envConfigs     map[string]EnvConfig // Working copy of env profiles
+	envEditor      *envProfilesEditor   // Non-nil while the env profile editor is open
+	envChanged     bool                 // Whether env profiles were edited
+	title          string               // Header override (e.g. for project settings)
+	projectDir     string               // Set when editing a project config instead of the global one
+	confirming     bool                 // Showing the save-time change summary
+	changes        []ConfigChange       // Changes shown in the save confirmation
+	saveErr        error                // Error computing the change summary
 	saved          bool
 	quitting       bool
 	width          int
@@ -562,11 +863,14 @@ type SettingsSection struct {
 // SettingsField represents a field within a section
 type SettingsField struct {
 	name        string
-	fieldType   string // "select", "toggle"
+	fieldType   string // "select", "toggle", "text", "action"
 	title       string
 	description string
 	value       interface{}
 	options     []string // for select fields
+	err         string   // why the value is invalid, shown under it; blocks saving
+	note        string   // what looking the value up found, shown under it
+	checking    bool     // note is a lookup still in progress
 }
 
 // createSettingsModal creates a new settings modal
@@ -601,6 +905,13 @@ func createSettingsModal(existing *Config) *SettingsModal {
 					description: "Mount ~/.ssh (read-only) for SSH authentication",
 					value:       existing.DefaultCredentials.SSH,
 				},
+				{
+					name:        "ssh-agent",
+					fieldType:   "toggle",
+					title:       "SSH agent",
+					description: "Forward the host ssh-agent so keys never enter the container",
+					value:       existing.DefaultCredentials.SSHAgent,
+				},
 				{
 					name:        "github",
 					fieldType:   "toggle",
@@ -615,6 +926,13 @@ func createSettingsModal(existing *Config) *SettingsModal {
 					description: "Mount ~/.gnupg (read-only) for commit signing",
 					value:       existing.DefaultCredentials.GPG,
 				},
+				{
+					name:        "gpg-agent",
+					fieldType:   "toggle",
+					title:       "GPG agent",
+					description: "Forward the host gpg-agent so signing works without the secret keys",
+					value:       existing.DefaultCredentials.GPGAgent,
+				},
 				{
 					name:        "npm",
 					fieldType:   "toggle",
@@ -629,6 +947,27 @@ func createSettingsModal(existing *Config) *SettingsModal {
 					description: "Mount ~/.aws and AWS environment variables",
 					value:       existing.DefaultCredentials.AWS,
 				},
+				{
+					name:        "gcp",
+					fieldType:   "toggle",
+					title:       "Google Cloud credentials",
+					description: "Mount ~/.config/gcloud and Google Cloud environment variables",
+					value:       existing.DefaultCredentials.GCP,
+				},
+				{
+					name:        "azure",
+					fieldType:   "toggle",
+					title:       "Azure credentials",
+					description: "Mount ~/.azure and Azure environment variables",
+					value:       existing.DefaultCredentials.Azure,
+				},
+				{
+					name:        "kube",
+					fieldType:   "toggle",
+					title:       "Kubernetes config",
+					description: "Mount ~/.kube/config read-only, resolving exec auth plugins on the host",
+					value:       existing.DefaultCredentials.Kube,
+				},
 			},
 		},
 		{
@@ -643,6 +982,14 @@ func createSettingsModal(existing *Config) *SettingsModal {
 					description: "Default container image to use (supports any registry)",
 					value:       getDefaultImageValue(existing),
 				},
+				{
+					name:        "image-variant",
+					fieldType:   "select",
+					title:       "Default image variant",
+					description: "Variant of ghcr.io/obra/packnplay-default (ignored for custom images)",
+					value:       getImageVariantValue(existing),
+					options:     DefaultImageVariants,
+				},
 				{
 					name:        "check-updates",
 					fieldType:   "toggle",
@@ -667,6 +1014,20 @@ func createSettingsModal(existing *Config) *SettingsModal {
 				},
 			},
 		},
+		{
+			name:        "env-configs",
+			title:       "Environment Profiles",
+			description: "Named env var sets selected with --config",
+			fields: []SettingsField{
+				{
+					name:        "env-profiles",
+					fieldType:   "action",
+					title:       "Manage profiles",
+					description: "Add, edit and delete profiles and their env vars",
+					value:       envProfilesSummary(existing.EnvConfigs),
+				},
+			},
+		},
 	}
 
 	// Initialize text input component
@@ -683,6 +1044,7 @@ func createSettingsModal(existing *Config) *SettingsModal {
 		currentButton:  0,
 		textInput:      ti,
 		textEditing:    false,
+		envConfigs:     copyEnvConfigs(existing.EnvConfigs),
 		width:          80,
 		height:         24,
 	}
@@ -759,13 +1121,12 @@ func runSettingsModal(existing *Config, configPath string, verbose bool) error {
 	modal := createSettingsModal(existing)
 	modal.configPath = configPath
 
-	program := tea.NewProgram(modal, tea.WithAltScreen())
-	finalModel, err := program.Run()
+	finalModel, err := runModal(modal)
 	if err != nil {
 		return fmt.Errorf("settings modal failed: %w", err)
 	}
 
-	if finalModel, ok := finalModel.(*SettingsModal); ok && finalModel.saved {
+	if finalModel.saved {
 		return applyModalConfigUpdates(finalModel, configPath)
 	}
 
@@ -774,57 +1135,26 @@ func runSettingsModal(existing *Config, configPath string, verbose bool) error {
 
 // applyModalConfigUpdates applies settings modal changes safely
 func applyModalConfigUpdates(modal *SettingsModal, configPath string) error {
-	runtime := ""
-	creds := Credentials{Git: true}
-	var containerConfig *DefaultContainerConfig
+	return UpdateConfigSafely(configPath, modalConfigUpdates(modal))
+}
 
-	// Extract values from modal sections
+// modalConfigUpdates extracts the config updates represented by the modal's fields
+func modalConfigUpdates(modal *SettingsModal) ConfigUpdates {
+	// Only fields present in the modal are written, so single-section
+	// modals (configure --section) leave the rest of the config alone
+	var updates ConfigUpdates
 	for _, section := range modal.sections {
 		for _, field := range section.fields {
-			switch field.name {
-			case "runtime":
-				runtime = field.value.(string)
-			case "ssh":
-				creds.SSH = field.value.(bool)
-			case "github":
-				creds.GH = field.value.(bool)
-			case "gpg":
-				creds.GPG = field.value.(bool)
-			case "npm":
-				creds.NPM = field.value.(bool)
-			case "aws":
-				creds.AWS = field.value.(bool)
-			case "container-image":
-				if containerConfig == nil {
-					containerConfig = &DefaultContainerConfig{}
-				}
-				containerConfig.Image = field.value.(string)
-			case "check-updates":
-				if containerConfig == nil {
-					containerConfig = &DefaultContainerConfig{}
-				}
-				containerConfig.CheckForUpdates = field.value.(bool)
-			case "auto-pull":
-				if containerConfig == nil {
-					containerConfig = &DefaultContainerConfig{}
-				}
-				containerConfig.AutoPullUpdates = field.value.(bool)
-			case "check-frequency":
-				if containerConfig == nil {
-					containerConfig = &DefaultContainerConfig{}
-				}
-				containerConfig.CheckFrequencyHours = parseFrequencyFromDisplay(field.value.(string))
-			}
+			setFieldUpdate(&updates, field.name, field.value)
 		}
 	}
 
-	updates := ConfigUpdates{
-		ContainerRuntime:   &runtime,
-		DefaultCredentials: &creds,
-		DefaultContainer:   containerConfig,
+	// Only write env profiles when edited so hand-made changes aren't clobbered
+	if modal.envChanged {
+		updates.EnvConfigs = &modal.envConfigs
 	}
 
-	return UpdateConfigSafely(configPath, updates)
+	return updates
 }
 
 // formatFrequencyForDisplay converts hours to display format
@@ -870,31 +1200,19 @@ func (m *SettingsModal) supportsTextEditing() bool {
 
 // extractDefaultContainerUpdates extracts default container updates from modal
 func extractDefaultContainerUpdates(modal *SettingsModal) ConfigUpdates {
-	var containerConfig *DefaultContainerConfig
+	var updates ConfigUpdates
 
 	// Find default container section
 	for _, section := range modal.sections {
 		if section.name == "default-container" {
-			containerConfig = &DefaultContainerConfig{}
 			for _, field := range section.fields {
-				switch field.name {
-				case "container-image":
-					containerConfig.Image = field.value.(string)
-				case "check-updates":
-					containerConfig.CheckForUpdates = field.value.(bool)
-				case "auto-pull":
-					containerConfig.AutoPullUpdates = field.value.(bool)
-				case "check-frequency":
-					containerConfig.CheckFrequencyHours = parseFrequencyFromDisplay(field.value.(string))
-				}
+				setFieldUpdate(&updates, field.name, field.value)
 			}
 			break
 		}
 	}
 
-	return ConfigUpdates{
-		DefaultContainer: containerConfig,
-	}
+	return updates
 }
 
 // getDefaultImageValue gets the image value with fallback to default
@@ -908,17 +1226,81 @@ func getDefaultImageValue(cfg *Config) string {
 	return "ghcr.io/obra/packnplay-default:latest"
 }
 
+// getImageVariantValue gets the configured variant with "full" as the default
+func getImageVariantValue(cfg *Config) string {
+	if cfg.DefaultContainer.Variant != "" {
+		return cfg.DefaultContainer.Variant
+	}
+	return "full"
+}
+
+// imageTagsMsg carries tags fetched from the registry for image suggestions
+type imageTagsMsg struct {
+	tags []string
+}
+
+// fetchImageTags looks up published tags of the default image in the background
+func fetchImageTags() tea.Msg {
+	tags, err := registry.NewClient().ListTags(DefaultImageRepository)
+	if err != nil {
+		return imageTagsMsg{} // Suggestions are best-effort
+	}
+	return imageTagsMsg{tags: tags}
+}
+
+// isImageField reports whether a text field holds a container image reference
+func isImageField(name string) bool {
+	return name == "container-image" || name == "project-image"
+}
+
+// imageSuggestions turns registry tags into full image references for the text input
+func imageSuggestions(tags []string) []string {
+	suggestions := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		suggestions = append(suggestions, DefaultImageRepository+":"+tag)
+	}
+	return suggestions
+}
+
 // Init implements tea.Model for SettingsModal
 func (m *SettingsModal) Init() tea.Cmd {
-	return nil
+	return fetchImageTags
 }
 
 // Update implements tea.Model for SettingsModal
 func (m *SettingsModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// The save confirmation takes over input while shown
+	if m.confirming {
+		return m.updateConfirm(msg)
+	}
+
+	// The env profile editor takes over input while open
+	if m.envEditor != nil {
+		if size, ok := msg.(tea.WindowSizeMsg); ok {
+			m.width = size.Width
+			m.height = size.Height
+		}
+		var cmd tea.Cmd
+		m.envEditor, cmd = m.envEditor.Update(msg)
+		if m.envEditor.done {
+			m.closeEnvEditor()
+		}
+		return m, cmd
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		m.textInput.Width = m.valueWidth()
+
+	case imageTagsMsg:
+		if len(msg.tags) > 0 {
+			m.textInput.SetSuggestions(imageSuggestions(msg.tags))
+		}
+
+	case imageCheckMsg:
+		m.applyImageCheck(msg)
 
 	case tea.KeyMsg:
 		switch msg.String() {
@@ -961,8 +1343,7 @@ func (m *SettingsModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.buttonFocused {
 				// Handle button actions
 				if m.currentButton == 0 { // Save
-					m.saved = true
-					return m, tea.Quit
+					return m.requestSave()
 				} else { // Cancel
 					m.quitting = true
 					return m, tea.Quit
@@ -970,18 +1351,26 @@ func (m *SettingsModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			} else if m.textEditing {
 				// Exit text editing mode and save the value
 				currentField := m.getCurrentField()
+				m.textEditing = false
 				if currentField != nil {
 					currentField.value = m.textInput.Value()
+					if isImageField(currentField.name) {
+						return m, m.validateImageField(currentField)
+					}
 				}
-				m.textEditing = false
 			} else {
 				// Check if current field is text field
 				currentField := m.getCurrentField()
 				if currentField != nil && currentField.fieldType == "text" {
-					// Enter text editing mode
+					// Enter text editing mode (only image fields get registry suggestions)
 					m.textInput.SetValue(currentField.value.(string))
+					m.textInput.ShowSuggestions = isImageField(currentField.name)
 					m.textInput.Focus()
 					m.textEditing = true
+				} else if currentField != nil && currentField.name == "env-profiles" {
+					m.envEditor = newEnvProfilesEditor(m.envConfigs)
+					m.envEditor.width = m.width
+					m.envEditor.height = m.height
 				} else {
 					// Activate toggle/select field
 					m = m.activateCurrentField()
@@ -989,8 +1378,7 @@ func (m *SettingsModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case "s", "ctrl+s":
-			m.saved = true
-			return m, tea.Quit
+			return m.requestSave()
 
 		case "c":
 			m.quitting = true
@@ -1015,12 +1403,36 @@ func (m *SettingsModal) View() string {
 	}
 
 	if m.saved {
-		return "✅ Configuration saved!\n"
+		return ui.Sym().Done + "Configuration saved!\n"
+	}
+
+	if m.envEditor != nil {
+		return m.envEditor.View()
+	}
+
+	if m.confirming {
+		return m.renderConfirm()
 	}
 
 	return m.renderModal()
 }
 
+// closeEnvEditor keeps the env profile editor's changes and returns to the modal
+func (m *SettingsModal) closeEnvEditor() {
+	if m.envEditor.changed {
+		m.envConfigs = m.envEditor.profiles
+		m.envChanged = true
+		for i := range m.sections {
+			for j := range m.sections[i].fields {
+				if m.sections[i].fields[j].name == "env-profiles" {
+					m.sections[i].fields[j].value = envProfilesSummary(m.envConfigs)
+				}
+			}
+		}
+	}
+	m.envEditor = nil
+}
+
 // navigateUp moves to previous field with section wrapping
 func (m *SettingsModal) navigateUp() *SettingsModal {
 	m.currentField--
@@ -1079,7 +1491,7 @@ func (m *SettingsModal) activateCurrentField() *SettingsModal {
 			nextIndex := (currentIndex + 1) % len(field.options)
 			field.value = field.options[nextIndex]
 		}
-	// Remove button handling from field activation - buttons are separate now
+		// Remove button handling from field activation - buttons are separate now
 	}
 
 	return m
@@ -1096,7 +1508,11 @@ func (m *SettingsModal) renderModal() string {
 		Align(lipgloss.Center).
 		Width(m.width)
 
-	sections = append(sections, headerStyle.Render("packnplay Configuration"))
+	title := "packnplay Configuration"
+	if m.title != "" {
+		title = m.title
+	}
+	sections = append(sections, headerStyle.Render(title))
 	sections = append(sections, "")
 
 	// Render each section
@@ -1131,16 +1547,24 @@ func (m *SettingsModal) renderField(field SettingsField, focused bool) string {
 	baseIndent := "   " // 3 spaces
 	cursor := " "       // 1 space when not focused
 	if focused {
-		cursor = ">"    // 1 character when focused
+		cursor = ">" // 1 character when focused
 	}
 
-	// Title styling with FIXED width to prevent right-align jumping
-	titleStyle := lipgloss.NewStyle().Width(40) // Fixed width regardless of styling
+	// Title styling with FIXED width to prevent right-align jumping.
+	// Narrow terminals put the value on its own line instead.
+	narrow := m.width < narrowWidth
+	titleStyle := lipgloss.NewStyle()
+	titleText := truncate(field.title, m.width-len(baseIndent)-1)
+	if !narrow {
+		titleWidth := titleColumnWidth(m.width)
+		titleStyle = titleStyle.Width(titleWidth) // Fixed width regardless of styling
+		titleText = truncate(field.title, titleWidth-1)
+	}
 	if focused {
 		titleStyle = titleStyle.Foreground(lipgloss.Color("39")).Bold(true)
 	}
 
-	title := titleStyle.Render(field.title)
+	title := titleStyle.Render(titleText)
 
 	// Value rendering based on type
 	var value string
@@ -1161,6 +1585,10 @@ func (m *SettingsModal) renderField(field SettingsField, focused bool) string {
 			Foreground(lipgloss.Color("39")).
 			Bold(true).
 			Render(field.value.(string))
+	case "action":
+		value = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("39")).
+			Render(field.value.(string) + " " + ui.Sym().Chevron)
 	case "text":
 		if focused && m.textEditing && field.name == m.getCurrentField().name {
 			// Show textinput component when editing this field
@@ -1170,31 +1598,53 @@ func (m *SettingsModal) renderField(field SettingsField, focused bool) string {
 			value = lipgloss.NewStyle().
 				Foreground(lipgloss.Color("39")).
 				Italic(true).
-				Render(field.value.(string))
+				Render(truncate(field.value.(string), m.valueWidth()))
 		}
 	}
 
 	// FIXED: Use fixed-width title to ensure right-alignment stays consistent
 	line := fmt.Sprintf("%s%s%s %s", baseIndent, cursor, title, value)
+	if narrow {
+		line = fmt.Sprintf("%s%s%s\n%s  %s", baseIndent, cursor, title, baseIndent, value)
+	}
 
 	// FIXED: Always show description, not just when focused
 	if field.description != "" {
 		descStyle := lipgloss.NewStyle().
 			Foreground(lipgloss.Color("240")).
 			Italic(true)
-		line += "\n" + baseIndent + "  " + descStyle.Render(field.description)
+		line += "\n" + baseIndent + "  " + descStyle.Render(truncate(field.description, m.width-len(baseIndent)-2))
+	}
+
+	// Validation errors, and what looking the value up found, go right under it
+	if field.err != "" {
+		line += "\n" + baseIndent + "  " + lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render(truncate(field.err, m.width-len(baseIndent)-2))
+	} else if field.note != "" {
+		noteStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+		if field.checking {
+			noteStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+		}
+		line += "\n" + baseIndent + "  " + noteStyle.Render(truncate(field.note, m.width-len(baseIndent)-2))
 	}
 
 	return line
 }
 
+// valueWidth returns the space available for a field value at the current width
+func (m *SettingsModal) valueWidth() int {
+	if m.width < narrowWidth {
+		return clampWidth(m.width-8, 10, 50)
+	}
+	return clampWidth(m.width-titleColumnWidth(m.width)-8, 10, 50)
+}
+
 // renderButtonBar renders the bottom button bar like a modal
 func (m *SettingsModal) renderButtonBar() string {
 	// Separator line
 	separator := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("240")).
 		Width(m.width).
-		Render(strings.Repeat("─", 60))
+		Render(strings.Repeat(ui.Sym().Rule, clampWidth(m.width, 1, 60)))
 
 	// Button styling based on focus
 	saveStyle := lipgloss.NewStyle().
@@ -1226,19 +1676,30 @@ func (m *SettingsModal) renderButtonBar() string {
 			Foreground(lipgloss.Color("240"))
 	}
 
-	saveButton := saveStyle.Render(" Save ")
-	cancelButton := cancelStyle.Render(" Cancel ")
+	saveLabel, cancelLabel := " Save ", " Cancel "
+	// Without color the focused button is bracketed instead of highlighted
+	if !ui.Color() && m.buttonFocused {
+		if m.currentButton == 0 {
+			saveLabel = "[Save]"
+		} else {
+			cancelLabel = "[Cancel]"
+		}
+	}
+	saveButton := saveStyle.Render(saveLabel)
+	cancelButton := cancelStyle.Render(cancelLabel)
 
 	buttons := fmt.Sprintf("    %s    %s", saveButton, cancelButton)
 
-	helpText := "Press Enter to activate • 's' save • 'q' cancel • ↑/↓ navigate"
+	sym := ui.Sym()
+	helpText := helpLine("Press Enter to activate", "'s' save", "'q' cancel", sym.UpDown+" navigate")
 	if m.buttonFocused {
-		helpText = "Press Enter to activate • ←/→ select button • ↑ back to fields"
+		helpText = helpLine("Press Enter to activate", sym.LeftRight+" select button", sym.Up+" back to fields")
 	}
 
 	return separator + "\n" + buttons + "\n\n" +
 		lipgloss.NewStyle().
 			Foreground(lipgloss.Color("240")).
+			Width(m.width). // Wrap instead of overflowing narrow terminals
 			Render(helpText)
 }
 
@@ -1247,14 +1708,60 @@ func RunInteractiveConfiguration(existing *Config, configPath string, verbose bo
 	return runScrollableSections(existing, configPath, verbose)
 }
 
-// GetConfigPath returns the path to the config file
+// ConfigSections maps --section names to settings modal sections
+var ConfigSections = map[string]string{
+	"runtime":     "runtime",
+	"credentials": "credentials",
+	"container":   "default-container",
+	"env":         "env-configs",
+}
+
+// RunSectionConfiguration runs the settings modal showing only the named section
+func RunSectionConfiguration(existing *Config, configPath, section string, verbose bool) error {
+	modal, err := createSectionModal(existing, section)
+	if err != nil {
+		return err
+	}
+	modal.configPath = configPath
+
+	finalModel, err := runModal(modal)
+	if err != nil {
+		return fmt.Errorf("configuration failed: %w", err)
+	}
+
+	if finalModel.saved {
+		return applyModalConfigUpdates(finalModel, configPath)
+	}
+
+	return nil
+}
+
+// createSectionModal creates a settings modal limited to a single section
+func createSectionModal(existing *Config, section string) (*SettingsModal, error) {
+	sectionName, ok := ConfigSections[section]
+	if !ok {
+		return nil, fmt.Errorf("unknown section '%s' (valid: runtime, credentials, container, env)", section)
+	}
+
+	modal := createSettingsModal(existing)
+	for _, s := range modal.sections {
+		if s.name == sectionName {
+			modal.sections = []SettingsSection{s}
+			break
+		}
+	}
+	return modal, nil
+}
+
+// GetConfigPath returns the path to the config file: config.json, config.yaml,
+// config.yml or config.toml, whichever exists first (config.json when none does)
 func GetConfigPath() string {
 	configHome := os.Getenv("XDG_CONFIG_HOME")
 	if configHome == "" {
 		home, _ := os.UserHomeDir()
 		configHome = filepath.Join(home, ".config")
 	}
-	return filepath.Join(configHome, "packnplay", "config.json")
+	return findConfigFile(filepath.Join(configHome, "packnplay"))
 }
 
 // Load loads the config file, or prompts for interactive setup if not found
@@ -1268,7 +1775,7 @@ func Load() (*Config, error) {
 	}
 
 	// Load existing config
-	data, err := os.ReadFile(configPath)
+	data, err := ReadConfigFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
@@ -1301,7 +1808,7 @@ func LoadWithoutRuntimeCheck() (*Config, error) {
 	}
 
 	// Load existing config
-	data, err := os.ReadFile(configPath)
+	data, err := ReadConfigFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
@@ -1333,7 +1840,7 @@ func Save(cfg *Config) error {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	if err := os.WriteFile(configPath, data, 0644); err != nil {
+	if err := WriteConfigFile(configPath, data); err != nil {
 		return fmt.Errorf("failed to write config: %w", err)
 	}
 
@@ -1375,13 +1882,12 @@ func runScrollableSections(existing *Config, configPath string, verbose bool) er
 	modal := createSettingsModal(existing)
 	modal.configPath = configPath
 
-	program := tea.NewProgram(modal, tea.WithAltScreen())
-	finalModel, err := program.Run()
+	finalModel, err := runModal(modal)
 	if err != nil {
 		return fmt.Errorf("configuration failed: %w", err)
 	}
 
-	if finalModel, ok := finalModel.(*SettingsModal); ok && finalModel.saved {
+	if finalModel.saved {
 		return applyModalConfigUpdates(finalModel, configPath)
 	}
 
@@ -1404,8 +1910,7 @@ func detectAvailableRuntimes() []string {
 	return available
 }
 
-
-// Init implements tea.Model for TabbedConfigModel  
+// Init implements tea.Model for TabbedConfigModel
 func (m *TabbedConfigModel) Init() tea.Cmd {
 	return nil
 }
@@ -1419,7 +1924,7 @@ func (m *TabbedConfigModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.quitting = true
 			return m, tea.Quit
 		case "s":
-			m.saved = true  
+			m.saved = true
 			return m, tea.Quit
 		}
 	}
@@ -1432,8 +1937,7 @@ func (m *TabbedConfigModel) View() string {
 		return "Configuration cancelled.\n"
 	}
 	if m.saved {
-		return "✅ Configuration saved!\n"
+		return ui.Sym().Done + "Configuration saved!\n"
 	}
 	return "Tabbed Config Placeholder"
 }
-
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -78,6 +79,17 @@ func TestConfig_SaveAndLoad(t *testing.T) {
 	if _, exists := loaded.EnvConfigs["z.ai"]; !exists {
 		t.Errorf("z.ai config not found in loaded config")
 	}
+
+	// Sections left unset aren't written out
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"shared_cache", "default_resources", "network", "log_capture", "docker", "scoped_credentials", "build_cache", "notifications", "dependency_caches"} {
+		if strings.Contains(string(data), `"`+key+`":`) {
+			t.Errorf("saved config has an empty %s section:\n%s", key, data)
+		}
+	}
 }
 
 func TestGetConfigPath(t *testing.T) {
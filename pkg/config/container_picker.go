@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ContainerEntry is a packnplay-managed container shown in the container picker
+type ContainerEntry struct {
+	Name     string
+	Project  string
+	Worktree string
+	Status   string
+	HostPath string
+}
+
+// ContainerAction is what the user picked to do with a container
+type ContainerAction int
+
+const (
+	ContainerActionNone ContainerAction = iota // quit without picking
+	ContainerActionAttach
+	ContainerActionStop
+	ContainerActionLogs
+	ContainerActionCopy // copy the command that reconnects to the container
+)
+
+// containerPicker lists containers and lets the user pick one along with an action
+type containerPicker struct {
+	entries []ContainerEntry
+	index   int
+	action  ContainerAction
+	width   int
+	height  int
+}
+
+// newContainerPicker creates a picker over entries
+func newContainerPicker(entries []ContainerEntry) *containerPicker {
+	return &containerPicker{entries: entries, width: 80, height: 24}
+}
+
+func (p *containerPicker) Init() tea.Cmd {
+	return nil
+}
+
+// Update moves the selection and picks an action
+func (p *containerPicker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.width = msg.Width
+		p.height = msg.Height
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q", "ctrl+c":
+			p.action = ContainerActionNone
+			return p, tea.Quit
+		case "up", "k":
+			if p.index > 0 {
+				p.index--
+			}
+		case "down", "j":
+			if p.index < len(p.entries)-1 {
+				p.index++
+			}
+		case "enter", "a":
+			return p.pick(ContainerActionAttach)
+		case "s":
+			return p.pick(ContainerActionStop)
+		case "l":
+			return p.pick(ContainerActionLogs)
+		case "c", "y":
+			return p.pick(ContainerActionCopy)
+		}
+	}
+	return p, nil
+}
+
+// pick ends the picker with action on the selected container, if there is one
+func (p *containerPicker) pick(action ContainerAction) (tea.Model, tea.Cmd) {
+	if len(p.entries) == 0 {
+		return p, nil
+	}
+	p.action = action
+	return p, tea.Quit
+}
+
+// View renders the container table, the selected container's path and the key help
+func (p *containerPicker) View() string {
+	headerStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("39"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	focusStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Bold(true)
+
+	// Project and worktree columns share what the status column leaves
+	statusWidth := 20
+	columnWidth := clampWidth((p.width-statusWidth-6)/2, 8, 40)
+
+	var out []string
+	out = append(out, headerStyle.Render("packnplay containers"), "")
+	out = append(out, dimStyle.Render(fmt.Sprintf("  %-*s  %-*s  %s", columnWidth, "PROJECT", columnWidth, "WORKTREE", "STATUS")))
+	if len(p.entries) == 0 {
+		out = append(out, dimStyle.Render("  (no containers running)"))
+	}
+	for i, entry := range p.entries {
+		line := fmt.Sprintf("%-*s  %-*s  %s",
+			columnWidth, truncate(entry.Project, columnWidth),
+			columnWidth, truncate(entry.Worktree, columnWidth),
+			truncate(entry.Status, statusWidth))
+		if i == p.index {
+			out = append(out, focusStyle.Render("> "+line))
+		} else {
+			out = append(out, "  "+line)
+		}
+	}
+
+	if entry, ok := p.selected(); ok {
+		detail := entry.Name
+		if entry.HostPath != "" {
+			detail += " (" + entry.HostPath + ")"
+		}
+		out = append(out, "", dimStyle.Italic(true).Render(truncate(detail, p.width-2)))
+	}
+
+	helpText := helpLine("Enter/a attach", "s stop", "l logs", "c copy reconnect command", "q quit")
+	out = append(out, "", dimStyle.Width(p.width).Render(helpText))
+	return strings.Join(out, "\n")
+}
+
+// selected returns the selected container, if there are any
+func (p *containerPicker) selected() (ContainerEntry, bool) {
+	if len(p.entries) == 0 {
+		return ContainerEntry{}, false
+	}
+	return p.entries[p.index], true
+}
+
+// PickContainer shows entries full screen and returns the container the user picked and
+// what to do with it. The action is ContainerActionNone when the user quit instead.
+func PickContainer(entries []ContainerEntry) (ContainerEntry, ContainerAction, error) {
+	picker := newContainerPicker(entries)
+	finalModel, err := tea.NewProgram(picker, tea.WithAltScreen()).Run()
+	if err != nil {
+		return ContainerEntry{}, ContainerActionNone, err
+	}
+	if finalModel, ok := finalModel.(*containerPicker); ok {
+		picker = finalModel
+	}
+	entry, _ := picker.selected()
+	return entry, picker.action, nil
+}
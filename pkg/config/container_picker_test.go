@@ -0,0 +1,54 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestContainerPicker(t *testing.T) {
+	entries := []ContainerEntry{
+		{Name: "packnplay-app-f227ecb4-main", Project: "app", Worktree: "main", Status: "Up 2 hours", HostPath: "/src/app"},
+		{Name: "packnplay-app-f227ecb4-feature", Project: "app", Worktree: "feature", Status: "Up 5 minutes"},
+	}
+
+	tests := []struct {
+		keys []string
+		want string
+		act  ContainerAction
+	}{
+		{[]string{"enter"}, "packnplay-app-f227ecb4-main", ContainerActionAttach},
+		{[]string{"j", "s"}, "packnplay-app-f227ecb4-feature", ContainerActionStop},
+		{[]string{"j", "j", "k", "l"}, "packnplay-app-f227ecb4-main", ContainerActionLogs},
+		{[]string{"j", "c"}, "packnplay-app-f227ecb4-feature", ContainerActionCopy},
+		{[]string{"j", "esc"}, "packnplay-app-f227ecb4-feature", ContainerActionNone},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.keys, " "), func(t *testing.T) {
+			p := newContainerPicker(entries)
+			for _, k := range tt.keys {
+				p.Update(keyMsg(k))
+			}
+			entry, _ := p.selected()
+			if entry.Name != tt.want || p.action != tt.act {
+				t.Errorf("picked %s with action %d, want %s with %d", entry.Name, p.action, tt.want, tt.act)
+			}
+		})
+	}
+}
+
+func TestContainerPickerView(t *testing.T) {
+	p := newContainerPicker([]ContainerEntry{{Name: "packnplay-app-f227ecb4-main", Project: "app", Worktree: "main", Status: "Up 2 hours", HostPath: "/src/app"}})
+	view := p.View()
+	for _, want := range []string{"app", "main", "Up 2 hours", "/src/app", "attach"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() missing %q:\n%s", want, view)
+		}
+	}
+
+	// Nothing to pick without containers
+	empty := newContainerPicker(nil)
+	empty.Update(keyMsg("enter"))
+	if empty.action != ContainerActionNone || !strings.Contains(empty.View(), "no containers running") {
+		t.Errorf("empty picker action = %d, view:\n%s", empty.action, empty.View())
+	}
+}
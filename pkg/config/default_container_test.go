@@ -87,4 +87,36 @@ func TestDefaultContainerDefaults(t *testing.T) {
 	if defaults.CheckFrequencyHours != 24 {
 		t.Errorf("Default CheckFrequencyHours = %v, want 24", defaults.CheckFrequencyHours)
 	}
-}
\ No newline at end of file
+}
+
+func TestGetDefaultImageVariant(t *testing.T) {
+	tests := []struct {
+		name   string
+		config DefaultContainerConfig
+		want   string
+	}{
+		{"full variant uses latest", DefaultContainerConfig{Variant: "full"}, "ghcr.io/obra/packnplay-default:latest"},
+		{"slim variant", DefaultContainerConfig{Variant: "slim"}, "ghcr.io/obra/packnplay-default:slim"},
+		{"variant overrides default repo tag", DefaultContainerConfig{Image: "ghcr.io/obra/packnplay-default:latest", Variant: "go"}, "ghcr.io/obra/packnplay-default:go"},
+		{"custom image wins over variant", DefaultContainerConfig{Image: "my-org/dev:v2", Variant: "python"}, "my-org/dev:v2"},
+		{"pinned tag wins over variant", DefaultContainerConfig{Image: "ghcr.io/obra/packnplay-default:v1.4", Variant: "slim"}, "ghcr.io/obra/packnplay-default:v1.4"},
+		{"digest wins over variant", DefaultContainerConfig{Image: "ghcr.io/obra/packnplay-default@sha256:abc", Variant: "go"}, "ghcr.io/obra/packnplay-default@sha256:abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{DefaultContainer: tt.config}
+			if got := config.GetDefaultImage(); got != tt.want {
+				t.Errorf("GetDefaultImage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidImageVariant(t *testing.T) {
+	for variant, want := range map[string]bool{"": true, "full": true, "slim": true, "python": true, "go": true, "rust": false} {
+		if got := ValidImageVariant(variant); got != want {
+			t.Errorf("ValidImageVariant(%q) = %v, want %v", variant, got, want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Dependency cache scopes
+const (
+	DependencyCacheProject = "project" // a volume per project and cache (default)
+	DependencyCacheGlobal  = "global"  // a volume per cache, shared by every project
+)
+
+// DependencyCachesConfig mounts named volumes over package manager caches such as ~/.npm,
+// so dependencies downloaded in one container are there in the next
+type DependencyCachesConfig struct {
+	Enabled bool     `json:"enabled"`          // mount the caches into every container
+	Scope   string   `json:"scope,omitempty"`  // project or global
+	Caches  []string `json:"caches,omitempty"` // built-in cache names, or name:path for others (default: the devcontainer's, else all built-ins)
+}
+
+// dependencyCacheName matches names that work in volume names
+var dependencyCacheName = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// Validate checks the scope and the form of the caches
+func (d DependencyCachesConfig) Validate() error {
+	switch d.Scope {
+	case "", DependencyCacheProject, DependencyCacheGlobal:
+	default:
+		return fmt.Errorf("unknown scope %q (use %s or %s)", d.Scope, DependencyCacheProject, DependencyCacheGlobal)
+	}
+	for _, entry := range d.Caches {
+		if err := ValidateDependencyCache(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateDependencyCache checks a cache entry: a name, or name:path where path is
+// absolute or starts with ~ for the container user's home
+func ValidateDependencyCache(entry string) error {
+	name, path, custom := strings.Cut(entry, ":")
+	if !dependencyCacheName.MatchString(name) {
+		return fmt.Errorf("invalid cache name in %q (use lowercase letters, digits, ., - and _)", entry)
+	}
+	if custom && !strings.HasPrefix(path, "/") && path != "~" && !strings.HasPrefix(path, "~/") {
+		return fmt.Errorf("invalid cache path in %q (use an absolute path or one starting with ~/)", entry)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package config
+
+import "testing"
+
+func TestDependencyCachesValidate(t *testing.T) {
+	tests := []struct {
+		caches  DependencyCachesConfig
+		wantErr bool
+	}{
+		{DependencyCachesConfig{}, false},
+		{DependencyCachesConfig{Enabled: true, Scope: DependencyCacheGlobal, Caches: []string{"npm", "go-mod"}}, false},
+		{DependencyCachesConfig{Caches: []string{"bundler:~/.bundle/cache", "ccache:/var/cache/ccache"}}, false},
+		{DependencyCachesConfig{Scope: "team"}, true},
+		{DependencyCachesConfig{Caches: []string{"NPM"}}, true},
+		{DependencyCachesConfig{Caches: []string{"bundler:.bundle"}}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.caches.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) error = %v, wantErr %v", tt.caches, err, tt.wantErr)
+		}
+	}
+}
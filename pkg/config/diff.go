@@ -0,0 +1,206 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/obra/packnplay/pkg/ui"
+)
+
+// ConfigChange describes one changed key between two configs, using dotted JSON paths
+type ConfigChange struct {
+	Key string
+	Old string // "" when the key was unset
+	New string // "" when the key is removed
+}
+
+// String formats the change as "key: old → new"
+func (c ConfigChange) String() string {
+	old, updated := c.Old, c.New
+	if old == "" {
+		old = "(unset)"
+	}
+	if updated == "" {
+		updated = "(unset)"
+	}
+	return fmt.Sprintf("%s: %s %s %s", c.Key, old, ui.Sym().Arrow, updated)
+}
+
+// diffConfigs compares two values by their JSON form and returns the changed keys, sorted
+func diffConfigs(before, after interface{}) ([]ConfigChange, error) {
+	oldValues, err := flattenJSON(before)
+	if err != nil {
+		return nil, err
+	}
+	newValues, err := flattenJSON(after)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool)
+	for k := range oldValues {
+		keys[k] = true
+	}
+	for k := range newValues {
+		keys[k] = true
+	}
+
+	var changes []ConfigChange
+	for k := range keys {
+		if oldValues[k] != newValues[k] {
+			changes = append(changes, ConfigChange{Key: k, Old: oldValues[k], New: newValues[k]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+
+	return changes, nil
+}
+
+// flattenJSON turns v into dotted-key → JSON-encoded leaf values. Arrays are kept as one
+// value since element-wise diffs of short lists are harder to read.
+func flattenJSON(v interface{}) (map[string]string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	values := make(map[string]string)
+	var walk func(prefix string, node interface{})
+	walk = func(prefix string, node interface{}) {
+		if obj, ok := node.(map[string]interface{}); ok {
+			for k, child := range obj {
+				key := k
+				if prefix != "" {
+					key = prefix + "." + k
+				}
+				walk(key, child)
+			}
+			return
+		}
+		if node == nil {
+			return
+		}
+		encoded, _ := json.Marshal(node)
+		values[prefix] = string(encoded)
+	}
+	walk("", generic)
+
+	return values, nil
+}
+
+// pendingChanges computes what saving the modal would change on disk
+func (m *SettingsModal) pendingChanges() ([]ConfigChange, error) {
+	if m.projectDir != "" {
+		current, err := LoadProjectConfig(m.projectDir)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := projectConfigFromModal(m, m.projectDir)
+		if err != nil {
+			return nil, err
+		}
+		return diffConfigs(current, updated)
+	}
+
+	current, err := LoadExistingOrEmpty(m.configPath)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := LoadExistingOrEmpty(m.configPath)
+	if err != nil {
+		return nil, err
+	}
+	applyConfigUpdates(updated, modalConfigUpdates(m))
+
+	return diffConfigs(current, updated)
+}
+
+// requestSave shows the change summary instead of saving immediately
+func (m *SettingsModal) requestSave() (tea.Model, tea.Cmd) {
+	// Invalid values are fixed before anything is saved
+	if m.focusInvalidField() {
+		return m, nil
+	}
+	m.changes, m.saveErr = m.pendingChanges()
+	m.confirming = true
+	return m, nil
+}
+
+// updateConfirm handles keys on the save confirmation screen
+func (m *SettingsModal) updateConfirm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "y", "enter":
+			if m.saveErr != nil {
+				// Nothing valid to save - go back and let the user fix it
+				m.confirming = false
+				return m, nil
+			}
+			if len(m.changes) == 0 {
+				m.quitting = true
+				return m, tea.Quit
+			}
+			m.saved = true
+			return m, tea.Quit
+		case "n", "esc":
+			m.confirming = false
+		case "ctrl+c":
+			m.quitting = true
+			return m, tea.Quit
+		}
+	}
+
+	return m, nil
+}
+
+// renderConfirm renders the save-time change summary
+func (m *SettingsModal) renderConfirm() string {
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+	lines := []string{headerStyle.Render("Review changes"), ""}
+
+	help := helpLine("Enter/y save", "n/Esc keep editing")
+	switch {
+	case m.saveErr != nil:
+		lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render(truncate(m.saveErr.Error(), m.width)))
+		help = "Enter/Esc back to editing"
+	case len(m.changes) == 0:
+		lines = append(lines, "No changes to save.")
+		help = helpLine("Enter close", "Esc keep editing")
+	default:
+		for _, change := range m.changes {
+			lines = append(lines, "  "+truncate(change.String(), m.width-2))
+		}
+	}
+
+	lines = append(lines, "", dimStyle.Width(m.width).Render(help))
+	return strings.Join(lines, "\n")
+}
+
+// formatChanges renders changes as plain text lines for the prompt fallback
+func formatChanges(changes []ConfigChange) string {
+	if len(changes) == 0 {
+		return "No changes to save.\n"
+	}
+	var b strings.Builder
+	b.WriteString("Changes:\n")
+	for _, change := range changes {
+		b.WriteString("  " + change.String() + "\n")
+	}
+	return b.String()
+}
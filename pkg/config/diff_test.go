@@ -0,0 +1,97 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffConfigs(t *testing.T) {
+	before := &Config{
+		ContainerRuntime:   "docker",
+		DefaultCredentials: Credentials{Git: true, SSH: false},
+		DefaultEnvVars:     []string{"A"},
+	}
+	after := &Config{
+		ContainerRuntime:   "podman",
+		DefaultCredentials: Credentials{Git: true, SSH: true},
+		DefaultEnvVars:     []string{"A"},
+		ClaudeSync:         "copy",
+	}
+
+	changes, err := diffConfigs(before, after)
+	if err != nil {
+		t.Fatalf("diffConfigs() error = %v", err)
+	}
+
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.String())
+	}
+	want := []string{
+		`claude_sync: (unset) → "copy"`,
+		`container_runtime: "docker" → "podman"`,
+		`default_credentials.ssh: false → true`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("diffConfigs() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSettingsModalConfirmsBeforeSaving(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	existing := &Config{ContainerRuntime: "docker", DefaultCredentials: Credentials{Git: true}, DefaultContainer: GetDefaultContainerConfig()}
+	if err := SaveConfig(existing, configPath); err != nil {
+		t.Fatal(err)
+	}
+
+	modal, err := createSectionModal(existing, "credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	modal.configPath = configPath
+	modal.sections[0].fields[0].value = true // ssh
+
+	modal.Update(keyMsg("s"))
+	if !modal.confirming || modal.saved {
+		t.Fatal("save should show the change summary first")
+	}
+	if len(modal.changes) != 1 || modal.changes[0].Key != "default_credentials.ssh" {
+		t.Errorf("changes = %v, want only default_credentials.ssh", modal.changes)
+	}
+	if !strings.Contains(modal.View(), "default_credentials.ssh: false → true") {
+		t.Errorf("confirmation view should list the change:\n%s", modal.View())
+	}
+
+	// Backing out returns to editing
+	modal.Update(keyMsg("esc"))
+	if modal.confirming || modal.saved {
+		t.Error("esc should return to editing without saving")
+	}
+
+	modal.Update(keyMsg("s"))
+	modal.Update(keyMsg("y"))
+	if !modal.saved {
+		t.Error("confirming should save")
+	}
+}
+
+func TestSettingsModalNoChanges(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	existing := &Config{ContainerRuntime: "docker", DefaultCredentials: Credentials{Git: true}}
+	if err := SaveConfig(existing, configPath); err != nil {
+		t.Fatal(err)
+	}
+
+	modal, _ := createSectionModal(existing, "credentials")
+	modal.configPath = configPath
+
+	modal.Update(keyMsg("s"))
+	if len(modal.changes) != 0 || !strings.Contains(modal.View(), "No changes") {
+		t.Errorf("changes = %v, want none", modal.changes)
+	}
+	modal.Update(keyMsg("enter"))
+	if modal.saved {
+		t.Error("nothing should be saved without changes")
+	}
+}
@@ -0,0 +1,33 @@
+package config
+
+import "fmt"
+
+// Docker access modes control whether commands in the container can use docker
+const (
+	DockerOff    = "off"    // no docker access (default)
+	DockerSocket = "socket" // the host's docker socket is mounted into the container
+	DockerDind   = "dind"   // a rootless docker-in-docker sidecar on a network shared with the container
+)
+
+// DockerConfig gives containers a docker daemon to build and run images with
+type DockerConfig struct {
+	Mode      string `json:"mode,omitempty"`       // off, socket, or dind (default: off, or what the devcontainer features ask for)
+	DindImage string `json:"dind_image,omitempty"` // image for the dind sidecar (default: docker:dind-rootless)
+}
+
+// ValidDockerMode reports whether mode is a known docker access mode
+func ValidDockerMode(mode string) bool {
+	switch mode {
+	case "", DockerOff, DockerSocket, DockerDind:
+		return true
+	}
+	return false
+}
+
+// Validate checks the mode
+func (d DockerConfig) Validate() error {
+	if !ValidDockerMode(d.Mode) {
+		return fmt.Errorf("unknown mode '%s' (valid: off, socket, dind)", d.Mode)
+	}
+	return nil
+}
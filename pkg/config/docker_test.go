@@ -0,0 +1,23 @@
+package config
+
+import "testing"
+
+func TestValidDockerMode(t *testing.T) {
+	for _, mode := range []string{"", DockerOff, DockerSocket, DockerDind} {
+		if !ValidDockerMode(mode) {
+			t.Errorf("ValidDockerMode(%q) = false", mode)
+		}
+	}
+	if ValidDockerMode("host") {
+		t.Error("ValidDockerMode(\"host\") = true")
+	}
+}
+
+func TestDockerConfigValidate(t *testing.T) {
+	if err := (DockerConfig{Mode: DockerDind}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := (DockerConfig{Mode: "podman"}).Validate(); err == nil {
+		t.Error("Validate() of an unknown mode succeeded")
+	}
+}
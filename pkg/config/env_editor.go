@@ -0,0 +1,405 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/obra/packnplay/pkg/ui"
+)
+
+// envEditorPane identifies which side of the env profile editor has focus
+type envEditorPane int
+
+const (
+	envPaneProfiles envEditorPane = iota
+	envPaneVars
+)
+
+// envEditorInput identifies what the text input is currently collecting
+type envEditorInput int
+
+const (
+	envInputNone envEditorInput = iota
+	envInputNewProfile
+	envInputDescription
+	envInputNewVar
+	envInputEditVar
+)
+
+// envProfilesEditor edits EnvConfigs in a split pane: profiles on the left,
+// env vars of the selected profile on the right
+type envProfilesEditor struct {
+	profiles     map[string]EnvConfig
+	names        []string
+	profileIndex int
+	varIndex     int
+	pane         envEditorPane
+	input        envEditorInput
+	textInput    textinput.Model
+	err          string
+	done         bool
+	changed      bool
+	width        int
+	height       int
+}
+
+// newEnvProfilesEditor creates an editor working on a copy of the given profiles
+func newEnvProfilesEditor(profiles map[string]EnvConfig) *envProfilesEditor {
+	ti := textinput.New()
+	ti.Width = 40
+
+	e := &envProfilesEditor{
+		profiles:  copyEnvConfigs(profiles),
+		textInput: ti,
+		width:     80,
+		height:    24,
+	}
+	e.refreshNames()
+	return e
+}
+
+// copyEnvConfigs deep-copies env profiles so edits can be discarded
+func copyEnvConfigs(profiles map[string]EnvConfig) map[string]EnvConfig {
+	copied := make(map[string]EnvConfig, len(profiles))
+	for name, profile := range profiles {
+		vars := make(map[string]string, len(profile.EnvVars))
+		for k, v := range profile.EnvVars {
+			vars[k] = v
+		}
+		profile.EnvVars = vars
+		copied[name] = profile
+	}
+	return copied
+}
+
+// refreshNames rebuilds the sorted profile list and clamps the selection
+func (e *envProfilesEditor) refreshNames() {
+	e.names = e.names[:0]
+	for name := range e.profiles {
+		e.names = append(e.names, name)
+	}
+	sort.Strings(e.names)
+
+	if e.profileIndex >= len(e.names) {
+		e.profileIndex = len(e.names) - 1
+	}
+	if e.profileIndex < 0 {
+		e.profileIndex = 0
+	}
+	if e.varIndex >= len(e.selectedVarKeys()) {
+		e.varIndex = len(e.selectedVarKeys()) - 1
+	}
+	if e.varIndex < 0 {
+		e.varIndex = 0
+	}
+}
+
+// selectedName returns the selected profile name, or "" when there are none
+func (e *envProfilesEditor) selectedName() string {
+	if len(e.names) == 0 {
+		return ""
+	}
+	return e.names[e.profileIndex]
+}
+
+// selectedVarKeys returns the sorted env var keys of the selected profile
+func (e *envProfilesEditor) selectedVarKeys() []string {
+	profile, ok := e.profiles[e.selectedName()]
+	if !ok {
+		return nil
+	}
+	keys := make([]string, 0, len(profile.EnvVars))
+	for k := range profile.EnvVars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// startInput switches to text input mode for the given purpose
+func (e *envProfilesEditor) startInput(mode envEditorInput, placeholder, value string) {
+	e.input = mode
+	e.err = ""
+	e.textInput.Placeholder = placeholder
+	e.textInput.SetValue(value)
+	e.textInput.CursorEnd()
+	e.textInput.Focus()
+}
+
+// Update handles key presses for the env profile editor
+func (e *envProfilesEditor) Update(msg tea.Msg) (*envProfilesEditor, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		e.width = msg.Width
+		e.height = msg.Height
+		e.textInput.Width = clampWidth(e.width-6, 10, 60)
+
+	case tea.KeyMsg:
+		if e.input != envInputNone {
+			return e.updateInput(msg)
+		}
+
+		switch msg.String() {
+		case "esc", "q":
+			e.done = true
+
+		case "up", "k":
+			if e.pane == envPaneProfiles && e.profileIndex > 0 {
+				e.profileIndex--
+				e.varIndex = 0
+			} else if e.pane == envPaneVars && e.varIndex > 0 {
+				e.varIndex--
+			}
+
+		case "down", "j":
+			if e.pane == envPaneProfiles && e.profileIndex < len(e.names)-1 {
+				e.profileIndex++
+				e.varIndex = 0
+			} else if e.pane == envPaneVars && e.varIndex < len(e.selectedVarKeys())-1 {
+				e.varIndex++
+			}
+
+		case "right", "l":
+			if e.selectedName() != "" {
+				e.pane = envPaneVars
+			}
+
+		case "left", "h":
+			e.pane = envPaneProfiles
+
+		case "tab":
+			if e.pane == envPaneProfiles && e.selectedName() != "" {
+				e.pane = envPaneVars
+			} else {
+				e.pane = envPaneProfiles
+			}
+
+		case "a":
+			if e.pane == envPaneProfiles {
+				e.startInput(envInputNewProfile, "profile name", "")
+			} else if e.selectedName() != "" {
+				e.startInput(envInputNewVar, "KEY=value", "")
+			}
+
+		case "enter", "e":
+			if e.pane == envPaneProfiles {
+				if name := e.selectedName(); name != "" {
+					e.startInput(envInputDescription, "description", e.profiles[name].Description)
+				}
+			} else if keys := e.selectedVarKeys(); len(keys) > 0 {
+				key := keys[e.varIndex]
+				e.startInput(envInputEditVar, "KEY=value", key+"="+e.profiles[e.selectedName()].EnvVars[key])
+			}
+
+		case "d", "delete":
+			e.deleteSelected()
+		}
+	}
+
+	return e, nil
+}
+
+// updateInput handles keys while the text input is active
+func (e *envProfilesEditor) updateInput(msg tea.KeyMsg) (*envProfilesEditor, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		e.input = envInputNone
+		e.err = ""
+		e.textInput.Blur()
+		return e, nil
+
+	case "enter":
+		if err := e.commitInput(strings.TrimSpace(e.textInput.Value())); err != nil {
+			e.err = err.Error()
+			return e, nil
+		}
+		e.input = envInputNone
+		e.err = ""
+		e.textInput.Blur()
+		return e, nil
+	}
+
+	var cmd tea.Cmd
+	e.textInput, cmd = e.textInput.Update(msg)
+	return e, cmd
+}
+
+// commitInput applies the text input value according to the current input mode
+func (e *envProfilesEditor) commitInput(value string) error {
+	switch e.input {
+	case envInputNewProfile:
+		if value == "" || strings.ContainsAny(value, " \t") {
+			return fmt.Errorf("profile name must be non-empty without spaces")
+		}
+		if _, exists := e.profiles[value]; exists {
+			return fmt.Errorf("profile %q already exists", value)
+		}
+		e.profiles[value] = EnvConfig{Name: value, EnvVars: map[string]string{}}
+		e.refreshNames()
+		for i, name := range e.names {
+			if name == value {
+				e.profileIndex = i
+			}
+		}
+		e.varIndex = 0
+
+	case envInputDescription:
+		name := e.selectedName()
+		profile := e.profiles[name]
+		profile.Description = value
+		e.profiles[name] = profile
+
+	case envInputNewVar, envInputEditVar:
+		key, val, ok := strings.Cut(value, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return fmt.Errorf("expected KEY=value")
+		}
+		name := e.selectedName()
+		profile := e.profiles[name]
+		if profile.EnvVars == nil {
+			profile.EnvVars = map[string]string{}
+		}
+		if e.input == envInputEditVar {
+			// Renaming a key replaces the old entry
+			delete(profile.EnvVars, e.selectedVarKeys()[e.varIndex])
+		}
+		profile.EnvVars[key] = val
+		e.profiles[name] = profile
+		for i, k := range e.selectedVarKeys() {
+			if k == key {
+				e.varIndex = i
+			}
+		}
+	}
+
+	e.changed = true
+	return nil
+}
+
+// deleteSelected removes the selected profile or env var
+func (e *envProfilesEditor) deleteSelected() {
+	name := e.selectedName()
+	if name == "" {
+		return
+	}
+
+	if e.pane == envPaneProfiles {
+		delete(e.profiles, name)
+		e.refreshNames()
+		e.varIndex = 0
+		e.changed = true
+		return
+	}
+
+	keys := e.selectedVarKeys()
+	if len(keys) == 0 {
+		return
+	}
+	delete(e.profiles[name].EnvVars, keys[e.varIndex])
+	e.refreshNames()
+	e.changed = true
+}
+
+// View renders the split pane editor
+func (e *envProfilesEditor) View() string {
+	headerStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("39"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	focusStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Bold(true)
+
+	// Pane widths include padding; borders add 2 cells each.
+	// Narrow terminals stack the panes vertically at full width.
+	stacked := e.width < stackedPanesWidth
+	leftWidth := clampWidth(e.width/3, 16, 30)
+	rightWidth := clampWidth(e.width-leftWidth-5, 20, 80)
+	if stacked {
+		leftWidth = clampWidth(e.width-2, 10, e.width)
+		rightWidth = leftWidth
+	}
+
+	// Left pane: profile names
+	var left []string
+	left = append(left, headerStyle.Render("Profiles"))
+	if len(e.names) == 0 {
+		left = append(left, dimStyle.Render("  (none)"))
+	}
+	for i, name := range e.names {
+		name = truncate(name, leftWidth-4)
+		line := "  " + name
+		if i == e.profileIndex {
+			line = "> " + name
+			if e.pane == envPaneProfiles {
+				line = focusStyle.Render(line)
+			}
+		}
+		left = append(left, line)
+	}
+
+	// Right pane: env vars of the selected profile
+	var right []string
+	name := e.selectedName()
+	right = append(right, headerStyle.Render("Environment"))
+	if name != "" {
+		if desc := e.profiles[name].Description; desc != "" {
+			right = append(right, dimStyle.Italic(true).Render(truncate(desc, rightWidth-2)))
+		}
+		keys := e.selectedVarKeys()
+		if len(keys) == 0 {
+			right = append(right, dimStyle.Render("  (no variables)"))
+		}
+		for i, key := range keys {
+			line := truncate(fmt.Sprintf("  %s=%s", key, e.profiles[name].EnvVars[key]), rightWidth-2)
+			if e.pane == envPaneVars && i == e.varIndex {
+				line = focusStyle.Render("> " + line[2:])
+			}
+			right = append(right, line)
+		}
+	}
+
+	paneStyle := lipgloss.NewStyle().
+		Border(ui.Sym().Border).
+		BorderForeground(lipgloss.Color("240")).
+		Padding(0, 1)
+	leftPane := paneStyle.Width(leftWidth).Render(strings.Join(left, "\n"))
+	rightPane := paneStyle.Width(rightWidth).Render(strings.Join(right, "\n"))
+
+	var out []string
+	out = append(out, headerStyle.Render("Environment Profiles"), "")
+	if stacked {
+		out = append(out, lipgloss.JoinVertical(lipgloss.Left, leftPane, rightPane))
+	} else {
+		out = append(out, lipgloss.JoinHorizontal(lipgloss.Top, leftPane, rightPane))
+	}
+
+	if e.input != envInputNone {
+		out = append(out, "", e.textInput.View())
+	}
+	if e.err != "" {
+		out = append(out, lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render(e.err))
+	}
+
+	helpText := helpLine("a add", "e/Enter edit", "d delete", "Tab switch pane", "Esc back")
+	if e.input != envInputNone {
+		helpText = helpLine("Enter confirm", "Esc cancel")
+	}
+	out = append(out, "", dimStyle.Width(e.width).Render(helpText))
+
+	return strings.Join(out, "\n")
+}
+
+// envProfilesSummary describes the configured profiles for the settings modal
+func envProfilesSummary(profiles map[string]EnvConfig) string {
+	switch len(profiles) {
+	case 0:
+		return "none"
+	case 1:
+		return "1 profile"
+	}
+	return fmt.Sprintf("%d profiles", len(profiles))
+}
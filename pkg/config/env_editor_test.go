@@ -0,0 +1,142 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keyMsg builds a key message, treating unknown names as typed text
+func keyMsg(k string) tea.KeyMsg {
+	switch k {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+}
+
+func typeKeys(e *envProfilesEditor, keys ...string) *envProfilesEditor {
+	for _, k := range keys {
+		e, _ = e.Update(keyMsg(k))
+	}
+	return e
+}
+
+func TestEnvProfilesEditorAddEditDelete(t *testing.T) {
+	e := newEnvProfilesEditor(map[string]EnvConfig{})
+
+	// Add a profile and a variable
+	e = typeKeys(e, "a", "z.ai", "enter", "tab", "a", "ANTHROPIC_BASE_URL=https://api.z.ai", "enter")
+	profile, ok := e.profiles["z.ai"]
+	if !ok {
+		t.Fatalf("profile z.ai not created, got %v", e.profiles)
+	}
+	if profile.EnvVars["ANTHROPIC_BASE_URL"] != "https://api.z.ai" {
+		t.Errorf("EnvVars = %v, want ANTHROPIC_BASE_URL set", profile.EnvVars)
+	}
+
+	// Edit the variable (renaming the key replaces it)
+	e = typeKeys(e, "e")
+	e.textInput.SetValue("ANTHROPIC_AUTH_TOKEN=${Z_AI_API_KEY}")
+	e = typeKeys(e, "enter")
+	vars := e.profiles["z.ai"].EnvVars
+	if _, exists := vars["ANTHROPIC_BASE_URL"]; exists || vars["ANTHROPIC_AUTH_TOKEN"] != "${Z_AI_API_KEY}" {
+		t.Errorf("EnvVars after edit = %v", vars)
+	}
+
+	// Delete the variable, then the profile
+	e = typeKeys(e, "d")
+	if len(e.profiles["z.ai"].EnvVars) != 0 {
+		t.Errorf("EnvVars after delete = %v, want empty", e.profiles["z.ai"].EnvVars)
+	}
+	e = typeKeys(e, "tab", "d")
+	if len(e.profiles) != 0 {
+		t.Errorf("profiles after delete = %v, want empty", e.profiles)
+	}
+
+	if !e.changed {
+		t.Error("editor should report changes")
+	}
+
+	e = typeKeys(e, "esc")
+	if !e.done {
+		t.Error("esc should close the editor")
+	}
+}
+
+func TestEnvProfilesEditorRejectsInvalidInput(t *testing.T) {
+	e := newEnvProfilesEditor(map[string]EnvConfig{
+		"work": {Name: "work", EnvVars: map[string]string{}},
+	})
+
+	// Duplicate profile name keeps the input open with an error
+	e = typeKeys(e, "a", "work", "enter")
+	if e.err == "" || e.input != envInputNewProfile {
+		t.Errorf("duplicate profile should be rejected, err=%q input=%v", e.err, e.input)
+	}
+	e = typeKeys(e, "esc", "tab", "a", "NOEQUALS", "enter")
+	if e.err == "" {
+		t.Error("variable without '=' should be rejected")
+	}
+}
+
+func TestEnvProfilesEditorDoesNotModifyOriginal(t *testing.T) {
+	original := map[string]EnvConfig{
+		"work": {Name: "work", EnvVars: map[string]string{"A": "1"}},
+	}
+	e := newEnvProfilesEditor(original)
+	e = typeKeys(e, "tab", "d")
+
+	if original["work"].EnvVars["A"] != "1" {
+		t.Error("editor should work on a copy of the profiles")
+	}
+}
+
+func TestSettingsModalSavesEnvProfiles(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	existing := &Config{
+		ContainerRuntime: "docker",
+		EnvConfigs: map[string]EnvConfig{
+			"work": {Name: "work", EnvVars: map[string]string{"A": "1"}},
+		},
+	}
+
+	modal := createSettingsModal(existing)
+	for i, section := range modal.sections {
+		if section.name == "env-configs" {
+			modal.currentSection = i
+			modal.currentField = 0
+		}
+	}
+
+	modal.Update(keyMsg("enter"))
+	if modal.envEditor == nil {
+		t.Fatal("enter on env profiles should open the editor")
+	}
+	for _, k := range []string{"a", "personal", "enter", "esc"} {
+		modal.Update(keyMsg(k))
+	}
+	if modal.envEditor != nil {
+		t.Fatal("esc should return to the settings modal")
+	}
+
+	if err := applyModalConfigUpdates(modal, configPath); err != nil {
+		t.Fatalf("applyModalConfigUpdates() error = %v", err)
+	}
+
+	saved, err := LoadConfigFromFile(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfigFromFile() error = %v", err)
+	}
+	if _, ok := saved.EnvConfigs["personal"]; !ok {
+		t.Errorf("new profile not saved: %v", saved.EnvConfigs)
+	}
+	if saved.EnvConfigs["work"].EnvVars["A"] != "1" {
+		t.Errorf("existing profile not preserved: %v", saved.EnvConfigs)
+	}
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config file formats, chosen by file extension
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// configFileNames are the global config files looked for, in order of preference
+var configFileNames = []string{"config.json", "config.yaml", "config.yml", "config.toml"}
+
+// FormatOf returns the format of a config file from its extension, JSON when unknown
+func FormatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	}
+	return FormatJSON
+}
+
+// ValidFormat reports whether format is a supported config file format
+func ValidFormat(format string) bool {
+	return format == FormatJSON || format == FormatYAML || format == FormatTOML
+}
+
+// findConfigFile returns the first of configFileNames that exists in dir, or config.json
+// when none does
+func findConfigFile(dir string) string {
+	for _, name := range configFileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(dir, configFileNames[0])
+}
+
+// ReadConfigFile reads a config file in any supported format and returns it as JSON, so
+// the json tags on Config apply to every format
+func ReadConfigFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ConvertFormat(data, FormatOf(path), FormatJSON)
+}
+
+// WriteConfigFile writes JSON config data to path in the format its extension selects
+func WriteConfigFile(path string, jsonData []byte) error {
+	data, err := ConvertFormat(jsonData, FormatJSON, FormatOf(path))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// ConvertFormat converts config data between formats. The data is converted as a
+// document rather than a Config, so keys packnplay doesn't know are kept. TOML has no
+// null, so null values are dropped when writing it.
+func ConvertFormat(data []byte, from, to string) ([]byte, error) {
+	var doc any
+	switch from {
+	case FormatYAML:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+	case FormatTOML:
+		if err := toml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse TOML: %w", err)
+		}
+	default:
+		if from == to {
+			return data, nil
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+	}
+	doc = normalizeDocument(doc, to == FormatTOML)
+	if doc == nil {
+		doc = map[string]any{}
+	}
+
+	switch to {
+	case FormatYAML:
+		return yaml.Marshal(doc)
+	case FormatTOML:
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(doc); err != nil {
+			return nil, fmt.Errorf("failed to write TOML: %w", err)
+		}
+		return buf.Bytes(), nil
+	default:
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+}
+
+// normalizeDocument turns a decoded document into plain maps, slices and scalars that
+// every encoder handles the same way: JSON numbers become int64 or float64, and nulls are
+// dropped when dropNulls is set
+func normalizeDocument(v any, dropNulls bool) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, value := range v {
+			value = normalizeDocument(value, dropNulls)
+			if value == nil && dropNulls {
+				continue
+			}
+			out[key] = value
+		}
+		return out
+	case []any:
+		out := make([]any, 0, len(v))
+		for _, value := range v {
+			value = normalizeDocument(value, dropNulls)
+			if value == nil && dropNulls {
+				continue
+			}
+			out = append(out, value)
+		}
+		return out
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	}
+	return v
+}
+
+// ConvertConfigFile rewrites the config file at path in format, next to it and with the
+// same base name. The old file is kept with a .bak suffix so the new one is the one
+// found. Returns the new file's path.
+func ConvertConfigFile(path, format string) (string, error) {
+	from := FormatOf(path)
+	if from == format {
+		return "", fmt.Errorf("%s is already %s", path, strings.ToUpper(format))
+	}
+
+	target := strings.TrimSuffix(path, filepath.Ext(path)) + "." + format
+	if _, err := os.Stat(target); err == nil {
+		return "", fmt.Errorf("%s already exists", target)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read config: %w", err)
+	}
+	converted, err := ConvertFormat(data, from, format)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(target, converted, 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	if err := os.Rename(path, path+".bak"); err != nil {
+		_ = os.Remove(target)
+		return "", fmt.Errorf("failed to move %s aside: %w", path, err)
+	}
+	return target, nil
+}
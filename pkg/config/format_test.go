@@ -0,0 +1,137 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sampleJSONConfig = `{
+  "container_runtime": "docker",
+  "default_credentials": {"git": true, "ssh": false},
+  "default_env_vars": null,
+  "default_resources": {"cpus": "1.5", "pids_limit": 1024},
+  "ulimits": ["nofile=65536:65536"],
+  "future_setting": {"enabled": true}
+}`
+
+func TestConvertFormatRoundTrip(t *testing.T) {
+	for _, format := range []string{FormatYAML, FormatTOML} {
+		t.Run(format, func(t *testing.T) {
+			converted, err := ConvertFormat([]byte(sampleJSONConfig), FormatJSON, format)
+			if err != nil {
+				t.Fatalf("ConvertFormat(json -> %s) error = %v", format, err)
+			}
+			if !strings.Contains(string(converted), "future_setting") {
+				t.Errorf("unknown key lost in %s:\n%s", format, converted)
+			}
+
+			back, err := ConvertFormat(converted, format, FormatJSON)
+			if err != nil {
+				t.Fatalf("ConvertFormat(%s -> json) error = %v", format, err)
+			}
+			path := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(path, back, 0644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := LoadConfigFromFile(path)
+			if err != nil {
+				t.Fatalf("LoadConfigFromFile() error = %v\n%s", err, back)
+			}
+			if cfg.ContainerRuntime != "docker" || !cfg.DefaultCredentials.Git || cfg.DefaultCredentials.SSH {
+				t.Errorf("config after round trip = %+v", cfg)
+			}
+			if want := (ResourcesConfig{CPUs: "1.5", PidsLimit: 1024}); cfg.DefaultResources != want {
+				t.Errorf("DefaultResources = %+v, want %+v", cfg.DefaultResources, want)
+			}
+			if !reflect.DeepEqual(cfg.Ulimits, []string{"nofile=65536:65536"}) {
+				t.Errorf("Ulimits = %v", cfg.Ulimits)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFromYAMLAndTOML(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.yaml": "container_runtime: podman\ndefault_credentials:\n  gh: true\ntimeout: 2h\n",
+		"config.toml": "container_runtime = \"podman\"\ntimeout = \"2h\"\n\n[default_credentials]\ngh = true\n",
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := LoadConfigFromFile(path)
+			if err != nil {
+				t.Fatalf("LoadConfigFromFile() error = %v", err)
+			}
+			if cfg.ContainerRuntime != "podman" || !cfg.DefaultCredentials.GH || cfg.Timeout != "2h" {
+				t.Errorf("LoadConfigFromFile() = %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestGetConfigPathFindsFormats(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	dir := filepath.Join(configHome, "packnplay")
+
+	if got := GetConfigPath(); got != filepath.Join(dir, "config.json") {
+		t.Errorf("GetConfigPath() with no config = %q", got)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte("container_runtime = \"docker\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := GetConfigPath(); got != filepath.Join(dir, "config.toml") {
+		t.Errorf("GetConfigPath() = %q, want config.toml", got)
+	}
+}
+
+func TestSaveConfigKeepsFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := SaveConfig(&Config{ContainerRuntime: "docker", Timeout: "1h"}, path); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "container_runtime: docker\n") {
+		t.Errorf("SaveConfig() didn't write YAML:\n%s", data)
+	}
+}
+
+func TestConvertConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(sampleJSONConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	target, err := ConvertConfigFile(path, FormatTOML)
+	if err != nil {
+		t.Fatalf("ConvertConfigFile() error = %v", err)
+	}
+	if target != filepath.Join(dir, "config.toml") {
+		t.Errorf("ConvertConfigFile() = %q", target)
+	}
+	if _, err := os.Stat(path + ".bak"); err != nil {
+		t.Errorf("old config not kept: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("old config still in place: %v", err)
+	}
+
+	if _, err := ConvertConfigFile(target, FormatTOML); err == nil {
+		t.Error("converting to the same format should fail")
+	}
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/obra/packnplay/pkg/registry"
+	"github.com/obra/packnplay/pkg/ui"
+)
+
+// imageCheckMsg carries what looking up an image field's value found
+type imageCheckMsg struct {
+	field string
+	image string
+	note  string // "" when the image was found, or couldn't be looked up
+}
+
+// imageExists looks image up in the local daemon, then in its registry; replaced in tests.
+// err is set when neither can tell, e.g. for a registry that can't be reached, and is
+// registry.ErrNotFoundOrPrivate when the registry wouldn't show it without a login.
+var imageExists = func(runtime, image string) (bool, error) {
+	if runtime == "" {
+		runtime = "docker"
+	}
+	if _, err := exec.LookPath(runtime); err == nil {
+		if exec.Command(runtime, "image", "inspect", image).Run() == nil {
+			return true, nil
+		}
+	}
+	return registry.NewClient().Exists(image)
+}
+
+// validateImageValue returns why image isn't a valid image reference, or "" when it is.
+// Empty is valid: it leaves the setting unset.
+func validateImageValue(image string) string {
+	if image == "" {
+		return ""
+	}
+	if _, err := registry.ParseReference(image); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// imageNotFoundNote warns about an image neither the local daemon nor its registry has
+func imageNotFoundNote(image string) string {
+	ref, err := registry.ParseReference(image)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("Not found locally or on %s; runs will fail to pull it", ref.Registry)
+}
+
+// imagePrivateNote warns about an image its registry wouldn't show without a login
+func imagePrivateNote(image string) string {
+	ref, err := registry.ParseReference(image)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("Not found locally, and %s says it doesn't exist or is private; runs need a login to pull it", ref.Registry)
+}
+
+// lookUpImage returns the note for image, "" when it exists or can't be looked up
+func lookUpImage(runtime, image string) string {
+	found, err := imageExists(runtime, image)
+	if errors.Is(err, registry.ErrNotFoundOrPrivate) {
+		return imagePrivateNote(image)
+	}
+	if err != nil || found {
+		return ""
+	}
+	return imageNotFoundNote(image)
+}
+
+// checkImage looks image up in the background for the field named field
+func checkImage(field, runtime, image string) tea.Cmd {
+	return func() tea.Msg {
+		return imageCheckMsg{field: field, image: image, note: lookUpImage(runtime, image)}
+	}
+}
+
+// validateImageField checks an image field's new value: an invalid reference is shown as
+// an error right away, and a valid one is looked up in the background
+func (m *SettingsModal) validateImageField(field *SettingsField) tea.Cmd {
+	image := strings.TrimSpace(field.value.(string))
+	field.value = image
+	field.err = validateImageValue(image)
+	field.note, field.checking = "", false
+	if field.err != "" || image == "" {
+		return nil
+	}
+	field.note, field.checking = "Checking the image"+ui.Sym().Ellipsis, true
+	return checkImage(field.name, m.runtime(), image)
+}
+
+// applyImageCheck shows a lookup's result on its field, unless the value changed since
+func (m *SettingsModal) applyImageCheck(msg imageCheckMsg) {
+	for i := range m.sections {
+		for j := range m.sections[i].fields {
+			field := &m.sections[i].fields[j]
+			if field.name == msg.field && field.value == msg.image {
+				field.note, field.checking = msg.note, false
+			}
+		}
+	}
+}
+
+// focusInvalidField moves to the first field with an invalid value, reporting whether
+// there is one
+func (m *SettingsModal) focusInvalidField() bool {
+	for i := range m.sections {
+		for j := range m.sections[i].fields {
+			if m.sections[i].fields[j].err != "" {
+				m.currentSection, m.currentField = i, j
+				m.buttonFocused = false
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// runtime is the container runtime images are looked up with ("" for the default)
+func (m *SettingsModal) runtime() string {
+	if m.config == nil {
+		return ""
+	}
+	return m.config.ContainerRuntime
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/registry"
+)
+
+// stubImageExists makes image lookups answer found without the network or a daemon
+func stubImageExists(t *testing.T, found bool) {
+	t.Helper()
+	saved := imageExists
+	imageExists = func(runtime, image string) (bool, error) { return found, nil }
+	t.Cleanup(func() { imageExists = saved })
+}
+
+func TestValidateImageValue(t *testing.T) {
+	for _, image := range []string{"", "ubuntu", "ghcr.io/obra/packnplay-default:slim", "localhost:5000/img@sha256:abc"} {
+		if problem := validateImageValue(image); problem != "" {
+			t.Errorf("validateImageValue(%q) = %q, want valid", image, problem)
+		}
+	}
+	for _, image := range []string{"Ubuntu", "my image", "ghcr.io/obra/img:", "img@nodigest"} {
+		if validateImageValue(image) == "" {
+			t.Errorf("validateImageValue(%q) accepted an invalid reference", image)
+		}
+	}
+}
+
+func TestLookUpImagePrivate(t *testing.T) {
+	saved := imageExists
+	imageExists = func(runtime, image string) (bool, error) {
+		return false, fmt.Errorf("library/nope on docker.io: %w", registry.ErrNotFoundOrPrivate)
+	}
+	t.Cleanup(func() { imageExists = saved })
+
+	if note := lookUpImage("", "nope:v1"); !strings.Contains(note, "doesn't exist or is private") {
+		t.Errorf("lookUpImage() = %q, want it to say the image may be private", note)
+	}
+}
+
+func TestSettingsModalImageValidation(t *testing.T) {
+	stubImageExists(t, false)
+	modal, err := createSectionModal(&Config{DefaultContainer: GetDefaultContainerConfig()}, "container")
+	if err != nil {
+		t.Fatal(err)
+	}
+	field := &modal.sections[0].fields[0]
+	if field.name != "container-image" {
+		t.Fatalf("first field = %s, want container-image", field.name)
+	}
+
+	// An invalid reference is an error under the field, and saving goes back to it
+	modal.Update(keyMsg("enter"))
+	modal.textInput.SetValue("My-Org/dev")
+	if _, cmd := modal.Update(keyMsg("enter")); cmd != nil {
+		t.Error("an invalid reference shouldn't be looked up")
+	}
+	if !strings.Contains(field.err, "lowercase") || !strings.Contains(modal.View(), "lowercase") {
+		t.Errorf("field.err = %q, want it shown in the view", field.err)
+	}
+	modal.currentField = 2
+	modal.buttonFocused = true
+	modal.Update(keyMsg("s"))
+	if modal.confirming || modal.buttonFocused || modal.currentField != 0 {
+		t.Error("saving with an invalid image should focus it instead of confirming")
+	}
+
+	// A valid one is looked up in the background
+	modal.Update(keyMsg("enter"))
+	modal.textInput.SetValue(" my-org/dev:v1 ")
+	_, cmd := modal.Update(keyMsg("enter"))
+	if field.err != "" || field.value != "my-org/dev:v1" || !field.checking {
+		t.Fatalf("field = %+v, want a lookup in progress", field)
+	}
+	modal.Update(cmd())
+	if field.checking || !strings.Contains(field.note, "docker.io") {
+		t.Errorf("field.note = %q, want a not-found warning", field.note)
+	}
+
+	// A lookup that finishes after the value changed again is dropped
+	field.value = "other:v2"
+	modal.applyImageCheck(imageCheckMsg{field: "container-image", image: "my-org/dev:v1", note: "stale"})
+	if field.note == "stale" {
+		t.Error("a stale lookup replaced the note")
+	}
+}
+
+func TestRunPromptModalImageValidation(t *testing.T) {
+	stubImageExists(t, false)
+	modal, err := createSectionModal(&Config{DefaultContainer: GetDefaultContainerConfig()}, "container")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// image (invalid, then valid but missing), then keep the rest and save
+	input := strings.Join([]string{"my image", "my-org/dev:v1", "", "", "", "", "y"}, "\n") + "\n"
+	var out bytes.Buffer
+	if err := runPromptModal(modal, strings.NewReader(input), &out); err != nil {
+		t.Fatalf("runPromptModal() error = %v", err)
+	}
+	if got := modal.sections[0].fields[0].value; got != "my-org/dev:v1" {
+		t.Errorf("image = %v, want my-org/dev:v1", got)
+	}
+	if !strings.Contains(out.String(), "whitespace") || !strings.Contains(out.String(), "Warning: Not found") {
+		t.Errorf("prompt output should explain both problems:\n%s", out.String())
+	}
+}
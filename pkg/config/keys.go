@@ -0,0 +1,316 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Keys address settings with the dotted json names of the fields leading to them, e.g.
+// default_container.image or env_configs.work.env_vars. In a map of plain values, such as
+// labels, the rest of the key is the map key, so labels.com.example.team is the
+// "com.example.team" label.
+
+// resolveKey checks key against Config and returns the path into the config document it
+// addresses and the type of the setting there
+func resolveKey(key string) ([]string, reflect.Type, error) {
+	parts := strings.Split(key, ".")
+	t := reflect.TypeOf(Config{})
+	var path []string
+	for i := 0; i < len(parts); i++ {
+		part := parts[i]
+		if part == "" {
+			return nil, nil, fmt.Errorf("invalid key '%s'", key)
+		}
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := fieldByJSONName(t, part)
+			if !ok {
+				return nil, nil, fmt.Errorf("unknown key '%s'", strings.Join(parts[:i+1], "."))
+			}
+			t = field.Type
+		case reflect.Map:
+			t = t.Elem()
+			if kind := derefKind(t); kind != reflect.Struct && kind != reflect.Map {
+				part = strings.Join(parts[i:], ".")
+				i = len(parts)
+			}
+		default:
+			return nil, nil, fmt.Errorf("'%s' is a single value and has no key '%s'", strings.Join(parts[:i], "."), part)
+		}
+		path = append(path, part)
+	}
+	return path, t, nil
+}
+
+// fieldByJSONName finds the field of t with the json name name
+func fieldByJSONName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tag == name && field.IsExported() {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func derefKind(t reflect.Type) reflect.Kind {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind()
+}
+
+// parseKeyValue parses a value given on the command line for a setting of type t.
+// Strings are taken as they are, lists of strings may be comma-separated, and other
+// structured values are JSON.
+func parseKeyValue(t reflect.Type, raw string) (any, error) {
+	switch derefKind(t) {
+	case reflect.String:
+		return raw, nil
+	case reflect.Bool:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not true or false", raw)
+		}
+		return v, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not a whole number", raw)
+		}
+		return v, nil
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not a number", raw)
+		}
+		return v, nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(raw), "[") {
+			items := []any{}
+			for _, item := range strings.Split(raw, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			return items, nil
+		}
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.UseNumber()
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil, fmt.Errorf("'%s' is not valid JSON: %w", raw, err)
+	}
+	switch derefKind(t) {
+	case reflect.Struct, reflect.Map:
+		if _, ok := v.(map[string]any); !ok {
+			return nil, fmt.Errorf("'%s' is not a JSON object", raw)
+		}
+	case reflect.Slice:
+		if _, ok := v.([]any); !ok {
+			return nil, fmt.Errorf("'%s' is not a JSON array", raw)
+		}
+	}
+	return v, nil
+}
+
+// configDocument returns cfg as a generic JSON document
+func configDocument(cfg *Config) (map[string]any, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return decodeDocument(data)
+}
+
+func decodeDocument(data []byte) (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc map[string]any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if doc == nil {
+		doc = map[string]any{}
+	}
+	return doc, nil
+}
+
+// GetKey returns the setting at key in cfg, and whether it is set. Fields packnplay
+// knows read as their zero value when the file leaves them out; map entries don't exist
+// until set.
+func GetKey(cfg *Config, key string) (any, bool, error) {
+	path, _, err := resolveKey(key)
+	if err != nil {
+		return nil, false, err
+	}
+	doc, err := configDocument(cfg)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var v any = doc
+	for _, part := range path {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, false, nil
+		}
+		if v, ok = m[part]; !ok {
+			return nil, false, nil
+		}
+	}
+	return v, v != nil, nil
+}
+
+// ListKeys flattens cfg into the dotted keys of its values, leaving out empty ones
+func ListKeys(cfg *Config) (map[string]any, error) {
+	doc, err := configDocument(cfg)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]any)
+	flattenDocument(doc, "", keys)
+	return keys, nil
+}
+
+func flattenDocument(v any, prefix string, keys map[string]any) {
+	switch v := v.(type) {
+	case nil:
+	case map[string]any:
+		for key, value := range v {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flattenDocument(value, key, keys)
+		}
+	case []any:
+		if len(v) > 0 {
+			keys[prefix] = v
+		}
+	default:
+		keys[prefix] = v
+	}
+}
+
+// SortedKeys returns the keys of a ListKeys result in order
+func SortedKeys(keys map[string]any) []string {
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// SetKey parses value for the setting at key and writes it to the config file at path.
+// The rest of the file, including keys packnplay doesn't know, is kept as it is. A new
+// file starts from the defaults packnplay run would use.
+func SetKey(path, key, value string) error {
+	docPath, t, err := resolveKey(key)
+	if err != nil {
+		return err
+	}
+	v, err := parseKeyValue(t, value)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+
+	return editConfigDocument(path, func(doc map[string]any) error {
+		m := doc
+		for _, part := range docPath[:len(docPath)-1] {
+			child, ok := m[part].(map[string]any)
+			if !ok {
+				child = map[string]any{}
+				m[part] = child
+			}
+			m = child
+		}
+		m[docPath[len(docPath)-1]] = v
+		return nil
+	})
+}
+
+// UnsetKey removes the setting at key from the config file at path, so it goes back to
+// its default
+func UnsetKey(path, key string) error {
+	docPath, _, err := resolveKey(key)
+	if err != nil {
+		return err
+	}
+
+	return editConfigDocument(path, func(doc map[string]any) error {
+		m := doc
+		for _, part := range docPath[:len(docPath)-1] {
+			child, ok := m[part].(map[string]any)
+			if !ok {
+				return fmt.Errorf("%s is not set", key)
+			}
+			m = child
+		}
+		last := docPath[len(docPath)-1]
+		if _, ok := m[last]; !ok {
+			return fmt.Errorf("%s is not set", key)
+		}
+		delete(m, last)
+		return nil
+	})
+}
+
+// editConfigDocument applies edit to the config file at path as a generic document, and
+// saves the result if it still loads as a valid Config
+func editConfigDocument(path string, edit func(doc map[string]any) error) error {
+	var doc map[string]any
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		cfg, err := LoadExistingOrEmpty(path)
+		if err != nil {
+			return err
+		}
+		if doc, err = configDocument(cfg); err != nil {
+			return err
+		}
+	} else {
+		data, err := ReadConfigFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read config: %w", err)
+		}
+		if doc, err = decodeDocument(data); err != nil {
+			return err
+		}
+	}
+
+	if err := edit(doc); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if problems := cfg.Problems(); len(problems) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	return WriteConfigFile(path, data)
+}
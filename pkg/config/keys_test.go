@@ -0,0 +1,175 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestResolveKey(t *testing.T) {
+	tests := []struct {
+		key      string
+		wantPath []string
+		wantKind reflect.Kind
+		wantErr  string
+	}{
+		{"default_container.image", []string{"default_container", "image"}, reflect.String, ""},
+		{"default_env_vars", []string{"default_env_vars"}, reflect.Slice, ""},
+		{"labels.com.example.team", []string{"labels", "com.example.team"}, reflect.String, ""},
+		{"env_configs.work.env_vars.TOKEN", []string{"env_configs", "work", "env_vars", "TOKEN"}, reflect.String, ""},
+		{"default_resources", []string{"default_resources"}, reflect.Struct, ""},
+		{"default_container.colour", nil, 0, "unknown key 'default_container.colour'"},
+		{"timeout.hours", nil, 0, "single value"},
+		{"network..mode", nil, 0, "invalid key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			path, typ, err := resolveKey(tt.key)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("resolveKey() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveKey() error = %v", err)
+			}
+			if !reflect.DeepEqual(path, tt.wantPath) || typ.Kind() != tt.wantKind {
+				t.Errorf("resolveKey() = %q %v, want %q %v", path, typ.Kind(), tt.wantPath, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestParseKeyValue(t *testing.T) {
+	tests := []struct {
+		key     string
+		raw     string
+		want    any
+		wantErr bool
+	}{
+		{"default_container.image", "foo:latest", "foo:latest", false},
+		{"auto_commit", "true", true, false},
+		{"auto_commit", "yes", nil, true},
+		{"default_container.check_frequency_hours", "12", int64(12), false},
+		{"default_env_vars", "A, B,", []any{"A", "B"}, false},
+		{"default_env_vars", `["A,B"]`, []any{"A,B"}, false},
+		{"default_resources", `{"cpus": "2"}`, map[string]any{"cpus": "2"}, false},
+		{"default_resources", "2", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.raw, func(t *testing.T) {
+			_, typ, err := resolveKey(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := parseKeyValue(typ, tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseKeyValue() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseKeyValue() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseKeyValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetGetUnsetKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"container_runtime": "docker", "future_setting": {"x": 1}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, value := range map[string]string{
+		"default_container.image": "foo:latest",
+		"labels.com.example.team": "platform",
+		"default_env_vars":        "ANTHROPIC_API_KEY,OPENAI_API_KEY",
+	} {
+		if err := SetKey(path, key, value); err != nil {
+			t.Fatalf("SetKey(%s) error = %v", key, err)
+		}
+	}
+	if err := SetKey(path, "summary", "loud"); err == nil || !strings.Contains(err.Error(), `unknown summary "loud"`) {
+		t.Errorf("SetKey() with an invalid mode error = %v", err)
+	}
+	if err := SetKey(path, "default_resources", `"2"`); err == nil {
+		t.Error("SetKey() with the wrong type should fail")
+	}
+
+	cfg, err := LoadConfigFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DefaultContainer.Image != "foo:latest" || cfg.Labels["com.example.team"] != "platform" || len(cfg.DefaultEnvVars) != 2 {
+		t.Errorf("config after SetKey() = %+v", cfg)
+	}
+	if cfg.Summary != "" {
+		t.Errorf("invalid value was saved: summary = %q", cfg.Summary)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "future_setting") {
+		t.Errorf("SetKey() dropped an unknown key:\n%s", data)
+	}
+
+	if got, ok, err := GetKey(cfg, "labels.com.example.team"); err != nil || !ok || got != "platform" {
+		t.Errorf("GetKey() = %v, %v, %v", got, ok, err)
+	}
+	if _, ok, err := GetKey(cfg, "labels.owner"); err != nil || ok {
+		t.Errorf("GetKey() of an unset label = %v, %v", ok, err)
+	}
+
+	if err := UnsetKey(path, "default_container.image"); err != nil {
+		t.Fatalf("UnsetKey() error = %v", err)
+	}
+	if err := UnsetKey(path, "labels.owner"); err == nil {
+		t.Error("UnsetKey() of an unset key should fail")
+	}
+	if cfg, _ = LoadConfigFromFile(path); cfg.DefaultContainer.Image != "" {
+		t.Errorf("UnsetKey() left image = %q", cfg.DefaultContainer.Image)
+	}
+}
+
+func TestSetKeyNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "packnplay", "config.yaml")
+	if err := SetKey(path, "timeout", "2h"); err != nil {
+		t.Fatalf("SetKey() error = %v", err)
+	}
+	cfg, err := LoadConfigFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Timeout != "2h" || !cfg.DefaultCredentials.Git {
+		t.Errorf("new config = %+v, want the timeout over the defaults", cfg)
+	}
+}
+
+func TestListKeys(t *testing.T) {
+	cfg := &Config{
+		ContainerRuntime: "docker",
+		Labels:           map[string]string{"team": "platform"},
+		DefaultEnvVars:   []string{},
+		GroupAdd:         []string{"video"},
+	}
+	keys, err := ListKeys(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys["container_runtime"] != "docker" || keys["labels.team"] != "platform" || !reflect.DeepEqual(keys["group_add"], []any{"video"}) {
+		t.Errorf("ListKeys() = %v", keys)
+	}
+	if _, ok := keys["default_env_vars"]; ok {
+		t.Error("ListKeys() should leave out empty lists")
+	}
+	if sorted := SortedKeys(keys); !sort.StringsAreSorted(sorted) {
+		t.Errorf("SortedKeys() = %q", sorted)
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+// Effective is the configuration a run in a project starts from: the global config with
+// the project's .packnplay.json layered on top. packnplay run applies its flags last.
+//
+// Precedence is flags > project > global. Single values (image, timeout, each credential
+// and resource limit, each label, the build cache, the dependency caches) are replaced by the higher layer; lists (ulimits, group
+// add) are added to the lower layer's.
+type Effective struct {
+	Image            string                   `json:"image"`
+	Command          CommandLine              `json:"command,omitempty"`
+	Env              []string                 `json:"env,omitempty"`
+	Credentials      Credentials              `json:"credentials"`
+	PublishPorts     []string                 `json:"publish_ports,omitempty"`
+	Mounts           []string                 `json:"mounts,omitempty"`
+	GroupAdd         []string                 `json:"group_add,omitempty"`
+	Labels           map[string]string        `json:"labels,omitempty"`
+	Ulimits          []string                 `json:"ulimits,omitempty"`
+	Resources        ResourcesConfig          `json:"resources"`
+	Timeout          string                   `json:"timeout,omitempty"`
+	Docker           string                   `json:"docker,omitempty"`
+	BuildCache       BuildCacheConfig         `json:"build_cache"`
+	Services         map[string]ServiceConfig `json:"services,omitempty"`
+	DependencyCaches DependencyCachesConfig   `json:"dependency_caches"`
+}
+
+// Layer merges the project config for projectDir over the global config
+func Layer(global *Config, project *ProjectConfig, projectDir string) (*Effective, error) {
+	mounts, err := project.ResolveMounts(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	services, err := project.ResolveServices(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	eff := &Effective{
+		Image:            global.GetDefaultImage(),
+		Command:          project.Command,
+		Env:              project.Env,
+		Credentials:      project.Credentials.Apply(global.DefaultCredentials),
+		PublishPorts:     project.PublishPorts,
+		Mounts:           mounts,
+		GroupAdd:         append(append([]string{}, global.GroupAdd...), project.GroupAdd...),
+		Labels:           make(map[string]string, len(global.Labels)+len(project.Labels)),
+		Ulimits:          append(append([]string{}, global.Ulimits...), project.Ulimits...),
+		Resources:        global.DefaultResources,
+		Timeout:          global.Timeout,
+		Docker:           global.Docker.Mode,
+		BuildCache:       global.BuildCache,
+		Services:         services,
+		DependencyCaches: global.DependencyCaches,
+	}
+	if project.Image != "" {
+		eff.Image = project.Image
+	}
+	for key, value := range global.Labels {
+		eff.Labels[key] = value
+	}
+	for key, value := range project.Labels {
+		eff.Labels[key] = value
+	}
+	if project.Resources != nil {
+		eff.Resources = eff.Resources.Apply(*project.Resources)
+	}
+	if project.Timeout != "" {
+		eff.Timeout = project.Timeout
+	}
+	if project.Docker != "" {
+		eff.Docker = project.Docker
+	}
+	// The cache is a unit: a project's source and destination go together
+	if project.BuildCache != nil {
+		eff.BuildCache = *project.BuildCache
+	}
+	if project.DependencyCaches != nil {
+		eff.DependencyCaches = *project.DependencyCaches
+	}
+	return eff, nil
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLayer(t *testing.T) {
+	projectDir := t.TempDir()
+	no := false
+	global := &Config{
+		DefaultImage:       "ghcr.io/obra/packnplay-default:latest",
+		DefaultCredentials: Credentials{Git: true, SSH: true},
+		GroupAdd:           []string{"video"},
+		Labels:             map[string]string{"team": "platform", "env": "dev"},
+		Ulimits:            []string{"nofile=1024"},
+		DefaultResources:   ResourcesConfig{CPUs: "2", Memory: "4g"},
+		Timeout:            "2h",
+		Docker:             DockerConfig{Mode: DockerSocket},
+	}
+	project := &ProjectConfig{
+		Image:        "node:22",
+		Mounts:       []string{"data:/data:ro"},
+		PublishPorts: []string{"3000:3000"},
+		Credentials:  &CredentialOverrides{SSH: &no},
+		GroupAdd:     []string{"docker"},
+		Labels:       map[string]string{"team": "payments"},
+		Ulimits:      []string{"nofile=65536"},
+		Env:          []string{"NODE_ENV=test"},
+		Resources:    &ResourcesConfig{Memory: "8g"},
+		Command:      CommandLine{"npm", "test"},
+		Docker:       DockerDind,
+	}
+
+	eff, err := Layer(global, project, projectDir)
+	if err != nil {
+		t.Fatalf("Layer() error = %v", err)
+	}
+
+	want := &Effective{
+		Image:        "node:22",
+		Command:      CommandLine{"npm", "test"},
+		Env:          []string{"NODE_ENV=test"},
+		Credentials:  Credentials{Git: true},
+		PublishPorts: []string{"3000:3000"},
+		Mounts:       []string{filepath.Join(projectDir, "data") + ":/data:ro"},
+		GroupAdd:     []string{"video", "docker"},
+		Labels:       map[string]string{"team": "payments", "env": "dev"},
+		Ulimits:      []string{"nofile=1024", "nofile=65536"},
+		Resources:    ResourcesConfig{CPUs: "2", Memory: "8g"},
+		Timeout:      "2h",
+		Docker:       DockerDind,
+	}
+	if !reflect.DeepEqual(eff, want) {
+		t.Errorf("Layer() =\n%+v\nwant\n%+v", eff, want)
+	}
+}
+
+func TestLayerWithoutProjectConfig(t *testing.T) {
+	global := &Config{DefaultImage: "ubuntu:24.04", Timeout: "30m"}
+	eff, err := Layer(global, &ProjectConfig{}, t.TempDir())
+	if err != nil {
+		t.Fatalf("Layer() error = %v", err)
+	}
+	if eff.Image != "ubuntu:24.04" || eff.Timeout != "30m" || eff.Command != nil {
+		t.Errorf("Layer() = %+v, want the global settings", eff)
+	}
+}
+
+func TestLayerBuildCache(t *testing.T) {
+	global := &Config{BuildCache: BuildCacheConfig{From: []string{"type=registry,ref=ghcr.io/team/base:cache"}, To: "type=inline"}}
+
+	eff, err := Layer(global, &ProjectConfig{}, t.TempDir())
+	if err != nil {
+		t.Fatalf("Layer() error = %v", err)
+	}
+	if !reflect.DeepEqual(eff.BuildCache, global.BuildCache) {
+		t.Errorf("BuildCache = %+v, want the global one", eff.BuildCache)
+	}
+
+	// A project's cache replaces the global one as a whole
+	project := &ProjectConfig{BuildCache: &BuildCacheConfig{From: []string{"type=registry,ref=ghcr.io/team/app:cache"}}}
+	if eff, _ = Layer(global, project, t.TempDir()); !reflect.DeepEqual(eff.BuildCache, *project.BuildCache) {
+		t.Errorf("BuildCache = %+v, want the project's", eff.BuildCache)
+	}
+}
+
+func TestProjectCommand(t *testing.T) {
+	var pc ProjectConfig
+	if err := json.Unmarshal([]byte(`{"command": "npm ci && npm test"}`), &pc); err != nil {
+		t.Fatal(err)
+	}
+	if want := (CommandLine{"sh", "-c", "npm ci && npm test"}); !reflect.DeepEqual(pc.Command, want) {
+		t.Errorf("Command = %q, want %q", pc.Command, want)
+	}
+
+	if err := json.Unmarshal([]byte(`{"command": ["claude", "--resume"]}`), &pc); err != nil {
+		t.Fatal(err)
+	}
+	if want := (CommandLine{"claude", "--resume"}); !reflect.DeepEqual(pc.Command, want) {
+		t.Errorf("Command = %q, want %q", pc.Command, want)
+	}
+
+	if err := json.Unmarshal([]byte(`{"command": 42}`), &pc); err == nil {
+		t.Error("a numeric command should be rejected")
+	}
+}
+
+func TestLayerDependencyCaches(t *testing.T) {
+	global := &Config{DependencyCaches: DependencyCachesConfig{Enabled: true, Scope: DependencyCacheGlobal}}
+
+	eff, err := Layer(global, &ProjectConfig{}, t.TempDir())
+	if err != nil {
+		t.Fatalf("Layer() error = %v", err)
+	}
+	if !reflect.DeepEqual(eff.DependencyCaches, global.DependencyCaches) {
+		t.Errorf("DependencyCaches = %+v, want the global setting", eff.DependencyCaches)
+	}
+
+	// A project's setting replaces the global one as a whole
+	project := &ProjectConfig{DependencyCaches: &DependencyCachesConfig{Enabled: true, Caches: []string{"npm"}}}
+	if eff, _ = Layer(global, project, t.TempDir()); !reflect.DeepEqual(eff.DependencyCaches, *project.DependencyCaches) {
+		t.Errorf("DependencyCaches = %+v, want the project's", eff.DependencyCaches)
+	}
+}
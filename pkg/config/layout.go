@@ -0,0 +1,59 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/obra/packnplay/pkg/ui"
+)
+
+// Layout thresholds for narrow terminals (small splits, ConPTY default windows)
+const (
+	// narrowWidth is the width below which modal fields stack title above value
+	narrowWidth = 60
+	// stackedPanesWidth is the width below which the env editor stacks its panes
+	stackedPanesWidth = 72
+	// maxTitleWidth is the title column width on wide terminals
+	maxTitleWidth = 40
+)
+
+// truncate shortens s to fit in width display cells, marking the cut with an ellipsis
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+
+	ellipsis := ui.Sym().Ellipsis
+	runes := []rune(s)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+lipgloss.Width(ellipsis) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + ellipsis
+}
+
+// helpLine joins key hints into one help line
+func helpLine(hints ...string) string {
+	return strings.Join(hints, " "+ui.Sym().Bullet+" ")
+}
+
+// titleColumnWidth returns the field title column width for a terminal width
+func titleColumnWidth(width int) int {
+	if w := width / 2; w < maxTitleWidth {
+		return w
+	}
+	return maxTitleWidth
+}
+
+// clampWidth keeps a layout width between min and max
+func clampWidth(width, min, max int) int {
+	if width < min {
+		return min
+	}
+	if width > max {
+		return max
+	}
+	return width
+}
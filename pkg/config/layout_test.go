@@ -0,0 +1,105 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/obra/packnplay/pkg/ui"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		input string
+		width int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"a much longer title", 8, "a much …"},
+		{"anything", 0, ""},
+	}
+
+	for _, tt := range tests {
+		if got := truncate(tt.input, tt.width); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.input, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestPlainSymbols(t *testing.T) {
+	ui.Setup(true, func(string) string { return "" })
+	defer ui.Setup(false, func(string) string { return "" })
+
+	if got := truncate("a much longer title", 8); got != "a muc..." {
+		t.Errorf("truncate() in plain mode = %q", got)
+	}
+	if got := helpLine("Enter save", "Esc back"); got != "Enter save | Esc back" {
+		t.Errorf("helpLine() in plain mode = %q", got)
+	}
+	if got := (ConfigChange{Key: "summary", Old: "short", New: "full"}).String(); got != "summary: short -> full" {
+		t.Errorf("ConfigChange.String() in plain mode = %q", got)
+	}
+}
+
+// maxLineWidth returns the widest rendered line in cells
+func maxLineWidth(view string) int {
+	widest := 0
+	for _, line := range strings.Split(view, "\n") {
+		if w := lipgloss.Width(line); w > widest {
+			widest = w
+		}
+	}
+	return widest
+}
+
+func TestSettingsModalFitsNarrowTerminal(t *testing.T) {
+	existing := &Config{
+		ContainerRuntime: "docker",
+		DefaultContainer: DefaultContainerConfig{Image: "ghcr.io/some-very-long-organization-name/some-long-image:latest"},
+	}
+
+	for _, width := range []int{40, 60, 100} {
+		modal := createSettingsModal(existing)
+		modal.Update(tea.WindowSizeMsg{Width: width, Height: 30})
+
+		if got := maxLineWidth(modal.View()); got > width {
+			t.Errorf("width %d: widest line = %d cells", width, got)
+		}
+	}
+}
+
+func TestEnvEditorStacksPanesWhenNarrow(t *testing.T) {
+	profiles := map[string]EnvConfig{
+		"a-rather-long-profile-name": {EnvVars: map[string]string{"ANTHROPIC_BASE_URL": "https://api.example.com/a/very/long/path"}},
+	}
+
+	narrow := newEnvProfilesEditor(profiles)
+	narrow.Update(tea.WindowSizeMsg{Width: 50, Height: 30})
+	view := narrow.View()
+	if got := maxLineWidth(view); got > 50 {
+		t.Errorf("narrow editor widest line = %d cells, want <= 50", got)
+	}
+	profilesLine, envLine := -1, -1
+	for i, line := range strings.Split(view, "\n") {
+		if !strings.Contains(line, "│") {
+			continue // Only look at pane contents, not the title
+		}
+		if strings.Contains(line, "Profiles") {
+			profilesLine = i
+		}
+		if strings.Contains(line, "Environment") {
+			envLine = i
+		}
+	}
+	if profilesLine < 0 || envLine <= profilesLine {
+		t.Errorf("narrow editor should stack the env pane below the profiles pane:\n%s", view)
+	}
+
+	wide := newEnvProfilesEditor(profiles)
+	wide.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	if got := maxLineWidth(wide.View()); got > 120 {
+		t.Errorf("wide editor widest line = %d cells, want <= 120", got)
+	}
+}
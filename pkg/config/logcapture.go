@@ -0,0 +1,45 @@
+package config
+
+import "fmt"
+
+// Defaults for log capture rotation
+const (
+	DefaultLogMaxSize  = "10m"
+	DefaultLogMaxFiles = 3
+)
+
+// LogCaptureConfig saves the output of every session to a log file per container on the
+// host, so it can be read after the session or the container is gone
+type LogCaptureConfig struct {
+	Enabled  bool   `json:"enabled,omitempty"`
+	MaxSize  string `json:"max_size,omitempty"`  // rotate the log when it reaches this size, e.g. "10m" (default: 10m)
+	MaxFiles int    `json:"max_files,omitempty"` // rotated logs to keep besides the current one (default: 3)
+}
+
+// Limits returns the size at which the log rotates and how many rotated logs to keep,
+// with the defaults filled in
+func (l LogCaptureConfig) Limits() (int64, int, error) {
+	size := l.MaxSize
+	if size == "" {
+		size = DefaultLogMaxSize
+	}
+	maxSize, err := ParseMemory(size)
+	if err != nil || maxSize <= 0 {
+		return 0, 0, fmt.Errorf("invalid max_size '%s' (use a size like 10m or 1g)", l.MaxSize)
+	}
+
+	maxFiles := l.MaxFiles
+	if maxFiles < 0 {
+		return 0, 0, fmt.Errorf("invalid max_files %d (use 0 or more)", l.MaxFiles)
+	}
+	if maxFiles == 0 {
+		maxFiles = DefaultLogMaxFiles
+	}
+	return maxSize, maxFiles, nil
+}
+
+// Validate checks the rotation limits
+func (l LogCaptureConfig) Validate() error {
+	_, _, err := l.Limits()
+	return err
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLogCaptureLimits(t *testing.T) {
+	tests := []struct {
+		name         string
+		capture      LogCaptureConfig
+		wantSize     int64
+		wantMaxFiles int
+		wantErr      string
+	}{
+		{"defaults", LogCaptureConfig{Enabled: true}, 10 << 20, DefaultLogMaxFiles, ""},
+		{"custom", LogCaptureConfig{MaxSize: "512k", MaxFiles: 7}, 512 << 10, 7, ""},
+		{"bad size", LogCaptureConfig{MaxSize: "lots"}, 0, 0, "invalid max_size 'lots'"},
+		{"negative files", LogCaptureConfig{MaxFiles: -1}, 0, 0, "invalid max_files -1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size, files, err := tt.capture.Limits()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Limits() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Limits() error = %v", err)
+			}
+			if size != tt.wantSize || files != tt.wantMaxFiles {
+				t.Errorf("Limits() = %d, %d, want %d, %d", size, files, tt.wantSize, tt.wantMaxFiles)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Migration is something an older packnplay version left behind that the current
+// naming scheme or config format doesn't match
+type Migration struct {
+	Subject string       // the container, worktree or config file
+	Problem string       // what doesn't match
+	Fix     string       // what Apply does, or what to do by hand when Apply is nil
+	Apply   func() error // nil when it can't be fixed without risk
+}
+
+// defaultImageFallback is what default_image was set to on load by older versions,
+// which then saved it along with the rest of the config
+const defaultImageFallback = "ghcr.io/obra/packnplay-default:latest"
+
+// ConfigMigrations checks the global config file at path for settings older versions
+// wrote: the deprecated default_image, and keys packnplay no longer reads. A missing
+// file has nothing to migrate.
+func ConfigMigrations(path string) ([]Migration, error) {
+	data, err := ReadConfigFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	var migrations []Migration
+	if m, ok := defaultImageMigration(&cfg, path); ok {
+		migrations = append(migrations, m)
+	}
+	for _, key := range unknownKeys(data, reflect.TypeOf(Config{})) {
+		migrations = append(migrations, Migration{
+			Subject: path,
+			Problem: fmt.Sprintf("%s isn't a setting packnplay reads (left by an older version, or misspelled)", key),
+			Fix:     "remove it from the file, or rename it to the current setting (see packnplay config list)",
+		})
+	}
+	return migrations, nil
+}
+
+// ProjectConfigMigrations checks the project's .packnplay.json for keys packnplay no
+// longer reads
+func ProjectConfigMigrations(projectDir string) ([]Migration, error) {
+	path := GetProjectConfigPath(projectDir)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ProjectConfigFile, err)
+	}
+
+	var migrations []Migration
+	for _, key := range unknownKeys(data, reflect.TypeOf(ProjectConfig{})) {
+		migrations = append(migrations, Migration{
+			Subject: path,
+			Problem: fmt.Sprintf("%s isn't a setting packnplay reads (left by an older version, or misspelled)", key),
+			Fix:     "remove it from the file, or rename it to the current setting",
+		})
+	}
+	return migrations, nil
+}
+
+// defaultImageMigration moves a default_image the user set into default_container.image,
+// or drops it when default_container already decides the image. Either is only offered
+// when the image runs use stays the same.
+func defaultImageMigration(cfg *Config, path string) (Migration, bool) {
+	if cfg.DefaultImage == "" || cfg.DefaultImage == defaultImageFallback {
+		return Migration{}, false
+	}
+	m := Migration{
+		Subject: path,
+		Problem: "default_image is deprecated in favor of default_container.image",
+		Fix:     "set default_container.image instead and remove default_image",
+	}
+
+	moved := *cfg
+	moved.DefaultImage = ""
+	move := cfg.DefaultContainer.Image == ""
+	if move {
+		moved.DefaultContainer.Image = cfg.DefaultImage
+	}
+	if moved.GetDefaultImage() != cfg.GetDefaultImage() {
+		return m, true
+	}
+
+	m.Fix = "remove default_image, which default_container overrides"
+	if move {
+		m.Fix = fmt.Sprintf("move %s to default_container.image", cfg.DefaultImage)
+	}
+	m.Apply = func() error {
+		return editConfigDocument(path, func(doc map[string]any) error {
+			delete(doc, "default_image")
+			if move {
+				container, ok := doc["default_container"].(map[string]any)
+				if !ok {
+					container = map[string]any{}
+					doc["default_container"] = container
+				}
+				container["image"] = cfg.DefaultImage
+			}
+			return nil
+		})
+	}
+	return m, true
+}
+
+// unknownKeys returns the sorted top-level keys of the JSON object data that aren't
+// fields of the struct type t
+func unknownKeys(data []byte, t reflect.Type) []string {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil
+	}
+
+	known := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" {
+			name = t.Field(i).Name
+		}
+		known[name] = true
+	}
+
+	var unknown []string
+	for key := range raw {
+		// encoding/json matches field names case-insensitively
+		if !known[key] && !knownFold(known, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// knownFold reports whether key matches a known name ignoring case
+func knownFold(known map[string]bool, key string) bool {
+	for name := range known {
+		if strings.EqualFold(name, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigMigrations(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		problems []string
+		fixable  bool
+		image    string // default image after fixing
+	}{
+		{"current", `{"container_runtime": "docker", "default_container": {"image": "custom:1"}}`, nil, false, ""},
+		{"default image written by older versions", `{"default_image": "ghcr.io/obra/packnplay-default:latest"}`, nil, false, ""},
+		{"default image moved", `{"default_image": "custom:1"}`, []string{"default_image is deprecated"}, true, "custom:1"},
+		{"default image overridden", `{"default_image": "old:1", "default_container": {"image": "custom:2"}}`, []string{"default_image is deprecated"}, true, "custom:2"},
+		// A variant would win over the moved image, which changes which image runs use
+		{"default image under a variant", `{"default_image": "custom:1", "default_container": {"variant": "slim"}}`, []string{"default_image is deprecated"}, false, ""},
+		{"unknown keys", `{"container_runtime": "docker", "old_setting": true, "Container_Runtime": "docker"}`, []string{"old_setting isn't a setting"}, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(path, []byte(tt.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			migrations, err := ConfigMigrations(path)
+			if err != nil {
+				t.Fatalf("ConfigMigrations() error = %v", err)
+			}
+			if len(migrations) != len(tt.problems) {
+				t.Fatalf("ConfigMigrations() = %+v, want %d", migrations, len(tt.problems))
+			}
+			for i, m := range migrations {
+				if !strings.Contains(m.Problem, tt.problems[i]) {
+					t.Errorf("problem = %q, want %q", m.Problem, tt.problems[i])
+				}
+				if (m.Apply != nil) != tt.fixable {
+					t.Errorf("fixable = %v, want %v", m.Apply != nil, tt.fixable)
+				}
+				if m.Apply == nil {
+					continue
+				}
+				if err := m.Apply(); err != nil {
+					t.Fatalf("Apply() error = %v", err)
+				}
+				cfg, err := LoadConfigFromFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if cfg.DefaultImage != "" || cfg.GetDefaultImage() != tt.image {
+					t.Errorf("after Apply default_image = %q, image = %q, want %q", cfg.DefaultImage, cfg.GetDefaultImage(), tt.image)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigMigrationsMissingFile(t *testing.T) {
+	migrations, err := ConfigMigrations(filepath.Join(t.TempDir(), "config.json"))
+	if err != nil || len(migrations) != 0 {
+		t.Errorf("ConfigMigrations() = %v, %v, want nothing", migrations, err)
+	}
+}
+
+func TestProjectConfigMigrations(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(GetProjectConfigPath(dir), []byte(`{"image": "node:20", "publish": ["3000"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	migrations, err := ProjectConfigMigrations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 1 || !strings.HasPrefix(migrations[0].Problem, "publish ") || migrations[0].Apply != nil {
+		t.Errorf("ProjectConfigMigrations() = %+v, want the unknown publish key", migrations)
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Network modes control what a container can reach
+const (
+	NetworkOpen      = "open"      // the runtime's default network (default)
+	NetworkNone      = "none"      // no network at all (--network none)
+	NetworkAllowlist = "allowlist" // only the allowed domains, through an egress proxy sidecar
+)
+
+// NetworkConfig restricts the network access of containers
+type NetworkConfig struct {
+	Mode       string   `json:"mode,omitempty"`        // open, none, or allowlist (default: open)
+	Allow      []string `json:"allow,omitempty"`       // domains reachable in allowlist mode, e.g. api.anthropic.com or *.github.com
+	ProxyImage string   `json:"proxy_image,omitempty"` // squid image for the allowlist proxy (default: ubuntu/squid)
+}
+
+// Isolated reports whether the mode takes the container off the runtime's default network
+func (n NetworkConfig) Isolated() bool {
+	return n.Mode == NetworkNone || n.Mode == NetworkAllowlist
+}
+
+// Validate checks the mode and the allowed domains
+func (n NetworkConfig) Validate() error {
+	switch n.Mode {
+	case "", NetworkOpen, NetworkNone:
+	case NetworkAllowlist:
+		if len(n.Allow) == 0 {
+			return fmt.Errorf("allowlist mode needs at least one domain in allow")
+		}
+	default:
+		return fmt.Errorf("unknown mode '%s' (valid: open, none, allowlist)", n.Mode)
+	}
+
+	for _, domain := range n.Allow {
+		if !ValidAllowedDomain(domain) {
+			return fmt.Errorf("invalid domain '%s' in allow (use a host name like api.anthropic.com, or *.github.com for subdomains)", domain)
+		}
+	}
+	return nil
+}
+
+// domainPattern matches a DNS host name, optionally prefixed with "*." for subdomains
+var domainPattern = regexp.MustCompile(`^(\*\.)?([a-z0-9]([a-z0-9-]*[a-z0-9])?\.)*[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// ValidAllowedDomain reports whether domain can be put on the egress allowlist
+func ValidAllowedDomain(domain string) bool {
+	return domainPattern.MatchString(strings.ToLower(domain))
+}
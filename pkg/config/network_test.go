@@ -0,0 +1,41 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNetworkValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		network NetworkConfig
+		wantErr string
+	}{
+		{"default", NetworkConfig{}, ""},
+		{"none", NetworkConfig{Mode: NetworkNone}, ""},
+		{"allowlist", NetworkConfig{Mode: NetworkAllowlist, Allow: []string{"api.anthropic.com", "*.github.com", "registry.npmjs.org"}}, ""},
+		{"allowlist without domains", NetworkConfig{Mode: NetworkAllowlist}, "at least one domain"},
+		{"unknown mode", NetworkConfig{Mode: "firewalled"}, "unknown mode"},
+		{"url instead of domain", NetworkConfig{Mode: NetworkAllowlist, Allow: []string{"https://github.com"}}, "invalid domain"},
+		{"wildcard in the middle", NetworkConfig{Mode: NetworkAllowlist, Allow: []string{"api.*.com"}}, "invalid domain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.network.Validate()
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNetworkIsolated(t *testing.T) {
+	for mode, want := range map[string]bool{"": false, NetworkOpen: false, NetworkNone: true, NetworkAllowlist: true} {
+		if got := (NetworkConfig{Mode: mode}).Isolated(); got != want {
+			t.Errorf("Isolated() for mode %q = %v, want %v", mode, got, want)
+		}
+	}
+}
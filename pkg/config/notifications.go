@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NotificationsConfig controls the notices about newer versions of the default image
+type NotificationsConfig struct {
+	Quiet          bool           `json:"quiet,omitempty"`           // never check or notify; implied when CI is set
+	QuietHours     string         `json:"quiet_hours,omitempty"`     // local time window without them, e.g. "22:00-08:00"
+	FrequencyHours map[string]int `json:"frequency_hours,omitempty"` // per image, hours between checks (default: default_container.check_frequency_hours)
+}
+
+// Silenced reports whether notifications are off at now, and why: the quiet setting, a
+// CI environment (CI set to anything but false or 0, as CI services do), or quiet hours
+func (n NotificationsConfig) Silenced(now time.Time, getenv func(string) string) (bool, string) {
+	if n.Quiet {
+		return true, "notifications.quiet is set"
+	}
+	if ci := strings.ToLower(getenv("CI")); ci != "" && ci != "false" && ci != "0" {
+		return true, "running in CI"
+	}
+	if n.QuietHours == "" {
+		return false, ""
+	}
+	start, end, err := parseQuietHours(n.QuietHours)
+	if err != nil {
+		return false, ""
+	}
+	minute := now.Hour()*60 + now.Minute()
+	// A window like 22:00-08:00 wraps around midnight
+	inside := minute >= start && minute < end
+	if start > end {
+		inside = minute >= start || minute < end
+	}
+	if inside {
+		return true, "quiet hours " + n.QuietHours
+	}
+	return false, ""
+}
+
+// CheckFrequencyHours returns the hours between update checks of image: its own
+// frequency_hours, else defaultHours
+func (n NotificationsConfig) CheckFrequencyHours(image string, defaultHours int) int {
+	if hours, ok := n.FrequencyHours[image]; ok {
+		return hours
+	}
+	return defaultHours
+}
+
+// Validate checks the quiet hours window and the frequencies
+func (n NotificationsConfig) Validate() error {
+	if n.QuietHours != "" {
+		if _, _, err := parseQuietHours(n.QuietHours); err != nil {
+			return err
+		}
+	}
+	for image, hours := range n.FrequencyHours {
+		if hours < 0 {
+			return fmt.Errorf("invalid frequency_hours %d for %s (use 0 or more)", hours, image)
+		}
+	}
+	return nil
+}
+
+// parseQuietHours parses a HH:MM-HH:MM window into minutes of the day
+func parseQuietHours(window string) (start, end int, err error) {
+	from, to, ok := strings.Cut(window, "-")
+	if ok {
+		start, err = parseClock(from)
+	}
+	if ok && err == nil {
+		end, err = parseClock(to)
+	}
+	if !ok || err != nil || start == end {
+		return 0, 0, fmt.Errorf("invalid quiet_hours '%s' (use a window like 22:00-08:00)", window)
+	}
+	return start, end, nil
+}
+
+// parseClock parses HH:MM into minutes of the day
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
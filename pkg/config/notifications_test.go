@@ -0,0 +1,85 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotificationsSilenced(t *testing.T) {
+	at := func(clock string) time.Time {
+		parsed, _ := time.Parse("15:04", clock)
+		return time.Date(2026, 1, 1, parsed.Hour(), parsed.Minute(), 0, 0, time.Local)
+	}
+	noEnv := func(string) string { return "" }
+	ci := func(name string) string {
+		if name == "CI" {
+			return "true"
+		}
+		return ""
+	}
+
+	tests := []struct {
+		name   string
+		config NotificationsConfig
+		now    time.Time
+		getenv func(string) string
+		want   bool
+	}{
+		{"default", NotificationsConfig{}, at("12:00"), noEnv, false},
+		{"quiet", NotificationsConfig{Quiet: true}, at("12:00"), noEnv, true},
+		{"ci", NotificationsConfig{}, at("12:00"), ci, true},
+		{"ci false", NotificationsConfig{}, at("12:00"), func(string) string { return "false" }, false},
+		{"inside window", NotificationsConfig{QuietHours: "09:00-17:00"}, at("12:00"), noEnv, true},
+		{"window end", NotificationsConfig{QuietHours: "09:00-17:00"}, at("17:00"), noEnv, false},
+		{"overnight late", NotificationsConfig{QuietHours: "22:00-08:00"}, at("23:30"), noEnv, true},
+		{"overnight early", NotificationsConfig{QuietHours: "22:00-08:00"}, at("07:59"), noEnv, true},
+		{"overnight day", NotificationsConfig{QuietHours: "22:00-08:00"}, at("12:00"), noEnv, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := tt.config.Silenced(tt.now, tt.getenv)
+			if got != tt.want {
+				t.Errorf("Silenced() = %v (%s), want %v", got, reason, tt.want)
+			}
+			if got && reason == "" {
+				t.Error("Silenced() gave no reason")
+			}
+		})
+	}
+}
+
+func TestNotificationsCheckFrequencyHours(t *testing.T) {
+	n := NotificationsConfig{FrequencyHours: map[string]int{"ghcr.io/obra/packnplay-default:latest": 168}}
+	if got := n.CheckFrequencyHours("ghcr.io/obra/packnplay-default:latest", 24); got != 168 {
+		t.Errorf("CheckFrequencyHours() = %d, want 168", got)
+	}
+	if got := n.CheckFrequencyHours("other:latest", 24); got != 24 {
+		t.Errorf("CheckFrequencyHours() = %d, want the default 24", got)
+	}
+}
+
+func TestNotificationsValidate(t *testing.T) {
+	tests := []struct {
+		config  NotificationsConfig
+		wantErr string
+	}{
+		{NotificationsConfig{QuietHours: "22:00-08:00", FrequencyHours: map[string]int{"a": 0}}, ""},
+		{NotificationsConfig{QuietHours: "night"}, "invalid quiet_hours 'night'"},
+		{NotificationsConfig{QuietHours: "25:00-08:00"}, "invalid quiet_hours"},
+		{NotificationsConfig{QuietHours: "08:00-08:00"}, "invalid quiet_hours"},
+		{NotificationsConfig{FrequencyHours: map[string]int{"a": -1}}, "invalid frequency_hours -1 for a"},
+	}
+	for _, tt := range tests {
+		err := tt.config.Validate()
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("Validate(%+v) error = %v", tt.config, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Validate(%+v) error = %v, want %q", tt.config, err, tt.wantErr)
+		}
+	}
+}
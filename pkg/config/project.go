@@ -0,0 +1,285 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ProjectConfigFile is the repo-local config file name, committed alongside the project
+const ProjectConfigFile = ".packnplay.json"
+
+// ProjectConfig holds per-project overrides of the global config
+type ProjectConfig struct {
+	Image            string                   `json:"image,omitempty"`             // image used when no devcontainer.json is found
+	Mounts           []string                 `json:"mounts,omitempty"`            // extra bind mounts: host:container[:ro]
+	PublishPorts     []string                 `json:"publish_ports,omitempty"`     // ports always published for this project
+	Credentials      *CredentialOverrides     `json:"credentials,omitempty"`       // per-credential overrides of default_credentials
+	GroupAdd         []string                 `json:"group_add,omitempty"`         // supplemental groups added to the global ones
+	Labels           map[string]string        `json:"labels,omitempty"`            // extra container labels, overriding global ones
+	Ulimits          []string                 `json:"ulimits,omitempty"`           // docker --ulimit values added to the global ones
+	Timeout          string                   `json:"timeout,omitempty"`           // command time limit, overriding the global one
+	Tasks            map[string]Task          `json:"tasks,omitempty"`             // named commands run with packnplay task <name>
+	Env              []string                 `json:"env,omitempty"`               // env vars (KEY=value, or KEY to pass through), below --env
+	Resources        *ResourcesConfig         `json:"resources,omitempty"`         // CPU, memory, swap and process limits, overriding default_resources
+	Command          CommandLine              `json:"command,omitempty"`           // command packnplay run uses when given none
+	Docker           string                   `json:"docker,omitempty"`            // docker access: off, socket, or dind, overriding the global mode
+	BuildCache       *BuildCacheConfig        `json:"build_cache,omitempty"`       // registry cache for devcontainer image builds, replacing the global one
+	Services         map[string]ServiceConfig `json:"services,omitempty"`          // sidecar services (databases, caches) started with the container
+	DependencyCaches *DependencyCachesConfig  `json:"dependency_caches,omitempty"` // package manager cache volumes, replacing the global setting
+}
+
+// CommandLine is a command given in JSON as an argument list, or as a string run with sh -c
+type CommandLine []string
+
+// UnmarshalJSON accepts an argument list or a shell string
+func (c *CommandLine) UnmarshalJSON(data []byte) error {
+	command, err := parseCommandJSON(data)
+	if err != nil {
+		return err
+	}
+	*c = command
+	return nil
+}
+
+// MarshalJSON writes a shell string command back as a string
+func (c CommandLine) MarshalJSON() ([]byte, error) {
+	if script, ok := ShellScript(c); ok {
+		return json.Marshal(script)
+	}
+	return json.Marshal([]string(c))
+}
+
+// parseCommandJSON decodes a command given as an argument list or a shell string
+func parseCommandJSON(data json.RawMessage) ([]string, error) {
+	var script string
+	if err := json.Unmarshal(data, &script); err == nil {
+		return []string{"sh", "-c", script}, nil
+	}
+	var command []string
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &command); err != nil {
+			return nil, fmt.Errorf("command must be a string or array of strings")
+		}
+	}
+	return command, nil
+}
+
+// ShellScript returns the script of an sh -c command, as a string command is run
+func ShellScript(command []string) (string, bool) {
+	if len(command) == 3 && command[0] == "sh" && command[1] == "-c" {
+		return command[2], true
+	}
+	return "", false
+}
+
+// Task is a named command a project defines for packnplay task
+type Task struct {
+	Description string            `json:"description,omitempty"`
+	Command     []string          `json:"command"`               // a string is run with sh -c
+	Env         map[string]string `json:"env,omitempty"`         // set in the container for this task
+	Workdir     string            `json:"workdir,omitempty"`     // relative to the mounted project root
+	Credentials []string          `json:"credentials,omitempty"` // credentials the task needs: git, ssh, gh, gpg, npm, aws, gcp, azure, kube, or a credential provider
+}
+
+// UnmarshalJSON accepts command as either an argument list or a shell string
+func (t *Task) UnmarshalJSON(data []byte) error {
+	type plain Task
+	var raw struct {
+		plain
+		Command json.RawMessage `json:"command"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*t = Task(raw.plain)
+
+	command, err := parseCommandJSON(raw.Command)
+	if err != nil {
+		return fmt.Errorf("task %w", err)
+	}
+	t.Command = command
+	return nil
+}
+
+// MarshalJSON writes a shell string command back as a string
+func (t Task) MarshalJSON() ([]byte, error) {
+	type plain Task
+	if script, ok := ShellScript(t.Command); ok {
+		return json.Marshal(struct {
+			plain
+			Command string `json:"command"`
+		}{plain(t), script})
+	}
+	return json.Marshal(plain(t))
+}
+
+// Validate checks that the task can run
+func (t Task) Validate() error {
+	if len(t.Command) == 0 {
+		return fmt.Errorf("no command")
+	}
+	if t.Workdir != "" && !filepath.IsLocal(t.Workdir) {
+		return fmt.Errorf("workdir '%s' must be a relative path inside the project", t.Workdir)
+	}
+	return nil
+}
+
+// CredentialOverrides overrides individual default credentials; nil means inherit
+type CredentialOverrides struct {
+	Git   *bool `json:"git,omitempty"`
+	SSH   *bool `json:"ssh,omitempty"`
+	GH    *bool `json:"gh,omitempty"`
+	GPG   *bool `json:"gpg,omitempty"`
+	NPM   *bool `json:"npm,omitempty"`
+	AWS   *bool `json:"aws,omitempty"`
+	GCP   *bool `json:"gcp,omitempty"`
+	Azure *bool `json:"azure,omitempty"`
+	Kube  *bool `json:"kube,omitempty"`
+
+	SSHAgent *bool `json:"ssh_agent,omitempty"`
+	GPGAgent *bool `json:"gpg_agent,omitempty"`
+}
+
+// Apply returns creds with the overrides applied
+func (o *CredentialOverrides) Apply(creds Credentials) Credentials {
+	if o == nil {
+		return creds
+	}
+	override := func(dst *bool, src *bool) {
+		if src != nil {
+			*dst = *src
+		}
+	}
+	override(&creds.Git, o.Git)
+	override(&creds.SSH, o.SSH)
+	override(&creds.GH, o.GH)
+	override(&creds.GPG, o.GPG)
+	override(&creds.NPM, o.NPM)
+	override(&creds.AWS, o.AWS)
+	override(&creds.GCP, o.GCP)
+	override(&creds.Azure, o.Azure)
+	override(&creds.Kube, o.Kube)
+	override(&creds.SSHAgent, o.SSHAgent)
+	override(&creds.GPGAgent, o.GPGAgent)
+	return creds
+}
+
+// isEmpty reports whether no credential is overridden
+func (o *CredentialOverrides) isEmpty() bool {
+	return o == nil || (o.Git == nil && o.SSH == nil && o.GH == nil && o.GPG == nil && o.NPM == nil && o.AWS == nil && o.GCP == nil && o.Azure == nil && o.Kube == nil && o.SSHAgent == nil && o.GPGAgent == nil)
+}
+
+// GetProjectConfigPath returns the path of the project config file in projectDir
+func GetProjectConfigPath(projectDir string) string {
+	return filepath.Join(projectDir, ProjectConfigFile)
+}
+
+// LoadProjectConfig loads the project config, returning an empty config if none exists
+func LoadProjectConfig(projectDir string) (*ProjectConfig, error) {
+	data, err := os.ReadFile(GetProjectConfigPath(projectDir))
+	if os.IsNotExist(err) {
+		return &ProjectConfig{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project config: %w", err)
+	}
+
+	var pc ProjectConfig
+	if err := json.Unmarshal(data, &pc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ProjectConfigFile, err)
+	}
+
+	return &pc, nil
+}
+
+// SaveProjectConfig writes the project config into projectDir
+func SaveProjectConfig(projectDir string, pc *ProjectConfig) error {
+	data, err := json.MarshalIndent(pc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal project config: %w", err)
+	}
+
+	return os.WriteFile(GetProjectConfigPath(projectDir), append(data, '\n'), 0644)
+}
+
+// ResolveMounts expands ~ and makes relative host paths absolute against projectDir
+func (pc *ProjectConfig) ResolveMounts(projectDir string) ([]string, error) {
+	var mounts []string
+	for _, mount := range pc.Mounts {
+		parts := strings.SplitN(mount, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid mount '%s' in %s (expected host:container[:ro])", mount, ProjectConfigFile)
+		}
+
+		hostPath := parts[0]
+		if hostPath == "~" || strings.HasPrefix(hostPath, "~/") {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get home directory: %w", err)
+			}
+			hostPath = filepath.Join(homeDir, strings.TrimPrefix(hostPath, "~"))
+		} else if !filepath.IsAbs(hostPath) {
+			hostPath = filepath.Join(projectDir, hostPath)
+		}
+
+		mounts = append(mounts, hostPath+":"+parts[1])
+	}
+	return mounts, nil
+}
+
+// ServiceConfig is a sidecar service, such as a database, that packnplay starts next to
+// the container on a shared network, where it is reachable by its name
+type ServiceConfig struct {
+	Type       string            `json:"type,omitempty"`        // postgres, mysql or redis: fills in the image, env and connection env vars
+	Image      string            `json:"image,omitempty"`       // required without a type
+	Ports      []string          `json:"ports,omitempty"`       // published on the host, docker -p syntax
+	Env        map[string]string `json:"env,omitempty"`         // set in the service, over the type's defaults
+	Volumes    []string          `json:"volumes,omitempty"`     // name:path keeps data across stop; host paths are relative to the project
+	ConnectEnv map[string]string `json:"connect_env,omitempty"` // set in the container, over the type's (e.g. DATABASE_URL)
+}
+
+// UnmarshalJSON also accepts just the type, as in "db": "postgres"
+func (s *ServiceConfig) UnmarshalJSON(data []byte) error {
+	var serviceType string
+	if err := json.Unmarshal(data, &serviceType); err == nil {
+		*s = ServiceConfig{Type: serviceType}
+		return nil
+	}
+	type plain ServiceConfig
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// ResolveServices returns the services with the host paths of their volumes expanded
+// like mounts; named volumes are left as they are
+func (pc *ProjectConfig) ResolveServices(projectDir string) (map[string]ServiceConfig, error) {
+	if len(pc.Services) == 0 {
+		return nil, nil
+	}
+	services := make(map[string]ServiceConfig, len(pc.Services))
+	for name, service := range pc.Services {
+		var volumes []string
+		for _, volume := range service.Volumes {
+			if !isHostVolume(volume) {
+				volumes = append(volumes, volume)
+				continue
+			}
+			resolved, err := (&ProjectConfig{Mounts: []string{volume}}).ResolveMounts(projectDir)
+			if err != nil {
+				return nil, fmt.Errorf("service %s: %w", name, err)
+			}
+			volumes = append(volumes, resolved...)
+		}
+		service.Volumes = volumes
+		services[name] = service
+	}
+	return services, nil
+}
+
+// isHostVolume reports whether a volume mounts a host path rather than a named volume
+func isHostVolume(volume string) bool {
+	source, _, _ := strings.Cut(volume, ":")
+	return strings.ContainsRune(source, '/') || strings.HasPrefix(source, ".") || strings.HasPrefix(source, "~")
+}
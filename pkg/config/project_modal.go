@@ -0,0 +1,238 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+// credentialOverrideOptions are the select values for per-project credential overrides
+var credentialOverrideOptions = []string{"inherit", "on", "off"}
+
+// createProjectSettingsModal creates a settings modal for the project config file.
+// Global values are shown in descriptions so it's clear what "inherit" means.
+func createProjectSettingsModal(project *ProjectConfig, global *Config, projectDir string) *SettingsModal {
+	overrides := project.Credentials
+	if overrides == nil {
+		overrides = &CredentialOverrides{}
+	}
+	globalCreds := global.DefaultCredentials
+
+	credentialField := func(name, title string, value *bool, globalValue bool) SettingsField {
+		return SettingsField{
+			name:        name,
+			fieldType:   "select",
+			title:       title,
+			description: fmt.Sprintf("Global default: %s", onOff(globalValue)),
+			value:       formatCredentialOverride(value),
+			options:     credentialOverrideOptions,
+		}
+	}
+
+	sections := []SettingsSection{
+		{
+			name:        "project-image",
+			title:       "Image",
+			description: "Image used when the project has no devcontainer.json",
+			fields: []SettingsField{
+				{
+					name:        "project-image",
+					fieldType:   "text",
+					title:       "Project image",
+					description: fmt.Sprintf("Empty uses the global default (%s)", global.GetDefaultImage()),
+					value:       project.Image,
+				},
+			},
+		},
+		{
+			name:        "project-mounts",
+			title:       "Mounts",
+			description: "Extra bind mounts for this project",
+			fields: []SettingsField{
+				{
+					name:        "project-mounts",
+					fieldType:   "text",
+					title:       "Mounts",
+					description: "Comma-separated host:container[:ro]; relative paths are from the repo root",
+					value:       strings.Join(project.Mounts, ", "),
+				},
+			},
+		},
+		{
+			name:        "project-ports",
+			title:       "Ports",
+			description: "Ports always published for this project",
+			fields: []SettingsField{
+				{
+					name:        "project-ports",
+					fieldType:   "text",
+					title:       "Published ports",
+					description: "Comma-separated, same format as -p (e.g. 3000:3000)",
+					value:       strings.Join(project.PublishPorts, ", "),
+				},
+			},
+		},
+		{
+			name:        "project-credentials",
+			title:       "Credential Overrides",
+			description: "Override default credentials for this project",
+			fields: []SettingsField{
+				credentialField("git", "Git config", overrides.Git, globalCreds.Git),
+				credentialField("ssh", "SSH keys", overrides.SSH, globalCreds.SSH),
+				credentialField("ssh-agent", "SSH agent", overrides.SSHAgent, globalCreds.SSHAgent),
+				credentialField("github", "GitHub CLI credentials", overrides.GH, globalCreds.GH),
+				credentialField("gpg", "GPG credentials", overrides.GPG, globalCreds.GPG),
+				credentialField("gpg-agent", "GPG agent", overrides.GPGAgent, globalCreds.GPGAgent),
+				credentialField("npm", "npm credentials", overrides.NPM, globalCreds.NPM),
+				credentialField("aws", "AWS credentials", overrides.AWS, globalCreds.AWS),
+				credentialField("gcp", "Google Cloud credentials", overrides.GCP, globalCreds.GCP),
+				credentialField("azure", "Azure credentials", overrides.Azure, globalCreds.Azure),
+				credentialField("kube", "Kubernetes config", overrides.Kube, globalCreds.Kube),
+			},
+		},
+	}
+
+	ti := textinput.New()
+	ti.Width = 50
+
+	return &SettingsModal{
+		config:     global,
+		sections:   sections,
+		textInput:  ti,
+		title:      fmt.Sprintf("packnplay Project Configuration (%s)", filepath.Base(projectDir)),
+		projectDir: projectDir,
+		width:      80,
+		height:     24,
+	}
+}
+
+// RunProjectConfiguration edits the project config file in projectDir with the settings modal
+func RunProjectConfiguration(global *Config, projectDir string, verbose bool) error {
+	project, err := LoadProjectConfig(projectDir)
+	if err != nil {
+		return err
+	}
+
+	modal := createProjectSettingsModal(project, global, projectDir)
+	modal.configPath = GetProjectConfigPath(projectDir)
+
+	finalModel, err := runModal(modal)
+	if err != nil {
+		return fmt.Errorf("project configuration failed: %w", err)
+	}
+
+	if finalModel.saved {
+		if err := applyProjectModalUpdates(finalModel, projectDir); err != nil {
+			return err
+		}
+		if verbose {
+			fmt.Printf("Saved %s\n", modal.configPath)
+		}
+	}
+
+	return nil
+}
+
+// applyProjectModalUpdates writes modal values into the project config, preserving other keys
+func applyProjectModalUpdates(modal *SettingsModal, projectDir string) error {
+	project, err := projectConfigFromModal(modal, projectDir)
+	if err != nil {
+		return err
+	}
+
+	return SaveProjectConfig(projectDir, project)
+}
+
+// projectConfigFromModal returns the project config that saving the modal would write
+func projectConfigFromModal(modal *SettingsModal, projectDir string) (*ProjectConfig, error) {
+	project, err := LoadProjectConfig(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := &CredentialOverrides{}
+	for _, section := range modal.sections {
+		for _, field := range section.fields {
+			switch field.name {
+			case "project-image":
+				project.Image = strings.TrimSpace(field.value.(string))
+			case "project-mounts":
+				project.Mounts = splitList(field.value.(string))
+			case "project-ports":
+				project.PublishPorts = splitList(field.value.(string))
+			case "git":
+				overrides.Git = parseCredentialOverride(field.value.(string))
+			case "ssh":
+				overrides.SSH = parseCredentialOverride(field.value.(string))
+			case "ssh-agent":
+				overrides.SSHAgent = parseCredentialOverride(field.value.(string))
+			case "github":
+				overrides.GH = parseCredentialOverride(field.value.(string))
+			case "gpg":
+				overrides.GPG = parseCredentialOverride(field.value.(string))
+			case "gpg-agent":
+				overrides.GPGAgent = parseCredentialOverride(field.value.(string))
+			case "npm":
+				overrides.NPM = parseCredentialOverride(field.value.(string))
+			case "aws":
+				overrides.AWS = parseCredentialOverride(field.value.(string))
+			case "gcp":
+				overrides.GCP = parseCredentialOverride(field.value.(string))
+			case "azure":
+				overrides.Azure = parseCredentialOverride(field.value.(string))
+			case "kube":
+				overrides.Kube = parseCredentialOverride(field.value.(string))
+			}
+		}
+	}
+
+	project.Credentials = overrides
+	if overrides.isEmpty() {
+		project.Credentials = nil
+	}
+
+	if _, err := project.ResolveMounts(projectDir); err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func formatCredentialOverride(value *bool) string {
+	if value == nil {
+		return "inherit"
+	}
+	return strings.ToLower(onOff(*value))
+}
+
+func parseCredentialOverride(display string) *bool {
+	switch display {
+	case "on":
+		v := true
+		return &v
+	case "off":
+		v := false
+		return &v
+	}
+	return nil
+}
+
+func onOff(value bool) string {
+	if value {
+		return "ON"
+	}
+	return "OFF"
+}
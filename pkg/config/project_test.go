@@ -0,0 +1,213 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadProjectConfigMissing(t *testing.T) {
+	pc, err := LoadProjectConfig(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+	if pc.Image != "" || len(pc.Mounts) != 0 || pc.Credentials != nil {
+		t.Errorf("LoadProjectConfig() = %+v, want empty config", pc)
+	}
+}
+
+func TestProjectConfigSaveAndLoad(t *testing.T) {
+	projectDir := t.TempDir()
+	ssh := true
+	original := &ProjectConfig{
+		Image:        "my-org/dev:v2",
+		Mounts:       []string{"./data:/data:ro"},
+		PublishPorts: []string{"3000:3000"},
+		Credentials:  &CredentialOverrides{SSH: &ssh},
+	}
+
+	if err := SaveProjectConfig(projectDir, original); err != nil {
+		t.Fatalf("SaveProjectConfig() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, ".packnplay.json")); err != nil {
+		t.Fatalf("project config not written to repo: %v", err)
+	}
+
+	loaded, err := LoadProjectConfig(projectDir)
+	if err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+	if loaded.Image != original.Image || loaded.PublishPorts[0] != "3000:3000" {
+		t.Errorf("LoadProjectConfig() = %+v, want %+v", loaded, original)
+	}
+	if loaded.Credentials == nil || loaded.Credentials.SSH == nil || !*loaded.Credentials.SSH {
+		t.Errorf("SSH override not preserved: %+v", loaded.Credentials)
+	}
+	if loaded.Credentials.GH != nil {
+		t.Error("unset overrides should stay nil (inherit)")
+	}
+}
+
+func TestCredentialOverridesApply(t *testing.T) {
+	on, off := true, false
+	base := Credentials{Git: true, SSH: false, GH: true}
+
+	got := (&CredentialOverrides{SSH: &on, GH: &off, SSHAgent: &on, GPGAgent: &on}).Apply(base)
+	if !got.Git || !got.SSH || got.GH || !got.SSHAgent || !got.GPGAgent {
+		t.Errorf("Apply() = %+v, want git=true ssh=true gh=false ssh_agent=true gpg_agent=true", got)
+	}
+
+	var none *CredentialOverrides
+	if none.Apply(base) != base {
+		t.Error("nil overrides should leave credentials unchanged")
+	}
+}
+
+func TestProjectConfigResolveMounts(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	pc := &ProjectConfig{Mounts: []string{"./data:/data:ro", "~/.cache/pip:/home/vscode/.cache/pip", "/abs:/abs"}}
+	got, err := pc.ResolveMounts("/repo")
+	if err != nil {
+		t.Fatalf("ResolveMounts() error = %v", err)
+	}
+
+	want := []string{"/repo/data:/data:ro", homeDir + "/.cache/pip:/home/vscode/.cache/pip", "/abs:/abs"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("ResolveMounts() = %v, want %v", got, want)
+	}
+
+	pc = &ProjectConfig{Mounts: []string{"no-container-path"}}
+	if _, err := pc.ResolveMounts("/repo"); err == nil {
+		t.Error("ResolveMounts() should reject mounts without a container path")
+	}
+}
+
+func TestApplyProjectModalUpdates(t *testing.T) {
+	projectDir := t.TempDir()
+
+	// Start from an existing project config
+	if err := os.WriteFile(filepath.Join(projectDir, ".packnplay.json"), []byte(`{"image":"old:1"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	project, _ := LoadProjectConfig(projectDir)
+	modal := createProjectSettingsModal(project, &Config{}, projectDir)
+	for i := range modal.sections {
+		for j := range modal.sections[i].fields {
+			field := &modal.sections[i].fields[j]
+			switch field.name {
+			case "project-image":
+				field.value = "new:2"
+			case "project-ports":
+				field.value = "3000:3000, 8080:80"
+			case "aws":
+				field.value = "on"
+			}
+		}
+	}
+
+	if err := applyProjectModalUpdates(modal, projectDir); err != nil {
+		t.Fatalf("applyProjectModalUpdates() error = %v", err)
+	}
+
+	saved, err := LoadProjectConfig(projectDir)
+	if err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+	if saved.Image != "new:2" {
+		t.Errorf("Image = %v, want new:2", saved.Image)
+	}
+	if len(saved.PublishPorts) != 2 || saved.PublishPorts[1] != "8080:80" {
+		t.Errorf("PublishPorts = %v", saved.PublishPorts)
+	}
+	if saved.Credentials == nil || saved.Credentials.AWS == nil || !*saved.Credentials.AWS || saved.Credentials.SSH != nil {
+		t.Errorf("Credentials = %+v, want only aws overridden", saved.Credentials)
+	}
+}
+
+func TestProjectTasks(t *testing.T) {
+	dir := t.TempDir()
+	content := `{
+  "tasks": {
+    "test": {"description": "Run the tests", "command": ["go", "test", "./..."], "env": {"CGO_ENABLED": "0"}, "credentials": ["git"]},
+    "lint": {"command": "golangci-lint run --fix", "workdir": "backend"}
+  }
+}`
+	if err := os.WriteFile(filepath.Join(dir, ProjectConfigFile), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pc, err := LoadProjectConfig(dir)
+	if err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+	if got := pc.Tasks["test"].Command; !reflect.DeepEqual(got, []string{"go", "test", "./..."}) {
+		t.Errorf("test command = %q", got)
+	}
+	lint := pc.Tasks["lint"]
+	if !reflect.DeepEqual(lint.Command, []string{"sh", "-c", "golangci-lint run --fix"}) || lint.Workdir != "backend" {
+		t.Errorf("lint task = %+v", lint)
+	}
+
+	// Shell string commands are saved as strings again
+	if err := SaveProjectConfig(dir, pc); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, ProjectConfigFile))
+	if !strings.Contains(string(data), `"command": "golangci-lint run --fix"`) {
+		t.Errorf("saved config =\n%s", data)
+	}
+	reloaded, err := LoadProjectConfig(dir)
+	if err != nil || !reflect.DeepEqual(reloaded.Tasks, pc.Tasks) {
+		t.Errorf("reloaded tasks = %+v, %v", reloaded.Tasks, err)
+	}
+}
+
+func TestTaskValidate(t *testing.T) {
+	tests := []struct {
+		task    Task
+		wantErr bool
+	}{
+		{Task{Command: []string{"make"}}, false},
+		{Task{Command: []string{"make"}, Workdir: "web/app"}, false},
+		{Task{}, true},
+		{Task{Command: []string{"make"}, Workdir: "../other"}, true},
+		{Task{Command: []string{"make"}, Workdir: "/etc"}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.task.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) error = %v, wantErr %v", tt.task, err, tt.wantErr)
+		}
+	}
+}
+
+func TestProjectServices(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	var pc ProjectConfig
+	data := `{"services": {"db": "postgres", "search": {"image": "opensearch:2", "ports": ["9200:9200"], "volumes": ["data:/usr/share/data", "./config:/config", "~/certs:/certs"]}}}`
+	if err := json.Unmarshal([]byte(data), &pc); err != nil {
+		t.Fatal(err)
+	}
+	if got := pc.Services["db"]; got.Type != "postgres" {
+		t.Errorf("services.db = %+v, want the type from the string shorthand", got)
+	}
+
+	services, err := pc.ResolveServices("/repo")
+	if err != nil {
+		t.Fatalf("ResolveServices() error = %v", err)
+	}
+	want := []string{"data:/usr/share/data", "/repo/config:/config", homeDir + "/certs:/certs"}
+	if got := services["search"].Volumes; !reflect.DeepEqual(got, want) {
+		t.Errorf("search volumes = %q, want %q", got, want)
+	}
+	if services["search"].Image != "opensearch:2" || services["db"].Type != "postgres" {
+		t.Errorf("ResolveServices() = %+v", services)
+	}
+}
@@ -0,0 +1,271 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// DisableTUI forces the plain prompt-based configuration (set by --no-tui)
+var DisableTUI bool
+
+// useBasicPrompts reports whether configuration should use plain prompts instead of the
+// full-screen modal: when requested, on dumb terminals, or when not attached to a terminal
+func useBasicPrompts() bool {
+	if DisableTUI {
+		return true
+	}
+	if term := os.Getenv("TERM"); term == "" || term == "dumb" {
+		return true
+	}
+	return !isTerminal(os.Stdin) || !isTerminal(os.Stdout)
+}
+
+// isTerminal reports whether f is a character device (a TTY)
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// runModal runs the settings modal as a TUI, falling back to plain prompts when
+// the terminal can't support it. The returned modal reports whether it was saved.
+func runModal(modal *SettingsModal) (*SettingsModal, error) {
+	if useBasicPrompts() {
+		err := runPromptModal(modal, os.Stdin, os.Stdout)
+		return modal, err
+	}
+
+	program := tea.NewProgram(modal, tea.WithAltScreen())
+	finalModel, err := program.Run()
+	if err != nil {
+		return nil, err
+	}
+
+	if finalModel, ok := finalModel.(*SettingsModal); ok {
+		return finalModel, nil
+	}
+	return modal, nil
+}
+
+// promptReader asks plain-text questions on a line-based reader
+type promptReader struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask prints a question and returns the trimmed answer
+func (p *promptReader) ask(question string) (string, error) {
+	_, _ = fmt.Fprint(p.out, question)
+	line, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("configuration input ended")
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// askYesNo asks a yes/no question, returning def on an empty answer
+func (p *promptReader) askYesNo(question string, def bool) (bool, error) {
+	hint := "[y/N]"
+	if def {
+		hint = "[Y/n]"
+	}
+	for {
+		answer, err := p.ask(fmt.Sprintf("%s %s: ", question, hint))
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		_, _ = fmt.Fprintln(p.out, "  Please answer y or n.")
+	}
+}
+
+// runPromptModal walks the modal's fields as plain questions and marks the modal saved
+// when the user confirms. Uses only ASCII output so it works on any terminal.
+func runPromptModal(modal *SettingsModal, in io.Reader, out io.Writer) error {
+	p := &promptReader{in: bufio.NewReader(in), out: out}
+
+	title := "packnplay Configuration"
+	if modal.title != "" {
+		title = modal.title
+	}
+	_, _ = fmt.Fprintf(out, "%s\nPress Enter to keep the current value.\n", title)
+
+	for i := range modal.sections {
+		section := &modal.sections[i]
+		_, _ = fmt.Fprintf(out, "\n== %s ==\n", section.title)
+
+		for j := range section.fields {
+			if err := promptField(p, modal, &section.fields[j]); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Show exactly what will be written before asking
+	changes, err := modal.pendingChanges()
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprint(out, "\n"+formatChanges(changes))
+	if len(changes) == 0 {
+		modal.quitting = true
+		return nil
+	}
+
+	save, err := p.askYesNo("Save changes?", true)
+	if err != nil {
+		return err
+	}
+	modal.saved = save
+	modal.quitting = !save
+	return nil
+}
+
+// promptField asks for a single field value according to its type
+func promptField(p *promptReader, modal *SettingsModal, field *SettingsField) error {
+	switch field.fieldType {
+	case "toggle":
+		value, err := p.askYesNo(field.title+"?", field.value.(bool))
+		if err != nil {
+			return err
+		}
+		field.value = value
+
+	case "select":
+		current := field.value.(string)
+		for {
+			answer, err := p.ask(fmt.Sprintf("%s (%s) [%s]: ", field.title, strings.Join(field.options, "/"), current))
+			if err != nil {
+				return err
+			}
+			if answer == "" {
+				break
+			}
+			if containsString(field.options, answer) {
+				field.value = answer
+				break
+			}
+			_, _ = fmt.Fprintf(p.out, "  Please choose one of: %s\n", strings.Join(field.options, ", "))
+		}
+
+	case "text":
+		for {
+			answer, err := p.ask(fmt.Sprintf("%s [%s] ('-' to clear): ", field.title, field.value.(string)))
+			if err != nil {
+				return err
+			}
+			if answer == "-" {
+				answer = ""
+			} else if answer == "" {
+				break
+			}
+			// Image references are checked before they are taken
+			if isImageField(field.name) && answer != "" {
+				if problem := validateImageValue(answer); problem != "" {
+					_, _ = fmt.Fprintf(p.out, "  %s\n", problem)
+					continue
+				}
+				if note := lookUpImage(modal.runtime(), answer); note != "" {
+					_, _ = fmt.Fprintf(p.out, "  Warning: %s\n", note)
+				}
+			}
+			field.value = answer
+			break
+		}
+
+	case "action":
+		if field.name == "env-profiles" {
+			return promptEnvProfiles(p, modal, field)
+		}
+	}
+
+	return nil
+}
+
+// promptEnvProfiles edits env profiles with a simple add/edit/delete loop
+func promptEnvProfiles(p *promptReader, modal *SettingsModal, field *SettingsField) error {
+	edit, err := p.askYesNo(fmt.Sprintf("Edit environment profiles (%s)?", field.value.(string)), false)
+	if err != nil || !edit {
+		return err
+	}
+
+	profiles := copyEnvConfigs(modal.envConfigs)
+	for {
+		name, err := p.ask("Profile name to add or edit (blank to finish): ")
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			break
+		}
+
+		profile, exists := profiles[name]
+		if exists {
+			remove, err := p.askYesNo(fmt.Sprintf("Delete profile %s?", name), false)
+			if err != nil {
+				return err
+			}
+			if remove {
+				delete(profiles, name)
+				continue
+			}
+		} else {
+			profile = EnvConfig{Name: name, EnvVars: map[string]string{}}
+		}
+		if profile.EnvVars == nil {
+			profile.EnvVars = map[string]string{}
+		}
+
+		for key, value := range profile.EnvVars {
+			_, _ = fmt.Fprintf(p.out, "  %s=%s\n", key, value)
+		}
+		for {
+			entry, err := p.ask("  KEY=value (blank to finish, -KEY to remove): ")
+			if err != nil {
+				return err
+			}
+			if entry == "" {
+				break
+			}
+			if strings.HasPrefix(entry, "-") {
+				delete(profile.EnvVars, strings.TrimPrefix(entry, "-"))
+				continue
+			}
+			key, value, ok := strings.Cut(entry, "=")
+			if !ok || strings.TrimSpace(key) == "" {
+				_, _ = fmt.Fprintln(p.out, "  Expected KEY=value.")
+				continue
+			}
+			profile.EnvVars[strings.TrimSpace(key)] = value
+		}
+		profiles[name] = profile
+	}
+
+	modal.envConfigs = profiles
+	modal.envChanged = true
+	field.value = envProfilesSummary(profiles)
+	return nil
+}
+
+func containsString(items []string, value string) bool {
+	for _, item := range items {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
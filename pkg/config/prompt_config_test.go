@@ -0,0 +1,111 @@
+package config
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunPromptModal(t *testing.T) {
+	existing := &Config{
+		ContainerRuntime:   "docker",
+		DefaultCredentials: Credentials{Git: true, SSH: true},
+		DefaultContainer:   GetDefaultContainerConfig(),
+	}
+	modal, err := createSectionModal(existing, "credentials")
+	if err != nil {
+		t.Fatalf("createSectionModal() error = %v", err)
+	}
+
+	// ssh: keep, ssh-agent: keep, github: yes, gpg: invalid then no, gpg-agent: keep, npm: keep, aws: y, gcp: keep, azure: keep, kube: keep, save: keep default
+	input := strings.Join([]string{"", "", "y", "maybe", "n", "", "", "y", "", "", "", ""}, "\n") + "\n"
+	var out bytes.Buffer
+	if err := runPromptModal(modal, strings.NewReader(input), &out); err != nil {
+		t.Fatalf("runPromptModal() error = %v", err)
+	}
+
+	if !modal.saved {
+		t.Error("modal should be saved after confirming")
+	}
+	if !strings.Contains(out.String(), "Please answer y or n") {
+		t.Errorf("invalid answer should be re-asked, output:\n%s", out.String())
+	}
+
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	if err := applyModalConfigUpdates(modal, configFile); err != nil {
+		t.Fatalf("applyModalConfigUpdates() error = %v", err)
+	}
+	saved, _ := LoadConfigFromFile(configFile)
+	want := Credentials{Git: true, SSH: true, GH: true, GPG: false, NPM: false, AWS: true}
+	if saved.DefaultCredentials != want {
+		t.Errorf("DefaultCredentials = %+v, want %+v", saved.DefaultCredentials, want)
+	}
+}
+
+func TestRunPromptModalSelectAndText(t *testing.T) {
+	stubImageExists(t, true)
+	existing := &Config{DefaultContainer: GetDefaultContainerConfig()}
+	modal, err := createSectionModal(existing, "container")
+	if err != nil {
+		t.Fatalf("createSectionModal() error = %v", err)
+	}
+
+	// image, variant (invalid then slim), check updates, auto-pull, frequency, save
+	input := strings.Join([]string{"my-org/dev:v1", "huge", "slim", "", "", "weekly", "y"}, "\n") + "\n"
+	if err := runPromptModal(modal, strings.NewReader(input), &bytes.Buffer{}); err != nil {
+		t.Fatalf("runPromptModal() error = %v", err)
+	}
+
+	container := extractDefaultContainerUpdates(modal).DefaultContainer.Apply(DefaultContainerConfig{})
+	if container.Image != "my-org/dev:v1" {
+		t.Errorf("Image = %v, want my-org/dev:v1", container.Image)
+	}
+	if container.Variant != "slim" {
+		t.Errorf("Variant = %v, want slim", container.Variant)
+	}
+	if container.CheckFrequencyHours != 168 {
+		t.Errorf("CheckFrequencyHours = %v, want 168", container.CheckFrequencyHours)
+	}
+}
+
+func TestRunPromptModalEnvProfiles(t *testing.T) {
+	existing := &Config{EnvConfigs: map[string]EnvConfig{}}
+	modal, err := createSectionModal(existing, "env")
+	if err != nil {
+		t.Fatalf("createSectionModal() error = %v", err)
+	}
+
+	input := strings.Join([]string{"y", "z.ai", "ANTHROPIC_BASE_URL=https://api.z.ai", "", "", "y"}, "\n") + "\n"
+	if err := runPromptModal(modal, strings.NewReader(input), &bytes.Buffer{}); err != nil {
+		t.Fatalf("runPromptModal() error = %v", err)
+	}
+
+	if !modal.envChanged || modal.envConfigs["z.ai"].EnvVars["ANTHROPIC_BASE_URL"] != "https://api.z.ai" {
+		t.Errorf("envConfigs = %v, want z.ai profile", modal.envConfigs)
+	}
+}
+
+func TestRunPromptModalInputEnded(t *testing.T) {
+	modal, _ := createSectionModal(&Config{}, "credentials")
+	if err := runPromptModal(modal, strings.NewReader(""), &bytes.Buffer{}); err == nil {
+		t.Error("runPromptModal() should fail when input ends")
+	}
+	if modal.saved {
+		t.Error("modal should not be saved when input ends")
+	}
+}
+
+func TestUseBasicPrompts(t *testing.T) {
+	t.Setenv("TERM", "dumb")
+	if !useBasicPrompts() {
+		t.Error("dumb terminals should use basic prompts")
+	}
+
+	DisableTUI = true
+	defer func() { DisableTUI = false }()
+	t.Setenv("TERM", "xterm-256color")
+	if !useBasicPrompts() {
+		t.Error("DisableTUI should force basic prompts")
+	}
+}
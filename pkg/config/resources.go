@@ -0,0 +1,181 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ResourcesConfig caps what a container may use, so a runaway agent can't take over the
+// host. Empty values leave the runtime's default (no limit).
+type ResourcesConfig struct {
+	CPUs       string `json:"cpus,omitempty"`        // number of CPUs, e.g. "2" or "1.5"
+	Memory     string `json:"memory,omitempty"`      // memory limit, e.g. "4g" or "512m"
+	MemorySwap string `json:"memory_swap,omitempty"` // memory plus swap, e.g. "8g"; "-1" allows unlimited swap
+	PidsLimit  int64  `json:"pids_limit,omitempty"`  // maximum number of processes; -1 for unlimited
+}
+
+// Apply returns r with the values set in override replacing its own
+func (r ResourcesConfig) Apply(override ResourcesConfig) ResourcesConfig {
+	if override.CPUs != "" {
+		r.CPUs = override.CPUs
+	}
+	if override.Memory != "" {
+		r.Memory = override.Memory
+	}
+	if override.MemorySwap != "" {
+		r.MemorySwap = override.MemorySwap
+	}
+	if override.PidsLimit != 0 {
+		r.PidsLimit = override.PidsLimit
+	}
+	return r
+}
+
+// raisedBy describes the limits in override that lift or go above r's, e.g. "memory 64g
+// (global 8g)". Limits r leaves unset can't be raised.
+func (r ResourcesConfig) raisedBy(override ResourcesConfig) []string {
+	var raised []string
+	if cpusRaised(r.CPUs, override.CPUs) {
+		raised = append(raised, fmt.Sprintf("cpus %s (global %s)", override.CPUs, r.CPUs))
+	}
+	if memoryRaised(r.Memory, override.Memory) {
+		raised = append(raised, fmt.Sprintf("memory %s (global %s)", override.Memory, r.Memory))
+	}
+	if memoryRaised(r.MemorySwap, override.MemorySwap) {
+		raised = append(raised, fmt.Sprintf("memory_swap %s (global %s)", override.MemorySwap, r.MemorySwap))
+	}
+	if pidsRaised(r.PidsLimit, override.PidsLimit) {
+		raised = append(raised, fmt.Sprintf("pids_limit %d (global %d)", override.PidsLimit, r.PidsLimit))
+	}
+	return raised
+}
+
+// clamp returns override without the limits that raise r's, so r's apply instead
+func (r ResourcesConfig) clamp(override ResourcesConfig) ResourcesConfig {
+	if cpusRaised(r.CPUs, override.CPUs) {
+		override.CPUs = ""
+	}
+	if memoryRaised(r.Memory, override.Memory) {
+		override.Memory = ""
+	}
+	if memoryRaised(r.MemorySwap, override.MemorySwap) {
+		override.MemorySwap = ""
+	}
+	if pidsRaised(r.PidsLimit, override.PidsLimit) {
+		override.PidsLimit = 0
+	}
+	return override
+}
+
+// cpusRaised reports whether value allows more CPUs than limit
+func cpusRaised(limit, value string) bool {
+	if limit == "" || value == "" {
+		return false
+	}
+	l, errLimit := strconv.ParseFloat(limit, 64)
+	v, errValue := strconv.ParseFloat(value, 64)
+	return errLimit != nil || errValue != nil || v > l
+}
+
+// memoryRaised reports whether value allows more memory than limit; "-1" is unlimited
+func memoryRaised(limit, value string) bool {
+	if limit == "" || limit == "-1" || value == "" {
+		return false
+	}
+	if value == "-1" {
+		return true
+	}
+	l, errLimit := ParseMemory(limit)
+	v, errValue := ParseMemory(value)
+	return errLimit != nil || errValue != nil || v > l
+}
+
+// pidsRaised reports whether value allows more processes than limit; -1 is unlimited
+func pidsRaised(limit, value int64) bool {
+	if limit == 0 || limit == -1 || value == 0 {
+		return false
+	}
+	return value == -1 || value > limit
+}
+
+// Validate checks the limits the way docker run would, so mistakes surface before the
+// container is created
+func (r ResourcesConfig) Validate() error {
+	if r.CPUs != "" {
+		cpus, err := strconv.ParseFloat(r.CPUs, 64)
+		if err != nil || cpus <= 0 {
+			return fmt.Errorf("invalid cpus '%s' (use a number of CPUs like 2 or 1.5)", r.CPUs)
+		}
+	}
+
+	var memory int64
+	if r.Memory != "" {
+		var err error
+		if memory, err = ParseMemory(r.Memory); err != nil {
+			return fmt.Errorf("invalid memory '%s' (use a size like 4g or 512m)", r.Memory)
+		}
+	}
+
+	if r.MemorySwap != "" && r.MemorySwap != "-1" {
+		swap, err := ParseMemory(r.MemorySwap)
+		if err != nil {
+			return fmt.Errorf("invalid memory_swap '%s' (use a size like 8g, or -1 for unlimited swap)", r.MemorySwap)
+		}
+		if r.Memory == "" {
+			return fmt.Errorf("memory_swap requires memory to be set")
+		}
+		if swap < memory {
+			return fmt.Errorf("memory_swap '%s' is smaller than memory '%s' (it includes memory)", r.MemorySwap, r.Memory)
+		}
+	} else if r.MemorySwap == "-1" && r.Memory == "" {
+		return fmt.Errorf("memory_swap requires memory to be set")
+	}
+
+	if r.PidsLimit < -1 {
+		return fmt.Errorf("invalid pids_limit %d (use a positive number, or -1 for unlimited)", r.PidsLimit)
+	}
+	return nil
+}
+
+// Args returns the docker run flags for the limits that are set
+func (r ResourcesConfig) Args() []string {
+	var args []string
+	if r.CPUs != "" {
+		args = append(args, "--cpus", r.CPUs)
+	}
+	if r.Memory != "" {
+		args = append(args, "--memory", r.Memory)
+	}
+	if r.MemorySwap != "" {
+		args = append(args, "--memory-swap", r.MemorySwap)
+	}
+	if r.PidsLimit != 0 {
+		args = append(args, "--pids-limit", strconv.FormatInt(r.PidsLimit, 10))
+	}
+	return args
+}
+
+// memoryPattern matches docker's memory sizes: a number with an optional b, k, m, g, t
+// or p unit, in either case and with an optional "b" or "ib" suffix
+var memoryPattern = regexp.MustCompile(`^(\d+(?:\.\d+)?) ?([kmgtp])?(?:i?b)?$`)
+
+// ParseMemory converts a docker memory size like "512m" or "4GB" to bytes (units are
+// powers of 1024, as docker treats them)
+func ParseMemory(size string) (int64, error) {
+	match := memoryPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(size)))
+	if match == nil {
+		return 0, fmt.Errorf("invalid size '%s'", size)
+	}
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size '%s'", size)
+	}
+
+	multiplier := int64(1)
+	if match[2] != "" {
+		multiplier = int64(1) << (10 * (strings.Index("kmgtp", match[2]) + 1))
+	}
+	return int64(value * float64(multiplier)), nil
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMemory(t *testing.T) {
+	tests := []struct {
+		size    string
+		want    int64
+		wantErr bool
+	}{
+		{"1024", 1024, false},
+		{"512m", 512 << 20, false},
+		{"4g", 4 << 30, false},
+		{"4GB", 4 << 30, false},
+		{"1.5GiB", 3 << 29, false},
+		{"2 k", 2048, false},
+		{"lots", 0, true},
+		{"4x", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMemory(tt.size)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseMemory(%q) = %d, %v, want %d (error %v)", tt.size, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestResourcesValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources ResourcesConfig
+		wantErr   string
+	}{
+		{"empty", ResourcesConfig{}, ""},
+		{"all set", ResourcesConfig{CPUs: "1.5", Memory: "4g", MemorySwap: "8g", PidsLimit: 512}, ""},
+		{"unlimited swap", ResourcesConfig{Memory: "4g", MemorySwap: "-1", PidsLimit: -1}, ""},
+		{"bad cpus", ResourcesConfig{CPUs: "two"}, "invalid cpus"},
+		{"zero cpus", ResourcesConfig{CPUs: "0"}, "invalid cpus"},
+		{"bad memory", ResourcesConfig{Memory: "4 gigs"}, "invalid memory"},
+		{"swap without memory", ResourcesConfig{MemorySwap: "8g"}, "requires memory"},
+		{"swap below memory", ResourcesConfig{Memory: "4g", MemorySwap: "2g"}, "smaller than memory"},
+		{"bad pids", ResourcesConfig{PidsLimit: -5}, "invalid pids_limit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.resources.Validate()
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResourcesApplyAndArgs(t *testing.T) {
+	global := ResourcesConfig{CPUs: "2", Memory: "4g", PidsLimit: 1024}
+	got := global.Apply(ResourcesConfig{Memory: "8g", MemorySwap: "-1"})
+
+	want := []string{"--cpus", "2", "--memory", "8g", "--memory-swap", "-1", "--pids-limit", "1024"}
+	if args := got.Args(); !reflect.DeepEqual(args, want) {
+		t.Errorf("Args() = %v, want %v", args, want)
+	}
+	if args := (ResourcesConfig{}).Args(); len(args) != 0 {
+		t.Errorf("Args() = %v, want none", args)
+	}
+}
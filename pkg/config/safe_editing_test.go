@@ -52,11 +52,10 @@ func TestConfigPreservationDuringEditing(t *testing.T) {
 
 	// Simulate interactive editing (updates only shown fields)
 	runtime := "podman"
-	creds := Credentials{
-		Git: true,
-		SSH: true,  // Changed from false
-		GH:  false, // Changed from true
-		GPG: true,  // New setting
+	creds := CredentialOverrides{
+		SSH: boolPtr(true),  // Changed from false
+		GH:  boolPtr(false), // Changed from true
+		GPG: boolPtr(true),  // New setting
 	}
 	updates := ConfigUpdates{
 		ContainerRuntime:   &runtime,
@@ -207,4 +206,123 @@ func TestPartialConfigUpdate(t *testing.T) {
 	if !updated.DefaultCredentials.GH {
 		t.Error("GH should be updated to true")
 	}
-}
\ No newline at end of file
+}
+
+func TestSectionModalPreservesOtherSections(t *testing.T) {
+	// Test that saving a single-section modal doesn't touch other sections
+
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	existing := &Config{
+		ContainerRuntime:   "podman",
+		DefaultCredentials: Credentials{Git: true, SSH: true},
+		DefaultContainer:   DefaultContainerConfig{Image: "my-custom/image:latest", CheckFrequencyHours: 12},
+	}
+	if err := SaveConfig(existing, configFile); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+
+	modal, err := createSectionModal(existing, "credentials")
+	if err != nil {
+		t.Fatalf("createSectionModal() error = %v", err)
+	}
+	if len(modal.sections) != 1 || modal.sections[0].name != "credentials" {
+		t.Fatalf("createSectionModal() sections = %v, want only credentials", modal.sections)
+	}
+
+	// Toggle GPG on
+	for i, field := range modal.sections[0].fields {
+		if field.name == "gpg" {
+			modal.sections[0].fields[i].value = true
+		}
+	}
+
+	if err := applyModalConfigUpdates(modal, configFile); err != nil {
+		t.Fatalf("applyModalConfigUpdates() error = %v", err)
+	}
+
+	updated, err := LoadConfigFromFile(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfigFromFile() error = %v", err)
+	}
+	if !updated.DefaultCredentials.GPG || !updated.DefaultCredentials.SSH {
+		t.Errorf("credentials = %+v, want ssh and gpg enabled", updated.DefaultCredentials)
+	}
+	if updated.ContainerRuntime != "podman" {
+		t.Errorf("ContainerRuntime = %v, want podman (preserved)", updated.ContainerRuntime)
+	}
+	if updated.DefaultContainer.Image != "my-custom/image:latest" || updated.DefaultContainer.CheckFrequencyHours != 12 {
+		t.Errorf("DefaultContainer = %+v, want preserved", updated.DefaultContainer)
+	}
+
+	if _, err := createSectionModal(existing, "bogus"); err == nil {
+		t.Error("createSectionModal() should reject unknown sections")
+	}
+}
+
+func TestUpdateConfigSafelyMergesFields(t *testing.T) {
+	// Test that updates only touch the individual fields they set
+
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	original := &Config{
+		ContainerRuntime:   "docker",
+		DefaultCredentials: Credentials{Git: false, NPM: true}, // Git deliberately disabled
+		DefaultContainer: DefaultContainerConfig{
+			Image:               "my-custom/image:latest",
+			CheckForUpdates:     true,
+			CheckFrequencyHours: 12,
+			Variant:             "slim",
+		},
+	}
+	if err := SaveConfig(original, configFile); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+
+	image := "other/image:v2"
+	updates := ConfigUpdates{
+		DefaultCredentials: &CredentialOverrides{SSH: boolPtr(true)},
+		DefaultContainer:   &DefaultContainerUpdates{Image: &image},
+	}
+	if err := UpdateConfigSafely(configFile, updates); err != nil {
+		t.Fatalf("UpdateConfigSafely() error = %v", err)
+	}
+
+	updated, err := LoadConfigFromFile(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfigFromFile() error = %v", err)
+	}
+
+	want := Credentials{Git: false, SSH: true, NPM: true}
+	if updated.DefaultCredentials != want {
+		t.Errorf("DefaultCredentials = %+v, want %+v", updated.DefaultCredentials, want)
+	}
+	wantContainer := DefaultContainerConfig{Image: image, CheckForUpdates: true, CheckFrequencyHours: 12, Variant: "slim"}
+	if updated.DefaultContainer != wantContainer {
+		t.Errorf("DefaultContainer = %+v, want %+v", updated.DefaultContainer, wantContainer)
+	}
+	if updated.ContainerRuntime != "docker" {
+		t.Errorf("ContainerRuntime = %v, want docker", updated.ContainerRuntime)
+	}
+}
+
+func TestModalDoesNotForceGitCredentials(t *testing.T) {
+	// The modal has no git toggle, so saving it must not re-enable git
+
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	existing := &Config{ContainerRuntime: "docker", DefaultCredentials: Credentials{Git: false}}
+	if err := SaveConfig(existing, configFile); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+
+	modal := createSettingsModal(existing)
+	if err := applyModalConfigUpdates(modal, configFile); err != nil {
+		t.Fatalf("applyModalConfigUpdates() error = %v", err)
+	}
+
+	updated, err := LoadConfigFromFile(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfigFromFile() error = %v", err)
+	}
+	if updated.DefaultCredentials.Git {
+		t.Error("Git credentials should stay disabled")
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ScopedCredentialsConfig sets up --scoped-creds: instead of mounting long-lived gh and
+// AWS credentials, packnplay mints short-lived, narrowly scoped tokens on the host for
+// each run and passes only those to the container
+type ScopedCredentialsConfig struct {
+	Enabled bool             `json:"enabled,omitempty"` // on every run, not only with --scoped-creds
+	GH      GHAppConfig      `json:"gh"`
+	AWS     AWSSessionConfig `json:"aws"`
+}
+
+// GHAppConfig is the GitHub App whose installation tokens replace the gh login. Tokens
+// last an hour and are limited to Permissions and, by default, the project's repository.
+type GHAppConfig struct {
+	AppID          string            `json:"app_id,omitempty"`
+	InstallationID string            `json:"installation_id,omitempty"`
+	PrivateKey     string            `json:"private_key,omitempty"`  // path to the app's .pem private key
+	Permissions    map[string]string `json:"permissions,omitempty"`  // e.g. {"contents": "write", "pull_requests": "write"}; default: all the app has
+	Repositories   []string          `json:"repositories,omitempty"` // repository names; default: the project's origin repository
+}
+
+// Configured reports whether enough is set to mint tokens
+func (g GHAppConfig) Configured() bool {
+	return g.AppID != "" && g.InstallationID != "" && g.PrivateKey != ""
+}
+
+// AWSSessionConfig is how temporary AWS credentials are obtained with the host's own:
+// sts assume-role when RoleARN is set, sts get-federation-token otherwise
+type AWSSessionConfig struct {
+	RoleARN  string `json:"role_arn,omitempty"`
+	Policy   string `json:"policy,omitempty"`   // session policy limiting the credentials: JSON, or file://path (required without role_arn)
+	Duration string `json:"duration,omitempty"` // how long the credentials last (default: 1h)
+}
+
+// DefaultAWSSessionDuration is how long scoped AWS credentials last by default
+const DefaultAWSSessionDuration = time.Hour
+
+// SessionDuration returns the configured duration, or the default
+func (a AWSSessionConfig) SessionDuration() (time.Duration, error) {
+	if a.Duration == "" {
+		return DefaultAWSSessionDuration, nil
+	}
+	d, err := time.ParseDuration(a.Duration)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration '%s': %w", a.Duration, err)
+	}
+	// STS hands out credentials for 15 minutes to 12 hours (36 for federation tokens)
+	if d < 15*time.Minute || d > 12*time.Hour {
+		return 0, fmt.Errorf("duration '%s' must be between 15m and 12h", a.Duration)
+	}
+	return d, nil
+}
+
+// Validate checks the settings that can be checked without the host's credentials
+func (s ScopedCredentialsConfig) Validate() error {
+	if _, err := s.AWS.SessionDuration(); err != nil {
+		return fmt.Errorf("aws: %w", err)
+	}
+	if s.AWS.RoleARN != "" && !strings.HasPrefix(s.AWS.RoleARN, "arn:") {
+		return fmt.Errorf("aws: role_arn '%s' is not an ARN", s.AWS.RoleARN)
+	}
+	gh := s.GH
+	if (gh.AppID != "" || gh.InstallationID != "" || gh.PrivateKey != "") && !gh.Configured() {
+		return fmt.Errorf("gh: app_id, installation_id and private_key must all be set")
+	}
+	return nil
+}
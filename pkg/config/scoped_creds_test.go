@@ -0,0 +1,43 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScopedCredentialsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		scoped  ScopedCredentialsConfig
+		wantErr string
+	}{
+		{"default", ScopedCredentialsConfig{}, ""},
+		{"assume role", ScopedCredentialsConfig{AWS: AWSSessionConfig{RoleARN: "arn:aws:iam::123456789012:role/agent", Duration: "30m"}}, ""},
+		{"github app", ScopedCredentialsConfig{GH: GHAppConfig{AppID: "1234", InstallationID: "5678", PrivateKey: "~/app.pem"}}, ""},
+		{"role name instead of arn", ScopedCredentialsConfig{AWS: AWSSessionConfig{RoleARN: "agent"}}, "not an ARN"},
+		{"duration too short", ScopedCredentialsConfig{AWS: AWSSessionConfig{Duration: "5m"}}, "between 15m and 12h"},
+		{"unparseable duration", ScopedCredentialsConfig{AWS: AWSSessionConfig{Duration: "an hour"}}, "invalid duration"},
+		{"github app without key", ScopedCredentialsConfig{GH: GHAppConfig{AppID: "1234", InstallationID: "5678"}}, "must all be set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.scoped.Validate()
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAWSSessionDuration(t *testing.T) {
+	if d, err := (AWSSessionConfig{}).SessionDuration(); err != nil || d != DefaultAWSSessionDuration {
+		t.Errorf("SessionDuration() default = %v, %v", d, err)
+	}
+	if d, err := (AWSSessionConfig{Duration: "2h"}).SessionDuration(); err != nil || d != 2*time.Hour {
+		t.Errorf("SessionDuration(2h) = %v, %v", d, err)
+	}
+}
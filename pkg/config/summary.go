@@ -0,0 +1,17 @@
+package config
+
+// Exit summary modes control what packnplay prints when a managed run's command exits
+const (
+	SummaryOff   = "off"   // print nothing
+	SummaryShort = "short" // exit status, duration, changed-file count and next steps (default)
+	SummaryFull  = "full"  // like short, also listing the changed files
+)
+
+// ValidSummaryMode reports whether mode is a known exit summary mode
+func ValidSummaryMode(mode string) bool {
+	switch mode {
+	case "", SummaryOff, SummaryShort, SummaryFull:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,14 @@
+package config
+
+import "testing"
+
+func TestValidSummaryMode(t *testing.T) {
+	for _, mode := range []string{"", SummaryOff, SummaryShort, SummaryFull} {
+		if !ValidSummaryMode(mode) {
+			t.Errorf("ValidSummaryMode(%q) = false", mode)
+		}
+	}
+	if ValidSummaryMode("long") {
+		t.Error("ValidSummaryMode(\"long\") = true")
+	}
+}
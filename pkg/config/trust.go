@@ -0,0 +1,105 @@
+package config
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/obra/packnplay/pkg/registry"
+)
+
+// Policies for starting an image that isn't trusted while secret credentials are enabled.
+// Unset, the policy is prompt on a terminal and warn without one.
+const (
+	UntrustedImageWarn   = "warn"   // print a warning and continue
+	UntrustedImagePrompt = "prompt" // ask before mounting; refuse when there is no terminal
+	UntrustedImageRefuse = "refuse" // don't start the container
+)
+
+// EffectiveUntrustedImagePolicy returns the policy that applies: policy when it is set,
+// else prompt when there is a terminal to ask on and warn when there isn't
+func EffectiveUntrustedImagePolicy(policy string, interactive bool) string {
+	if policy != "" {
+		return policy
+	}
+	if interactive {
+		return UntrustedImagePrompt
+	}
+	return UntrustedImageWarn
+}
+
+// ValidUntrustedImagePolicy reports whether policy is a known untrusted image policy
+func ValidUntrustedImagePolicy(policy string) bool {
+	switch policy {
+	case "", UntrustedImageWarn, UntrustedImagePrompt, UntrustedImageRefuse:
+		return true
+	}
+	return false
+}
+
+// ImageTrusted reports whether image matches one of patterns. The packnplay default
+// image is always trusted. A pattern without a tag matches every tag of that repository,
+// "*" matches any characters, and Docker Hub shorthand is expanded on both sides, so
+// "ubuntu" and "docker.io/library/ubuntu:*" both match "ubuntu:24.04".
+func ImageTrusted(image string, patterns []string) bool {
+	ref, err := registry.ParseReference(image)
+	if err != nil {
+		// Not a registry reference; only an exact pattern can vouch for it
+		for _, pattern := range patterns {
+			if pattern == image {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ref.Name() == DefaultImageRepository {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		if imagePatternMatches(pattern, ref, image) {
+			return true
+		}
+	}
+	return false
+}
+
+// imagePatternMatches matches one trusted_images pattern against a parsed image
+func imagePatternMatches(pattern string, ref *registry.Reference, image string) bool {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return false
+	}
+
+	if strings.Contains(pattern, "*") {
+		re := regexp.MustCompile("^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$")
+		return re.MatchString(image) || re.MatchString(repositoryOf(image)) || re.MatchString(ref.Name()) || re.MatchString(ref.String())
+	}
+
+	patternRef, err := registry.ParseReference(pattern)
+	if err != nil {
+		return pattern == image
+	}
+	if patternRef.Name() != ref.Name() {
+		return false
+	}
+	// Tag and digest are only compared when the pattern names them
+	name, _, _ := strings.Cut(pattern, "@")
+	explicitTag := strings.LastIndex(name, ":") > strings.LastIndex(name, "/")
+	if explicitTag && patternRef.Tag != ref.Tag {
+		return false
+	}
+	if patternRef.Digest != "" && patternRef.Digest != ref.Digest {
+		return false
+	}
+	return true
+}
+
+// repositoryOf returns image as written without its tag or digest
+func repositoryOf(image string) string {
+	name, _, _ := strings.Cut(image, "@")
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	return name
+}
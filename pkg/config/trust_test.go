@@ -0,0 +1,45 @@
+package config
+
+import "testing"
+
+func TestImageTrusted(t *testing.T) {
+	tests := []struct {
+		name     string
+		image    string
+		patterns []string
+		want     bool
+	}{
+		{"default image always trusted", "ghcr.io/obra/packnplay-default:slim", nil, true},
+		{"no patterns", "ubuntu:24.04", nil, false},
+		{"repository matches any tag", "ubuntu:24.04", []string{"ubuntu"}, true},
+		{"hub shorthand expanded", "docker.io/library/ubuntu:24.04", []string{"ubuntu"}, true},
+		{"explicit tag must match", "ubuntu:22.04", []string{"ubuntu:24.04"}, false},
+		{"explicit tag matches", "ubuntu", []string{"ubuntu:latest"}, true},
+		{"digest must match", "ubuntu@sha256:bbb", []string{"ubuntu@sha256:aaa"}, false},
+		{"digest matches", "ubuntu:24.04@sha256:aaa", []string{"ubuntu@sha256:aaa"}, true},
+		{"typosquat rejected", "ghcr.io/obra/packnplay-defau1t:latest", []string{"ubuntu"}, false},
+		{"wildcard organization", "ghcr.io/my-company/dev:1.2", []string{"ghcr.io/my-company/*"}, true},
+		{"wildcard other organization", "ghcr.io/my-company-evil/dev:1.2", []string{"ghcr.io/my-company/*"}, false},
+		{"wildcard against normalized name", "node:20", []string{"docker.io/library/*"}, true},
+		{"built devcontainer image", "packnplay-app-devcontainer:latest", []string{"packnplay-*-devcontainer"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ImageTrusted(tt.image, tt.patterns); got != tt.want {
+				t.Errorf("ImageTrusted(%q, %v) = %v, want %v", tt.image, tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidUntrustedImagePolicy(t *testing.T) {
+	for _, policy := range []string{"", UntrustedImageWarn, UntrustedImagePrompt, UntrustedImageRefuse} {
+		if !ValidUntrustedImagePolicy(policy) {
+			t.Errorf("ValidUntrustedImagePolicy(%q) = false", policy)
+		}
+	}
+	if ValidUntrustedImagePolicy("ask") {
+		t.Error("ValidUntrustedImagePolicy(\"ask\") = true")
+	}
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Problems returns what is wrong with the settings in c, such as unknown modes or
+// unparseable durations, or nil when they are all usable
+func (c *Config) Problems() []string {
+	var problems []string
+	switch c.ContainerRuntime {
+	case "", "docker", "podman", "container":
+	default:
+		problems = append(problems, fmt.Sprintf("unknown container_runtime %q", c.ContainerRuntime))
+	}
+	if !ValidImageVariant(c.DefaultContainer.Variant) {
+		problems = append(problems, fmt.Sprintf("unknown default_container.variant %q", c.DefaultContainer.Variant))
+	}
+	if !ValidClaudeSyncMode(c.ClaudeSync) {
+		problems = append(problems, fmt.Sprintf("unknown claude_sync %q", c.ClaudeSync))
+	}
+	if !ValidClaudeJSONSyncMode(c.ClaudeJSONSync) {
+		problems = append(problems, fmt.Sprintf("unknown claude_json_sync %q", c.ClaudeJSONSync))
+	}
+	if !ValidUntrustedImagePolicy(c.UntrustedImagePolicy) {
+		problems = append(problems, fmt.Sprintf("unknown untrusted_image_policy %q", c.UntrustedImagePolicy))
+	}
+	if !ValidSummaryMode(c.Summary) {
+		problems = append(problems, fmt.Sprintf("unknown summary %q", c.Summary))
+	}
+	if c.Timeout != "" && c.Timeout != "0" {
+		if d, err := time.ParseDuration(c.Timeout); err != nil || d < 0 {
+			problems = append(problems, fmt.Sprintf("invalid timeout %q", c.Timeout))
+		}
+	}
+	if err := c.DefaultResources.Validate(); err != nil {
+		problems = append(problems, "default_resources: "+err.Error())
+	}
+	if err := c.Network.Validate(); err != nil {
+		problems = append(problems, "network: "+err.Error())
+	}
+	if err := c.Docker.Validate(); err != nil {
+		problems = append(problems, "docker: "+err.Error())
+	}
+	if err := c.DependencyCaches.Validate(); err != nil {
+		problems = append(problems, "dependency_caches: "+err.Error())
+	}
+	if err := c.ScopedCredentials.Validate(); err != nil {
+		problems = append(problems, "scoped_credentials: "+err.Error())
+	}
+	if err := c.LogCapture.Validate(); err != nil {
+		problems = append(problems, "log_capture: "+err.Error())
+	}
+	if err := c.Notifications.Validate(); err != nil {
+		problems = append(problems, "notifications: "+err.Error())
+	}
+	providers := slices.Sorted(maps.Keys(c.CredentialProviders))
+	for _, name := range providers {
+		if err := ValidateCredentialProviderName(name); err != nil {
+			problems = append(problems, "credential_providers: "+err.Error())
+		}
+	}
+	if _, err := ParseAutoCommitMessage(c.AutoCommitMessage); err != nil {
+		problems = append(problems, err.Error())
+	}
+	return problems
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfigProblems(t *testing.T) {
+	if problems := (&Config{}).Problems(); len(problems) != 0 {
+		t.Errorf("Problems() of an empty config = %q", problems)
+	}
+
+	cfg := &Config{ContainerRuntime: "lxc", Timeout: "soon", Network: NetworkConfig{Mode: NetworkAllowlist}, Docker: DockerConfig{Mode: "host"},
+		CredentialProviders: map[string]CredentialProviderConfig{"gcp": {Command: "gcp-creds"}, "vault": {Command: "vault-creds"}}}
+	got := strings.Join(cfg.Problems(), "; ")
+	for _, want := range []string{`unknown container_runtime "lxc"`, `invalid timeout "soon"`, "network: allowlist mode needs at least one domain", "docker: unknown mode 'host'", "credential_providers: credential provider 'gcp' has the name of a built-in credential"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Problems() = %q, missing %q", got, want)
+		}
+	}
+	if strings.Contains(got, "vault") {
+		t.Errorf("Problems() = %q, want the vault provider accepted", got)
+	}
+}
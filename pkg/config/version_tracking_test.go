@@ -1,9 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -52,6 +54,103 @@ func TestVersionTrackingPersistence(t *testing.T) {
 	}
 }
 
+func TestUpdateVersionTrackingConcurrent(t *testing.T) {
+	trackingFile := filepath.Join(t.TempDir(), "version-tracking.json")
+
+	// Each run loads, adds its own image and saves; none may clobber another's
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			image := fmt.Sprintf("image-%d:latest", i)
+			err := UpdateVersionTracking(trackingFile, func(tracking *VersionTrackingData) {
+				tracking.Notifications[image] = VersionNotification{Digest: "sha256:abc", ImageName: image}
+			})
+			if err != nil {
+				t.Errorf("UpdateVersionTracking() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	loaded, err := LoadVersionTracking(trackingFile)
+	if err != nil {
+		t.Fatalf("LoadVersionTracking() error = %v", err)
+	}
+	if len(loaded.Notifications) != 20 {
+		t.Errorf("Loaded notifications count = %v, want 20", len(loaded.Notifications))
+	}
+}
+
+func TestUpdateVersionTrackingKeepsLaterCheck(t *testing.T) {
+	trackingFile := filepath.Join(t.TempDir(), "version-tracking.json")
+	later := time.Now().Truncate(time.Second)
+	if err := SaveVersionTracking(&VersionTrackingData{LastCheck: later}, trackingFile); err != nil {
+		t.Fatal(err)
+	}
+
+	err := UpdateVersionTracking(trackingFile, func(tracking *VersionTrackingData) {
+		tracking.LastCheck = later.Add(-time.Hour)
+		tracking.Pulls["img"] = ImagePull{Platform: "linux/amd64"}
+	})
+	if err != nil {
+		t.Fatalf("UpdateVersionTracking() error = %v", err)
+	}
+
+	loaded, err := LoadVersionTracking(trackingFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.LastCheck.Equal(later) {
+		t.Errorf("LastCheck = %v, want %v", loaded.LastCheck, later)
+	}
+	if loaded.Pulls["img"].Platform != "linux/amd64" {
+		t.Errorf("Pulls = %v, want the update applied", loaded.Pulls)
+	}
+}
+
+func TestResetVersionNotifications(t *testing.T) {
+	trackingFile := filepath.Join(t.TempDir(), "version-tracking.json")
+	if n, err := ResetVersionNotifications(trackingFile, ""); err != nil || n != 0 {
+		t.Fatalf("ResetVersionNotifications() without a file = %d, %v", n, err)
+	}
+
+	tracking := &VersionTrackingData{
+		LastCheck: time.Now(),
+		Notifications: map[string]VersionNotification{
+			"a:latest": {Digest: "sha256:a", ImageName: "a:latest"},
+			"b:latest": {Digest: "sha256:b", ImageName: "b:latest"},
+		},
+		Pulls: map[string]ImagePull{"a:latest": {Platform: "linux/amd64"}},
+	}
+	if err := SaveVersionTracking(tracking, trackingFile); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := ResetVersionNotifications(trackingFile, "a:latest")
+	if err != nil || n != 1 {
+		t.Fatalf("ResetVersionNotifications(a:latest) = %d, %v, want 1", n, err)
+	}
+	loaded, err := LoadVersionTracking(trackingFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loaded.Notifications["b:latest"]; !ok || len(loaded.Notifications) != 1 {
+		t.Errorf("Notifications = %v, want only b:latest", loaded.Notifications)
+	}
+	if !loaded.LastCheck.IsZero() {
+		t.Errorf("LastCheck = %v, want it cleared", loaded.LastCheck)
+	}
+	if len(loaded.Pulls) != 1 {
+		t.Errorf("Pulls = %v, want them kept", loaded.Pulls)
+	}
+
+	if n, err := ResetVersionNotifications(trackingFile, ""); err != nil || n != 1 {
+		t.Errorf("ResetVersionNotifications() = %d, %v, want 1", n, err)
+	}
+}
+
 func TestVersionTrackingFileLocation(t *testing.T) {
 	// Test that version tracking file is stored in correct location
 
@@ -0,0 +1,313 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A cloned repository's .packnplay.json can reach past the sandbox: mount host paths, turn
+// on credentials, pass host env vars through, hand out docker access, push to registries,
+// lift the global resource limits and timeout, and pick the commands that run. Until the
+// user trusts the workspace those settings are ignored.
+
+// WorkspaceTrusted reports whether the repository at dir is in trusted_workspaces
+func (c *Config) WorkspaceTrusted(dir string) bool {
+	dir = filepath.Clean(dir)
+	for _, trusted := range c.TrustedWorkspaces {
+		if filepath.Clean(trusted) == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// TrustWorkspace adds dir to trusted_workspaces in the config file at path
+func TrustWorkspace(path, dir string) error {
+	dir = filepath.Clean(dir)
+	return editConfigDocument(path, func(doc map[string]any) error {
+		list, _ := doc["trusted_workspaces"].([]any)
+		for _, entry := range list {
+			if s, ok := entry.(string); ok && filepath.Clean(s) == dir {
+				return nil
+			}
+		}
+		doc["trusted_workspaces"] = append(list, dir)
+		return nil
+	})
+}
+
+// UntrustWorkspace removes dir from trusted_workspaces in the config file at path
+func UntrustWorkspace(path, dir string) error {
+	dir = filepath.Clean(dir)
+	return editConfigDocument(path, func(doc map[string]any) error {
+		list, _ := doc["trusted_workspaces"].([]any)
+		kept := []any{}
+		found := false
+		for _, entry := range list {
+			if s, ok := entry.(string); ok && filepath.Clean(s) == dir {
+				found = true
+				continue
+			}
+			kept = append(kept, entry)
+		}
+		if !found {
+			return fmt.Errorf("%s is not a trusted workspace", dir)
+		}
+		if len(kept) == 0 {
+			delete(doc, "trusted_workspaces")
+		} else {
+			doc["trusted_workspaces"] = kept
+		}
+		return nil
+	})
+}
+
+// TrustRisks describes the settings in pc that reach past the sandbox of the project in
+// projectDir or the limits of the global config, one line each, or nil when there are none
+func (pc *ProjectConfig) TrustRisks(projectDir string, global *Config) []string {
+	var risks []string
+	if mounts := pc.hostMounts(projectDir); len(mounts) > 0 {
+		risks = append(risks, "mounts host paths: "+strings.Join(mounts, ", "))
+	}
+	if volumes := pc.serviceHostVolumes(projectDir); len(volumes) > 0 {
+		risks = append(risks, "mounts host paths into services: "+strings.Join(volumes, ", "))
+	}
+	if pc.DependencyCaches != nil && pc.DependencyCaches.Scope == DependencyCacheGlobal {
+		risks = append(risks, "shares dependency caches with other projects")
+	}
+	if creds := pc.Credentials.enabled(); len(creds) > 0 {
+		risks = append(risks, "turns on credentials: "+strings.Join(creds, ", "))
+	}
+	if passed := passthroughEnv(pc.Env); len(passed) > 0 {
+		risks = append(risks, "passes host env vars: "+strings.Join(passed, ", "))
+	}
+	if pc.Docker != "" && pc.Docker != DockerOff {
+		risks = append(risks, "gives the container docker access: "+pc.Docker)
+	}
+	if pc.BuildCache != nil && pc.BuildCache.To != "" {
+		risks = append(risks, "pushes the build cache with the host's registry logins: "+pc.BuildCache.To)
+	}
+	if pc.Resources != nil {
+		if raised := global.DefaultResources.raisedBy(*pc.Resources); len(raised) > 0 {
+			risks = append(risks, "raises the resource limits: "+strings.Join(raised, ", "))
+		}
+	}
+	if timeoutRaised(global.Timeout, pc.Timeout) {
+		risks = append(risks, fmt.Sprintf("raises the timeout: %s (global %s)", timeoutLabel(pc.Timeout), global.Timeout))
+	}
+	if len(pc.Command) > 0 {
+		risks = append(risks, "sets the default command: "+strings.Join(pc.Command, " "))
+	}
+	if len(pc.Tasks) > 0 {
+		names := make([]string, 0, len(pc.Tasks))
+		for name := range pc.Tasks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		risks = append(risks, "defines tasks: "+strings.Join(names, ", "))
+	}
+	return risks
+}
+
+// Restricted returns pc without the settings TrustRisks reports, for untrusted workspaces.
+// Turning credentials off, setting env values, mounting the project's own files and
+// lowering limits stay; raised limits fall back to the global ones.
+func (pc *ProjectConfig) Restricted(projectDir string, global *Config) *ProjectConfig {
+	restricted := *pc
+	restricted.Mounts = nil
+	for _, mount := range pc.Mounts {
+		if mountInProject(mount, projectDir) {
+			restricted.Mounts = append(restricted.Mounts, mount)
+		}
+	}
+	if pc.Credentials != nil {
+		restricted.Credentials = pc.Credentials.disabledOnly()
+	}
+	if pc.DependencyCaches != nil && pc.DependencyCaches.Scope == DependencyCacheGlobal {
+		caches := *pc.DependencyCaches
+		caches.Scope = DependencyCacheProject
+		restricted.DependencyCaches = &caches
+	}
+	if len(pc.Services) > 0 {
+		restricted.Services = make(map[string]ServiceConfig, len(pc.Services))
+		for name, service := range pc.Services {
+			service.Volumes = nil
+			for _, volume := range pc.Services[name].Volumes {
+				if !isHostVolume(volume) || mountInProject(volume, projectDir) {
+					service.Volumes = append(service.Volumes, volume)
+				}
+			}
+			restricted.Services[name] = service
+		}
+	}
+	restricted.Env = nil
+	for _, env := range pc.Env {
+		if strings.Contains(env, "=") {
+			restricted.Env = append(restricted.Env, env)
+		}
+	}
+	if pc.Docker != DockerOff {
+		restricted.Docker = ""
+	}
+	if pc.BuildCache != nil {
+		cache := *pc.BuildCache
+		cache.To = ""
+		restricted.BuildCache = &cache
+	}
+	if pc.Resources != nil {
+		resources := global.DefaultResources.clamp(*pc.Resources)
+		restricted.Resources = &resources
+	}
+	if timeoutRaised(global.Timeout, pc.Timeout) {
+		restricted.Timeout = ""
+	}
+	restricted.Command = nil
+	restricted.Tasks = nil
+	return &restricted
+}
+
+// timeoutRaised reports whether a project timeout lifts or lengthens the global limit.
+// "" and "0" mean no limit.
+func timeoutRaised(limit, value string) bool {
+	if limit == "" || limit == "0" || value == "" {
+		return false
+	}
+	l, err := time.ParseDuration(limit)
+	if err != nil || l <= 0 {
+		return false
+	}
+	if value == "0" {
+		return true
+	}
+	v, err := time.ParseDuration(value)
+	return err != nil || v > l
+}
+
+// timeoutLabel describes a timeout setting, where "0" means no limit
+func timeoutLabel(timeout string) string {
+	if timeout == "0" {
+		return "none"
+	}
+	return timeout
+}
+
+// hostMounts returns the mounts whose host path is outside projectDir
+func (pc *ProjectConfig) hostMounts(projectDir string) []string {
+	var mounts []string
+	for _, mount := range pc.Mounts {
+		if !mountInProject(mount, projectDir) {
+			mounts = append(mounts, mount)
+		}
+	}
+	return mounts
+}
+
+// serviceHostVolumes returns the service volumes whose host path is outside projectDir,
+// sorted by service
+func (pc *ProjectConfig) serviceHostVolumes(projectDir string) []string {
+	names := make([]string, 0, len(pc.Services))
+	for name := range pc.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var volumes []string
+	for _, name := range names {
+		for _, volume := range pc.Services[name].Volumes {
+			if isHostVolume(volume) && !mountInProject(volume, projectDir) {
+				volumes = append(volumes, name+": "+volume)
+			}
+		}
+	}
+	return volumes
+}
+
+// mountInProject reports whether mount's host path resolves inside projectDir. Symlinks
+// are followed, as docker does, so a link committed to the repository can't point a
+// mount outside it.
+func mountInProject(mount, projectDir string) bool {
+	resolved, err := (&ProjectConfig{Mounts: []string{mount}}).ResolveMounts(projectDir)
+	if err != nil || len(resolved) == 0 {
+		return false
+	}
+	hostPath, _, _ := strings.Cut(resolved[0], ":")
+	return PathInProject(hostPath, projectDir)
+}
+
+// PathInProject reports whether path resolves inside projectDir, following symlinks
+func PathInProject(path, projectDir string) bool {
+	rel, err := filepath.Rel(realPath(projectDir), realPath(path))
+	return err == nil && filepath.IsLocal(rel)
+}
+
+// realPath returns path with symlinks resolved. Only the part that exists can be
+// resolved; the rest, which docker would create, is appended as it is.
+func realPath(path string) string {
+	path = filepath.Clean(path)
+	var missing []string
+	for {
+		if real, err := filepath.EvalSymlinks(path); err == nil {
+			return filepath.Join(append([]string{real}, missing...)...)
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return filepath.Join(append([]string{path}, missing...)...)
+		}
+		missing = append([]string{filepath.Base(path)}, missing...)
+		path = parent
+	}
+}
+
+// passthroughEnv returns the names of env entries that copy the host's value
+func passthroughEnv(env []string) []string {
+	var names []string
+	for _, entry := range env {
+		if !strings.Contains(entry, "=") {
+			names = append(names, entry)
+		}
+	}
+	return names
+}
+
+// enabled returns the secret credentials the overrides turn on; git config alone is not
+// a secret
+func (o *CredentialOverrides) enabled() []string {
+	if o == nil {
+		return nil
+	}
+	var names []string
+	for _, cred := range o.secretFields() {
+		if *cred.ptr != nil && **cred.ptr {
+			names = append(names, cred.name)
+		}
+	}
+	return names
+}
+
+// disabledOnly returns the overrides without those that turn secret credentials on
+func (o *CredentialOverrides) disabledOnly() *CredentialOverrides {
+	restricted := *o
+	for _, cred := range restricted.secretFields() {
+		if *cred.ptr != nil && **cred.ptr {
+			*cred.ptr = nil
+		}
+	}
+	return &restricted
+}
+
+// secretFields lists the overrides of secret credentials by name
+func (o *CredentialOverrides) secretFields() []struct {
+	name string
+	ptr  **bool
+} {
+	return []struct {
+		name string
+		ptr  **bool
+	}{
+		{"ssh", &o.SSH}, {"ssh-agent", &o.SSHAgent}, {"gh", &o.GH}, {"gpg", &o.GPG}, {"gpg-agent", &o.GPGAgent},
+		{"npm", &o.NPM}, {"aws", &o.AWS}, {"gcp", &o.GCP}, {"azure", &o.Azure}, {"kube", &o.Kube},
+	}
+}
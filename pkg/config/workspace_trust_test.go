@@ -0,0 +1,213 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTrustRisks(t *testing.T) {
+	projectDir := t.TempDir()
+	yes, no := true, false
+
+	if risks := (&ProjectConfig{}).TrustRisks(projectDir, &Config{}); risks != nil {
+		t.Errorf("TrustRisks() of an empty config = %q", risks)
+	}
+
+	harmless := &ProjectConfig{
+		Mounts:           []string{"./fixtures:/fixtures:ro"},
+		Credentials:      &CredentialOverrides{Git: &yes, SSH: &no},
+		Env:              []string{"NODE_ENV=test"},
+		Docker:           DockerOff,
+		BuildCache:       &BuildCacheConfig{From: []string{"type=registry,ref=ghcr.io/team/app:buildcache"}},
+		Services:         map[string]ServiceConfig{"db": {Type: "postgres", Volumes: []string{"pgdata:/var/lib/postgresql/data", "./sql:/docker-entrypoint-initdb.d"}}},
+		DependencyCaches: &DependencyCachesConfig{Enabled: true, Scope: DependencyCacheProject},
+	}
+	if risks := harmless.TrustRisks(projectDir, &Config{}); risks != nil {
+		t.Errorf("TrustRisks() of harmless settings = %q", risks)
+	}
+
+	pc := &ProjectConfig{
+		Mounts:           []string{"./fixtures:/fixtures", "../other:/other", "/etc:/host-etc:ro"},
+		Credentials:      &CredentialOverrides{AWS: &yes, SSH: &no, SSHAgent: &yes},
+		Env:              []string{"NODE_ENV=test", "SENTRY_DSN"},
+		Docker:           DockerSocket,
+		BuildCache:       &BuildCacheConfig{To: "type=registry,ref=evil.example/cache"},
+		Command:          CommandLine{"claude"},
+		Tasks:            map[string]Task{"test": {Command: []string{"make"}}, "e2e": {Command: []string{"make", "e2e"}}},
+		Services:         map[string]ServiceConfig{"db": {Type: "postgres", Volumes: []string{"pgdata:/data", "/var/run/docker.sock:/var/run/docker.sock"}}},
+		DependencyCaches: &DependencyCachesConfig{Enabled: true, Scope: DependencyCacheGlobal},
+	}
+	want := []string{
+		"mounts host paths: ../other:/other, /etc:/host-etc:ro",
+		"mounts host paths into services: db: /var/run/docker.sock:/var/run/docker.sock",
+		"shares dependency caches with other projects",
+		"turns on credentials: ssh-agent, aws",
+		"passes host env vars: SENTRY_DSN",
+		"gives the container docker access: socket",
+		"pushes the build cache with the host's registry logins: type=registry,ref=evil.example/cache",
+		"sets the default command: claude",
+		"defines tasks: e2e, test",
+	}
+	if got := pc.TrustRisks(projectDir, &Config{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("TrustRisks() =\n%q\nwant\n%q", got, want)
+	}
+
+	restricted := pc.Restricted(projectDir, &Config{})
+	if risks := restricted.TrustRisks(projectDir, &Config{}); risks != nil {
+		t.Errorf("TrustRisks() of the restricted config = %q", risks)
+	}
+	if !reflect.DeepEqual(restricted.Mounts, []string{"./fixtures:/fixtures"}) || !reflect.DeepEqual(restricted.Env, []string{"NODE_ENV=test"}) {
+		t.Errorf("Restricted() = %+v, want the project mount and env value kept", restricted)
+	}
+	if got := restricted.Services["db"].Volumes; !reflect.DeepEqual(got, []string{"pgdata:/data"}) {
+		t.Errorf("Restricted() service volumes = %q, want the named volume kept", got)
+	}
+	if !restricted.DependencyCaches.Enabled || restricted.DependencyCaches.Scope != DependencyCacheProject {
+		t.Errorf("Restricted() dependency caches = %+v, want them kept per project", restricted.DependencyCaches)
+	}
+	if restricted.Credentials.SSH == nil || *restricted.Credentials.SSH {
+		t.Error("Restricted() dropped an override turning ssh off")
+	}
+	// The original is left as it was
+	if pc.Credentials.AWS == nil || pc.Docker != DockerSocket || len(pc.Tasks) != 2 || len(pc.Services["db"].Volumes) != 2 || pc.DependencyCaches.Scope != DependencyCacheGlobal || pc.BuildCache.To == "" {
+		t.Errorf("Restricted() modified its receiver: %+v", pc)
+	}
+}
+
+func TestTrustRisksSymlinks(t *testing.T) {
+	projectDir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(projectDir, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(projectDir, "data"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("data", filepath.Join(projectDir, "inside")); err != nil {
+		t.Fatal(err)
+	}
+
+	pc := &ProjectConfig{
+		Mounts:   []string{"./escape:/escape", "./escape/new:/new", "./inside:/inside"},
+		Services: map[string]ServiceConfig{"db": {Type: "postgres", Volumes: []string{"./escape/pg:/var/lib/postgresql/data", "./inside:/seed"}}},
+	}
+	want := []string{
+		"mounts host paths: ./escape:/escape, ./escape/new:/new",
+		"mounts host paths into services: db: ./escape/pg:/var/lib/postgresql/data",
+	}
+	if got := pc.TrustRisks(projectDir, &Config{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("TrustRisks() =\n%q\nwant\n%q", got, want)
+	}
+
+	restricted := pc.Restricted(projectDir, &Config{})
+	if !reflect.DeepEqual(restricted.Mounts, []string{"./inside:/inside"}) || !reflect.DeepEqual(restricted.Services["db"].Volumes, []string{"./inside:/seed"}) {
+		t.Errorf("Restricted() = %+v, want only the links inside the project kept", restricted)
+	}
+}
+
+func TestTrustRisksResources(t *testing.T) {
+	projectDir := t.TempDir()
+	global := &Config{DefaultResources: ResourcesConfig{CPUs: "4", Memory: "8g", MemorySwap: "16g", PidsLimit: 512}}
+
+	lowered := &ProjectConfig{Resources: &ResourcesConfig{CPUs: "2", Memory: "4g", PidsLimit: 256}}
+	if risks := lowered.TrustRisks(projectDir, global); risks != nil {
+		t.Errorf("TrustRisks() of lowered limits = %q", risks)
+	}
+	if got := lowered.Restricted(projectDir, global).Resources; !reflect.DeepEqual(got, lowered.Resources) {
+		t.Errorf("Restricted() resources = %+v, want the lowered limits kept", got)
+	}
+	// Without global limits there is nothing to raise
+	if risks := lowered.TrustRisks(projectDir, &Config{}); risks != nil {
+		t.Errorf("TrustRisks() without global limits = %q", risks)
+	}
+
+	pc := &ProjectConfig{Resources: &ResourcesConfig{CPUs: "16", Memory: "64g", MemorySwap: "-1", PidsLimit: -1}}
+	want := []string{"raises the resource limits: cpus 16 (global 4), memory 64g (global 8g), memory_swap -1 (global 16g), pids_limit -1 (global 512)"}
+	if got := pc.TrustRisks(projectDir, global); !reflect.DeepEqual(got, want) {
+		t.Errorf("TrustRisks() =\n%q\nwant\n%q", got, want)
+	}
+	restricted := pc.Restricted(projectDir, global)
+	if got := *restricted.Resources; got != (ResourcesConfig{}) {
+		t.Errorf("Restricted() resources = %+v, want the global limits to apply", got)
+	}
+	if pc.Resources.CPUs != "16" {
+		t.Errorf("Restricted() modified its receiver: %+v", pc.Resources)
+	}
+}
+
+func TestTrustRisksTimeout(t *testing.T) {
+	projectDir := t.TempDir()
+	global := &Config{Timeout: "2h"}
+
+	tests := []struct {
+		timeout   string
+		wantRisks []string
+	}{
+		{"30m", nil},
+		{"2h", nil},
+		{"10h", []string{"raises the timeout: 10h (global 2h)"}},
+		{"0", []string{"raises the timeout: none (global 2h)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.timeout, func(t *testing.T) {
+			pc := &ProjectConfig{Timeout: tt.timeout}
+			if got := pc.TrustRisks(projectDir, global); !reflect.DeepEqual(got, tt.wantRisks) {
+				t.Errorf("TrustRisks() = %q, want %q", got, tt.wantRisks)
+			}
+			wantTimeout := tt.timeout
+			if tt.wantRisks != nil {
+				wantTimeout = ""
+			}
+			if got := pc.Restricted(projectDir, global).Timeout; got != wantTimeout {
+				t.Errorf("Restricted() timeout = %q, want %q", got, wantTimeout)
+			}
+		})
+	}
+
+	// Without a global timeout there is nothing to raise
+	if risks := (&ProjectConfig{Timeout: "0"}).TrustRisks(projectDir, &Config{}); risks != nil {
+		t.Errorf("TrustRisks() without a global timeout = %q", risks)
+	}
+}
+
+func TestTrustWorkspace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"container_runtime": "docker"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dir := range []string{"/src/app", "/src/app/", "/src/lib"} {
+		if err := TrustWorkspace(path, dir); err != nil {
+			t.Fatalf("TrustWorkspace(%q) error = %v", dir, err)
+		}
+	}
+	cfg, err := LoadConfigFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/src/app", "/src/lib"}; !reflect.DeepEqual(cfg.TrustedWorkspaces, want) {
+		t.Errorf("TrustedWorkspaces = %q, want %q", cfg.TrustedWorkspaces, want)
+	}
+	if cfg.ContainerRuntime != "docker" {
+		t.Errorf("TrustWorkspace() lost container_runtime")
+	}
+	if !cfg.WorkspaceTrusted("/src/app/") || cfg.WorkspaceTrusted("/src") {
+		t.Errorf("WorkspaceTrusted() doesn't match trusted_workspaces %q", cfg.TrustedWorkspaces)
+	}
+
+	if err := UntrustWorkspace(path, "/src/app"); err != nil {
+		t.Fatalf("UntrustWorkspace() error = %v", err)
+	}
+	if err := UntrustWorkspace(path, "/src/app"); err == nil {
+		t.Error("UntrustWorkspace() of an untrusted workspace succeeded")
+	}
+	cfg, err = LoadConfigFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/src/lib"}; !reflect.DeepEqual(cfg.TrustedWorkspaces, want) {
+		t.Errorf("TrustedWorkspaces = %q, want %q", cfg.TrustedWorkspaces, want)
+	}
+}
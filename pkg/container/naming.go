@@ -1,18 +1,67 @@
 package container
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
-// GenerateContainerName creates a container name from project and worktree
+// ProjectHashLabel records the hash of the project path that is part of a container's name
+const ProjectHashLabel = "packnplay-project-hash"
+
+// projectHashLength is how many hex digits of the path hash go into names
+const projectHashLength = 8
+
+// ProjectHash returns a short hash of the absolute project path, which tells apart
+// projects with the same directory name
+func ProjectHash(projectPath string) string {
+	if abs, err := filepath.Abs(projectPath); err == nil {
+		projectPath = abs
+	}
+	sum := sha256.Sum256([]byte(projectPath))
+	return hex.EncodeToString(sum[:])[:projectHashLength]
+}
+
+// GenerateContainerName creates a container name from project and worktree, like
+// "packnplay-myproject-1a2b3c4d-main"
 func GenerateContainerName(projectPath, worktreeName string) string {
+	projectName := filepath.Base(projectPath)
+	sanitizedWorktree := sanitizeName(worktreeName)
+	return fmt.Sprintf("packnplay-%s-%s-%s", projectName, ProjectHash(projectPath), sanitizedWorktree)
+}
+
+// LegacyContainerName is the name containers had before it included the project hash
+func LegacyContainerName(projectPath, worktreeName string) string {
 	projectName := filepath.Base(projectPath)
 	sanitizedWorktree := sanitizeName(worktreeName)
 	return fmt.Sprintf("packnplay-%s-%s", projectName, sanitizedWorktree)
 }
 
+// ResolveContainerName returns the name of the container for a project and worktree.
+// A container started before names included the project hash keeps its legacy name,
+// unless a container already has the new one. lookup returns the labels of the
+// container with a name, and whether it exists. A legacy container whose host path
+// label is another directory belongs to another project with the same name, and is
+// ignored.
+func ResolveContainerName(projectPath, worktreeName string, lookup func(name string) (map[string]string, bool)) string {
+	name := GenerateContainerName(projectPath, worktreeName)
+	if _, exists := lookup(name); exists {
+		return name
+	}
+	legacy := LegacyContainerName(projectPath, worktreeName)
+	labels, exists := lookup(legacy)
+	if !exists {
+		return name
+	}
+	if hostPath := labels["packnplay-host-path"]; hostPath != "" && filepath.Clean(hostPath) != filepath.Clean(projectPath) {
+		return name
+	}
+	return legacy
+}
+
 // sanitizeName converts a name to docker-compatible format
 func sanitizeName(name string) string {
 	// Docker container names: [a-zA-Z0-9][a-zA-Z0-9_.-]*
@@ -22,6 +71,10 @@ func sanitizeName(name string) string {
 	return name
 }
 
+// PortsLabel records a container's published port mappings, space-separated, with the
+// host ports picked at start
+const PortsLabel = "packnplay-ports"
+
 // GenerateLabels creates Docker labels for packnplay-managed containers
 func GenerateLabels(projectName, worktreeName string) map[string]string {
 	return map[string]string{
@@ -42,6 +95,54 @@ func GenerateLabelsWithLaunchInfo(projectName, worktreeName, hostPath, launchCom
 	}
 }
 
+// maxHostnameLength is the longest valid DNS label
+const maxHostnameLength = 63
+
+// GenerateHostname creates a container hostname like "myproject-feature-auth", so
+// shell prompts and logs show which sandbox they came from instead of a hex ID
+func GenerateHostname(projectName, worktreeName string) string {
+	var b strings.Builder
+	lastHyphen := true // avoid a leading hyphen
+	for _, r := range strings.ToLower(projectName + "-" + worktreeName) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			lastHyphen = false
+		} else if !lastHyphen {
+			b.WriteByte('-')
+			lastHyphen = true
+		}
+	}
+
+	hostname := b.String()
+	if len(hostname) > maxHostnameLength {
+		hostname = hostname[:maxHostnameLength]
+	}
+	hostname = strings.TrimRight(hostname, "-")
+	if hostname == "" {
+		return "packnplay"
+	}
+	return hostname
+}
+
+// isReservedLabel reports whether key is a label packnplay uses to track its containers
+func isReservedLabel(key string) bool {
+	return key == "managed-by" || strings.HasPrefix(key, "packnplay-")
+}
+
+// AddUserLabels adds user-defined labels to labels. Reserved packnplay labels can't be
+// overridden; their keys are returned so the caller can warn about them.
+func AddUserLabels(labels, extra map[string]string) (ignored []string) {
+	for key, value := range extra {
+		if isReservedLabel(key) {
+			ignored = append(ignored, key)
+			continue
+		}
+		labels[key] = value
+	}
+	sort.Strings(ignored)
+	return ignored
+}
+
 // LabelsToArgs converts label map to docker --label args
 func LabelsToArgs(labels map[string]string) []string {
 	args := make([]string, 0, len(labels)*2)
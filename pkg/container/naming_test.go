@@ -1,6 +1,7 @@
 package container
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -15,13 +16,13 @@ func TestGenerateContainerName(t *testing.T) {
 			name:         "basic naming",
 			projectPath:  "/home/user/myproject",
 			worktreeName: "main",
-			want:         "packnplay-myproject-main",
+			want:         "packnplay-myproject-f227ecb4-main",
 		},
 		{
 			name:         "sanitized worktree name",
 			projectPath:  "/home/user/myproject",
 			worktreeName: "feature/auth",
-			want:         "packnplay-myproject-feature-auth",
+			want:         "packnplay-myproject-f227ecb4-feature-auth",
 		},
 	}
 
@@ -35,6 +36,47 @@ func TestGenerateContainerName(t *testing.T) {
 	}
 }
 
+func TestGenerateContainerNameSeparatesProjects(t *testing.T) {
+	work := GenerateContainerName("/home/user/work/api", "main")
+	personal := GenerateContainerName("/home/user/personal/api", "main")
+	if work == personal {
+		t.Errorf("projects in different directories share container name %s", work)
+	}
+	if got := LegacyContainerName("/home/user/work/api", "main"); got != "packnplay-api-main" {
+		t.Errorf("LegacyContainerName() = %v, want packnplay-api-main", got)
+	}
+}
+
+func TestResolveContainerName(t *testing.T) {
+	projectPath := "/home/user/work/api"
+	name := GenerateContainerName(projectPath, "main")
+	legacy := LegacyContainerName(projectPath, "main")
+
+	tests := []struct {
+		name       string
+		containers map[string]map[string]string
+		want       string
+	}{
+		{"no containers", nil, name},
+		{"current name", map[string]map[string]string{name: {}, legacy: {}}, name},
+		{"legacy container", map[string]map[string]string{legacy: {"packnplay-host-path": projectPath}}, legacy},
+		{"legacy container without host path", map[string]map[string]string{legacy: {}}, legacy},
+		{"legacy container of another project", map[string]map[string]string{legacy: {"packnplay-host-path": "/home/user/personal/api"}}, name},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookup := func(name string) (map[string]string, bool) {
+				labels, ok := tt.containers[name]
+				return labels, ok
+			}
+			if got := ResolveContainerName(projectPath, "main", lookup); got != tt.want {
+				t.Errorf("ResolveContainerName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGenerateLabels(t *testing.T) {
 	labels := GenerateLabels("myproject", "feature-auth")
 
@@ -79,3 +121,47 @@ func TestGenerateLabelsWithLaunchInfo(t *testing.T) {
 		t.Errorf("packnplay-launch-command label = %v, want %v", labels["packnplay-launch-command"], launchCommand)
 	}
 }
+
+func TestGenerateHostname(t *testing.T) {
+	tests := []struct {
+		name     string
+		project  string
+		worktree string
+		want     string
+	}{
+		{"simple", "myproject", "main", "myproject-main"},
+		{"branch with slash", "myproject", "feature/auth", "myproject-feature-auth"},
+		{"uppercase and underscores", "My_Project", "Fix__Bug", "my-project-fix-bug"},
+		{"leading and trailing symbols", ".dotfiles", "wip-", "dotfiles-wip"},
+		{"truncated to 63 chars", "project", strings.Repeat("a", 80), "project-" + strings.Repeat("a", 55)},
+		{"nothing usable", "...", "///", "packnplay"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GenerateHostname(tt.project, tt.worktree); got != tt.want {
+				t.Errorf("GenerateHostname() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddUserLabels(t *testing.T) {
+	labels := GenerateLabels("myproject", "main")
+
+	ignored := AddUserLabels(labels, map[string]string{
+		"team":              "platform",
+		"managed-by":        "someone-else",
+		"packnplay-project": "spoofed",
+	})
+
+	if labels["team"] != "platform" {
+		t.Errorf("team label = %v, want platform", labels["team"])
+	}
+	if labels["managed-by"] != "packnplay" || labels["packnplay-project"] != "myproject" {
+		t.Errorf("reserved labels were overridden: %v", labels)
+	}
+	if len(ignored) != 2 || ignored[0] != "managed-by" || ignored[1] != "packnplay-project" {
+		t.Errorf("ignored = %v, want [managed-by packnplay-project]", ignored)
+	}
+}
@@ -0,0 +1,165 @@
+package credentials
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/obra/packnplay/pkg/aws"
+)
+
+// awsStaticKeys are the env vars holding AWS credentials themselves
+var awsStaticKeys = []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN"}
+
+// AWSProvider passes AWS credentials to the container. Priority order: static
+// credentials in the environment, then the profile's credential_process (or SSO
+// token cache), then whatever AWS_* variables the host has.
+type AWSProvider struct {
+	Profile string // overrides AWS_PROFILE (--aws-profile)
+
+	ttl time.Duration // lifetime of credentials from credential_process
+}
+
+func (p *AWSProvider) Name() string { return "aws" }
+
+func (p *AWSProvider) Detect(env Environment) bool { return true }
+
+func (p *AWSProvider) RefreshTTL() time.Duration { return p.ttl }
+
+func (p *AWSProvider) Fetch(env Environment) (*Result, error) {
+	result := &Result{Env: make(map[string]string)}
+	credSource := ""
+
+	// Priority 1: Check if static credentials are already set in environment.
+	// An explicit --aws-profile wins, since host static creds may belong to another account.
+	if aws.HasStaticCredentials() && p.Profile == "" {
+		env.verbosef("Using existing AWS credentials from environment variables\n")
+		for key, value := range aws.GetAWSEnvVars() {
+			result.Env[key] = value
+		}
+	} else {
+		// Priority 2: Try credential_process if AWS_PROFILE is set
+		awsProfile := os.Getenv("AWS_PROFILE")
+		if p.Profile != "" {
+			awsProfile = p.Profile
+		}
+		if awsProfile != "" {
+			if p.fetchFromProcess(env, awsProfile, result) {
+				credSource = "credential_process"
+			}
+		} else {
+			env.verbosef("No AWS_PROFILE set, skipping credential_process lookup\n")
+		}
+
+		// If credential_process didn't work, try getting from environment anyway
+		if credSource == "" {
+			for key, value := range aws.GetAWSEnvVars() {
+				// Static creds would shadow the explicitly selected profile
+				if p.Profile != "" && isAWSStaticKey(key) {
+					continue
+				}
+				result.Env[key] = value
+			}
+			if len(result.Env) > 0 {
+				env.verbosef("Using AWS environment variables from host\n")
+			}
+		}
+	}
+
+	if p.Profile != "" {
+		result.Env["AWS_PROFILE"] = p.Profile
+	}
+
+	// AWS_CONFIG_FILE/AWS_SHARED_CREDENTIALS_FILE hold host paths; remap them to mounted locations
+	relocatedMounts, missingFiles := aws.RelocatedFileMounts(env.HomeDir, env.ContainerHome)
+	for _, envVar := range missingFiles {
+		env.problemf("%s points to a missing file, not passing it to the container", envVar)
+		delete(result.Env, envVar)
+	}
+	for _, mount := range relocatedMounts {
+		if mount.NeedsMount {
+			result.Mounts = append(result.Mounts, Mount{HostPath: mount.HostPath, ContainerPath: mount.ContainerPath})
+			env.verbosef("Mounting %s from %s\n", mount.EnvVar, mount.HostPath)
+		}
+		result.Env[mount.EnvVar] = mount.ContainerPath
+	}
+
+	// Mount ~/.aws directory if it exists (read-write for SSO token refresh)
+	awsPath := filepath.Join(env.HomeDir, ".aws")
+	if fileExists(awsPath) {
+		// Use read-write mount to allow SSO token refresh and CLI caching
+		result.Mounts = append(result.Mounts, Mount{HostPath: awsPath, ContainerPath: filepath.Join(env.ContainerHome, ".aws")})
+		env.verbosef("Mounting AWS config directory (read-write for token refresh)\n")
+	} else if len(relocatedMounts) == 0 {
+		// Always warn if ~/.aws is missing, not just in verbose; it's only a problem
+		// when there are no credentials from the environment or credential_process either
+		if credSource != "" || aws.HasStaticCredentials() {
+			env.logf("Warning: ~/.aws directory not found, AWS CLI config and SSO cache unavailable\n")
+		} else {
+			env.problemf("~/.aws directory not found, AWS CLI config and SSO cache unavailable")
+		}
+	}
+
+	return result, nil
+}
+
+// fetchFromProcess runs the profile's credential_process and adds its credentials to
+// result. Reports whether credentials were obtained; problems are warnings, not errors.
+func (p *AWSProvider) fetchFromProcess(env Environment, profile string, result *Result) bool {
+	credentialProcess, err := aws.ParseAWSConfig(profile)
+	var ssoErr *aws.SSOProfileError
+	if errors.As(err, &ssoErr) {
+		// SSO profiles work in the container through the mounted ~/.aws token cache
+		if ssoErr.SSO.HasValidToken(env.HomeDir, time.Now()) {
+			env.verbosef("Profile '%s' uses AWS SSO, sharing the cached SSO token via ~/.aws\n", profile)
+		} else {
+			env.problemf("no valid AWS SSO token for profile '%s'; run '%s' on the host", profile, ssoErr.SSO.LoginCommand())
+		}
+		return false
+	} else if err != nil {
+		// Always warn, not just in verbose mode
+		env.problemf("failed to get credential_process for profile '%s': %v", profile, err)
+		return false
+	}
+
+	env.verbosef("Executing credential_process for profile '%s'\n", profile)
+	creds, err := aws.GetCredentialsFromProcess(credentialProcess)
+	if err != nil {
+		// Always warn, not just in verbose mode
+		env.problemf("credential_process failed: %v", err)
+		return false
+	}
+	env.verbosef("Successfully obtained AWS credentials from credential_process\n")
+
+	result.Env["AWS_ACCESS_KEY_ID"] = creds.AccessKeyID
+	result.Env["AWS_SECRET_ACCESS_KEY"] = creds.SecretAccessKey
+	if creds.SessionToken != "" {
+		result.Env["AWS_SESSION_TOKEN"] = creds.SessionToken
+	}
+	// Also include other AWS_* env vars (region, profile, etc.) but not credentials
+	for key, value := range aws.GetAWSEnvVars() {
+		if !isAWSStaticKey(key) {
+			result.Env[key] = value
+		}
+	}
+
+	if expiration, err := time.Parse(time.RFC3339, creds.Expiration); err == nil {
+		p.ttl = time.Until(expiration).Round(time.Minute)
+	}
+	return true
+}
+
+func isAWSStaticKey(key string) bool {
+	for _, k := range awsStaticKeys {
+		if key == k {
+			return true
+		}
+	}
+	return false
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,142 @@
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stsTimeout bounds how long the aws CLI may take to hand out temporary credentials
+const stsTimeout = 30 * time.Second
+
+// AWSSessionProvider passes temporary AWS credentials minted on the host for the run in
+// place of the host's own (--scoped-creds). With RoleARN it runs sts assume-role, otherwise
+// sts get-federation-token; Policy, a session policy, narrows what the credentials can do.
+// Nothing from ~/.aws is mounted, so the container never sees long-lived keys or the SSO
+// cache.
+type AWSSessionProvider struct {
+	Profile     string        // host profile that calls STS (--aws-profile)
+	RoleARN     string        // role to assume
+	Policy      string        // session policy JSON, or file://path
+	Duration    time.Duration // lifetime of the credentials
+	SessionName string        // identifies the run in CloudTrail
+
+	ttl time.Duration // lifetime of the minted credentials
+
+	// run runs aws on the host and returns its stdout; nil runs the real CLI
+	run func(ctx context.Context, args ...string) ([]byte, error)
+}
+
+func (p *AWSSessionProvider) Name() string { return "aws" }
+
+func (p *AWSSessionProvider) Detect(env Environment) bool { return true }
+
+func (p *AWSSessionProvider) RefreshTTL() time.Duration { return p.ttl }
+
+// stsCredentials is the part of assume-role's and get-federation-token's output
+// packnplay uses
+type stsCredentials struct {
+	Credentials struct {
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string `json:"SecretAccessKey"`
+		SessionToken    string `json:"SessionToken"`
+		Expiration      string `json:"Expiration"`
+	} `json:"Credentials"`
+}
+
+func (p *AWSSessionProvider) Fetch(env Environment) (*Result, error) {
+	result := &Result{Env: make(map[string]string)}
+	if p.RoleARN == "" && p.Policy == "" {
+		env.problemf("scoped AWS credentials need a role_arn or a session policy in scoped_credentials.aws, not passing AWS credentials")
+		return result, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), stsTimeout)
+	defer cancel()
+	run := p.run
+	if run == nil {
+		run = runAWS
+	}
+
+	args := p.stsArgs()
+	env.verbosef("Running aws %s\n", strings.Join(args[:2], " "))
+	output, err := run(ctx, args...)
+	if err != nil {
+		env.problemf("failed to get temporary AWS credentials: %v", err)
+		return result, nil
+	}
+	var creds stsCredentials
+	if err := json.Unmarshal(output, &creds); err != nil || creds.Credentials.AccessKeyID == "" {
+		env.problemf("aws %s printed no credentials", args[1])
+		return result, nil
+	}
+
+	result.Env["AWS_ACCESS_KEY_ID"] = creds.Credentials.AccessKeyID
+	result.Env["AWS_SECRET_ACCESS_KEY"] = creds.Credentials.SecretAccessKey
+	result.Env["AWS_SESSION_TOKEN"] = creds.Credentials.SessionToken
+	if expiration, err := time.Parse(time.RFC3339, creds.Credentials.Expiration); err == nil {
+		result.Env["AWS_CREDENTIAL_EXPIRATION"] = expiration.UTC().Format(time.RFC3339)
+		p.ttl = time.Until(expiration).Round(time.Minute)
+	}
+	env.verbosef("Successfully obtained temporary AWS credentials\n")
+
+	// Without ~/.aws the container only knows the region from the environment
+	for _, key := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if value := os.Getenv(key); value != "" {
+			result.Env[key] = value
+		}
+	}
+	if result.Env["AWS_REGION"] == "" && result.Env["AWS_DEFAULT_REGION"] == "" {
+		regionArgs := []string{"configure", "get", "region"}
+		if p.Profile != "" {
+			regionArgs = append(regionArgs, "--profile", p.Profile)
+		}
+		if region, err := run(ctx, regionArgs...); err == nil && strings.TrimSpace(string(region)) != "" {
+			result.Env["AWS_REGION"] = strings.TrimSpace(string(region))
+		}
+	}
+	return result, nil
+}
+
+// stsArgs returns the aws arguments that mint the credentials
+func (p *AWSSessionProvider) stsArgs() []string {
+	seconds := strconv.Itoa(int(p.Duration.Seconds()))
+	var args []string
+	if p.RoleARN != "" {
+		args = []string{"sts", "assume-role", "--role-arn", p.RoleARN, "--role-session-name", stsName(p.SessionName, 64), "--duration-seconds", seconds}
+	} else {
+		args = []string{"sts", "get-federation-token", "--name", stsName(p.SessionName, 32), "--duration-seconds", seconds}
+	}
+	if p.Policy != "" {
+		args = append(args, "--policy", p.Policy)
+	}
+	if p.Profile != "" {
+		args = append(args, "--profile", p.Profile)
+	}
+	return append(args, "--output", "json")
+}
+
+// stsNameInvalid matches characters STS doesn't allow in session and federated user names
+var stsNameInvalid = regexp.MustCompile(`[^\w+=,.@-]`)
+
+// stsName makes name usable as an STS session or federated user name of at most max
+// characters
+func stsName(name string, max int) string {
+	name = stsNameInvalid.ReplaceAllString(name, "-")
+	if len(name) > max {
+		name = name[:max]
+	}
+	if len(name) < 2 {
+		return "packnplay"
+	}
+	return name
+}
+
+// runAWS runs the host's aws CLI
+func runAWS(ctx context.Context, args ...string) ([]byte, error) {
+	return runHostCLI(ctx, stsTimeout, "aws", args...)
+}
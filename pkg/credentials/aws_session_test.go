@@ -0,0 +1,119 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAWSSessionProviderArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider AWSSessionProvider
+		want     string
+	}{
+		{
+			name:     "assume role",
+			provider: AWSSessionProvider{RoleARN: "arn:aws:iam::123456789012:role/agent", Duration: time.Hour, SessionName: "packnplay-app-f227ecb4-main"},
+			want:     "sts assume-role --role-arn arn:aws:iam::123456789012:role/agent --role-session-name packnplay-app-f227ecb4-main --duration-seconds 3600 --output json",
+		},
+		{
+			name:     "federation token with policy and profile",
+			provider: AWSSessionProvider{Policy: "file:///home/dev/agent-policy.json", Profile: "dev", Duration: 30 * time.Minute, SessionName: "packnplay-a-very-long-project-name-main"},
+			want:     "sts get-federation-token --name packnplay-a-very-long-project-na --duration-seconds 1800 --policy file:///home/dev/agent-policy.json --profile dev --output json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(tt.provider.stsArgs(), " "); got != tt.want {
+				t.Errorf("stsArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAWSSessionProviderFetch(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	expiration := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name     string
+		output   string
+		err      error
+		wantKey  string
+		wantWarn string
+	}{
+		{
+			name:    "credentials",
+			output:  `{"Credentials": {"AccessKeyId": "ASIAEXAMPLE", "SecretAccessKey": "secret", "SessionToken": "token", "Expiration": "` + expiration + `"}}`,
+			wantKey: "ASIAEXAMPLE",
+		},
+		{name: "access denied", err: errors.New("An error occurred (AccessDenied) when calling the AssumeRole operation"), wantWarn: "failed to get temporary AWS credentials: An error occurred (AccessDenied)"},
+		{name: "no credentials", output: `{}`, wantWarn: "aws assume-role printed no credentials"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &AWSSessionProvider{RoleARN: "arn:aws:iam::123456789012:role/agent", Duration: time.Hour, run: func(ctx context.Context, args ...string) ([]byte, error) {
+				if args[0] == "configure" {
+					return []byte("us-west-2\n"), nil
+				}
+				return []byte(tt.output), tt.err
+			}}
+			var log bytes.Buffer
+			result, err := provider.Fetch(Environment{HomeDir: t.TempDir(), ContainerHome: "/home/vscode", Log: &log})
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if result.Env["AWS_ACCESS_KEY_ID"] != tt.wantKey {
+				t.Errorf("AWS_ACCESS_KEY_ID = %q, want %q", result.Env["AWS_ACCESS_KEY_ID"], tt.wantKey)
+			}
+			if tt.wantKey != "" {
+				if result.Env["AWS_SESSION_TOKEN"] != "token" || result.Env["AWS_REGION"] != "us-west-2" {
+					t.Errorf("Env = %v, want the session token and the profile's region", result.Env)
+				}
+				if provider.RefreshTTL() < 59*time.Minute {
+					t.Errorf("RefreshTTL() = %s, want about an hour", provider.RefreshTTL())
+				}
+			}
+			if len(result.Mounts) != 0 {
+				t.Errorf("Mounts = %+v, want nothing from ~/.aws", result.Mounts)
+			}
+			if !strings.Contains(log.String(), tt.wantWarn) {
+				t.Errorf("warnings = %q, want %q", log.String(), tt.wantWarn)
+			}
+		})
+	}
+}
+
+func TestAWSSessionProviderNeedsRoleOrPolicy(t *testing.T) {
+	var log bytes.Buffer
+	result, err := (&AWSSessionProvider{Duration: time.Hour}).Fetch(Environment{HomeDir: t.TempDir(), Log: &log})
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(result.Env) != 0 || !strings.Contains(log.String(), "need a role_arn or a session policy") {
+		t.Errorf("Fetch() env = %v, warnings = %q", result.Env, log.String())
+	}
+}
+
+func TestSTSName(t *testing.T) {
+	tests := []struct {
+		name string
+		max  int
+		want string
+	}{
+		{"packnplay-app-main", 64, "packnplay-app-main"},
+		{"packnplay-app-feature/login", 64, "packnplay-app-feature-login"},
+		{"packnplay-app-0123456789abcdef", 20, "packnplay-app-012345"},
+		{"", 64, "packnplay"},
+	}
+	for _, tt := range tests {
+		if got := stsName(tt.name, tt.max); got != tt.want {
+			t.Errorf("stsName(%q, %d) = %q, want %q", tt.name, tt.max, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package credentials
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// clearAWSEnv unsets AWS_* variables for the duration of the test
+func clearAWSEnv(t *testing.T) {
+	t.Helper()
+	for _, kv := range os.Environ() {
+		if key, _, _ := strings.Cut(kv, "="); strings.HasPrefix(key, "AWS_") {
+			t.Setenv(key, "")
+			_ = os.Unsetenv(key)
+		}
+	}
+}
+
+func TestAWSProviderStaticCredentials(t *testing.T) {
+	clearAWSEnv(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIATEST")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "eu-west-1")
+
+	homeDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(homeDir, ".aws"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	env := Environment{HomeDir: homeDir, ContainerHome: "/home/vscode", Log: &bytes.Buffer{}}
+
+	result, err := (&AWSProvider{}).Fetch(env)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if result.Env["AWS_ACCESS_KEY_ID"] != "AKIATEST" || result.Env["AWS_REGION"] != "eu-west-1" {
+		t.Errorf("Env = %v, want static credentials and region", result.Env)
+	}
+	if len(result.Mounts) != 1 || result.Mounts[0].ContainerPath != "/home/vscode/.aws" || result.Mounts[0].ReadOnly {
+		t.Errorf("Mounts = %+v, want read-write ~/.aws mount", result.Mounts)
+	}
+}
+
+func TestAWSProviderProfileOverridesStaticCredentials(t *testing.T) {
+	clearAWSEnv(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAOTHER")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_PROFILE", "default")
+
+	configPath := filepath.Join(t.TempDir(), "config")
+	config := "[profile staging]\ncredential_process = echo '{\"Version\":1,\"AccessKeyId\":\"AKIASTAGING\",\"SecretAccessKey\":\"s\"}'\n"
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_CONFIG_FILE", configPath)
+
+	var log bytes.Buffer
+	env := Environment{HomeDir: t.TempDir(), ContainerHome: "/home/vscode", Log: &log}
+
+	result, err := (&AWSProvider{Profile: "staging"}).Fetch(env)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if result.Env["AWS_ACCESS_KEY_ID"] != "AKIASTAGING" {
+		t.Errorf("AWS_ACCESS_KEY_ID = %q, want credentials from the selected profile", result.Env["AWS_ACCESS_KEY_ID"])
+	}
+	if result.Env["AWS_PROFILE"] != "staging" {
+		t.Errorf("AWS_PROFILE = %q, want staging", result.Env["AWS_PROFILE"])
+	}
+	// The config file lives outside ~/.aws, so it gets its own mount
+	if result.Env["AWS_CONFIG_FILE"] != "/home/vscode/.aws-packnplay/config" {
+		t.Errorf("AWS_CONFIG_FILE = %q, want remapped container path", result.Env["AWS_CONFIG_FILE"])
+	}
+	if strings.Contains(log.String(), "~/.aws directory not found") {
+		t.Errorf("should not warn about ~/.aws when a relocated config is mounted: %q", log.String())
+	}
+}
+
+func TestAWSProviderWarnsWithoutConfig(t *testing.T) {
+	clearAWSEnv(t)
+
+	var log bytes.Buffer
+	env := Environment{HomeDir: t.TempDir(), ContainerHome: "/home/vscode", Log: &log}
+
+	result, err := (&AWSProvider{}).Fetch(env)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(result.Env) != 0 || len(result.Mounts) != 0 {
+		t.Errorf("Fetch() = %+v, want empty result", result)
+	}
+	if !strings.Contains(log.String(), "Warning: ~/.aws directory not found") {
+		t.Errorf("expected missing ~/.aws warning, got %q", log.String())
+	}
+}
@@ -0,0 +1,131 @@
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// azTimeout bounds how long az may take to hand out an access token
+const azTimeout = 30 * time.Second
+
+// AzureProvider passes Azure credentials to the container: the az CLI's config directory
+// with its login and token cache, and the host's AZURE_* variables. With Token set it
+// also runs az account get-access-token on the host and passes the short-lived token in
+// AZURE_ACCESS_TOKEN, for tools that can't use the az login.
+type AzureProvider struct {
+	Token bool // inject an access token from the host's az login (--azure-token)
+
+	ttl time.Duration // lifetime of the injected access token
+
+	// run runs az on the host and returns its stdout; nil runs the real CLI
+	run func(ctx context.Context, args ...string) ([]byte, error)
+}
+
+func (p *AzureProvider) Name() string { return "azure" }
+
+func (p *AzureProvider) Detect(env Environment) bool { return true }
+
+func (p *AzureProvider) RefreshTTL() time.Duration { return p.ttl }
+
+func (p *AzureProvider) Fetch(env Environment) (*Result, error) {
+	result := &Result{Env: azureHostEnv()}
+
+	// The config directory holds the az login and MSAL token cache. AZURE_CONFIG_DIR holds
+	// a host path, so it is mounted in place of ~/.azure.
+	configDir := filepath.Join(env.HomeDir, ".azure")
+	if custom := result.Env["AZURE_CONFIG_DIR"]; custom != "" {
+		configDir = custom
+		delete(result.Env, "AZURE_CONFIG_DIR")
+	}
+	if fileExists(configDir) {
+		// Read-write so az can refresh and cache tokens
+		result.Mounts = append(result.Mounts, Mount{HostPath: configDir, ContainerPath: filepath.Join(env.ContainerHome, ".azure")})
+		env.verbosef("Mounting Azure CLI config directory (read-write for token refresh)\n")
+	} else if !p.Token {
+		// Always warn, not just in verbose
+		env.problemf("%s not found, run 'az login' on the host", configDir)
+	}
+
+	if p.Token {
+		p.fetchToken(env, result)
+	}
+	return result, nil
+}
+
+// azureToken is the part of az account get-access-token's output packnplay uses
+type azureToken struct {
+	AccessToken string `json:"accessToken"`
+	ExpiresOn   int64  `json:"expires_on"` // Unix time; older az versions only print a local time string
+}
+
+// fetchToken adds an access token from the host's az login to result. Problems are
+// warnings, not errors, since the mounted config directory may be enough.
+func (p *AzureProvider) fetchToken(env Environment, result *Result) {
+	ctx, cancel := context.WithTimeout(context.Background(), azTimeout)
+	defer cancel()
+
+	run := p.run
+	if run == nil {
+		run = runAz
+	}
+	env.verbosef("Running az account get-access-token\n")
+	output, err := run(ctx, "account", "get-access-token", "--output", "json")
+	if err != nil {
+		env.problemf("failed to get an Azure access token: %v; run 'az login' on the host", err)
+		return
+	}
+
+	var token azureToken
+	if err := json.Unmarshal(output, &token); err != nil || token.AccessToken == "" {
+		env.problemf("az account get-access-token printed no access token")
+		return
+	}
+	result.Env["AZURE_ACCESS_TOKEN"] = token.AccessToken
+	if token.ExpiresOn > 0 {
+		expires := time.Unix(token.ExpiresOn, 0)
+		result.Env["AZURE_ACCESS_TOKEN_EXPIRES_ON"] = expires.UTC().Format(time.RFC3339)
+		p.ttl = time.Until(expires).Round(time.Minute)
+	}
+	env.verbosef("Successfully obtained an Azure access token\n")
+}
+
+// runAz runs the host's az CLI
+func runAz(ctx context.Context, args ...string) ([]byte, error) {
+	return runHostCLI(ctx, azTimeout, "az", args...)
+}
+
+// runHostCLI runs a CLI on the host under a context with the given timeout, returning
+// its stdout, or its stderr as the error when it fails
+func runHostCLI(ctx context.Context, timeout time.Duration, name string, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, fmt.Errorf("%s not found in PATH", name)
+	}
+	output, err := exec.CommandContext(ctx, name, args...).Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%s timed out after %s", name, timeout)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return nil, fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return output, err
+}
+
+// azureHostEnv returns the host's AZURE_* variables that are set
+func azureHostEnv() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if value != "" && strings.HasPrefix(key, "AZURE_") {
+			env[key] = value
+		}
+	}
+	return env
+}
@@ -0,0 +1,126 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearAzureEnv unsets the host's AZURE_* variables for the duration of the test
+func clearAzureEnv(t *testing.T) {
+	t.Helper()
+	for key := range azureHostEnv() {
+		t.Setenv(key, "")
+		_ = os.Unsetenv(key)
+	}
+}
+
+func TestAzureProviderConfigDir(t *testing.T) {
+	clearAzureEnv(t)
+	t.Setenv("AZURE_SUBSCRIPTION_ID", "0000-1111")
+	homeDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(homeDir, ".azure"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var log bytes.Buffer
+	env := Environment{HomeDir: homeDir, ContainerHome: "/home/vscode", Log: &log}
+	result, err := (&AzureProvider{}).Fetch(env)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if result.Env["AZURE_SUBSCRIPTION_ID"] != "0000-1111" {
+		t.Errorf("Env = %v, want AZURE_SUBSCRIPTION_ID passed", result.Env)
+	}
+	if len(result.Mounts) != 1 || result.Mounts[0].ContainerPath != "/home/vscode/.azure" || result.Mounts[0].ReadOnly {
+		t.Errorf("Mounts = %+v, want ~/.azure mounted read-write", result.Mounts)
+	}
+	if log.Len() != 0 {
+		t.Errorf("unexpected warnings: %s", log.String())
+	}
+}
+
+func TestAzureProviderCustomConfigDir(t *testing.T) {
+	clearAzureEnv(t)
+	customConfig := t.TempDir()
+	t.Setenv("AZURE_CONFIG_DIR", customConfig)
+
+	env := Environment{HomeDir: t.TempDir(), ContainerHome: "/home/vscode", Log: &bytes.Buffer{}}
+	result, err := (&AzureProvider{}).Fetch(env)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if _, ok := result.Env["AZURE_CONFIG_DIR"]; ok {
+		t.Error("AZURE_CONFIG_DIR holds a host path and should not be passed")
+	}
+	if len(result.Mounts) != 1 || result.Mounts[0].HostPath != customConfig {
+		t.Errorf("Mounts = %+v, want AZURE_CONFIG_DIR mounted as ~/.azure", result.Mounts)
+	}
+}
+
+func TestAzureProviderToken(t *testing.T) {
+	clearAzureEnv(t)
+	expires := time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		name      string
+		output    string
+		err       error
+		wantToken string
+		wantWarn  string
+	}{
+		{
+			name:      "token",
+			output:    `{"accessToken": "eyJ0eXAi", "expiresOn": "2026-10-16 13:00:00.000000", "expires_on": ` + strconv.FormatInt(expires, 10) + `}`,
+			wantToken: "eyJ0eXAi",
+		},
+		{name: "not logged in", err: errors.New("Please run 'az login' to setup account."), wantWarn: "failed to get an Azure access token"},
+		{name: "no token", output: `{}`, wantWarn: "printed no access token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotArgs []string
+			provider := &AzureProvider{Token: true, run: func(ctx context.Context, args ...string) ([]byte, error) {
+				gotArgs = args
+				return []byte(tt.output), tt.err
+			}}
+			var log bytes.Buffer
+			env := Environment{HomeDir: t.TempDir(), ContainerHome: "/home/vscode", Log: &log}
+			result, err := provider.Fetch(env)
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if strings.Join(gotArgs, " ") != "account get-access-token --output json" {
+				t.Errorf("az args = %v", gotArgs)
+			}
+			if result.Env["AZURE_ACCESS_TOKEN"] != tt.wantToken {
+				t.Errorf("AZURE_ACCESS_TOKEN = %q, want %q", result.Env["AZURE_ACCESS_TOKEN"], tt.wantToken)
+			}
+			if tt.wantToken != "" && (provider.RefreshTTL() < 59*time.Minute || result.Env["AZURE_ACCESS_TOKEN_EXPIRES_ON"] == "") {
+				t.Errorf("RefreshTTL() = %s, expiry = %q, want about an hour", provider.RefreshTTL(), result.Env["AZURE_ACCESS_TOKEN_EXPIRES_ON"])
+			}
+			if !strings.Contains(log.String(), tt.wantWarn) {
+				t.Errorf("warnings = %q, want %q", log.String(), tt.wantWarn)
+			}
+		})
+	}
+}
+
+func TestAzureProviderWarnsWithoutLogin(t *testing.T) {
+	clearAzureEnv(t)
+	var log bytes.Buffer
+	env := Environment{HomeDir: t.TempDir(), ContainerHome: "/home/vscode", Log: &log}
+	if _, err := (&AzureProvider{}).Fetch(env); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if !strings.Contains(log.String(), "run 'az login' on the host") {
+		t.Errorf("expected a login hint, got %q", log.String())
+	}
+}
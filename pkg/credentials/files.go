@@ -0,0 +1,64 @@
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FilesLabel lists the host credential files a container mounts, so packnplay prune can
+// tell which of them are still in use
+const FilesLabel = "packnplay-credential-files"
+
+// SharedClaudeCredentials is the container-managed Claude login every container without
+// host credentials mounts. It outlives containers, so prune never removes it.
+const SharedClaudeCredentials = "claude-credentials.json"
+
+// FileDirs returns the host directories packnplay writes credential files into: the
+// container-managed credentials and resolved kubeconfigs
+func FileDirs() ([]string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	return []string{
+		filepath.Join(dataHome, "packnplay", "credentials"),
+		filepath.Join(dataHome, "packnplay", "kube"),
+	}, nil
+}
+
+// FilesLabelArgs returns the run arguments recording which bind mounts in args come from
+// dirs, or nil when none do
+func FilesLabelArgs(args []string, dirs []string) []string {
+	var files []string
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] != "-v" {
+			continue
+		}
+		source, _, _ := strings.Cut(args[i+1], ":")
+		if seen[source] || !underAny(source, dirs) {
+			continue
+		}
+		seen[source] = true
+		files = append(files, source)
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	return []string{"--label", FilesLabel + "=" + strings.Join(files, ",")}
+}
+
+// underAny reports whether path is inside one of dirs
+func underAny(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
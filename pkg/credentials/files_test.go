@@ -0,0 +1,63 @@
+package credentials
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileDirs(t *testing.T) {
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+
+	dirs, err := FileDirs()
+	if err != nil {
+		t.Fatalf("FileDirs() error = %v", err)
+	}
+	want := []string{
+		filepath.Join(dataHome, "packnplay", "credentials"),
+		filepath.Join(dataHome, "packnplay", "kube"),
+	}
+	if !reflect.DeepEqual(dirs, want) {
+		t.Errorf("FileDirs() = %v, want %v", dirs, want)
+	}
+}
+
+func TestFilesLabelArgs(t *testing.T) {
+	dirs := []string{"/data/packnplay/credentials", "/data/packnplay/kube"}
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "credential mounts",
+			args: []string{"run", "-v", "/data/packnplay/credentials/claude-credentials.json:/home/u/.claude/.credentials.json",
+				"-v", "/work:/work", "-v", "/data/packnplay/kube/ab12.yaml:/home/u/.kube/packnplay/0-config:ro"},
+			want: []string{"--label", FilesLabel + "=/data/packnplay/credentials/claude-credentials.json,/data/packnplay/kube/ab12.yaml"},
+		},
+		{
+			name: "repeated mount",
+			args: []string{"-v", "/data/packnplay/kube/a.yaml:/a", "-v", "/data/packnplay/kube/a.yaml:/b"},
+			want: []string{"--label", FilesLabel + "=/data/packnplay/kube/a.yaml"},
+		},
+		{
+			name: "directory itself and lookalike prefix",
+			args: []string{"-v", "/data/packnplay/kube:/k", "-v", "/data/packnplay/kubeconfigs/x:/x"},
+			want: nil,
+		},
+		{
+			name: "no mounts",
+			args: []string{"run", "--rm"},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FilesLabelArgs(tt.args, dirs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilesLabelArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// gcpEnvVars are the variables besides CLOUDSDK_* that pick the Google Cloud project and
+// region, or switch agents to Vertex AI
+var gcpEnvVars = []string{
+	"GOOGLE_CLOUD_PROJECT", "GCLOUD_PROJECT", "GOOGLE_CLOUD_REGION", "GOOGLE_CLOUD_LOCATION",
+	"CLAUDE_CODE_USE_VERTEX", "ANTHROPIC_VERTEX_PROJECT_ID", "CLOUD_ML_REGION",
+	"GOOGLE_GENAI_USE_VERTEXAI",
+}
+
+// GCPProvider passes Google Cloud credentials to the container. Priority order: a
+// service account key in GOOGLE_APPLICATION_CREDENTIALS, then the application default
+// credentials and gcloud login in the gcloud config directory. CLOUDSDK_* settings and
+// the project, region and Vertex AI variables are passed along either way.
+type GCPProvider struct{}
+
+func (p *GCPProvider) Name() string { return "gcp" }
+
+func (p *GCPProvider) Detect(env Environment) bool { return true }
+
+// RefreshTTL is 0: gcloud and the client libraries refresh access tokens themselves
+// through the mounted config directory
+func (p *GCPProvider) RefreshTTL() time.Duration { return 0 }
+
+func (p *GCPProvider) Fetch(env Environment) (*Result, error) {
+	result := &Result{Env: make(map[string]string)}
+	containerConfig := filepath.Join(env.ContainerHome, ".config", "gcloud")
+
+	for key, value := range gcpHostEnv() {
+		result.Env[key] = value
+	}
+
+	// Priority 1: a service account key file, mounted read-only where the variable can find it
+	if keyFile := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); keyFile != "" {
+		if fileExists(keyFile) {
+			containerPath := filepath.Join(env.ContainerHome, ".config", "packnplay-gcp", filepath.Base(keyFile))
+			result.Mounts = append(result.Mounts, Mount{HostPath: keyFile, ContainerPath: containerPath, ReadOnly: true})
+			result.Env["GOOGLE_APPLICATION_CREDENTIALS"] = containerPath
+			env.verbosef("Using the Google Cloud key file in GOOGLE_APPLICATION_CREDENTIALS\n")
+		} else {
+			env.problemf("GOOGLE_APPLICATION_CREDENTIALS points to a missing file, not passing it to the container")
+		}
+	}
+
+	// Priority 2: the gcloud config directory, holding the gcloud login and application
+	// default credentials. CLOUDSDK_CONFIG holds a host path, so it is mounted in its place.
+	configDir := filepath.Join(env.HomeDir, ".config", "gcloud")
+	if custom := result.Env["CLOUDSDK_CONFIG"]; custom != "" {
+		configDir = custom
+		delete(result.Env, "CLOUDSDK_CONFIG")
+	}
+	if fileExists(configDir) {
+		// Read-write so access tokens can be refreshed and cached
+		result.Mounts = append(result.Mounts, Mount{HostPath: configDir, ContainerPath: containerConfig})
+		env.verbosef("Mounting gcloud config directory (read-write for token refresh)\n")
+	} else if result.Env["GOOGLE_APPLICATION_CREDENTIALS"] == "" {
+		// Always warn, not just in verbose
+		env.problemf("%s not found and GOOGLE_APPLICATION_CREDENTIALS is not set; run 'gcloud auth application-default login' on the host", configDir)
+	}
+
+	return result, nil
+}
+
+// gcpHostEnv returns the host's CLOUDSDK_* variables and gcpEnvVars that are set
+func gcpHostEnv() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if value != "" && strings.HasPrefix(key, "CLOUDSDK_") {
+			env[key] = value
+		}
+	}
+	for _, key := range gcpEnvVars {
+		if value := os.Getenv(key); value != "" {
+			env[key] = value
+		}
+	}
+	return env
+}
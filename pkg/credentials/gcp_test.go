@@ -0,0 +1,103 @@
+package credentials
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// clearGCPEnv unsets the variables GCPProvider reads for the duration of the test
+func clearGCPEnv(t *testing.T) {
+	t.Helper()
+	for _, kv := range os.Environ() {
+		if key, _, _ := strings.Cut(kv, "="); strings.HasPrefix(key, "CLOUDSDK_") {
+			t.Setenv(key, "")
+			_ = os.Unsetenv(key)
+		}
+	}
+	for _, key := range append([]string{"GOOGLE_APPLICATION_CREDENTIALS"}, gcpEnvVars...) {
+		t.Setenv(key, "")
+		_ = os.Unsetenv(key)
+	}
+}
+
+func TestGCPProviderKeyFile(t *testing.T) {
+	clearGCPEnv(t)
+	keyFile := filepath.Join(t.TempDir(), "sa-key.json")
+	if err := os.WriteFile(keyFile, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", keyFile)
+	t.Setenv("CLOUDSDK_CORE_PROJECT", "acme-dev")
+	t.Setenv("CLAUDE_CODE_USE_VERTEX", "1")
+	t.Setenv("CLOUD_ML_REGION", "us-east5")
+
+	homeDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(homeDir, ".config", "gcloud"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	env := Environment{HomeDir: homeDir, ContainerHome: "/home/vscode", Log: &bytes.Buffer{}}
+
+	result, err := (&GCPProvider{}).Fetch(env)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	wantKey := "/home/vscode/.config/packnplay-gcp/sa-key.json"
+	if result.Env["GOOGLE_APPLICATION_CREDENTIALS"] != wantKey || result.Env["CLOUDSDK_CORE_PROJECT"] != "acme-dev" ||
+		result.Env["CLAUDE_CODE_USE_VERTEX"] != "1" || result.Env["CLOUD_ML_REGION"] != "us-east5" {
+		t.Errorf("Env = %v", result.Env)
+	}
+	if len(result.Mounts) != 2 {
+		t.Fatalf("Mounts = %+v, want the key file and the gcloud directory", result.Mounts)
+	}
+	if m := result.Mounts[0]; m.HostPath != keyFile || m.ContainerPath != wantKey || !m.ReadOnly {
+		t.Errorf("key file mount = %+v, want read-only", m)
+	}
+	if m := result.Mounts[1]; m.ContainerPath != "/home/vscode/.config/gcloud" || m.ReadOnly {
+		t.Errorf("gcloud mount = %+v, want read-write", m)
+	}
+}
+
+func TestGCPProviderConfigDir(t *testing.T) {
+	clearGCPEnv(t)
+	customConfig := t.TempDir()
+	t.Setenv("CLOUDSDK_CONFIG", customConfig)
+
+	var log bytes.Buffer
+	env := Environment{HomeDir: t.TempDir(), ContainerHome: "/home/vscode", Log: &log}
+	result, err := (&GCPProvider{}).Fetch(env)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if _, ok := result.Env["CLOUDSDK_CONFIG"]; ok {
+		t.Error("CLOUDSDK_CONFIG holds a host path and should not be passed")
+	}
+	if len(result.Mounts) != 1 || result.Mounts[0].HostPath != customConfig || result.Mounts[0].ContainerPath != "/home/vscode/.config/gcloud" {
+		t.Errorf("Mounts = %+v, want CLOUDSDK_CONFIG mounted as ~/.config/gcloud", result.Mounts)
+	}
+	if log.Len() != 0 {
+		t.Errorf("unexpected warnings: %s", log.String())
+	}
+}
+
+func TestGCPProviderMissing(t *testing.T) {
+	clearGCPEnv(t)
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", filepath.Join(t.TempDir(), "gone.json"))
+
+	var log bytes.Buffer
+	env := Environment{HomeDir: t.TempDir(), ContainerHome: "/home/vscode", Log: &log}
+	result, err := (&GCPProvider{}).Fetch(env)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(result.Env) != 0 || len(result.Mounts) != 0 {
+		t.Errorf("Fetch() = %+v, want nothing", result)
+	}
+	for _, want := range []string{"points to a missing file", "gcloud auth application-default login"} {
+		if !strings.Contains(log.String(), want) {
+			t.Errorf("warnings missing %q:\n%s", want, log.String())
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package credentials
+
+import (
+	"path/filepath"
+	"time"
+)
+
+// GHProvider mounts the GitHub CLI config directory. On macOS the token lives in the
+// Keychain instead, so the runner copies it in after the container starts.
+type GHProvider struct{}
+
+func (p *GHProvider) Name() string { return "gh" }
+
+func (p *GHProvider) Detect(env Environment) bool {
+	return fileExists(p.configPath(env))
+}
+
+func (p *GHProvider) Fetch(env Environment) (*Result, error) {
+	return &Result{
+		Mounts: []Mount{{
+			HostPath:      p.configPath(env),
+			ContainerPath: filepath.Join(env.ContainerHome, ".config", "gh"),
+		}},
+	}, nil
+}
+
+func (p *GHProvider) RefreshTTL() time.Duration { return 0 }
+
+func (p *GHProvider) configPath(env Environment) string {
+	return filepath.Join(env.HomeDir, ".config", "gh")
+}
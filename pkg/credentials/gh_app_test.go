@@ -0,0 +1,121 @@
+package credentials
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeAppKey writes a new PKCS #1 RSA key, as GitHub issues them, to ~/app.pem in homeDir
+func writeAppKey(t *testing.T, homeDir string) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(filepath.Join(homeDir, "app.pem"), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestGHAppProviderFetch(t *testing.T) {
+	homeDir := t.TempDir()
+	key := writeAppKey(t, homeDir)
+	expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	var gotPath string
+	var gotBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+
+		// The JWT must be signed with the app's key and issued by the app
+		parts := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+		if len(parts) != 3 {
+			http.Error(w, `{"message": "A JSON web token could not be decoded"}`, http.StatusUnauthorized)
+			return
+		}
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		if rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature) != nil || !strings.Contains(string(claims), `"iss":"1234"`) {
+			http.Error(w, `{"message": "Bad credentials"}`, http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"token": "ghs_example", "expires_at": "` + expires.Format(time.RFC3339) + `"}`))
+	}))
+	defer server.Close()
+
+	provider := &GHAppProvider{
+		AppID:          "1234",
+		InstallationID: "5678",
+		PrivateKey:     "~/app.pem",
+		Permissions:    map[string]string{"contents": "write"},
+		Repositories:   []string{"packnplay"},
+		apiURL:         server.URL,
+	}
+	var log bytes.Buffer
+	result, err := provider.Fetch(Environment{HomeDir: homeDir, ContainerHome: "/home/vscode", Log: &log})
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if result.Env["GH_TOKEN"] != "ghs_example" || len(result.Mounts) != 0 {
+		t.Fatalf("Fetch() = %+v, warnings %q; want only GH_TOKEN", result, log.String())
+	}
+	if gotPath != "/app/installations/5678/access_tokens" {
+		t.Errorf("requested %s", gotPath)
+	}
+	if repos, _ := gotBody["repositories"].([]interface{}); len(repos) != 1 || repos[0] != "packnplay" {
+		t.Errorf("request body = %v, want the token limited to packnplay", gotBody)
+	}
+	if provider.RefreshTTL() < 59*time.Minute {
+		t.Errorf("RefreshTTL() = %s, want about an hour", provider.RefreshTTL())
+	}
+}
+
+func TestGHAppProviderProblems(t *testing.T) {
+	homeDir := t.TempDir()
+	writeAppKey(t, homeDir)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Integration not found"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		provider GHAppProvider
+		wantWarn string
+	}{
+		{"not configured", GHAppProvider{AppID: "1234"}, "need app_id, installation_id and private_key"},
+		{"missing key", GHAppProvider{AppID: "1234", InstallationID: "5678", PrivateKey: "~/missing.pem"}, "failed to read private key"},
+		{"unknown installation", GHAppProvider{AppID: "1234", InstallationID: "5678", PrivateKey: "~/app.pem", apiURL: server.URL}, "Integration not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log bytes.Buffer
+			result, err := tt.provider.Fetch(Environment{HomeDir: homeDir, Log: &log})
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if len(result.Env) != 0 || !strings.Contains(log.String(), tt.wantWarn) {
+				t.Errorf("Fetch() env = %v, warnings = %q, want %q", result.Env, log.String(), tt.wantWarn)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package credentials
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/obra/packnplay/pkg/kube"
+)
+
+// KubeProvider mounts the host's kubeconfig read-only. Users that authenticate with an
+// exec plugin have it run on the host, since the plugin and its own credentials usually
+// aren't in the container, and a copy of the kubeconfig holding the resulting token is
+// mounted instead.
+type KubeProvider struct {
+	ttl time.Duration // lifetime of tokens from exec plugins
+}
+
+func (p *KubeProvider) Name() string { return "kube" }
+
+func (p *KubeProvider) Detect(env Environment) bool { return true }
+
+func (p *KubeProvider) RefreshTTL() time.Duration { return p.ttl }
+
+func (p *KubeProvider) Fetch(env Environment) (*Result, error) {
+	result := &Result{Env: make(map[string]string)}
+	kubeDir := filepath.Join(env.ContainerHome, ".kube")
+	fromEnv := os.Getenv("KUBECONFIG") != ""
+
+	var containerPaths []string
+	for i, path := range kube.Paths(env.HomeDir) {
+		if !fileExists(path) {
+			// kubectl skips missing files in KUBECONFIG too
+			env.verbosef("Kubeconfig %s not found, skipping\n", path)
+			continue
+		}
+		hostPath := p.resolveExec(env, path)
+
+		containerPath := filepath.Join(kubeDir, "config")
+		if fromEnv {
+			containerPath = filepath.Join(kubeDir, "packnplay", fmt.Sprintf("%d-%s", i, filepath.Base(path)))
+		}
+		result.Mounts = append(result.Mounts, Mount{HostPath: hostPath, ContainerPath: containerPath, ReadOnly: true})
+		containerPaths = append(containerPaths, containerPath)
+	}
+
+	if len(containerPaths) == 0 {
+		// Always warn, not just in verbose
+		env.problemf("no kubeconfig found (%s), not passing Kubernetes credentials", strings.Join(kube.Paths(env.HomeDir), ", "))
+		return result, nil
+	}
+	// KUBECONFIG holds host paths; point it at the mounted copies
+	if fromEnv {
+		result.Env["KUBECONFIG"] = strings.Join(containerPaths, ":")
+	}
+	env.verbosef("Mounting kubeconfig read-only: %s\n", strings.Join(containerPaths, ", "))
+	return result, nil
+}
+
+// resolveExec runs the exec plugins of a kubeconfig's users on the host and returns the
+// path to mount: a resolved copy when a plugin ran, the kubeconfig itself otherwise
+func (p *KubeProvider) resolveExec(env Environment, path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		env.problemf("failed to read %s: %v", path, err)
+		return path
+	}
+	resolved, err := kube.ResolveExec(data, filepath.Dir(path))
+	if err != nil {
+		env.logf("Warning: %s: %v; mounting it as is\n", path, err)
+		return path
+	}
+	if len(resolved.ExecUsers) == 0 {
+		return path
+	}
+
+	for _, user := range resolved.ExecUsers {
+		if err := resolved.Failed[user]; err != nil {
+			// Always warn, not just in verbose
+			env.problemf("kubeconfig user '%s' uses an exec plugin that failed on the host (%v); it only works if the plugin is installed and logged in inside the container", user, err)
+		}
+	}
+	if len(resolved.Failed) == len(resolved.ExecUsers) {
+		return path
+	}
+
+	copyPath, err := kube.WriteResolved(path, resolved.Data)
+	if err != nil {
+		env.logf("Warning: %v; mounting %s as is\n", err, path)
+		return path
+	}
+	env.verbosef("Resolved exec plugin credentials in %s on the host\n", path)
+	if !resolved.Expires.IsZero() {
+		ttl := time.Until(resolved.Expires).Round(time.Minute)
+		if p.ttl == 0 || ttl < p.ttl {
+			p.ttl = ttl
+		}
+	}
+	return copyPath
+}
@@ -0,0 +1,128 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/obra/packnplay/pkg/kube"
+)
+
+func TestKubeProviderDefaultConfig(t *testing.T) {
+	t.Setenv("KUBECONFIG", "")
+	homeDir := t.TempDir()
+	config := filepath.Join(homeDir, ".kube", "config")
+	if err := os.MkdirAll(filepath.Dir(config), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(config, []byte("apiVersion: v1\nusers:\n- name: me\n  user:\n    token: abc\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var log bytes.Buffer
+	env := Environment{HomeDir: homeDir, ContainerHome: "/home/vscode", Log: &log}
+	result, err := (&KubeProvider{}).Fetch(env)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(result.Mounts) != 1 || result.Mounts[0].HostPath != config || result.Mounts[0].ContainerPath != "/home/vscode/.kube/config" || !result.Mounts[0].ReadOnly {
+		t.Errorf("Mounts = %+v, want ~/.kube/config mounted read-only", result.Mounts)
+	}
+	if _, ok := result.Env["KUBECONFIG"]; ok {
+		t.Error("KUBECONFIG should not be set for the default location")
+	}
+	if log.Len() != 0 {
+		t.Errorf("unexpected warnings: %s", log.String())
+	}
+}
+
+func TestKubeProviderKubeconfigList(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	dir := t.TempDir()
+	prod := filepath.Join(dir, "prod.yaml")
+	dev := filepath.Join(dir, "dev.yaml")
+	execConfig := "apiVersion: v1\nusers:\n- name: eks\n  user:\n    exec:\n      command: aws\n      args: [eks, get-token]\n"
+	if err := os.WriteFile(prod, []byte(execConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dev, []byte("apiVersion: v1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG", strings.Join([]string{prod, filepath.Join(dir, "missing.yaml"), dev}, string(os.PathListSeparator)))
+
+	original := kube.RunExec
+	defer func() { kube.RunExec = original }()
+	expires := time.Now().Add(15 * time.Minute).UTC().Format(time.RFC3339)
+	kube.RunExec = func(ctx context.Context, dir string, config kube.ExecConfig) ([]byte, error) {
+		return []byte(`{"status":{"token":"k8s-aws-v1.xyz","expirationTimestamp":"` + expires + `"}}`), nil
+	}
+
+	provider := &KubeProvider{}
+	env := Environment{HomeDir: t.TempDir(), ContainerHome: "/home/vscode", Log: &bytes.Buffer{}}
+	result, err := provider.Fetch(env)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	want := "/home/vscode/.kube/packnplay/0-prod.yaml:/home/vscode/.kube/packnplay/2-dev.yaml"
+	if result.Env["KUBECONFIG"] != want {
+		t.Errorf("KUBECONFIG = %q, want %q", result.Env["KUBECONFIG"], want)
+	}
+	if len(result.Mounts) != 2 {
+		t.Fatalf("Mounts = %+v, want the two existing files", result.Mounts)
+	}
+	resolved, err := os.ReadFile(result.Mounts[0].HostPath)
+	if err != nil || result.Mounts[0].HostPath == prod || !strings.Contains(string(resolved), "k8s-aws-v1.xyz") {
+		t.Errorf("prod mount = %s (%v), want a resolved copy holding the token", result.Mounts[0].HostPath, err)
+	}
+	if result.Mounts[1].HostPath != dev {
+		t.Errorf("dev mount = %s, want the file itself", result.Mounts[1].HostPath)
+	}
+	if ttl := provider.RefreshTTL(); ttl < 14*time.Minute || ttl > 15*time.Minute {
+		t.Errorf("RefreshTTL() = %s, want about 15m", ttl)
+	}
+}
+
+func TestKubeProviderFailedExecPlugin(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(config, []byte("users:\n- name: oidc\n  user:\n    exec:\n      command: kubelogin\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG", config)
+
+	original := kube.RunExec
+	defer func() { kube.RunExec = original }()
+	kube.RunExec = func(ctx context.Context, dir string, config kube.ExecConfig) ([]byte, error) {
+		return nil, os.ErrNotExist
+	}
+
+	var log bytes.Buffer
+	env := Environment{HomeDir: t.TempDir(), ContainerHome: "/home/vscode", Log: &log}
+	result, err := (&KubeProvider{}).Fetch(env)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(result.Mounts) != 1 || result.Mounts[0].HostPath != config {
+		t.Errorf("Mounts = %+v, want the kubeconfig itself", result.Mounts)
+	}
+	if !strings.Contains(log.String(), "user 'oidc' uses an exec plugin that failed") {
+		t.Errorf("expected a warning about the exec plugin, got %q", log.String())
+	}
+}
+
+func TestKubeProviderMissing(t *testing.T) {
+	t.Setenv("KUBECONFIG", "")
+	var log bytes.Buffer
+	env := Environment{HomeDir: t.TempDir(), ContainerHome: "/home/vscode", Log: &log}
+	result, err := (&KubeProvider{}).Fetch(env)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(result.Mounts) != 0 || !strings.Contains(log.String(), "no kubeconfig found") {
+		t.Errorf("Fetch() = %+v, log %q, want nothing and a warning", result, log.String())
+	}
+}
@@ -0,0 +1,146 @@
+package credentials
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Provider exposes one kind of host credential to a container. Built-in providers
+// (AWS, gh) and user-defined script providers all implement it, so the runner only
+// has to detect, fetch and apply results.
+type Provider interface {
+	// Name identifies the provider in flags, config and messages
+	Name() string
+	// Detect reports whether the host has anything for this provider to share.
+	// Providers that only run when asked for (AWS, Google Cloud, Azure, kube) always
+	// report true, so Fetch can explain what is missing rather than skipping silently.
+	Detect(env Environment) bool
+	// Fetch returns the env vars and mounts that expose the credentials
+	Fetch(env Environment) (*Result, error)
+	// RefreshTTL is how long fetched credentials stay valid; 0 means they don't
+	// expire (e.g. mounted config directories the tools refresh themselves)
+	RefreshTTL() time.Duration
+}
+
+// Environment describes the host and container a provider is fetching for
+type Environment struct {
+	HomeDir       string    // host home directory
+	ContainerHome string    // container user's home directory
+	Verbose       bool      // print progress notes, not just warnings
+	Strict        bool      // credentials that can't be provided fail Collect instead of warning
+	Log           io.Writer // destination for warnings and notes
+
+	problems *[]string // collected by Collect
+}
+
+// logf writes a warning or note to the environment's log
+func (e Environment) logf(format string, args ...interface{}) {
+	w := e.Log
+	if w == nil {
+		w = os.Stderr
+	}
+	_, _ = fmt.Fprintf(w, format, args...)
+}
+
+// problemf reports credentials that can't be provided: a warning, or in strict mode a
+// problem that fails Collect
+func (e Environment) problemf(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if e.problems != nil {
+		*e.problems = append(*e.problems, message)
+	}
+	if !e.Strict {
+		e.logf("Warning: %s\n", message)
+	}
+}
+
+// verbosef writes a note only in verbose mode
+func (e Environment) verbosef(format string, args ...interface{}) {
+	if e.Verbose {
+		e.logf(format, args...)
+	}
+}
+
+// Mount is a bind mount of a host path into the container
+type Mount struct {
+	HostPath      string
+	ContainerPath string
+	ReadOnly      bool
+}
+
+// String formats the mount as a docker -v argument
+func (m Mount) String() string {
+	if m.ReadOnly {
+		return fmt.Sprintf("%s:%s:ro", m.HostPath, m.ContainerPath)
+	}
+	return fmt.Sprintf("%s:%s", m.HostPath, m.ContainerPath)
+}
+
+// Result is what a provider contributes to the container
+type Result struct {
+	Env    map[string]string
+	Mounts []Mount
+}
+
+// EnvArgs returns the env vars as KEY=value pairs in sorted order
+func (r *Result) EnvArgs() []string {
+	keys := make([]string, 0, len(r.Env))
+	for key := range r.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := make([]string, 0, len(keys))
+	for _, key := range keys {
+		args = append(args, fmt.Sprintf("%s=%s", key, r.Env[key]))
+	}
+	return args
+}
+
+// Collect detects and fetches each provider in order. Providers with nothing on the
+// host are skipped; fetch failures are warned about and skipped so one broken helper
+// doesn't block the run.
+func Collect(providers []Provider, env Environment) ([]*Result, error) {
+	var problems []string
+	env.problems = &problems
+
+	var results []*Result
+	for _, provider := range providers {
+		if !provider.Detect(env) {
+			if env.Strict {
+				env.problemf("no %s credentials found on host", provider.Name())
+			} else {
+				env.verbosef("No %s credentials found on host, skipping\n", provider.Name())
+			}
+			continue
+		}
+
+		result, err := provider.Fetch(env)
+		if err != nil {
+			env.problemf("%s credential provider failed: %v", provider.Name(), err)
+			continue
+		}
+		if ttl := provider.RefreshTTL(); ttl > 0 {
+			env.verbosef("%s credentials are a snapshot valid for about %s\n", provider.Name(), ttl)
+		}
+		results = append(results, result)
+	}
+
+	if env.Strict && len(problems) > 0 {
+		return nil, &StrictError{Problems: problems}
+	}
+	return results, nil
+}
+
+// StrictError lists the credentials that couldn't be provided in strict mode
+type StrictError struct {
+	Problems []string
+}
+
+func (e *StrictError) Error() string {
+	return "requested credentials can't be provided:\n  " + strings.Join(e.Problems, "\n  ")
+}
@@ -0,0 +1,125 @@
+package credentials
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeProvider is a Provider with canned answers
+type fakeProvider struct {
+	name     string
+	detected bool
+	result   *Result
+	err      error
+}
+
+func (p *fakeProvider) Name() string                           { return p.name }
+func (p *fakeProvider) Detect(env Environment) bool            { return p.detected }
+func (p *fakeProvider) Fetch(env Environment) (*Result, error) { return p.result, p.err }
+func (p *fakeProvider) RefreshTTL() time.Duration              { return 0 }
+
+func TestCollect(t *testing.T) {
+	var log bytes.Buffer
+	env := Environment{HomeDir: t.TempDir(), ContainerHome: "/home/vscode", Log: &log}
+
+	ok := &Result{Env: map[string]string{"TOKEN": "abc"}}
+	results, err := Collect([]Provider{
+		&fakeProvider{name: "missing", detected: false},
+		&fakeProvider{name: "broken", detected: true, err: errors.New("boom")},
+		&fakeProvider{name: "ok", detected: true, result: ok},
+	}, env)
+
+	if err != nil {
+		t.Fatalf("Collect() error = %v outside strict mode", err)
+	}
+	if len(results) != 1 || results[0] != ok {
+		t.Fatalf("Collect() = %v, want only the working provider's result", results)
+	}
+	if !strings.Contains(log.String(), "Warning: broken credential provider failed: boom") {
+		t.Errorf("expected warning for failed provider, got %q", log.String())
+	}
+	if strings.Contains(log.String(), "missing") {
+		t.Errorf("undetected provider should only be mentioned in verbose mode, got %q", log.String())
+	}
+}
+
+func TestCollectStrict(t *testing.T) {
+	var log bytes.Buffer
+	env := Environment{HomeDir: t.TempDir(), ContainerHome: "/home/vscode", Strict: true, Log: &log}
+
+	_, err := Collect([]Provider{
+		&fakeProvider{name: "missing", detected: false},
+		&fakeProvider{name: "broken", detected: true, err: errors.New("boom")},
+		&fakeProvider{name: "ok", detected: true, result: &Result{}},
+	}, env)
+
+	var strictErr *StrictError
+	if !errors.As(err, &strictErr) {
+		t.Fatalf("Collect() error = %v, want a StrictError", err)
+	}
+	want := []string{"no missing credentials found on host", "broken credential provider failed: boom"}
+	if !reflect.DeepEqual(strictErr.Problems, want) {
+		t.Errorf("Problems = %q, want %q", strictErr.Problems, want)
+	}
+	if log.Len() != 0 {
+		t.Errorf("strict mode should report problems in the error, not as warnings: %q", log.String())
+	}
+
+	// Problems providers warn about while fetching count too
+	for _, key := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_PROFILE", "AWS_CONFIG_FILE", "AWS_SHARED_CREDENTIALS_FILE"} {
+		t.Setenv(key, "")
+	}
+	_, err = Collect([]Provider{&AWSProvider{}}, env)
+	if err == nil || !strings.Contains(err.Error(), "~/.aws directory not found") {
+		t.Errorf("Collect() error = %v, want the missing ~/.aws", err)
+	}
+}
+
+func TestMountString(t *testing.T) {
+	if got := (Mount{HostPath: "/h/.ssh", ContainerPath: "/home/u/.ssh", ReadOnly: true}).String(); got != "/h/.ssh:/home/u/.ssh:ro" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := (Mount{HostPath: "/h/.aws", ContainerPath: "/home/u/.aws"}).String(); got != "/h/.aws:/home/u/.aws" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestResultEnvArgsSorted(t *testing.T) {
+	result := &Result{Env: map[string]string{"B": "2", "A": "1", "C": "3"}}
+	want := []string{"A=1", "B=2", "C=3"}
+	if got := result.EnvArgs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("EnvArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestGHProvider(t *testing.T) {
+	env := Environment{HomeDir: t.TempDir(), ContainerHome: "/home/vscode"}
+	provider := &GHProvider{}
+
+	if provider.Detect(env) {
+		t.Error("Detect() = true without ~/.config/gh")
+	}
+
+	ghDir := filepath.Join(env.HomeDir, ".config", "gh")
+	if err := os.MkdirAll(ghDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if !provider.Detect(env) {
+		t.Error("Detect() = false with ~/.config/gh")
+	}
+
+	result, err := provider.Fetch(env)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	want := []Mount{{HostPath: ghDir, ContainerPath: "/home/vscode/.config/gh"}}
+	if !reflect.DeepEqual(result.Mounts, want) {
+		t.Errorf("Mounts = %+v, want %+v", result.Mounts, want)
+	}
+}
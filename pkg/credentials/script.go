@@ -0,0 +1,55 @@
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// scriptTimeout bounds how long a user-defined credential script may run
+const scriptTimeout = 30 * time.Second
+
+// ScriptProvider runs a user-defined command on the host and passes the env vars it
+// prints to the container. The command must print JSON: {"env": {"KEY": "value"}}.
+type ScriptProvider struct {
+	ProviderName string
+	Command      string
+	TTL          time.Duration
+}
+
+func (p *ScriptProvider) Name() string { return p.ProviderName }
+
+func (p *ScriptProvider) Detect(env Environment) bool { return p.Command != "" }
+
+func (p *ScriptProvider) RefreshTTL() time.Duration { return p.TTL }
+
+func (p *ScriptProvider) Fetch(env Environment) (*Result, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), scriptTimeout)
+	defer cancel()
+
+	env.verbosef("Running credential provider '%s'\n", p.ProviderName)
+
+	// Use shell to execute command, same as AWS credential_process
+	cmd := exec.CommandContext(ctx, "sh", "-c", p.Command)
+	output, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("command timed out after %s", scriptTimeout)
+		}
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("command failed: %w\nOutput: %s", err, string(exitErr.Stderr))
+		}
+		return nil, fmt.Errorf("command failed: %w", err)
+	}
+
+	var parsed struct {
+		Env map[string]string `json:"env"`
+	}
+	if err := json.Unmarshal(output, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse command output as JSON: %w", err)
+	}
+
+	return &Result{Env: parsed.Env}, nil
+}
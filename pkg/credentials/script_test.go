@@ -0,0 +1,63 @@
+package credentials
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScriptProviderFetch(t *testing.T) {
+	env := Environment{HomeDir: t.TempDir(), ContainerHome: "/home/vscode"}
+
+	tests := []struct {
+		name        string
+		command     string
+		wantEnv     map[string]string
+		errContains string
+	}{
+		{
+			name:    "env from JSON",
+			command: `echo '{"env": {"VAULT_TOKEN": "s.abc", "VAULT_ADDR": "https://vault"}}'`,
+			wantEnv: map[string]string{"VAULT_TOKEN": "s.abc", "VAULT_ADDR": "https://vault"},
+		},
+		{
+			name:        "invalid JSON",
+			command:     "echo not-json",
+			errContains: "failed to parse command output",
+		},
+		{
+			name:        "failing command",
+			command:     "echo denied >&2; exit 1",
+			errContains: "denied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &ScriptProvider{ProviderName: "vault", Command: tt.command}
+			result, err := provider.Fetch(env)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("Fetch() error = %v, want error containing %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if len(result.Env) != len(tt.wantEnv) {
+				t.Fatalf("Env = %v, want %v", result.Env, tt.wantEnv)
+			}
+			for key, value := range tt.wantEnv {
+				if result.Env[key] != value {
+					t.Errorf("Env[%s] = %q, want %q", key, result.Env[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestScriptProviderDetect(t *testing.T) {
+	if (&ScriptProvider{ProviderName: "empty"}).Detect(Environment{}) {
+		t.Error("Detect() = true for a provider with no command")
+	}
+}
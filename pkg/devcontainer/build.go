@@ -0,0 +1,138 @@
+package devcontainer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// BuildHashLabel is the image label holding the hash of the inputs an image was built from
+const BuildHashLabel = "packnplay-build-hash"
+
+// BuildConfig is the devcontainer.json "build" object. Paths are relative to the
+// .devcontainer directory.
+type BuildConfig struct {
+	Dockerfile string            `json:"dockerfile"`
+	Context    string            `json:"context"`
+	Args       map[string]string `json:"args"`
+	Target     string            `json:"target"`
+	CacheFrom  StringList        `json:"cacheFrom"`
+}
+
+// StringList accepts either a single string or an array of strings
+type StringList []string
+
+// UnmarshalJSON accepts both "image" and ["image", ...]
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = StringList{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("expected a string or array of strings: %s", string(data))
+	}
+	*l = list
+	return nil
+}
+
+// normalizeBuild folds the legacy top-level dockerFile and context fields and the build
+// object together, so DockerFile is set whenever the image is built and Build is non-nil
+func (c *Config) normalizeBuild() {
+	if c.Build == nil {
+		if c.DockerFile == "" {
+			return
+		}
+		c.Build = &BuildConfig{}
+	}
+	if c.Build.Dockerfile == "" {
+		c.Build.Dockerfile = c.DockerFile
+	}
+	if c.Build.Context == "" {
+		c.Build.Context = c.Context
+	}
+	c.DockerFile = c.Build.Dockerfile
+}
+
+// DockerfilePath returns the Dockerfile location for a project
+func (c *Config) DockerfilePath(projectPath string) string {
+	return devcontainerPath(projectPath, c.DockerFile)
+}
+
+// BuildContext returns the build context directory for a project, defaulting to
+// .devcontainer
+func (c *Config) BuildContext(projectPath string) string {
+	context := "."
+	if c.Build != nil && c.Build.Context != "" {
+		context = c.Build.Context
+	}
+	return devcontainerPath(projectPath, context)
+}
+
+// BuildPaths returns the Dockerfile and build context of an image built from a
+// Dockerfile, nil otherwise. Either may be outside the project.
+func (c *Config) BuildPaths(projectPath string) []string {
+	if c.DockerFile == "" {
+		return nil
+	}
+	return []string{c.DockerfilePath(projectPath), c.BuildContext(projectPath)}
+}
+
+// devcontainerPath resolves a devcontainer.json path, relative to the .devcontainer
+// directory unless it is absolute
+func devcontainerPath(projectPath, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(projectPath, ".devcontainer", path)
+}
+
+// BuildArgs returns the docker build flags for args, target and cacheFrom, with
+// ${localEnv:...} references in args expanded using localEnv. Args are sorted for stable
+// output.
+func (c *Config) BuildArgs(localEnv LookupFunc) []string {
+	if c.Build == nil {
+		return nil
+	}
+
+	var args []string
+	keys := make([]string, 0, len(c.Build.Args))
+	for key := range c.Build.Args {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "--build-arg", key+"="+ExpandVariables(c.Build.Args[key], localEnv, nil))
+	}
+	if c.Build.Target != "" {
+		args = append(args, "--target", c.Build.Target)
+	}
+	for _, image := range c.Build.CacheFrom {
+		args = append(args, "--cache-from", image)
+	}
+	return args
+}
+
+// BuildHash hashes everything that affects the built image except the rest of the build
+// context: the Dockerfile contents, context path and build flags. A changed hash means
+// the image must be rebuilt.
+func (c *Config) BuildHash(projectPath string, localEnv LookupFunc) (string, error) {
+	dockerfile, err := os.ReadFile(c.DockerfilePath(projectPath))
+	if err != nil {
+		return "", fmt.Errorf("failed to read Dockerfile: %w", err)
+	}
+
+	h := sha256.New()
+	h.Write(dockerfile)
+	fmt.Fprintf(h, "\x00context=%s", c.BuildContext(projectPath))
+	for _, arg := range c.BuildArgs(localEnv) {
+		fmt.Fprintf(h, "\x00%s", arg)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
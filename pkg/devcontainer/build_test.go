@@ -0,0 +1,146 @@
+package devcontainer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeBuildProject(t *testing.T, devcontainerJSON, dockerfile string) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	devcontainerDir := filepath.Join(tmpDir, ".devcontainer")
+	_ = os.Mkdir(devcontainerDir, 0755)
+	_ = os.WriteFile(filepath.Join(devcontainerDir, "devcontainer.json"), []byte(devcontainerJSON), 0644)
+	_ = os.WriteFile(filepath.Join(devcontainerDir, "Dockerfile"), []byte(dockerfile), 0644)
+	return tmpDir
+}
+
+func TestLoadConfigBuild(t *testing.T) {
+	tests := []struct {
+		name        string
+		json        string
+		wantContext string
+		wantArgs    []string
+	}{
+		{
+			name:        "legacy dockerFile",
+			json:        `{"dockerFile": "Dockerfile", "remoteUser": "vscode"}`,
+			wantContext: ".devcontainer",
+		},
+		{
+			name:        "legacy context",
+			json:        `{"dockerFile": "Dockerfile", "context": "..", "remoteUser": "vscode"}`,
+			wantContext: "",
+		},
+		{
+			name: "build object",
+			json: `{
+				"build": {
+					"dockerfile": "Dockerfile",
+					"context": "..",
+					"args": {"VARIANT": "3.12", "NODE_VERSION": "20"},
+					"target": "dev",
+					"cacheFrom": "ghcr.io/acme/app:cache"
+				},
+				"remoteUser": "vscode"
+			}`,
+			wantContext: "",
+			wantArgs: []string{
+				"--build-arg", "NODE_VERSION=20",
+				"--build-arg", "VARIANT=3.12",
+				"--target", "dev",
+				"--cache-from", "ghcr.io/acme/app:cache",
+			},
+		},
+		{
+			name:        "cacheFrom list",
+			json:        `{"build": {"dockerfile": "Dockerfile", "cacheFrom": ["a:1", "b:2"]}, "remoteUser": "vscode"}`,
+			wantContext: ".devcontainer",
+			wantArgs:    []string{"--cache-from", "a:1", "--cache-from", "b:2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectDir := writeBuildProject(t, tt.json, "FROM ubuntu\n")
+			config, err := LoadConfig(projectDir)
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+
+			if config.DockerFile != "Dockerfile" {
+				t.Errorf("DockerFile = %q, want Dockerfile", config.DockerFile)
+			}
+			if got := config.DockerfilePath(projectDir); got != filepath.Join(projectDir, ".devcontainer", "Dockerfile") {
+				t.Errorf("DockerfilePath() = %q", got)
+			}
+			if got := config.BuildContext(projectDir); got != filepath.Join(projectDir, tt.wantContext) {
+				t.Errorf("BuildContext() = %q, want %q", got, filepath.Join(projectDir, tt.wantContext))
+			}
+			if got := config.BuildArgs(os.LookupEnv); !reflect.DeepEqual(got, tt.wantArgs) {
+				t.Errorf("BuildArgs() = %v, want %v", got, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuildArgsExpandsLocalEnv(t *testing.T) {
+	t.Setenv("PACKNPLAY_TEST_NPM_TOKEN", "secret")
+	config := &Config{Build: &BuildConfig{Args: map[string]string{"NPM_TOKEN": "${localEnv:PACKNPLAY_TEST_NPM_TOKEN}"}}}
+
+	want := []string{"--build-arg", "NPM_TOKEN=secret"}
+	if got := config.BuildArgs(os.LookupEnv); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildArgs() = %v, want %v", got, want)
+	}
+
+	// An untrusted workspace can't bake the host's variables into the image
+	want = []string{"--build-arg", "NPM_TOKEN="}
+	if got := config.BuildArgs(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildArgs(nil) = %v, want %v", got, want)
+	}
+}
+
+func TestBuildPaths(t *testing.T) {
+	config := &Config{DockerFile: "../docker/Dockerfile", Build: &BuildConfig{Dockerfile: "../docker/Dockerfile", Context: "/srv/context"}}
+	want := []string{"/src/app/docker/Dockerfile", "/srv/context"}
+	if got := config.BuildPaths("/src/app"); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildPaths() = %v, want %v", got, want)
+	}
+	if got := (&Config{Image: "ubuntu"}).BuildPaths("/src/app"); got != nil {
+		t.Errorf("BuildPaths() for a pulled image = %v, want nil", got)
+	}
+}
+
+func TestBuildHash(t *testing.T) {
+	projectDir := writeBuildProject(t, `{"build": {"dockerfile": "Dockerfile", "args": {"VARIANT": "3.12"}}}`, "FROM python:3\n")
+	config, err := LoadConfig(projectDir)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	hash, err := config.BuildHash(projectDir, os.LookupEnv)
+	if err != nil {
+		t.Fatalf("BuildHash() error = %v", err)
+	}
+	if again, _ := config.BuildHash(projectDir, os.LookupEnv); again != hash {
+		t.Errorf("BuildHash() not stable: %s then %s", hash, again)
+	}
+
+	config.Build.Args["VARIANT"] = "3.13"
+	if changed, _ := config.BuildHash(projectDir, os.LookupEnv); changed == hash {
+		t.Error("BuildHash() unchanged after changing build args")
+	}
+	config.Build.Args["VARIANT"] = "3.12"
+
+	_ = os.WriteFile(config.DockerfilePath(projectDir), []byte("FROM python:3\nRUN pip install uv\n"), 0644)
+	if changed, _ := config.BuildHash(projectDir, os.LookupEnv); changed == hash {
+		t.Error("BuildHash() unchanged after editing the Dockerfile")
+	}
+
+	_ = os.Remove(config.DockerfilePath(projectDir))
+	if _, err := config.BuildHash(projectDir, os.LookupEnv); err == nil {
+		t.Error("BuildHash() succeeded without a Dockerfile")
+	}
+}
@@ -2,35 +2,105 @@ package devcontainer
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/obra/packnplay/pkg/userdetect"
 )
 
 // Config represents a parsed devcontainer.json
 type Config struct {
-	Image       string `json:"image"`
-	DockerFile  string `json:"dockerFile"`
-	RemoteUser  string `json:"remoteUser"`
+	Image          string             `json:"image"`
+	DockerFile     string             `json:"dockerFile"` // legacy form of build.dockerfile
+	Context        string             `json:"context"`    // legacy form of build.context
+	Build          *BuildConfig       `json:"build"`
+	RemoteUser     string             `json:"remoteUser"`
+	ForwardPorts   []ForwardPort      `json:"forwardPorts"`
+	ContainerEnv   map[string]string  `json:"containerEnv"` // set on the container at creation
+	RemoteEnv      map[string]*string `json:"remoteEnv"`    // set for the exec'd command; null unsets
+	Features       map[string]any     `json:"features"`     // not installed; only consulted for docker access
+	Customizations Customizations     `json:"customizations"`
 }
 
-// LoadConfig loads and parses .devcontainer/devcontainer.json if it exists
-func LoadConfig(projectPath string) (*Config, error) {
-	configPath := filepath.Join(projectPath, ".devcontainer", "devcontainer.json")
+// Customizations holds the tool-specific settings of a devcontainer.json. Editor settings
+// and extensions are ignored; only packnplay's own are read.
+type Customizations struct {
+	Packnplay struct {
+		DependencyCaches []string `json:"dependencyCaches"` // caches the project uses, as in dependency_caches.caches
+	} `json:"packnplay"`
+}
 
-	// Check if file exists
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return nil, nil
+// DockerFeature returns the first docker access feature the config asks for:
+// docker-in-docker, docker-outside-of-docker or its older name docker-from-docker
+func (c *Config) DockerFeature() string {
+	for _, name := range []string{"docker-in-docker", "docker-outside-of-docker", "docker-from-docker"} {
+		if c.HasFeature(name) {
+			return name
+		}
 	}
+	return ""
+}
 
-	data, err := os.ReadFile(configPath)
+// HasFeature reports whether the config asks for the feature with the given name, however
+// its ID is written: "docker-in-docker" matches both
+// "ghcr.io/devcontainers/features/docker-in-docker:2" and the legacy "docker-in-docker"
+func (c *Config) HasFeature(name string) bool {
+	for id := range c.Features {
+		if i := strings.LastIndex(id, "/"); i >= 0 {
+			id = id[i+1:]
+		}
+		if i := strings.IndexAny(id, ":@"); i >= 0 {
+			id = id[:i]
+		}
+		if id == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ForwardPort is a forwardPorts entry: a port number, or "host:port" for a port on
+// another service (e.g. "db:5432" in a compose setup)
+type ForwardPort struct {
+	Host string // empty for the dev container itself
+	Port int
+}
+
+// UnmarshalJSON accepts both 3000 and "3000" / "db:5432"
+func (p *ForwardPort) UnmarshalJSON(data []byte) error {
+	var port int
+	if err := json.Unmarshal(data, &port); err == nil {
+		p.Port = port
+		return nil
+	}
+
+	var spec string
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return fmt.Errorf("forwardPorts entry must be a number or string: %s", string(data))
+	}
+
+	host, portStr := "", spec
+	if i := strings.LastIndex(spec, ":"); i >= 0 {
+		host, portStr = spec[:i], spec[i+1:]
+	}
+	port, err := strconv.Atoi(portStr)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("invalid forwardPorts entry %q", spec)
+	}
+	if host == "localhost" || host == "127.0.0.1" {
+		host = ""
 	}
+	p.Host, p.Port = host, port
+	return nil
+}
 
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
+// LoadConfig loads and parses .devcontainer/devcontainer.json if it exists
+func LoadConfig(projectPath string) (*Config, error) {
+	config, err := ParseConfig(projectPath)
+	if config == nil || err != nil {
 		return nil, err
 	}
 
@@ -45,6 +115,30 @@ func LoadConfig(projectPath string) (*Config, error) {
 		}
 	}
 
+	return config, nil
+}
+
+// ParseConfig is LoadConfig without detecting a missing remoteUser, which may need the
+// image pulled
+func ParseConfig(projectPath string) (*Config, error) {
+	configPath := filepath.Join(projectPath, ".devcontainer", "devcontainer.json")
+
+	// Check if file exists
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// devcontainer.json is JSONC: comments and trailing commas are allowed
+	var config Config
+	if err := json.Unmarshal(standardizeJSON(data), &config); err != nil {
+		return nil, err
+	}
+	config.normalizeBuild()
 	return &config, nil
 }
 
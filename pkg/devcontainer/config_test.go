@@ -72,3 +72,104 @@ func TestGetDefaultConfig(t *testing.T) {
 		t.Errorf("GetDefaultConfig(%v) RemoteUser should not be empty", ubuntuImage)
 	}
 }
+
+func TestLoadConfig_ForwardPorts(t *testing.T) {
+	tmpDir := t.TempDir()
+	devcontainerDir := filepath.Join(tmpDir, ".devcontainer")
+	_ = os.Mkdir(devcontainerDir, 0755)
+
+	configContent := `{
+		"image": "mcr.microsoft.com/devcontainers/base:ubuntu",
+		"remoteUser": "vscode",
+		"forwardPorts": [3000, "8080", "db:5432", "localhost:9229"]
+	}`
+	_ = os.WriteFile(filepath.Join(devcontainerDir, "devcontainer.json"), []byte(configContent), 0644)
+
+	config, err := LoadConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	want := []ForwardPort{{Port: 3000}, {Port: 8080}, {Host: "db", Port: 5432}, {Port: 9229}}
+	if len(config.ForwardPorts) != len(want) {
+		t.Fatalf("ForwardPorts = %+v, want %+v", config.ForwardPorts, want)
+	}
+	for i := range want {
+		if config.ForwardPorts[i] != want[i] {
+			t.Errorf("ForwardPorts[%d] = %+v, want %+v", i, config.ForwardPorts[i], want[i])
+		}
+	}
+}
+
+func TestLoadConfig_InvalidForwardPort(t *testing.T) {
+	tmpDir := t.TempDir()
+	devcontainerDir := filepath.Join(tmpDir, ".devcontainer")
+	_ = os.Mkdir(devcontainerDir, 0755)
+	_ = os.WriteFile(filepath.Join(devcontainerDir, "devcontainer.json"), []byte(`{"image": "x", "remoteUser": "u", "forwardPorts": ["web"]}`), 0644)
+
+	if _, err := LoadConfig(tmpDir); err == nil {
+		t.Error("LoadConfig() expected error for non-numeric forwardPorts entry")
+	}
+}
+
+func TestHasFeature(t *testing.T) {
+	config := &Config{Features: map[string]any{
+		"ghcr.io/devcontainers/features/docker-in-docker:2": map[string]any{},
+		"ghcr.io/devcontainers/features/node@sha256:abc":    map[string]any{},
+		"docker-from-docker":                                "latest",
+	}}
+	for _, name := range []string{"docker-in-docker", "node", "docker-from-docker"} {
+		if !config.HasFeature(name) {
+			t.Errorf("HasFeature(%q) = false", name)
+		}
+	}
+	for _, name := range []string{"docker-outside-of-docker", "docker"} {
+		if config.HasFeature(name) {
+			t.Errorf("HasFeature(%q) = true", name)
+		}
+	}
+}
+
+func TestDockerFeature(t *testing.T) {
+	tests := []struct {
+		features map[string]any
+		want     string
+	}{
+		{nil, ""},
+		{map[string]any{"ghcr.io/devcontainers/features/node:1": map[string]any{}}, ""},
+		{map[string]any{"ghcr.io/devcontainers/features/docker-outside-of-docker:1": map[string]any{}}, "docker-outside-of-docker"},
+		{map[string]any{"docker-from-docker": "latest"}, "docker-from-docker"},
+		{map[string]any{
+			"ghcr.io/devcontainers/features/docker-in-docker:2":         map[string]any{},
+			"ghcr.io/devcontainers/features/docker-outside-of-docker:1": map[string]any{},
+		}, "docker-in-docker"},
+	}
+	for _, tt := range tests {
+		if got := (&Config{Features: tt.features}).DockerFeature(); got != tt.want {
+			t.Errorf("DockerFeature() of %v = %q, want %q", tt.features, got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfig_Customizations(t *testing.T) {
+	tmpDir := t.TempDir()
+	devcontainerDir := filepath.Join(tmpDir, ".devcontainer")
+	_ = os.Mkdir(devcontainerDir, 0755)
+
+	configContent := `{
+		"image": "mcr.microsoft.com/devcontainers/base:ubuntu",
+		"customizations": {
+			"vscode": {"extensions": ["golang.go"]},
+			"packnplay": {"dependencyCaches": ["go-build", "go-mod"]}
+		}
+	}`
+	_ = os.WriteFile(filepath.Join(devcontainerDir, "devcontainer.json"), []byte(configContent), 0644)
+
+	config, err := LoadConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if got := config.Customizations.Packnplay.DependencyCaches; len(got) != 2 || got[0] != "go-build" {
+		t.Errorf("dependencyCaches = %q, want go-build and go-mod", got)
+	}
+}
@@ -0,0 +1,138 @@
+package devcontainer
+
+import (
+	"regexp"
+	"sort"
+)
+
+// variablePattern matches ${localEnv:VAR}, ${containerEnv:VAR} and their ${...:VAR:default} forms
+var variablePattern = regexp.MustCompile(`\$\{(localEnv|containerEnv):([A-Za-z_][A-Za-z0-9_]*)(?::([^}]*))?\}`)
+
+// LookupFunc returns the value of a variable and whether it is set
+type LookupFunc func(name string) (string, bool)
+
+// ExpandVariables substitutes ${localEnv:VAR} using localEnv, the host's environment, and
+// ${containerEnv:VAR} using containerEnv, falling back to the reference's default (or
+// empty). A nil lookup leaves only defaults for its references: a workspace that isn't
+// trusted gets nil for localEnv, so it can't read host variables.
+func ExpandVariables(value string, localEnv, containerEnv LookupFunc) string {
+	return variablePattern.ReplaceAllStringFunc(value, func(ref string) string {
+		match := variablePattern.FindStringSubmatch(ref)
+		lookup := localEnv
+		if match[1] == "containerEnv" {
+			lookup = containerEnv
+		}
+		if lookup != nil {
+			if value, ok := lookup(match[2]); ok {
+				return value
+			}
+		}
+		return match[3]
+	})
+}
+
+// ContainerEnvArgs returns containerEnv as sorted KEY=value pairs with ${localEnv:...}
+// references expanded using localEnv
+func (c *Config) ContainerEnvArgs(localEnv LookupFunc) []string {
+	var env []string
+	for key, value := range c.ContainerEnv {
+		env = append(env, key+"="+ExpandVariables(value, localEnv, nil))
+	}
+	sort.Strings(env)
+	return env
+}
+
+// RemoteEnvArgs returns remoteEnv as sorted KEY=value pairs with variables expanded, and
+// the sorted names of variables set to null, which should be removed from the environment
+func (c *Config) RemoteEnvArgs(localEnv, containerEnv LookupFunc) (env []string, unset []string) {
+	for key, value := range c.RemoteEnv {
+		if value == nil {
+			unset = append(unset, key)
+			continue
+		}
+		env = append(env, key+"="+ExpandVariables(*value, localEnv, containerEnv))
+	}
+	sort.Strings(env)
+	sort.Strings(unset)
+	return env, unset
+}
+
+// ContainerEnvFromHost returns the containerEnv keys whose values read host variables
+func (c *Config) ContainerEnvFromHost() map[string]bool {
+	keys := make(map[string]bool)
+	for key, value := range c.ContainerEnv {
+		if len(localEnvNames(value)) > 0 {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+// RemoteEnvFromHost returns the remoteEnv keys whose values read host variables
+func (c *Config) RemoteEnvFromHost() map[string]bool {
+	keys := make(map[string]bool)
+	for key, value := range c.RemoteEnv {
+		if value != nil && len(localEnvNames(*value)) > 0 {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+// LocalEnvRefs returns the sorted names of the host variables containerEnv, remoteEnv and
+// build args read with ${localEnv:...}
+func (c *Config) LocalEnvRefs() []string {
+	var values []string
+	for _, value := range c.ContainerEnv {
+		values = append(values, value)
+	}
+	for _, value := range c.RemoteEnv {
+		if value != nil {
+			values = append(values, *value)
+		}
+	}
+	if c.Build != nil {
+		for _, value := range c.Build.Args {
+			values = append(values, value)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, value := range values {
+		for _, name := range localEnvNames(value) {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// localEnvNames returns the names of the host variables value reads
+func localEnvNames(value string) []string {
+	var names []string
+	for _, match := range variablePattern.FindAllStringSubmatch(value, -1) {
+		if match[1] == "localEnv" {
+			names = append(names, match[2])
+		}
+	}
+	return names
+}
+
+// NeedsContainerEnv reports whether any remoteEnv value refers to ${containerEnv:...}
+func (c *Config) NeedsContainerEnv() bool {
+	for _, value := range c.RemoteEnv {
+		if value == nil {
+			continue
+		}
+		for _, match := range variablePattern.FindAllStringSubmatch(*value, -1) {
+			if match[1] == "containerEnv" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package devcontainer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandVariables(t *testing.T) {
+	t.Setenv("PACKNPLAY_TEST_TOKEN", "secret")
+	containerEnv := func(name string) (string, bool) {
+		if name == "PATH" {
+			return "/usr/bin", true
+		}
+		return "", false
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"plain value", "production", "production"},
+		{"local env", "${localEnv:PACKNPLAY_TEST_TOKEN}", "secret"},
+		{"unset local env", "${localEnv:PACKNPLAY_TEST_UNSET}", ""},
+		{"local env default", "${localEnv:PACKNPLAY_TEST_UNSET:fallback}", "fallback"},
+		{"set value ignores default", "${localEnv:PACKNPLAY_TEST_TOKEN:fallback}", "secret"},
+		{"container env", "${containerEnv:PATH}:/workspace/bin", "/usr/bin:/workspace/bin"},
+		{"unset container env default", "${containerEnv:GOPATH:/go}", "/go"},
+		{"several references", "${localEnv:PACKNPLAY_TEST_TOKEN}-${containerEnv:PATH}", "secret-/usr/bin"},
+		{"unknown kind untouched", "${env:HOME}", "${env:HOME}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExpandVariables(tt.value, os.LookupEnv, containerEnv); got != tt.want {
+				t.Errorf("ExpandVariables(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContainerEnvArgs(t *testing.T) {
+	t.Setenv("PACKNPLAY_TEST_REGION", "eu-west-1")
+	config := &Config{ContainerEnv: map[string]string{
+		"NODE_ENV":   "development",
+		"AWS_REGION": "${localEnv:PACKNPLAY_TEST_REGION}",
+		"MISSING":    "${containerEnv:PATH:none}",
+	}}
+
+	want := []string{"AWS_REGION=eu-west-1", "MISSING=none", "NODE_ENV=development"}
+	if got := config.ContainerEnvArgs(os.LookupEnv); !reflect.DeepEqual(got, want) {
+		t.Errorf("ContainerEnvArgs() = %v, want %v", got, want)
+	}
+
+	// An untrusted workspace can't read the host's variables
+	want = []string{"AWS_REGION=", "MISSING=none", "NODE_ENV=development"}
+	if got := config.ContainerEnvArgs(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("ContainerEnvArgs(nil) = %v, want %v", got, want)
+	}
+	if got := config.ContainerEnvFromHost(); !reflect.DeepEqual(got, map[string]bool{"AWS_REGION": true}) {
+		t.Errorf("ContainerEnvFromHost() = %v, want AWS_REGION", got)
+	}
+}
+
+func TestLocalEnvRefs(t *testing.T) {
+	token := "Bearer ${localEnv:API_TOKEN}"
+	config := &Config{
+		ContainerEnv: map[string]string{"REGION": "${localEnv:AWS_REGION:us-east-1}", "PLAIN": "x"},
+		RemoteEnv:    map[string]*string{"AUTH": &token, "PROXY": nil},
+		Build:        &BuildConfig{Args: map[string]string{"NPM_TOKEN": "${localEnv:NPM_TOKEN}", "PATH_TOO": "${containerEnv:PATH}"}},
+	}
+	want := []string{"API_TOKEN", "AWS_REGION", "NPM_TOKEN"}
+	if got := config.LocalEnvRefs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("LocalEnvRefs() = %v, want %v", got, want)
+	}
+	if got := config.RemoteEnvFromHost(); !reflect.DeepEqual(got, map[string]bool{"AUTH": true}) {
+		t.Errorf("RemoteEnvFromHost() = %v, want AUTH", got)
+	}
+}
+
+func TestRemoteEnvArgs(t *testing.T) {
+	path := "${containerEnv:PATH}:/home/vscode/.local/bin"
+	debug := "1"
+	config := &Config{RemoteEnv: map[string]*string{
+		"PATH":      &path,
+		"DEBUG":     &debug,
+		"HTTPPROXY": nil,
+	}}
+
+	if !config.NeedsContainerEnv() {
+		t.Error("NeedsContainerEnv() = false, want true for a ${containerEnv:...} reference")
+	}
+
+	env, unset := config.RemoteEnvArgs(os.LookupEnv, func(name string) (string, bool) {
+		return "/usr/bin", name == "PATH"
+	})
+	wantEnv := []string{"DEBUG=1", "PATH=/usr/bin:/home/vscode/.local/bin"}
+	if !reflect.DeepEqual(env, wantEnv) {
+		t.Errorf("RemoteEnvArgs() env = %v, want %v", env, wantEnv)
+	}
+	if !reflect.DeepEqual(unset, []string{"HTTPPROXY"}) {
+		t.Errorf("RemoteEnvArgs() unset = %v, want [HTTPPROXY]", unset)
+	}
+
+	if (&Config{RemoteEnv: map[string]*string{"DEBUG": &debug}}).NeedsContainerEnv() {
+		t.Error("NeedsContainerEnv() = true without container references")
+	}
+}
+
+func TestLoadConfigEnv(t *testing.T) {
+	tmpDir := t.TempDir()
+	devcontainerDir := filepath.Join(tmpDir, ".devcontainer")
+	_ = os.Mkdir(devcontainerDir, 0755)
+	_ = os.WriteFile(filepath.Join(devcontainerDir, "devcontainer.json"), []byte(`{
+		"image": "ubuntu",
+		"remoteUser": "vscode",
+		"containerEnv": {"NODE_ENV": "development"},
+		"remoteEnv": {"DEBUG": "1", "PROXY": null}
+	}`), 0644)
+
+	config, err := LoadConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if config.ContainerEnv["NODE_ENV"] != "development" {
+		t.Errorf("ContainerEnv = %v", config.ContainerEnv)
+	}
+	if value, ok := config.RemoteEnv["PROXY"]; !ok || value != nil {
+		t.Errorf("RemoteEnv[PROXY] = %v, %v; want explicit null", value, ok)
+	}
+	if value := config.RemoteEnv["DEBUG"]; value == nil || *value != "1" {
+		t.Errorf("RemoteEnv[DEBUG] = %v, want 1", value)
+	}
+}
@@ -0,0 +1,142 @@
+package devcontainer
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFile lists build context paths packnplay leaves out on top of .dockerignore,
+// for repos that keep their .dockerignore for other builds
+const IgnoreFile = ".packnplayignore"
+
+// IgnorePatterns returns the .dockerignore patterns that apply to the build context: the
+// Dockerfile's own Dockerfile.dockerignore or the context's .dockerignore, followed by the
+// context's .packnplayignore. extra reports whether .packnplayignore added any, which the
+// builder doesn't know about by itself.
+func (c *Config) IgnorePatterns(projectPath string) (patterns []string, extra bool, err error) {
+	dockerignore := c.DockerfilePath(projectPath) + ".dockerignore"
+	if _, statErr := os.Stat(dockerignore); statErr != nil {
+		dockerignore = filepath.Join(c.BuildContext(projectPath), ".dockerignore")
+	}
+	if patterns, err = ReadIgnoreFile(dockerignore); err != nil {
+		return nil, false, err
+	}
+	own, err := ReadIgnoreFile(filepath.Join(c.BuildContext(projectPath), IgnoreFile))
+	if err != nil {
+		return nil, false, err
+	}
+	return append(patterns, own...), len(own) > 0, nil
+}
+
+// ReadIgnoreFile reads the patterns in a .dockerignore-style file, without comments and
+// blank lines. A missing file has none.
+func ReadIgnoreFile(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
+
+// Ignored reports whether patterns leave the context-relative path rel out of the build
+// context. As with .dockerignore, the last matching pattern wins, "!" re-includes, "**"
+// matches any number of directories, and a pattern matching a directory covers
+// everything in it.
+func Ignored(patterns []string, rel string) bool {
+	parts := strings.Split(path.Clean(filepath.ToSlash(rel)), "/")
+	ignored := false
+	for _, pattern := range patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(strings.TrimSpace(pattern))), "/")
+		if pattern == "" {
+			continue
+		}
+		patternParts := strings.Split(pattern, "/")
+		for i := 1; i <= len(parts); i++ {
+			if matchParts(patternParts, parts[:i]) {
+				ignored = !negated
+				break
+			}
+		}
+	}
+	return ignored
+}
+
+// matchParts matches path segments against pattern segments, where "**" stands for any
+// number of segments
+func matchParts(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchParts(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+	return matchParts(pattern[1:], parts[1:])
+}
+
+// ContextSize adds up the files in the build context dir that patterns don't ignore, as
+// the builder would send them
+func ContextSize(dir string, patterns []string) (size int64, files int, err error) {
+	reincludes := false
+	for _, pattern := range patterns {
+		reincludes = reincludes || strings.HasPrefix(pattern, "!")
+	}
+
+	err = filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil || rel == "." {
+			return err
+		}
+		if Ignored(patterns, rel) {
+			// Something below an ignored directory may be included again
+			if entry.IsDir() && !reincludes {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Type().IsRegular() {
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+			files++
+		}
+		return nil
+	})
+	return size, files, err
+}
@@ -0,0 +1,80 @@
+package devcontainer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIgnored(t *testing.T) {
+	patterns := []string{"node_modules", "**/*.log", "/build", "data/*", "!data/schema.sql"}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"node_modules", true},
+		{"node_modules/react/index.js", true},
+		{"web/node_modules/x.js", false},
+		{"debug.log", true},
+		{"logs/app/server.log", true},
+		{"build/out.bin", true},
+		{"src/build/out.bin", false},
+		{"data/users.csv", true},
+		{"data/schema.sql", false},
+		{"src/main.go", false},
+	}
+	for _, tt := range tests {
+		if got := Ignored(patterns, tt.path); got != tt.want {
+			t.Errorf("Ignored(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestIgnorePatternsAndContextSize(t *testing.T) {
+	projectPath := t.TempDir()
+	files := map[string]string{
+		".devcontainer/Dockerfile": "FROM ubuntu\n",
+		".dockerignore":            "# build output\n.git\n\nnode_modules\n",
+		".packnplayignore":         "fixtures\n",
+		"src/main.go":              "package main\n",
+		"node_modules/big.js":      "0123456789",
+		"fixtures/dump.sql":        "0123456789",
+	}
+	for name, content := range files {
+		path := filepath.Join(projectPath, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config := &Config{DockerFile: "Dockerfile", Build: &BuildConfig{Dockerfile: "Dockerfile", Context: ".."}}
+
+	patterns, extra, err := config.IgnorePatterns(projectPath)
+	if err != nil {
+		t.Fatalf("IgnorePatterns() error = %v", err)
+	}
+	if want := []string{".git", "node_modules", "fixtures"}; !reflect.DeepEqual(patterns, want) || !extra {
+		t.Errorf("IgnorePatterns() = %v, %v, want %v, true", patterns, extra, want)
+	}
+
+	size, count, err := ContextSize(config.BuildContext(projectPath), patterns)
+	if err != nil {
+		t.Fatalf("ContextSize() error = %v", err)
+	}
+	// The Dockerfile, both ignore files and main.go
+	if count != 4 || size != int64(len("FROM ubuntu\n")+len(files[".dockerignore"])+len("fixtures\n")+len("package main\n")) {
+		t.Errorf("ContextSize() = %d bytes in %d files", size, count)
+	}
+
+	// A Dockerfile's own ignore file replaces the context's .dockerignore
+	if err := os.WriteFile(filepath.Join(projectPath, ".devcontainer", "Dockerfile.dockerignore"), []byte("src\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if patterns, _, _ := config.IgnorePatterns(projectPath); !reflect.DeepEqual(patterns, []string{"src", "fixtures"}) {
+		t.Errorf("IgnorePatterns() with Dockerfile.dockerignore = %v", patterns)
+	}
+}
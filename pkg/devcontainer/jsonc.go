@@ -0,0 +1,62 @@
+package devcontainer
+
+// standardizeJSON converts JSONC (JSON with // and /* */ comments and trailing commas,
+// as VS Code writes devcontainer.json) into plain JSON. Comments are replaced with
+// spaces, keeping newlines, so byte offsets and line numbers in errors stay accurate.
+func standardizeJSON(data []byte) []byte {
+	out := make([]byte, len(data))
+	copy(out, data)
+
+	// lastComma is the offset of a comma that may turn out to be trailing, or -1
+	lastComma := -1
+	inString := false
+
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+
+		if inString {
+			switch c {
+			case '\\':
+				i++ // skip the escaped character
+			case '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			lastComma = -1
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			out[i], out[i+1] = ' ', ' '
+			for i += 2; i < len(out); i++ {
+				if out[i] == '*' && i+1 < len(out) && out[i+1] == '/' {
+					out[i], out[i+1] = ' ', ' '
+					i++
+					break
+				}
+				if out[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+		case c == ',':
+			lastComma = i
+		case c == '}' || c == ']':
+			if lastComma >= 0 {
+				out[lastComma] = ' '
+			}
+			lastComma = -1
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			// whitespace doesn't end a potential trailing comma
+		default:
+			lastComma = -1
+		}
+	}
+
+	return out
+}
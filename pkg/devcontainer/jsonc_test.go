@@ -0,0 +1,107 @@
+package devcontainer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStandardizeJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]interface{}
+	}{
+		{
+			name:  "line comments",
+			input: "{\n  // the image\n  \"image\": \"ubuntu\" // trailing\n}",
+			want:  map[string]interface{}{"image": "ubuntu"},
+		},
+		{
+			name:  "block comments",
+			input: "{ /* multi\n line */ \"image\": /* inline */ \"ubuntu\" }",
+			want:  map[string]interface{}{"image": "ubuntu"},
+		},
+		{
+			name:  "trailing commas in objects and arrays",
+			input: `{"forwardPorts": [3000, 8080,], "image": "ubuntu",}`,
+			want:  map[string]interface{}{"forwardPorts": []interface{}{3000.0, 8080.0}, "image": "ubuntu"},
+		},
+		{
+			name:  "trailing comma before comment",
+			input: "{\"image\": \"ubuntu\", // last\n}",
+			want:  map[string]interface{}{"image": "ubuntu"},
+		},
+		{
+			name:  "comment markers inside strings are kept",
+			input: `{"url": "https://example.com/*path*/", "note": "a // b"}`,
+			want:  map[string]interface{}{"url": "https://example.com/*path*/", "note": "a // b"},
+		},
+		{
+			name:  "escaped quotes in strings",
+			input: `{"cmd": "echo \"hi\" // not a comment",}`,
+			want:  map[string]interface{}{"cmd": `echo "hi" // not a comment`},
+		},
+		{
+			name:  "comma inside string before closing brace",
+			input: `{"list": "a,"}`,
+			want:  map[string]interface{}{"list": "a,"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]interface{}
+			if err := json.Unmarshal(standardizeJSON([]byte(tt.input)), &got); err != nil {
+				t.Fatalf("Unmarshal(standardizeJSON()) error = %v", err)
+			}
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(tt.want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("got %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestStandardizeJSONPreservesLines(t *testing.T) {
+	input := "{\n/* a\nb */\n\"image\": 1,\n}"
+	got := standardizeJSON([]byte(input))
+	if len(got) != len(input) {
+		t.Errorf("length changed: %d -> %d", len(input), len(got))
+	}
+	for i := range input {
+		if (input[i] == '\n') != (got[i] == '\n') {
+			t.Fatalf("newline mismatch at offset %d", i)
+		}
+	}
+}
+
+func TestLoadConfig_JSONC(t *testing.T) {
+	tmpDir := t.TempDir()
+	devcontainerDir := filepath.Join(tmpDir, ".devcontainer")
+	_ = os.Mkdir(devcontainerDir, 0755)
+
+	configContent := `// For format details, see https://aka.ms/devcontainer.json
+{
+	"name": "Node.js",
+	// Or use a Dockerfile or Docker Compose file.
+	"image": "mcr.microsoft.com/devcontainers/javascript-node:20",
+	"remoteUser": "node",
+	/* "postCreateCommand": "npm install", */
+	"forwardPorts": [3000,],
+}`
+	_ = os.WriteFile(filepath.Join(devcontainerDir, "devcontainer.json"), []byte(configContent), 0644)
+
+	config, err := LoadConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if config.Image != "mcr.microsoft.com/devcontainers/javascript-node:20" || config.RemoteUser != "node" {
+		t.Errorf("LoadConfig() = %+v", config)
+	}
+	if len(config.ForwardPorts) != 1 || config.ForwardPorts[0].Port != 3000 {
+		t.Errorf("ForwardPorts = %+v, want [3000]", config.ForwardPorts)
+	}
+}
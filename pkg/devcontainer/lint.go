@@ -0,0 +1,310 @@
+package devcontainer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Severity says how much a lint finding matters
+type Severity string
+
+const (
+	// SeverityError means packnplay can't use the file as written
+	SeverityError Severity = "error"
+	// SeverityIgnored marks a valid spec field packnplay doesn't act on, so the
+	// container behaves differently than it would in VS Code
+	SeverityIgnored Severity = "ignored"
+	// SeverityUnknown marks a field that isn't in the devcontainer spec at all
+	SeverityUnknown Severity = "unknown"
+)
+
+// Finding is one problem Lint found
+type Finding struct {
+	Field    string // JSON path, e.g. "build.args" or "forwardPorts[1]"
+	Severity Severity
+	Message  string
+}
+
+// jsonKind is a bit set of JSON value types
+type jsonKind int
+
+const (
+	kindString jsonKind = 1 << iota
+	kindNumber
+	kindBool
+	kindObject
+	kindArray
+	kindNull
+)
+
+func (k jsonKind) String() string {
+	var names []string
+	for _, kind := range []struct {
+		bit  jsonKind
+		name string
+	}{{kindString, "string"}, {kindNumber, "number"}, {kindBool, "boolean"}, {kindObject, "object"}, {kindArray, "array"}, {kindNull, "null"}} {
+		if k&kind.bit != 0 {
+			names = append(names, kind.name)
+		}
+	}
+	return strings.Join(names, " or ")
+}
+
+// property is a devcontainer.json property from the spec schema. An empty ignored
+// note means packnplay supports it.
+type property struct {
+	kinds   jsonKind
+	ignored string
+}
+
+const (
+	lifecycleIgnored = "lifecycle commands are not run; do the setup in the Dockerfile or inside the container"
+	composeIgnored   = "Docker Compose setups are not supported; packnplay runs a single container from image or build"
+	securityIgnored  = "container security options are not applied"
+	userIgnored      = "packnplay runs as remoteUser, or the user detected from the image"
+	portsIgnored     = "use forwardPorts or packnplay run -p to publish ports"
+	lifetimeIgnored  = "packnplay manages the container's command and lifetime itself"
+	mountsIgnored    = "packnplay mounts the project at its host path along with credentials; other mounts are not added"
+)
+
+// specProperties lists the top-level devcontainer.json properties from the spec's
+// schema, for image and Dockerfile based configs as well as Docker Compose ones
+var specProperties = map[string]property{
+	"$schema":      {kinds: kindString},
+	"name":         {kinds: kindString},
+	"image":        {kinds: kindString},
+	"dockerFile":   {kinds: kindString},
+	"context":      {kinds: kindString},
+	"build":        {kinds: kindObject},
+	"remoteUser":   {kinds: kindString},
+	"forwardPorts": {kinds: kindArray},
+	"containerEnv": {kinds: kindObject},
+	"remoteEnv":    {kinds: kindObject},
+
+	"features":                    {kinds: kindObject, ignored: "features are not installed (docker-in-docker and docker-outside-of-docker only select --docker); add the tools to the image or Dockerfile"},
+	"overrideFeatureInstallOrder": {kinds: kindArray, ignored: "features are not installed"},
+	"customizations":              {kinds: kindObject, ignored: "editor settings and extensions are not applied (only packnplay.dependencyCaches is used)"},
+	"initializeCommand":           {kinds: kindString | kindArray | kindObject, ignored: lifecycleIgnored},
+	"onCreateCommand":             {kinds: kindString | kindArray | kindObject, ignored: lifecycleIgnored},
+	"updateContentCommand":        {kinds: kindString | kindArray | kindObject, ignored: lifecycleIgnored},
+	"postCreateCommand":           {kinds: kindString | kindArray | kindObject, ignored: lifecycleIgnored},
+	"postStartCommand":            {kinds: kindString | kindArray | kindObject, ignored: lifecycleIgnored},
+	"postAttachCommand":           {kinds: kindString | kindArray | kindObject, ignored: lifecycleIgnored},
+	"waitFor":                     {kinds: kindString, ignored: lifecycleIgnored},
+	"mounts":                      {kinds: kindArray, ignored: mountsIgnored},
+	"workspaceMount":              {kinds: kindString, ignored: mountsIgnored},
+	"workspaceFolder":             {kinds: kindString, ignored: "the project is mounted and opened at its host path"},
+	"runArgs":                     {kinds: kindArray, ignored: "extra docker run arguments are not passed"},
+	"containerUser":               {kinds: kindString, ignored: userIgnored},
+	"updateRemoteUserUID":         {kinds: kindBool, ignored: userIgnored},
+	"userEnvProbe":                {kinds: kindString, ignored: "the user's shell profile is not probed for environment variables; use remoteEnv"},
+	"privileged":                  {kinds: kindBool, ignored: securityIgnored},
+	"capAdd":                      {kinds: kindArray, ignored: securityIgnored},
+	"securityOpt":                 {kinds: kindArray, ignored: securityIgnored},
+	"init":                        {kinds: kindBool, ignored: lifetimeIgnored},
+	"overrideCommand":             {kinds: kindBool, ignored: lifetimeIgnored},
+	"shutdownAction":              {kinds: kindString, ignored: lifetimeIgnored},
+	"appPort":                     {kinds: kindNumber | kindString | kindArray, ignored: portsIgnored},
+	"portsAttributes":             {kinds: kindObject, ignored: portsIgnored},
+	"otherPortsAttributes":        {kinds: kindObject, ignored: portsIgnored},
+	"hostRequirements":            {kinds: kindObject, ignored: "host requirements are not checked"},
+	"secrets":                     {kinds: kindObject, ignored: "secrets are not prompted for; use credential providers or --env"},
+	"dockerComposeFile":           {kinds: kindString | kindArray, ignored: composeIgnored},
+	"service":                     {kinds: kindString, ignored: composeIgnored},
+	"runServices":                 {kinds: kindArray, ignored: composeIgnored},
+}
+
+// buildProperties lists the properties of the "build" object
+var buildProperties = map[string]property{
+	"dockerfile": {kinds: kindString},
+	"context":    {kinds: kindString},
+	"args":       {kinds: kindObject},
+	"target":     {kinds: kindString},
+	"cacheFrom":  {kinds: kindString | kindArray},
+	"options":    {kinds: kindArray, ignored: "extra docker build options are not passed"},
+}
+
+// Lint checks a devcontainer.json (JSONC allowed) against the spec's schema and the
+// subset of it packnplay supports. It returns an error only when the file isn't a
+// JSON object at all; everything else is a finding.
+func Lint(data []byte) ([]Finding, error) {
+	fields, err := objectFields(standardizeJSON(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	var findings []Finding
+	present := make(map[string]json.RawMessage)
+	for _, field := range fields {
+		present[field.key] = field.value
+		findings = append(findings, lintProperty(field.key, field.key, field.value, specProperties)...)
+	}
+
+	// The values of supported fields have to be usable, not just the right type
+	if value, ok := present["build"]; ok && kindOf(value) == kindObject {
+		buildFields, _ := objectFields(value)
+		for _, field := range buildFields {
+			findings = append(findings, lintProperty("build."+field.key, field.key, field.value, buildProperties)...)
+			switch field.key {
+			case "args":
+				findings = append(findings, lintStringMap("build.args", field.value, false)...)
+			case "cacheFrom":
+				var list StringList
+				if kindOf(field.value)&(kindString|kindArray) != 0 && json.Unmarshal(field.value, &list) != nil {
+					findings = append(findings, Finding{Field: "build.cacheFrom", Severity: SeverityError, Message: "expected a string or array of strings"})
+				}
+			}
+		}
+	}
+	if value, ok := present["forwardPorts"]; ok && kindOf(value) == kindArray {
+		var entries []json.RawMessage
+		_ = json.Unmarshal(value, &entries)
+		for i, entry := range entries {
+			var port ForwardPort
+			if err := port.UnmarshalJSON(entry); err != nil {
+				findings = append(findings, Finding{Field: fmt.Sprintf("forwardPorts[%d]", i), Severity: SeverityError, Message: err.Error()})
+			} else if port.Port < 1 || port.Port > 65535 {
+				findings = append(findings, Finding{Field: fmt.Sprintf("forwardPorts[%d]", i), Severity: SeverityError, Message: fmt.Sprintf("port %d is out of range", port.Port)})
+			}
+		}
+	}
+	if value, ok := present["containerEnv"]; ok && kindOf(value) == kindObject {
+		findings = append(findings, lintStringMap("containerEnv", value, false)...)
+	}
+	if value, ok := present["remoteEnv"]; ok && kindOf(value) == kindObject {
+		findings = append(findings, lintStringMap("remoteEnv", value, true)...)
+	}
+
+	// packnplay needs something to run: an image, or a Dockerfile to build one
+	var build BuildConfig
+	if value, ok := present["build"]; ok {
+		_ = json.Unmarshal(value, &build)
+	}
+	_, hasImage := present["image"]
+	hasDockerfile := build.Dockerfile != "" || jsonString(present["dockerFile"]) != ""
+	switch {
+	case hasDockerfile && hasImage:
+		findings = append(findings, Finding{Field: "image", Severity: SeverityIgnored, Message: "the image is built from the Dockerfile instead"})
+	case !hasDockerfile && present["build"] != nil:
+		findings = append(findings, Finding{Field: "build.dockerfile", Severity: SeverityError, Message: "build needs a dockerfile"})
+	case !hasDockerfile && !hasImage:
+		message := "needs an image, or a Dockerfile in build.dockerfile"
+		if _, ok := present["dockerComposeFile"]; ok {
+			message += "; Docker Compose setups are not supported"
+		}
+		findings = append(findings, Finding{Field: "image", Severity: SeverityError, Message: message})
+	}
+
+	return findings, nil
+}
+
+// lintProperty checks a property's name and JSON type against a schema
+func lintProperty(path, key string, value json.RawMessage, schema map[string]property) []Finding {
+	prop, ok := schema[key]
+	if !ok {
+		message := "not a devcontainer.json property"
+		for name := range schema {
+			if strings.EqualFold(name, key) {
+				message = fmt.Sprintf("not a devcontainer.json property; did you mean %q?", name)
+				break
+			}
+		}
+		return []Finding{{Field: path, Severity: SeverityUnknown, Message: message}}
+	}
+
+	if kind := kindOf(value); prop.kinds&kind == 0 {
+		return []Finding{{Field: path, Severity: SeverityError, Message: fmt.Sprintf("expected %s, got %s", prop.kinds, kind)}}
+	}
+	if prop.ignored != "" {
+		return []Finding{{Field: path, Severity: SeverityIgnored, Message: prop.ignored}}
+	}
+	return nil
+}
+
+// lintStringMap checks that every value of an object is a string, or null when
+// allowNull is set
+func lintStringMap(path string, value json.RawMessage, allowNull bool) []Finding {
+	want := kindString
+	if allowNull {
+		want |= kindNull
+	}
+
+	var findings []Finding
+	fields, _ := objectFields(value)
+	for _, field := range fields {
+		if kind := kindOf(field.value); want&kind == 0 {
+			findings = append(findings, Finding{
+				Field:    path + "." + field.key,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("expected %s, got %s", want, kind),
+			})
+		}
+	}
+	return findings
+}
+
+// objectField is a key and raw value of a JSON object, kept in file order
+type objectField struct {
+	key   string
+	value json.RawMessage
+}
+
+// objectFields splits a JSON object into its fields in the order they appear
+func objectFields(data []byte) ([]objectField, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expected an object")
+	}
+
+	var fields []objectField
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		fields = append(fields, objectField{key: token.(string), value: value})
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// kindOf returns the type of a JSON value from its first byte
+func kindOf(value json.RawMessage) jsonKind {
+	value = bytes.TrimSpace(value)
+	if len(value) == 0 {
+		return kindNull
+	}
+	switch value[0] {
+	case '"':
+		return kindString
+	case '{':
+		return kindObject
+	case '[':
+		return kindArray
+	case 't', 'f':
+		return kindBool
+	case 'n':
+		return kindNull
+	default:
+		return kindNumber
+	}
+}
+
+// jsonString returns a JSON string's value, or "" for anything else
+func jsonString(value json.RawMessage) string {
+	var s string
+	_ = json.Unmarshal(value, &s)
+	return s
+}
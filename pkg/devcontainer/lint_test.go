@@ -0,0 +1,116 @@
+package devcontainer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want []Finding
+	}{
+		{
+			name: "supported subset",
+			json: `{
+				// comments are fine
+				"name": "app",
+				"image": "ubuntu:24.04",
+				"remoteUser": "vscode",
+				"forwardPorts": [3000, "db:5432"],
+				"containerEnv": {"A": "1"},
+				"remoteEnv": {"B": null},
+			}`,
+		},
+		{
+			name: "ignored and unknown fields in file order",
+			json: `{
+				"image": "ubuntu",
+				"postCreateCommand": "npm install",
+				"features": {"ghcr.io/devcontainers/features/node:1": {}},
+				"remoteuser": "vscode",
+				"colour": "blue"
+			}`,
+			want: []Finding{
+				{Field: "postCreateCommand", Severity: SeverityIgnored, Message: lifecycleIgnored},
+				{Field: "features", Severity: SeverityIgnored, Message: "features are not installed (docker-in-docker and docker-outside-of-docker only select --docker); add the tools to the image or Dockerfile"},
+				{Field: "remoteuser", Severity: SeverityUnknown, Message: `not a devcontainer.json property; did you mean "remoteUser"?`},
+				{Field: "colour", Severity: SeverityUnknown, Message: "not a devcontainer.json property"},
+			},
+		},
+		{
+			name: "wrong types",
+			json: `{"image": 42, "runArgs": "--init", "containerEnv": {"PORT": 8080}, "remoteEnv": {"X": false}}`,
+			want: []Finding{
+				{Field: "image", Severity: SeverityError, Message: "expected string, got number"},
+				{Field: "runArgs", Severity: SeverityError, Message: "expected array, got string"},
+				{Field: "containerEnv.PORT", Severity: SeverityError, Message: "expected string, got number"},
+				{Field: "remoteEnv.X", Severity: SeverityError, Message: "expected string or null, got boolean"},
+			},
+		},
+		{
+			name: "build",
+			json: `{
+				"image": "ubuntu",
+				"build": {"dockerfile": "Dockerfile", "args": {"V": 1}, "cacheFrom": [1], "options": ["--pull"], "platform": "x"}
+			}`,
+			want: []Finding{
+				{Field: "build.args.V", Severity: SeverityError, Message: "expected string, got number"},
+				{Field: "build.cacheFrom", Severity: SeverityError, Message: "expected a string or array of strings"},
+				{Field: "build.options", Severity: SeverityIgnored, Message: "extra docker build options are not passed"},
+				{Field: "build.platform", Severity: SeverityUnknown, Message: "not a devcontainer.json property"},
+				{Field: "image", Severity: SeverityIgnored, Message: "the image is built from the Dockerfile instead"},
+			},
+		},
+		{
+			name: "bad forwardPorts",
+			json: `{"image": "ubuntu", "forwardPorts": [70000, "web:http", true]}`,
+			want: []Finding{
+				{Field: "forwardPorts[0]", Severity: SeverityError, Message: "port 70000 is out of range"},
+				{Field: "forwardPorts[1]", Severity: SeverityError, Message: `invalid forwardPorts entry "web:http"`},
+				{Field: "forwardPorts[2]", Severity: SeverityError, Message: "forwardPorts entry must be a number or string: true"},
+			},
+		},
+		{
+			name: "compose only",
+			json: `{"dockerComposeFile": "compose.yml", "service": "app"}`,
+			want: []Finding{
+				{Field: "dockerComposeFile", Severity: SeverityIgnored, Message: composeIgnored},
+				{Field: "service", Severity: SeverityIgnored, Message: composeIgnored},
+				{Field: "image", Severity: SeverityError, Message: "needs an image, or a Dockerfile in build.dockerfile; Docker Compose setups are not supported"},
+			},
+		},
+		{
+			name: "build without dockerfile",
+			json: `{"build": {"context": ".."}}`,
+			want: []Finding{
+				{Field: "build.dockerfile", Severity: SeverityError, Message: "build needs a dockerfile"},
+			},
+		},
+		{
+			name: "legacy dockerFile",
+			json: `{"dockerFile": "Dockerfile", "context": ".."}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Lint([]byte(tt.json))
+			if err != nil {
+				t.Fatalf("Lint() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lint() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintInvalidJSON(t *testing.T) {
+	for _, data := range []string{`{"image": }`, `["image"]`, ``} {
+		if _, err := Lint([]byte(data)); err == nil {
+			t.Errorf("Lint(%q) succeeded, want an error", data)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+// Package devproxy routes http://<worktree>.<project>.localhost on the host to the port a
+// sandbox publishes, so several worktrees of the same app can serve at once without
+// anyone having to remember which host port each one got. Browsers resolve *.localhost to
+// the loopback address on their own, so no DNS setup is needed.
+package devproxy
+
+import (
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultListen is where the proxy listens unless told otherwise
+const DefaultListen = "127.0.0.1:8800"
+
+// Port is a container TCP port published on the host
+type Port struct {
+	ContainerPort int
+	HostAddr      string // host:port to connect to
+}
+
+// Target is a running container the proxy can route to
+type Target struct {
+	Container string
+	Project   string
+	Worktree  string
+	Ports     []Port
+}
+
+// Hostname returns the host name that routes to t, e.g. feature-auth.myapp.localhost
+func (t Target) Hostname() string {
+	return hostLabel(t.Worktree) + "." + hostLabel(t.Project) + ".localhost"
+}
+
+// defaultPort is the port a host name without a port label routes to: the lowest
+// published container port
+func (t Target) defaultPort() (Port, bool) {
+	if len(t.Ports) == 0 {
+		return Port{}, false
+	}
+	best := t.Ports[0]
+	for _, p := range t.Ports[1:] {
+		if p.ContainerPort < best.ContainerPort {
+			best = p
+		}
+	}
+	return best, true
+}
+
+// port returns the published port for containerPort
+func (t Target) port(containerPort int) (Port, bool) {
+	for _, p := range t.Ports {
+		if p.ContainerPort == containerPort {
+			return p, true
+		}
+	}
+	return Port{}, false
+}
+
+// hostLabel turns a project or worktree name into a DNS label: lowercase letters, digits
+// and single hyphens
+func hostLabel(name string) string {
+	var b strings.Builder
+	lastHyphen := true // avoid a leading hyphen
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			lastHyphen = false
+		} else if !lastHyphen {
+			b.WriteByte('-')
+			lastHyphen = true
+		}
+	}
+	label := strings.TrimRight(b.String(), "-")
+	if len(label) > 63 {
+		label = strings.TrimRight(label[:63], "-")
+	}
+	return label
+}
+
+// Lookup lists the containers the proxy can route to
+type Lookup func() ([]Target, error)
+
+// cacheTTL is how long a container list is reused, so a page loading dozens of assets
+// doesn't list containers for each of them
+const cacheTTL = 2 * time.Second
+
+// Proxy is the reverse proxy's HTTP handler
+type Proxy struct {
+	lookup Lookup
+
+	mu      sync.Mutex
+	targets []Target
+	fetched time.Time
+	now     func() time.Time
+}
+
+// New returns a proxy that finds containers with lookup
+func New(lookup Lookup) *Proxy {
+	return &Proxy{lookup: lookup, now: time.Now}
+}
+
+// currentTargets returns the container list, listing again once the cached one is stale
+func (p *Proxy) currentTargets() ([]Target, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.targets != nil && p.now().Sub(p.fetched) < cacheTTL {
+		return p.targets, nil
+	}
+	targets, err := p.lookup()
+	if err != nil {
+		return nil, err
+	}
+	if targets == nil {
+		targets = []Target{}
+	}
+	p.targets, p.fetched = targets, p.now()
+	return targets, nil
+}
+
+// Resolve finds where a request for host goes. Host names are
+// [<port>.]<worktree>.<project>.localhost; <project>.localhost works too when the
+// project has a single container.
+func Resolve(targets []Target, host string) (Target, Port, error) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	name, ok := strings.CutSuffix(strings.ToLower(strings.TrimSuffix(host, ".")), ".localhost")
+	if !ok {
+		return Target{}, Port{}, fmt.Errorf("%s is not a .localhost name", host)
+	}
+	labels := strings.Split(name, ".")
+
+	containerPort := 0
+	if len(labels) == 3 {
+		port, err := strconv.Atoi(labels[0])
+		if err != nil {
+			return Target{}, Port{}, fmt.Errorf("unknown host %s", host)
+		}
+		containerPort, labels = port, labels[1:]
+	}
+
+	var matches []Target
+	switch len(labels) {
+	case 2:
+		for _, t := range targets {
+			if hostLabel(t.Worktree) == labels[0] && hostLabel(t.Project) == labels[1] {
+				matches = append(matches, t)
+			}
+		}
+	case 1:
+		for _, t := range targets {
+			if hostLabel(t.Project) == labels[0] {
+				matches = append(matches, t)
+			}
+		}
+		if len(matches) > 1 {
+			return Target{}, Port{}, fmt.Errorf("project %s has %d containers, use <worktree>.%s", labels[0], len(matches), host)
+		}
+	}
+	if len(matches) == 0 {
+		return Target{}, Port{}, fmt.Errorf("no running container for %s", host)
+	}
+
+	target := matches[0]
+	var port Port
+	if containerPort != 0 {
+		port, ok = target.port(containerPort)
+	} else {
+		port, ok = target.defaultPort()
+	}
+	if !ok {
+		if containerPort != 0 {
+			return Target{}, Port{}, fmt.Errorf("%s does not publish port %d", target.Container, containerPort)
+		}
+		return Target{}, Port{}, fmt.Errorf("%s publishes no ports; start it with -p or forwardPorts", target.Container)
+	}
+	return target, port, nil
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	targets, err := p.currentTargets()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to list containers: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	_, port, err := Resolve(targets, r.Host)
+	if err != nil {
+		writeIndex(w, r, targets, err)
+		return
+	}
+
+	upstream := &url.URL{Scheme: "http", Host: port.HostAddr}
+	proxy := &httputil.ReverseProxy{
+		// The original Host is kept, so apps see the name they were opened with
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(upstream)
+			pr.SetXForwarded()
+			pr.Out.Host = pr.In.Host
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, fmt.Sprintf("nothing answered on %s: %v", port.HostAddr, err), http.StatusBadGateway)
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+// writeIndex answers requests that don't route anywhere with the reason and the host
+// names that do, on the port the request came in on
+func writeIndex(w http.ResponseWriter, r *http.Request, targets []Target, reason error) {
+	suffix := ""
+	if _, port, err := net.SplitHostPort(r.Host); err == nil {
+		suffix = ":" + port
+	}
+	var hosts []string
+	for _, t := range targets {
+		if len(t.Ports) > 0 {
+			hosts = append(hosts, t.Hostname()+suffix)
+		}
+	}
+	sort.Strings(hosts)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusNotFound)
+	_, _ = fmt.Fprintf(w, "<!doctype html><title>packnplay proxy</title><p>%s</p>\n", html.EscapeString(reason.Error()))
+	if len(hosts) == 0 {
+		_, _ = fmt.Fprintln(w, "<p>No running containers publish ports.</p>")
+		return
+	}
+	_, _ = fmt.Fprintln(w, "<ul>")
+	for _, host := range hosts {
+		_, _ = fmt.Fprintf(w, "<li><a href=\"//%s\">%s</a></li>\n", html.EscapeString(host), html.EscapeString(host))
+	}
+	_, _ = fmt.Fprintln(w, "</ul>")
+}
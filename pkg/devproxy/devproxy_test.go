@@ -0,0 +1,111 @@
+package devproxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHostname(t *testing.T) {
+	target := Target{Project: "My_App", Worktree: "feature/Auth"}
+	if got := target.Hostname(); got != "feature-auth.my-app.localhost" {
+		t.Errorf("Hostname() = %q", got)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	targets := []Target{
+		{Container: "packnplay-app-main", Project: "app", Worktree: "main", Ports: []Port{
+			{ContainerPort: 5173, HostAddr: "127.0.0.1:49160"},
+			{ContainerPort: 3000, HostAddr: "127.0.0.1:49161"},
+		}},
+		{Container: "packnplay-app-feature-auth", Project: "app", Worktree: "feature/auth", Ports: []Port{
+			{ContainerPort: 3000, HostAddr: "127.0.0.1:49170"},
+		}},
+		{Container: "packnplay-api-main", Project: "api", Worktree: "main", Ports: []Port{
+			{ContainerPort: 8080, HostAddr: "127.0.0.1:8080"},
+		}},
+		{Container: "packnplay-cli-main", Project: "cli", Worktree: "main"},
+	}
+
+	tests := []struct {
+		host      string
+		wantAddr  string
+		wantError string
+	}{
+		{host: "main.app.localhost:8800", wantAddr: "127.0.0.1:49161"},
+		{host: "5173.main.app.localhost:8800", wantAddr: "127.0.0.1:49160"},
+		{host: "feature-auth.app.localhost", wantAddr: "127.0.0.1:49170"},
+		{host: "Feature-Auth.App.localhost.", wantAddr: "127.0.0.1:49170"},
+		{host: "api.localhost:8800", wantAddr: "127.0.0.1:8080"},
+		{host: "app.localhost", wantError: "has 2 containers"},
+		{host: "9999.main.app.localhost", wantError: "does not publish port 9999"},
+		{host: "main.cli.localhost", wantError: "publishes no ports"},
+		{host: "other.app.localhost", wantError: "no running container"},
+		{host: "www.example.com", wantError: "not a .localhost name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			_, port, err := Resolve(targets, tt.host)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("Resolve() error = %v, want %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if port.HostAddr != tt.wantAddr {
+				t.Errorf("Resolve() = %s, want %s", port.HostAddr, tt.wantAddr)
+			}
+		})
+	}
+}
+
+func TestProxyServeHTTP(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Host+" "+r.URL.Path+" "+r.Header.Get("X-Forwarded-Host"))
+	}))
+	defer upstream.Close()
+
+	lookups := 0
+	proxy := New(func() ([]Target, error) {
+		lookups++
+		return []Target{{Container: "packnplay-app-main", Project: "app", Worktree: "main", Ports: []Port{
+			{ContainerPort: 3000, HostAddr: strings.TrimPrefix(upstream.URL, "http://")},
+		}}}, nil
+	})
+	now := time.Now()
+	proxy.now = func() time.Time { return now }
+
+	get := func(host string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "http://"+host+"/assets/app.js", nil)
+		rec := httptest.NewRecorder()
+		proxy.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("main.app.localhost:8800")
+	if rec.Code != http.StatusOK || rec.Body.String() != "main.app.localhost:8800 /assets/app.js main.app.localhost:8800" {
+		t.Errorf("proxied response = %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec = get("other.app.localhost:8800")
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), `href="//main.app.localhost:8800"`) {
+		t.Errorf("unrouted response = %d %q, want an index of host names", rec.Code, rec.Body.String())
+	}
+	if lookups != 1 {
+		t.Errorf("lookups = %d, want the container list cached between requests", lookups)
+	}
+
+	now = now.Add(cacheTTL)
+	get("main.app.localhost:8800")
+	if lookups != 2 {
+		t.Errorf("lookups = %d, want the container list refreshed once stale", lookups)
+	}
+}
@@ -0,0 +1,385 @@
+package diagnostics
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/git"
+	"github.com/obra/packnplay/pkg/preflight"
+)
+
+// DefaultMaxImageAge is how old the default image may get before ImageCheck warns
+const DefaultMaxImageAge = 30 * 24 * time.Hour
+
+// RuntimeCheck reports whether a container runtime CLI is installed
+type RuntimeCheck struct{}
+
+func (RuntimeCheck) Name() string { return "container runtime" }
+
+func (RuntimeCheck) Run(env *Environment) Result {
+	if env.Docker == nil {
+		return Result{
+			Status:  Fail,
+			Message: fmt.Sprintf("%v", env.RuntimeErr),
+			Fix:     "Install Docker, OrbStack or Podman, or set container_runtime with packnplay configure",
+		}
+	}
+	path, err := exec.LookPath(env.Docker.Command())
+	if err != nil {
+		path = env.Docker.Command()
+	}
+	return Result{Status: OK, Message: fmt.Sprintf("using %s (%s)", env.Docker.Command(), path)}
+}
+
+// PodmanMachineCheck reports whether Podman's VM is running on macOS and Windows, where
+// Podman runs containers in one. GOOS is the host OS, runtime.GOOS when empty.
+type PodmanMachineCheck struct {
+	GOOS string
+}
+
+func (PodmanMachineCheck) Name() string { return "podman machine" }
+
+func (c PodmanMachineCheck) Run(env *Environment) Result {
+	goos := c.GOOS
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	if env.Docker == nil || env.Docker.Command() != "podman" {
+		return Result{Status: Skip, Message: "not using Podman"}
+	}
+	if goos == "linux" {
+		return Result{Status: Skip, Message: "Podman runs natively on Linux"}
+	}
+
+	output, err := env.Docker.Run("machine", "list", "--format", "json")
+	if err != nil {
+		return Result{Status: Warn, Message: "cannot list Podman machines: " + FirstLine(output, err)}
+	}
+	machines, err := docker.ParsePodmanMachines(output)
+	if err != nil {
+		return Result{Status: Warn, Message: err.Error()}
+	}
+	if len(machines) == 0 {
+		return Result{Status: Fail, Message: "no Podman machine", Fix: "podman machine init && podman machine start"}
+	}
+	for _, machine := range machines {
+		if machine.Running {
+			return Result{Status: OK, Message: machine.Name + " is running"}
+		}
+	}
+	for _, machine := range machines {
+		if machine.Starting {
+			return Result{Status: Warn, Message: machine.Name + " is starting", Fix: "Wait for podman machine start to finish"}
+		}
+	}
+	name := machines[0].Name
+	for _, machine := range machines {
+		if machine.Default {
+			name = machine.Name
+		}
+	}
+	return Result{Status: Fail, Message: name + " is stopped", Fix: "podman machine start " + name}
+}
+
+// DaemonCheck reports whether the runtime's daemon answers
+type DaemonCheck struct{}
+
+func (DaemonCheck) Name() string { return "daemon" }
+
+func (DaemonCheck) Run(env *Environment) Result {
+	version, err := env.Daemon()
+	if errors.Is(err, errNoRuntime) {
+		return Result{Status: Skip, Message: "no container runtime"}
+	}
+	if err != nil {
+		fix := "Start Docker Desktop or OrbStack, or run: sudo systemctl start docker"
+		if env.Docker.Command() == "podman" {
+			fix = "Start the Podman VM with podman machine start (macOS and Windows), or the Podman socket with: systemctl --user start podman.socket"
+		}
+		if host := os.Getenv("DOCKER_HOST"); host != "" {
+			fix = fmt.Sprintf("Check that DOCKER_HOST=%s is reachable, or unset it to use the local daemon", host)
+		}
+		return Result{Status: Fail, Message: "cannot reach the daemon: " + err.Error(), Fix: fix}
+	}
+	return Result{Status: OK, Message: "server version " + version}
+}
+
+// ClockCheck reports whether the daemon's clock, which containers use, has drifted from
+// the host's
+type ClockCheck struct{}
+
+func (ClockCheck) Name() string { return "clock" }
+
+func (ClockCheck) Run(env *Environment) Result {
+	if _, err := env.Daemon(); err != nil {
+		return Result{Status: Skip, Message: "daemon unavailable"}
+	}
+	skew, err := preflight.ClockSkew(env.Docker, time.Now)
+	if errors.Is(err, preflight.ErrClockUnsupported) {
+		return Result{Status: Skip, Message: "not supported with " + env.Docker.Command()}
+	}
+	if err != nil {
+		return Result{Status: Warn, Message: err.Error()}
+	}
+	if issues := preflight.CheckClockSkew(skew); len(issues) > 0 {
+		return Result{Status: Warn, Message: issues[0].Message, Fix: issues[0].Fix}
+	}
+	return Result{Status: OK, Message: fmt.Sprintf("container clock within %s of the host's", skew.Abs().Round(time.Second))}
+}
+
+// ConfigCheck reports whether the config file exists and holds valid settings
+type ConfigCheck struct{}
+
+func (ConfigCheck) Name() string { return "config" }
+
+func (ConfigCheck) Run(env *Environment) Result {
+	if env.ConfigErr != nil {
+		if errors.Is(env.ConfigErr, os.ErrNotExist) {
+			return Result{
+				Status:  Warn,
+				Message: "no config file at " + env.ConfigPath + " (packnplay run will prompt for setup)",
+				Fix:     "packnplay configure",
+			}
+		}
+		return Result{
+			Status:  Fail,
+			Message: fmt.Sprintf("%s: %v", env.ConfigPath, env.ConfigErr),
+			Fix:     "Fix the JSON by hand, or move the file aside and run: packnplay configure",
+		}
+	}
+
+	problems := env.Config.Problems()
+	if len(problems) > 0 {
+		return Result{
+			Status:  Fail,
+			Message: strings.Join(problems, "; "),
+			Fix:     "Edit " + env.ConfigPath + " or run: packnplay configure",
+		}
+	}
+	return Result{Status: OK, Message: env.ConfigPath}
+}
+
+// CredentialsCheck reports enabled default credentials whose host files are missing
+type CredentialsCheck struct{}
+
+func (CredentialsCheck) Name() string { return "credentials" }
+
+func (CredentialsCheck) Run(env *Environment) Result {
+	if env.Config == nil {
+		return Result{Status: Skip, Message: "no valid config"}
+	}
+
+	creds := env.Config.DefaultCredentials
+	files := []struct {
+		enabled bool
+		name    string
+		path    string
+	}{
+		{creds.Git, "git", ".gitconfig"},
+		{creds.SSH, "ssh", ".ssh"},
+		{creds.GH, "gh", filepath.Join(".config", "gh")},
+		{creds.GPG, "gpg", ".gnupg"},
+		{creds.NPM, "npm", ".npmrc"},
+		{creds.AWS, "aws", ".aws"},
+		{creds.GCP, "gcp", filepath.Join(".config", "gcloud")},
+		{creds.Azure, "azure", ".azure"},
+		{creds.Kube, "kube", filepath.Join(".kube", "config")},
+	}
+
+	var enabled, missing []string
+	for _, file := range files {
+		if !file.enabled {
+			continue
+		}
+		enabled = append(enabled, file.name)
+		if _, err := os.Stat(filepath.Join(env.HomeDir, file.path)); err != nil {
+			missing = append(missing, fmt.Sprintf("%s (~/%s)", file.name, file.path))
+		}
+	}
+
+	if len(missing) > 0 {
+		return Result{
+			Status:  Warn,
+			Message: "enabled but not found on the host: " + strings.Join(missing, ", "),
+			Fix:     "Set them up on the host, or turn them off with: packnplay configure",
+		}
+	}
+	if len(enabled) == 0 {
+		return Result{Status: OK, Message: "no default credentials enabled"}
+	}
+	return Result{Status: OK, Message: "found " + strings.Join(enabled, ", ")}
+}
+
+// ImageCheck reports whether the default image is pulled and recent
+type ImageCheck struct {
+	MaxAge time.Duration
+}
+
+func (ImageCheck) Name() string { return "default image" }
+
+func (c ImageCheck) Run(env *Environment) Result {
+	if _, err := env.Daemon(); err != nil {
+		return Result{Status: Skip, Message: "daemon unavailable"}
+	}
+
+	image := env.DefaultImage()
+	output, err := env.Docker.Run("image", "inspect", "--format", "{{.Created}}", image)
+	if err != nil {
+		return Result{
+			Status:  Warn,
+			Message: image + " is not pulled; the first run will download it",
+			Fix:     "packnplay refresh-container",
+		}
+	}
+
+	created, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(output))
+	if err != nil {
+		return Result{Status: OK, Message: image}
+	}
+	age := time.Since(created)
+	days := int(age.Hours() / 24)
+	if c.MaxAge > 0 && age > c.MaxAge {
+		return Result{
+			Status:  Warn,
+			Message: fmt.Sprintf("%s was built %d days ago", image, days),
+			Fix:     "packnplay refresh-container",
+		}
+	}
+	return Result{Status: OK, Message: fmt.Sprintf("%s (built %d days ago)", image, days)}
+}
+
+// WorktreeCheck reports stale git worktrees of the repository in the working directory
+type WorktreeCheck struct{}
+
+func (WorktreeCheck) Name() string { return "worktrees" }
+
+func (WorktreeCheck) Run(env *Environment) Result {
+	if env.WorkDir == "" || !git.IsGitRepo(env.WorkDir) {
+		return Result{Status: Skip, Message: "not in a git repository"}
+	}
+
+	output, err := exec.Command("git", "-C", env.WorkDir, "worktree", "list", "--porcelain").Output()
+	if err != nil {
+		return Result{Status: Warn, Message: fmt.Sprintf("git worktree list failed: %v", err)}
+	}
+
+	worktrees := parseWorktreeList(string(output))
+	var stale []string
+	for _, wt := range worktrees {
+		if wt.Prunable {
+			stale = append(stale, wt.Path)
+		} else if _, err := os.Stat(wt.Path); err != nil {
+			stale = append(stale, wt.Path)
+		}
+	}
+
+	if len(stale) > 0 {
+		return Result{
+			Status:  Warn,
+			Message: "missing worktree directories: " + strings.Join(stale, ", "),
+			Fix:     fmt.Sprintf("git -C %s worktree prune", env.WorkDir),
+		}
+	}
+	return Result{Status: OK, Message: fmt.Sprintf("%d worktrees", len(worktrees))}
+}
+
+// worktreeEntry is one worktree from `git worktree list --porcelain`
+type worktreeEntry struct {
+	Path     string
+	Prunable bool
+}
+
+// parseWorktreeList parses `git worktree list --porcelain` output, which has one
+// blank-line separated block per worktree
+func parseWorktreeList(output string) []worktreeEntry {
+	var worktrees []worktreeEntry
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case strings.HasPrefix(line, "worktree "):
+			worktrees = append(worktrees, worktreeEntry{Path: strings.TrimPrefix(line, "worktree ")})
+		case line == "prunable" || strings.HasPrefix(line, "prunable "):
+			if len(worktrees) > 0 {
+				worktrees[len(worktrees)-1].Prunable = true
+			}
+		}
+	}
+	return worktrees
+}
+
+// OrphanCheck reports packnplay containers that were left behind: stopped but not
+// removed, or still running for a directory that no longer exists
+type OrphanCheck struct{}
+
+func (OrphanCheck) Name() string { return "orphaned containers" }
+
+func (OrphanCheck) Run(env *Environment) Result {
+	if _, err := env.Daemon(); err != nil {
+		return Result{Status: Skip, Message: "daemon unavailable"}
+	}
+
+	output, err := env.Docker.Run("ps", "-a",
+		"--filter", "label=managed-by=packnplay",
+		"--format", `{{.Names}}\t{{.State}}\t{{.Label "packnplay-host-path"}}`)
+	if err != nil {
+		return Result{Status: Warn, Message: "failed to list containers: " + FirstLine(output, err)}
+	}
+
+	containers := parseContainerStates(output)
+	var orphans []string
+	var descriptions []string
+	for _, c := range containers {
+		switch {
+		case c.State != "running":
+			orphans = append(orphans, c.Name)
+			descriptions = append(descriptions, fmt.Sprintf("%s (%s)", c.Name, c.State))
+		case c.HostPath != "":
+			if _, err := os.Stat(c.HostPath); err != nil {
+				orphans = append(orphans, c.Name)
+				descriptions = append(descriptions, fmt.Sprintf("%s (%s is gone)", c.Name, c.HostPath))
+			}
+		}
+	}
+
+	if len(orphans) > 0 {
+		return Result{
+			Status:  Warn,
+			Message: strings.Join(descriptions, ", "),
+			Fix:     fmt.Sprintf("%s rm -f %s", env.Docker.Command(), strings.Join(orphans, " ")),
+		}
+	}
+	return Result{Status: OK, Message: fmt.Sprintf("%d containers, none orphaned", len(containers))}
+}
+
+// containerState is one line of the OrphanCheck ps output
+type containerState struct {
+	Name     string
+	State    string
+	HostPath string
+}
+
+// parseContainerStates parses tab-separated name, state and host path lines
+func parseContainerStates(output string) []containerState {
+	var containers []containerState
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 3)
+		for len(fields) < 3 {
+			fields = append(fields, "")
+		}
+		containers = append(containers, containerState{
+			Name:     fields[0],
+			State:    fields[1],
+			HostPath: strings.TrimSpace(fields[2]),
+		})
+	}
+	return containers
+}
@@ -0,0 +1,331 @@
+package diagnostics
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/obra/packnplay/pkg/config"
+)
+
+func TestRuntimeCheck(t *testing.T) {
+	result := RuntimeCheck{}.Run(&Environment{RuntimeErr: errors.New("no container runtime found (tried: docker, podman)")})
+	if result.Status != Fail || result.Fix == "" {
+		t.Errorf("missing runtime: %+v, want Fail with fix", result)
+	}
+
+	result = RuntimeCheck{}.Run(&Environment{Docker: &fakeDocker{}})
+	if result.Status != OK {
+		t.Errorf("runtime found: %+v, want OK", result)
+	}
+}
+
+func TestPodmanMachineCheck(t *testing.T) {
+	podman := func(output string, failed bool) *fakeDocker {
+		return &fakeDocker{
+			command: "podman",
+			outputs: map[string]string{"machine list": output},
+			errs:    map[string]bool{"machine list": failed},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		goos   string
+		docker *fakeDocker
+		want   Status
+		substr string
+	}{
+		{"docker", "darwin", &fakeDocker{}, Skip, ""},
+		{"podman on linux", "linux", podman("", false), Skip, ""},
+		{"running", "darwin", podman(`[{"Name":"podman-machine-default*","Running":true}]`, false), OK, "podman-machine-default is running"},
+		{"stopped", "darwin", podman(`[{"Name":"dev"},{"Name":"podman-machine-default","Default":true}]`, false), Fail, "podman-machine-default is stopped"},
+		{"starting", "darwin", podman(`[{"Name":"dev","Starting":true}]`, false), Warn, "starting"},
+		{"none", "darwin", podman(`[]`, false), Fail, "no Podman machine"},
+		{"list fails", "windows", podman("Error: unknown command", true), Warn, "unknown command"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := PodmanMachineCheck{GOOS: tt.goos}.Run(&Environment{Docker: tt.docker})
+			if result.Status != tt.want {
+				t.Errorf("Status = %v, want %v (%+v)", result.Status, tt.want, result)
+			}
+			if !strings.Contains(result.Message, tt.substr) {
+				t.Errorf("Message = %q, want it to contain %q", result.Message, tt.substr)
+			}
+			if tt.want == Fail && result.Fix == "" {
+				t.Error("Fail without a fix")
+			}
+		})
+	}
+}
+
+func TestDaemonCheck(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "")
+
+	tests := []struct {
+		name   string
+		env    *Environment
+		want   Status
+		substr string
+	}{
+		{
+			name: "no runtime",
+			env:  &Environment{},
+			want: Skip,
+		},
+		{
+			name: "unreachable",
+			env: &Environment{Docker: &fakeDocker{
+				outputs: map[string]string{"version": "Cannot connect to the Docker daemon\nmore"},
+				errs:    map[string]bool{"version": true},
+			}},
+			want:   Fail,
+			substr: "Cannot connect to the Docker daemon",
+		},
+		{
+			name:   "reachable",
+			env:    &Environment{Docker: &fakeDocker{outputs: map[string]string{"version": "27.1.0"}}},
+			want:   OK,
+			substr: "27.1.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := DaemonCheck{}.Run(tt.env)
+			if result.Status != tt.want {
+				t.Errorf("Status = %v, want %v", result.Status, tt.want)
+			}
+			if !strings.Contains(result.Message, tt.substr) {
+				t.Errorf("Message = %q, want it to contain %q", result.Message, tt.substr)
+			}
+		})
+	}
+}
+
+func TestConfigCheck(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		want   Status
+		substr string
+	}{
+		{
+			name: "missing",
+			path: filepath.Join(dir, "missing.json"),
+			want: Warn,
+		},
+		{
+			name:   "invalid json",
+			path:   write("broken.json", "{"),
+			want:   Fail,
+			substr: "failed to parse config",
+		},
+		{
+			name:   "invalid values",
+			path:   write("values.json", `{"container_runtime":"lxc","claude_sync":"sometimes","summary":"long","timeout":"soon","default_resources":{"cpus":"many"},"network":{"mode":"allowlist"}}`),
+			want:   Fail,
+			substr: `unknown container_runtime "lxc"; unknown claude_sync "sometimes"; unknown summary "long"; invalid timeout "soon"; default_resources: invalid cpus 'many' (use a number of CPUs like 2 or 1.5); network: allowlist mode needs at least one domain in allow`,
+		},
+		{
+			name: "valid",
+			path: write("valid.json", `{"container_runtime":"docker","default_container":{"variant":"slim"},"timeout":"2h"}`),
+			want: OK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := &Environment{ConfigPath: tt.path}
+			env.Config, env.ConfigErr = config.LoadConfigFromFile(tt.path)
+
+			result := ConfigCheck{}.Run(env)
+			if result.Status != tt.want {
+				t.Errorf("Status = %v, want %v (%s)", result.Status, tt.want, result.Message)
+			}
+			if !strings.Contains(result.Message, tt.substr) {
+				t.Errorf("Message = %q, want it to contain %q", result.Message, tt.substr)
+			}
+		})
+	}
+}
+
+func TestCredentialsCheck(t *testing.T) {
+	home := t.TempDir()
+	if err := os.WriteFile(filepath.Join(home, ".gitconfig"), []byte("[user]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	env := &Environment{
+		HomeDir: home,
+		Config:  &config.Config{DefaultCredentials: config.Credentials{Git: true, SSH: true}},
+	}
+	result := CredentialsCheck{}.Run(env)
+	if result.Status != Warn || !strings.Contains(result.Message, "ssh (~/.ssh)") || strings.Contains(result.Message, "git") {
+		t.Errorf("missing ssh: %+v", result)
+	}
+
+	env.Config.DefaultCredentials.SSH = false
+	if result := (CredentialsCheck{}).Run(env); result.Status != OK {
+		t.Errorf("git only: %+v, want OK", result)
+	}
+
+	if result := (CredentialsCheck{}).Run(&Environment{}); result.Status != Skip {
+		t.Errorf("no config: %+v, want Skip", result)
+	}
+}
+
+func TestImageCheck(t *testing.T) {
+	recent := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339Nano)
+	old := time.Now().Add(-90 * 24 * time.Hour).UTC().Format(time.RFC3339Nano)
+
+	tests := []struct {
+		name string
+		env  *Environment
+		want Status
+	}{
+		{
+			name: "daemon down",
+			env:  &Environment{},
+			want: Skip,
+		},
+		{
+			name: "not pulled",
+			env: &Environment{Docker: &fakeDocker{
+				outputs: map[string]string{"version": "27.1.0"},
+				errs:    map[string]bool{"image inspect": true},
+			}},
+			want: Warn,
+		},
+		{
+			name: "stale",
+			env: &Environment{Docker: &fakeDocker{outputs: map[string]string{
+				"version":       "27.1.0",
+				"image inspect": old + "\n",
+			}}},
+			want: Warn,
+		},
+		{
+			name: "fresh",
+			env: &Environment{Docker: &fakeDocker{outputs: map[string]string{
+				"version":       "27.1.0",
+				"image inspect": recent + "\n",
+			}}},
+			want: OK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ImageCheck{MaxAge: DefaultMaxImageAge}.Run(tt.env)
+			if result.Status != tt.want {
+				t.Errorf("Status = %v, want %v (%s)", result.Status, tt.want, result.Message)
+			}
+		})
+	}
+}
+
+func TestParseWorktreeList(t *testing.T) {
+	output := `worktree /src/app
+HEAD abc
+branch refs/heads/main
+
+worktree /src/app-worktrees/feature
+HEAD def
+branch refs/heads/feature
+prunable gitdir file points to non-existent location
+
+`
+	want := []worktreeEntry{
+		{Path: "/src/app"},
+		{Path: "/src/app-worktrees/feature", Prunable: true},
+	}
+	if got := parseWorktreeList(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseWorktreeList() = %+v, want %+v", got, want)
+	}
+}
+
+func TestWorktreeCheckOutsideRepo(t *testing.T) {
+	if result := (WorktreeCheck{}).Run(&Environment{WorkDir: t.TempDir()}); result.Status != Skip {
+		t.Errorf("outside repo: %+v, want Skip", result)
+	}
+}
+
+func TestOrphanCheck(t *testing.T) {
+	existing := t.TempDir()
+	output := fmt.Sprintf("packnplay-a-main\trunning\t%s\npacknplay-b-old\texited\t%s\npacknplay-c-gone\trunning\t/nonexistent/path\n", existing, existing)
+
+	env := &Environment{Docker: &fakeDocker{outputs: map[string]string{"version": "27.1.0", "ps": output}}}
+	result := OrphanCheck{}.Run(env)
+	if result.Status != Warn {
+		t.Fatalf("Status = %v, want Warn", result.Status)
+	}
+	if result.Fix != "docker rm -f packnplay-b-old packnplay-c-gone" {
+		t.Errorf("Fix = %q", result.Fix)
+	}
+	if strings.Contains(result.Message, "packnplay-a-main") {
+		t.Errorf("healthy container reported: %q", result.Message)
+	}
+
+	env = &Environment{Docker: &fakeDocker{outputs: map[string]string{"version": "27.1.0", "ps": "packnplay-a-main\trunning\t\n"}}}
+	if result := (OrphanCheck{}).Run(env); result.Status != OK {
+		t.Errorf("no orphans: %+v, want OK", result)
+	}
+}
+
+func TestClockCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       *Environment
+		want      Status
+		wantInMsg string
+	}{
+		{
+			name: "daemon down",
+			env:  &Environment{},
+			want: Skip,
+		},
+		{
+			name: "in sync",
+			env: &Environment{Docker: &fakeDocker{outputs: map[string]string{
+				"version": "27.1.0",
+				"info":    time.Now().UTC().Format(time.RFC3339Nano) + "\n",
+			}}},
+			want: OK,
+		},
+		{
+			name: "drifted",
+			env: &Environment{Docker: &fakeDocker{outputs: map[string]string{
+				"version": "27.1.0",
+				"info":    time.Now().Add(-20*time.Minute).UTC().Format(time.RFC3339Nano) + "\n",
+			}}},
+			want:      Warn,
+			wantInMsg: "behind",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ClockCheck{}.Run(tt.env)
+			if result.Status != tt.want || !strings.Contains(result.Message, tt.wantInMsg) {
+				t.Errorf("ClockCheck = %+v, want %v with %q", result, tt.want, tt.wantInMsg)
+			}
+		})
+	}
+}
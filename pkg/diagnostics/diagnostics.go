@@ -0,0 +1,171 @@
+// Package diagnostics checks the host setup packnplay depends on and suggests fixes.
+// Checks are independent and pluggable: each one implements Check and reads what it
+// needs from a shared Environment.
+package diagnostics
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/docker"
+)
+
+// Status is the outcome of a check
+type Status int
+
+const (
+	OK Status = iota
+	Skip
+	Warn
+	Fail
+)
+
+// String returns the label shown next to a check
+func (s Status) String() string {
+	switch s {
+	case OK:
+		return "ok"
+	case Skip:
+		return "skip"
+	case Warn:
+		return "warn"
+	default:
+		return "FAIL"
+	}
+}
+
+// Result is what a check found
+type Result struct {
+	Status  Status
+	Message string // what was found
+	Fix     string // command or change that resolves a warning or failure
+}
+
+// Check is one diagnostic
+type Check interface {
+	// Name identifies the check in the report
+	Name() string
+	// Run inspects the environment; it must not change anything
+	Run(env *Environment) Result
+}
+
+// Environment is the host state checks inspect. Fields are filled in once by
+// NewEnvironment so that checks agree on what they see.
+type Environment struct {
+	HomeDir    string
+	WorkDir    string
+	ConfigPath string
+	Config     *config.Config // nil when the config file is missing or invalid
+	ConfigErr  error
+	Docker     docker.Runner // nil when no container runtime was found
+	RuntimeErr error
+
+	daemonChecked bool
+	daemonVersion string
+	daemonErr     error
+}
+
+// NewEnvironment loads the config and finds the container runtime the way run would
+func NewEnvironment(workDir string) *Environment {
+	homeDir, _ := os.UserHomeDir()
+	env := &Environment{
+		HomeDir:    homeDir,
+		WorkDir:    workDir,
+		ConfigPath: config.GetConfigPath(),
+	}
+
+	env.Config, env.ConfigErr = config.LoadConfigFromFile(env.ConfigPath)
+
+	runtime := ""
+	if env.Config != nil {
+		runtime = env.Config.ContainerRuntime
+	}
+	client, err := docker.NewClientWithRuntime(runtime, false)
+	if err != nil {
+		env.RuntimeErr = err
+	} else {
+		env.Docker = client
+	}
+	return env
+}
+
+// errNoRuntime is reported by Daemon when there is no runtime to ask
+var errNoRuntime = errors.New("no container runtime")
+
+// Daemon returns the daemon's server version, or why it can't be reached. The
+// result is cached so dependent checks don't repeat a slow timeout.
+func (e *Environment) Daemon() (string, error) {
+	if !e.daemonChecked {
+		e.daemonChecked = true
+		if e.Docker == nil {
+			e.daemonErr = errNoRuntime
+		} else {
+			output, err := e.Docker.Run("version", "--format", "{{.Server.Version}}")
+			if err != nil {
+				e.daemonErr = errors.New(FirstLine(output, err))
+			} else {
+				e.daemonVersion = strings.TrimSpace(output)
+			}
+		}
+	}
+	return e.daemonVersion, e.daemonErr
+}
+
+// DefaultImage returns the image run uses when a project has no devcontainer
+func (e *Environment) DefaultImage() string {
+	if e.Config != nil {
+		return e.Config.GetDefaultImage()
+	}
+	return (&config.Config{}).GetDefaultImage()
+}
+
+// Report is a check's name and result
+type Report struct {
+	Check string
+	Result
+}
+
+// Run runs checks in order
+func Run(checks []Check, env *Environment) []Report {
+	reports := make([]Report, 0, len(checks))
+	for _, check := range checks {
+		reports = append(reports, Report{Check: check.Name(), Result: check.Run(env)})
+	}
+	return reports
+}
+
+// Failed reports whether any check failed
+func Failed(reports []Report) bool {
+	for _, report := range reports {
+		if report.Status == Fail {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultChecks returns the built-in checks, cheapest and most fundamental first
+func DefaultChecks() []Check {
+	return []Check{
+		RuntimeCheck{},
+		PodmanMachineCheck{},
+		DaemonCheck{},
+		ClockCheck{},
+		ConfigCheck{},
+		CredentialsCheck{},
+		ImageCheck{MaxAge: DefaultMaxImageAge},
+		WorktreeCheck{},
+		OrphanCheck{},
+	}
+}
+
+// FirstLine reports the first line of command output, or of err if there was none
+func FirstLine(output string, err error) string {
+	output = strings.TrimSpace(output)
+	if output == "" && err != nil {
+		output = strings.TrimSpace(err.Error())
+	}
+	return strings.SplitN(output, "\n", 2)[0]
+}
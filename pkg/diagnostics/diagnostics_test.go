@@ -0,0 +1,93 @@
+package diagnostics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeDocker answers commands from a table keyed by the joined args
+type fakeDocker struct {
+	command string // "docker" when empty
+	outputs map[string]string
+	errs    map[string]bool
+	calls   []string
+}
+
+func (f *fakeDocker) Command() string {
+	if f.command == "" {
+		return "docker"
+	}
+	return f.command
+}
+
+func (f *fakeDocker) Run(args ...string) (string, error) {
+	key := strings.Join(args, " ")
+	f.calls = append(f.calls, key)
+	for prefix, failed := range f.errs {
+		if failed && strings.HasPrefix(key, prefix) {
+			return f.outputs[prefix], errors.New("exit status 1")
+		}
+	}
+	for prefix, output := range f.outputs {
+		if strings.HasPrefix(key, prefix) {
+			return output, nil
+		}
+	}
+	return "", nil
+}
+
+type staticCheck struct {
+	name   string
+	status Status
+}
+
+func (c staticCheck) Name() string                { return c.name }
+func (c staticCheck) Run(env *Environment) Result { return Result{Status: c.status} }
+
+func TestRunAndFailed(t *testing.T) {
+	env := &Environment{}
+
+	reports := Run([]Check{staticCheck{"a", OK}, staticCheck{"b", Warn}}, env)
+	if len(reports) != 2 || reports[0].Check != "a" || reports[1].Status != Warn {
+		t.Fatalf("Run() = %+v", reports)
+	}
+	if Failed(reports) {
+		t.Error("Failed() = true for warnings only")
+	}
+
+	reports = Run([]Check{staticCheck{"a", OK}, staticCheck{"c", Fail}}, env)
+	if !Failed(reports) {
+		t.Error("Failed() = false with a failing check")
+	}
+}
+
+func TestDaemonIsCached(t *testing.T) {
+	docker := &fakeDocker{outputs: map[string]string{"version": "27.1.0\n"}}
+	env := &Environment{Docker: docker}
+
+	for i := 0; i < 3; i++ {
+		version, err := env.Daemon()
+		if err != nil || version != "27.1.0" {
+			t.Fatalf("Daemon() = %q, %v", version, err)
+		}
+	}
+	if len(docker.calls) != 1 {
+		t.Errorf("daemon queried %d times, want 1", len(docker.calls))
+	}
+}
+
+func TestDaemonWithoutRuntime(t *testing.T) {
+	env := &Environment{RuntimeErr: errors.New("no container runtime found")}
+	if _, err := env.Daemon(); !errors.Is(err, errNoRuntime) {
+		t.Errorf("Daemon() error = %v, want errNoRuntime", err)
+	}
+}
+
+func TestStatusString(t *testing.T) {
+	for status, want := range map[Status]string{OK: "ok", Skip: "skip", Warn: "warn", Fail: "FAIL"} {
+		if got := status.String(); got != want {
+			t.Errorf("%d.String() = %q, want %q", status, got, want)
+		}
+	}
+}
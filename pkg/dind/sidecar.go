@@ -0,0 +1,94 @@
+// Package dind runs a rootless docker-in-docker sidecar for containers started with
+// --docker=dind. The sidecar and the container share a private network, and the
+// container's docker CLI talks to the sidecar's daemon over it, so builds and nested
+// containers never touch the host's daemon.
+package dind
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/obra/packnplay/pkg/docker"
+)
+
+// DefaultImage is the sidecar image unless one is configured
+const DefaultImage = "docker:dind-rootless"
+
+// Alias is the sidecar's host name on the shared network
+const Alias = "packnplay-dind"
+
+// Port is where the sidecar's daemon listens. TLS is off: only the container shares the
+// network with it.
+const Port = 2375
+
+// ForLabel marks the sidecar and its network with the container they serve
+const ForLabel = "packnplay-dind-for"
+
+// Name returns the name of the sidecar and its network for containerName
+func Name(containerName string) string {
+	return containerName + "-dind"
+}
+
+// Host is the DOCKER_HOST the container uses to reach the sidecar
+func Host() string {
+	return fmt.Sprintf("tcp://%s:%d", Alias, Port)
+}
+
+// readyTimeout bounds how long Start waits for the sidecar's daemon to answer
+var readyTimeout = 60 * time.Second
+
+// pollInterval is how often Start checks whether the daemon is up. Replaced in tests.
+var pollInterval = 500 * time.Millisecond
+
+// Start creates the shared network for containerName and starts its sidecar, replacing
+// any left over from an earlier run, then waits for the daemon to answer. It returns the
+// network the container must join.
+func Start(d docker.Runner, containerName, image string) (string, error) {
+	if image == "" {
+		image = DefaultImage
+	}
+	name := Name(containerName)
+	label := ForLabel + "=" + containerName
+	Stop(d, containerName)
+
+	if output, err := d.Run("network", "create", "--label", label, name); err != nil {
+		return "", fmt.Errorf("failed to create dind network: %w\nDocker output:\n%s", err, output)
+	}
+
+	// Rootless dind still needs --privileged to set up its user namespace
+	if output, err := d.Run("run", "-d", "--name", name, "--label", label, "--privileged",
+		"--restart", "unless-stopped", "--network", name, "--network-alias", Alias,
+		"-e", "DOCKER_TLS_CERTDIR=", image); err != nil {
+		Stop(d, containerName)
+		return "", fmt.Errorf("failed to start dind sidecar from %s: %w\nDocker output:\n%s", image, err, output)
+	}
+
+	if err := waitReady(d, name); err != nil {
+		Stop(d, containerName)
+		return "", err
+	}
+	return name, nil
+}
+
+// waitReady polls the sidecar's daemon until it answers or readyTimeout passes
+func waitReady(d docker.Runner, name string) error {
+	deadline := time.Now().Add(readyTimeout)
+	for {
+		output, err := d.Run("exec", name, "docker", "--host", fmt.Sprintf("tcp://127.0.0.1:%d", Port), "version")
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("dind sidecar did not start within %s (see docker logs %s)\nDocker output:\n%s", readyTimeout, name, output)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// Stop removes the sidecar, its storage and its network for containerName, if there are
+// any
+func Stop(d docker.Runner, containerName string) {
+	name := Name(containerName)
+	_, _ = d.Run("rm", "-f", "-v", name)
+	_, _ = d.Run("network", "rm", name)
+}
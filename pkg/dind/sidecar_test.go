@@ -0,0 +1,104 @@
+package dind
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeDocker struct {
+	calls    [][]string
+	failOn   string // first argument of the command that fails
+	notReady int    // number of version checks that fail before the daemon answers
+}
+
+func (f *fakeDocker) Command() string {
+	return "docker"
+}
+
+func (f *fakeDocker) Run(args ...string) (string, error) {
+	f.calls = append(f.calls, args)
+	if args[0] == f.failOn {
+		return "boom", errors.New("exit status 1")
+	}
+	if args[0] == "exec" && f.notReady > 0 {
+		f.notReady--
+		return "Cannot connect to the Docker daemon", errors.New("exit status 1")
+	}
+	return "", nil
+}
+
+func commands(calls [][]string) []string {
+	var result []string
+	for _, call := range calls {
+		command := call[0]
+		if command == "network" {
+			command += " " + call[1]
+		}
+		result = append(result, command)
+	}
+	return result
+}
+
+func TestStart(t *testing.T) {
+	pollInterval = 0
+	d := &fakeDocker{notReady: 2}
+	network, err := Start(d, "packnplay-app-main", "")
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if network != "packnplay-app-main-dind" {
+		t.Errorf("Start() network = %q", network)
+	}
+
+	want := []string{"rm", "network rm", "network create", "run", "exec", "exec", "exec"}
+	if got := commands(d.calls); !reflect.DeepEqual(got, want) {
+		t.Errorf("Start() ran %q, want %q", got, want)
+	}
+	run := d.calls[3]
+	if got := run[len(run)-1]; got != DefaultImage {
+		t.Errorf("sidecar image = %q, want %q", got, DefaultImage)
+	}
+	for _, arg := range []string{"--privileged", Alias, "packnplay-dind-for=packnplay-app-main"} {
+		found := false
+		for _, a := range run {
+			found = found || a == arg
+		}
+		if !found {
+			t.Errorf("sidecar run %q is missing %q", run, arg)
+		}
+	}
+}
+
+func TestStartFailureCleansUp(t *testing.T) {
+	pollInterval = 0
+	d := &fakeDocker{failOn: "run"}
+	if _, err := Start(d, "packnplay-app-main", "docker:27-dind-rootless"); err == nil {
+		t.Fatal("Start() succeeded, want an error")
+	}
+	got := commands(d.calls)
+	if want := []string{"rm", "network rm"}; !reflect.DeepEqual(got[len(got)-2:], want) {
+		t.Errorf("Start() ran %q, want it to end with %q", got, want)
+	}
+}
+
+func TestStartTimesOut(t *testing.T) {
+	pollInterval, readyTimeout = 0, 0
+	defer func() { readyTimeout = 60 * time.Second }()
+
+	d := &fakeDocker{notReady: 1000}
+	if _, err := Start(d, "packnplay-app-main", ""); err == nil {
+		t.Fatal("Start() succeeded, want a timeout")
+	}
+	got := commands(d.calls)
+	if want := []string{"rm", "network rm"}; !reflect.DeepEqual(got[len(got)-2:], want) {
+		t.Errorf("Start() ran %q, want it to end with %q", got, want)
+	}
+}
+
+func TestHost(t *testing.T) {
+	if got := Host(); got != "tcp://packnplay-dind:2375" {
+		t.Errorf("Host() = %q", got)
+	}
+}
@@ -0,0 +1,306 @@
+package docker
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// appleAdapter drives Apple's container CLI. It names some commands differently, prints
+// containers in its own JSON shape, has no filters or cp, and runs each container in a
+// lightweight VM, so several docker run flags have no equivalent.
+type appleAdapter struct{}
+
+// TranslateArgs maps docker commands to their container CLI names
+func (appleAdapter) TranslateArgs(args []string) []string {
+	if len(args) == 0 {
+		return args
+	}
+
+	switch args[0] {
+	case "ps":
+		// Apple Container doesn't support --filter or Go template format; List filters
+		// its JSON instead
+		newArgs := []string{"ls"}
+		for i := 1; i < len(args); i++ {
+			if (args[i] == "--filter" || args[i] == "--format") && i+1 < len(args) {
+				i++
+				continue
+			}
+			newArgs = append(newArgs, args[i])
+		}
+		return append(newArgs, "--format", "json")
+
+	case "rm":
+		return append([]string{"delete"}, args[1:]...)
+
+	case "pull":
+		return append([]string{"image", "pull"}, args[1:]...)
+
+	case "inspect":
+		// Only containers can be inspected, so there is no --type
+		var newArgs []string
+		for i := 0; i < len(args); i++ {
+			if args[i] == "--type" && i+1 < len(args) {
+				i++
+				continue
+			}
+			newArgs = append(newArgs, args[i])
+		}
+		return newArgs
+	}
+
+	return args
+}
+
+// appleContainer is a container as container inspect and container ls --format json
+// print it
+type appleContainer struct {
+	Status        string `json:"status"`
+	Configuration struct {
+		ID     string            `json:"id"`
+		Labels map[string]string `json:"labels"`
+		Image  struct {
+			Reference string `json:"reference"`
+		} `json:"image"`
+		InitProcess struct {
+			Environment      []string `json:"environment"`
+			WorkingDirectory string   `json:"workingDirectory"`
+			User             struct {
+				Raw *struct {
+					UserString string `json:"userString"`
+				} `json:"raw"`
+				ID *struct {
+					UID int `json:"uid"`
+					GID int `json:"gid"`
+				} `json:"id"`
+			} `json:"user"`
+		} `json:"initProcess"`
+		Mounts []struct {
+			Source      string `json:"source"`
+			Destination string `json:"destination"`
+		} `json:"mounts"`
+	} `json:"configuration"`
+	Networks []struct {
+		Network string `json:"network"`
+		Address string `json:"address"` // with a prefix length, e.g. 192.168.64.3/24
+	} `json:"networks"`
+}
+
+// info converts the container to the docker inspect form. The name is the ID.
+func (a appleContainer) info() ContainerInfo {
+	config := a.Configuration
+	info := ContainerInfo{
+		ID:    config.ID,
+		Name:  config.ID,
+		State: ContainerState{Status: a.Status, Running: a.Status == "running"},
+		Config: ContainerConfig{
+			Image:      config.Image.Reference,
+			Env:        config.InitProcess.Environment,
+			Labels:     config.Labels,
+			WorkingDir: config.InitProcess.WorkingDirectory,
+		},
+	}
+	switch user := config.InitProcess.User; {
+	case user.Raw != nil:
+		info.Config.User = user.Raw.UserString
+	case user.ID != nil:
+		info.Config.User = fmt.Sprintf("%d:%d", user.ID.UID, user.ID.GID)
+	}
+	for _, network := range a.Networks {
+		if info.NetworkSettings.Networks == nil {
+			info.NetworkSettings.Networks = make(map[string]ContainerNetwork)
+		}
+		address, _, _ := strings.Cut(network.Address, "/")
+		info.NetworkSettings.Networks[network.Network] = ContainerNetwork{IPAddress: address}
+	}
+	for _, mount := range config.Mounts {
+		info.Mounts = append(info.Mounts, ContainerMount{Type: "bind", Source: mount.Source, Destination: mount.Destination})
+	}
+	return info
+}
+
+// parseAppleContainers parses the JSON list container inspect and ls print
+func parseAppleContainers(output string) ([]ContainerInfo, error) {
+	var containers []appleContainer
+	if err := json.Unmarshal([]byte(output), &containers); err != nil {
+		return nil, fmt.Errorf("failed to parse container output: %w", err)
+	}
+	infos := make([]ContainerInfo, 0, len(containers))
+	for _, c := range containers {
+		infos = append(infos, c.info())
+	}
+	return infos, nil
+}
+
+func (appleAdapter) Inspect(cli CLI, nameOrID string) (*ContainerInfo, error) {
+	output, err := cli.Run("inspect", nameOrID)
+	if err != nil {
+		// container inspect reports a missing container as "notFound"
+		var cmdErr *CommandError
+		if errors.As(err, &cmdErr) && cmdErr.Kind == nil && strings.Contains(strings.ToLower(cmdErr.Output), "notfound") {
+			cmdErr.Kind, cmdErr.Detail = ErrNoSuchContainer, nameOrID
+		}
+		return nil, err
+	}
+	infos, err := parseAppleContainers(output)
+	if err != nil {
+		return nil, err
+	}
+	if len(infos) == 0 {
+		return nil, newCommandError([]string{"inspect", nameOrID}, "no such container: "+nameOrID, errors.New("empty inspect output"))
+	}
+	return &infos[0], nil
+}
+
+// List lists every container and applies the filters itself
+func (appleAdapter) List(cli CLI, filters map[string][]string, all bool) ([]ContainerInfo, error) {
+	args := []string{"ls", "--format", "json"}
+	if all {
+		args = append(args, "--all")
+	}
+	output, err := cli.Run(args...)
+	if err != nil {
+		return nil, err
+	}
+	infos, err := parseAppleContainers(output)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []ContainerInfo
+	for _, info := range infos {
+		ok, err := matchFilters(info, filters)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, info)
+		}
+	}
+	return matched, nil
+}
+
+// matchFilters applies docker ps filters to a container. As in docker, every label
+// filter must match, while any one value of the other filters will do.
+func matchFilters(info ContainerInfo, filters map[string][]string) (bool, error) {
+	for key, values := range filters {
+		switch key {
+		case "label":
+			for _, value := range values {
+				name, want, hasValue := strings.Cut(value, "=")
+				got, ok := info.Config.Labels[name]
+				if !ok || hasValue && got != want {
+					return false, nil
+				}
+			}
+		case "name", "id", "status":
+			matched := false
+			for _, value := range values {
+				switch key {
+				case "name":
+					re, err := regexp.Compile(value)
+					if err != nil {
+						return false, fmt.Errorf("invalid name filter %q: %w", value, err)
+					}
+					matched = matched || re.MatchString(info.Name)
+				case "id":
+					matched = matched || strings.HasPrefix(info.ID, value)
+				case "status":
+					matched = matched || info.State.Status == value
+				}
+			}
+			if !matched {
+				return false, nil
+			}
+		default:
+			return false, fmt.Errorf("filter %q is not supported with Apple Container", key)
+		}
+	}
+	return true, nil
+}
+
+// CopyTo streams the file, or a tar of the directory, into the container through exec,
+// since the container CLI has no cp
+func (appleAdapter) CopyTo(cli CLI, src, container, dst string) error {
+	src = strings.TrimSuffix(src, "/.")
+	info, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+
+	var output string
+	if info.IsDir() {
+		reader, writer := io.Pipe()
+		go func() { writer.CloseWithError(writeTar(writer, src)) }()
+		output, err = cli.RunWithInput(reader, "exec", "-i", container,
+			"sh", "-c", `mkdir -p "$1" && tar -x -C "$1"`, "sh", dst)
+		reader.Close()
+	} else {
+		file, openErr := os.Open(src)
+		if openErr != nil {
+			return fmt.Errorf("failed to copy %s: %w", src, openErr)
+		}
+		defer file.Close()
+		output, err = cli.RunWithInput(file, "exec", "-i", container,
+			"sh", "-c", `mkdir -p "$(dirname "$1")" && cat > "$1" && chmod "$2" "$1"`, "sh", dst, fmt.Sprintf("%o", info.Mode().Perm()))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to copy %s to %s: %w\n%s", src, dst, err, output)
+	}
+	return nil
+}
+
+// writeTar writes the contents of dir to w as a tar archive
+func writeTar(w io.Writer, dir string) error {
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tw, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// Capabilities: each container is its own VM, without docker's daemon, event stream,
+// TTY for detached containers, or the run flags that tune a shared kernel
+func (appleAdapter) Capabilities() Capabilities {
+	return Capabilities{}
+}
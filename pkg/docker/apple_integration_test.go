@@ -0,0 +1,66 @@
+//go:build integration && darwin
+
+package docker
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestAppleContainerIntegration drives a real container through the Apple adapter. Run it
+// on a Mac with the container CLI installed and its system service started:
+//
+//	go test -tags integration ./pkg/docker
+func TestAppleContainerIntegration(t *testing.T) {
+	if _, err := exec.LookPath("container"); err != nil {
+		t.Skip("container CLI not installed")
+	}
+	client := &Client{cmd: "container"}
+	name := "packnplay-adapter-test"
+	_, _ = client.Run("rm", "-f", name)
+
+	if _, err := client.Run("run", "-d", "--name", name, "--label", "managed-by=packnplay-test",
+		"alpine", "sleep", "300"); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	t.Cleanup(func() { _, _ = client.Run("rm", "-f", name) })
+
+	info, err := client.InspectContainer(name)
+	if err != nil {
+		t.Fatalf("InspectContainer() error = %v", err)
+	}
+	if !info.State.Running || info.Config.Labels["managed-by"] != "packnplay-test" {
+		t.Errorf("inspect = %+v", info)
+	}
+
+	infos, err := client.ListContainers(map[string][]string{"label": {"managed-by=packnplay-test"}}, false)
+	if err != nil {
+		t.Fatalf("ListContainers() error = %v", err)
+	}
+	if len(infos) != 1 || infos[0].Name != name {
+		t.Errorf("ListContainers() = %+v", infos)
+	}
+
+	src := filepath.Join(t.TempDir(), "greeting")
+	if err := os.WriteFile(src, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CopyToContainer(src, name, "/tmp/copied/greeting"); err != nil {
+		t.Fatalf("CopyToContainer() error = %v", err)
+	}
+	output, err := client.Run("exec", name, "cat", "/tmp/copied/greeting")
+	if err != nil || strings.TrimSpace(output) != "hello" {
+		t.Errorf("exec cat = %q, %v", output, err)
+	}
+
+	if _, err := client.Run("rm", "-f", name); err != nil {
+		t.Fatalf("rm: %v", err)
+	}
+	if _, err := client.InspectContainer(name); !errors.Is(err, ErrNoSuchContainer) {
+		t.Errorf("InspectContainer() after rm error = %v, want ErrNoSuchContainer", err)
+	}
+}
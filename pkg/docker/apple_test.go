@@ -0,0 +1,220 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const appleListJSON = `[
+  {
+    "status": "running",
+    "configuration": {
+      "id": "packnplay-app-main",
+      "labels": {"managed-by": "packnplay", "packnplay-project": "app"},
+      "image": {"reference": "ghcr.io/obra/packnplay-default:latest"},
+      "initProcess": {
+        "environment": ["PATH=/usr/bin", "HOME=/home/vscode"],
+        "workingDirectory": "/workspace",
+        "user": {"id": {"uid": 1000, "gid": 1000}}
+      },
+      "mounts": [{"source": "/Users/me/app", "destination": "/workspace"}]
+    },
+    "networks": [{"network": "default", "address": "192.168.64.3/24"}]
+  },
+  {
+    "status": "stopped",
+    "configuration": {
+      "id": "other",
+      "labels": {},
+      "image": {"reference": "alpine"},
+      "initProcess": {"user": {"raw": {"userString": "root"}}}
+    }
+  }
+]`
+
+func TestAppleTranslateArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"ps", "-a", "--filter", "label=x", "--format", "{{json .}}"}, []string{"ls", "-a", "--format", "json"}},
+		{[]string{"rm", "-f", "app"}, []string{"delete", "-f", "app"}},
+		{[]string{"pull", "alpine"}, []string{"image", "pull", "alpine"}},
+		{[]string{"inspect", "--type", "container", "app"}, []string{"inspect", "app"}},
+		{[]string{"exec", "app", "ls"}, []string{"exec", "app", "ls"}},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		if got := (appleAdapter{}).TranslateArgs(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("TranslateArgs(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestParseAppleContainers(t *testing.T) {
+	infos, err := parseAppleContainers(appleListJSON)
+	if err != nil {
+		t.Fatalf("parseAppleContainers() error = %v", err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("got %d containers, want 2", len(infos))
+	}
+
+	app := infos[0]
+	if app.ID != "packnplay-app-main" || app.Name != "packnplay-app-main" {
+		t.Errorf("ID, Name = %q, %q", app.ID, app.Name)
+	}
+	if !app.State.Running || app.State.Status != "running" {
+		t.Errorf("State = %+v", app.State)
+	}
+	if app.Config.User != "1000:1000" || app.Config.WorkingDir != "/workspace" || app.Config.Image != "ghcr.io/obra/packnplay-default:latest" {
+		t.Errorf("Config = %+v", app.Config)
+	}
+	if app.Config.Labels["packnplay-project"] != "app" || len(app.Config.Env) != 2 {
+		t.Errorf("Labels, Env = %v, %v", app.Config.Labels, app.Config.Env)
+	}
+	if got := app.NetworkSettings.Networks["default"].IPAddress; got != "192.168.64.3" {
+		t.Errorf("IPAddress = %q", got)
+	}
+	if len(app.Mounts) != 1 || app.Mounts[0].Destination != "/workspace" {
+		t.Errorf("Mounts = %+v", app.Mounts)
+	}
+
+	other := infos[1]
+	if other.State.Running || other.Config.User != "root" {
+		t.Errorf("other = %+v", other)
+	}
+
+	if _, err := parseAppleContainers("not json"); err == nil {
+		t.Error("expected an error for invalid output")
+	}
+}
+
+func TestMatchFilters(t *testing.T) {
+	infos, err := parseAppleContainers(appleListJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := infos[0]
+
+	tests := []struct {
+		name    string
+		filters map[string][]string
+		want    bool
+		wantErr bool
+	}{
+		{"none", nil, true, false},
+		{"label present", map[string][]string{"label": {"managed-by"}}, true, false},
+		{"label value", map[string][]string{"label": {"managed-by=packnplay"}}, true, false},
+		{"every label must match", map[string][]string{"label": {"managed-by=packnplay", "packnplay-project=other"}}, false, false},
+		{"name regex", map[string][]string{"name": {"^packnplay-app"}}, true, false},
+		{"any name", map[string][]string{"name": {"nope", "main$"}}, true, false},
+		{"name mismatch", map[string][]string{"name": {"nope"}}, false, false},
+		{"id prefix", map[string][]string{"id": {"packnplay-"}}, true, false},
+		{"status", map[string][]string{"status": {"exited"}}, false, false},
+		{"invalid regex", map[string][]string{"name": {"("}}, false, true},
+		{"unsupported", map[string][]string{"ancestor": {"alpine"}}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchFilters(app, tt.filters)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("matchFilters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("matchFilters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppleListAndInspect(t *testing.T) {
+	cli := &fakeCLI{
+		outputs: map[string]string{
+			"ls --format json --all": appleListJSON,
+			"inspect missing":        `Error: notFound: "container missing"`,
+		},
+		errs: map[string]error{
+			"inspect missing": newCommandError([]string{"inspect", "missing"}, `Error: notFound: "container missing"`, errors.New("exit status 1")),
+		},
+	}
+	adapter := appleAdapter{}
+
+	infos, err := adapter.List(cli, map[string][]string{"label": {"managed-by=packnplay"}}, true)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(infos) != 1 || infos[0].Name != "packnplay-app-main" {
+		t.Errorf("List() = %+v", infos)
+	}
+
+	_, err = adapter.Inspect(cli, "missing")
+	if !errors.Is(err, ErrNoSuchContainer) {
+		t.Errorf("Inspect() error = %v, want ErrNoSuchContainer", err)
+	}
+}
+
+func TestAppleCopyTo(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "token")
+	if err := os.WriteFile(file, []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "tree", "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tree", "sub", "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("file", func(t *testing.T) {
+		cli := &fakeCLI{}
+		if err := (appleAdapter{}).CopyTo(cli, file, "app", "/home/user/.token"); err != nil {
+			t.Fatalf("CopyTo() error = %v", err)
+		}
+		if len(cli.ran) != 1 || !strings.HasPrefix(cli.ran[0], "exec -i app sh -c ") || !strings.HasSuffix(cli.ran[0], " sh /home/user/.token 600") {
+			t.Errorf("ran %q", cli.ran)
+		}
+		if string(cli.input) != "secret" {
+			t.Errorf("input = %q", cli.input)
+		}
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		cli := &fakeCLI{}
+		if err := (appleAdapter{}).CopyTo(cli, filepath.Join(dir, "tree")+"/.", "app", "/workspace"); err != nil {
+			t.Fatalf("CopyTo() error = %v", err)
+		}
+		if len(cli.ran) != 1 || !strings.HasSuffix(cli.ran[0], " sh /workspace") {
+			t.Errorf("ran %q", cli.ran)
+		}
+		var names []string
+		tr := tar.NewReader(bytes.NewReader(cli.input))
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("reading tar: %v", err)
+			}
+			names = append(names, strings.TrimSuffix(header.Name, "/"))
+		}
+		if want := []string{"sub", "sub/a.txt"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("tar entries = %v, want %v", names, want)
+		}
+	})
+
+	t.Run("missing source", func(t *testing.T) {
+		if err := (appleAdapter{}).CopyTo(&fakeCLI{}, filepath.Join(dir, "nope"), "app", "/x"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
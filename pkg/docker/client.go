@@ -1,15 +1,31 @@
 package docker
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"sync"
 )
 
+// Runner runs container runtime commands. *Client implements it; packages that start
+// sidecars take a Runner so their tests can fake the runtime.
+type Runner interface {
+	Command() string
+	Run(args ...string) (string, error)
+}
+
 // Client handles Docker CLI interactions
 type Client struct {
 	cmd     string
 	verbose bool
+
+	rootlessOnce sync.Once
+	rootless     bool
+
+	engineOnce sync.Once
+	engine     *engine // nil when the CLI is used
 }
 
 // NewClient creates a new Docker client
@@ -19,6 +35,10 @@ func NewClient(verbose bool) (*Client, error) {
 
 // NewClientWithRuntime creates a client with a specific runtime preference
 func NewClientWithRuntime(preferredRuntime string, verbose bool) (*Client, error) {
+	if err := CheckNested(); err != nil {
+		return nil, err
+	}
+
 	client := &Client{verbose: verbose}
 
 	var cmd string
@@ -69,12 +89,30 @@ func (c *Client) DetectCLI() (string, error) {
 
 // Run executes a docker command
 func (c *Client) Run(args ...string) (string, error) {
-	// Translate Docker commands to Apple Container CLI if needed
-	if c.cmd == "container" {
-		args = c.translateToAppleContainer(args)
-	}
+	return c.RunWithInput(nil, args...)
+}
+
+// RunWithInput executes a docker command with input on its stdin
+func (c *Client) RunWithInput(input io.Reader, args ...string) (string, error) {
+	return c.run(input, nil, args)
+}
+
+// RunWithEnv executes a docker command with env (KEY=value) added to its environment,
+// leaving packnplay's own alone
+func (c *Client) RunWithEnv(env []string, args ...string) (string, error) {
+	return c.run(nil, env, args)
+}
+
+// run executes a docker command with input on its stdin and env added to its environment
+func (c *Client) run(input io.Reader, env []string, args []string) (string, error) {
+	// Translate Docker commands to the runtime's CLI
+	args = c.Adapter().TranslateArgs(args)
 
 	cmd := exec.Command(c.cmd, args...)
+	cmd.Stdin = input
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	if c.verbose {
 		fmt.Fprintf(os.Stderr, "+ %s %v\n", c.cmd, args)
@@ -86,70 +124,44 @@ func (c *Client) Run(args ...string) (string, error) {
 		fmt.Fprintf(os.Stderr, "%s\n", output)
 	}
 
-	return string(output), err
+	if err != nil {
+		return string(output), newCommandError(args, string(output), err)
+	}
+	return string(output), nil
 }
 
-// translateToAppleContainer translates Docker CLI args to Apple Container CLI
-func (c *Client) translateToAppleContainer(args []string) []string {
-	if len(args) == 0 {
-		return args
-	}
+// Output executes a docker command whose output is data, returning only its stdout;
+// stderr is kept for the error
+func (c *Client) Output(args ...string) (string, error) {
+	args = c.Adapter().TranslateArgs(args)
 
-	switch args[0] {
-	case "ps":
-		// Translate: ps -> ls (list)
-		newArgs := []string{"ls"}
-
-		// Apple Container doesn't support --filter or Go template format
-		// Remove --filter and --format args, use --format json instead
-		for i := 1; i < len(args); i++ {
-			if args[i] == "--filter" && i+1 < len(args) {
-				// Skip --filter and its value
-				i++
-				continue
-			}
-			if args[i] == "--format" && i+1 < len(args) {
-				// Skip --format and its template value
-				i++
-				continue
-			}
-			newArgs = append(newArgs, args[i])
-		}
+	cmd := exec.Command(c.cmd, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
 
-		// Always use json format for Apple Container
-		newArgs = append(newArgs, "--format", "json")
-		return newArgs
-
-	case "rm":
-		// Translate: rm -> delete
-		newArgs := []string{"delete"}
-		newArgs = append(newArgs, args[1:]...)
-		return newArgs
-
-	case "pull":
-		// Translate: pull -> image pull
-		newArgs := []string{"image", "pull"}
-		newArgs = append(newArgs, args[1:]...)
-		return newArgs
-
-	case "build":
-		// build stays as build (no translation needed)
-		return args
-
-	case "image":
-		// image commands need special handling
-		if len(args) > 1 && args[1] == "inspect" {
-			// image inspect -> images ls with filter by name
-			// For now, keep as-is and handle in response parsing
-			return args
-		}
-		return args
+	if c.verbose {
+		fmt.Fprintf(os.Stderr, "+ %s %v\n", c.cmd, args)
 	}
 
-	return args
+	if err := cmd.Run(); err != nil {
+		return stdout.String(), newCommandError(args, stderr.String(), err)
+	}
+	return stdout.String(), nil
 }
 
 // Command returns the docker command being used
 func (c *Client) Command() string {
 	return c.cmd
 }
+
+// RuntimeName is the runtime's name for messages
+func (c *Client) RuntimeName() string {
+	switch c.cmd {
+	case "container":
+		return "Apple Container"
+	case "podman":
+		return "Podman"
+	}
+	return "Docker"
+}
@@ -1,7 +1,9 @@
 package docker
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -53,3 +55,37 @@ func TestDetectDockerCLI(t *testing.T) {
 		})
 	}
 }
+
+func TestOutputKeepsStderrOut(t *testing.T) {
+	fake := filepath.Join(t.TempDir(), "docker")
+	script := "#!/bin/sh\necho data\necho warning >&2\n[ \"$1\" = ok ]\n"
+	if err := os.WriteFile(fake, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	client := &Client{cmd: fake}
+
+	if out, err := client.Output("ok"); err != nil || out != "data\n" {
+		t.Errorf("Output() = %q, %v, want only stdout", out, err)
+	}
+	_, err := client.Output("fail")
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Output != "warning\n" {
+		t.Errorf("Output() error = %#v, want stderr as its output", err)
+	}
+}
+
+func TestRunWithEnv(t *testing.T) {
+	fake := filepath.Join(t.TempDir(), "docker")
+	if err := os.WriteFile(fake, []byte("#!/bin/sh\necho \"$DOCKER_BUILDKIT\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	client := &Client{cmd: fake}
+	t.Setenv("DOCKER_BUILDKIT", "")
+
+	if out, err := client.RunWithEnv([]string{"DOCKER_BUILDKIT=1"}, "build"); err != nil || out != "1\n" {
+		t.Errorf("RunWithEnv() = %q, %v, want the variable set for the command", out, err)
+	}
+	if value := os.Getenv("DOCKER_BUILDKIT"); value != "" {
+		t.Errorf("DOCKER_BUILDKIT = %q in packnplay's own environment, want it left alone", value)
+	}
+}
@@ -0,0 +1,395 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The methods in this file inspect, list, start, stop and remove containers, run
+// one-off commands in them and follow their events. They use the Engine API when the
+// runtime's socket answers, and the CLI otherwise, through the runtime's adapter. Both
+// paths decode the daemon's own JSON rather than scraping formatted text. Creating
+// containers (docker run, whose flags are built up across packnplay) and interactive
+// sessions (docker exec -it) always go through the CLI.
+
+// ContainerInfo is the part of a container's inspect output packnplay reads
+type ContainerInfo struct {
+	ID              string          `json:"Id"`
+	Name            string          `json:"Name"` // without the leading slash the API adds
+	Created         string          `json:"Created"`
+	State           ContainerState  `json:"State"`
+	Config          ContainerConfig `json:"Config"`
+	NetworkSettings struct {
+		Networks map[string]ContainerNetwork `json:"Networks"`
+	} `json:"NetworkSettings"`
+	Mounts []ContainerMount `json:"Mounts"`
+}
+
+// ContainerState is whether a container is running and how it last exited
+type ContainerState struct {
+	Status     string `json:"Status"` // created, running, exited, ...
+	Running    bool   `json:"Running"`
+	ExitCode   int    `json:"ExitCode"`
+	StartedAt  string `json:"StartedAt"`
+	FinishedAt string `json:"FinishedAt"`
+}
+
+// ContainerConfig is what a container was created with
+type ContainerConfig struct {
+	Image      string            `json:"Image"`
+	Env        []string          `json:"Env"`
+	Labels     map[string]string `json:"Labels"`
+	User       string            `json:"User"`
+	WorkingDir string            `json:"WorkingDir"`
+}
+
+// ContainerNetwork is a container's attachment to one network
+type ContainerNetwork struct {
+	IPAddress string `json:"IPAddress"`
+}
+
+// ContainerMount is a volume or bind mount of a container
+type ContainerMount struct {
+	Type        string `json:"Type"`
+	Source      string `json:"Source"`
+	Destination string `json:"Destination"`
+}
+
+// Event is a daemon event, as the API streams it and docker events --format '{{json .}}'
+// prints it
+type Event struct {
+	Type   string `json:"Type"`
+	Action string `json:"Action"`
+	Actor  struct {
+		ID         string            `json:"ID"`
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+	TimeNano int64 `json:"timeNano"`
+}
+
+// Time returns when the event happened
+func (e Event) Time() time.Time {
+	return time.Unix(0, e.TimeNano)
+}
+
+// api returns the Engine API client for the runtime, or nil when the CLI must be used:
+// for runtimes without the API (Apple Container), with PACKNPLAY_DOCKER_CLI set, or when
+// the socket the CLI would use doesn't answer. The answer is found once and cached.
+func (c *Client) api() *engine {
+	c.engineOnce.Do(func() {
+		if !c.Capabilities().EngineAPI || os.Getenv("PACKNPLAY_DOCKER_CLI") != "" {
+			return
+		}
+		homeDir, _ := os.UserHomeDir()
+		socket := engineSocket(c.cmd, os.Getenv, homeDir, os.Getuid())
+		if socket == "" {
+			return
+		}
+		e := newEngine(socket, c.verbose)
+		if err := e.ping(); err != nil {
+			if c.verbose {
+				fmt.Fprintf(os.Stderr, "Engine API unavailable at %s, using the %s CLI: %v\n", socket, c.cmd, err)
+			}
+			return
+		}
+		c.engine = e
+	})
+	return c.engine
+}
+
+// InspectContainer returns what the runtime knows about a container, by name or ID. A
+// missing container is reported as ErrNoSuchContainer.
+func (c *Client) InspectContainer(nameOrID string) (*ContainerInfo, error) {
+	var info ContainerInfo
+	if e := c.api(); e != nil {
+		if err := e.call(http.MethodGet, "/containers/"+url.PathEscape(nameOrID)+"/json", nil, nil, &info); err != nil {
+			return nil, err
+		}
+	} else {
+		cliInfo, err := c.Adapter().Inspect(c, nameOrID)
+		if err != nil {
+			return nil, err
+		}
+		info = *cliInfo
+	}
+	info.Name = strings.TrimPrefix(info.Name, "/")
+	return &info, nil
+}
+
+// ListContainers returns the containers matching filters (as for docker ps --filter,
+// e.g. {"label": {"managed-by=packnplay"}}), running ones only unless all is set,
+// sorted by name
+func (c *Client) ListContainers(filters map[string][]string, all bool) ([]ContainerInfo, error) {
+	var ids []string
+	if e := c.api(); e != nil {
+		query := url.Values{"filters": {filtersQuery(filters)}}
+		if all {
+			query.Set("all", "1")
+		}
+		var summaries []struct {
+			ID string `json:"Id"`
+		}
+		if err := e.call(http.MethodGet, "/containers/json", query, nil, &summaries); err != nil {
+			return nil, err
+		}
+		for _, s := range summaries {
+			ids = append(ids, s.ID)
+		}
+	} else {
+		infos, err := c.Adapter().List(c, filters, all)
+		if err != nil {
+			return nil, err
+		}
+		sortByName(infos)
+		return infos, nil
+	}
+
+	infos := make([]ContainerInfo, 0, len(ids))
+	for _, id := range ids {
+		// A container removed since it was listed is left out
+		info, err := c.InspectContainer(id)
+		if errors.Is(err, ErrNoSuchContainer) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, *info)
+	}
+	sortByName(infos)
+	return infos, nil
+}
+
+// sortByName sorts containers by name
+func sortByName(infos []ContainerInfo) {
+	for i := range infos {
+		infos[i].Name = strings.TrimPrefix(infos[i].Name, "/")
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+}
+
+// filterArgs turns filters into --filter flags, in a stable order
+func filterArgs(filters map[string][]string) []string {
+	keys := make([]string, 0, len(filters))
+	for key := range filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var args []string
+	for _, key := range keys {
+		for _, value := range filters[key] {
+			args = append(args, "--filter", key+"="+value)
+		}
+	}
+	return args
+}
+
+// StartContainer starts a stopped container
+func (c *Client) StartContainer(nameOrID string) error {
+	if e := c.api(); e != nil {
+		return e.call(http.MethodPost, "/containers/"+url.PathEscape(nameOrID)+"/start", nil, nil, nil)
+	}
+	_, err := c.Run("start", nameOrID)
+	return err
+}
+
+// StopContainer stops a container, killing it after timeout; a negative timeout uses
+// the container's own stop timeout
+func (c *Client) StopContainer(nameOrID string, timeout time.Duration) error {
+	if e := c.api(); e != nil {
+		var query url.Values
+		if timeout >= 0 {
+			query = url.Values{"t": {strconv.Itoa(int(timeout.Seconds()))}}
+		}
+		return e.call(http.MethodPost, "/containers/"+url.PathEscape(nameOrID)+"/stop", query, nil, nil)
+	}
+	args := []string{"stop"}
+	if timeout >= 0 {
+		args = append(args, "-t", strconv.Itoa(int(timeout.Seconds())))
+	}
+	_, err := c.Run(append(args, nameOrID)...)
+	return err
+}
+
+// RemoveContainer removes a container; force removes it even while running, and
+// volumes also removes its anonymous volumes
+func (c *Client) RemoveContainer(nameOrID string, force, volumes bool) error {
+	if e := c.api(); e != nil {
+		query := url.Values{}
+		if force {
+			query.Set("force", "1")
+		}
+		if volumes {
+			query.Set("v", "1")
+		}
+		return e.call(http.MethodDelete, "/containers/"+url.PathEscape(nameOrID), query, nil, nil)
+	}
+	args := []string{"rm"}
+	if force {
+		args = append(args, "-f")
+	}
+	if volumes {
+		args = append(args, "-v")
+	}
+	_, err := c.Run(append(args, nameOrID)...)
+	return err
+}
+
+// ExecOutput runs command in a running container as user (the container's user when
+// empty) without a terminal, and returns its combined output and exit code. err is only
+// set when the command couldn't be run.
+func (c *Client) ExecOutput(nameOrID, user string, command ...string) (string, int, error) {
+	e := c.api()
+	if e == nil {
+		args := []string{"exec"}
+		if user != "" {
+			args = append(args, "-u", user)
+		}
+		args = append(append(args, nameOrID), command...)
+		output, err := c.Run(args...)
+		var cmdErr *CommandError
+		var exitErr *exec.ExitError
+		if errors.As(err, &cmdErr) && cmdErr.Kind == nil && errors.As(err, &exitErr) {
+			return output, exitErr.ExitCode(), nil
+		}
+		return output, 0, err
+	}
+
+	var created struct {
+		ID string `json:"Id"`
+	}
+	err := e.call(http.MethodPost, "/containers/"+url.PathEscape(nameOrID)+"/exec", nil, map[string]any{
+		"AttachStdout": true,
+		"AttachStderr": true,
+		"Cmd":          command,
+		"User":         user,
+	}, &created)
+	if err != nil {
+		return "", 0, err
+	}
+
+	resp, err := e.do(context.Background(), http.MethodPost, "/exec/"+created.ID+"/start", nil, map[string]any{"Detach": false, "Tty": false})
+	if err != nil {
+		return "", 0, err
+	}
+	var output strings.Builder
+	err = demuxStream(resp.Body, &output)
+	resp.Body.Close()
+	if err != nil {
+		return output.String(), 0, fmt.Errorf("failed to read exec output: %w", err)
+	}
+
+	var result struct {
+		ExitCode int `json:"ExitCode"`
+	}
+	if err := e.call(http.MethodGet, "/exec/"+created.ID+"/json", nil, nil, &result); err != nil {
+		return output.String(), 0, err
+	}
+	return output.String(), result.ExitCode, nil
+}
+
+// demuxStream copies the payload of a multiplexed stdout/stderr stream to w. Each frame
+// has an 8-byte header: the stream in the first byte and the payload size, big-endian,
+// in the last four.
+func demuxStream(r io.Reader, w io.Writer) error {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.CopyN(w, r, size); err != nil {
+			return err
+		}
+	}
+}
+
+// Events calls handle with each daemon event matching filters (as for docker events
+// --filter, e.g. {"type": {"container"}}) until ctx is done or the stream ends
+func (c *Client) Events(ctx context.Context, filters map[string][]string, handle func(Event)) error {
+	if e := c.api(); e != nil {
+		resp, err := e.do(ctx, http.MethodGet, "/events", url.Values{"filters": {filtersQuery(filters)}}, nil)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		return decodeEvents(ctx, resp.Body, handle)
+	}
+
+	if !c.Capabilities().Events {
+		return fmt.Errorf("%s has no event stream", c.RuntimeName())
+	}
+	args := append([]string{"events", "--format", "{{json .}}"}, filterArgs(filters)...)
+	if c.verbose {
+		fmt.Fprintf(os.Stderr, "+ %s %v\n", c.cmd, args)
+	}
+	cmd := exec.CommandContext(ctx, c.cmd, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s events: %w", c.cmd, err)
+	}
+	err = decodeEvents(ctx, stdout, handle)
+	_ = cmd.Wait()
+	return err
+}
+
+// decodeEvents reads one JSON event per line. Podman prints its own event format, whose
+// ID, Name and Status are mapped onto the API's.
+func decodeEvents(ctx context.Context, r io.Reader, handle func(Event)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			continue
+		}
+		if event.Action == "" {
+			var podman struct {
+				ID         string            `json:"ID"`
+				Name       string            `json:"Name"`
+				Status     string            `json:"Status"`
+				Type       string            `json:"Type"`
+				Attributes map[string]string `json:"Attributes"`
+			}
+			if json.Unmarshal(line, &podman) != nil || podman.Status == "" {
+				continue
+			}
+			event.Type, event.Action, event.Actor.ID = podman.Type, podman.Status, podman.ID
+			event.Actor.Attributes = podman.Attributes
+			if event.Actor.Attributes == nil {
+				event.Actor.Attributes = map[string]string{}
+			}
+			if podman.Name != "" {
+				event.Actor.Attributes["name"] = podman.Name
+			}
+		}
+		if event.TimeNano == 0 {
+			event.TimeNano = time.Now().UnixNano()
+		}
+		handle(event)
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,138 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// frame encodes payload as one frame of a multiplexed stdout/stderr stream
+func frame(stream byte, payload string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return append(header, payload...)
+}
+
+func TestContainersOverEngineAPI(t *testing.T) {
+	var requests []string
+	mux := http.NewServeMux()
+	record := func(r *http.Request) { requests = append(requests, r.Method+" "+r.URL.RequestURI()) }
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		var filters map[string][]string
+		_ = json.Unmarshal([]byte(r.URL.Query().Get("filters")), &filters)
+		if !reflect.DeepEqual(filters, map[string][]string{"label": {"managed-by=packnplay"}}) {
+			t.Errorf("list filters = %v", filters)
+		}
+		_, _ = w.Write([]byte(`[{"Id":"bbb"},{"Id":"aaa"}]`))
+	})
+	mux.HandleFunc("/containers/", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		switch r.URL.Path {
+		case "/containers/aaa/json", "/containers/packnplay-app-main/json":
+			_, _ = w.Write([]byte(`{"Id":"aaa","Name":"/packnplay-app-main","State":{"Status":"running","Running":true},
+				"Config":{"Env":["PATH=/usr/bin"],"Labels":{"managed-by":"packnplay"}},
+				"NetworkSettings":{"Networks":{"bridge":{"IPAddress":"172.17.0.2"}}}}`))
+		case "/containers/bbb/json":
+			_, _ = w.Write([]byte(`{"Id":"bbb","Name":"/packnplay-app-feature","State":{"Status":"exited"}}`))
+		case "/containers/aaa/exec":
+			_, _ = w.Write([]byte(`{"Id":"exec1"}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	mux.HandleFunc("/exec/", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		if strings.HasSuffix(r.URL.Path, "/start") {
+			_, _ = w.Write(append(frame(1, "hello "), frame(2, "world\n")...))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ExitCode":3}`))
+	})
+	client := serveEngine(t, mux)
+
+	info, err := client.InspectContainer("packnplay-app-main")
+	if err != nil {
+		t.Fatalf("InspectContainer() error = %v", err)
+	}
+	if info.ID != "aaa" || info.Name != "packnplay-app-main" || !info.State.Running ||
+		info.Config.Labels["managed-by"] != "packnplay" || info.NetworkSettings.Networks["bridge"].IPAddress != "172.17.0.2" {
+		t.Errorf("InspectContainer() = %+v", info)
+	}
+
+	list, err := client.ListContainers(map[string][]string{"label": {"managed-by=packnplay"}}, true)
+	if err != nil {
+		t.Fatalf("ListContainers() error = %v", err)
+	}
+	if len(list) != 2 || list[0].Name != "packnplay-app-feature" || list[1].Name != "packnplay-app-main" {
+		t.Errorf("ListContainers() = %+v, want both sorted by name", list)
+	}
+
+	requests = nil
+	if err := client.StopContainer("aaa", 5*time.Second); err != nil {
+		t.Errorf("StopContainer() error = %v", err)
+	}
+	if err := client.RemoveContainer("aaa", true, true); err != nil {
+		t.Errorf("RemoveContainer() error = %v", err)
+	}
+	if err := client.StartContainer("aaa"); err != nil {
+		t.Errorf("StartContainer() error = %v", err)
+	}
+	want := []string{"POST /containers/aaa/stop?t=5", "DELETE /containers/aaa?force=1&v=1", "POST /containers/aaa/start"}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %q, want %q", requests, want)
+	}
+
+	output, code, err := client.ExecOutput("aaa", "root", "sh", "-c", "exit 3")
+	if err != nil || output != "hello world\n" || code != 3 {
+		t.Errorf("ExecOutput() = %q, %d, %v", output, code, err)
+	}
+}
+
+func TestDemuxStream(t *testing.T) {
+	var out bytes.Buffer
+	stream := append(frame(1, "out"), frame(2, "err")...)
+	if err := demuxStream(bytes.NewReader(stream), &out); err != nil || out.String() != "outerr" {
+		t.Errorf("demuxStream() = %q, %v", out.String(), err)
+	}
+	if err := demuxStream(bytes.NewReader(stream[:5]), &out); err == nil {
+		t.Error("demuxStream() accepted a truncated header")
+	}
+}
+
+func TestDecodeEvents(t *testing.T) {
+	stream := strings.Join([]string{
+		`{"status":"start","id":"aaa","Type":"container","Action":"start","Actor":{"ID":"aaa","Attributes":{"name":"packnplay-app-main"}},"timeNano":1700000000000000000}`,
+		`not json`,
+		`{"ID":"bbb","Name":"packnplay-app-feature","Status":"die","Type":"container","Attributes":{"managed-by":"packnplay"}}`,
+	}, "\n")
+
+	var events []Event
+	if err := decodeEvents(context.Background(), strings.NewReader(stream), func(e Event) { events = append(events, e) }); err != nil {
+		t.Fatalf("decodeEvents() error = %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("decodeEvents() = %+v, want 2 events", events)
+	}
+	if events[0].Action != "start" || events[0].Actor.Attributes["name"] != "packnplay-app-main" || events[0].Time().Unix() != 1700000000 {
+		t.Errorf("docker event = %+v", events[0])
+	}
+	if events[1].Action != "die" || events[1].Actor.ID != "bbb" || events[1].Actor.Attributes["name"] != "packnplay-app-feature" {
+		t.Errorf("podman event = %+v", events[1])
+	}
+}
+
+func TestFilterArgs(t *testing.T) {
+	got := filterArgs(map[string][]string{"type": {"container"}, "label": {"managed-by=packnplay", "x=y"}})
+	want := []string{"--filter", "label=managed-by=packnplay", "--filter", "label=x=y", "--filter", "type=container"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("filterArgs() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,213 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// engine talks to the Docker Engine API over a unix socket. Podman's compatibility
+// socket serves the same API. Paths are unversioned, so the daemon answers with its
+// own API version.
+type engine struct {
+	socket  string
+	http    *http.Client
+	verbose bool
+}
+
+// pingTimeout bounds how long the first request may take before Client falls back to
+// the CLI
+const pingTimeout = 2 * time.Second
+
+func newEngine(socket string, verbose bool) *engine {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}
+	return &engine{socket: socket, http: &http.Client{Transport: transport}, verbose: verbose}
+}
+
+// ping reports whether the daemon answers on the socket
+func (e *engine) ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	resp, err := e.do(ctx, http.MethodGet, "/_ping", nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do sends a request and returns the response when its status is below 400. Errors
+// from the daemon come back as a *CommandError classified like CLI output.
+func (e *engine) do(ctx context.Context, method, path string, query url.Values, body any) (*http.Response, error) {
+	target := path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	if e.verbose {
+		fmt.Fprintf(os.Stderr, "+ %s %s (%s)\n", method, target, e.socket)
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, "http://docker"+target, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := e.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		return nil, apiError(method, path, resp)
+	}
+	return resp, nil
+}
+
+// call sends a request and decodes the JSON response into out, if out isn't nil
+func (e *engine) call(method, path string, query url.Values, body, out any) error {
+	resp, err := e.do(context.Background(), method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+// apiError turns an error response into a *CommandError. The daemon's message is
+// classified the way CLI output is; a 404 on a container path that wasn't recognized
+// still means the container doesn't exist.
+func apiError(method, path string, resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	var body struct {
+		Message string `json:"message"`
+	}
+	message := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &body) == nil && body.Message != "" {
+		message = body.Message
+	}
+
+	err := newCommandError([]string{method, path}, message, fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, message))
+	if err.Kind == nil && resp.StatusCode == http.StatusNotFound && strings.HasPrefix(path, "/containers/") {
+		err.Kind = ErrNoSuchContainer
+	}
+	return err
+}
+
+// filtersQuery encodes filters the way the API takes them: a JSON object of lists
+func filtersQuery(filters map[string][]string) string {
+	data, _ := json.Marshal(filters)
+	return string(data)
+}
+
+// engineSocket returns the unix socket the runtime's CLI would talk to, or "" when it
+// talks to something else (a tcp or ssh DOCKER_HOST, a remote Podman connection)
+func engineSocket(runtime string, getenv func(string) string, homeDir string, uid int) string {
+	switch runtime {
+	case "docker":
+		return unixPath(dockerHost(getenv, homeDir))
+	case "podman":
+		if host := getenv("CONTAINER_HOST"); host != "" {
+			return unixPath(host)
+		}
+		if getenv("CONTAINER_CONNECTION") != "" {
+			return ""
+		}
+		if uid == 0 {
+			return "/run/podman/podman.sock"
+		}
+		runtimeDir := getenv("XDG_RUNTIME_DIR")
+		if runtimeDir == "" {
+			return ""
+		}
+		return filepath.Join(runtimeDir, "podman", "podman.sock")
+	}
+	return ""
+}
+
+// defaultDockerHost is where the docker CLI connects without DOCKER_HOST or a context
+const defaultDockerHost = "unix:///var/run/docker.sock"
+
+// dockerHost resolves the daemon address the way the docker CLI does: DOCKER_HOST, then
+// the DOCKER_CONTEXT or current context's endpoint, then the default socket
+func dockerHost(getenv func(string) string, homeDir string) string {
+	if host := getenv("DOCKER_HOST"); host != "" {
+		return host
+	}
+
+	configDir := getenv("DOCKER_CONFIG")
+	if configDir == "" {
+		configDir = filepath.Join(homeDir, ".docker")
+	}
+	name := getenv("DOCKER_CONTEXT")
+	if name == "" {
+		var cliConfig struct {
+			CurrentContext string `json:"currentContext"`
+		}
+		if data, err := os.ReadFile(filepath.Join(configDir, "config.json")); err == nil {
+			_ = json.Unmarshal(data, &cliConfig)
+		}
+		name = cliConfig.CurrentContext
+	}
+	if name == "" || name == "default" {
+		return defaultDockerHost
+	}
+
+	// Context metadata lives in a directory named for the SHA-256 of the context name
+	digest := sha256.Sum256([]byte(name))
+	data, err := os.ReadFile(filepath.Join(configDir, "contexts", "meta", hex.EncodeToString(digest[:]), "meta.json"))
+	if err != nil {
+		return ""
+	}
+	var meta struct {
+		Endpoints map[string]struct {
+			Host string `json:"Host"`
+		} `json:"Endpoints"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return ""
+	}
+	return meta.Endpoints["docker"].Host
+}
+
+// unixPath returns the socket path of a unix:// address, or "" for any other address
+func unixPath(host string) string {
+	path, ok := strings.CutPrefix(host, "unix://")
+	if !ok {
+		return ""
+	}
+	return path
+}
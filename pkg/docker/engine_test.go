@@ -0,0 +1,118 @@
+package docker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// serveEngine serves handler on a unix socket and returns a client that uses it
+func serveEngine(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+	// Socket paths are limited to about 100 bytes, so t.TempDir() may be too long
+	dir, err := os.MkdirTemp("", "pnp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "d.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: handler}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { server.Close() })
+
+	client := &Client{cmd: "docker"}
+	client.engineOnce.Do(func() {})
+	client.engine = newEngine(socket, false)
+	return client
+}
+
+func TestEnginePingAndErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/_ping", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("OK")) })
+	mux.HandleFunc("/containers/gone/json", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"No such container: gone"}`))
+	})
+	mux.HandleFunc("/containers/odd/json", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"something else"}`))
+	})
+	client := serveEngine(t, mux)
+
+	if err := client.engine.ping(); err != nil {
+		t.Fatalf("ping() error = %v", err)
+	}
+	for _, name := range []string{"gone", "odd"} {
+		_, err := client.InspectContainer(name)
+		if !errors.Is(err, ErrNoSuchContainer) {
+			t.Errorf("InspectContainer(%q) error = %v, want ErrNoSuchContainer", name, err)
+		}
+	}
+	if err := newEngine(filepath.Join(t.TempDir(), "missing.sock"), false).ping(); err == nil {
+		t.Error("ping() of a missing socket succeeded")
+	}
+}
+
+func TestEngineSocket(t *testing.T) {
+	home := t.TempDir()
+
+	// A context's metadata lives under the SHA-256 of its name
+	contextDir := filepath.Join(home, ".docker", "contexts", "meta", sha256Hex("desktop-linux"))
+	if err := os.MkdirAll(contextDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	meta := `{"Name":"desktop-linux","Endpoints":{"docker":{"Host":"unix:///Users/me/.docker/run/docker.sock"}}}`
+	if err := os.WriteFile(filepath.Join(contextDir, "meta.json"), []byte(meta), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		runtime string
+		env     map[string]string
+		config  string // ~/.docker/config.json
+		uid     int
+		want    string
+	}{
+		{name: "default", runtime: "docker", uid: 1000, want: "/var/run/docker.sock"},
+		{name: "DOCKER_HOST unix", runtime: "docker", env: map[string]string{"DOCKER_HOST": "unix:///run/user/1000/docker.sock"}, want: "/run/user/1000/docker.sock"},
+		{name: "DOCKER_HOST tcp", runtime: "docker", env: map[string]string{"DOCKER_HOST": "tcp://build:2376"}, want: ""},
+		{name: "current context", runtime: "docker", config: `{"currentContext":"desktop-linux"}`, want: "/Users/me/.docker/run/docker.sock"},
+		{name: "DOCKER_CONTEXT wins", runtime: "docker", env: map[string]string{"DOCKER_CONTEXT": "default"}, config: `{"currentContext":"desktop-linux"}`, want: "/var/run/docker.sock"},
+		{name: "unknown context", runtime: "docker", env: map[string]string{"DOCKER_CONTEXT": "remote"}, want: ""},
+		{name: "podman rootless", runtime: "podman", env: map[string]string{"XDG_RUNTIME_DIR": "/run/user/1000"}, uid: 1000, want: "/run/user/1000/podman/podman.sock"},
+		{name: "podman root", runtime: "podman", uid: 0, want: "/run/podman/podman.sock"},
+		{name: "podman remote connection", runtime: "podman", env: map[string]string{"CONTAINER_CONNECTION": "machine"}, uid: 1000, want: ""},
+		{name: "podman CONTAINER_HOST", runtime: "podman", env: map[string]string{"CONTAINER_HOST": "unix:///tmp/podman.sock"}, uid: 1000, want: "/tmp/podman.sock"},
+		{name: "apple container", runtime: "container", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(home, ".docker", "config.json")
+			_ = os.Remove(configPath)
+			if tt.config != "" {
+				if err := os.WriteFile(configPath, []byte(tt.config), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			getenv := func(key string) string { return tt.env[key] }
+			if got := engineSocket(tt.runtime, getenv, home, tt.uid); got != tt.want {
+				t.Errorf("engineSocket() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func sha256Hex(s string) string {
+	digest := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(digest[:])
+}
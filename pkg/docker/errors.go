@@ -0,0 +1,145 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Errors the daemon commonly reports, recognized from a failed command's output. Run
+// returns a *CommandError that matches one of them with errors.Is when it can tell.
+var (
+	ErrDaemonUnreachable = errors.New("cannot connect to the container daemon")
+	ErrPermissionDenied  = errors.New("permission denied on the container daemon socket")
+	ErrNameInUse         = errors.New("container name is already in use")
+	ErrNoSuchContainer   = errors.New("no such container")
+	ErrNotRunning        = errors.New("container is not running")
+	ErrPortAllocated     = errors.New("port is already allocated")
+	ErrImageNotFound     = errors.New("image not found")
+	ErrRegistryAuth      = errors.New("registry authentication required")
+	ErrNoSpace           = errors.New("no space left on device")
+)
+
+// errorPatterns maps lowercased fragments of daemon output to the error they mean, most
+// specific first: a pull that needs a login also says the repository may not exist
+var errorPatterns = []struct {
+	kind     error
+	patterns []string
+}{
+	{ErrPermissionDenied, []string{"permission denied while trying to connect"}},
+	{ErrDaemonUnreachable, []string{"cannot connect to the docker daemon", "is the docker daemon running", "cannot connect to podman", "unable to connect to podman"}},
+	{ErrNameInUse, []string{"is already in use by container", "that name is already in use"}},
+	{ErrNoSuchContainer, []string{"no such container", "no container with name or id", "no such object"}},
+	{ErrNotRunning, []string{"is not running", "can only create exec sessions on running containers"}},
+	{ErrPortAllocated, []string{"port is already allocated", "address already in use"}},
+	{ErrRegistryAuth, []string{"unauthorized: authentication required", "authentication required", "no basic auth credentials"}},
+	{ErrImageNotFound, []string{"pull access denied", "manifest unknown", "repository does not exist", "no such image", "image not known"}},
+	{ErrNoSpace, []string{"no space left on device"}},
+}
+
+// CommandError is a docker command that failed, with what it printed. Error keeps the
+// exec error's message unless the output was recognized, so callers that already print
+// the output don't repeat it.
+type CommandError struct {
+	Args   []string
+	Output string
+	Err    error  // the exec error
+	Kind   error  // one of the Err* errors above, or nil when the output wasn't recognized
+	Detail string // the container name or port the error is about, when the output says
+}
+
+func (e *CommandError) Error() string {
+	if e.Kind == nil {
+		return e.Err.Error()
+	}
+	if e.Detail != "" {
+		return fmt.Sprintf("%v: %s", e.Kind, e.Detail)
+	}
+	return e.Kind.Error()
+}
+
+func (e *CommandError) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+var (
+	nameInUseRe    = regexp.MustCompile(`container name "/?([^"]+)" is already in use`)
+	portRe         = regexp.MustCompile(`(?:[\d.]+|\[[0-9a-f:]*\]):(\d+)`)
+	noSuchTargetRe = regexp.MustCompile(`(?i)no such (?:container|object): "?([^"\s]+)`)
+)
+
+// newCommandError classifies the output of a failed command
+func newCommandError(args []string, output string, err error) *CommandError {
+	e := &CommandError{Args: args, Output: output, Err: err, Kind: Classify(output)}
+	switch e.Kind {
+	case ErrNameInUse:
+		if m := nameInUseRe.FindStringSubmatch(output); m != nil {
+			e.Detail = m[1]
+		}
+	case ErrNoSuchContainer:
+		if m := noSuchTargetRe.FindStringSubmatch(output); m != nil {
+			e.Detail = m[1]
+		}
+	case ErrPortAllocated:
+		if m := portRe.FindStringSubmatch(output); m != nil {
+			e.Detail = m[1]
+		}
+	}
+	return e
+}
+
+// Classify returns the Err* error daemon output reports, or nil if it isn't recognized
+func Classify(output string) error {
+	lower := strings.ToLower(output)
+	for _, p := range errorPatterns {
+		for _, pattern := range p.patterns {
+			if strings.Contains(lower, pattern) {
+				return p.kind
+			}
+		}
+	}
+	return nil
+}
+
+// Hint returns what the user can do about err, or "" when err isn't a recognized
+// daemon error
+func Hint(err error) string {
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) {
+		return ""
+	}
+	switch cmdErr.Kind {
+	case ErrDaemonUnreachable:
+		if strings.Contains(strings.ToLower(cmdErr.Output), "podman") {
+			return "Start the Podman VM with podman machine start (macOS and Windows), or the Podman socket with: systemctl --user start podman.socket"
+		}
+		return "Start Docker Desktop or the docker service (sudo systemctl start docker), or check that DOCKER_HOST points at a running daemon"
+	case ErrPermissionDenied:
+		return "Add yourself to the docker group (sudo usermod -aG docker $USER) and log in again, or use rootless Docker or Podman"
+	case ErrNameInUse:
+		if cmdErr.Detail != "" {
+			return fmt.Sprintf("A container named %s already exists; reconnect with packnplay run --reconnect, or remove it with: packnplay stop %s", cmdErr.Detail, cmdErr.Detail)
+		}
+		return "A container with this name already exists; reconnect with packnplay run --reconnect, or remove it with packnplay stop"
+	case ErrNoSuchContainer:
+		return "The container is gone; see what is running with: packnplay list"
+	case ErrNotRunning:
+		return "The container has stopped; start it again with packnplay run"
+	case ErrPortAllocated:
+		if cmdErr.Detail != "" {
+			return fmt.Sprintf("Something else is using host port %s; stop it, or publish the container port on another host port with -p", cmdErr.Detail)
+		}
+		return "Something else is using that host port; stop it, or publish on another host port with -p"
+	case ErrImageNotFound:
+		return "Check the image name and tag; if the image is private, log in to its registry with docker login"
+	case ErrRegistryAuth:
+		return "Log in to the registry with docker login <registry>"
+	case ErrNoSpace:
+		return "Free up disk space with packnplay prune and docker system prune"
+	}
+	return ""
+}
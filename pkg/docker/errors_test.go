@@ -0,0 +1,72 @@
+package docker
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestNewCommandError(t *testing.T) {
+	exitErr := exec.Command("false").Run()
+
+	tests := []struct {
+		name       string
+		output     string
+		wantKind   error
+		wantDetail string
+		wantHint   string
+	}{
+		{"daemon down", "Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?", ErrDaemonUnreachable, "", "systemctl start docker"},
+		{"podman vm down", "Cannot connect to Podman. Please verify your connection to the Linux system using `podman system connection list`, or try `podman machine init` and `podman machine start` to manage a new Linux VM", ErrDaemonUnreachable, "", "podman machine start"},
+		{"socket permissions", "permission denied while trying to connect to the Docker daemon socket at unix:///var/run/docker.sock", ErrPermissionDenied, "", "docker group"},
+		{"name in use", `docker: Error response from daemon: Conflict. The container name "/packnplay-app-main" is already in use by container "4f2a". You have to remove (or rename) that container to be able to reuse that name.`, ErrNameInUse, "packnplay-app-main", "packnplay stop packnplay-app-main"},
+		{"no such container", "Error response from daemon: No such container: packnplay-app-main", ErrNoSuchContainer, "packnplay-app-main", "packnplay list"},
+		{"inspect of a missing object", "Error: No such object: packnplay-app-main", ErrNoSuchContainer, "packnplay-app-main", "packnplay list"},
+		{"podman missing container", `Error: no container with name or ID "packnplay-app-main" found: no such container`, ErrNoSuchContainer, "", "packnplay list"},
+		{"stopped container", "Error response from daemon: Container 4f2a is not running", ErrNotRunning, "", "packnplay run"},
+		{"port allocated", "docker: Error response from daemon: driver failed programming external connectivity on endpoint packnplay-app-main (9c1d): Bind for 0.0.0.0:8080 failed: port is already allocated.", ErrPortAllocated, "8080", "host port 8080"},
+		{"private image", "Error response from daemon: pull access denied for acme/private, repository does not exist or may require 'docker login': denied: requested access to the resource is denied", ErrImageNotFound, "", "docker login"},
+		{"missing tag", "Error response from daemon: manifest for node:99 not found: manifest unknown: manifest unknown", ErrImageNotFound, "", "image name and tag"},
+		{"registry login", "Error response from daemon: Head \"https://ghcr.io/v2/acme/app/manifests/latest\": unauthorized: authentication required", ErrRegistryAuth, "", "docker login"},
+		{"disk full", "write /var/lib/docker/tmp/GetImageBlob123: no space left on device", ErrNoSpace, "", "prune"},
+		{"unrecognized", "Error response from daemon: something new went wrong", nil, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newCommandError([]string{"run"}, tt.output, exitErr)
+			if err.Kind != tt.wantKind || err.Detail != tt.wantDetail {
+				t.Errorf("newCommandError() = %v %q, want %v %q", err.Kind, err.Detail, tt.wantKind, tt.wantDetail)
+			}
+			if tt.wantKind != nil && !errors.Is(err, tt.wantKind) {
+				t.Errorf("errors.Is(err, %v) = false", tt.wantKind)
+			}
+			var target *exec.ExitError
+			if !errors.As(err, &target) {
+				t.Error("the exec error should still be reachable with errors.As")
+			}
+
+			wrapped := errors.Join(errors.New("failed to start container"), err)
+			hint := Hint(wrapped)
+			if (tt.wantHint == "") != (hint == "") || !strings.Contains(hint, tt.wantHint) {
+				t.Errorf("Hint() = %q, want one mentioning %q", hint, tt.wantHint)
+			}
+		})
+	}
+}
+
+func TestCommandErrorMessage(t *testing.T) {
+	exitErr := exec.Command("false").Run()
+
+	unrecognized := newCommandError(nil, "something new", exitErr)
+	if unrecognized.Error() != exitErr.Error() {
+		t.Errorf("Error() = %q, want the exec error's message", unrecognized.Error())
+	}
+	gone := newCommandError(nil, "Error response from daemon: No such container: app", exitErr)
+	if got := gone.Error(); got != "no such container: app" {
+		t.Errorf("Error() = %q", got)
+	}
+	if Hint(errors.New("plain")) != "" {
+		t.Error("Hint() of an error that isn't a docker command error should be empty")
+	}
+}
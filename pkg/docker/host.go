@@ -0,0 +1,39 @@
+package docker
+
+import (
+	"os"
+	"strings"
+)
+
+// DaemonHost returns the address of the daemon the docker CLI talks to: DOCKER_HOST, or
+// the endpoint of DOCKER_CONTEXT or the current context. It is "" when a context is
+// named but can't be read.
+func DaemonHost() string {
+	homeDir, _ := os.UserHomeDir()
+	return dockerHost(os.Getenv, homeDir)
+}
+
+// IsRemoteHost reports whether a daemon address is on another machine, where host paths
+// can't be bind mounted. Unix sockets and Windows named pipes are local; so are tcp
+// addresses on the loopback interface, which Docker Desktop and tunnels use.
+func IsRemoteHost(host string) bool {
+	scheme, rest, ok := strings.Cut(host, "://")
+	if !ok {
+		return false
+	}
+	switch scheme {
+	case "ssh":
+		return true
+	case "tcp", "http", "https":
+		address := rest
+		if i := strings.IndexAny(address, "/?"); i != -1 {
+			address = address[:i]
+		}
+		if i := strings.LastIndex(address, ":"); i != -1 && !strings.HasSuffix(address, "]") {
+			address = address[:i]
+		}
+		address = strings.Trim(address, "[]")
+		return address != "localhost" && address != "::1" && !strings.HasPrefix(address, "127.")
+	}
+	return false
+}
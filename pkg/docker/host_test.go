@@ -0,0 +1,58 @@
+package docker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsRemoteHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"", false},
+		{"unix:///var/run/docker.sock", false},
+		{"npipe:////./pipe/docker_engine", false},
+		{"tcp://127.0.0.1:2375", false},
+		{"tcp://localhost:2376", false},
+		{"tcp://[::1]:2375", false},
+		{"tcp://build-01:2376", true},
+		{"tcp://10.0.0.5:2375", true},
+		{"tcp://[2001:db8::1]:2376", true},
+		{"https://docker.example.com/v1.43", true},
+		{"ssh://dev@build-01", true},
+	}
+	for _, tt := range tests {
+		if got := IsRemoteHost(tt.host); got != tt.want {
+			t.Errorf("IsRemoteHost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestDaemonHostFollowsContext(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("DOCKER_HOST", "")
+	t.Setenv("DOCKER_CONFIG", configDir)
+	t.Setenv("DOCKER_CONTEXT", "build")
+
+	digest := sha256.Sum256([]byte("build"))
+	metaDir := filepath.Join(configDir, "contexts", "meta", hex.EncodeToString(digest[:]))
+	if err := os.MkdirAll(metaDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	meta := `{"Name":"build","Endpoints":{"docker":{"Host":"tcp://build-01:2376"}}}`
+	if err := os.WriteFile(filepath.Join(metaDir, "meta.json"), []byte(meta), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := DaemonHost(); got != "tcp://build-01:2376" {
+		t.Errorf("DaemonHost() = %q", got)
+	}
+	t.Setenv("DOCKER_HOST", "unix:///tmp/other.sock")
+	if got := DaemonHost(); got != "unix:///tmp/other.sock" {
+		t.Errorf("DaemonHost() with DOCKER_HOST = %q", got)
+	}
+}
@@ -0,0 +1,62 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// SandboxEnv is set to "1" in every packnplay container
+const SandboxEnv = "IS_SANDBOX"
+
+// NestedEnv opts in to running packnplay inside a packnplay container, against a daemon
+// reached through a mounted socket or DOCKER_HOST
+const NestedEnv = "PACKNPLAY_NESTED"
+
+// DefaultSocketPath is where a docker socket mounted into the container is expected
+const DefaultSocketPath = "/var/run/docker.sock"
+
+var nestedWarning sync.Once
+
+// checkNested stops packnplay from running inside one of its own containers, where there
+// is no daemon and runtime errors would be confusing. With NestedEnv set and a daemon
+// socket available, it runs against that daemon instead. The returned warning is empty
+// outside a sandbox.
+func checkNested(getenv func(string) string, socketPath string) (warning string, err error) {
+	if getenv(SandboxEnv) != "1" {
+		return "", nil
+	}
+
+	daemon := getenv("DOCKER_HOST")
+	if daemon == "" {
+		if _, err := os.Stat(socketPath); err == nil {
+			daemon = socketPath
+		}
+	}
+
+	if getenv(NestedEnv) != "1" {
+		return "", fmt.Errorf("packnplay is running inside a packnplay container (%s=1), which has no container runtime of its own.\n"+
+			"Run packnplay on the host instead, or mount the host's docker socket into this container "+
+			"(\"%s:%s\" in the project's mounts) and set %s=1", SandboxEnv, DefaultSocketPath, DefaultSocketPath, NestedEnv)
+	}
+	if daemon == "" {
+		return "", fmt.Errorf("%s=1 is set, but no docker daemon is reachable from this container: "+
+			"mount the host's docker socket at %s or set DOCKER_HOST", NestedEnv, DefaultSocketPath)
+	}
+	return fmt.Sprintf("Warning: running nested inside a packnplay container against %s. "+
+		"Containers are started by that daemon, so mounted paths must exist at the same location on its host "+
+		"(the project directory does; this container's home directory doesn't)", daemon), nil
+}
+
+// CheckNested returns an error when packnplay runs inside a packnplay container without
+// nested operation enabled, and warns once when it is
+func CheckNested() error {
+	warning, err := checkNested(os.Getenv, DefaultSocketPath)
+	if err != nil {
+		return err
+	}
+	if warning != "" {
+		nestedWarning.Do(func() { fmt.Fprintln(os.Stderr, warning) })
+	}
+	return nil
+}
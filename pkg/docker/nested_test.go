@@ -0,0 +1,45 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckNested(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	if err := os.WriteFile(socket, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(t.TempDir(), "docker.sock")
+
+	tests := []struct {
+		name        string
+		env         map[string]string
+		socketPath  string
+		wantErr     string
+		wantWarning string
+	}{
+		{"on the host", map[string]string{}, missing, "", ""},
+		{"in a sandbox", map[string]string{SandboxEnv: "1"}, socket, "inside a packnplay container", ""},
+		{"nested without a daemon", map[string]string{SandboxEnv: "1", NestedEnv: "1"}, missing, "no docker daemon is reachable", ""},
+		{"nested with a mounted socket", map[string]string{SandboxEnv: "1", NestedEnv: "1"}, socket, "", socket},
+		{"nested with DOCKER_HOST", map[string]string{SandboxEnv: "1", NestedEnv: "1", "DOCKER_HOST": "tcp://10.0.0.2:2375"}, missing, "", "tcp://10.0.0.2:2375"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			warning, err := checkNested(getenv, tt.socketPath)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("checkNested() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("checkNested() error = %v, want %q", err, tt.wantErr)
+			}
+			if (tt.wantWarning == "") != (warning == "") || !strings.Contains(warning, tt.wantWarning) {
+				t.Errorf("checkNested() warning = %q, want one mentioning %q", warning, tt.wantWarning)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PSEntry is one line of ps --format '{{json .}}'. Docker prints Names and Labels as
+// comma-separated strings, while Podman prints a list of names and an object of labels;
+// both decode to Docker's form. Podman may also leave Status empty, in which case the
+// container's State stands in.
+type PSEntry struct {
+	ID     string
+	Names  string
+	Status string
+	State  string
+	Labels string
+}
+
+func (e *PSEntry) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		ID     string          `json:"ID"`
+		Names  json.RawMessage `json:"Names"`
+		Status string          `json:"Status"`
+		State  string          `json:"State"`
+		Labels json.RawMessage `json:"Labels"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	names, err := decodePSNames(raw.Names)
+	if err != nil {
+		return fmt.Errorf("failed to decode Names: %w", err)
+	}
+	labels, err := decodePSLabels(raw.Labels)
+	if err != nil {
+		return fmt.Errorf("failed to decode Labels: %w", err)
+	}
+	*e = PSEntry{ID: raw.ID, Names: names, Status: raw.Status, State: raw.State, Labels: labels}
+	if e.Status == "" {
+		e.Status = e.State
+	}
+	return nil
+}
+
+// decodePSNames accepts Docker's "a,b" and Podman's ["a", "b"]
+func decodePSNames(data json.RawMessage) (string, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return "", nil
+	}
+	var names string
+	if err := json.Unmarshal(data, &names); err == nil {
+		return names, nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return "", err
+	}
+	return strings.Join(list, ","), nil
+}
+
+// decodePSLabels accepts Docker's "k=v,k=v" and Podman's {"k": "v"}, which is written
+// out in Docker's form sorted by key
+func decodePSLabels(data json.RawMessage) (string, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return "", nil
+	}
+	var labels string
+	if err := json.Unmarshal(data, &labels); err == nil {
+		return labels, nil
+	}
+	var object map[string]string
+	if err := json.Unmarshal(data, &object); err != nil {
+		return "", err
+	}
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+object[key])
+	}
+	return strings.Join(pairs, ","), nil
+}
+
+// PodmanMachine is a VM from podman machine list. On macOS and Windows, Podman runs
+// containers in one, and nothing works until it is started.
+type PodmanMachine struct {
+	Name     string `json:"Name"`
+	Default  bool   `json:"Default"`
+	Running  bool   `json:"Running"`
+	Starting bool   `json:"Starting"`
+}
+
+// PodmanMachines lists the podman machine VMs
+func (c *Client) PodmanMachines() ([]PodmanMachine, error) {
+	output, err := c.Run("machine", "list", "--format", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list podman machines: %w", err)
+	}
+	return ParsePodmanMachines(output)
+}
+
+// ParsePodmanMachines parses podman machine list --format json. Podman marks the default
+// machine with a trailing "*" in its name in some versions; it is removed.
+func ParsePodmanMachines(output string) ([]PodmanMachine, error) {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return nil, nil
+	}
+	var machines []PodmanMachine
+	if err := json.Unmarshal([]byte(output), &machines); err != nil {
+		return nil, fmt.Errorf("failed to parse podman machine list: %w", err)
+	}
+	for i := range machines {
+		if name, ok := strings.CutSuffix(machines[i].Name, "*"); ok {
+			machines[i].Name = name
+			machines[i].Default = true
+		}
+	}
+	return machines, nil
+}
@@ -0,0 +1,83 @@
+package docker
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPSEntryUnmarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want PSEntry
+	}{
+		{
+			"docker",
+			`{"ID":"4f2a","Names":"packnplay-app-main","Status":"Up 5 minutes","State":"running","Labels":"managed-by=packnplay,packnplay-project=app"}`,
+			PSEntry{ID: "4f2a", Names: "packnplay-app-main", Status: "Up 5 minutes", State: "running", Labels: "managed-by=packnplay,packnplay-project=app"},
+		},
+		{
+			"podman",
+			`{"Id":"4f2a","Names":["packnplay-app-main"],"Status":"Up 5 minutes","State":"running","Labels":{"packnplay-project":"app","managed-by":"packnplay"}}`,
+			PSEntry{ID: "4f2a", Names: "packnplay-app-main", Status: "Up 5 minutes", State: "running", Labels: "managed-by=packnplay,packnplay-project=app"},
+		},
+		{
+			"podman without status or labels",
+			`{"Id":"4f2a","Names":["a","b"],"State":"running","Labels":null}`,
+			PSEntry{ID: "4f2a", Names: "a,b", Status: "running", State: "running"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got PSEntry
+			if err := json.Unmarshal([]byte(tt.line), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("PSEntry = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	var entry PSEntry
+	if err := json.Unmarshal([]byte(`{"Names":42}`), &entry); err == nil {
+		t.Error("Unmarshal() of numeric Names should fail")
+	}
+}
+
+func TestParsePodmanMachines(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    []PodmanMachine
+		wantErr bool
+	}{
+		{"none", "", nil, false},
+		{"empty list", "[]", []PodmanMachine{}, false},
+		{
+			"running default",
+			`[{"Name":"podman-machine-default*","Default":false,"Running":true,"VMType":"applehv"}]`,
+			[]PodmanMachine{{Name: "podman-machine-default", Default: true, Running: true}},
+			false,
+		},
+		{
+			"stopped and starting",
+			`[{"Name":"dev","Default":true,"Running":false},{"Name":"ci","Starting":true}]`,
+			[]PodmanMachine{{Name: "dev", Default: true}, {Name: "ci", Starting: true}},
+			false,
+		},
+		{"not json", "NAME  VM TYPE", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePodmanMachines(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePodmanMachines() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePodmanMachines() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
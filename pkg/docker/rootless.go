@@ -0,0 +1,120 @@
+package docker
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// rootlessCacheTTL is how long a daemon's answer is reused by later runs. A daemon rarely
+// changes mode, and switching between daemons changes the cache key.
+const rootlessCacheTTL = 24 * time.Hour
+
+// rootlessCacheEntry is a daemon's answer as the cache file keeps it
+type rootlessCacheEntry struct {
+	Rootless bool      `json:"rootless"`
+	Checked  time.Time `json:"checked"`
+}
+
+// Rootless reports whether the runtime runs containers without root: rootless Docker, or
+// Podman run by a normal user. In a rootless container, root is the host user and other
+// users map to subordinate IDs, which changes who owns bind-mounted files. The answer is
+// asked of the runtime once a day per daemon and cached, so runs don't each pay for
+// docker info.
+func (c *Client) Rootless() bool {
+	c.rootlessOnce.Do(func() {
+		if c.cmd != "docker" && c.cmd != "podman" {
+			return
+		}
+		key := c.cmd + " " + DaemonHost()
+		if rootless, ok := cachedRootless(key, time.Now()); ok {
+			c.rootless = rootless
+			return
+		}
+		var output string
+		var err error
+		if c.cmd == "docker" {
+			output, err = c.Run("info", "--format", "{{json .SecurityOptions}}")
+			c.rootless = err == nil && dockerInfoRootless(output)
+		} else {
+			output, err = c.Run("info", "--format", "{{.Host.Security.Rootless}}")
+			c.rootless = err == nil && strings.TrimSpace(output) == "true"
+		}
+		if err == nil {
+			cacheRootless(key, c.rootless, time.Now())
+		}
+	})
+	return c.rootless
+}
+
+// IsPodman reports whether the runtime is Podman
+func (c *Client) IsPodman() bool {
+	return c.cmd == "podman"
+}
+
+// dockerInfoRootless reports whether docker info's security options include rootless
+func dockerInfoRootless(securityOptions string) bool {
+	return strings.Contains(securityOptions, "name=rootless")
+}
+
+// rootlessCachePath returns the file daemons' rootless answers are cached in
+func rootlessCachePath() (string, error) {
+	cacheDir := os.Getenv("XDG_CACHE_HOME")
+	if cacheDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		cacheDir = filepath.Join(homeDir, ".cache")
+	}
+	return filepath.Join(cacheDir, "packnplay", "rootless.json"), nil
+}
+
+// readRootlessCache returns the cached answers by daemon, empty when there are none
+func readRootlessCache() map[string]rootlessCacheEntry {
+	entries := make(map[string]rootlessCacheEntry)
+	path, err := rootlessCachePath()
+	if err != nil {
+		return entries
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &entries)
+	}
+	return entries
+}
+
+// cachedRootless returns the cached answer for the daemon key, when it is recent enough
+func cachedRootless(key string, now time.Time) (bool, bool) {
+	entry, ok := readRootlessCache()[key]
+	if !ok || now.Sub(entry.Checked) > rootlessCacheTTL || entry.Checked.After(now) {
+		return false, false
+	}
+	return entry.Rootless, true
+}
+
+// cacheRootless records the answer for the daemon key. Failing to is harmless: the next
+// run asks again.
+func cacheRootless(key string, rootless bool, now time.Time) {
+	path, err := rootlessCachePath()
+	if err != nil {
+		return
+	}
+	entries := readRootlessCache()
+	entries[key] = rootlessCacheEntry{Rootless: rootless, Checked: now}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, data, 0644); err != nil {
+		return
+	}
+	if err := os.Rename(temp, path); err != nil {
+		_ = os.Remove(temp)
+	}
+}
@@ -0,0 +1,62 @@
+package docker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDockerInfoRootless(t *testing.T) {
+	tests := []struct {
+		output string
+		want   bool
+	}{
+		{`["name=seccomp,profile=builtin","name=rootless","name=cgroupns"]` + "\n", true},
+		{`["name=apparmor","name=seccomp,profile=builtin","name=cgroupns"]` + "\n", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := dockerInfoRootless(tt.output); got != tt.want {
+			t.Errorf("dockerInfoRootless(%q) = %v, want %v", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestRootlessUnknownRuntime(t *testing.T) {
+	c := &Client{cmd: "container"}
+	if c.Rootless() {
+		t.Error("Rootless() should be false for runtimes it can't ask")
+	}
+}
+
+func TestRootlessCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	now := time.Now()
+
+	if _, ok := cachedRootless("docker unix:///var/run/docker.sock", now); ok {
+		t.Fatal("cachedRootless() found an answer in an empty cache")
+	}
+	cacheRootless("docker unix:///var/run/docker.sock", true, now)
+	cacheRootless("podman unix:///run/podman.sock", false, now)
+
+	if rootless, ok := cachedRootless("docker unix:///var/run/docker.sock", now.Add(time.Hour)); !ok || !rootless {
+		t.Errorf("cachedRootless() = %v, %v, want the cached true", rootless, ok)
+	}
+	if rootless, ok := cachedRootless("podman unix:///run/podman.sock", now); !ok || rootless {
+		t.Errorf("cachedRootless(podman) = %v, %v, want the cached false", rootless, ok)
+	}
+	if _, ok := cachedRootless("docker unix:///var/run/docker.sock", now.Add(rootlessCacheTTL+time.Minute)); ok {
+		t.Error("cachedRootless() returned an expired answer")
+	}
+}
+
+func TestRootlessUsesCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("DOCKER_HOST", "unix:///tmp/packnplay-test.sock")
+	cacheRootless("docker unix:///tmp/packnplay-test.sock", true, time.Now())
+
+	// No docker to ask: the answer can only come from the cache
+	c := &Client{cmd: "docker"}
+	if !c.Rootless() {
+		t.Error("Rootless() = false, want the cached answer")
+	}
+}
@@ -0,0 +1,141 @@
+package docker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RuntimeAdapter is how a Client drives one runtime's CLI. Docker and Podman share the
+// docker CLI; Apple Container's differs in command names, output formats and features.
+// Methods take the CLI to run commands with, whose arguments go through TranslateArgs.
+type RuntimeAdapter interface {
+	// TranslateArgs rewrites docker CLI arguments for the runtime's CLI
+	TranslateArgs(args []string) []string
+	// Inspect looks up a container by name or ID; a missing one is ErrNoSuchContainer
+	Inspect(cli CLI, nameOrID string) (*ContainerInfo, error)
+	// List returns the containers matching filters (as for docker ps --filter), running
+	// ones only unless all is set
+	List(cli CLI, filters map[string][]string, all bool) ([]ContainerInfo, error)
+	// CopyTo copies a host file, or a directory's contents when src ends in "/.", to dst
+	// in a running container
+	CopyTo(cli CLI, src, container, dst string) error
+	// Capabilities reports what the runtime supports beyond creating and running containers
+	Capabilities() Capabilities
+}
+
+// CLI runs the runtime's command
+type CLI interface {
+	Run(args ...string) (string, error)
+	RunWithInput(input io.Reader, args ...string) (string, error)
+}
+
+// Capabilities are the features packnplay uses that not every runtime has
+type Capabilities struct {
+	DetachedTTY bool // run -d -it
+	Hostname    bool // run --hostname
+	Ulimits     bool // run --ulimit
+	SwapAndPids bool // run --memory-swap and --pids-limit
+	GroupAdd    bool // run --group-add
+	Networks    bool // user-defined networks, which the egress proxy and sidecars join
+	DaemonInfo  bool // info, read for rootless detection and the clock check
+	Events      bool // an event stream
+	EngineAPI   bool // the Docker Engine API on a unix socket
+	BuildKit    bool // build --secret, --ssh, --cache-from and --cache-to
+}
+
+// adapterFor returns the adapter for a runtime command
+func adapterFor(cmd string) RuntimeAdapter {
+	if cmd == "container" {
+		return appleAdapter{}
+	}
+	return dockerAdapter{}
+}
+
+// Adapter returns the client's runtime adapter
+func (c *Client) Adapter() RuntimeAdapter {
+	return adapterFor(c.cmd)
+}
+
+// Capabilities reports what the client's runtime supports
+func (c *Client) Capabilities() Capabilities {
+	return c.Adapter().Capabilities()
+}
+
+// CopyToContainer copies a host file, or a directory's contents when src ends in "/.",
+// to dst in a running container
+func (c *Client) CopyToContainer(src, container, dst string) error {
+	return c.Adapter().CopyTo(c, src, container, dst)
+}
+
+// dockerAdapter drives the docker CLI and Podman's compatible one
+type dockerAdapter struct{}
+
+func (dockerAdapter) TranslateArgs(args []string) []string { return args }
+
+// Inspect runs docker inspect, which prints a JSON list with the container in it
+func (dockerAdapter) Inspect(cli CLI, nameOrID string) (*ContainerInfo, error) {
+	output, err := cli.Run("inspect", "--type", "container", nameOrID)
+	if err != nil {
+		return nil, err
+	}
+	var infos []ContainerInfo
+	if err := json.Unmarshal([]byte(output), &infos); err != nil {
+		return nil, fmt.Errorf("failed to parse inspect output: %w", err)
+	}
+	if len(infos) == 0 {
+		return nil, newCommandError([]string{"inspect", nameOrID}, "no such container: "+nameOrID, errors.New("empty inspect output"))
+	}
+	return &infos[0], nil
+}
+
+// List filters with docker ps, then inspects each container it finds
+func (a dockerAdapter) List(cli CLI, filters map[string][]string, all bool) ([]ContainerInfo, error) {
+	args := []string{"ps", "-q", "--no-trunc"}
+	if all {
+		args = append(args, "-a")
+	}
+	args = append(args, filterArgs(filters)...)
+	output, err := cli.Run(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []ContainerInfo
+	for _, id := range strings.Fields(output) {
+		// A container removed since it was listed is left out
+		info, err := a.Inspect(cli, id)
+		if errors.Is(err, ErrNoSuchContainer) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, *info)
+	}
+	return infos, nil
+}
+
+func (dockerAdapter) CopyTo(cli CLI, src, container, dst string) error {
+	if output, err := cli.Run("cp", src, container+":"+dst); err != nil {
+		return fmt.Errorf("failed to copy %s to %s: %w\n%s", src, dst, err, output)
+	}
+	return nil
+}
+
+func (dockerAdapter) Capabilities() Capabilities {
+	return Capabilities{
+		DetachedTTY: true,
+		Hostname:    true,
+		Ulimits:     true,
+		SwapAndPids: true,
+		GroupAdd:    true,
+		Networks:    true,
+		DaemonInfo:  true,
+		Events:      true,
+		EngineAPI:   true,
+		BuildKit:    true,
+	}
+}
@@ -0,0 +1,90 @@
+package docker
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeCLI answers commands from a table keyed by their space-joined arguments and
+// records what it ran, with the input it was given
+type fakeCLI struct {
+	outputs map[string]string
+	errs    map[string]error
+	ran     []string
+	input   []byte
+}
+
+func (f *fakeCLI) Run(args ...string) (string, error) {
+	return f.RunWithInput(nil, args...)
+}
+
+func (f *fakeCLI) RunWithInput(input io.Reader, args ...string) (string, error) {
+	key := strings.Join(args, " ")
+	f.ran = append(f.ran, key)
+	if input != nil {
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return "", err
+		}
+		f.input = data
+	}
+	if err := f.errs[key]; err != nil {
+		return f.outputs[key], err
+	}
+	return f.outputs[key], nil
+}
+
+func TestAdapterFor(t *testing.T) {
+	if _, ok := adapterFor("docker").(dockerAdapter); !ok {
+		t.Error("docker should use the docker adapter")
+	}
+	if _, ok := adapterFor("podman").(dockerAdapter); !ok {
+		t.Error("podman should use the docker adapter")
+	}
+	if _, ok := adapterFor("container").(appleAdapter); !ok {
+		t.Error("container should use the Apple adapter")
+	}
+	if caps := (&Client{cmd: "container"}).Capabilities(); caps.DetachedTTY || caps.EngineAPI || caps.Networks {
+		t.Errorf("Apple Container capabilities = %+v", caps)
+	}
+	if caps := (&Client{cmd: "docker"}).Capabilities(); !caps.DetachedTTY || !caps.EngineAPI || !caps.Networks {
+		t.Errorf("docker capabilities = %+v", caps)
+	}
+}
+
+func TestDockerAdapter(t *testing.T) {
+	cli := &fakeCLI{
+		outputs: map[string]string{
+			"ps -q --no-trunc -a --filter label=managed-by=packnplay": "aaa\nbbb\n",
+			"inspect --type container aaa":                            `[{"Id":"aaa","Name":"/packnplay-app-main","State":{"Running":true}}]`,
+			"inspect --type container bbb":                            "Error: No such container: bbb",
+		},
+		errs: map[string]error{
+			"inspect --type container bbb": newCommandError([]string{"inspect"}, "Error: No such container: bbb", errors.New("exit status 1")),
+		},
+	}
+	adapter := dockerAdapter{}
+
+	infos, err := adapter.List(cli, map[string][]string{"label": {"managed-by=packnplay"}}, true)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(infos) != 1 || infos[0].ID != "aaa" {
+		t.Errorf("List() = %+v, want only aaa (bbb was removed)", infos)
+	}
+
+	if err := adapter.CopyTo(cli, "/tmp/creds", "aaa", "/home/user/.creds"); err != nil {
+		t.Fatalf("CopyTo() error = %v", err)
+	}
+	if got := cli.ran[len(cli.ran)-1]; got != "cp /tmp/creds aaa:/home/user/.creds" {
+		t.Errorf("CopyTo ran %q", got)
+	}
+
+	args := []string{"ps", "--format", "{{.Names}}"}
+	if got := adapter.TranslateArgs(args); !reflect.DeepEqual(got, args) {
+		t.Errorf("TranslateArgs() = %v, want unchanged", got)
+	}
+}
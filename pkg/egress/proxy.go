@@ -0,0 +1,137 @@
+// Package egress runs the allowlist proxy for containers in network allowlist mode. The
+// container is attached only to an internal network with no route out; a squid sidecar on
+// both that network and the default bridge forwards requests to the allowed domains and
+// refuses the rest.
+package egress
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/obra/packnplay/pkg/docker"
+)
+
+// DefaultProxyImage is the squid image the proxy runs when none is configured
+const DefaultProxyImage = "ubuntu/squid:latest"
+
+// ProxyAlias is the proxy's host name on the internal network
+const ProxyAlias = "packnplay-egress"
+
+// ProxyPort is the port squid listens on
+const ProxyPort = 3128
+
+// ForLabel marks the proxy container and internal network with the container they serve
+const ForLabel = "packnplay-egress-for"
+
+// Name returns the name of the proxy container and internal network for containerName
+func Name(containerName string) string {
+	return containerName + "-egress"
+}
+
+// ProxyURL is the proxy address containers on the internal network use
+func ProxyURL() string {
+	return fmt.Sprintf("http://%s:%d", ProxyAlias, ProxyPort)
+}
+
+// EnvArgs returns the proxy variables for the container, in both cases since tools
+// disagree on which they read
+func EnvArgs() []string {
+	proxy := ProxyURL()
+	return []string{
+		"HTTP_PROXY=" + proxy,
+		"HTTPS_PROXY=" + proxy,
+		"http_proxy=" + proxy,
+		"https_proxy=" + proxy,
+		"NO_PROXY=localhost,127.0.0.1",
+		"no_proxy=localhost,127.0.0.1",
+	}
+}
+
+// SquidConfig renders a squid.conf that only allows requests to domains. "*.example.com"
+// allows example.com and its subdomains; other names are matched exactly. Tunnels
+// (HTTPS) are only allowed to port 443.
+func SquidConfig(domains []string) string {
+	var acl []string
+	for _, domain := range domains {
+		domain = strings.ToLower(domain)
+		if strings.HasPrefix(domain, "*.") {
+			domain = domain[1:]
+		}
+		acl = append(acl, domain)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "http_port %d\n", ProxyPort)
+	fmt.Fprintf(&b, "acl allowed dstdomain %s\n", strings.Join(acl, " "))
+	b.WriteString("acl SSL_ports port 443\n")
+	b.WriteString("acl CONNECT method CONNECT\n")
+	b.WriteString("http_access deny CONNECT !SSL_ports\n")
+	b.WriteString("http_access allow allowed\n")
+	b.WriteString("http_access deny all\n")
+	b.WriteString("cache deny all\n")
+	b.WriteString("access_log stdio:/dev/stdout\n")
+	return b.String()
+}
+
+// Start creates the internal network for containerName and starts its proxy, replacing
+// any left over from an earlier run. It returns the network the container must join.
+func Start(d docker.Runner, containerName, image string, domains []string) (string, error) {
+	if image == "" {
+		image = DefaultProxyImage
+	}
+	name := Name(containerName)
+	label := ForLabel + "=" + containerName
+	Stop(d, containerName)
+
+	if output, err := d.Run("network", "create", "--internal", "--label", label, name); err != nil {
+		return "", fmt.Errorf("failed to create internal network: %w\nDocker output:\n%s", err, output)
+	}
+
+	if output, err := d.Run("create", "--name", name, "--label", label, "--restart", "unless-stopped", image); err != nil {
+		Stop(d, containerName)
+		return "", fmt.Errorf("failed to create egress proxy from %s: %w\nDocker output:\n%s", image, err, output)
+	}
+
+	if err := copySquidConfig(d, name, SquidConfig(domains)); err != nil {
+		Stop(d, containerName)
+		return "", err
+	}
+
+	if output, err := d.Run("network", "connect", "--alias", ProxyAlias, name, name); err != nil {
+		Stop(d, containerName)
+		return "", fmt.Errorf("failed to attach egress proxy to the internal network: %w\nDocker output:\n%s", err, output)
+	}
+	if output, err := d.Run("start", name); err != nil {
+		Stop(d, containerName)
+		return "", fmt.Errorf("failed to start egress proxy: %w\nDocker output:\n%s", err, output)
+	}
+	return name, nil
+}
+
+// copySquidConfig writes the config into the created proxy container. docker cp works for
+// remote daemons too, unlike a bind mount of a local file.
+func copySquidConfig(d docker.Runner, proxy, conf string) error {
+	tempDir, err := os.MkdirTemp("", "packnplay-egress-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tempDir) }()
+
+	confPath := filepath.Join(tempDir, "squid.conf")
+	if err := os.WriteFile(confPath, []byte(conf), 0644); err != nil {
+		return fmt.Errorf("failed to write squid.conf: %w", err)
+	}
+	if output, err := d.Run("cp", confPath, proxy+":/etc/squid/squid.conf"); err != nil {
+		return fmt.Errorf("failed to copy squid.conf into the egress proxy: %w\nDocker output:\n%s", err, output)
+	}
+	return nil
+}
+
+// Stop removes the proxy and internal network for containerName, if there are any
+func Stop(d docker.Runner, containerName string) {
+	name := Name(containerName)
+	_, _ = d.Run("rm", "-f", name)
+	_, _ = d.Run("network", "rm", name)
+}
@@ -0,0 +1,89 @@
+package egress
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fakeDocker struct {
+	calls  [][]string
+	failOn string // first argument of the command that fails
+}
+
+func (f *fakeDocker) Command() string {
+	return "docker"
+}
+
+func (f *fakeDocker) Run(args ...string) (string, error) {
+	f.calls = append(f.calls, args)
+	if args[0] == f.failOn {
+		return "boom", errors.New("exit status 1")
+	}
+	return "", nil
+}
+
+func TestSquidConfig(t *testing.T) {
+	conf := SquidConfig([]string{"api.anthropic.com", "*.GitHub.com"})
+
+	for _, want := range []string{
+		"http_port 3128\n",
+		"acl allowed dstdomain api.anthropic.com .github.com\n",
+		"http_access allow allowed\nhttp_access deny all\n",
+	} {
+		if !strings.Contains(conf, want) {
+			t.Errorf("SquidConfig() missing %q:\n%s", want, conf)
+		}
+	}
+}
+
+func TestStart(t *testing.T) {
+	d := &fakeDocker{}
+	network, err := Start(d, "packnplay-app-main", "", []string{"github.com"})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if network != "packnplay-app-main-egress" {
+		t.Errorf("Start() network = %q", network)
+	}
+
+	var commands []string
+	for _, call := range d.calls {
+		command := call[0]
+		if command == "network" {
+			command += " " + call[1]
+		}
+		commands = append(commands, command)
+	}
+	want := []string{"rm", "network rm", "network create", "create", "cp", "network connect", "start"}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("Start() ran %q, want %q", commands, want)
+	}
+	if got := d.calls[3][len(d.calls[3])-1]; got != DefaultProxyImage {
+		t.Errorf("proxy image = %q, want %q", got, DefaultProxyImage)
+	}
+	if got := d.calls[4][2]; got != "packnplay-app-main-egress:/etc/squid/squid.conf" {
+		t.Errorf("squid.conf copied to %q", got)
+	}
+}
+
+func TestStartCleansUpOnFailure(t *testing.T) {
+	d := &fakeDocker{failOn: "start"}
+	if _, err := Start(d, "packnplay-app-main", "squid:custom", []string{"github.com"}); err == nil {
+		t.Fatal("Start() should fail when the proxy doesn't start")
+	}
+
+	last := d.calls[len(d.calls)-2:]
+	want := [][]string{{"rm", "-f", "packnplay-app-main-egress"}, {"network", "rm", "packnplay-app-main-egress"}}
+	if !reflect.DeepEqual(last, want) {
+		t.Errorf("Start() finished with %q, want cleanup %q", last, want)
+	}
+}
+
+func TestEnvArgs(t *testing.T) {
+	env := EnvArgs()
+	if env[0] != "HTTP_PROXY=http://packnplay-egress:3128" {
+		t.Errorf("EnvArgs()[0] = %q", env[0])
+	}
+}
@@ -0,0 +1,95 @@
+// Package events writes machine-readable lifecycle events for programs that drive
+// packnplay, one JSON object per line.
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Event types, in the order they occur during a run
+const (
+	Created     = "created"      // a new container was started
+	Reconnected = "reconnected"  // an existing container is being reused
+	ExecStarted = "exec-started" // the command is running in the container
+	Exited      = "exited"       // the command finished; Code holds its exit status
+	Error       = "error"        // packnplay failed before or while running the command
+)
+
+// EventsFD is the file descriptor events go to when the caller opened it
+const EventsFD = 3
+
+// Event is one lifecycle event
+type Event struct {
+	Event       string    `json:"event"`
+	Time        time.Time `json:"time"`
+	Container   string    `json:"container,omitempty"`
+	ContainerID string    `json:"container_id,omitempty"`
+	Command     []string  `json:"command,omitempty"`
+	Code        *int      `json:"code,omitempty"`
+	TimedOut    bool      `json:"timed_out,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Writer emits events as JSON lines. A nil *Writer discards events, so callers don't
+// need to check whether events were requested.
+type Writer struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	now func() time.Time
+}
+
+// New returns a Writer that writes to w
+func New(w io.Writer) *Writer {
+	return &Writer{enc: json.NewEncoder(w), now: time.Now}
+}
+
+// Open returns a Writer for fd 3 when the caller passed one in, so events stay apart from
+// the command's output, and for stdout otherwise
+func Open() *Writer {
+	// Probed before it is wrapped: an *os.File that is dropped closes its descriptor when
+	// collected, and fd 3 may be one the Go runtime opened for itself
+	if usableFD(EventsFD) {
+		return New(os.NewFile(EventsFD, "events"))
+	}
+	return New(os.Stdout)
+}
+
+// usableFD reports whether fd is a pipe, socket, terminal or file a caller could have
+// handed us, rather than closed or a descriptor the Go runtime opened for itself (like
+// epoll, which has no file type bits)
+func usableFD(fd int) bool {
+	var st syscall.Stat_t
+	if err := syscall.Fstat(fd, &st); err != nil {
+		return false
+	}
+	switch uint32(st.Mode) & syscall.S_IFMT {
+	case syscall.S_IFREG, syscall.S_IFIFO, syscall.S_IFSOCK, syscall.S_IFCHR:
+		return true
+	}
+	return false
+}
+
+// Emit writes e, filling in the time. Write errors are ignored: a consumer that went away
+// must not break the session.
+func (w *Writer) Emit(e Event) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if e.Time.IsZero() {
+		e.Time = w.now().UTC()
+	}
+	_ = w.enc.Encode(e)
+}
+
+// ExitCode returns a pointer to code for Event.Code
+func ExitCode(code int) *int {
+	return &code
+}
@@ -0,0 +1,69 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmit(t *testing.T) {
+	var buf bytes.Buffer
+	w := New(&buf)
+	w.now = func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	w.Emit(Event{Event: Created, Container: "packnplay-app-main", ContainerID: "abc123"})
+	w.Emit(Event{Event: Exited, Code: ExitCode(0)})
+	w.Emit(Event{Event: Exited, Code: ExitCode(124), TimedOut: true})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		`{"event":"created","time":"2025-01-02T03:04:05Z","container":"packnplay-app-main","container_id":"abc123"}`,
+		`{"event":"exited","time":"2025-01-02T03:04:05Z","code":0}`,
+		`{"event":"exited","time":"2025-01-02T03:04:05Z","code":124,"timed_out":true}`,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), buf.String())
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %s, want %s", i, lines[i], want[i])
+		}
+		if !json.Valid([]byte(lines[i])) {
+			t.Errorf("line %d is not valid JSON", i)
+		}
+	}
+}
+
+func TestNilWriterDiscards(t *testing.T) {
+	var w *Writer
+	w.Emit(Event{Event: Error, Error: "boom"}) // must not panic
+}
+
+func TestUsableFD(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if !usableFD(int(w.Fd())) {
+		t.Error("usableFD(pipe) = false, want true")
+	}
+
+	file, err := os.Create(t.TempDir() + "/events.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !usableFD(int(file.Fd())) {
+		t.Error("usableFD(regular file) = false, want true")
+	}
+
+	closed := int(w.Fd())
+	_ = w.Close()
+	_ = file.Close()
+	if usableFD(closed) {
+		t.Error("usableFD(closed) = true, want false")
+	}
+}
@@ -0,0 +1,102 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WorktreeStatus is where a checkout stands: its uncommitted changes and how far its
+// branch has moved from the one it will be merged into
+type WorktreeStatus struct {
+	Branch     string    // "" when HEAD is detached
+	Base       string    // the upstream, or the default branch when there is none; "" when neither exists
+	Ahead      int       // commits on HEAD that aren't on Base
+	Behind     int       // commits on Base that aren't on HEAD
+	Dirty      int       // changed and untracked files
+	LastCommit time.Time // zero when the branch has no commits yet
+}
+
+// GetWorktreeStatus reads the status of the checkout at path. Like ChangedFiles, it runs
+// no hooks or fsmonitor, since a sandboxed agent could have changed the checkout.
+func GetWorktreeStatus(path string) (*WorktreeStatus, error) {
+	output, err := sandboxSafeGit(path, "status", "--porcelain=v2", "--branch", "-z", "--untracked-files=all").Output()
+	if err != nil {
+		return nil, err
+	}
+	status, hasCommits := parseStatusV2(string(output))
+	if !hasCommits {
+		return status, nil
+	}
+
+	if status.Base == "" {
+		if base := defaultBranch(path); base != "" && base != status.Branch {
+			counts, err := sandboxSafeGit(path, "rev-list", "--left-right", "--count", base+"...HEAD").Output()
+			if err != nil {
+				return nil, fmt.Errorf("failed to compare with %s: %w", base, err)
+			}
+			status.Base = base
+			status.Behind, status.Ahead = parseLeftRight(string(counts))
+		}
+	}
+
+	committed, err := sandboxSafeGit(path, "log", "-1", "--format=%ct").Output()
+	if err != nil {
+		return nil, err
+	}
+	if seconds, err := strconv.ParseInt(strings.TrimSpace(string(committed)), 10, 64); err == nil {
+		status.LastCommit = time.Unix(seconds, 0)
+	}
+	return status, nil
+}
+
+// parseStatusV2 reads git status --porcelain=v2 --branch -z output. It reports whether
+// HEAD has a commit.
+func parseStatusV2(output string) (*WorktreeStatus, bool) {
+	status := &WorktreeStatus{}
+	hasCommits := true
+	entries := strings.Split(output, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		switch {
+		case entry == "# branch.oid (initial)":
+			hasCommits = false
+		case strings.HasPrefix(entry, "# branch.head "):
+			if head := strings.TrimPrefix(entry, "# branch.head "); head != "(detached)" {
+				status.Branch = head
+			}
+		case strings.HasPrefix(entry, "# branch.upstream "):
+			status.Base = strings.TrimPrefix(entry, "# branch.upstream ")
+		case strings.HasPrefix(entry, "# branch.ab "):
+			_, _ = fmt.Sscanf(strings.TrimPrefix(entry, "# branch.ab "), "+%d -%d", &status.Ahead, &status.Behind)
+		case strings.HasPrefix(entry, "1 "), strings.HasPrefix(entry, "u "), strings.HasPrefix(entry, "? "):
+			status.Dirty++
+		case strings.HasPrefix(entry, "2 "):
+			// Renames and copies are followed by their source path
+			status.Dirty++
+			i++
+		}
+	}
+	return status, hasCommits
+}
+
+// parseLeftRight reads git rev-list --left-right --count output
+func parseLeftRight(output string) (left, right int) {
+	_, _ = fmt.Sscanf(strings.TrimSpace(output), "%d %d", &left, &right)
+	return left, right
+}
+
+// defaultBranch returns the branch work in path is merged into: origin's HEAD when it's
+// known, otherwise a local main or master. Returns "" when there's none.
+func defaultBranch(path string) string {
+	if output, err := sandboxSafeGit(path, "symbolic-ref", "-q", "--short", "refs/remotes/origin/HEAD").Output(); err == nil {
+		return strings.TrimSpace(string(output))
+	}
+	for _, branch := range []string{"main", "master"} {
+		if sandboxSafeGit(path, "rev-parse", "--verify", "-q", "refs/heads/"+branch).Run() == nil {
+			return branch
+		}
+	}
+	return ""
+}
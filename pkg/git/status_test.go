@@ -0,0 +1,98 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseStatusV2(t *testing.T) {
+	tests := []struct {
+		name       string
+		output     string
+		want       WorktreeStatus
+		wantCommit bool
+	}{
+		{
+			name: "tracking branch with changes",
+			output: "# branch.oid 1234\x00# branch.head feature\x00# branch.upstream origin/feature\x00# branch.ab +3 -1\x00" +
+				"1 .M N... 100644 100644 100644 aaaa bbbb pkg/a.go\x00" +
+				"2 R. N... 100644 100644 100644 aaaa bbbb R100 new.go\x00old.go\x00" +
+				"? notes.txt\x00",
+			want:       WorktreeStatus{Branch: "feature", Base: "origin/feature", Ahead: 3, Behind: 1, Dirty: 3},
+			wantCommit: true,
+		},
+		{
+			name:       "detached without upstream",
+			output:     "# branch.oid 1234\x00# branch.head (detached)\x00",
+			want:       WorktreeStatus{},
+			wantCommit: true,
+		},
+		{
+			name:   "new repository",
+			output: "# branch.oid (initial)\x00# branch.head main\x00? a.txt\x00",
+			want:   WorktreeStatus{Branch: "main", Dirty: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, hasCommits := parseStatusV2(tt.output)
+			if *got != tt.want {
+				t.Errorf("parseStatusV2() = %+v, want %+v", *got, tt.want)
+			}
+			if hasCommits != tt.wantCommit {
+				t.Errorf("parseStatusV2() hasCommits = %v, want %v", hasCommits, tt.wantCommit)
+			}
+		})
+	}
+}
+
+func TestGetWorktreeStatus(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	run := func(args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	repoDir := t.TempDir()
+	worktreeDir := filepath.Join(t.TempDir(), "feature")
+	run("init", "-q", "-b", "main", repoDir)
+	run("-C", repoDir, "commit", "-q", "--allow-empty", "-m", "initial")
+	run("-C", repoDir, "worktree", "add", "-q", "-b", "feature", worktreeDir)
+	run("-C", worktreeDir, "commit", "-q", "--allow-empty", "-m", "one")
+	run("-C", worktreeDir, "commit", "-q", "--allow-empty", "-m", "two")
+	run("-C", repoDir, "commit", "-q", "--allow-empty", "-m", "on main")
+	if err := os.WriteFile(filepath.Join(worktreeDir, "wip.txt"), []byte("wip\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := GetWorktreeStatus(worktreeDir)
+	if err != nil {
+		t.Fatalf("GetWorktreeStatus() error = %v", err)
+	}
+	if status.Branch != "feature" || status.Base != "main" || status.Ahead != 2 || status.Behind != 1 || status.Dirty != 1 {
+		t.Errorf("GetWorktreeStatus() = %+v, want feature 2 ahead and 1 behind main with 1 dirty file", *status)
+	}
+	if status.LastCommit.IsZero() {
+		t.Error("GetWorktreeStatus() didn't read the last commit time")
+	}
+
+	status, err = GetWorktreeStatus(repoDir)
+	if err != nil {
+		t.Fatalf("GetWorktreeStatus() error = %v", err)
+	}
+	if status.Base != "" || status.Ahead != 0 || status.Behind != 0 {
+		t.Errorf("GetWorktreeStatus() on the default branch = %+v, want no base", *status)
+	}
+
+	if _, err := GetWorktreeStatus(t.TempDir()); err == nil {
+		t.Error("GetWorktreeStatus() outside a repo should fail")
+	}
+}
@@ -1,6 +1,7 @@
 package git
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"os/exec"
@@ -14,21 +15,14 @@ func DetermineWorktreePath(projectPath, worktreeName string) string {
 	projectName := filepath.Base(projectPath)
 	sanitizedName := sanitizeBranchName(worktreeName)
 
-	// Get user's home directory
-	homeDir, err := os.UserHomeDir()
+	root, err := WorktreesRoot()
 	if err != nil {
 		// Fallback to old behavior if can't get home
-		parentDir := filepath.Dir(projectPath)
-		return filepath.Join(parentDir, fmt.Sprintf("%s-%s", projectName, sanitizedName))
+		return LegacyWorktreePath(projectPath, worktreeName)
 	}
 
 	// XDG-compliant path: ~/.local/share/packnplay/worktrees/<project>/<worktree>
-	xdgDataHome := os.Getenv("XDG_DATA_HOME")
-	if xdgDataHome == "" {
-		xdgDataHome = filepath.Join(homeDir, ".local", "share")
-	}
-
-	worktreePath := filepath.Join(xdgDataHome, "packnplay", "worktrees", projectName, sanitizedName)
+	worktreePath := filepath.Join(root, projectName, sanitizedName)
 
 	// Ensure parent directory exists
 	_ = os.MkdirAll(filepath.Dir(worktreePath), 0755)
@@ -36,6 +30,31 @@ func DetermineWorktreePath(projectPath, worktreeName string) string {
 	return worktreePath
 }
 
+// LegacyWorktreePath is where older versions created worktrees: next to the project,
+// as <project>-<worktree>
+func LegacyWorktreePath(projectPath, worktreeName string) string {
+	return filepath.Join(filepath.Dir(projectPath), fmt.Sprintf("%s-%s", filepath.Base(projectPath), sanitizeBranchName(worktreeName)))
+}
+
+// WorktreesRoot returns the directory packnplay creates worktrees in:
+// $XDG_DATA_HOME/packnplay/worktrees, by default ~/.local/share/packnplay/worktrees
+func WorktreesRoot() (string, error) {
+	xdgDataHome := os.Getenv("XDG_DATA_HOME")
+	if xdgDataHome == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		xdgDataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	return filepath.Join(xdgDataHome, "packnplay", "worktrees"), nil
+}
+
+// WorktreeDirName returns the directory name used for a worktree's branch
+func WorktreeDirName(worktreeName string) string {
+	return sanitizeBranchName(worktreeName)
+}
+
 // sanitizeBranchName converts branch name to filesystem-safe name
 func sanitizeBranchName(name string) string {
 	// Replace slashes with dashes
@@ -52,6 +71,16 @@ func IsGitRepo(path string) bool {
 	return cmd.Run() == nil
 }
 
+// GetRepoRoot returns the top-level directory of the repository containing path
+func GetRepoRoot(path string) (string, error) {
+	cmd := exec.Command("git", "-C", path, "rev-parse", "--show-toplevel")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
 // GetCurrentBranch returns the current branch name
 func GetCurrentBranch(path string) (string, error) {
 	cmd := exec.Command("git", "-C", path, "branch", "--show-current")
@@ -62,6 +91,246 @@ func GetCurrentBranch(path string) (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
+// GetRemoteURL returns the URL of the named remote
+func GetRemoteURL(path, remote string) (string, error) {
+	cmd := exec.Command("git", "-C", path, "remote", "get-url", remote)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// sandboxSafeConfig turns off repository settings that make git run programs, for
+// commands on checkouts a sandboxed agent could have modified (including .git/hooks)
+var sandboxSafeConfig = []string{"-c", "core.hooksPath=/dev/null", "-c", "core.fsmonitor=false"}
+
+// sandboxSafeGit returns a git command in path with sandboxSafeConfig applied, and the
+// repository's own settings that run programs overridden by repositoryOverrides
+func sandboxSafeGit(path string, args ...string) *exec.Cmd {
+	return exec.Command("git", append(append(append([]string{"-C", path}, sandboxSafeConfig...), repositoryOverrides(path)...), args...)...)
+}
+
+// repositoryOverrides returns git options undoing the settings in path's repository config
+// (and the files it includes) that make git run a program, such as filter and diff
+// drivers, credential helpers and ssh commands. The agent can write that config; the
+// user's global and system config it can't, so their values are put back. A work tree
+// moved elsewhere with core.worktree is pinned back to path.
+func repositoryOverrides(path string) []string {
+	cmd := exec.Command("git", append(append([]string{"-C", path}, sandboxSafeConfig...), "config", "--list", "--includes", "--show-scope", "--name-only")...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	var overrides []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(output), "\n") {
+		scope, key, ok := strings.Cut(line, "\t")
+		if !ok || (scope != "local" && scope != "worktree") || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if key == "core.worktree" {
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				abs = path
+			}
+			overrides = append(overrides, "--work-tree="+abs)
+			continue
+		}
+		if !runsProgram(key) {
+			continue
+		}
+		// An empty value turns the setting off (false, for filter.*.required), or clears a
+		// list such as credential.helper
+		overrides = append(overrides, "-c", key+"=")
+		for _, value := range userConfigValues(key) {
+			overrides = append(overrides, "-c", key+"="+value)
+		}
+	}
+	return overrides
+}
+
+// runsProgram reports whether the config key names a program git runs, or makes git fail
+// without it
+func runsProgram(key string) bool {
+	section, rest, _ := strings.Cut(key, ".")
+	name := rest[strings.LastIndex(rest, ".")+1:]
+	switch section {
+	case "filter":
+		return name == "clean" || name == "smudge" || name == "process" || name == "required"
+	case "diff":
+		return name == "textconv" || name == "command"
+	case "merge":
+		return name == "driver"
+	case "credential":
+		return name == "helper"
+	case "gpg":
+		return name == "program"
+	case "core":
+		switch name {
+		case "sshcommand", "askpass", "editor", "pager", "alternaterefscommand", "gitproxy":
+			return true
+		}
+	case "sequence":
+		return name == "editor"
+	case "remote":
+		return name == "uploadpack" || name == "receivepack"
+	case "uploadpack":
+		return name == "packobjectshook"
+	case "pager":
+		return true
+	}
+	return false
+}
+
+// userConfigValues returns the values the user's global and system config give key
+func userConfigValues(key string) []string {
+	// Outside of any repository, only those are read
+	output, err := exec.Command("git", "-C", string(filepath.Separator), "config", "--get-all", key).Output()
+	if err != nil {
+		return nil
+	}
+	return strings.Split(strings.TrimRight(string(output), "\n"), "\n")
+}
+
+// ChangedFiles returns the paths with uncommitted changes in the working tree at path,
+// untracked files included
+func ChangedFiles(path string) ([]string, error) {
+	cmd := sandboxSafeGit(path, "status", "--porcelain", "-z", "--untracked-files=all")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseStatusZ(string(output)), nil
+}
+
+// CommitAll stages every change in the working tree at path, untracked files included,
+// and commits them with message. Hooks don't run. Returns the new commit's short hash, or
+// "" when there was nothing to commit.
+func CommitAll(path, message string) (string, error) {
+	changed, err := ChangedFiles(path)
+	if err != nil {
+		return "", err
+	}
+	if len(changed) == 0 {
+		return "", nil
+	}
+
+	if output, err := sandboxSafeGit(path, "add", "-A").CombinedOutput(); err != nil {
+		return "", fmt.Errorf("git add failed: %w\n%s", err, output)
+	}
+	if output, err := sandboxSafeGit(path, "commit", "-q", "--no-verify", "-m", message).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("git commit failed: %w\n%s", err, output)
+	}
+	output, err := sandboxSafeGit(path, "rev-parse", "--short", "HEAD").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ApplyPatch applies a patch from git diff to the working tree at path. git apply
+// refuses paths outside the tree, in .git, or behind symlinks, and applies nothing when
+// any hunk doesn't fit.
+func ApplyPatch(path string, patch []byte) error {
+	cmd := sandboxSafeGit(path, "apply", "--whitespace=nowarn", "-")
+	cmd.Stdin = bytes.NewReader(patch)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git apply failed: %w\n%s", err, output)
+	}
+	return nil
+}
+
+// PatchStat returns a diffstat of patch for the working tree at path, without applying it
+func PatchStat(path string, patch []byte) (string, error) {
+	cmd := sandboxSafeGit(path, "apply", "--stat", "-")
+	cmd.Stdin = bytes.NewReader(patch)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git apply --stat failed: %w", err)
+	}
+	return string(output), nil
+}
+
+// Push pushes branch from the checkout at path to remote and sets it as the upstream.
+// A sandboxed agent could have changed the checkout's config, so hooks don't run, the
+// remote's push URL is resolved once and must use a network or local transport (not a
+// remote helper such as ext::), and ssh is the user's, not one the repository names.
+func Push(path, remote, branch string) error {
+	output, err := sandboxSafeGit(path, "remote", "get-url", "--push", remote).Output()
+	if err != nil {
+		return fmt.Errorf("failed to get the push URL of %s: %w", remote, err)
+	}
+	url := strings.TrimSpace(string(output))
+	if err := checkPushURL(url); err != nil {
+		return fmt.Errorf("not pushing to %s: %w", remote, err)
+	}
+
+	ref := "refs/heads/" + branch
+	cmd := sandboxSafeGit(path, "-c", "protocol.ext.allow=never", "push", "--no-verify", url, ref+":"+ref)
+	cmd.Env = os.Environ()
+	if os.Getenv("GIT_SSH_COMMAND") == "" && os.Getenv("GIT_SSH") == "" {
+		sshCommand := "ssh"
+		if values := userConfigValues("core.sshCommand"); len(values) > 0 {
+			sshCommand = values[len(values)-1]
+		}
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND="+sshCommand)
+	}
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git push failed: %w", err)
+	}
+
+	// What push -u would have done, had it pushed to the remote by name
+	for _, args := range [][]string{
+		{"update-ref", "refs/remotes/" + remote + "/" + branch, ref},
+		{"config", "branch." + branch + ".remote", remote},
+		{"config", "branch." + branch + ".merge", ref},
+	} {
+		if output, err := sandboxSafeGit(path, args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to set the upstream of %s: %w\n%s", branch, err, output)
+		}
+	}
+	return nil
+}
+
+// checkPushURL rejects push URLs that don't name a plain transport: remote helpers
+// (transport::address, which for ext:: runs any command) and unknown URL schemes
+func checkPushURL(url string) error {
+	if i := strings.Index(url, "::"); i > 0 && !strings.ContainsAny(url[:i], ":/") {
+		return fmt.Errorf("%s uses the %s remote helper", url, url[:i])
+	}
+	if scheme, _, ok := strings.Cut(url, "://"); ok {
+		switch strings.ToLower(scheme) {
+		case "https", "http", "ssh", "git", "git+ssh", "ssh+git", "file":
+		default:
+			return fmt.Errorf("%s uses the unsupported %s:// transport", url, scheme)
+		}
+	}
+	return nil
+}
+
+// parseStatusZ extracts the paths from git status --porcelain -z output. A rename or copy
+// entry is followed by its source path, which is skipped.
+func parseStatusZ(output string) []string {
+	var paths []string
+	entries := strings.Split(output, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		paths = append(paths, entry[3:])
+		if entry[0] == 'R' || entry[0] == 'C' {
+			i++
+		}
+	}
+	return paths
+}
+
 // WorktreeExists checks if a worktree with the given name exists
 func WorktreeExists(worktreeName string) (bool, error) {
 	cmd := exec.Command("git", "worktree", "list", "--porcelain")
@@ -85,7 +354,13 @@ func WorktreeExists(worktreeName string) (bool, error) {
 
 // GetWorktreePath gets the actual path of an existing worktree
 func GetWorktreePath(worktreeName string) (string, error) {
-	cmd := exec.Command("git", "worktree", "list", "--porcelain")
+	return FindWorktree(".", worktreeName)
+}
+
+// FindWorktree returns the path of the worktree of the repository at repoPath that has
+// branch checked out
+func FindWorktree(repoPath, branch string) (string, error) {
+	cmd := exec.Command("git", "-C", repoPath, "worktree", "list", "--porcelain")
 	output, err := cmd.Output()
 	if err != nil {
 		return "", err
@@ -103,13 +378,50 @@ func GetWorktreePath(worktreeName string) (string, error) {
 		if strings.HasPrefix(line, "worktree ") {
 			currentPath = strings.TrimPrefix(line, "worktree ")
 		} else if strings.HasPrefix(line, "branch ") {
-			branch := strings.TrimPrefix(line, "branch refs/heads/")
-			if branch == worktreeName {
+			if strings.TrimPrefix(line, "branch refs/heads/") == branch {
 				return currentPath, nil
 			}
 		}
 	}
-	return "", fmt.Errorf("worktree '%s' not found", worktreeName)
+	return "", fmt.Errorf("worktree '%s' not found", branch)
+}
+
+// Worktree is a checkout of the repository with a branch
+type Worktree struct {
+	Path   string
+	Branch string // "" when detached
+}
+
+// ListWorktrees returns the worktrees of the repository at repoPath, the main checkout
+// first
+func ListWorktrees(repoPath string) ([]Worktree, error) {
+	cmd := exec.Command("git", "-C", repoPath, "worktree", "list", "--porcelain")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var worktrees []Worktree
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(line, "worktree ") {
+			worktrees = append(worktrees, Worktree{Path: strings.TrimPrefix(line, "worktree ")})
+		} else if strings.HasPrefix(line, "branch ") && len(worktrees) > 0 {
+			worktrees[len(worktrees)-1].Branch = strings.TrimPrefix(line, "branch refs/heads/")
+		}
+	}
+	return worktrees, nil
+}
+
+// MoveWorktree moves the worktree at from to to, creating to's parent directory
+func MoveWorktree(repoPath, from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+	output, err := sandboxSafeGit(repoPath, "worktree", "move", from, to).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git worktree move failed: %w\n%s", err, output)
+	}
+	return nil
 }
 
 // CreateWorktree creates a new worktree
@@ -140,3 +452,20 @@ func CreateWorktree(path, branchName string, verbose bool) error {
 
 	return cmd.Run()
 }
+
+// RemoveWorktree removes the worktree at path from the repository at repoPath, even with
+// changes in it, leaving its branch alone. A worktree whose directory was only half
+// created is deleted and pruned instead.
+func RemoveWorktree(repoPath, path string) error {
+	output, err := sandboxSafeGit(repoPath, "worktree", "remove", "--force", path).CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	if IsGitRepo(path) {
+		return fmt.Errorf("git worktree remove failed: %w\n%s", err, output)
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return sandboxSafeGit(repoPath, "worktree", "prune").Run()
+}
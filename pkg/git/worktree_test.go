@@ -1,6 +1,10 @@
 package git
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -53,3 +57,323 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestGetRemoteURL(t *testing.T) {
+	repoDir := t.TempDir()
+	if out, err := exec.Command("git", "-C", repoDir, "init", "-q").CombinedOutput(); err != nil {
+		t.Skipf("git init failed: %v: %s", err, out)
+	}
+	if _, err := GetRemoteURL(repoDir, "origin"); err == nil {
+		t.Error("GetRemoteURL() succeeded without an origin remote")
+	}
+
+	if out, err := exec.Command("git", "-C", repoDir, "remote", "add", "origin", "git@github.com:obra/packnplay.git").CombinedOutput(); err != nil {
+		t.Fatalf("git remote add failed: %v: %s", err, out)
+	}
+	url, err := GetRemoteURL(repoDir, "origin")
+	if err != nil || url != "git@github.com:obra/packnplay.git" {
+		t.Errorf("GetRemoteURL() = %q, %v", url, err)
+	}
+}
+
+func TestWorktreesRoot(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", "/data")
+	root, err := WorktreesRoot()
+	if err != nil {
+		t.Fatalf("WorktreesRoot() error = %v", err)
+	}
+	if root != "/data/packnplay/worktrees" {
+		t.Errorf("WorktreesRoot() = %v, want /data/packnplay/worktrees", root)
+	}
+
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("HOME", "/home/me")
+	root, err = WorktreesRoot()
+	if err != nil {
+		t.Fatalf("WorktreesRoot() error = %v", err)
+	}
+	if root != "/home/me/.local/share/packnplay/worktrees" {
+		t.Errorf("WorktreesRoot() = %v, want /home/me/.local/share/packnplay/worktrees", root)
+	}
+}
+
+func TestParseStatusZ(t *testing.T) {
+	output := " M pkg/a.go\x00?? new file.txt\x00R  renamed.go\x00original.go\x00D  gone.go\x00"
+	got := parseStatusZ(output)
+	want := []string{"pkg/a.go", "new file.txt", "renamed.go", "gone.go"}
+	if len(got) != len(want) {
+		t.Fatalf("parseStatusZ() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("parseStatusZ()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	repoDir := t.TempDir()
+	if out, err := exec.Command("git", "-C", repoDir, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, out)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "notes.txt"), []byte("hi\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ChangedFiles(repoDir)
+	if err != nil {
+		t.Fatalf("ChangedFiles() error = %v", err)
+	}
+	if len(got) != 1 || got[0] != "notes.txt" {
+		t.Errorf("ChangedFiles() = %q, want [notes.txt]", got)
+	}
+
+	if _, err := ChangedFiles(t.TempDir()); err == nil {
+		t.Error("ChangedFiles() outside a repo should fail")
+	}
+}
+
+func TestCommitAll(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	repoDir := t.TempDir()
+	if out, err := exec.Command("git", "-C", repoDir, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, out)
+	}
+
+	if hash, err := CommitAll(repoDir, "nothing"); err != nil || hash != "" {
+		t.Fatalf("CommitAll() on a clean tree = %q, %v, want no commit", hash, err)
+	}
+
+	// A hook planted in the checkout must not run
+	hook := filepath.Join(repoDir, ".git", "hooks", "pre-commit")
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "out.txt"), []byte("result\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := CommitAll(repoDir, "agent output")
+	if err != nil {
+		t.Fatalf("CommitAll() error = %v", err)
+	}
+	if hash == "" {
+		t.Fatal("CommitAll() made no commit")
+	}
+	subject, err := exec.Command("git", "-C", repoDir, "log", "-1", "--format=%s").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(subject); got != "agent output\n" {
+		t.Errorf("commit subject = %q", got)
+	}
+	if changed, _ := ChangedFiles(repoDir); len(changed) != 0 {
+		t.Errorf("ChangedFiles() after commit = %q", changed)
+	}
+}
+
+func TestCommitAllIgnoresRepositoryPrograms(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	repoDir := t.TempDir()
+	if out, err := exec.Command("git", "-C", repoDir, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, out)
+	}
+
+	// The agent sets up a filter driver, directly and through an include, and moves the
+	// work tree to a directory it wants committed
+	pwned := filepath.Join(t.TempDir(), "pwned")
+	elsewhere := t.TempDir()
+	if err := os.WriteFile(filepath.Join(elsewhere, "secret"), []byte("key\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	included := filepath.Join(repoDir, ".git", "extra")
+	if err := os.WriteFile(included, []byte("[filter \"inc\"]\n\tclean = touch "+pwned+"-inc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, kv := range [][]string{
+		{"filter.evil.clean", "touch " + pwned},
+		{"filter.evil.required", "true"},
+		{"include.path", "extra"},
+		{"core.worktree", elsewhere},
+	} {
+		if out, err := exec.Command("git", "-C", repoDir, "config", kv[0], kv[1]).CombinedOutput(); err != nil {
+			t.Fatalf("git config failed: %v\n%s", err, out)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, ".gitattributes"), []byte("*.txt filter=evil\n*.md filter=inc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"out.txt", "notes.md"} {
+		if err := os.WriteFile(filepath.Join(repoDir, name), []byte("result\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := CommitAll(repoDir, "agent output"); err != nil {
+		t.Fatalf("CommitAll() error = %v", err)
+	}
+	for _, path := range []string{pwned, pwned + "-inc"} {
+		if _, err := os.Stat(path); err == nil {
+			t.Errorf("a filter configured in the repository ran (%s exists)", path)
+		}
+	}
+	files, err := exec.Command("git", "-C", repoDir, "ls-tree", "-r", "--name-only", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(files); got != ".gitattributes\nnotes.md\nout.txt\n" {
+		t.Errorf("committed files = %q, want the checkout's", got)
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	repoDir := t.TempDir()
+	if out, err := exec.Command("git", "-C", repoDir, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, out)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "notes.txt"), []byte("hi\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	patch := []byte("diff --git a/notes.txt b/notes.txt\n--- a/notes.txt\n+++ b/notes.txt\n@@ -1 +1,2 @@\n hi\n+there\n")
+	stat, err := PatchStat(repoDir, patch)
+	if err != nil || !strings.Contains(stat, "notes.txt") {
+		t.Errorf("PatchStat() = %q, %v", stat, err)
+	}
+	if err := ApplyPatch(repoDir, patch); err != nil {
+		t.Fatalf("ApplyPatch() error = %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repoDir, "notes.txt")); string(data) != "hi\nthere\n" {
+		t.Errorf("notes.txt = %q", data)
+	}
+
+	// Applied twice, the context no longer fits
+	if err := ApplyPatch(repoDir, patch); err == nil {
+		t.Error("ApplyPatch() of a stale patch succeeded")
+	}
+	hook := []byte("diff --git a/.git/hooks/pre-commit b/.git/hooks/pre-commit\nnew file mode 100755\n--- /dev/null\n+++ b/.git/hooks/pre-commit\n@@ -0,0 +1 @@\n+evil\n")
+	if err := ApplyPatch(repoDir, hook); err == nil {
+		t.Error("ApplyPatch() wrote into .git")
+	}
+}
+
+func TestFindWorktreeAndPush(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	run := func(args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	remoteDir := t.TempDir()
+	repoDir := t.TempDir()
+	worktreeDir := filepath.Join(t.TempDir(), "feature")
+	run("init", "-q", "--bare", remoteDir)
+	run("init", "-q", "-b", "main", repoDir)
+	run("-C", repoDir, "commit", "-q", "--allow-empty", "-m", "initial")
+	run("-C", repoDir, "remote", "add", "origin", remoteDir)
+	run("-C", repoDir, "worktree", "add", "-q", "-b", "feature", worktreeDir)
+
+	got, err := FindWorktree(repoDir, "feature")
+	if err != nil {
+		t.Fatalf("FindWorktree() error = %v", err)
+	}
+	if resolved, _ := filepath.EvalSymlinks(worktreeDir); got != worktreeDir && got != resolved {
+		t.Errorf("FindWorktree() = %q, want %q", got, worktreeDir)
+	}
+	if _, err := FindWorktree(repoDir, "missing"); err == nil {
+		t.Error("FindWorktree() should fail for a branch without a worktree")
+	}
+
+	// A planted pre-push hook must not run
+	hook := filepath.Join(repoDir, ".git", "hooks", "pre-push")
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Push(worktreeDir, "origin", "feature"); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	run("-C", remoteDir, "rev-parse", "--verify", "-q", "refs/heads/feature")
+	if upstream, err := exec.Command("git", "-C", worktreeDir, "rev-parse", "--abbrev-ref", "feature@{upstream}").Output(); err != nil || string(upstream) != "origin/feature\n" {
+		t.Errorf("upstream = %q, %v, want origin/feature", upstream, err)
+	}
+
+	// A push URL the agent pointed at the ext:: helper must not run its command
+	pwned := filepath.Join(t.TempDir(), "pwned")
+	run("-C", repoDir, "config", "url.ext::sh -c touch% "+pwned+" #.pushInsteadOf", remoteDir)
+	if err := Push(worktreeDir, "origin", "feature"); err == nil || !strings.Contains(err.Error(), "remote helper") {
+		t.Errorf("Push() error = %v, want the ext:: URL refused", err)
+	}
+	if _, err := os.Stat(pwned); err == nil {
+		t.Error("the ext:: command ran")
+	}
+}
+
+func TestCheckPushURL(t *testing.T) {
+	for _, url := range []string{"https://github.com/obra/packnplay.git", "git@github.com:obra/packnplay.git", "ssh://git@host/repo", "/srv/git/repo.git", "file:///srv/git/repo.git"} {
+		if err := checkPushURL(url); err != nil {
+			t.Errorf("checkPushURL(%q) error = %v", url, err)
+		}
+	}
+	for _, url := range []string{"ext::sh -c touch% /tmp/x", "fd::3", "evil://host/repo"} {
+		if err := checkPushURL(url); err == nil {
+			t.Errorf("checkPushURL(%q) accepted it", url)
+		}
+	}
+}
+
+func TestRemoveWorktree(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	run := func(args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	repoDir := t.TempDir()
+	worktreeDir := filepath.Join(t.TempDir(), "feature")
+	run("init", "-q", "-b", "main", repoDir)
+	run("-C", repoDir, "commit", "-q", "--allow-empty", "-m", "initial")
+	run("-C", repoDir, "worktree", "add", "-q", "-b", "feature", worktreeDir)
+	if err := os.WriteFile(filepath.Join(worktreeDir, "wip.txt"), []byte("wip\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RemoveWorktree(repoDir, worktreeDir); err != nil {
+		t.Fatalf("RemoveWorktree() error = %v", err)
+	}
+	if _, err := os.Stat(worktreeDir); !os.IsNotExist(err) {
+		t.Errorf("worktree directory still exists: %v", err)
+	}
+	run("-C", repoDir, "rev-parse", "--verify", "-q", "refs/heads/feature")
+
+	// A directory git never finished setting up is just deleted
+	halfDir := filepath.Join(t.TempDir(), "half")
+	if err := os.MkdirAll(filepath.Join(halfDir, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveWorktree(repoDir, halfDir); err != nil {
+		t.Fatalf("RemoveWorktree() of a half-created worktree error = %v", err)
+	}
+	if _, err := os.Stat(halfDir); !os.IsNotExist(err) {
+		t.Errorf("half-created worktree still exists: %v", err)
+	}
+}
@@ -0,0 +1,342 @@
+// Package imageinfo collects what is known about a container image's origin: its
+// labels, creation metadata, base image and any SBOM or provenance attestations
+// published with it.
+package imageinfo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/obra/packnplay/pkg/docker"
+)
+
+// OCI annotation labels describing the base image
+const (
+	BaseNameLabel   = "org.opencontainers.image.base.name"
+	BaseDigestLabel = "org.opencontainers.image.base.digest"
+)
+
+// Info describes a locally pulled image
+type Info struct {
+	Reference     string
+	ID            string
+	RepoDigests   []string
+	Created       time.Time
+	Author        string
+	DockerVersion string
+	Platform      string
+	Size          int64
+	Labels        map[string]string
+	BaseImage     string
+	BaseDigest    string
+
+	// Attestations are read from the registry; AttestationError says why they
+	// couldn't be (e.g. the image was built locally or buildx is missing)
+	SBOMs            []SBOM
+	Provenance       []Provenance
+	AttestationError error
+}
+
+// SBOM summarizes an SPDX software bill of materials attached to one platform
+type SBOM struct {
+	Platform string
+	Version  string
+	Packages int
+	Creators []string
+}
+
+// Provenance summarizes a SLSA provenance attestation attached to one platform
+type Provenance struct {
+	Platform  string
+	BuilderID string
+	BuildType string
+	Source    string
+	Started   string
+	Materials []string
+}
+
+// SortedLabels returns the label keys in order
+func (i *Info) SortedLabels() []string {
+	keys := make([]string, 0, len(i.Labels))
+	for k := range i.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Inspect gathers local metadata for image and, when the registry has them, its
+// attestations. The image must already be pulled.
+func Inspect(dockerClient *docker.Client, image string) (*Info, error) {
+	info, err := InspectLocal(dockerClient, image)
+	if err != nil {
+		return nil, err
+	}
+
+	sbomOutput, err := dockerClient.Run("buildx", "imagetools", "inspect", image, "--format", "{{json .SBOM}}")
+	if err != nil {
+		info.AttestationError = fmt.Errorf("%s", firstLine(sbomOutput, err))
+		return info, nil
+	}
+	if info.SBOMs, err = parseSBOM(sbomOutput); err != nil {
+		info.AttestationError = err
+		return info, nil
+	}
+
+	provenanceOutput, err := dockerClient.Run("buildx", "imagetools", "inspect", image, "--format", "{{json .Provenance}}")
+	if err != nil {
+		info.AttestationError = fmt.Errorf("%s", firstLine(provenanceOutput, err))
+		return info, nil
+	}
+	if info.Provenance, err = parseProvenance(provenanceOutput); err != nil {
+		info.AttestationError = err
+		return info, nil
+	}
+
+	// Labels are authoritative, but most images only record the base in provenance
+	if info.BaseImage == "" {
+		for _, p := range info.Provenance {
+			if base := baseFromMaterials(p.Materials); base != "" {
+				info.BaseImage = base
+				break
+			}
+		}
+	}
+	return info, nil
+}
+
+// InspectLocal gathers local metadata for image, without asking the registry for
+// attestations
+func InspectLocal(dockerClient *docker.Client, image string) (*Info, error) {
+	output, err := dockerClient.Run("image", "inspect", "--format", "{{json .}}", image)
+	if err != nil {
+		return nil, fmt.Errorf("image %s not found locally (pull it with packnplay refresh-container or docker pull): %s", image, strings.TrimSpace(output))
+	}
+
+	info, err := parseInspect(output)
+	if err != nil {
+		return nil, err
+	}
+	info.Reference = image
+	return info, nil
+}
+
+// firstLine reports the first line of command output, or err if there was none
+func firstLine(output string, err error) string {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return err.Error()
+	}
+	return strings.SplitN(output, "\n", 2)[0]
+}
+
+// parseInspect parses `docker image inspect --format {{json .}}` output
+func parseInspect(output string) (*Info, error) {
+	var raw struct {
+		ID            string   `json:"Id"`
+		RepoDigests   []string `json:"RepoDigests"`
+		Created       string   `json:"Created"`
+		Author        string   `json:"Author"`
+		DockerVersion string   `json:"DockerVersion"`
+		Architecture  string   `json:"Architecture"`
+		Variant       string   `json:"Variant"`
+		Os            string   `json:"Os"`
+		Size          int64    `json:"Size"`
+		Config        struct {
+			Labels map[string]string `json:"Labels"`
+		} `json:"Config"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse image inspect output: %w", err)
+	}
+
+	info := &Info{
+		ID:            raw.ID,
+		RepoDigests:   raw.RepoDigests,
+		Author:        raw.Author,
+		DockerVersion: raw.DockerVersion,
+		Size:          raw.Size,
+		Labels:        raw.Config.Labels,
+		BaseImage:     raw.Config.Labels[BaseNameLabel],
+		BaseDigest:    raw.Config.Labels[BaseDigestLabel],
+	}
+	if created, err := time.Parse(time.RFC3339Nano, raw.Created); err == nil {
+		info.Created = created
+	}
+	if raw.Os != "" {
+		info.Platform = raw.Os + "/" + raw.Architecture
+		if raw.Variant != "" {
+			info.Platform += "/" + raw.Variant
+		}
+	}
+	return info, nil
+}
+
+// perPlatform splits imagetools output keyed by key ("SPDX" or "SLSA"). Single-platform
+// images put the key at the top level; multi-platform images nest it under each platform.
+func perPlatform(output, key string) (map[string]json.RawMessage, error) {
+	output = strings.TrimSpace(output)
+	if output == "" || output == "null" {
+		return nil, nil
+	}
+
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(output), &top); err != nil {
+		return nil, fmt.Errorf("failed to parse attestation: %w", err)
+	}
+	if doc, ok := top[key]; ok {
+		return map[string]json.RawMessage{"": doc}, nil
+	}
+
+	docs := make(map[string]json.RawMessage)
+	for platform, value := range top {
+		var nested map[string]json.RawMessage
+		if err := json.Unmarshal(value, &nested); err != nil {
+			continue
+		}
+		if doc, ok := nested[key]; ok && string(doc) != "null" {
+			docs[platform] = doc
+		}
+	}
+	return docs, nil
+}
+
+// sortedPlatforms returns the platforms of docs in order
+func sortedPlatforms(docs map[string]json.RawMessage) []string {
+	platforms := make([]string, 0, len(docs))
+	for p := range docs {
+		platforms = append(platforms, p)
+	}
+	sort.Strings(platforms)
+	return platforms
+}
+
+// parseSBOM parses `docker buildx imagetools inspect --format {{json .SBOM}}` output
+func parseSBOM(output string) ([]SBOM, error) {
+	docs, err := perPlatform(output, "SPDX")
+	if err != nil {
+		return nil, err
+	}
+
+	var sboms []SBOM
+	for _, platform := range sortedPlatforms(docs) {
+		var doc struct {
+			SPDXVersion  string            `json:"spdxVersion"`
+			Packages     []json.RawMessage `json:"packages"`
+			CreationInfo struct {
+				Creators []string `json:"creators"`
+			} `json:"creationInfo"`
+		}
+		if err := json.Unmarshal(docs[platform], &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse SBOM for %s: %w", platform, err)
+		}
+		sboms = append(sboms, SBOM{
+			Platform: platform,
+			Version:  doc.SPDXVersion,
+			Packages: len(doc.Packages),
+			Creators: doc.CreationInfo.Creators,
+		})
+	}
+	return sboms, nil
+}
+
+// parseProvenance parses `docker buildx imagetools inspect --format {{json .Provenance}}`
+// output. Both SLSA v0.2 and v1 predicates are understood.
+func parseProvenance(output string) ([]Provenance, error) {
+	docs, err := perPlatform(output, "SLSA")
+	if err != nil {
+		return nil, err
+	}
+
+	type material struct {
+		URI string `json:"uri"`
+	}
+	var provenance []Provenance
+	for _, platform := range sortedPlatforms(docs) {
+		var doc struct {
+			// SLSA v0.2
+			Builder struct {
+				ID string `json:"id"`
+			} `json:"builder"`
+			BuildType  string `json:"buildType"`
+			Invocation struct {
+				ConfigSource struct {
+					URI string `json:"uri"`
+				} `json:"configSource"`
+			} `json:"invocation"`
+			Metadata struct {
+				BuildStartedOn string `json:"buildStartedOn"`
+			} `json:"metadata"`
+			Materials []material `json:"materials"`
+
+			// SLSA v1
+			BuildDefinition struct {
+				BuildType            string     `json:"buildType"`
+				ResolvedDependencies []material `json:"resolvedDependencies"`
+			} `json:"buildDefinition"`
+			RunDetails struct {
+				Builder struct {
+					ID string `json:"id"`
+				} `json:"builder"`
+				Metadata struct {
+					StartedOn string `json:"startedOn"`
+				} `json:"metadata"`
+			} `json:"runDetails"`
+		}
+		if err := json.Unmarshal(docs[platform], &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse provenance for %s: %w", platform, err)
+		}
+
+		p := Provenance{
+			Platform:  platform,
+			BuilderID: firstNonEmpty(doc.Builder.ID, doc.RunDetails.Builder.ID),
+			BuildType: firstNonEmpty(doc.BuildType, doc.BuildDefinition.BuildType),
+			Source:    doc.Invocation.ConfigSource.URI,
+			Started:   firstNonEmpty(doc.Metadata.BuildStartedOn, doc.RunDetails.Metadata.StartedOn),
+		}
+		for _, m := range append(doc.Materials, doc.BuildDefinition.ResolvedDependencies...) {
+			if m.URI != "" {
+				p.Materials = append(p.Materials, m.URI)
+			}
+		}
+		provenance = append(provenance, p)
+	}
+	return provenance, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// baseFromMaterials returns the first image among provenance materials as an image
+// reference. BuildKit records images as package URLs such as
+// pkg:docker/ubuntu@24.04?platform=linux%2Famd64.
+func baseFromMaterials(materials []string) string {
+	for _, uri := range materials {
+		if !strings.HasPrefix(uri, "pkg:docker/") {
+			continue
+		}
+		ref := strings.TrimPrefix(uri, "pkg:docker/")
+		if i := strings.IndexAny(ref, "?#"); i >= 0 {
+			ref = ref[:i]
+		}
+		if unescaped, err := url.PathUnescape(ref); err == nil {
+			ref = unescaped
+		}
+		// The purl version is the tag, or a digest
+		if i := strings.LastIndex(ref, "@"); i >= 0 && !strings.HasPrefix(ref[i+1:], "sha256:") {
+			ref = ref[:i] + ":" + ref[i+1:]
+		}
+		return ref
+	}
+	return ""
+}
@@ -0,0 +1,157 @@
+package imageinfo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseInspect(t *testing.T) {
+	output := `{"Id":"sha256:abc","RepoDigests":["ghcr.io/obra/packnplay-default@sha256:def"],"Created":"2025-03-01T12:00:00.123456789Z","Author":"","DockerVersion":"","Architecture":"arm64","Variant":"v8","Os":"linux","Size":2048,"Config":{"Labels":{"org.opencontainers.image.base.name":"docker.io/library/ubuntu:24.04","org.opencontainers.image.base.digest":"sha256:base","org.opencontainers.image.source":"https://github.com/obra/packnplay"}}}`
+
+	info, err := parseInspect(output)
+	if err != nil {
+		t.Fatalf("parseInspect() error = %v", err)
+	}
+	if info.ID != "sha256:abc" || info.Size != 2048 {
+		t.Errorf("ID/Size = %q/%d", info.ID, info.Size)
+	}
+	if info.Platform != "linux/arm64/v8" {
+		t.Errorf("Platform = %q, want linux/arm64/v8", info.Platform)
+	}
+	if want := time.Date(2025, 3, 1, 12, 0, 0, 123456789, time.UTC); !info.Created.Equal(want) {
+		t.Errorf("Created = %v, want %v", info.Created, want)
+	}
+	if info.BaseImage != "docker.io/library/ubuntu:24.04" || info.BaseDigest != "sha256:base" {
+		t.Errorf("base = %q@%q", info.BaseImage, info.BaseDigest)
+	}
+	want := []string{BaseDigestLabel, BaseNameLabel, "org.opencontainers.image.source"}
+	if got := info.SortedLabels(); !reflect.DeepEqual(got, want) {
+		t.Errorf("SortedLabels() = %v, want %v", got, want)
+	}
+}
+
+func TestParseInspectInvalid(t *testing.T) {
+	if _, err := parseInspect("not json"); err == nil {
+		t.Error("parseInspect() expected error for invalid JSON")
+	}
+}
+
+func TestParseSBOM(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []SBOM
+	}{
+		{
+			name:   "none",
+			output: "{}",
+			want:   nil,
+		},
+		{
+			name:   "null",
+			output: "null\n",
+			want:   nil,
+		},
+		{
+			name:   "single platform",
+			output: `{"SPDX":{"spdxVersion":"SPDX-2.3","packages":[{},{},{}],"creationInfo":{"creators":["Tool: syft-v1.0.0"]}}}`,
+			want:   []SBOM{{Version: "SPDX-2.3", Packages: 3, Creators: []string{"Tool: syft-v1.0.0"}}},
+		},
+		{
+			name:   "multi platform",
+			output: `{"linux/arm64":{"SPDX":{"spdxVersion":"SPDX-2.3","packages":[{}]}},"linux/amd64":{"SPDX":{"spdxVersion":"SPDX-2.3","packages":[{},{}]}}}`,
+			want: []SBOM{
+				{Platform: "linux/amd64", Version: "SPDX-2.3", Packages: 2},
+				{Platform: "linux/arm64", Version: "SPDX-2.3", Packages: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSBOM(tt.output)
+			if err != nil {
+				t.Fatalf("parseSBOM() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSBOM() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseProvenance(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []Provenance
+	}{
+		{
+			name:   "slsa v0.2",
+			output: `{"SLSA":{"builder":{"id":"https://github.com/obra/packnplay/actions/runs/1"},"buildType":"https://mobyproject.org/buildkit@v1","invocation":{"configSource":{"uri":"https://github.com/obra/packnplay.git#refs/heads/main"}},"metadata":{"buildStartedOn":"2025-03-01T12:00:00Z"},"materials":[{"uri":"pkg:docker/ubuntu@24.04?platform=linux%2Famd64"}]}}`,
+			want: []Provenance{{
+				BuilderID: "https://github.com/obra/packnplay/actions/runs/1",
+				BuildType: "https://mobyproject.org/buildkit@v1",
+				Source:    "https://github.com/obra/packnplay.git#refs/heads/main",
+				Started:   "2025-03-01T12:00:00Z",
+				Materials: []string{"pkg:docker/ubuntu@24.04?platform=linux%2Famd64"},
+			}},
+		},
+		{
+			name:   "slsa v1",
+			output: `{"linux/amd64":{"SLSA":{"buildDefinition":{"buildType":"https://github.com/moby/buildkit/blob/master/docs/attestations/slsa-definitions.md","resolvedDependencies":[{"uri":"pkg:docker/debian@bookworm"}]},"runDetails":{"builder":{"id":"builder-1"},"metadata":{"startedOn":"2025-03-02T00:00:00Z"}}}}}`,
+			want: []Provenance{{
+				Platform:  "linux/amd64",
+				BuilderID: "builder-1",
+				BuildType: "https://github.com/moby/buildkit/blob/master/docs/attestations/slsa-definitions.md",
+				Started:   "2025-03-02T00:00:00Z",
+				Materials: []string{"pkg:docker/debian@bookworm"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseProvenance(tt.output)
+			if err != nil {
+				t.Fatalf("parseProvenance() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseProvenance() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBaseFromMaterials(t *testing.T) {
+	tests := []struct {
+		name      string
+		materials []string
+		want      string
+	}{
+		{
+			name:      "tag",
+			materials: []string{"pkg:docker/ubuntu@24.04?platform=linux%2Famd64"},
+			want:      "ubuntu:24.04",
+		},
+		{
+			name:      "registry and digest",
+			materials: []string{"https://example.com/file.tar", "pkg:docker/ghcr.io/org/base@sha256:abc"},
+			want:      "ghcr.io/org/base@sha256:abc",
+		},
+		{
+			name:      "no images",
+			materials: []string{"https://example.com/file.tar"},
+			want:      "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := baseFromMaterials(tt.materials); got != tt.want {
+				t.Errorf("baseFromMaterials() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+// Package inflight records operations packnplay has started but not finished, such as
+// creating a worktree or starting a container. A record outlives the process only when
+// packnplay dies halfway (a crash, Ctrl-C, a closed terminal), so the next run can find
+// what was left half set up and clean it up or pick it up again.
+package inflight
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Kind is what an operation was setting up
+type Kind string
+
+const (
+	Worktree  Kind = "worktree"  // git worktree add
+	Container Kind = "container" // starting a container and copying files into it
+)
+
+// Operation is an operation in progress, or one that was interrupted
+type Operation struct {
+	Kind    Kind      `json:"kind"`
+	Target  string    `json:"target"`            // Worktree path or container name
+	Repo    string    `json:"repo,omitempty"`    // Repository a worktree is added to
+	Runtime string    `json:"runtime,omitempty"` // Container runtime command
+	PID     int       `json:"pid"`
+	Started time.Time `json:"started"`
+
+	path string // record file, empty once done
+}
+
+// Dir returns where records are kept: $XDG_DATA_HOME/packnplay/inflight, by default
+// ~/.local/share/packnplay/inflight
+func Dir() string {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		homeDir, _ := os.UserHomeDir()
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	return filepath.Join(dataHome, "packnplay", "inflight")
+}
+
+// recordPath returns the record file of the operation on target, one per kind and target
+func recordPath(kind Kind, target string) string {
+	sum := sha256.Sum256([]byte(target))
+	return filepath.Join(Dir(), fmt.Sprintf("%s-%s.json", kind, hex.EncodeToString(sum[:])[:16]))
+}
+
+// Begin records that this process has started op. The record is written to a temporary
+// file and renamed into place, so a crash never leaves half a record behind.
+func Begin(op Operation) (*Operation, error) {
+	op.PID = os.Getpid()
+	op.Started = time.Now()
+	path := recordPath(op.Kind, op.Target)
+
+	data, err := json.MarshalIndent(op, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal operation: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".record-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write state file: %w", err)
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if err := errors.Join(writeErr, closeErr); err != nil {
+		_ = os.Remove(tmp.Name())
+		return nil, fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return nil, fmt.Errorf("failed to write state file: %w", err)
+	}
+
+	op.path = path
+	return &op, nil
+}
+
+// Done removes the record of op. It is safe to call more than once, and on nil, which
+// stands for an operation that couldn't be recorded.
+func (op *Operation) Done() {
+	if op == nil || op.path == "" {
+		return
+	}
+	_ = os.Remove(op.path)
+	op.path = ""
+}
+
+// Interrupted returns the recorded operations whose process is gone, oldest first.
+// Operations of packnplay processes still running are in progress, not interrupted.
+func Interrupted() ([]Operation, error) {
+	entries, err := os.ReadDir(Dir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read state directory: %w", err)
+	}
+
+	var ops []Operation
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(Dir(), entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var op Operation
+		if err := json.Unmarshal(data, &op); err != nil || op.Target == "" {
+			// Records are renamed into place whole, so this isn't ours to interpret
+			continue
+		}
+		if processAlive(op.PID) {
+			continue
+		}
+		op.path = path
+		ops = append(ops, op)
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].Started.Before(ops[j].Started) })
+	return ops, nil
+}
+
+// Describe says what op was doing when it was interrupted
+func (op Operation) Describe() string {
+	switch op.Kind {
+	case Worktree:
+		return fmt.Sprintf("creating worktree %s", op.Target)
+	case Container:
+		return fmt.Sprintf("starting container %s", op.Target)
+	}
+	return fmt.Sprintf("%s %s", op.Kind, op.Target)
+}
+
+// processAlive reports whether a process with pid exists. Signal 0 checks without
+// sending anything; EPERM means it exists but belongs to someone else.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,79 @@
+package inflight
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBeginDone(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	op, err := Begin(Operation{Kind: Worktree, Target: "/worktrees/app/feature", Repo: "/src/app"})
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if op.PID != os.Getpid() || op.Started.IsZero() {
+		t.Errorf("Begin() = %+v, want this process and a start time", op)
+	}
+	if _, err := os.Stat(recordPath(Worktree, "/worktrees/app/feature")); err != nil {
+		t.Fatalf("record not written: %v", err)
+	}
+
+	// This process is alive, so its operation is in progress rather than interrupted
+	if ops, err := Interrupted(); err != nil || len(ops) != 0 {
+		t.Errorf("Interrupted() = %v, %v, want none", ops, err)
+	}
+
+	op.Done()
+	op.Done()
+	if entries, _ := os.ReadDir(Dir()); len(entries) != 0 {
+		t.Errorf("Done() left %d files behind", len(entries))
+	}
+
+	var none *Operation
+	none.Done()
+}
+
+func TestInterrupted(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	if ops, err := Interrupted(); err != nil || len(ops) != 0 {
+		t.Fatalf("Interrupted() without a state directory = %v, %v", ops, err)
+	}
+
+	write := func(op Operation) {
+		t.Helper()
+		data, _ := json.Marshal(op)
+		if err := os.MkdirAll(Dir(), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(recordPath(op.Kind, op.Target), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	write(Operation{Kind: Container, Target: "packnplay-app-main", Runtime: "docker", PID: -1, Started: now})
+	write(Operation{Kind: Worktree, Target: "/worktrees/app/main", Repo: "/src/app", PID: -1, Started: now.Add(-time.Minute)})
+	write(Operation{Kind: Container, Target: "packnplay-app-live", PID: os.Getpid(), Started: now})
+	if err := os.WriteFile(filepath.Join(Dir(), "garbage.json"), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ops, err := Interrupted()
+	if err != nil {
+		t.Fatalf("Interrupted() error = %v", err)
+	}
+	if len(ops) != 2 || ops[0].Kind != Worktree || ops[1].Target != "packnplay-app-main" {
+		t.Fatalf("Interrupted() = %+v, want the worktree then the container", ops)
+	}
+	if got := ops[0].Describe(); got != "creating worktree /worktrees/app/main" {
+		t.Errorf("Describe() = %q", got)
+	}
+
+	ops[0].Done()
+	if ops, _ := Interrupted(); len(ops) != 1 {
+		t.Errorf("Done() on an interrupted operation left %d", len(ops))
+	}
+}
@@ -0,0 +1,220 @@
+// Package kube reads kubeconfig files for the kube credential provider. Users that
+// authenticate with an exec plugin (aws eks get-token, gke-gcloud-auth-plugin,
+// kubelogin, ...) only work where the plugin and its own credentials exist, so their
+// plugins are run on the host and the credentials they print put in their place.
+package kube
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// execTimeout bounds how long an exec plugin may take
+const execTimeout = 30 * time.Second
+
+// Paths returns the kubeconfig files kubectl would read: those listed in KUBECONFIG, or
+// ~/.kube/config
+func Paths(homeDir string) []string {
+	if list := os.Getenv("KUBECONFIG"); list != "" {
+		var paths []string
+		for _, path := range filepath.SplitList(list) {
+			if path != "" {
+				paths = append(paths, path)
+			}
+		}
+		return paths
+	}
+	return []string{filepath.Join(homeDir, ".kube", "config")}
+}
+
+// ExecConfig is a user's exec plugin, as in the kubeconfig
+type ExecConfig struct {
+	APIVersion string   `yaml:"apiVersion"`
+	Command    string   `yaml:"command"`
+	Args       []string `yaml:"args"`
+	Env        []struct {
+		Name  string `yaml:"name"`
+		Value string `yaml:"value"`
+	} `yaml:"env"`
+}
+
+// execCredential is the part of an exec plugin's output that holds the credentials
+type execCredential struct {
+	Status struct {
+		Token                 string `json:"token"`
+		ClientCertificateData string `json:"clientCertificateData"`
+		ClientKeyData         string `json:"clientKeyData"`
+		ExpirationTimestamp   string `json:"expirationTimestamp"`
+	} `json:"status"`
+}
+
+// Resolved is a kubeconfig with its exec plugins run
+type Resolved struct {
+	Data      []byte           // the kubeconfig with each resolved user's exec replaced by its credentials
+	ExecUsers []string         // users that had an exec plugin
+	Failed    map[string]error // users whose plugin failed; their exec is left as it was
+	Expires   time.Time        // earliest expiry of the resolved credentials, zero when none expire
+}
+
+// RunExec runs an exec plugin and returns what it printed. Replaced in tests.
+var RunExec = func(ctx context.Context, dir string, config ExecConfig) ([]byte, error) {
+	command := config.Command
+	// Relative paths with a slash are relative to the kubeconfig, as kubectl does it
+	if strings.Contains(command, "/") && !filepath.IsAbs(command) {
+		command = filepath.Join(dir, command)
+	}
+	cmd := exec.CommandContext(ctx, command, config.Args...)
+	cmd.Env = os.Environ()
+	for _, env := range config.Env {
+		cmd.Env = append(cmd.Env, env.Name+"="+env.Value)
+	}
+	info, _ := json.Marshal(map[string]any{
+		"apiVersion": config.APIVersion,
+		"kind":       "ExecCredential",
+		"spec":       map[string]any{"interactive": false},
+	})
+	cmd.Env = append(cmd.Env, "KUBERNETES_EXEC_INFO="+string(info))
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%s timed out after %s", config.Command, execTimeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s failed: %s", config.Command, msg)
+		}
+		return nil, fmt.Errorf("%s failed: %w", config.Command, err)
+	}
+	return output, nil
+}
+
+// ResolveExec runs the exec plugin of each user in the kubeconfig data, read from dir, and
+// replaces it with the token or client certificate the plugin printed. Users whose plugin
+// fails are reported in Failed and left unchanged. Data is nil when no user has a plugin.
+func ResolveExec(data []byte, dir string) (*Resolved, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %w", err)
+	}
+
+	resolved := &Resolved{Failed: make(map[string]error)}
+	users, _ := doc["users"].([]any)
+	for _, entry := range users {
+		named, _ := entry.(map[string]any)
+		user, _ := named["user"].(map[string]any)
+		execSection, ok := user["exec"]
+		if !ok {
+			continue
+		}
+		name, _ := named["name"].(string)
+		resolved.ExecUsers = append(resolved.ExecUsers, name)
+
+		var config ExecConfig
+		raw, _ := yaml.Marshal(execSection)
+		if err := yaml.Unmarshal(raw, &config); err != nil || config.Command == "" {
+			resolved.Failed[name] = fmt.Errorf("exec section has no command")
+			continue
+		}
+		cred, err := runPlugin(dir, config)
+		if err != nil {
+			resolved.Failed[name] = err
+			continue
+		}
+
+		delete(user, "exec")
+		if cred.Status.Token != "" {
+			user["token"] = cred.Status.Token
+		} else {
+			user["client-certificate-data"] = base64.StdEncoding.EncodeToString([]byte(cred.Status.ClientCertificateData))
+			user["client-key-data"] = base64.StdEncoding.EncodeToString([]byte(cred.Status.ClientKeyData))
+		}
+		if expires, err := time.Parse(time.RFC3339, cred.Status.ExpirationTimestamp); err == nil {
+			if resolved.Expires.IsZero() || expires.Before(resolved.Expires) {
+				resolved.Expires = expires
+			}
+		}
+	}
+	sort.Strings(resolved.ExecUsers)
+
+	if len(resolved.ExecUsers) == 0 {
+		return resolved, nil
+	}
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+	resolved.Data = out
+	return resolved, nil
+}
+
+// runPlugin runs an exec plugin and parses the credentials it printed
+func runPlugin(dir string, config ExecConfig) (*execCredential, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), execTimeout)
+	defer cancel()
+
+	output, err := RunExec(ctx, dir, config)
+	if err != nil {
+		return nil, err
+	}
+	var cred execCredential
+	if err := json.Unmarshal(output, &cred); err != nil {
+		return nil, fmt.Errorf("failed to parse %s output: %w", config.Command, err)
+	}
+	if cred.Status.Token == "" && (cred.Status.ClientCertificateData == "" || cred.Status.ClientKeyData == "") {
+		return nil, fmt.Errorf("%s printed no token or client certificate", config.Command)
+	}
+	return &cred, nil
+}
+
+// WriteResolved saves a resolved copy of the kubeconfig at source under the packnplay data
+// directory and returns its path. Only the user can read it, since it holds credentials.
+// The file is replaced rather than rewritten, so running containers keep the copy they
+// mounted.
+func WriteResolved(source string, data []byte) (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	dir := filepath.Join(dataHome, "packnplay", "kube")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	sum := sha256.Sum256([]byte(source))
+	path := filepath.Join(dir, hex.EncodeToString(sum[:8])+".yaml")
+	tmp, err := os.CreateTemp(dir, ".kubeconfig-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to write resolved kubeconfig: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to write resolved kubeconfig: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,172 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const execKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: prod
+  cluster:
+    server: https://prod.example.com
+users:
+- name: eks
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: aws
+      args: [eks, get-token, --cluster-name, prod]
+      env:
+      - name: AWS_PROFILE
+        value: prod
+- name: broken
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: kubelogin
+- name: static
+  user:
+    token: abc123
+`
+
+func TestPaths(t *testing.T) {
+	t.Setenv("KUBECONFIG", "")
+	if got := Paths("/home/me"); !reflect.DeepEqual(got, []string{"/home/me/.kube/config"}) {
+		t.Errorf("Paths() = %v", got)
+	}
+	t.Setenv("KUBECONFIG", "/a/config"+string(os.PathListSeparator)+string(os.PathListSeparator)+"/b/dev.yaml")
+	if got := Paths("/home/me"); !reflect.DeepEqual(got, []string{"/a/config", "/b/dev.yaml"}) {
+		t.Errorf("Paths() = %v", got)
+	}
+}
+
+func TestResolveExec(t *testing.T) {
+	expires := time.Now().Add(15 * time.Minute).UTC().Truncate(time.Second)
+	original := RunExec
+	defer func() { RunExec = original }()
+	var ran []ExecConfig
+	RunExec = func(ctx context.Context, dir string, config ExecConfig) ([]byte, error) {
+		ran = append(ran, config)
+		if config.Command == "kubelogin" {
+			return nil, errors.New("kubelogin failed: not logged in")
+		}
+		return []byte(`{"kind":"ExecCredential","status":{"token":"k8s-aws-v1.xyz","expirationTimestamp":"` + expires.Format(time.RFC3339) + `"}}`), nil
+	}
+
+	resolved, err := ResolveExec([]byte(execKubeconfig), "/home/me/.kube")
+	if err != nil {
+		t.Fatalf("ResolveExec() error = %v", err)
+	}
+	if !reflect.DeepEqual(resolved.ExecUsers, []string{"broken", "eks"}) {
+		t.Errorf("ExecUsers = %v", resolved.ExecUsers)
+	}
+	if len(resolved.Failed) != 1 || resolved.Failed["broken"] == nil {
+		t.Errorf("Failed = %v, want only broken", resolved.Failed)
+	}
+	if !resolved.Expires.Equal(expires) {
+		t.Errorf("Expires = %v, want %v", resolved.Expires, expires)
+	}
+	if len(ran) != 2 || ran[0].Command != "aws" || strings.Join(ran[0].Args, " ") != "eks get-token --cluster-name prod" || ran[0].Env[0].Name != "AWS_PROFILE" {
+		t.Errorf("plugins run = %+v", ran)
+	}
+
+	var doc struct {
+		Users []struct {
+			Name string         `yaml:"name"`
+			User map[string]any `yaml:"user"`
+		} `yaml:"users"`
+	}
+	if err := yaml.Unmarshal(resolved.Data, &doc); err != nil {
+		t.Fatalf("resolved kubeconfig doesn't parse: %v", err)
+	}
+	users := map[string]map[string]any{}
+	for _, u := range doc.Users {
+		users[u.Name] = u.User
+	}
+	if users["eks"]["token"] != "k8s-aws-v1.xyz" || users["eks"]["exec"] != nil {
+		t.Errorf("eks user = %v, want its exec replaced by the token", users["eks"])
+	}
+	if users["broken"]["exec"] == nil {
+		t.Errorf("broken user = %v, want its exec kept", users["broken"])
+	}
+	if users["static"]["token"] != "abc123" {
+		t.Errorf("static user = %v, want it unchanged", users["static"])
+	}
+}
+
+func TestResolveExecWithoutPlugins(t *testing.T) {
+	resolved, err := ResolveExec([]byte("apiVersion: v1\nusers:\n- name: me\n  user:\n    token: abc\n"), "/tmp")
+	if err != nil {
+		t.Fatalf("ResolveExec() error = %v", err)
+	}
+	if resolved.Data != nil || len(resolved.ExecUsers) != 0 {
+		t.Errorf("ResolveExec() = %+v, want nothing to resolve", resolved)
+	}
+
+	if _, err := ResolveExec([]byte("users: [unclosed"), "/tmp"); err == nil {
+		t.Error("expected an error for a kubeconfig that doesn't parse")
+	}
+}
+
+func TestRunExec(t *testing.T) {
+	dir := t.TempDir()
+	plugin := filepath.Join(dir, "bin", "plugin")
+	if err := os.MkdirAll(filepath.Dir(plugin), 0755); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\necho \"{\\\"status\\\":{\\\"token\\\":\\\"$1-$CLUSTER\\\"}}\"\necho \"$KUBERNETES_EXEC_INFO\" >&2\nexit ${FAIL:-0}\n"
+	if err := os.WriteFile(plugin, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	config := ExecConfig{APIVersion: "client.authentication.k8s.io/v1", Command: "./bin/plugin", Args: []string{"token"}}
+	config.Env = append(config.Env, struct {
+		Name  string `yaml:"name"`
+		Value string `yaml:"value"`
+	}{"CLUSTER", "prod"})
+	output, err := RunExec(context.Background(), dir, config)
+	if err != nil {
+		t.Fatalf("RunExec() error = %v", err)
+	}
+	if strings.TrimSpace(string(output)) != `{"status":{"token":"token-prod"}}` {
+		t.Errorf("RunExec() = %q", output)
+	}
+
+	t.Setenv("FAIL", "1")
+	if _, err := RunExec(context.Background(), dir, config); err == nil || !strings.Contains(err.Error(), "ExecCredential") {
+		t.Errorf("RunExec() error = %v, want the plugin's stderr", err)
+	}
+}
+
+func TestWriteResolved(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	path, err := WriteResolved("/home/me/.kube/config", []byte("users: []\n"))
+	if err != nil {
+		t.Fatalf("WriteResolved() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	again, err := WriteResolved("/home/me/.kube/config", []byte("users: [x]\n"))
+	if err != nil || again != path {
+		t.Errorf("WriteResolved() = %s, %v, want the same path %s", again, err, path)
+	}
+	if other, _ := WriteResolved("/home/me/.kube/dev.yaml", nil); other == path {
+		t.Error("different kubeconfigs should get different copies")
+	}
+}
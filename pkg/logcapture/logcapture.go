@@ -0,0 +1,128 @@
+// Package logcapture saves session output to a log file per container under the packnplay
+// data directory, rotating it by size, so sessions can be looked at after the fact even
+// once their container is gone.
+package logcapture
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dir returns where logs are kept: $XDG_DATA_HOME/packnplay/logs, by default
+// ~/.local/share/packnplay/logs
+func Dir() string {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		homeDir, _ := os.UserHomeDir()
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	return filepath.Join(dataHome, "packnplay", "logs")
+}
+
+// Path returns the log file of containerName
+func Path(containerName string) string {
+	return filepath.Join(Dir(), containerName+".log")
+}
+
+// File is a log file that rotates once it reaches MaxSize: name.log becomes name.log.1,
+// name.log.1 becomes name.log.2, and so on, keeping MaxFiles rotated logs
+type File struct {
+	mu       sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+	err      error // first write error, reported by Close
+}
+
+// Open opens the log at path for appending (mode 0600, since sessions may show secrets)
+func Open(path string, maxSize int64, maxFiles int) (*File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	f := &File{path: path, maxSize: maxSize, maxFiles: maxFiles}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *File) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// StartSession writes a header marking the start of a session running command
+func (f *File) StartSession(command []string) {
+	_, _ = fmt.Fprintf(f, "=== %s packnplay: %s ===\n", time.Now().Format(time.RFC3339), strings.Join(command, " "))
+}
+
+// Write appends p, rotating first if p would take the log past its maximum size. Like a
+// recording, it never fails, so a full disk can't break the session it copies; the first
+// error is returned by Close instead.
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil || f.file == nil {
+		return len(p), nil
+	}
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if f.err = f.rotate(); f.err != nil {
+			return len(p), nil
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if err != nil {
+		f.err = err
+	}
+	return len(p), nil
+}
+
+// rotate shifts the rotated logs up by one, dropping the oldest, and starts a new log
+func (f *File) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+
+	_ = os.Remove(fmt.Sprintf("%s.%d", f.path, f.maxFiles))
+	for i := f.maxFiles - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+	}
+	if f.maxFiles > 0 {
+		if err := os.Rename(f.path, f.path+".1"); err != nil {
+			return fmt.Errorf("failed to rotate log file: %w", err)
+		}
+	} else if err := os.Remove(f.path); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	return f.open()
+}
+
+// Close closes the log, returning the first error writing it
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file != nil {
+		if err := f.file.Close(); err != nil && f.err == nil {
+			f.err = err
+		}
+		f.file = nil
+	}
+	return f.err
+}
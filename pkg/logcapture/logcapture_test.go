@@ -0,0 +1,73 @@
+package logcapture
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", "/data")
+	if got := Path("packnplay-app-main"); got != "/data/packnplay/logs/packnplay-app-main.log" {
+		t.Errorf("Path() = %q", got)
+	}
+}
+
+func TestFileRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	f, err := Open(path, 10, 2)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		path:        "fourth\n",
+		path + ".1": "third\n",
+		path + ".2": "second\n",
+	}
+	for file, content := range want {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("reading %s: %v", file, err)
+		}
+		if string(data) != content {
+			t.Errorf("%s = %q, want %q", filepath.Base(file), data, content)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); err == nil {
+		t.Error("rotation kept more than max files")
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("log mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestFileAppendsAcrossSessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	for _, command := range [][]string{{"claude"}, {"npm", "test"}} {
+		f, err := Open(path, 1<<20, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.StartSession(command)
+		_, _ = f.Write([]byte("output\n"))
+		_ = f.Close()
+	}
+
+	data, _ := os.ReadFile(path)
+	if got := strings.Count(string(data), "packnplay: "); got != 2 {
+		t.Errorf("log has %d session headers, want 2:\n%s", got, data)
+	}
+	if !strings.Contains(string(data), "packnplay: npm test ===\noutput\n") {
+		t.Errorf("log = %q", data)
+	}
+}
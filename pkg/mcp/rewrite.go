@@ -0,0 +1,196 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/obra/packnplay/pkg/config"
+)
+
+// HostGatewayName is the hostname containers use to reach the host
+const HostGatewayName = "host.docker.internal"
+
+// RewriteResult holds a rewritten .claude.json and what the container needs to use it
+type RewriteResult struct {
+	Data             []byte
+	NeedsHostGateway bool     // a server URL was pointed at HostGatewayName
+	Changed          []string // names of servers that were rewritten or removed
+}
+
+// RewriteClaudeJSON applies MCP server mappings to the contents of a .claude.json file.
+// Both the global "mcpServers" block and per-project "projects.<path>.mcpServers" blocks
+// are rewritten. A mapping named "*" applies to every server without its own mapping.
+// Unknown keys are preserved.
+func RewriteClaudeJSON(data []byte, mappings map[string]config.MCPServerMapping) (*RewriteResult, error) {
+	result := &RewriteResult{Data: data}
+	if len(mappings) == 0 {
+		return result, nil
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse .claude.json: %w", err)
+	}
+
+	rewriteServers(doc, mappings, result)
+
+	if projects, ok := doc["projects"].(map[string]interface{}); ok {
+		for _, project := range projects {
+			if projectDoc, ok := project.(map[string]interface{}); ok {
+				rewriteServers(projectDoc, mappings, result)
+			}
+		}
+	}
+
+	if len(result.Changed) == 0 {
+		return result, nil
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal .claude.json: %w", err)
+	}
+	result.Data = out
+	return result, nil
+}
+
+// rewriteServers rewrites the mcpServers block of a single document level
+func rewriteServers(doc map[string]interface{}, mappings map[string]config.MCPServerMapping, result *RewriteResult) {
+	servers, ok := doc["mcpServers"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for name, raw := range servers {
+		mapping, ok := mappings[name]
+		if !ok {
+			mapping, ok = mappings["*"]
+			if !ok {
+				continue
+			}
+		}
+
+		if mapping.Disabled {
+			delete(servers, name)
+			result.Changed = append(result.Changed, name)
+			continue
+		}
+
+		server, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if applyMapping(server, mapping, result) {
+			result.Changed = append(result.Changed, name)
+		}
+	}
+}
+
+// applyMapping rewrites a single server definition, returning true if it changed
+func applyMapping(server map[string]interface{}, mapping config.MCPServerMapping, result *RewriteResult) bool {
+	changed := false
+
+	if mapping.Command != "" {
+		server["command"] = mapping.Command
+		changed = true
+	}
+	if mapping.Args != nil {
+		args := make([]interface{}, len(mapping.Args))
+		for i, arg := range mapping.Args {
+			args[i] = arg
+		}
+		server["args"] = args
+		changed = true
+	}
+	if len(mapping.Env) > 0 {
+		env, _ := server["env"].(map[string]interface{})
+		if env == nil {
+			env = make(map[string]interface{})
+		}
+		for k, v := range mapping.Env {
+			env[k] = v
+		}
+		server["env"] = env
+		changed = true
+	}
+	if mapping.URL != "" {
+		server["url"] = mapping.URL
+		changed = true
+	}
+	if mapping.ForwardHostPorts {
+		if rawURL, ok := server["url"].(string); ok {
+			if rewritten, ok := pointAtHostGateway(rawURL); ok {
+				server["url"] = rewritten
+				result.NeedsHostGateway = true
+				changed = true
+			}
+		}
+	}
+
+	return changed
+}
+
+// pointAtHostGateway replaces a loopback host in rawURL with HostGatewayName
+func pointAtHostGateway(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL, false
+	}
+
+	host := u.Hostname()
+	if host != "localhost" && host != "0.0.0.0" {
+		ip := net.ParseIP(host)
+		if ip == nil || !ip.IsLoopback() {
+			return rawURL, false
+		}
+	}
+
+	if port := u.Port(); port != "" {
+		u.Host = net.JoinHostPort(HostGatewayName, port)
+	} else {
+		u.Host = HostGatewayName
+	}
+	return u.String(), true
+}
+
+// RestoreHostServers returns the container's .claude.json with every mcpServers block,
+// global and per project, taken from the host's, so the rest of what changed in the
+// container can be written back without the servers the mappings rewrote for it
+func RestoreHostServers(containerData, hostData []byte) ([]byte, error) {
+	var doc, host map[string]interface{}
+	if err := json.Unmarshal(containerData, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse the container's .claude.json: %w", err)
+	}
+	if err := json.Unmarshal(hostData, &host); err != nil {
+		return nil, fmt.Errorf("failed to parse .claude.json: %w", err)
+	}
+
+	restoreServers(doc, host)
+	hostProjects, _ := host["projects"].(map[string]interface{})
+	if projects, ok := doc["projects"].(map[string]interface{}); ok {
+		for path, project := range projects {
+			if projectDoc, ok := project.(map[string]interface{}); ok {
+				hostProject, _ := hostProjects[path].(map[string]interface{})
+				restoreServers(projectDoc, hostProject)
+			}
+		}
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal .claude.json: %w", err)
+	}
+	return out, nil
+}
+
+// restoreServers replaces the mcpServers block of a document level with host's
+func restoreServers(doc, host map[string]interface{}) {
+	if servers, ok := host["mcpServers"]; ok {
+		doc["mcpServers"] = servers
+	} else {
+		delete(doc, "mcpServers")
+	}
+}
@@ -0,0 +1,161 @@
+package mcp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/config"
+)
+
+const sampleClaudeJSON = `{
+  "numStartups": 12,
+  "mcpServers": {
+    "filesystem": {"type": "stdio", "command": "/opt/homebrew/bin/mcp-fs", "args": ["/Users/jesse"]},
+    "browser": {"type": "http", "url": "http://localhost:3333/mcp"},
+    "remote": {"type": "sse", "url": "https://mcp.example.com/sse"}
+  },
+  "projects": {
+    "/Users/jesse/app": {
+      "mcpServers": {
+        "db": {"type": "http", "url": "http://127.0.0.1:5050"}
+      }
+    }
+  }
+}`
+
+func decode(t *testing.T, data []byte) map[string]interface{} {
+	t.Helper()
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("failed to parse rewritten JSON: %v", err)
+	}
+	return doc
+}
+
+func TestRewriteClaudeJSONNoMappings(t *testing.T) {
+	result, err := RewriteClaudeJSON([]byte(sampleClaudeJSON), nil)
+	if err != nil {
+		t.Fatalf("RewriteClaudeJSON() error = %v", err)
+	}
+	if string(result.Data) != sampleClaudeJSON {
+		t.Error("RewriteClaudeJSON() with no mappings should return input unchanged")
+	}
+}
+
+func TestRewriteClaudeJSONCommandAndDisable(t *testing.T) {
+	mappings := map[string]config.MCPServerMapping{
+		"filesystem": {Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-filesystem", "/workspace"}},
+		"remote":     {Disabled: true},
+	}
+
+	result, err := RewriteClaudeJSON([]byte(sampleClaudeJSON), mappings)
+	if err != nil {
+		t.Fatalf("RewriteClaudeJSON() error = %v", err)
+	}
+
+	doc := decode(t, result.Data)
+	servers := doc["mcpServers"].(map[string]interface{})
+
+	fs := servers["filesystem"].(map[string]interface{})
+	if fs["command"] != "npx" {
+		t.Errorf("filesystem command = %v, want npx", fs["command"])
+	}
+	if args := fs["args"].([]interface{}); len(args) != 3 || args[2] != "/workspace" {
+		t.Errorf("filesystem args = %v", args)
+	}
+	if fs["type"] != "stdio" {
+		t.Errorf("unmapped keys should be preserved, type = %v", fs["type"])
+	}
+
+	if _, exists := servers["remote"]; exists {
+		t.Error("disabled server should be removed")
+	}
+
+	// Unrelated keys survive
+	if doc["numStartups"] != float64(12) {
+		t.Errorf("numStartups = %v, want 12", doc["numStartups"])
+	}
+}
+
+func TestRewriteClaudeJSONForwardHostPorts(t *testing.T) {
+	mappings := map[string]config.MCPServerMapping{
+		"*": {ForwardHostPorts: true},
+	}
+
+	result, err := RewriteClaudeJSON([]byte(sampleClaudeJSON), mappings)
+	if err != nil {
+		t.Fatalf("RewriteClaudeJSON() error = %v", err)
+	}
+
+	if !result.NeedsHostGateway {
+		t.Error("NeedsHostGateway should be set when localhost URLs are rewritten")
+	}
+
+	doc := decode(t, result.Data)
+	servers := doc["mcpServers"].(map[string]interface{})
+	if url := servers["browser"].(map[string]interface{})["url"]; url != "http://host.docker.internal:3333/mcp" {
+		t.Errorf("browser url = %v", url)
+	}
+	if url := servers["remote"].(map[string]interface{})["url"]; url != "https://mcp.example.com/sse" {
+		t.Errorf("remote url should be untouched, got %v", url)
+	}
+
+	project := doc["projects"].(map[string]interface{})["/Users/jesse/app"].(map[string]interface{})
+	db := project["mcpServers"].(map[string]interface{})["db"].(map[string]interface{})
+	if db["url"] != "http://host.docker.internal:5050" {
+		t.Errorf("project db url = %v", db["url"])
+	}
+}
+
+func TestRewriteClaudeJSONInvalid(t *testing.T) {
+	_, err := RewriteClaudeJSON([]byte("{not json"), map[string]config.MCPServerMapping{"x": {Disabled: true}})
+	if err == nil {
+		t.Error("RewriteClaudeJSON() should fail on invalid JSON")
+	}
+}
+
+func TestRestoreHostServers(t *testing.T) {
+	mappings := map[string]config.MCPServerMapping{"*": {ForwardHostPorts: true}}
+	result, err := RewriteClaudeJSON([]byte(sampleClaudeJSON), mappings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The container counts a startup and adds a project with its own server
+	container := decode(t, result.Data)
+	container["numStartups"] = 13
+	container["projects"].(map[string]interface{})["/workspace/new"] = map[string]interface{}{
+		"mcpServers": map[string]interface{}{"local": map[string]interface{}{"command": "/usr/bin/mcp"}},
+	}
+	containerData := mustMarshal(t, container)
+
+	restored, err := RestoreHostServers(containerData, []byte(sampleClaudeJSON))
+	if err != nil {
+		t.Fatalf("RestoreHostServers() error = %v", err)
+	}
+	doc := decode(t, restored)
+	if doc["numStartups"] != float64(13) {
+		t.Errorf("numStartups = %v, want the container's change kept", doc["numStartups"])
+	}
+	host := decode(t, []byte(sampleClaudeJSON))
+	if got := mustMarshal(t, doc["mcpServers"]); string(got) != string(mustMarshal(t, host["mcpServers"])) {
+		t.Errorf("mcpServers = %s, want the host's", got)
+	}
+	projects := doc["projects"].(map[string]interface{})
+	db := projects["/Users/jesse/app"].(map[string]interface{})["mcpServers"].(map[string]interface{})["db"].(map[string]interface{})
+	if db["url"] != "http://127.0.0.1:5050" {
+		t.Errorf("project db url = %v, want the host's", db["url"])
+	}
+	if _, ok := projects["/workspace/new"].(map[string]interface{})["mcpServers"]; ok {
+		t.Error("a server only the container has was written back")
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
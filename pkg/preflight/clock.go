@@ -0,0 +1,58 @@
+package preflight
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/obra/packnplay/pkg/docker"
+)
+
+// MaxClockSkew is how far the daemon's clock may drift from the host's before it is
+// reported. AWS rejects SigV4 requests skewed by more than 5 minutes, and short-lived
+// tokens and TLS certificates start failing around the same point.
+const MaxClockSkew = 2 * time.Minute
+
+// ErrClockUnsupported is returned by ClockSkew for runtimes whose info doesn't include
+// the clock: Podman and Apple Container
+var ErrClockUnsupported = errors.New("the runtime doesn't report its clock")
+
+// ClockSkew returns how far the daemon's clock is ahead of the host's (negative when it
+// is behind). Containers read the clock of the daemon's kernel, which on Docker Desktop,
+// OrbStack and Colima is a VM that can fall behind while the laptop sleeps.
+func ClockSkew(d docker.Runner, now func() time.Time) (time.Duration, error) {
+	if cmd := d.Command(); cmd == "podman" || cmd == "container" {
+		return 0, ErrClockUnsupported
+	}
+	before := now()
+	output, err := d.Run("info", "--format", "{{.SystemTime}}")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the daemon's clock: %w", err)
+	}
+	after := now()
+
+	daemonTime, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(output))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse the daemon's clock %q: %w", strings.TrimSpace(output), err)
+	}
+	// Compare against the middle of the round trip
+	hostTime := before.Add(after.Sub(before) / 2)
+	return daemonTime.Sub(hostTime), nil
+}
+
+// CheckClockSkew reports a skew, as measured by ClockSkew, of more than MaxClockSkew
+func CheckClockSkew(skew time.Duration) []Issue {
+	if skew.Abs() <= MaxClockSkew {
+		return nil
+	}
+	direction := "ahead of"
+	if skew < 0 {
+		direction, skew = "behind", -skew
+	}
+	return []Issue{{
+		Check:   "clock skew",
+		Message: fmt.Sprintf("the container clock is %s %s the host's; TLS and signed cloud requests (AWS SigV4) may fail", skew.Round(time.Second), direction),
+		Fix:     "Restart Docker Desktop, OrbStack or Colima, or resync the VM clock: docker run --rm --privileged alpine hwclock -s",
+	}}
+}
@@ -0,0 +1,67 @@
+package preflight
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeDocker struct {
+	cmd    string
+	output string
+	err    error
+}
+
+func (f fakeDocker) Command() string {
+	if f.cmd == "" {
+		return "docker"
+	}
+	return f.cmd
+}
+
+func (f fakeDocker) Run(args ...string) (string, error) {
+	if strings.Join(args, " ") != "info --format {{.SystemTime}}" {
+		return "", errors.New("unexpected command")
+	}
+	return f.output, f.err
+}
+
+func TestCheckClockSkew(t *testing.T) {
+	host := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	now := func() time.Time { return host }
+
+	tests := []struct {
+		name      string
+		docker    fakeDocker
+		wantSkew  time.Duration
+		wantIssue string
+	}{
+		{"in sync", fakeDocker{output: "2026-03-01T12:00:03.5Z\n"}, 3500 * time.Millisecond, ""},
+		{"behind", fakeDocker{output: "2026-03-01T11:47:00+00:00\n"}, -13 * time.Minute, "13m0s behind"},
+		{"ahead", fakeDocker{output: "2026-03-01T13:00:00.123456789+01:00\n"}, 123456789, ""},
+		{"far ahead", fakeDocker{output: "2026-03-01T12:10:00Z"}, 10 * time.Minute, "10m0s ahead of"},
+		{"unreadable", fakeDocker{output: "Cannot connect", err: errors.New("exit status 1")}, 0, ""},
+		{"podman", fakeDocker{cmd: "podman", err: ErrClockUnsupported}, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skew, err := ClockSkew(tt.docker, now)
+			if (err != nil) != (tt.docker.err != nil) || skew != tt.wantSkew {
+				t.Errorf("ClockSkew() = %v, %v, want %v", skew, err, tt.wantSkew)
+			}
+
+			issues := CheckClockSkew(skew)
+			if tt.wantIssue == "" {
+				if len(issues) != 0 {
+					t.Errorf("CheckClockSkew() = %v, want none", issues)
+				}
+				return
+			}
+			if len(issues) != 1 || !strings.Contains(issues[0].Message, tt.wantIssue) || issues[0].Fix == "" {
+				t.Errorf("CheckClockSkew() = %v, want an issue with %q", issues, tt.wantIssue)
+			}
+		})
+	}
+}
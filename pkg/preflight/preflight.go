@@ -0,0 +1,105 @@
+package preflight
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Recommended minimums for file-watching dev servers and agents (the values VS Code suggests)
+const (
+	MinInotifyWatches   = 524288
+	MinInotifyInstances = 512
+)
+
+// Issue is a host problem likely to cause confusing failures inside containers
+type Issue struct {
+	Check   string // short name, e.g. "inotify watches"
+	Message string // what is wrong
+	Fix     string // command or config change that resolves it
+}
+
+// String formats the issue as a warning with its fix on the next line
+func (i Issue) String() string {
+	return fmt.Sprintf("%s\n  Fix: %s", i.Message, i.Fix)
+}
+
+// Run performs all host checks. procDir is normally "/proc"; on hosts without it
+// (macOS, where containers run in a VM) the kernel checks are skipped.
+func Run(procDir string) []Issue {
+	return CheckInotify(procDir)
+}
+
+// CheckInotify reports inotify limits below the recommended minimums. Containers share
+// the host kernel's limits, so watchers in every sandbox draw from the same pool.
+func CheckInotify(procDir string) []Issue {
+	var issues []Issue
+
+	limits := []struct {
+		name    string
+		file    string
+		minimum int
+	}{
+		{"inotify watches", "max_user_watches", MinInotifyWatches},
+		{"inotify instances", "max_user_instances", MinInotifyInstances},
+	}
+
+	for _, limit := range limits {
+		value, ok := readProcInt(filepath.Join(procDir, "sys", "fs", "inotify", limit.file))
+		if !ok || value >= limit.minimum {
+			continue
+		}
+		key := "fs.inotify." + limit.file
+		issues = append(issues, Issue{
+			Check:   limit.name,
+			Message: fmt.Sprintf("%s is %d; file watchers (dev servers, test runners) may fail with ENOSPC or \"too many open files\"", key, value),
+			Fix: fmt.Sprintf("sudo sysctl %s=%d && echo '%s=%d' | sudo tee /etc/sysctl.d/60-packnplay-inotify.conf",
+				key, limit.minimum, key, limit.minimum),
+		})
+	}
+
+	return issues
+}
+
+// readProcInt reads a single integer from a /proc file
+func readProcInt(path string) (int, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	return value, err == nil
+}
+
+// ulimitNames are the resource names docker run --ulimit accepts
+var ulimitNames = map[string]bool{
+	"core": true, "cpu": true, "data": true, "fsize": true, "locks": true,
+	"memlock": true, "msgqueue": true, "nice": true, "nofile": true, "nproc": true,
+	"rss": true, "rtprio": true, "rttime": true, "sigpending": true, "stack": true,
+}
+
+// ValidateUlimit checks a --ulimit value: name=soft[:hard], with -1 for unlimited
+func ValidateUlimit(ulimit string) error {
+	name, limits, ok := strings.Cut(ulimit, "=")
+	if !ok || !ulimitNames[name] {
+		return fmt.Errorf("invalid ulimit '%s' (format: name=soft[:hard], e.g. nofile=65536:65536)", ulimit)
+	}
+
+	softStr, hardStr, hasHard := strings.Cut(limits, ":")
+	soft, err := strconv.ParseInt(softStr, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid ulimit '%s': soft limit must be a number", ulimit)
+	}
+	if hasHard {
+		hard, err := strconv.ParseInt(hardStr, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid ulimit '%s': hard limit must be a number", ulimit)
+		}
+		if hard != -1 && (soft == -1 || soft > hard) {
+			return fmt.Errorf("invalid ulimit '%s': soft limit exceeds hard limit", ulimit)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package preflight
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeInotifyLimits creates a fake /proc tree with the given limits
+func writeInotifyLimits(t *testing.T, watches, instances string) string {
+	t.Helper()
+	procDir := t.TempDir()
+	inotifyDir := filepath.Join(procDir, "sys", "fs", "inotify")
+	if err := os.MkdirAll(inotifyDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if watches != "" {
+		_ = os.WriteFile(filepath.Join(inotifyDir, "max_user_watches"), []byte(watches+"\n"), 0644)
+	}
+	if instances != "" {
+		_ = os.WriteFile(filepath.Join(inotifyDir, "max_user_instances"), []byte(instances+"\n"), 0644)
+	}
+	return procDir
+}
+
+func TestCheckInotify(t *testing.T) {
+	tests := []struct {
+		name       string
+		watches    string
+		instances  string
+		wantChecks []string
+	}{
+		{"healthy", "1048576", "1024", nil},
+		{"low watches", "8192", "1024", []string{"inotify watches"}},
+		{"both low", "8192", "128", []string{"inotify watches", "inotify instances"}},
+		{"unreadable", "", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := CheckInotify(writeInotifyLimits(t, tt.watches, tt.instances))
+			if len(issues) != len(tt.wantChecks) {
+				t.Fatalf("CheckInotify() = %+v, want checks %v", issues, tt.wantChecks)
+			}
+			for i, issue := range issues {
+				if issue.Check != tt.wantChecks[i] {
+					t.Errorf("issue %d check = %q, want %q", i, issue.Check, tt.wantChecks[i])
+				}
+				if !strings.Contains(issue.Fix, "sudo sysctl") {
+					t.Errorf("issue %d fix = %q, want a sysctl command", i, issue.Fix)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckInotifyNoProc(t *testing.T) {
+	if issues := Run(filepath.Join(t.TempDir(), "missing")); len(issues) != 0 {
+		t.Errorf("Run() without /proc = %+v, want no issues", issues)
+	}
+}
+
+func TestValidateUlimit(t *testing.T) {
+	tests := []struct {
+		ulimit  string
+		wantErr bool
+	}{
+		{"nofile=65536:65536", false},
+		{"nproc=4096", false},
+		{"memlock=-1:-1", false},
+		{"core=0", false},
+		{"nofile", true},
+		{"files=1024", true},
+		{"nofile=lots", true},
+		{"nofile=1024:many", true},
+		{"nofile=2048:1024", true},
+		{"nofile=-1:1024", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ulimit, func(t *testing.T) {
+			err := ValidateUlimit(tt.ulimit)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateUlimit(%q) error = %v, wantErr %v", tt.ulimit, err, tt.wantErr)
+			}
+		})
+	}
+}
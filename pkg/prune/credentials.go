@@ -0,0 +1,102 @@
+package prune
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/obra/packnplay/pkg/credentials"
+)
+
+// credentialMode is the only mode a credential file may have
+const credentialMode os.FileMode = 0600
+
+// credentialGrace spares files written so recently that the run writing them may not
+// have created its container yet
+const credentialGrace = time.Hour
+
+// CredentialFile is a file in one of packnplay's credential directories
+type CredentialFile struct {
+	Path    string
+	Mode    os.FileMode
+	ModTime time.Time
+}
+
+// ScanCredentialFiles lists the regular files directly in dirs. Missing directories
+// are skipped.
+func ScanCredentialFiles(dirs []string) ([]CredentialFile, error) {
+	var files []CredentialFile
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+		}
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			files = append(files, CredentialFile{
+				Path:    filepath.Join(dir, entry.Name()),
+				Mode:    info.Mode().Perm(),
+				ModTime: info.ModTime(),
+			})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// planCredentials removes credential files no kept container references and restricts
+// the rest to credentialMode. A container's references are its credential files label
+// and its bind mounts. Nothing is removed when some container's references can't be
+// known: mounts weren't checked, or a container from before the label couldn't be
+// inspected.
+func planCredentials(files []CredentialFile, kept []Container, checkMounts bool, now time.Time) []Candidate {
+	referenced := make(map[string]bool)
+	known := checkMounts
+	for _, c := range kept {
+		for _, path := range c.CredentialFiles {
+			referenced[path] = true
+		}
+		for _, source := range c.MountSources {
+			referenced[source] = true
+		}
+		if len(c.CredentialFiles) == 0 && !c.MountsInspected {
+			known = false
+		}
+	}
+
+	var candidates []Candidate
+	for _, f := range files {
+		removable := known && !referenced[f.Path] &&
+			filepath.Base(f.Path) != credentials.SharedClaudeCredentials &&
+			now.Sub(f.ModTime) > credentialGrace
+		if removable {
+			candidates = append(candidates, Candidate{Kind: KindCredential, Name: f.Path, Label: f.Path, Reason: "no container uses it"})
+			continue
+		}
+		if f.Mode != credentialMode {
+			candidates = append(candidates, Candidate{Kind: KindPermission, Name: f.Path, Label: f.Path,
+				Reason: fmt.Sprintf("mode %04o, should be %04o", f.Mode, credentialMode)})
+		}
+	}
+	return candidates
+}
+
+// parseCredentialFiles splits the credential files label
+func parseCredentialFiles(label string) []string {
+	var files []string
+	for _, path := range strings.Split(label, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			files = append(files, path)
+		}
+	}
+	return files
+}
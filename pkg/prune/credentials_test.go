@@ -0,0 +1,118 @@
+package prune
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestScanCredentialFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "b.yaml"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.json"), []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := ScanCredentialFiles([]string{dir, filepath.Join(dir, "missing")})
+	if err != nil {
+		t.Fatalf("ScanCredentialFiles() error = %v", err)
+	}
+	if len(files) != 2 || files[0].Path != filepath.Join(dir, "a.json") || files[0].Mode != 0600 || files[1].Mode != 0644 {
+		t.Errorf("ScanCredentialFiles() = %+v", files)
+	}
+}
+
+func TestPlanCredentials(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := now.Add(-48 * time.Hour)
+	files := []CredentialFile{
+		{Path: "/c/credentials/claude-credentials.json", Mode: 0644, ModTime: old},
+		{Path: "/c/credentials/container-gone.credentials.json", Mode: 0600, ModTime: old},
+		{Path: "/c/kube/used-by-label.yaml", Mode: 0600, ModTime: old},
+		{Path: "/c/kube/used-by-mount.yaml", Mode: 0640, ModTime: old},
+		{Path: "/c/kube/just-written.yaml", Mode: 0600, ModTime: now.Add(-time.Minute)},
+	}
+
+	tests := []struct {
+		name        string
+		kept        []Container
+		checkMounts bool
+		want        []Candidate
+	}{
+		{
+			name: "unreferenced files are removed, the rest restricted",
+			kept: []Container{
+				{Name: "a", CredentialFiles: []string{"/c/kube/used-by-label.yaml"}},
+				{Name: "b", MountsInspected: true, MountSources: []string{"/c/kube/used-by-mount.yaml"}},
+			},
+			checkMounts: true,
+			want: []Candidate{
+				{Kind: KindPermission, Name: "/c/credentials/claude-credentials.json", Label: "/c/credentials/claude-credentials.json", Reason: "mode 0644, should be 0600"},
+				{Kind: KindCredential, Name: "/c/credentials/container-gone.credentials.json", Label: "/c/credentials/container-gone.credentials.json", Reason: "no container uses it"},
+				{Kind: KindPermission, Name: "/c/kube/used-by-mount.yaml", Label: "/c/kube/used-by-mount.yaml", Reason: "mode 0640, should be 0600"},
+			},
+		},
+		{
+			name:        "remote daemon: only permissions",
+			checkMounts: false,
+			want: []Candidate{
+				{Kind: KindPermission, Name: "/c/credentials/claude-credentials.json", Label: "/c/credentials/claude-credentials.json", Reason: "mode 0644, should be 0600"},
+				{Kind: KindPermission, Name: "/c/kube/used-by-mount.yaml", Label: "/c/kube/used-by-mount.yaml", Reason: "mode 0640, should be 0600"},
+			},
+		},
+		{
+			name:        "a container whose references are unknown keeps everything",
+			kept:        []Container{{Name: "uninspected"}},
+			checkMounts: true,
+			want: []Candidate{
+				{Kind: KindPermission, Name: "/c/credentials/claude-credentials.json", Label: "/c/credentials/claude-credentials.json", Reason: "mode 0644, should be 0600"},
+				{Kind: KindPermission, Name: "/c/kube/used-by-mount.yaml", Label: "/c/kube/used-by-mount.yaml", Reason: "mode 0640, should be 0600"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := planCredentials(files, tt.kept, tt.checkMounts, now)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("planCredentials() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyCredentials(t *testing.T) {
+	dir := t.TempDir()
+	unused := filepath.Join(dir, "unused.yaml")
+	loose := filepath.Join(dir, "loose.json")
+	for _, path := range []string{unused, loose} {
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := Apply(nil, []Candidate{
+		{Kind: KindCredential, Name: unused, Label: unused},
+		{Kind: KindPermission, Name: loose, Label: loose},
+	}, io.Discard)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if _, err := os.Stat(unused); !os.IsNotExist(err) {
+		t.Errorf("%s should be removed", unused)
+	}
+	info, err := os.Stat(loose)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("%s mode = %04o, want 0600", loose, info.Mode().Perm())
+	}
+}
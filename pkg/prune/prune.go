@@ -0,0 +1,419 @@
+// Package prune finds what packnplay left behind: containers for deleted worktrees or
+// that were never removed, worktree directories git no longer knows about,
+// devcontainer images superseded by newer builds, and credential files no container
+// uses any more or that others can read.
+package prune
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/obra/packnplay/pkg/credentials"
+	"github.com/obra/packnplay/pkg/devcontainer"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/git"
+)
+
+// Kind is the type of resource a candidate is
+type Kind string
+
+const (
+	KindContainer  Kind = "container"
+	KindWorktree   Kind = "worktree"
+	KindImage      Kind = "image"
+	KindCredential Kind = "credential file"
+	KindPermission Kind = "credential permission"
+)
+
+// Candidate is something prune would remove
+type Candidate struct {
+	Kind   Kind
+	Name   string // container name, worktree path, or image reference
+	Label  string // what to show the user
+	Reason string
+}
+
+// Container is a packnplay-managed container
+type Container struct {
+	Name         string
+	State        string
+	Project      string
+	Worktree     string
+	Image        string
+	MountSources []string
+
+	// CredentialFiles are the host credential files the container was started with,
+	// from its label; containers from before the label have none
+	CredentialFiles []string
+	// MountsInspected is set once MountSources has been read from the container
+	MountsInspected bool
+}
+
+// Worktree is a directory under the packnplay worktrees root
+type Worktree struct {
+	Path    string
+	Project string
+	Name    string
+	Stale   string // why git no longer tracks it, empty when it's live
+}
+
+// Image is a devcontainer image packnplay built
+type Image struct {
+	ID         string
+	Repository string
+	Tag        string
+	Created    time.Time
+}
+
+// Inventory is everything prune cross-references
+type Inventory struct {
+	Containers []Container
+	Worktrees  []Worktree
+	Images     []Image
+	// CredentialFiles are the files in packnplay's credential directories
+	CredentialFiles []CredentialFile
+
+	// CheckMounts compares container bind mounts with the local filesystem; it's off
+	// when the daemon is remote and the sources live on another machine
+	CheckMounts bool
+}
+
+// Options tunes what Plan considers old
+type Options struct {
+	ImageAge time.Duration // unused tagged images built longer ago than this are removed (0 = keep)
+	Now      time.Time
+}
+
+// Plan decides what to remove. Containers go first since removing them can free
+// worktrees and images they were using.
+func Plan(inv *Inventory, opts Options) []Candidate {
+	var candidates []Candidate
+
+	// Containers, and which worktrees and images the kept ones still use
+	inUseWorktrees := make(map[string]bool)
+	var keptContainers []Container
+	for _, c := range inv.Containers {
+		if reason := containerReason(c, inv.CheckMounts); reason != "" {
+			candidates = append(candidates, Candidate{Kind: KindContainer, Name: c.Name, Label: c.Name, Reason: reason})
+			continue
+		}
+		keptContainers = append(keptContainers, c)
+		inUseWorktrees[c.Project+"/"+git.WorktreeDirName(c.Worktree)] = true
+	}
+
+	for _, wt := range inv.Worktrees {
+		if wt.Stale == "" || inUseWorktrees[wt.Project+"/"+wt.Name] {
+			continue
+		}
+		candidates = append(candidates, Candidate{Kind: KindWorktree, Name: wt.Path, Label: wt.Path, Reason: wt.Stale})
+	}
+
+	for _, img := range inv.Images {
+		if imageInUse(img, keptContainers) {
+			continue
+		}
+		// Tagged images are removed by tag so other tags of the same ID survive
+		name := img.Repository + ":" + img.Tag
+		label := name
+		reason := ""
+		switch {
+		case img.Repository == "<none>":
+			name = img.ID
+			label = shortID(img.ID)
+			reason = "superseded by a newer build"
+		case opts.ImageAge > 0 && !img.Created.IsZero() && opts.Now.Sub(img.Created) > opts.ImageAge:
+			reason = fmt.Sprintf("unused, built %d days ago", int(opts.Now.Sub(img.Created).Hours()/24))
+		}
+		if reason != "" {
+			candidates = append(candidates, Candidate{Kind: KindImage, Name: name, Label: label, Reason: reason})
+		}
+	}
+
+	// Credential files are only kept for the containers that remain
+	candidates = append(candidates, planCredentials(inv.CredentialFiles, keptContainers, inv.CheckMounts, opts.Now)...)
+
+	return candidates
+}
+
+// containerReason explains why a container is orphaned, or returns ""
+func containerReason(c Container, checkMounts bool) string {
+	if c.State != "running" {
+		return fmt.Sprintf("%s, never removed", c.State)
+	}
+	if checkMounts {
+		for _, source := range c.MountSources {
+			if _, err := os.Stat(source); os.IsNotExist(err) {
+				return fmt.Sprintf("mounted %s no longer exists", source)
+			}
+		}
+	}
+	return ""
+}
+
+// imageInUse reports whether any container runs img. Containers show an image by
+// name, or by ID once a rebuild has moved the tag away.
+func imageInUse(img Image, containers []Container) bool {
+	id := strings.TrimPrefix(img.ID, "sha256:")
+	for _, c := range containers {
+		if c.Image == img.Repository+":"+img.Tag || (img.Tag == "latest" && c.Image == img.Repository) {
+			return true
+		}
+		ref := strings.TrimPrefix(c.Image, "sha256:")
+		if len(ref) >= 12 && strings.HasPrefix(id, ref) {
+			return true
+		}
+	}
+	return false
+}
+
+func shortID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// ScanWorktrees lists worktree directories under root (<root>/<project>/<worktree>)
+// and marks those git no longer tracks: the .git file is missing or points at an
+// admin directory that has been pruned or whose repository was deleted.
+func ScanWorktrees(root string) ([]Worktree, error) {
+	projects, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", root, err)
+	}
+
+	var worktrees []Worktree
+	for _, project := range projects {
+		if !project.IsDir() {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(root, project.Name()))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			path := filepath.Join(root, project.Name(), entry.Name())
+			worktrees = append(worktrees, Worktree{
+				Path:    path,
+				Project: project.Name(),
+				Name:    entry.Name(),
+				Stale:   worktreeStaleReason(path),
+			})
+		}
+	}
+	return worktrees, nil
+}
+
+// worktreeStaleReason explains why git no longer tracks the worktree at path, or returns ""
+func worktreeStaleReason(path string) string {
+	dotGit := filepath.Join(path, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return "not a git worktree"
+	}
+	if info.IsDir() {
+		// A full clone rather than a worktree; it's not ours to judge
+		return ""
+	}
+
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return ""
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return "not a git worktree"
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(path, gitDir)
+	}
+	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+		return "its repository no longer tracks it"
+	}
+	return ""
+}
+
+// Collect gathers containers and images from the runtime, worktrees from worktreeRoot
+// and credential files from credentialDirs
+func Collect(dockerClient *docker.Client, worktreeRoot string, credentialDirs []string, checkMounts bool) (*Inventory, error) {
+	inv := &Inventory{CheckMounts: checkMounts}
+
+	output, err := dockerClient.Run("ps", "-a",
+		"--filter", "label=managed-by=packnplay",
+		"--format", `{{.Names}}\t{{.State}}\t{{.Label "packnplay-project"}}\t{{.Label "packnplay-worktree"}}\t{{.Image}}\t{{.Label "`+credentials.FilesLabel+`"}}`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w: %s", err, strings.TrimSpace(output))
+	}
+	inv.Containers = parseContainers(output)
+
+	if checkMounts {
+		for i := range inv.Containers {
+			mounts, err := dockerClient.Run("inspect", "--format",
+				`{{range .Mounts}}{{if eq .Type "bind"}}{{.Source}}{{"\n"}}{{end}}{{end}}`, inv.Containers[i].Name)
+			if err != nil {
+				continue
+			}
+			inv.Containers[i].MountsInspected = true
+			for _, source := range strings.Split(mounts, "\n") {
+				if source = strings.TrimSpace(source); source != "" {
+					inv.Containers[i].MountSources = append(inv.Containers[i].MountSources, source)
+				}
+			}
+		}
+	}
+
+	// Builds are labeled with their build hash; older builds are found by name
+	seen := make(map[string]bool)
+	for _, filter := range []string{"label=" + devcontainer.BuildHashLabel, "reference=packnplay-*-devcontainer"} {
+		output, err := dockerClient.Run("images", "--no-trunc", "--filter", filter,
+			"--format", `{{.ID}}\t{{.Repository}}\t{{.Tag}}\t{{.CreatedAt}}`)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list images: %w: %s", err, strings.TrimSpace(output))
+		}
+		for _, img := range parseImages(output) {
+			if !seen[img.ID+img.Repository+img.Tag] {
+				seen[img.ID+img.Repository+img.Tag] = true
+				inv.Images = append(inv.Images, img)
+			}
+		}
+	}
+
+	if worktreeRoot != "" {
+		inv.Worktrees, err = ScanWorktrees(worktreeRoot)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	inv.CredentialFiles, err = ScanCredentialFiles(credentialDirs)
+	if err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// parseContainers parses the tab-separated ps output Collect requests
+func parseContainers(output string) []Container {
+	var containers []Container
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		for len(fields) < 6 {
+			fields = append(fields, "")
+		}
+		containers = append(containers, Container{
+			Name:            fields[0],
+			State:           fields[1],
+			Project:         fields[2],
+			Worktree:        fields[3],
+			Image:           strings.TrimSpace(fields[4]),
+			CredentialFiles: parseCredentialFiles(fields[5]),
+		})
+	}
+	return containers
+}
+
+// createdAtLayout is the format of {{.CreatedAt}} in docker images output
+const createdAtLayout = "2006-01-02 15:04:05 -0700 MST"
+
+// parseImages parses the tab-separated images output Collect requests
+func parseImages(output string) []Image {
+	var images []Image
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) < 3 || fields[0] == "" {
+			continue
+		}
+		img := Image{ID: fields[0], Repository: fields[1], Tag: fields[2]}
+		if len(fields) > 3 {
+			if created, err := time.Parse(createdAtLayout, fields[3]); err == nil {
+				img.Created = created
+			}
+		}
+		images = append(images, img)
+	}
+	return images
+}
+
+// Print writes candidates grouped by kind
+func Print(w io.Writer, candidates []Candidate) {
+	for _, kind := range []Kind{KindContainer, KindWorktree, KindImage, KindCredential, KindPermission} {
+		var group []Candidate
+		for _, c := range candidates {
+			if c.Kind == kind {
+				group = append(group, c)
+			}
+		}
+		if len(group) == 0 {
+			continue
+		}
+		sort.SliceStable(group, func(i, j int) bool { return group[i].Label < group[j].Label })
+		_, _ = fmt.Fprintf(w, "%ss:\n", strings.ToUpper(string(kind[:1]))+string(kind[1:]))
+		for _, c := range group {
+			_, _ = fmt.Fprintf(w, "  %s (%s)\n", c.Label, c.Reason)
+		}
+	}
+}
+
+// Apply removes candidates in order, continuing past failures. It returns an error
+// describing how many removals failed.
+func Apply(dockerClient *docker.Client, candidates []Candidate, out io.Writer) error {
+	failed := 0
+	for _, c := range candidates {
+		var err error
+		switch c.Kind {
+		case KindContainer:
+			var output string
+			if output, err = dockerClient.Run("rm", "-f", c.Name); err != nil {
+				err = fmt.Errorf("%w: %s", err, strings.TrimSpace(output))
+			}
+		case KindWorktree:
+			err = os.RemoveAll(c.Name)
+			// Drop the project directory once its last worktree is gone
+			_ = os.Remove(filepath.Dir(c.Name))
+		case KindImage:
+			var output string
+			if output, err = dockerClient.Run("rmi", c.Name); err != nil {
+				err = fmt.Errorf("%w: %s", err, strings.TrimSpace(output))
+			}
+		case KindCredential:
+			err = os.Remove(c.Name)
+		case KindPermission:
+			if err = os.Chmod(c.Name, credentialMode); err == nil {
+				_, _ = fmt.Fprintf(out, "Restricted %s to %04o\n", c.Label, credentialMode)
+				continue
+			}
+		}
+
+		if err != nil {
+			failed++
+			if c.Kind == KindPermission {
+				fmt.Fprintf(os.Stderr, "Warning: failed to restrict %s: %v\n", c.Label, err)
+			} else {
+				fmt.Fprintf(os.Stderr, "Warning: failed to remove %s %s: %v\n", c.Kind, c.Label, err)
+			}
+			continue
+		}
+		_, _ = fmt.Fprintf(out, "Removed %s %s\n", c.Kind, c.Label)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to clean up %d of %d items", failed, len(candidates))
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package prune
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlan(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	existing := t.TempDir()
+
+	inv := &Inventory{
+		CheckMounts: true,
+		Containers: []Container{
+			{Name: "packnplay-app-main", State: "running", Project: "app", Worktree: "main", Image: "packnplay-app-devcontainer:latest", MountSources: []string{existing}},
+			{Name: "packnplay-app-old", State: "exited", Project: "app", Worktree: "old", Image: "ubuntu"},
+			{Name: "packnplay-app-gone", State: "running", Project: "app", Worktree: "feature/gone", Image: "ubuntu", MountSources: []string{existing, "/nonexistent/worktree"}},
+			{Name: "packnplay-api-fix", State: "running", Project: "api", Worktree: "fix/bug", Image: "5f2e8a1b9c3d"},
+		},
+		Worktrees: []Worktree{
+			{Path: "/w/app/main", Project: "app", Name: "main"},
+			{Path: "/w/app/feature-gone", Project: "app", Name: "feature-gone", Stale: "its repository no longer tracks it"},
+			{Path: "/w/api/fix-bug", Project: "api", Name: "fix-bug", Stale: "not a git worktree"},
+		},
+		Images: []Image{
+			{ID: "sha256:aaaa", Repository: "packnplay-app-devcontainer", Tag: "latest", Created: now.Add(-90 * 24 * time.Hour)},
+			{ID: "sha256:5f2e8a1b9c3d0000", Repository: "<none>", Tag: "<none>", Created: now.Add(-10 * 24 * time.Hour)},
+			{ID: "sha256:bbbbbbbbbbbbbbbb", Repository: "<none>", Tag: "<none>"},
+			{ID: "sha256:cccc", Repository: "packnplay-web-devcontainer", Tag: "latest", Created: now.Add(-60 * 24 * time.Hour)},
+			{ID: "sha256:dddd", Repository: "packnplay-cli-devcontainer", Tag: "latest", Created: now.Add(-2 * 24 * time.Hour)},
+		},
+	}
+
+	got := Plan(inv, Options{ImageAge: 30 * 24 * time.Hour, Now: now})
+	want := []Candidate{
+		{Kind: KindContainer, Name: "packnplay-app-old", Label: "packnplay-app-old", Reason: "exited, never removed"},
+		{Kind: KindContainer, Name: "packnplay-app-gone", Label: "packnplay-app-gone", Reason: "mounted /nonexistent/worktree no longer exists"},
+		// fix-bug is stale but its container still runs
+		{Kind: KindWorktree, Name: "/w/app/feature-gone", Label: "/w/app/feature-gone", Reason: "its repository no longer tracks it"},
+		// The running api container uses the dangling 5f2e... image by ID
+		{Kind: KindImage, Name: "sha256:bbbbbbbbbbbbbbbb", Label: "bbbbbbbbbbbb", Reason: "superseded by a newer build"},
+		{Kind: KindImage, Name: "packnplay-web-devcontainer:latest", Label: "packnplay-web-devcontainer:latest", Reason: "unused, built 60 days ago"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Plan() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestPlanSkipsMountsForRemoteDaemon(t *testing.T) {
+	inv := &Inventory{
+		Containers: []Container{{Name: "c", State: "running", MountSources: []string{"/only/on/remote"}}},
+	}
+	if got := Plan(inv, Options{}); len(got) != 0 {
+		t.Errorf("Plan() = %+v, want nothing when mounts aren't checked", got)
+	}
+}
+
+func TestScanWorktrees(t *testing.T) {
+	root := t.TempDir()
+	repoGitDir := t.TempDir()
+
+	mkWorktree := func(project, name, dotGit string, isDir bool) {
+		dir := filepath.Join(root, project, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case isDir:
+			if err := os.Mkdir(filepath.Join(dir, ".git"), 0755); err != nil {
+				t.Fatal(err)
+			}
+		case dotGit != "":
+			if err := os.WriteFile(filepath.Join(dir, ".git"), []byte(dotGit), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	mkWorktree("app", "live", "gitdir: "+repoGitDir+"\n", false)
+	mkWorktree("app", "pruned", "gitdir: /nonexistent/.git/worktrees/pruned\n", false)
+	mkWorktree("app", "empty", "", false)
+	mkWorktree("api", "clone", "", true)
+	if err := os.WriteFile(filepath.Join(root, "stray-file"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	worktrees, err := ScanWorktrees(root)
+	if err != nil {
+		t.Fatalf("ScanWorktrees() error = %v", err)
+	}
+
+	stale := make(map[string]string)
+	for _, wt := range worktrees {
+		stale[wt.Project+"/"+wt.Name] = wt.Stale
+	}
+	want := map[string]string{
+		"api/clone":  "",
+		"app/empty":  "not a git worktree",
+		"app/live":   "",
+		"app/pruned": "its repository no longer tracks it",
+	}
+	if !reflect.DeepEqual(stale, want) {
+		t.Errorf("ScanWorktrees() stale = %v, want %v", stale, want)
+	}
+
+	if worktrees, err := ScanWorktrees(filepath.Join(root, "missing")); err != nil || worktrees != nil {
+		t.Errorf("missing root: %v, %v", worktrees, err)
+	}
+}
+
+func TestParseContainersAndImages(t *testing.T) {
+	containers := parseContainers("packnplay-app-main\trunning\tapp\tmain\tubuntu:24.04\n\n")
+	want := []Container{{Name: "packnplay-app-main", State: "running", Project: "app", Worktree: "main", Image: "ubuntu:24.04"}}
+	if !reflect.DeepEqual(containers, want) {
+		t.Errorf("parseContainers() = %+v, want %+v", containers, want)
+	}
+
+	containers = parseContainers("packnplay-app-main\trunning\tapp\tmain\tubuntu\t/c/a.json,/c/b.yaml\n")
+	if len(containers) != 1 || !reflect.DeepEqual(containers[0].CredentialFiles, []string{"/c/a.json", "/c/b.yaml"}) {
+		t.Errorf("parseContainers() credential files = %+v", containers)
+	}
+
+	images := parseImages("sha256:abc\tpacknplay-app-devcontainer\tlatest\t2025-03-01 12:00:00 +0000 UTC\n")
+	if len(images) != 1 || images[0].Tag != "latest" || !images[0].Created.Equal(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("parseImages() = %+v", images)
+	}
+}
+
+func TestPrint(t *testing.T) {
+	var buf bytes.Buffer
+	Print(&buf, []Candidate{
+		{Kind: KindImage, Label: "abc", Reason: "superseded by a newer build"},
+		{Kind: KindContainer, Label: "b", Reason: "exited, never removed"},
+		{Kind: KindContainer, Label: "a", Reason: "exited, never removed"},
+	})
+
+	want := "Containers:\n  a (exited, never removed)\n  b (exited, never removed)\nImages:\n  abc (superseded by a newer build)\n"
+	if buf.String() != want {
+		t.Errorf("Print() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,183 @@
+package ptyexec
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"golang.org/x/sys/unix"
+)
+
+// drainTimeout bounds how long to wait for buffered PTY output after the child exits
+const drainTimeout = time.Second
+
+// Options configures a managed exec
+type Options struct {
+	// Output receives a copy of everything the child writes to the terminal (e.g. a recorder)
+	Output io.Writer
+	// OnResize is called with the new size whenever the terminal is resized
+	OnResize func(cols, rows int)
+	// Timeout, when set, calls OnTimeout once the child has run this long. Stopping the
+	// child is up to OnTimeout, since killing a docker exec client leaves the command running.
+	Timeout   time.Duration
+	OnTimeout func()
+}
+
+// Interactive reports whether packnplay is attached to a terminal on both stdin and
+// stdout. When either is redirected (CI, pipes, output to a file) commands should run
+// without a TTY.
+func Interactive() bool {
+	return term.IsTerminal(os.Stdin.Fd()) && term.IsTerminal(os.Stdout.Fd())
+}
+
+// Run starts cmd as a child process and waits for it, returning its exit code. Unlike
+// syscall.Exec, packnplay stays alive to observe the session. When Interactive, the
+// child gets its own PTY: the terminal is put in raw mode and proxied, window size
+// changes are applied to the PTY, and Ctrl-C/Ctrl-Z reach the child as keystrokes just
+// as they would with a direct exec. Signals sent to packnplay itself are forwarded.
+func Run(cmd *exec.Cmd, opts Options) (int, error) {
+	if opts.Timeout > 0 && opts.OnTimeout != nil {
+		timer := time.AfterFunc(opts.Timeout, opts.OnTimeout)
+		defer timer.Stop()
+	}
+
+	if !Interactive() {
+		return runWithoutTTY(cmd, opts)
+	}
+	return runWithTTY(cmd, opts)
+}
+
+// runWithTTY runs cmd on a new PTY proxied to the current terminal
+func runWithTTY(cmd *exec.Cmd, opts Options) (int, error) {
+	master, slave, err := openPTY()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = master.Close() }()
+
+	resize := func() {
+		ws, err := unix.IoctlGetWinsize(int(os.Stdin.Fd()), unix.TIOCGWINSZ)
+		if err != nil {
+			return
+		}
+		_ = unix.IoctlSetWinsize(int(master.Fd()), unix.TIOCSWINSZ, ws)
+		if opts.OnResize != nil {
+			opts.OnResize(int(ws.Col), int(ws.Row))
+		}
+	}
+	resize()
+
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	if err := cmd.Start(); err != nil {
+		_ = slave.Close()
+		return 0, fmt.Errorf("failed to start %s: %w", cmd.Path, err)
+	}
+	// The child holds the slave now; closing ours lets master reads end when it exits
+	_ = slave.Close()
+
+	// The signal goroutine replaces the saved state after a suspend/resume
+	var state atomic.Pointer[term.State]
+	initial, err := term.MakeRaw(os.Stdin.Fd())
+	if err != nil {
+		_ = cmd.Process.Kill()
+		return 0, fmt.Errorf("failed to put terminal in raw mode: %w", err)
+	}
+	state.Store(initial)
+	restore := func() { _ = term.Restore(os.Stdin.Fd(), state.Load()) }
+	defer restore()
+
+	signals := make(chan os.Signal, 8)
+	signal.Notify(signals, syscall.SIGWINCH, syscall.SIGTSTP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	go func() {
+		for sig := range signals {
+			switch sig {
+			case syscall.SIGWINCH:
+				resize()
+			case syscall.SIGTSTP:
+				// Suspend packnplay like a shell job; the child keeps running in its own session
+				restore()
+				_ = syscall.Kill(os.Getpid(), syscall.SIGSTOP)
+				// Resumed (fg): back to raw mode, and the terminal may have changed size
+				if s, err := term.MakeRaw(os.Stdin.Fd()); err == nil {
+					state.Store(s)
+				}
+				resize()
+			default:
+				_ = cmd.Process.Signal(sig)
+			}
+		}
+	}()
+
+	// stdin -> PTY; this goroutine stays blocked on the read after the child exits,
+	// which is harmless since the process exits soon after
+	go func() { _, _ = io.Copy(master, os.Stdin) }()
+
+	output := io.Writer(os.Stdout)
+	if opts.Output != nil {
+		output = io.MultiWriter(os.Stdout, opts.Output)
+	}
+	copied := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(output, master) // ends with EIO once the child's side closes
+		close(copied)
+	}()
+
+	waitErr := cmd.Wait()
+	select {
+	case <-copied:
+	case <-time.After(drainTimeout):
+	}
+
+	return exitCode(cmd, waitErr)
+}
+
+// runWithoutTTY runs cmd with inherited stdio (pipes, CI), forwarding signals
+func runWithoutTTY(cmd *exec.Cmd, opts Options) (int, error) {
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if opts.Output != nil {
+		cmd.Stdout = io.MultiWriter(os.Stdout, opts.Output)
+		cmd.Stderr = io.MultiWriter(os.Stderr, opts.Output)
+	}
+	// Own process group, so a signal is delivered once (by us) rather than twice
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to start %s: %w", cmd.Path, err)
+	}
+
+	signals := make(chan os.Signal, 8)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+	go func() {
+		for sig := range signals {
+			_ = cmd.Process.Signal(sig)
+		}
+	}()
+
+	return exitCode(cmd, cmd.Wait())
+}
+
+// exitCode converts a Wait result into a shell-style exit code (128+N for signals)
+func exitCode(cmd *exec.Cmd, waitErr error) (int, error) {
+	var exitErr *exec.ExitError
+	if waitErr != nil && !errors.As(waitErr, &exitErr) {
+		return 0, waitErr
+	}
+
+	if status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal()), nil
+	}
+	return cmd.ProcessState.ExitCode(), nil
+}
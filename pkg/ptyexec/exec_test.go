@@ -0,0 +1,146 @@
+package ptyexec
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestRunExitCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   int
+	}{
+		{"success", "exit 0", 0},
+		{"failure", "exit 3", 3},
+		{"killed by signal", "kill -KILL $$", 128 + int(syscall.SIGKILL)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := runWithoutTTY(exec.Command("sh", "-c", tt.script), Options{})
+			if err != nil {
+				t.Fatalf("runWithoutTTY() error = %v", err)
+			}
+			if code != tt.want {
+				t.Errorf("exit code = %d, want %d", code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunStartFailure(t *testing.T) {
+	if _, err := runWithoutTTY(exec.Command("/nonexistent/packnplay-test-binary"), Options{}); err == nil {
+		t.Error("expected error for missing binary")
+	}
+}
+
+func TestRunCopiesOutput(t *testing.T) {
+	var recorded bytes.Buffer
+	code, err := runWithoutTTY(exec.Command("sh", "-c", "echo hello; echo oops >&2"), Options{Output: &recorded})
+	if err != nil || code != 0 {
+		t.Fatalf("runWithoutTTY() = %d, %v", code, err)
+	}
+	if !strings.Contains(recorded.String(), "hello") || !strings.Contains(recorded.String(), "oops") {
+		t.Errorf("recorded output = %q, want stdout and stderr", recorded.String())
+	}
+}
+
+func TestRunForwardsSignals(t *testing.T) {
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		_ = syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	}()
+
+	code, err := runWithoutTTY(exec.Command("sh", "-c", `trap "exit 7" TERM; while :; do sleep 0.1; done`), Options{})
+	if err != nil {
+		t.Fatalf("runWithoutTTY() error = %v", err)
+	}
+	if code != 7 {
+		t.Errorf("exit code = %d, want 7 (child's TERM handler)", code)
+	}
+}
+
+func TestOpenPTY(t *testing.T) {
+	master, slave, err := openPTY()
+	if err != nil {
+		t.Skipf("PTYs unavailable: %v", err)
+	}
+	defer func() { _ = master.Close() }()
+	defer func() { _ = slave.Close() }()
+
+	// A size set on the master side is what the child sees on the slave side
+	want := &unix.Winsize{Row: 40, Col: 120}
+	if err := unix.IoctlSetWinsize(int(master.Fd()), unix.TIOCSWINSZ, want); err != nil {
+		t.Fatalf("set winsize: %v", err)
+	}
+	got, err := unix.IoctlGetWinsize(int(slave.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		t.Fatalf("get winsize: %v", err)
+	}
+	if got.Row != want.Row || got.Col != want.Col {
+		t.Errorf("slave winsize = %dx%d, want %dx%d", got.Col, got.Row, want.Col, want.Row)
+	}
+
+	// Bytes written by the child come out of the master
+	if _, err := slave.Write([]byte("ping\n")); err != nil {
+		t.Fatalf("write slave: %v", err)
+	}
+	buf := make([]byte, 64)
+	n, err := master.Read(buf)
+	if err != nil || !strings.Contains(string(buf[:n]), "ping") {
+		t.Errorf("master read = %q, %v", buf[:n], err)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "sleep 5")
+	fired := make(chan struct{})
+
+	start := time.Now()
+	code, err := Run(cmd, Options{
+		Timeout: 100 * time.Millisecond,
+		OnTimeout: func() {
+			close(fired)
+			_ = cmd.Process.Kill()
+		},
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	select {
+	case <-fired:
+	default:
+		t.Fatal("OnTimeout was not called")
+	}
+	if code != 128+int(syscall.SIGKILL) {
+		t.Errorf("exit code = %d, want killed", code)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Error("Run() kept waiting after the timeout handler killed the child")
+	}
+}
+
+func TestInteractiveWithRedirectedOutput(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = r.Close(); _ = w.Close() }()
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	if Interactive() {
+		t.Error("Interactive() = true with stdout redirected to a pipe")
+	}
+}
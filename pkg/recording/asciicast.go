@@ -0,0 +1,142 @@
+package recording
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Header is the first line of an asciicast v2 file
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Recorder writes terminal output as an asciicast v2 stream
+// (https://docs.asciinema.org/manual/asciicast/v2/), replayable with `asciinema play`
+type Recorder struct {
+	mu      sync.Mutex
+	w       io.Writer
+	closer  io.Closer
+	start   time.Time
+	now     func() time.Time
+	pending []byte // incomplete UTF-8 sequence held until the next write
+	err     error
+}
+
+// Create starts a recording in a new file at path (mode 0600, since sessions may show secrets)
+func Create(path string, width, height int, title string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording file: %w", err)
+	}
+
+	recorder, err := New(file, width, height, title, time.Now)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	recorder.closer = file
+	return recorder, nil
+}
+
+// New starts a recording on w, writing the header immediately
+func New(w io.Writer, width, height int, title string, now func() time.Time) (*Recorder, error) {
+	r := &Recorder{w: w, now: now, start: now()}
+
+	header := Header{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: r.start.Unix(),
+		Title:     title,
+		Env:       map[string]string{"TERM": os.Getenv("TERM"), "SHELL": os.Getenv("SHELL")},
+	}
+	if err := r.writeLine(header); err != nil {
+		return nil, fmt.Errorf("failed to write recording header: %w", err)
+	}
+	return r, nil
+}
+
+// Write records terminal output. It never fails the session: recording errors are
+// kept and reported by Close.
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data := append(r.pending, p...)
+	// Hold back a multi-byte character split across writes so each event is valid UTF-8
+	cut := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	r.pending = append([]byte(nil), data[cut:]...)
+
+	if cut > 0 {
+		r.event("o", string(data[:cut]))
+	}
+	return len(p), nil
+}
+
+// Resize records a terminal size change
+func (r *Recorder) Resize(cols, rows int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.event("r", fmt.Sprintf("%dx%d", cols, rows))
+}
+
+// Close writes any held-back output and closes the file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.pending) > 0 {
+		r.event("o", string(r.pending))
+		r.pending = nil
+	}
+	if r.closer != nil {
+		if err := r.closer.Close(); err != nil && r.err == nil {
+			r.err = err
+		}
+	}
+	return r.err
+}
+
+// event writes [elapsed, type, data]; callers hold r.mu
+func (r *Recorder) event(eventType, data string) {
+	elapsed := r.now().Sub(r.start).Seconds()
+	if err := r.writeLine([]interface{}{roundMicro(elapsed), eventType, data}); err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+// writeLine writes one JSON line unbuffered, so an interrupted session still leaves
+// a playable file
+func (r *Recorder) writeLine(v interface{}) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := r.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+// roundMicro keeps timestamps short and stable in the file
+func roundMicro(seconds float64) float64 {
+	return float64(int64(seconds*1e6)) / 1e6
+}
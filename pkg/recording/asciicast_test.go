@@ -0,0 +1,126 @@
+package recording
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeClock advances one second per call
+func fakeClock() func() time.Time {
+	t := time.Unix(1700000000, 0)
+	return func() time.Time {
+		now := t
+		t = t.Add(time.Second)
+		return now
+	}
+}
+
+// readCast splits an asciicast into its header and events
+func readCast(t *testing.T, data []byte) (Header, [][]interface{}) {
+	t.Helper()
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	var header Header
+	if !scanner.Scan() {
+		t.Fatal("empty recording")
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		t.Fatalf("invalid header %q: %v", scanner.Text(), err)
+	}
+
+	var events [][]interface{}
+	for scanner.Scan() {
+		var event []interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("invalid event %q: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+	return header, events
+}
+
+func TestRecorder(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := New(&buf, 120, 40, "claude", fakeClock())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	_, _ = r.Write([]byte("hello\r\n"))
+	r.Resize(100, 30)
+	_, _ = r.Write([]byte("bye"))
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	header, events := readCast(t, buf.Bytes())
+	if header.Version != 2 || header.Width != 120 || header.Height != 40 || header.Title != "claude" || header.Timestamp != 1700000000 {
+		t.Errorf("header = %+v", header)
+	}
+
+	want := [][]interface{}{
+		{1.0, "o", "hello\r\n"},
+		{2.0, "r", "100x30"},
+		{3.0, "o", "bye"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %v, want %v", events, want)
+	}
+	for i := range want {
+		for j := range want[i] {
+			if events[i][j] != want[i][j] {
+				t.Errorf("event %d = %v, want %v", i, events[i], want[i])
+				break
+			}
+		}
+	}
+}
+
+func TestRecorderSplitUTF8(t *testing.T) {
+	var buf bytes.Buffer
+	r, _ := New(&buf, 80, 24, "", fakeClock())
+
+	check := []byte("✓ ok") // ✓ is 3 bytes
+	_, _ = r.Write(check[:2])
+	_, _ = r.Write(check[2:])
+	_ = r.Close()
+
+	_, events := readCast(t, buf.Bytes())
+	var out string
+	for _, event := range events {
+		out += event[2].(string)
+	}
+	if out != "✓ ok" {
+		t.Errorf("recorded output = %q, want %q", out, "✓ ok")
+	}
+}
+
+func TestCreate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.cast")
+	r, err := Create(path, 80, 24, "test")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	_, _ = r.Write([]byte("output"))
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("recording mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	data, _ := os.ReadFile(path)
+	if _, events := readCast(t, data); len(events) != 1 {
+		t.Errorf("events = %v, want one output event", events)
+	}
+}
@@ -0,0 +1,218 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Client queries OCI/Docker registries using the Registry HTTP API v2.
+// Only anonymous (public) pull access is supported.
+type Client struct {
+	httpClient *http.Client
+	scheme     string
+}
+
+// NewClient creates a registry client with a short timeout suitable for interactive use
+func NewClient() *Client {
+	return &Client{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		scheme:     "https",
+	}
+}
+
+// ListTags returns the tags published for the repository of image, sorted
+func (c *Client) ListTags(image string) ([]string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s://%s/v2/%s/tags/list", c.scheme, ref.APIHost(), ref.Repository)
+	resp, err := c.get(endpoint, "")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("registry returned %s for %s", resp.Status, ref.Repository)
+	}
+
+	var body struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse tag list: %w", err)
+	}
+
+	sort.Strings(body.Tags)
+	return body.Tags, nil
+}
+
+// ErrNotFoundOrPrivate is returned when a registry refuses anonymous access to an image.
+// Docker Hub answers that way for repositories that don't exist as well as private ones.
+var ErrNotFoundOrPrivate = errors.New("not found, or private")
+
+// Exists reports whether the registry has image's tag or digest. A registry that can't be
+// reached gives an error rather than an answer, and one that refuses anonymous access
+// gives ErrNotFoundOrPrivate.
+func (c *Client) Exists(image string) (bool, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return false, err
+	}
+	target := ref.Tag
+	if ref.Digest != "" {
+		target = ref.Digest
+	}
+
+	endpoint := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", c.scheme, ref.APIHost(), ref.Repository, target)
+	resp, err := c.get(endpoint, manifestAccept)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return false, fmt.Errorf("%s on %s: %w", ref.Repository, ref.Registry, ErrNotFoundOrPrivate)
+	}
+	return false, fmt.Errorf("registry returned %s for %s", resp.Status, ref)
+}
+
+// get performs a GET, handling the bearer token challenge registries use for anonymous access
+func (c *Client) get(endpoint, accept string) (*http.Response, error) {
+	resp, err := c.do(endpoint, accept, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	_ = resp.Body.Close()
+
+	token, err := c.fetchToken(challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.do(endpoint, accept, token)
+}
+
+func (c *Client) do(endpoint, accept, token string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("registry request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// fetchToken requests an anonymous token from the realm named in a Bearer challenge
+func (c *Client) fetchToken(challenge string) (string, error) {
+	params := parseChallenge(challenge)
+	realm := params["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("registry requires authentication (no token realm offered)")
+	}
+
+	query := url.Values{}
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	if scope := params["scope"]; scope != "" {
+		query.Set("scope", scope)
+	}
+
+	tokenURL := realm
+	if len(query) > 0 {
+		tokenURL += "?" + query.Encode()
+	}
+
+	resp, err := c.do(tokenURL, "", "")
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("token request failed: %s %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to parse token response: %w", err)
+	}
+
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// parseChallenge parses `Bearer realm="...",service="...",scope="..."`
+func parseChallenge(challenge string) map[string]string {
+	params := make(map[string]string)
+
+	challenge = strings.TrimSpace(challenge)
+	if idx := strings.Index(challenge, " "); idx != -1 {
+		challenge = challenge[idx+1:]
+	}
+
+	for _, part := range splitChallenge(challenge) {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		params[strings.ToLower(kv[0])] = strings.Trim(kv[1], `"`)
+	}
+
+	return params
+}
+
+// splitChallenge splits on commas that are not inside quotes (scopes may contain commas)
+func splitChallenge(s string) []string {
+	var parts []string
+	inQuotes := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			inQuotes = !inQuotes
+		case ',':
+			if !inQuotes {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,108 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestRegistry serves a tag list that requires an anonymous bearer token
+func newTestRegistry(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if !strings.HasPrefix(r.URL.Query().Get("scope"), "repository:obra/") {
+				http.Error(w, "bad scope", http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{"token":"anon-token"}`)
+		case "/v2/obra/img/tags/list":
+			if r.Header.Get("Authorization") != "Bearer anon-token" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:obra/img:pull"`, server.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"name":"obra/img","tags":["slim","latest","go"]}`)
+		case "/v2/obra/img/manifests/latest":
+			fmt.Fprint(w, `{"schemaVersion":2}`)
+		case "/v2/obra/private/manifests/latest":
+			w.WriteHeader(http.StatusForbidden)
+		case "/v2/obra/gone/manifests/latest":
+			// Like Docker Hub, an anonymous token doesn't say whether the repository exists
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:obra/gone:pull",error="insufficient_scope"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return server
+}
+
+func TestListTags(t *testing.T) {
+	server := newTestRegistry(t)
+	defer server.Close()
+
+	client := NewClient()
+	client.scheme = "http"
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	tags, err := client.ListTags(host + "/obra/img:latest")
+	if err != nil {
+		t.Fatalf("ListTags() error = %v", err)
+	}
+
+	want := []string{"go", "latest", "slim"}
+	if strings.Join(tags, ",") != strings.Join(want, ",") {
+		t.Errorf("ListTags() = %v, want %v", tags, want)
+	}
+}
+
+func TestListTagsNotFound(t *testing.T) {
+	server := newTestRegistry(t)
+	defer server.Close()
+
+	client := NewClient()
+	client.scheme = "http"
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	if _, err := client.ListTags(host + "/obra/missing"); err == nil {
+		t.Error("ListTags() should fail for unknown repository")
+	}
+}
+
+func TestExists(t *testing.T) {
+	server := newTestRegistry(t)
+	defer server.Close()
+
+	client := NewClient()
+	client.scheme = "http"
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	if found, err := client.Exists(host + "/obra/img"); !found || err != nil {
+		t.Errorf("Exists(latest) = %v, %v, want true", found, err)
+	}
+	if found, err := client.Exists(host + "/obra/img:nope"); found || err != nil {
+		t.Errorf("Exists(missing tag) = %v, %v, want false without an error", found, err)
+	}
+	for _, image := range []string{"/obra/private", "/obra/gone"} {
+		if found, err := client.Exists(host + image); found || !errors.Is(err, ErrNotFoundOrPrivate) {
+			t.Errorf("Exists(%s) = %v, %v, want ErrNotFoundOrPrivate", image, found, err)
+		}
+	}
+	if _, err := client.Exists("127.0.0.1:1/obra/img"); err == nil || errors.Is(err, ErrNotFoundOrPrivate) {
+		t.Errorf("Exists() of an unreachable registry = %v, want a plain error", err)
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	params := parseChallenge(`Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="repository:a/b:pull,push"`)
+	if params["realm"] != "https://ghcr.io/token" || params["service"] != "ghcr.io" || params["scope"] != "repository:a/b:pull,push" {
+		t.Errorf("parseChallenge() = %v", params)
+	}
+}
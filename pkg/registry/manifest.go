@@ -0,0 +1,203 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Manifest media types, single-platform and multi-platform, in Docker's and OCI's forms
+const (
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+)
+
+// manifestAccept asks for whichever form the registry has
+var manifestAccept = strings.Join([]string{mediaTypeOCIIndex, mediaTypeDockerManifestList, mediaTypeOCIManifest, mediaTypeDockerManifest}, ", ")
+
+// maxManifestSize bounds how much of a manifest is read
+const maxManifestSize = 4 << 20
+
+// Platform is an OS and CPU architecture an image is built for
+type Platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// ParsePlatform parses "os/arch[/variant]", normalizing architecture names the way
+// docker info and uname print them (x86_64, aarch64, armv7l)
+func ParsePlatform(s string) (Platform, error) {
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return Platform{}, fmt.Errorf("invalid platform %q (want os/arch[/variant])", s)
+	}
+	p := Platform{OS: strings.ToLower(parts[0])}
+	p.Architecture, p.Variant = normalizeArch(strings.ToLower(parts[1]))
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+	return p, nil
+}
+
+// normalizeArch maps an architecture name to the one registries use, with its variant
+func normalizeArch(arch string) (string, string) {
+	switch arch {
+	case "x86_64", "x86-64":
+		return "amd64", ""
+	case "aarch64":
+		return "arm64", ""
+	case "armv7l", "armhf":
+		return "arm", "v7"
+	case "armv6l", "armel":
+		return "arm", "v6"
+	case "i386", "i686":
+		return "386", ""
+	}
+	return arch, ""
+}
+
+func (p Platform) String() string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+// matches reports whether an index entry for other can run on p. An arm64 variant is
+// almost always v8, so a missing one matches it.
+func (p Platform) matches(other Platform) bool {
+	if p.OS != other.OS || p.Architecture != other.Architecture {
+		return false
+	}
+	if p.Variant == other.Variant {
+		return true
+	}
+	if p.Architecture == "arm64" {
+		return (p.Variant == "" || p.Variant == "v8") && (other.Variant == "" || other.Variant == "v8")
+	}
+	return p.Variant == ""
+}
+
+// Resolution is what an image tag points at for one platform
+type Resolution struct {
+	Reference string
+	// IndexDigest is the digest the tag resolves to: the multi-platform index, or the
+	// manifest itself for a single-platform image
+	IndexDigest string
+	// Digest is the platform's manifest, which changes only when its image does
+	Digest string
+	// ConfigDigest is the image config's digest, which the docker image store uses as
+	// the image ID
+	ConfigDigest string
+	Platform     Platform
+	// Platforms lists what a multi-platform index offers; it is empty otherwise
+	Platforms []Platform
+}
+
+// MultiPlatform reports whether the tag points at an index of several platforms
+func (r *Resolution) MultiPlatform() bool {
+	return len(r.Platforms) > 0
+}
+
+// manifest is the subset of an index or image manifest read here
+type manifest struct {
+	MediaType string `json:"mediaType"`
+	Config    struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	Manifests []struct {
+		MediaType string   `json:"mediaType"`
+		Digest    string   `json:"digest"`
+		Platform  Platform `json:"platform"`
+	} `json:"manifests"`
+}
+
+// Resolve finds the manifest image's tag (or digest) points at for platform. For a
+// multi-platform index it picks the platform's entry, skipping attestation manifests.
+func (c *Client) Resolve(image string, platform Platform) (*Resolution, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return nil, err
+	}
+	target := ref.Tag
+	if ref.Digest != "" {
+		target = ref.Digest
+	}
+
+	top, topDigest, err := c.fetchManifest(ref, target)
+	if err != nil {
+		return nil, err
+	}
+	res := &Resolution{Reference: ref.String(), IndexDigest: topDigest, Platform: platform}
+
+	if len(top.Manifests) == 0 {
+		// A single-platform image
+		res.Digest, res.ConfigDigest = topDigest, top.Config.Digest
+		return res, nil
+	}
+
+	for _, entry := range top.Manifests {
+		if entry.Platform.OS == "unknown" || entry.Platform.Architecture == "unknown" {
+			// buildx attaches attestations as unknown/unknown entries
+			continue
+		}
+		res.Platforms = append(res.Platforms, entry.Platform)
+		if res.Digest == "" && platform.matches(entry.Platform) {
+			res.Digest, res.Platform = entry.Digest, entry.Platform
+		}
+	}
+	if res.Digest == "" {
+		return nil, fmt.Errorf("%s has no image for %s (available: %s)", ref, platform, joinPlatforms(res.Platforms))
+	}
+
+	platformManifest, _, err := c.fetchManifest(ref, res.Digest)
+	if err != nil {
+		return nil, err
+	}
+	res.ConfigDigest = platformManifest.Config.Digest
+	return res, nil
+}
+
+// fetchManifest fetches the manifest for a tag or digest, returning it with its digest
+func (c *Client) fetchManifest(ref *Reference, target string) (*manifest, string, error) {
+	endpoint := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", c.scheme, ref.APIHost(), ref.Repository, target)
+	resp, err := c.get(endpoint, manifestAccept)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("registry returned %s for %s:%s", resp.Status, ref.Repository, target)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var m manifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, "", fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		digest = fmt.Sprintf("sha256:%x", sha256.Sum256(body))
+	}
+	return &m, digest, nil
+}
+
+func joinPlatforms(platforms []Platform) string {
+	names := make([]string, 0, len(platforms))
+	for _, p := range platforms {
+		names = append(names, p.String())
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,119 @@
+package registry
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newManifestRegistry serves a multi-platform index at :latest, with an attestation
+// entry, and a single-platform manifest at :single
+func newManifestRegistry(t *testing.T) *httptest.Server {
+	t.Helper()
+	manifests := map[string]string{
+		"latest": `{"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[
+			{"digest":"sha256:amd","platform":{"os":"linux","architecture":"amd64"}},
+			{"digest":"sha256:arm","platform":{"os":"linux","architecture":"arm64","variant":"v8"}},
+			{"digest":"sha256:att","platform":{"os":"unknown","architecture":"unknown"}}]}`,
+		"sha256:amd": `{"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"digest":"sha256:amdconfig"}}`,
+		"sha256:arm": `{"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"digest":"sha256:armconfig"}}`,
+		"single":     `{"mediaType":"application/vnd.docker.distribution.manifest.v2+json","config":{"digest":"sha256:singleconfig"}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target, ok := strings.CutPrefix(r.URL.Path, "/v2/obra/img/manifests/")
+		body, found := manifests[target]
+		if !ok || !found {
+			http.NotFound(w, r)
+			return
+		}
+		if !strings.Contains(r.Header.Get("Accept"), mediaTypeOCIIndex) {
+			http.Error(w, "missing Accept", http.StatusBadRequest)
+			return
+		}
+		if target == "latest" {
+			w.Header().Set("Docker-Content-Digest", "sha256:index")
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestResolve(t *testing.T) {
+	server := newManifestRegistry(t)
+	client := NewClient()
+	client.scheme = "http"
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	tests := []struct {
+		name       string
+		image      string
+		platform   string
+		wantDigest string
+		wantConfig string
+		wantIndex  string
+		wantMulti  bool
+		wantErr    bool
+	}{
+		{"amd64", "/obra/img:latest", "linux/amd64", "sha256:amd", "sha256:amdconfig", "sha256:index", true, false},
+		{"arm64 without variant", "/obra/img:latest", "linux/aarch64", "sha256:arm", "sha256:armconfig", "sha256:index", true, false},
+		{"missing platform", "/obra/img:latest", "linux/s390x", "", "", "", false, true},
+		{"single platform", "/obra/img:single", "linux/amd64", "", "sha256:singleconfig", "", false, false},
+		{"unknown tag", "/obra/img:nope", "linux/amd64", "", "", "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			platform, err := ParsePlatform(tt.platform)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := client.Resolve(host+tt.image, platform)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if res.ConfigDigest != tt.wantConfig || res.MultiPlatform() != tt.wantMulti {
+				t.Errorf("Resolve() = %+v", res)
+			}
+			if tt.wantMulti {
+				if res.Digest != tt.wantDigest || res.IndexDigest != tt.wantIndex || len(res.Platforms) != 2 {
+					t.Errorf("Resolve() = %+v", res)
+				}
+			} else if res.Digest == "" || res.Digest != res.IndexDigest {
+				// Without a Docker-Content-Digest header the digest is computed
+				t.Errorf("single-platform digests = %q, %q", res.Digest, res.IndexDigest)
+			}
+		})
+	}
+}
+
+func TestParsePlatform(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"linux/amd64", "linux/amd64", false},
+		{"linux/x86_64", "linux/amd64", false},
+		{"linux/aarch64", "linux/arm64", false},
+		{"linux/armv7l", "linux/arm/v7", false},
+		{"linux/arm64/v8", "linux/arm64/v8", false},
+		{"Linux/AMD64", "linux/amd64", false},
+		{"linux", "", true},
+		{"linux/", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePlatform(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePlatform(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got.String() != tt.want {
+			t.Errorf("ParsePlatform(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
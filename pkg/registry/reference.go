@@ -0,0 +1,97 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Reference is a parsed container image reference
+type Reference struct {
+	Registry   string // e.g. ghcr.io, docker.io
+	Repository string // e.g. obra/packnplay-default, library/ubuntu
+	Tag        string // e.g. latest (empty when only a digest is given)
+	Digest     string // e.g. sha256:abc... (optional)
+}
+
+// ParseReference parses an image reference like "ubuntu", "ghcr.io/obra/img:tag"
+// or "localhost:5000/img@sha256:...", applying Docker's defaults for registry and tag.
+func ParseReference(image string) (*Reference, error) {
+	if image == "" {
+		return nil, fmt.Errorf("image reference is empty")
+	}
+	if strings.ContainsAny(image, " \t\n") {
+		return nil, fmt.Errorf("invalid image reference %q: contains whitespace", image)
+	}
+
+	ref := &Reference{}
+	name := image
+
+	if idx := strings.Index(name, "@"); idx != -1 {
+		ref.Digest = name[idx+1:]
+		name = name[:idx]
+		if !strings.Contains(ref.Digest, ":") {
+			return nil, fmt.Errorf("invalid image reference %q: malformed digest", image)
+		}
+	}
+
+	// A tag is a colon after the last slash (colons before it belong to a registry port)
+	if idx := strings.LastIndex(name, ":"); idx != -1 && idx > strings.LastIndex(name, "/") {
+		ref.Tag = name[idx+1:]
+		name = name[:idx]
+		if ref.Tag == "" {
+			return nil, fmt.Errorf("invalid image reference %q: empty tag", image)
+		}
+	}
+
+	// The first component is a registry if it looks like a hostname
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.Registry = parts[0]
+		ref.Repository = parts[1]
+	} else {
+		ref.Registry = "docker.io"
+		ref.Repository = name
+	}
+
+	if ref.Registry == "docker.io" && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+
+	if ref.Repository == "" || strings.HasPrefix(ref.Repository, "/") || strings.HasSuffix(ref.Repository, "/") || strings.Contains(ref.Repository, "//") {
+		return nil, fmt.Errorf("invalid image reference %q: malformed repository", image)
+	}
+	if ref.Repository != strings.ToLower(ref.Repository) {
+		return nil, fmt.Errorf("invalid image reference %q: repository name must be lowercase", image)
+	}
+
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+
+	return ref, nil
+}
+
+// APIHost returns the host serving the registry API for this reference
+func (r *Reference) APIHost() string {
+	if r.Registry == "docker.io" {
+		return "registry-1.docker.io"
+	}
+	return r.Registry
+}
+
+// Name returns the reference without tag or digest, e.g. ghcr.io/obra/packnplay-default
+func (r *Reference) Name() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// String returns the fully qualified reference
+func (r *Reference) String() string {
+	s := r.Name()
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
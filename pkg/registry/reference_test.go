@@ -0,0 +1,58 @@
+package registry
+
+import "testing"
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		image      string
+		registry   string
+		repository string
+		tag        string
+		digest     string
+	}{
+		{"ubuntu", "docker.io", "library/ubuntu", "latest", ""},
+		{"ubuntu:22.04", "docker.io", "library/ubuntu", "22.04", ""},
+		{"node/node:20", "docker.io", "node/node", "20", ""},
+		{"ghcr.io/obra/packnplay-default:latest", "ghcr.io", "obra/packnplay-default", "latest", ""},
+		{"ghcr.io/obra/packnplay-default", "ghcr.io", "obra/packnplay-default", "latest", ""},
+		{"localhost:5000/img", "localhost:5000", "img", "latest", ""},
+		{"localhost/img:dev", "localhost", "img", "dev", ""},
+		{"mcr.microsoft.com/devcontainers/base@sha256:abc123", "mcr.microsoft.com", "devcontainers/base", "", "sha256:abc123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			ref, err := ParseReference(tt.image)
+			if err != nil {
+				t.Fatalf("ParseReference(%q) error = %v", tt.image, err)
+			}
+			if ref.Registry != tt.registry || ref.Repository != tt.repository || ref.Tag != tt.tag || ref.Digest != tt.digest {
+				t.Errorf("ParseReference(%q) = %+v, want %s %s %s %s", tt.image, ref, tt.registry, tt.repository, tt.tag, tt.digest)
+			}
+		})
+	}
+}
+
+func TestParseReferenceInvalid(t *testing.T) {
+	invalid := []string{"", "Ubuntu", "ubuntu:", "my image", "ghcr.io/", "img@nodigest"}
+	for _, image := range invalid {
+		if _, err := ParseReference(image); err == nil {
+			t.Errorf("ParseReference(%q) should fail", image)
+		}
+	}
+}
+
+func TestReferenceAPIHost(t *testing.T) {
+	ref, _ := ParseReference("ubuntu")
+	if ref.APIHost() != "registry-1.docker.io" {
+		t.Errorf("APIHost() = %v, want registry-1.docker.io", ref.APIHost())
+	}
+
+	ref, _ = ParseReference("ghcr.io/obra/packnplay-default")
+	if ref.APIHost() != "ghcr.io" {
+		t.Errorf("APIHost() = %v, want ghcr.io", ref.APIHost())
+	}
+	if ref.String() != "ghcr.io/obra/packnplay-default:latest" {
+		t.Errorf("String() = %v", ref.String())
+	}
+}
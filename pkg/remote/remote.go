@@ -0,0 +1,130 @@
+// Package remote runs packnplay against a docker daemon on another machine over SSH.
+// The docker CLI talks to the remote daemon through DOCKER_HOST=ssh://..., and the
+// project is cloned on the remote machine since local paths can't be mounted there.
+package remote
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Host is a remote machine reachable with ssh, e.g. "dev@build-01"
+type Host struct {
+	Target string
+}
+
+// DockerHost returns the DOCKER_HOST value that reaches the docker daemon on h
+func (h Host) DockerHost() string {
+	return "ssh://" + h.Target
+}
+
+// TargetFromDockerHost returns the ssh target in an ssh:// DOCKER_HOST, or "" for other
+// (local or tcp) hosts
+func TargetFromDockerHost(dockerHost string) string {
+	target, ok := strings.CutPrefix(dockerHost, "ssh://")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSuffix(target, "/")
+}
+
+// ValidateTarget checks that target looks like [user@]host[:port] and can't be mistaken
+// for an ssh option
+func ValidateTarget(target string) error {
+	if target == "" || strings.HasPrefix(target, "-") || strings.ContainsAny(target, " \t\n/") {
+		return fmt.Errorf("invalid remote host '%s' (expected user@server)", target)
+	}
+	return nil
+}
+
+// Command returns an ssh command that runs script with sh on h, passing args as $1, $2...
+// Everything is quoted for the remote shell.
+func (h Host) Command(script string, args ...string) *exec.Cmd {
+	remote := []string{"sh", "-c", shellQuote(script), "packnplay"}
+	for _, arg := range args {
+		remote = append(remote, shellQuote(arg))
+	}
+	sshArgs := []string{"-o", "BatchMode=yes"}
+	target := h.Target
+	if host, port, ok := strings.Cut(target, ":"); ok {
+		target = host
+		sshArgs = append(sshArgs, "-p", port)
+	}
+	sshArgs = append(sshArgs, target, "--", strings.Join(remote, " "))
+	return exec.Command("ssh", sshArgs...)
+}
+
+// prepareWorktreeScript clones the repo (or fetches when already cloned) into the remote
+// packnplay data directory and checks out the branch, creating it when it doesn't exist
+// on the remote. An existing checkout is fast-forwarded to origin's branch; local changes
+// on the remote are never discarded, so one that can't be is left with a warning. Prints
+// the path.
+const prepareWorktreeScript = `set -e
+data="${XDG_DATA_HOME:-$HOME/.local/share}/packnplay/worktrees/$1"
+dir="$data/$2"
+if [ -d "$dir/.git" ]; then
+	git -C "$dir" fetch --quiet origin
+else
+	mkdir -p "$data"
+	git clone --quiet "$3" "$dir"
+fi
+git -C "$dir" checkout --quiet "$4" 2>/dev/null || git -C "$dir" checkout --quiet -b "$4"
+if git -C "$dir" rev-parse --verify --quiet "refs/remotes/origin/$4" >/dev/null; then
+	git -C "$dir" merge --ff-only --quiet "origin/$4" >/dev/null 2>&1 ||
+		echo "Warning: $dir has changes that aren't on origin/$4; not updated from it" >&2
+fi
+printf '%s\n' "$dir"
+`
+
+// PrepareWorktree makes a checkout of branch from repoURL on h and returns its path on h
+func (h Host) PrepareWorktree(repoURL, project, branch string) (string, error) {
+	cmd := h.Command(prepareWorktreeScript, filepath.Base(project), sanitizeName(branch), repoURL, branch)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to prepare worktree on %s: %w\n%s", h.Target, err, strings.TrimSpace(string(output)))
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	path := lines[len(lines)-1]
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("unexpected output preparing worktree on %s: %s", h.Target, output)
+	}
+	return path, nil
+}
+
+// BindMount is a local path that was bind mounted into the container
+type BindMount struct {
+	Source string
+	Target string
+}
+
+// LocalMounts splits docker run args into those usable on a remote daemon and the
+// bind mounts of local paths, which don't exist there. Bind mounts of keep (a path on the
+// remote machine) and named volumes are kept.
+func LocalMounts(args []string, keep string) (remoteArgs []string, dropped []BindMount) {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "-v" && i+1 < len(args) {
+			source, rest, _ := strings.Cut(args[i+1], ":")
+			if filepath.IsAbs(source) && source != keep {
+				target, _, _ := strings.Cut(rest, ":")
+				dropped = append(dropped, BindMount{Source: source, Target: target})
+				i++
+				continue
+			}
+		}
+		remoteArgs = append(remoteArgs, args[i])
+	}
+	return remoteArgs, dropped
+}
+
+// sanitizeName makes a branch name safe for a single path component
+func sanitizeName(name string) string {
+	return strings.NewReplacer("/", "-", ":", "-", " ", "-").Replace(name)
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,162 @@
+package remote
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// runAsRemote runs the remote half of an ssh command with the local shell, the way sshd
+// would hand it to the user's shell
+func runAsRemote(t *testing.T, cmd *exec.Cmd) (string, error) {
+	t.Helper()
+	remoteCommand := cmd.Args[len(cmd.Args)-1]
+	out, err := exec.Command("sh", "-c", remoteCommand).CombinedOutput()
+	return string(out), err
+}
+
+func TestCommandQuoting(t *testing.T) {
+	cmd := Host{Target: "dev@build-01"}.Command(`printf '%s|' "$@"`, "plain", "it's quoted", "$HOME", "a b")
+	if cmd.Args[0] != "ssh" || cmd.Args[3] != "dev@build-01" || cmd.Args[4] != "--" {
+		t.Errorf("ssh args = %v", cmd.Args)
+	}
+
+	out, err := runAsRemote(t, cmd)
+	if err != nil {
+		t.Fatalf("remote command failed: %v: %s", err, out)
+	}
+	if out != "plain|it's quoted|$HOME|a b|" {
+		t.Errorf("remote args = %q, want them passed through verbatim", out)
+	}
+}
+
+func TestCommandPort(t *testing.T) {
+	cmd := Host{Target: "dev@build-01:2222"}.Command("true")
+	want := []string{"ssh", "-o", "BatchMode=yes", "-p", "2222", "dev@build-01", "--"}
+	if !reflect.DeepEqual(cmd.Args[:len(want)], want) {
+		t.Errorf("ssh args = %v, want prefix %v", cmd.Args, want)
+	}
+}
+
+func TestTargetFromDockerHost(t *testing.T) {
+	tests := map[string]string{
+		"ssh://dev@build-01":          "dev@build-01",
+		"ssh://dev@build-01:2222/":    "dev@build-01:2222",
+		"tcp://10.0.0.5:2376":         "",
+		"unix:///var/run/docker.sock": "",
+		"":                            "",
+	}
+	for dockerHost, want := range tests {
+		if got := TargetFromDockerHost(dockerHost); got != want {
+			t.Errorf("TargetFromDockerHost(%q) = %q, want %q", dockerHost, got, want)
+		}
+	}
+
+	if got := (Host{Target: "dev@build-01"}).DockerHost(); got != "ssh://dev@build-01" {
+		t.Errorf("DockerHost() = %q", got)
+	}
+}
+
+func TestValidateTarget(t *testing.T) {
+	for _, target := range []string{"dev@build-01", "build-01", "dev@10.0.0.5:2222"} {
+		if err := ValidateTarget(target); err != nil {
+			t.Errorf("ValidateTarget(%q) error = %v", target, err)
+		}
+	}
+	for _, target := range []string{"", "-oProxyCommand=evil", "dev@host name", "ssh://dev@host"} {
+		if err := ValidateTarget(target); err == nil {
+			t.Errorf("ValidateTarget(%q) accepted an invalid target", target)
+		}
+	}
+}
+
+func TestPrepareWorktreeScript(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	// An origin repo with a main branch and a feature branch
+	origin := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-q", "--allow-empty", "-m", "init"},
+		{"branch", "feature/login"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", origin}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+	}
+
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	host := Host{Target: "dev@build-01"}
+
+	for _, branch := range []string{"feature/login", "feature/login", "new-branch"} {
+		out, err := runAsRemote(t, host.Command(prepareWorktreeScript, "myapp", sanitizeName(branch), origin, branch))
+		if err != nil {
+			t.Fatalf("prepare %s failed: %v: %s", branch, err, out)
+		}
+
+		wantDir := filepath.Join(dataHome, "packnplay", "worktrees", "myapp", sanitizeName(branch))
+		if strings.TrimSpace(out) != wantDir {
+			t.Errorf("prepare %s printed %q, want %q", branch, out, wantDir)
+		}
+		current, _ := exec.Command("git", "-C", wantDir, "branch", "--show-current").Output()
+		if strings.TrimSpace(string(current)) != branch {
+			t.Errorf("checked out %q, want %q", current, branch)
+		}
+	}
+
+	// A branch that moved on origin is brought up to date in the existing checkout
+	if out, err := exec.Command("git", "-C", origin, "-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-q", "--allow-empty", "-m", "more").CombinedOutput(); err != nil {
+		t.Fatalf("commit failed: %v: %s", err, out)
+	}
+	if out, err := runAsRemote(t, host.Command(prepareWorktreeScript, "myapp", "main", origin, "main")); err != nil {
+		t.Fatalf("prepare main failed: %v: %s", err, out)
+	}
+	want, _ := exec.Command("git", "-C", origin, "rev-parse", "HEAD").Output()
+	got, _ := exec.Command("git", "-C", filepath.Join(dataHome, "packnplay", "worktrees", "myapp", "main"), "rev-parse", "HEAD").Output()
+	if string(got) != string(want) {
+		t.Errorf("remote checkout is at %s, want origin's %s", got, want)
+	}
+}
+
+func TestLocalMounts(t *testing.T) {
+	args := []string{
+		"run", "-d",
+		"-v", "/home/me/.claude:/home/vscode/.claude",
+		"-v", "/srv/packnplay/worktrees/app/main:/srv/packnplay/worktrees/app/main",
+		"-v", "/home/me/.ssh:/home/vscode/.ssh:ro",
+		"-v", "packnplay-cache:/var/cache/packnplay",
+		"-e", "IS_SANDBOX=1",
+		"ubuntu",
+	}
+
+	got, dropped := LocalMounts(args, "/srv/packnplay/worktrees/app/main")
+	want := []string{
+		"run", "-d",
+		"-v", "/srv/packnplay/worktrees/app/main:/srv/packnplay/worktrees/app/main",
+		"-v", "packnplay-cache:/var/cache/packnplay",
+		"-e", "IS_SANDBOX=1",
+		"ubuntu",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LocalMounts() args = %v, want %v", got, want)
+	}
+	wantDropped := []BindMount{
+		{Source: "/home/me/.claude", Target: "/home/vscode/.claude"},
+		{Source: "/home/me/.ssh", Target: "/home/vscode/.ssh"},
+	}
+	if !reflect.DeepEqual(dropped, wantDropped) {
+		t.Errorf("LocalMounts() dropped = %v", dropped)
+	}
+}
+
+func TestMain(m *testing.M) {
+	// Keep git from reading the developer's config in the script test
+	os.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	os.Exit(m.Run())
+}
@@ -0,0 +1,113 @@
+package remote
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// fileState is what Scan compares to tell that a file changed
+type fileState struct {
+	size    int64
+	modTime time.Time
+	mode    os.FileMode
+}
+
+// Snapshot is the state of the files under a directory, keyed by slash-separated
+// relative path
+type Snapshot map[string]fileState
+
+// Scan records the regular files and symlinks under root. Git's own directory is left
+// out: the container commits to its copy, which the host's must not overwrite.
+func Scan(root string) (Snapshot, error) {
+	snapshot := make(Snapshot)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path != root && os.IsNotExist(err) {
+				// Deleted while walking
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" && path != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() && info.Mode()&os.ModeSymlink == 0 {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		snapshot[filepath.ToSlash(rel)] = fileState{size: info.Size(), modTime: info.ModTime(), mode: info.Mode()}
+		return nil
+	})
+	return snapshot, err
+}
+
+// Changed returns the files in s that are new or different since prev, sorted. Files
+// deleted since prev aren't reported; a sync only adds and updates.
+func (s Snapshot) Changed(prev Snapshot) []string {
+	var changed []string
+	for path, state := range s {
+		if old, ok := prev[path]; !ok || old != state {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// WriteTar writes files, relative to root, to w as a tar archive. Files that vanished
+// since they were listed are skipped.
+func WriteTar(w io.Writer, root string, files []string) error {
+	tw := tar.NewWriter(w)
+	for _, rel := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = rel
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			if err := copyFile(tw, path, header.Size); err != nil {
+				return err
+			}
+		}
+	}
+	return tw.Close()
+}
+
+// copyFile writes the first size bytes of path, the size its header promised even if the
+// file has grown since
+func copyFile(w io.Writer, path string, size int64) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	_, err = io.CopyN(w, file, size)
+	return err
+}
@@ -0,0 +1,99 @@
+package remote
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestScanAndChanged(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main.go", "package main")
+	write("pkg/a.go", "package pkg")
+	write(".git/index", "git state")
+	if err := os.Symlink("main.go", filepath.Join(root, "link.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := Scan(root)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if got, want := first.Changed(nil), []string{"link.go", "main.go", "pkg/a.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Changed(nil) = %v, want %v", got, want)
+	}
+
+	write("pkg/a.go", "package pkg // edited")
+	write("new.txt", "new")
+	write(".git/index", "changed git state")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(root, "pkg/a.go"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(root, "main.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := Scan(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := second.Changed(first), []string{"new.txt", "pkg/a.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Changed() = %v, want %v", got, want)
+	}
+}
+
+func TestWriteTar(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "pkg", "a.go"), []byte("package pkg"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("pkg/a.go", filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTar(&buf, root, []string{"link", "pkg/a.go", "vanished.txt"}); err != nil {
+		t.Fatalf("WriteTar() error = %v", err)
+	}
+
+	tr := tar.NewReader(&buf)
+	got := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeSymlink {
+			got[header.Name] = "-> " + header.Linkname
+			continue
+		}
+		data, _ := io.ReadAll(tr)
+		got[header.Name] = string(data)
+	}
+	want := map[string]string{"link": "-> pkg/a.go", "pkg/a.go": "package pkg"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tar contents = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,149 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Forwarded agent sockets are mounted through a per-container host directory holding a
+// hard link to the agent's socket, rather than by mounting the socket file itself. A file
+// mount pins the socket the agent had when the container started, so once the agent is
+// restarted (after the laptop sleeps, or a new login session) the container is left with
+// a dead socket. Replacing the link in the mounted directory points the running container
+// at the new one.
+
+// forwardedAgent is an agent whose socket can be forwarded through a link directory
+type forwardedAgent struct {
+	name            string                 // for messages
+	kind            string                 // subdirectory of the container's agent directory
+	containerSocket string                 // where the socket appears in the container
+	hostSocket      func() (string, error) // finds the agent's current socket on the host
+}
+
+var forwardedAgents = []forwardedAgent{
+	{name: "ssh-agent", kind: "ssh", containerSocket: containerAgentSocket, hostSocket: sshAuthSock},
+	{name: "gpg-agent", kind: "gpg", containerSocket: containerGPGAgentSocket, hostSocket: gpgExtraSocket},
+}
+
+// agentDir is the host directory holding the agent socket links of a container. It lives
+// under XDG_RUNTIME_DIR when there is one, which is where agents usually keep their
+// sockets too, since a hard link can't cross filesystems.
+func agentDir(containerName string) string {
+	return filepath.Join(agentsRoot(), containerName)
+}
+
+// agentsRoot is the host directory holding the agent directories of all containers
+func agentsRoot() string {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "packnplay", "agents")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("packnplay-%d", os.Getuid()), "agents")
+}
+
+// linkAgentMounts rewrites the agent socket mounts in args to mount a link directory
+// instead, so refreshAgentSockets can re-forward them later. A socket that can't be linked
+// (one on another filesystem, or Docker Desktop's relay, which lives in its VM) keeps its
+// file mount.
+func linkAgentMounts(args []string, containerName string, verbose bool) []string {
+	dir := agentDir(containerName)
+	// Links left by an earlier container of the same name point at old sockets
+	_ = os.RemoveAll(dir)
+
+	rewritten := make([]string, len(args))
+	copy(rewritten, args)
+	for i := 0; i+1 < len(rewritten); i++ {
+		if rewritten[i] != "-v" {
+			continue
+		}
+		source, target, _ := strings.Cut(rewritten[i+1], ":")
+		for _, agent := range forwardedAgents {
+			if target != agent.containerSocket || source == dockerDesktopAgentSocket {
+				continue
+			}
+			link := agent.link(dir)
+			if err := linkSocket(source, link); err != nil {
+				if verbose {
+					fmt.Fprintf(os.Stderr, "Mounting %s socket directly, so it won't be re-forwarded if the agent restarts: %v\n", agent.name, err)
+				}
+				continue
+			}
+			rewritten[i+1] = fmt.Sprintf("%s:%s", filepath.Dir(link), filepath.Dir(agent.containerSocket))
+		}
+	}
+	return rewritten
+}
+
+// refreshAgentSockets re-forwards the agents of a running container whose host socket
+// changed since it was linked. Agents that were mounted directly are left alone.
+func refreshAgentSockets(containerName string, verbose bool) {
+	dir := agentDir(containerName)
+	for _, agent := range forwardedAgents {
+		link := agent.link(dir)
+		linked, err := os.Stat(link)
+		if err != nil {
+			continue
+		}
+
+		socket, err := agent.hostSocket()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s can't be re-forwarded into the container: %v\n", agent.name, err)
+			continue
+		}
+		if current, err := os.Stat(socket); err == nil && os.SameFile(linked, current) {
+			continue
+		}
+		if err := linkSocket(socket, link); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s moved to %s, which can't be re-forwarded into the running container (%v); restart the container to forward it again\n", agent.name, socket, err)
+			continue
+		}
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Re-forwarded %s from %s\n", agent.name, socket)
+		}
+	}
+}
+
+// RemoveAgentLinks removes the agent socket links of a container that was removed
+func RemoveAgentLinks(containerName string) {
+	_ = os.RemoveAll(agentDir(containerName))
+}
+
+// link is where the agent's socket is linked in a container's agent directory
+func (a forwardedAgent) link(dir string) string {
+	return filepath.Join(dir, a.kind, filepath.Base(a.containerSocket))
+}
+
+// linkSocket points link at socket. The new link is put in place with a rename, so a
+// container connecting meanwhile sees either the old socket or the new one.
+func linkSocket(socket, link string) error {
+	if err := os.MkdirAll(filepath.Dir(link), 0700); err != nil {
+		return err
+	}
+	// The container user may not be the host user, and the socket's own mode decides access
+	if err := os.Chmod(filepath.Dir(link), 0755); err != nil {
+		return err
+	}
+	tmp := link + ".tmp"
+	_ = os.Remove(tmp)
+	if err := os.Link(socket, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, link); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// sshAuthSock returns the host's current ssh-agent socket
+func sshAuthSock() (string, error) {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return "", fmt.Errorf("SSH_AUTH_SOCK is not set; start an agent with: eval $(ssh-agent) && ssh-add")
+	}
+	if !fileExists(socket) {
+		return "", fmt.Errorf("ssh-agent socket %s does not exist", socket)
+	}
+	return socket, nil
+}
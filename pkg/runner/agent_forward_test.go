@@ -0,0 +1,102 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLinkAgentMounts(t *testing.T) {
+	runtimeDir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+	// Hard links work the same for regular files, which stand in for the sockets
+	sshSocket := filepath.Join(runtimeDir, "ssh-agent.sock")
+	gpgSocket := filepath.Join(runtimeDir, "S.gpg-agent.extra")
+	for _, path := range []string{sshSocket, gpgSocket} {
+		if err := os.WriteFile(path, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	missing := filepath.Join(runtimeDir, "missing.sock")
+	dir := agentDir("pnp-test")
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			"ssh-agent is mounted through its link directory",
+			[]string{"-v", sshSocket + ":" + containerAgentSocket, "-e", "SSH_AUTH_SOCK=" + containerAgentSocket},
+			[]string{"-v", filepath.Join(dir, "ssh") + ":" + filepath.Dir(containerAgentSocket), "-e", "SSH_AUTH_SOCK=" + containerAgentSocket},
+		},
+		{
+			"gpg-agent is mounted through its link directory",
+			[]string{"-v", gpgSocket + ":" + containerGPGAgentSocket, "-v", "/keys.gpg:" + containerGPGPublicKeys + ":ro"},
+			[]string{"-v", filepath.Join(dir, "gpg") + ":" + filepath.Dir(containerGPGAgentSocket), "-v", "/keys.gpg:" + containerGPGPublicKeys + ":ro"},
+		},
+		{
+			"docker desktop's relay stays a file mount",
+			[]string{"-v", dockerDesktopAgentSocket + ":" + containerAgentSocket},
+			[]string{"-v", dockerDesktopAgentSocket + ":" + containerAgentSocket},
+		},
+		{
+			"a socket that can't be linked stays a file mount",
+			[]string{"-v", missing + ":" + containerAgentSocket},
+			[]string{"-v", missing + ":" + containerAgentSocket},
+		},
+		{
+			"other mounts are left alone",
+			[]string{"-v", sshSocket + ":/home/user/agent.sock"},
+			[]string{"-v", sshSocket + ":/home/user/agent.sock"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := linkAgentMounts(tt.args, "pnp-test", false)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("linkAgentMounts() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefreshAgentSockets(t *testing.T) {
+	runtimeDir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+	before := filepath.Join(runtimeDir, "before.sock")
+	after := filepath.Join(runtimeDir, "after.sock")
+	for _, path := range []string{before, after} {
+		if err := os.WriteFile(path, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	linkAgentMounts([]string{"-v", before + ":" + containerAgentSocket}, "pnp-test", false)
+	link := filepath.Join(agentDir("pnp-test"), "ssh", filepath.Base(containerAgentSocket))
+
+	// The agent restarted with a new socket while the container kept running
+	if err := os.Remove(before); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SSH_AUTH_SOCK", after)
+	refreshAgentSockets("pnp-test", false)
+
+	linked, err := os.Stat(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := os.Stat(after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(linked, current) {
+		t.Errorf("link still points at the old socket after refresh")
+	}
+
+	RemoveAgentLinks("pnp-test")
+	if _, err := os.Stat(agentDir("pnp-test")); !os.IsNotExist(err) {
+		t.Errorf("RemoveAgentLinks() left %s behind", agentDir("pnp-test"))
+	}
+}
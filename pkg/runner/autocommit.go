@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/git"
+)
+
+// finishRun wraps up a managed run once its command has exited: the changes are committed
+// with --auto-commit, then the exit summary is printed
+func finishRun(containerName, workingDir string, config *RunConfig, code int, duration time.Duration) {
+	if config.AutoCommit {
+		if config.remoteName() != "" {
+			fmt.Fprintf(os.Stderr, "Warning: --auto-commit doesn't work with remote hosts, leaving changes uncommitted\n")
+		} else if config.CopyWorkspace {
+			fmt.Fprintf(os.Stderr, "Warning: --auto-commit doesn't work with --copy-workspace; packnplay export applies the changes\n")
+		} else {
+			autoCommit(workingDir, append([][]string{config.Command}, config.Then...), config.AutoCommitMessage, code, time.Now())
+		}
+	}
+	reportExit(containerName, workingDir, config, code, duration)
+}
+
+// autoCommit commits everything the run changed in workingDir to its current branch, so
+// unattended runs leave a reviewable commit instead of a dirty tree. Failures are only
+// warnings; the changes stay in the tree.
+func autoCommit(workingDir string, steps [][]string, tmpl string, code int, exited time.Time) {
+	if !git.IsGitRepo(workingDir) {
+		fmt.Fprintf(os.Stderr, "Warning: %s is not a git repository, nothing to auto-commit\n", workingDir)
+		return
+	}
+
+	var commands []string
+	for _, step := range steps {
+		commands = append(commands, strings.Join(step, " "))
+	}
+	branch, _ := git.GetCurrentBranch(workingDir)
+	message, err := config.AutoCommitMessage(tmpl, config.AutoCommitData{
+		Command:  strings.Join(commands, " --then "),
+		Time:     exited.Format(time.RFC3339),
+		ExitCode: code,
+		Branch:   branch,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to auto-commit: %v\n", err)
+		return
+	}
+
+	hash, err := git.CommitAll(workingDir, message)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to auto-commit: %v\n", err)
+		return
+	}
+	if hash != "" {
+		fmt.Fprintf(os.Stderr, "packnplay: committed changes to %s as %s\n", branchOrHEAD(branch), hash)
+	}
+}
+
+// branchOrHEAD names a detached HEAD, where the current branch is empty
+func branchOrHEAD(branch string) string {
+	if branch == "" {
+		return "detached HEAD"
+	}
+	return branch
+}
@@ -0,0 +1,38 @@
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAutoCommit(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	repoDir := t.TempDir()
+	if out, err := exec.Command("git", "-C", repoDir, "init", "-q", "-b", "agent-run").CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, out)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "fix.go"), []byte("package fix\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	exited := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	steps := [][]string{{"npm", "ci"}, {"claude", "-p", "fix it"}}
+	autoCommit(repoDir, steps, "{{.Branch}}: {{.Command}} ({{.ExitCode}}, {{.Time}})", 2, exited)
+
+	out, err := exec.Command("git", "-C", repoDir, "log", "-1", "--format=%s").Output()
+	if err != nil {
+		t.Fatalf("no commit made: %v", err)
+	}
+	want := "agent-run: npm ci --then claude -p fix it (2, 2026-10-16T09:30:00Z)"
+	if got := strings.TrimSpace(string(out)); got != want {
+		t.Errorf("commit subject = %q, want %q", got, want)
+	}
+}
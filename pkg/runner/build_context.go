@@ -0,0 +1,134 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/obra/packnplay/pkg/cache"
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/devcontainer"
+)
+
+// buildContextWarnSize is the build context size above which builds warn that the
+// whole context is sent to the builder
+const buildContextWarnSize = 500 * 1024 * 1024
+
+// warnLargeBuildContext warns when the build context, less ignored files, is large
+// enough to slow every build down
+func warnLargeBuildContext(contextDir string, patterns []string, verbose bool) {
+	size, files, err := devcontainer.ContextSize(contextDir, patterns)
+	if err != nil {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Warning: failed to measure build context: %v\n", err)
+		}
+		return
+	}
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Build context %s: %s in %d files\n", contextDir, cache.FormatSize(size), files)
+	}
+	if size > buildContextWarnSize {
+		fmt.Fprintf(os.Stderr, "Warning: the build context %s is %s in %d files, all sent to the builder; leave out what the image doesn't need with .dockerignore or %s, or point build.context in devcontainer.json at a smaller directory\n",
+			contextDir, cache.FormatSize(size), files, devcontainer.IgnoreFile)
+	}
+}
+
+// withIgnorePatterns makes a build leave out patterns, which include .packnplayignore's
+// that the builder doesn't read by itself. Podman takes an --ignorefile; Docker reads
+// Dockerfile.dockerignore next to the Dockerfile, so the Dockerfile is copied next to
+// one. The returned cleanup removes the files.
+func withIgnorePatterns(args []string, podman bool, patterns []string) ([]string, func(), error) {
+	dir, err := os.MkdirTemp("", "packnplay-build-")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+	ignore := []byte(strings.Join(patterns, "\n") + "\n")
+
+	args = append([]string{}, args...)
+	if podman {
+		ignoreFile := filepath.Join(dir, ".containerignore")
+		if err := os.WriteFile(ignoreFile, ignore, 0644); err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		return append(args[:1], append([]string{"--ignorefile", ignoreFile}, args[1:]...)...), cleanup, nil
+	}
+
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] != "-f" {
+			continue
+		}
+		dockerfile, err := os.ReadFile(args[i+1])
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("failed to read Dockerfile: %w", err)
+		}
+		copied := filepath.Join(dir, "Dockerfile")
+		if err := os.WriteFile(copied, dockerfile, 0644); err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		if err := os.WriteFile(copied+".dockerignore", ignore, 0644); err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		args[i+1] = copied
+		break
+	}
+	return args, cleanup, nil
+}
+
+// checkBuildPaths refuses to build an untrusted workspace's image from a Dockerfile or
+// build context outside the project, which would send host files to the builder
+func checkBuildPaths(devConfig *devcontainer.Config, projectPath string) error {
+	for _, path := range devConfig.BuildPaths(projectPath) {
+		if !config.PathInProject(path, projectPath) {
+			return fmt.Errorf("devcontainer.json builds from %s, outside the untrusted workspace %s (trust it with: packnplay trust)", path, projectPath)
+		}
+	}
+	return nil
+}
+
+// buildOptions are build flags for devcontainer images that aren't in devcontainer.json
+// because they refer to the host: BuildKit secrets and ssh agent sockets, used by
+// RUN --mount=type=secret and --mount=type=ssh without ending up in a layer, and the
+// registry cache shared with other machines
+type buildOptions struct {
+	Secrets  []string // --secret values
+	SSH      []string // --ssh values
+	Cache    config.BuildCacheConfig
+	LocalEnv devcontainer.LookupFunc // Expands ${localEnv:VAR} in build args; nil for an untrusted workspace
+}
+
+// needsBuildKit reports whether the build uses flags only BuildKit understands
+func (o buildOptions) needsBuildKit() bool {
+	return len(o.Secrets) > 0 || len(o.SSH) > 0 || len(o.Cache.From) > 0 || o.Cache.To != ""
+}
+
+// apply adds the options to docker build args, before the trailing build context. It
+// fails when the runtime can't pass secrets, since the build would fail without them.
+func (o buildOptions) apply(args []string, supported bool) ([]string, error) {
+	if !o.needsBuildKit() {
+		return args, nil
+	}
+	if !supported {
+		return nil, fmt.Errorf("this container runtime can't pass build secrets, SSH access or a registry cache to image builds")
+	}
+	var flags []string
+	for _, secret := range o.Secrets {
+		flags = append(flags, "--secret", secret)
+	}
+	for _, ssh := range o.SSH {
+		flags = append(flags, "--ssh", ssh)
+	}
+	for _, from := range o.Cache.From {
+		flags = append(flags, "--cache-from", from)
+	}
+	if o.Cache.To != "" {
+		flags = append(flags, "--cache-to", o.Cache.To)
+	}
+	context := args[len(args)-1]
+	return append(append(append([]string{}, args[:len(args)-1]...), flags...), context), nil
+}
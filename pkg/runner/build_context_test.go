@@ -0,0 +1,124 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/devcontainer"
+)
+
+func TestWithIgnorePatterns(t *testing.T) {
+	dockerfile := filepath.Join(t.TempDir(), "Dockerfile")
+	if err := os.WriteFile(dockerfile, []byte("FROM ubuntu\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"build", "-f", dockerfile, "-t", "packnplay-app-devcontainer:latest", "/src/app"}
+	patterns := []string{"node_modules", "fixtures"}
+
+	t.Run("docker", func(t *testing.T) {
+		got, cleanup, err := withIgnorePatterns(args, false, patterns)
+		if err != nil {
+			t.Fatalf("withIgnorePatterns() error = %v", err)
+		}
+		defer cleanup()
+		if got[2] == dockerfile || got[len(got)-1] != "/src/app" {
+			t.Fatalf("args = %v, want a copied Dockerfile and the same context", got)
+		}
+		ignore, err := os.ReadFile(got[2] + ".dockerignore")
+		if err != nil || string(ignore) != "node_modules\nfixtures\n" {
+			t.Errorf("Dockerfile.dockerignore = %q, %v", ignore, err)
+		}
+		cleanup()
+		if _, err := os.Stat(got[2]); !os.IsNotExist(err) {
+			t.Errorf("cleanup left %s", got[2])
+		}
+	})
+
+	t.Run("podman", func(t *testing.T) {
+		got, cleanup, err := withIgnorePatterns(args, true, patterns)
+		if err != nil {
+			t.Fatalf("withIgnorePatterns() error = %v", err)
+		}
+		defer cleanup()
+		want := append([]string{"build", "--ignorefile", got[2]}, args[1:]...)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("args = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestBuildOptionsApply(t *testing.T) {
+	args := []string{"build", "-f", "/src/app/.devcontainer/Dockerfile", "-t", "packnplay-app-devcontainer:latest", "/src/app"}
+
+	if got, err := (buildOptions{}).apply(args, false); err != nil || !reflect.DeepEqual(got, args) {
+		t.Errorf("apply() without options = %v, %v", got, err)
+	}
+
+	opts := buildOptions{
+		Secrets: []string{"id=npmrc,src=/home/me/.npmrc"},
+		SSH:     []string{"default"},
+		Cache:   config.BuildCacheConfig{From: []string{"type=registry,ref=ghcr.io/team/app:buildcache"}, To: "type=inline"},
+	}
+	got, err := opts.apply(args, true)
+	if err != nil {
+		t.Fatalf("apply() error = %v", err)
+	}
+	want := []string{
+		"build", "-f", "/src/app/.devcontainer/Dockerfile", "-t", "packnplay-app-devcontainer:latest",
+		"--secret", "id=npmrc,src=/home/me/.npmrc",
+		"--ssh", "default",
+		"--cache-from", "type=registry,ref=ghcr.io/team/app:buildcache",
+		"--cache-to", "type=inline",
+		"/src/app",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("apply() = %v, want %v", got, want)
+	}
+	if args[len(args)-1] != "/src/app" {
+		t.Errorf("apply() modified its input: %v", args)
+	}
+
+	if _, err := opts.apply(args, false); err == nil {
+		t.Error("apply() on a runtime without build secrets should fail")
+	}
+}
+
+func TestCheckBuildPaths(t *testing.T) {
+	projectPath := t.TempDir()
+	outside := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(projectPath, ".devcontainer"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A link committed to the repository can't point the context elsewhere
+	if err := os.Symlink(outside, filepath.Join(projectPath, "linked")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		build   devcontainer.BuildConfig
+		wantErr bool
+	}{
+		{"inside", devcontainer.BuildConfig{Dockerfile: "Dockerfile", Context: ".."}, false},
+		{"context traversal", devcontainer.BuildConfig{Dockerfile: "Dockerfile", Context: "../../.."}, true},
+		{"dockerfile traversal", devcontainer.BuildConfig{Dockerfile: "../../../etc/Dockerfile"}, true},
+		{"absolute context", devcontainer.BuildConfig{Dockerfile: "Dockerfile", Context: outside}, true},
+		{"symlinked context", devcontainer.BuildConfig{Dockerfile: "Dockerfile", Context: "../linked"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			build := tt.build
+			devConfig := &devcontainer.Config{DockerFile: build.Dockerfile, Build: &build}
+			if err := checkBuildPaths(devConfig, projectPath); (err != nil) != tt.wantErr {
+				t.Errorf("checkBuildPaths() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := checkBuildPaths(&devcontainer.Config{Image: "ubuntu"}, projectPath); err != nil {
+		t.Errorf("checkBuildPaths() for a pulled image = %v", err)
+	}
+}
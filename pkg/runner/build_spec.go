@@ -0,0 +1,683 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/obra/packnplay/pkg/cache"
+	"github.com/obra/packnplay/pkg/container"
+	"github.com/obra/packnplay/pkg/credentials"
+	"github.com/obra/packnplay/pkg/devcontainer"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/git"
+	"github.com/obra/packnplay/pkg/inflight"
+	"github.com/obra/packnplay/pkg/mcp"
+	"github.com/obra/packnplay/pkg/preflight"
+	"github.com/obra/packnplay/pkg/remote"
+	"github.com/obra/packnplay/pkg/secrets"
+	"github.com/obra/packnplay/pkg/services"
+)
+
+// agentConfigDirs are the home directories of AI agents other than Claude, mounted when
+// they exist
+var agentConfigDirs = []string{".codex", ".gemini", ".copilot", ".qwen", ".cursor", ".deepseek", filepath.Join(".config", "amp")}
+
+// prepareWorkspace returns the directory to mount for the worktree, creating the worktree
+// when it doesn't exist yet (a dry run only says it would), and the main repository's
+// .git directory a worktree needs mounted alongside it
+func prepareWorkspace(config *RunConfig, workDir, worktreeName string) (mountPath, mainRepoGitDir string, err error) {
+	if config.RemoteHost != "" {
+		// Clone on the remote machine, since local paths can't be mounted there
+		mountPath, err = prepareRemoteWorktree(config.RemoteHost, workDir, worktreeName, config.Verbose)
+		return mountPath, "", err
+	}
+	if worktreeName == noWorktree {
+		return workDir, "", nil
+	}
+
+	exists, err := git.WorktreeExists(worktreeName)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to check worktree: %w", err)
+	}
+	switch {
+	case exists:
+		mountPath, err = git.GetWorktreePath(worktreeName)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get worktree path: %w", err)
+		}
+		if config.Verbose {
+			fmt.Fprintf(os.Stderr, "Using existing worktree at %s\n", mountPath)
+		}
+	case config.DryRun:
+		mountPath = git.DetermineWorktreePath(workDir, worktreeName)
+		dryRunNote(os.Stdout, "creates worktree %s at %s", worktreeName, mountPath)
+	default:
+		mountPath = git.DetermineWorktreePath(workDir, worktreeName)
+		if config.Verbose {
+			fmt.Fprintf(os.Stderr, "Creating worktree at %s\n", mountPath)
+		}
+		// Recorded so a worktree left half created by a crash is found next time
+		op := beginOperation(inflight.Operation{Kind: inflight.Worktree, Target: mountPath, Repo: workDir}, config.Verbose)
+		err := git.CreateWorktree(mountPath, worktreeName, config.Verbose)
+		op.Done()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to create worktree: %w", err)
+		}
+	}
+
+	// The worktree's .git file points into the main repo's .git directory by its real
+	// path, so symlinks are resolved to match
+	realWorkDir, err := filepath.EvalSymlinks(workDir)
+	if err != nil {
+		realWorkDir = workDir
+	}
+	return mountPath, filepath.Join(realWorkDir, ".git"), nil
+}
+
+// containerLabels returns the labels packnplay finds its containers by, with the
+// configured user labels added
+func containerLabels(config *RunConfig, workDir, projectName, worktreeName string) map[string]string {
+	var labels map[string]string
+	if config.HostPath != "" && config.LaunchCommand != "" {
+		labels = container.GenerateLabelsWithLaunchInfo(projectName, worktreeName, config.HostPath, config.LaunchCommand)
+	} else {
+		labels = container.GenerateLabels(projectName, worktreeName)
+	}
+	labels[container.ProjectHashLabel] = container.ProjectHash(workDir)
+	for _, key := range container.AddUserLabels(labels, config.Labels) {
+		fmt.Fprintf(os.Stderr, "Warning: label '%s' is reserved by packnplay, ignoring configured value\n", key)
+	}
+	return labels
+}
+
+// alreadyRunningError explains that the worktree's container is running, with the
+// commands to run command in it or stop it. currentDir decides whether the reconnect
+// command needs --worktree.
+func alreadyRunningError(details *ContainerDetails, command []string, worktreeName, currentDir string) error {
+	var cmdStr strings.Builder
+	for i, arg := range command {
+		if i > 0 {
+			cmdStr.WriteString(" ")
+		}
+		if strings.Contains(arg, " ") {
+			cmdStr.WriteString(fmt.Sprintf("'%s'", arg))
+		} else {
+			cmdStr.WriteString(arg)
+		}
+	}
+
+	// --worktree isn't needed from the directory the container was started for
+	worktreeFlag := ""
+	if (currentDir == "" || details.HostPath == "" || currentDir != details.HostPath) && worktreeName != noWorktree {
+		worktreeFlag = fmt.Sprintf(" --worktree=%s", worktreeName)
+	}
+
+	var msg strings.Builder
+	msg.WriteString("container already running for this worktree\n\n")
+	msg.WriteString("Container Details:\n")
+	fmt.Fprintf(&msg, "  Name: %s\n", details.Names)
+	fmt.Fprintf(&msg, "  Status: %s\n", details.Status)
+	fmt.Fprintf(&msg, "  Project: %s\n", details.Project)
+	fmt.Fprintf(&msg, "  Worktree: %s\n", details.Worktree)
+	if details.HostPath != "" {
+		fmt.Fprintf(&msg, "  Host Path: %s\n", details.HostPath)
+	}
+	if details.LaunchCommand != "" {
+		fmt.Fprintf(&msg, "  Original Command: %s\n", details.LaunchCommand)
+	}
+	msg.WriteString("\nTo run your command in the existing container:\n")
+	fmt.Fprintf(&msg, "  packnplay run%s --reconnect %s\n", worktreeFlag, cmdStr.String())
+	msg.WriteString("\nTo stop the existing container:\n")
+	fmt.Fprintf(&msg, "  packnplay stop %s", details.Names)
+	return fmt.Errorf("%s", msg.String())
+}
+
+// startCommand returns the subcommand that starts the container: run in the background,
+// or with --rm create, so .claude.json can be copied in before the command starts. Some
+// runtimes (Apple Container) don't support -it with -d.
+func startCommand(ephemeral, detachedTTY, interactive bool) []string {
+	switch {
+	case ephemeral:
+		return []string{"create", "--rm", execTTYFlags(interactive)}
+	case !detachedTTY:
+		return []string{"run", "-d"}
+	}
+	return []string{"run", "-d", "-it"}
+}
+
+// addClaudeMounts mounts ~/.claude according to the settings sync mode. When the host has
+// no usable .credentials.json, a per-container one is mounted over it.
+func addClaudeMounts(spec *ContainerSpec, config *RunConfig, homeDir, remoteUser string) error {
+	hostCredFile := filepath.Join(homeDir, ".claude", ".credentials.json")
+	hostHasCredentials := hostHasClaudeCredentials(homeDir)
+
+	var credentialFile string
+	if !hostHasCredentials {
+		if config.Verbose {
+			if !fileExists(hostCredFile) {
+				fmt.Fprintf(os.Stderr, "Host has no .credentials.json, using container-managed credentials\n")
+			} else {
+				fmt.Fprintf(os.Stderr, "Host .credentials.json is too small (%d bytes), using container-managed credentials\n", getFileSize(hostCredFile))
+			}
+		}
+		var err error
+		credentialFile, err = getOrCreateContainerCredentialFile(spec.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get credential file: %w", err)
+		}
+	} else if config.Verbose {
+		fmt.Fprintf(os.Stderr, "Using host .credentials.json (%d bytes)\n", getFileSize(hostCredFile))
+	}
+
+	claudeMounts, err := generateClaudeMounts(config.ClaudeSync, homeDir, remoteUser, spec.Name, hostHasCredentials, config.Verbose)
+	if err != nil {
+		return fmt.Errorf("failed to prepare .claude directory: %w", err)
+	}
+	spec.AddArgs(claudeMounts)
+
+	// Mounted after the .claude directory, so it lands on top of it
+	if !hostHasCredentials {
+		spec.AddMount(fmt.Sprintf("%s:/home/%s/.claude/.credentials.json", credentialFile, remoteUser))
+	}
+	return nil
+}
+
+// hostHasClaudeCredentials reports whether homeDir has a .credentials.json worth sharing;
+// an empty or placeholder file isn't
+func hostHasClaudeCredentials(homeDir string) bool {
+	stat, err := os.Stat(filepath.Join(homeDir, ".claude", ".credentials.json"))
+	return err == nil && stat.Size() >= 20
+}
+
+// copyContainerCredentials copies the container-managed .credentials.json into the
+// container's ~/.claude when the host has no usable one (macOS keeps it in the keychain).
+// docker cp works before the container starts, so --rm containers get it too.
+func copyContainerCredentials(dockerClient *docker.Client, containerID, containerName, homeDir, remoteUser string, verbose bool) {
+	if hostHasClaudeCredentials(homeDir) {
+		return
+	}
+	credentialFile, err := getOrCreateContainerCredentialFile(containerName)
+	if err != nil {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Warning: failed to get credential file: %v\n", err)
+		}
+		return
+	}
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Copying container credentials into .claude directory...\n")
+	}
+	if err := dockerClient.CopyToContainer(credentialFile, containerID, fmt.Sprintf("/home/%s/.claude/.credentials.json", remoteUser)); err != nil && verbose {
+		fmt.Fprintf(os.Stderr, "Warning: failed to copy credentials: %v\n", err)
+	}
+}
+
+// addAgentConfigMounts mounts the config directories of the other AI agents that exist
+// in homeDir
+func addAgentConfigMounts(spec *ContainerSpec, homeDir, remoteUser string, verbose bool) {
+	for _, dir := range agentConfigDirs {
+		agentPath := filepath.Join(homeDir, dir)
+		if !fileExists(agentPath) {
+			continue
+		}
+		spec.AddMount(fmt.Sprintf("%s:/home/%s/%s", agentPath, remoteUser, dir))
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Mounting %s config directory\n", dir)
+		}
+	}
+}
+
+// addCredentialFileMounts mounts the requested credential files from homeDir read-only:
+// .gitconfig, .ssh, .npmrc, and .gnupg unless the gpg-agent is forwarded, since a
+// read-only ~/.gnupg would stop gpg from using it. Missing ones are problems with
+// --strict-creds.
+func addCredentialFileMounts(spec *ContainerSpec, config *RunConfig, homeDir, remoteUser string, gpgAgentForwarded bool, creds *credentialCheck) {
+	type credentialFile struct {
+		requested bool
+		name      string // path in the home directory
+		kind      string
+		resolve   bool // files are mounted by their symlink target
+	}
+	files := []credentialFile{
+		{config.Credentials.Git, ".gitconfig", "git", true},
+		{config.Credentials.SSH, ".ssh", "SSH", false},
+		{config.Credentials.GPG && !gpgAgentForwarded, ".gnupg", "GPG", false},
+		{config.Credentials.NPM, ".npmrc", "npm", true},
+	}
+	for _, file := range files {
+		if !file.requested {
+			continue
+		}
+		path := filepath.Join(homeDir, file.name)
+		if !fileExists(path) {
+			creds.problemIfStrict("%s credentials requested but %s does not exist", file.kind, path)
+			continue
+		}
+		source := path
+		if file.resolve {
+			resolved, err := resolveMountPath(path)
+			if err != nil {
+				if config.Verbose {
+					fmt.Fprintf(os.Stderr, "Warning: failed to resolve %s symlink: %v\n", file.name, err)
+				}
+				resolved = path
+			}
+			source = resolved
+		}
+		spec.AddMount(fmt.Sprintf("%s:/home/%s/%s:ro", source, remoteUser, file.name))
+	}
+}
+
+// addAgentForwarding forwards the ssh-agent and gpg-agent when requested, so the container
+// can use the keys without seeing them. It reports whether the gpg-agent was forwarded.
+func addAgentForwarding(spec *ContainerSpec, dockerClient *docker.Client, config *RunConfig, isLinux bool, creds *credentialCheck) bool {
+	if config.Credentials.SSHAgent {
+		agentArgs, agentWarning := sshAgentArgs(dockerClient.Command(), os.Getenv("SSH_AUTH_SOCK"), isLinux, config.remoteName() != "")
+		spec.AddArgs(agentArgs)
+		if agentWarning != "" {
+			creds.problem("%s", strings.TrimPrefix(agentWarning, "Warning: "))
+		}
+	}
+
+	if !config.Credentials.GPGAgent {
+		return false
+	}
+	agentArgs, agentWarning := gpgAgentArgs(dockerClient.Command(), isLinux, config.remoteName() != "", prepareGPGAgent)
+	spec.AddArgs(agentArgs)
+	if agentWarning != "" {
+		creds.problem("%s", strings.TrimPrefix(agentWarning, "Warning: "))
+	}
+	return len(agentArgs) > 0
+}
+
+// addResourceLimits adds ulimits and CPU, memory and process caps, leaving out (with a
+// warning) what the runtime doesn't support; Apple Container only has CPU and memory
+// limits. Later values for the same resource win in docker.
+func addResourceLimits(spec *ContainerSpec, runtimeName string, caps docker.Capabilities, config *RunConfig) {
+	if len(config.Ulimits) > 0 && !caps.Ulimits {
+		fmt.Fprintf(os.Stderr, "Warning: %s does not support ulimits, ignoring %s\n", runtimeName, strings.Join(config.Ulimits, ", "))
+	} else {
+		for _, ulimit := range config.Ulimits {
+			spec.AddOption("--ulimit", ulimit)
+		}
+	}
+
+	resources := config.Resources
+	if !caps.SwapAndPids && (resources.MemorySwap != "" || resources.PidsLimit != 0) {
+		fmt.Fprintf(os.Stderr, "Warning: %s does not support swap or pids limits, ignoring them\n", runtimeName)
+		resources.MemorySwap, resources.PidsLimit = "", 0
+	}
+	spec.AddArgs(resources.Args())
+}
+
+// addUserMapping sets up how the container user maps to the host: rootless runtimes map
+// users differently, which changes who owns the mounted project, and supplemental groups
+// give it access to mounted sockets and devices
+func addUserMapping(spec *ContainerSpec, dockerClient *docker.Client, caps docker.Capabilities, remoteUser string, config *RunConfig, socketMounts []string) {
+	rootless := caps.DaemonInfo && dockerClient.Rootless()
+	rootlessRunArgs, rootlessWarning := rootlessArgs(dockerClient.IsPodman(), rootless, remoteUser)
+	spec.AddArgs(rootlessRunArgs)
+	if rootlessWarning != "" {
+		fmt.Fprintln(os.Stderr, rootlessWarning)
+	}
+	if config.Verbose && rootless {
+		fmt.Fprintf(os.Stderr, "Rootless %s detected\n", dockerClient.Command())
+	}
+
+	groups := supplementalGroups(config.GroupAdd, socketMounts, rootless && !dockerClient.IsPodman())
+	if len(groups) > 0 && !caps.GroupAdd {
+		fmt.Fprintf(os.Stderr, "Warning: %s does not support supplemental groups, ignoring %s\n", dockerClient.RuntimeName(), strings.Join(groups, ", "))
+		return
+	}
+	for _, group := range groups {
+		spec.AddOption("--group-add", group)
+	}
+	if config.Verbose && len(groups) > 0 {
+		fmt.Fprintf(os.Stderr, "Adding container user to groups: %s\n", strings.Join(groups, ", "))
+	}
+}
+
+// addPublishedPorts publishes --publish ports and devcontainer forwardPorts, and records
+// where each ended up for packnplay ports. Isolated containers have no route to publish
+// ports on.
+func addPublishedPorts(spec *ContainerSpec, config *RunConfig, forwardPorts []devcontainer.ForwardPort) error {
+	if config.Network.Isolated() {
+		if len(config.PublishPorts) > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: network mode %s can't publish ports, ignoring %s\n", config.Network.Mode, strings.Join(config.PublishPorts, ", "))
+		}
+		return nil
+	}
+
+	publishPorts := config.PublishPorts
+	// Host ports can only be checked when the runtime runs on this machine
+	if config.remoteName() == "" && len(publishPorts) > 0 {
+		var notes []string
+		var err error
+		publishPorts, notes, err = resolvePublishPorts(publishPorts, portChecker{
+			available: hostPortFree,
+			free:      freeHostPort,
+			reassign:  confirmPortReassignOnTerminal(),
+		})
+		if err != nil {
+			return err
+		}
+		for _, note := range notes {
+			fmt.Fprintln(os.Stderr, note)
+		}
+	}
+
+	if len(forwardPorts) > 0 && !config.NoForwardPorts {
+		mappings, skipped := forwardPortMappings(forwardPorts, publishPorts, hostPortAvailable)
+		publishPorts = append(publishPorts, mappings...)
+		if config.Verbose && len(mappings) > 0 {
+			fmt.Fprintf(os.Stderr, "Forwarding devcontainer ports: %s\n", strings.Join(mappings, ", "))
+		}
+		for _, reason := range skipped {
+			fmt.Fprintf(os.Stderr, "Warning: not forwarding port %s\n", reason)
+		}
+	}
+
+	for _, port := range publishPorts {
+		spec.AddPort(port)
+	}
+	if len(publishPorts) > 0 {
+		spec.AddLabel(container.PortsLabel, strings.Join(publishPorts, " "))
+	}
+	return nil
+}
+
+// addMCPHostGateway maps the host gateway name MCP server rewrites point at; Docker
+// Desktop provides host.docker.internal, Linux needs it mapped explicitly
+func addMCPHostGateway(spec *ContainerSpec, rewrite *mcp.RewriteResult, isLinux bool, config *RunConfig) {
+	if rewrite != nil && rewrite.NeedsHostGateway && isLinux && !config.Network.Isolated() {
+		spec.AddOption("--add-host", mcp.HostGatewayName+":host-gateway")
+	}
+}
+
+// runTarget is where a container is started: its name, the worktree, the directory
+// mounted as its workspace, and the host user's home directory
+type runTarget struct {
+	containerName  string
+	workDir        string
+	worktreeName   string
+	mountPath      string
+	mainRepoGitDir string // the main repo's .git directory a worktree needs, if any
+	homeDir        string
+	dockerMode     string
+}
+
+// launch is a container ready to start: its spec, and what is set up once it runs
+type launch struct {
+	spec              *ContainerSpec
+	homeDir           string
+	claudeJSONSrc     string
+	mcpRewrite        *mcp.RewriteResult
+	creds             *credentialCheck
+	gpgAgentForwarded bool
+	dependencyCaches  []cache.DependencyCache
+	services          []services.Service
+	remoteCopies      []remote.BindMount // host files copied in, since the daemon can't mount them
+	syncedMounts      []syncedMount      // volumes the workspace is copied into
+	sync              workspaceSync
+}
+
+// buildSpec gathers what the container is started with: its mounts, credentials, env
+// vars, limits and ports. It creates nothing on the daemon; the sidecars and sync volumes
+// the spec refers to are started separately.
+func buildSpec(dockerClient *docker.Client, devConfig *devcontainer.Config, config *RunConfig, target runTarget) (*launch, error) {
+	projectName := filepath.Base(target.workDir)
+	containerName := target.containerName
+
+	// Check if we're on Linux (idmap only supported on Linux)
+	isLinux := os.Getenv("OSTYPE") == "linux-gnu" || fileExists("/proc/version")
+
+	caps := dockerClient.Capabilities()
+	spec := NewContainerSpec(containerName)
+	spec.Image = containerImageName(devConfig, projectName)
+	spec.Command = keepAliveCommand(caps.DetachedTTY)
+	spec.WorkingDir = target.mountPath
+	for key, value := range containerLabels(config, target.workDir, projectName, target.worktreeName) {
+		spec.AddLabel(key, value)
+	}
+	// Name the host after the project and worktree instead of the container ID
+	if caps.Hostname {
+		spec.Hostname = container.GenerateHostname(projectName, target.worktreeName)
+	}
+
+	// ~/.claude, then the workspace at its host path (preserving absolute paths), then the
+	// other agents' config directories
+	if err := addClaudeMounts(spec, config, target.homeDir, devConfig.RemoteUser); err != nil {
+		return nil, err
+	}
+	spec.AddMount(fmt.Sprintf("%s:%s", target.mountPath, target.mountPath))
+	addAgentConfigMounts(spec, target.homeDir, devConfig.RemoteUser, config.Verbose)
+
+	// A worktree's .git file (gitdir: <path>) needs the main repo's .git at its real path
+	if target.mainRepoGitDir != "" {
+		spec.AddMount(fmt.Sprintf("%s:%s", target.mainRepoGitDir, target.mainRepoGitDir))
+	}
+
+	// Requested credentials that can't be provided; errors with --strict-creds
+	creds := &credentialCheck{strict: config.StrictCreds}
+	gpgAgentForwarded := addAgentForwarding(spec, dockerClient, config, isLinux, creds)
+	addCredentialFileMounts(spec, config, target.homeDir, devConfig.RemoteUser, gpgAgentForwarded, creds)
+
+	// Credential providers (gh, AWS, user-defined scripts) contribute mounts and env vars
+	config.ScopedCreds = scopedCredentials(config.ScopedCreds, target.workDir)
+	config.CredentialSession = containerName
+	providers, err := credentialProviders(config, isLinux)
+	if err != nil {
+		return nil, err
+	}
+	// Signed requests and short-lived tokens fail in a container whose clock has drifted
+	if !config.SkipPreflight && caps.DaemonInfo && timeSensitiveCredentials(config) {
+		if skew, err := preflight.ClockSkew(dockerClient, time.Now); err == nil {
+			for _, issue := range preflight.CheckClockSkew(skew) {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", issue)
+			}
+		}
+	}
+	credentialEnv := credentials.Environment{
+		HomeDir:       target.homeDir,
+		ContainerHome: fmt.Sprintf("/home/%s", devConfig.RemoteUser),
+		Verbose:       config.Verbose,
+		Strict:        config.StrictCreds,
+		Log:           os.Stderr,
+	}
+	credentialResults, err := collectCredentials(os.Stdout, providers, credentialEnv, config.DryRun)
+	if err := creds.add(err); err != nil {
+		return nil, err
+	}
+	if err := creds.err(); err != nil {
+		return nil, err
+	}
+	for _, result := range credentialResults {
+		for _, mount := range result.Mounts {
+			spec.AddMount(mount.String())
+		}
+	}
+	// Recorded so reconnecting and packnplay exec can fetch fresh ones once these expire
+	if credentialRefresh := credentialRefreshSpec(providers, config); credentialRefresh != nil {
+		spec.AddArgs(credentialRefresh.label())
+	}
+
+	// Shared cache for large downloads (browsers, model weights) reused across projects
+	if config.SharedCache {
+		sharedDir, err := cache.HostDir()
+		if err != nil {
+			return nil, err
+		}
+		if config.SharedCacheMax > 0 && !config.DryRun {
+			if result, err := cache.Prune(sharedDir, config.SharedCacheMax, time.Now()); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to prune shared cache: %v\n", err)
+			} else if result.Removed > 0 && config.Verbose {
+				fmt.Fprintf(os.Stderr, "Pruned %d entries (%s) from shared cache\n", result.Removed, cache.FormatSize(result.Freed))
+			}
+		}
+		spec.AddMount(fmt.Sprintf("%s:%s", sharedDir, cache.ContainerPath))
+		if config.Verbose {
+			fmt.Fprintf(os.Stderr, "Mounting shared cache %s at %s\n", sharedDir, cache.ContainerPath)
+		}
+	}
+
+	// Volumes over package manager caches, so installs don't start cold in each container
+	var dependencyCaches []cache.DependencyCache
+	if config.DependencyCaches.Enabled {
+		dependencyCaches, err = addDependencyCaches(spec, dockerClient, config.DependencyCaches, devConfig, target.workDir, config.DryRun, config.Verbose)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Extra mounts from project config
+	for _, mount := range config.Mounts {
+		spec.AddMount(mount)
+		if config.Verbose {
+			fmt.Fprintf(os.Stderr, "Mounting %s (project config)\n", mount)
+		}
+	}
+
+	// The host's docker socket, for --docker=socket
+	socketMounts := config.Mounts
+	if target.dockerMode == dockerSocketMode {
+		mount, err := dockerSocketMount(dockerClient.IsPodman())
+		if err != nil {
+			return nil, err
+		}
+		spec.AddMount(mount)
+		socketMounts = append(append([]string{}, config.Mounts...), mount)
+		fmt.Fprintf(os.Stderr, "Warning: --docker=socket gives the container full control of the host's docker daemon, which amounts to root on the host; --docker=dind keeps it in a sidecar\n")
+	}
+
+	addResourceLimits(spec, dockerClient.RuntimeName(), caps, config)
+
+	// Warn about host limits that make file watchers fail confusingly in containers
+	if !config.SkipPreflight && config.remoteName() == "" {
+		for _, issue := range preflight.Run("/proc") {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", issue)
+		}
+	}
+
+	addUserMapping(spec, dockerClient, caps, devConfig.RemoteUser, config, socketMounts)
+
+	// Fetch values that refer to a secret manager (op://, vault://, aws-sm://); a dry run
+	// shows the references instead
+	userEnv := config.Env
+	userSecrets := make(map[string]bool)
+	for _, entry := range config.Env {
+		if key, value, _ := strings.Cut(entry, "="); secrets.IsReference(value) {
+			userSecrets[key] = true
+		}
+	}
+	if !config.DryRun {
+		userEnv, err = secrets.ResolveEnv(config.Env)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Env vars pointing at the project's services come first, so --env can override them.
+	// Named volumes are per worktree, not per container, so data outlives --rm runs.
+	projectServices, err := resolveServices(spec, config.Services, container.GenerateContainerName(target.workDir, target.worktreeName), config.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only safe terminal/locale variables come from the host, plus API keys, credentials
+	// and --env values (which can override defaults and credentials)
+	runEnv{
+		RemoteUser:   devConfig.RemoteUser,
+		PassThrough:  config.DefaultEnvVars,
+		SharedCache:  config.SharedCache,
+		ContainerEnv: devConfig.ContainerEnvArgs(config.localEnv()),
+		HostEnv:      devConfig.ContainerEnvFromHost(),
+		Credentials:  credentialResults,
+		User:         userEnv,
+		UserSecrets:  userSecrets,
+		Getenv:       os.Getenv,
+	}.apply(spec)
+
+	// Rewrite host MCP server definitions for the container's .claude.json copy
+	claudeJSONSrc := filepath.Join(target.homeDir, ".claude.json")
+	var mcpRewrite *mcp.RewriteResult
+	if fileExists(claudeJSONSrc) {
+		mcpRewrite, err = rewriteClaudeJSON(claudeJSONSrc, config.MCPServers)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply mcp_servers mappings: %w", err)
+		}
+		addMCPHostGateway(spec, mcpRewrite, isLinux, config)
+	}
+
+	if err := addPublishedPorts(spec, config, devConfig.ForwardPorts); err != nil {
+		return nil, err
+	}
+	// Recorded so packnplay prune can tell which credential files are still in use
+	if dirs, err := credentials.FileDirs(); err == nil {
+		spec.AddArgs(credentials.FilesLabelArgs(spec.MountArgs(), dirs))
+	}
+
+	// Local files can't be bind mounted on a remote host; copy them in after starting
+	var remoteCopies []remote.BindMount
+	if config.RemoteHost != "" {
+		spec.RewriteMounts(func(args []string) []string {
+			args, remoteCopies = splitRemoteMounts(args, target.mountPath)
+			return args
+		})
+	}
+
+	// A daemon that isn't reached over ssh has no clone of the repo; the workspace and its
+	// git directory are copied into volumes and kept up to date from here. With
+	// --copy-workspace they are copied the same way but never synced: the agent works on
+	// the copy, and packnplay diff and export bring its changes back.
+	var syncedMounts []syncedMount
+	var sync workspaceSync
+	if config.RemoteDaemon != "" || config.CopyWorkspace {
+		syncedDirs := map[string]string{target.mountPath: "workspace"}
+		if target.mainRepoGitDir != "" {
+			syncedDirs[target.mainRepoGitDir] = "git"
+		}
+		spec.RewriteMounts(func(args []string) []string {
+			args, syncedMounts = volumeMounts(args, containerName, syncedDirs)
+			if config.RemoteDaemon != "" {
+				args, remoteCopies = splitRemoteMounts(args, "")
+			}
+			return args
+		})
+		// Either way the copy is what the container's changes live in until they are
+		// exported, so packnplay diff, export and stop look at it
+		sync = workspaceSync{User: devConfig.RemoteUser, Source: target.mountPath, Target: target.mountPath}
+		spec.AddArgs(copyWorkspaceArgs(sync))
+		if !config.CopyWorkspace {
+			spec.AddArgs(sync.label())
+		}
+	}
+
+	// Forward agent sockets through links that can be replaced when the agents restart
+	if !config.Ephemeral && !config.DryRun {
+		spec.RewriteMounts(func(args []string) []string {
+			return linkAgentMounts(args, containerName, config.Verbose)
+		})
+	}
+	// SELinux keeps containers away from host files that aren't labeled for them
+	if isLinux && config.remoteName() == "" && selinuxEnforcing(selinuxEnforcePath) {
+		spec.RewriteMounts(func(args []string) []string {
+			return selinuxMountArgs(args, target.homeDir, selinuxRelabelDirs(target.homeDir, target.workDir, target.mountPath), config.SELinuxLabelDisable)
+		})
+	}
+
+	return &launch{
+		spec:              spec,
+		homeDir:           target.homeDir,
+		claudeJSONSrc:     claudeJSONSrc,
+		mcpRewrite:        mcpRewrite,
+		creds:             creds,
+		gpgAgentForwarded: gpgAgentForwarded,
+		dependencyCaches:  dependencyCaches,
+		services:          projectServices,
+		remoteCopies:      remoteCopies,
+		syncedMounts:      syncedMounts,
+		sync:              sync,
+	}, nil
+}
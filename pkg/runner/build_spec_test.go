@@ -0,0 +1,137 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/devcontainer"
+	"github.com/obra/packnplay/pkg/docker"
+)
+
+func TestAlreadyRunningError(t *testing.T) {
+	details := &ContainerDetails{Names: "packnplay-app-1234abcd-main", Status: "Up", Project: "app", Worktree: "main", HostPath: "/src/app"}
+
+	err := alreadyRunningError(details, []string{"claude", "fix the bug"}, "main", "/src/app")
+	if !strings.Contains(err.Error(), "packnplay run --reconnect claude 'fix the bug'") {
+		t.Errorf("error = %q, want the reconnect command without --worktree", err)
+	}
+	if !strings.HasSuffix(err.Error(), "packnplay stop packnplay-app-1234abcd-main") {
+		t.Errorf("error = %q, want the stop command last", err)
+	}
+
+	err = alreadyRunningError(details, []string{"claude"}, "main", "/elsewhere")
+	if !strings.Contains(err.Error(), "packnplay run --worktree=main --reconnect claude") {
+		t.Errorf("error = %q, want --worktree from another directory", err)
+	}
+}
+
+func TestStartCommand(t *testing.T) {
+	tests := []struct {
+		ephemeral, detachedTTY bool
+		want                   []string
+	}{
+		{false, true, []string{"run", "-d", "-it"}},
+		{false, false, []string{"run", "-d"}},
+		{true, true, []string{"create", "--rm", "-it"}},
+	}
+	for _, tt := range tests {
+		if got := startCommand(tt.ephemeral, tt.detachedTTY, true); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("startCommand(%v, %v) = %v, want %v", tt.ephemeral, tt.detachedTTY, got, tt.want)
+		}
+	}
+}
+
+func TestAddCredentialFileMounts(t *testing.T) {
+	home := t.TempDir()
+	for _, name := range []string{".gitconfig", ".npmrc"} {
+		if err := os.WriteFile(filepath.Join(home, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(home, ".gnupg"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	runConfig := &RunConfig{Credentials: config.Credentials{Git: true, SSH: true, GPG: true, NPM: true}}
+	spec := NewContainerSpec("c")
+	creds := &credentialCheck{strict: true}
+	addCredentialFileMounts(spec, runConfig, home, "dev", true, creds)
+
+	want := []string{
+		filepath.Join(home, ".gitconfig") + ":/home/dev/.gitconfig:ro",
+		filepath.Join(home, ".npmrc") + ":/home/dev/.npmrc:ro",
+	}
+	if !reflect.DeepEqual(spec.Mounts, want) {
+		t.Errorf("Mounts = %v, want %v (no .gnupg with the agent forwarded)", spec.Mounts, want)
+	}
+	if len(creds.problems) != 1 || !strings.Contains(creds.problems[0], "SSH credentials requested") {
+		t.Errorf("problems = %v, want the missing .ssh", creds.problems)
+	}
+}
+
+func TestAddResourceLimits(t *testing.T) {
+	runConfig := &RunConfig{
+		Ulimits:   []string{"nofile=1024"},
+		Resources: config.ResourcesConfig{CPUs: "2", PidsLimit: 100},
+	}
+
+	spec := NewContainerSpec("c")
+	addResourceLimits(spec, "docker", docker.Capabilities{Ulimits: true, SwapAndPids: true}, runConfig)
+	if want := []string{"--ulimit", "nofile=1024", "--cpus", "2", "--pids-limit", "100"}; !reflect.DeepEqual(spec.Options, want) {
+		t.Errorf("Options = %v, want %v", spec.Options, want)
+	}
+
+	spec = NewContainerSpec("c")
+	addResourceLimits(spec, "container", docker.Capabilities{}, runConfig)
+	if want := []string{"--cpus", "2"}; !reflect.DeepEqual(spec.Options, want) {
+		t.Errorf("Options = %v, want %v without unsupported limits", spec.Options, want)
+	}
+}
+
+func TestBuildSpec(t *testing.T) {
+	fakeDockerCLI(t, "", "")
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dockerClient, err := docker.NewClient(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	workDir := filepath.Join(t.TempDir(), "app")
+	devConfig := &devcontainer.Config{Image: "ubuntu:24.04", RemoteUser: "vscode"}
+	l, err := buildSpec(dockerClient, devConfig, &RunConfig{DryRun: true, SkipPreflight: true}, runTarget{
+		containerName: "packnplay-app-main",
+		workDir:       workDir,
+		worktreeName:  "main",
+		mountPath:     workDir,
+		homeDir:       home,
+		dockerMode:    dockerOffMode,
+	})
+	if err != nil {
+		t.Fatalf("buildSpec() error = %v", err)
+	}
+
+	spec := l.spec
+	if spec.Name != "packnplay-app-main" || spec.Image != "ubuntu:24.04" || spec.WorkingDir != workDir {
+		t.Errorf("spec = %s %s in %s", spec.Name, spec.Image, spec.WorkingDir)
+	}
+	args := strings.Join(spec.Args(), " ")
+	if !strings.Contains(args, "-v "+workDir+":"+workDir) {
+		t.Errorf("args = %q, want the workspace mounted at its host path", args)
+	}
+	if !strings.Contains(args, "-e IS_SANDBOX=1") {
+		t.Errorf("args = %q, want IS_SANDBOX set", args)
+	}
+	if spec.Labels["managed-by"] != "packnplay" {
+		t.Errorf("labels = %v, want managed-by=packnplay", spec.Labels)
+	}
+	// Nothing to set up once it runs
+	if l.homeDir != home || l.syncedMounts != nil || l.services != nil || l.gpgAgentForwarded {
+		t.Errorf("launch = %+v", l)
+	}
+}
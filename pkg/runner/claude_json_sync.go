@@ -0,0 +1,228 @@
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/mcp"
+)
+
+// claudeJSONSyncAction describes which side of .claude.json should be copied
+type claudeJSONSyncAction int
+
+const (
+	claudeJSONInSync claudeJSONSyncAction = iota
+	claudeJSONHostToContainer
+	claudeJSONContainerToHost
+)
+
+// claudeJSONSyncEnabled reports whether .claude.json should be re-synced after start
+func claudeJSONSyncEnabled(strategy string) bool {
+	return strategy != "" && strategy != config.ClaudeJSONSyncOff
+}
+
+// decideClaudeJSONSync compares host and container hashes against the last synced hash.
+// When both sides changed, the conflict strategy picks the winner.
+func decideClaudeJSONSync(baseHash, hostHash, containerHash, strategy string) claudeJSONSyncAction {
+	hostChanged := hostHash != baseHash
+	containerChanged := containerHash != baseHash
+
+	switch {
+	case hostHash == containerHash:
+		return claudeJSONInSync
+	case hostChanged && !containerChanged:
+		return claudeJSONHostToContainer
+	case containerChanged && !hostChanged:
+		return claudeJSONContainerToHost
+	}
+
+	// Both changed: resolve using the conflict strategy
+	if strategy == config.ClaudeJSONSyncContainer {
+		return claudeJSONContainerToHost
+	}
+	return claudeJSONHostToContainer
+}
+
+// rewriteClaudeJSON applies MCP server mappings to the host .claude.json.
+// Returns nil when no mappings are configured so callers can copy the file as-is.
+func rewriteClaudeJSON(hostPath string, mappings map[string]config.MCPServerMapping) (*mcp.RewriteResult, error) {
+	if len(mappings) == 0 {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(hostPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", hostPath, err)
+	}
+
+	return mcp.RewriteClaudeJSON(data, mappings)
+}
+
+// copyClaudeJSONToContainer copies .claude.json into the container, using the
+// MCP-rewritten contents when available
+func copyClaudeJSONToContainer(dockerClient *docker.Client, containerID, hostPath, containerPath, user string, rewrite *mcp.RewriteResult, verbose bool) error {
+	if rewrite == nil {
+		return copyFileToContainer(dockerClient, containerID, hostPath, containerPath, user, verbose)
+	}
+
+	tempDir, err := os.MkdirTemp("", "packnplay-claude-json-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tempDir) }()
+
+	tempPath := filepath.Join(tempDir, ".claude.json")
+	if err := os.WriteFile(tempPath, rewrite.Data, 0600); err != nil {
+		return fmt.Errorf("failed to write rewritten .claude.json: %w", err)
+	}
+
+	if verbose && len(rewrite.Changed) > 0 {
+		fmt.Fprintf(os.Stderr, "Rewrote MCP servers for container: %s\n", strings.Join(rewrite.Changed, ", "))
+	}
+
+	return copyFileToContainer(dockerClient, containerID, tempPath, containerPath, user, verbose)
+}
+
+// syncClaudeJSON reconciles ~/.claude.json between host and a running container.
+// With MCP mappings configured, the host side is compared after rewriting, and container
+// changes are written back with the host's MCP servers in place of the rewritten ones.
+func syncClaudeJSON(dockerClient *docker.Client, containerID, containerName, homeDir, remoteUser, strategy string, mappings map[string]config.MCPServerMapping, verbose bool) error {
+	hostPath := filepath.Join(homeDir, ".claude.json")
+	containerPath := fmt.Sprintf("/home/%s/.claude.json", remoteUser)
+
+	if !fileExists(hostPath) {
+		return nil
+	}
+
+	rewrite, err := rewriteClaudeJSON(hostPath, mappings)
+	if err != nil {
+		return err
+	}
+
+	hostFile, err := os.ReadFile(hostPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", hostPath, err)
+	}
+	hostData := hostFile
+	if rewrite != nil {
+		hostData = rewrite.Data
+	}
+
+	containerData, ok := readContainerClaudeJSON(dockerClient, containerID, containerPath)
+
+	baseHash := loadClaudeJSONSyncHash(containerName)
+	hostHash := hashContent(hostData)
+	containerHash := hashContent(containerData)
+
+	action := decideClaudeJSONSync(baseHash, hostHash, containerHash, strategy)
+	if !ok {
+		// Nothing usable in the container - treat as host change
+		action = claudeJSONHostToContainer
+	}
+
+	switch action {
+	case claudeJSONHostToContainer:
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Syncing host .claude.json into container\n")
+		}
+		if err := copyClaudeJSONToContainer(dockerClient, containerID, hostPath, containerPath, remoteUser, rewrite, verbose); err != nil {
+			return err
+		}
+		return saveClaudeJSONSyncHash(containerName, hostHash)
+
+	case claudeJSONContainerToHost:
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Syncing container .claude.json back to host\n")
+		}
+		if rewrite != nil {
+			// The container's servers were rewritten for it; the host keeps its own
+			if containerData, err = mcp.RestoreHostServers(containerData, hostFile); err != nil {
+				return err
+			}
+		}
+		// Keep the previous host version around in case the container copy was bad
+		if err := os.WriteFile(hostPath+".packnplay-backup", hostFile, 0600); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", hostPath, err)
+		}
+		if err := config.WriteFileAtomic(hostPath, containerData, 0600); err != nil {
+			return err
+		}
+		if rewrite == nil {
+			return saveClaudeJSONSyncHash(containerName, containerHash)
+		}
+		// Both sides are then the merged file, rewritten for the container as usual
+		if rewrite, err = rewriteClaudeJSON(hostPath, mappings); err != nil {
+			return err
+		}
+		if err := copyClaudeJSONToContainer(dockerClient, containerID, hostPath, containerPath, remoteUser, rewrite, verbose); err != nil {
+			return err
+		}
+		return saveClaudeJSONSyncHash(containerName, hashContent(rewrite.Data))
+	}
+
+	return saveClaudeJSONSyncHash(containerName, hostHash)
+}
+
+// readContainerClaudeJSON reads .claude.json from the container's stdout alone. ok is
+// false when it is missing, empty or not JSON, so a failed read never reaches the host.
+func readContainerClaudeJSON(dockerClient *docker.Client, containerID, containerPath string) (data []byte, ok bool) {
+	output, err := dockerClient.Output("exec", containerID, "cat", containerPath)
+	if err != nil || !json.Valid([]byte(output)) {
+		return nil, false
+	}
+	return []byte(output), true
+}
+
+// recordClaudeJSONSync remembers the hash of the .claude.json copied at container start
+func recordClaudeJSONSync(containerName, hostPath string, rewrite *mcp.RewriteResult) error {
+	if rewrite != nil {
+		return saveClaudeJSONSyncHash(containerName, hashContent(rewrite.Data))
+	}
+	data, err := os.ReadFile(hostPath)
+	if err != nil {
+		return err
+	}
+	return saveClaudeJSONSyncHash(containerName, hashContent(data))
+}
+
+func hashContent(data []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// claudeJSONSyncStatePath returns where the last synced hash for a container is stored
+func claudeJSONSyncStatePath(containerName string) (string, error) {
+	dataDir, err := packnplayDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "claude-json-sync", containerName+".sha256"), nil
+}
+
+func loadClaudeJSONSyncHash(containerName string) string {
+	statePath, err := claudeJSONSyncStatePath(containerName)
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func saveClaudeJSONSyncHash(containerName, hash string) error {
+	statePath, err := claudeJSONSyncStatePath(containerName)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(statePath), 0755); err != nil {
+		return fmt.Errorf("failed to create sync state dir: %w", err)
+	}
+	return os.WriteFile(statePath, []byte(hash+"\n"), 0644)
+}
@@ -0,0 +1,123 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/docker"
+)
+
+func TestDecideClaudeJSONSync(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      string
+		host      string
+		container string
+		strategy  string
+		want      claudeJSONSyncAction
+	}{
+		{"nothing changed", "a", "a", "a", "host", claudeJSONInSync},
+		{"host changed", "a", "b", "a", "host", claudeJSONHostToContainer},
+		{"container changed", "a", "a", "c", "host", claudeJSONContainerToHost},
+		{"both changed identically", "a", "b", "b", "host", claudeJSONInSync},
+		{"conflict host wins", "a", "b", "c", "host", claudeJSONHostToContainer},
+		{"conflict container wins", "a", "b", "c", "container", claudeJSONContainerToHost},
+		{"no base recorded", "", "b", "c", "host", claudeJSONHostToContainer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := decideClaudeJSONSync(tt.base, tt.host, tt.container, tt.strategy)
+			if got != tt.want {
+				t.Errorf("decideClaudeJSONSync() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaudeJSONSyncHashRoundTrip(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	if got := loadClaudeJSONSyncHash("packnplay-proj-main"); got != "" {
+		t.Errorf("loadClaudeJSONSyncHash() before save = %q, want empty", got)
+	}
+
+	hash := hashContent([]byte(`{"mcpServers":{}}`))
+	if err := saveClaudeJSONSyncHash("packnplay-proj-main", hash); err != nil {
+		t.Fatalf("saveClaudeJSONSyncHash() error = %v", err)
+	}
+
+	if got := loadClaudeJSONSyncHash("packnplay-proj-main"); got != hash {
+		t.Errorf("loadClaudeJSONSyncHash() = %q, want %q", got, hash)
+	}
+}
+
+func TestClaudeJSONSyncEnabled(t *testing.T) {
+	for mode, want := range map[string]bool{"": false, "off": false, "host": true, "container": true} {
+		if got := claudeJSONSyncEnabled(mode); got != want {
+			t.Errorf("claudeJSONSyncEnabled(%q) = %v, want %v", mode, got, want)
+		}
+	}
+}
+
+// fakeClaudeJSONContainer stands in for docker with a container whose .claude.json is
+// the file it returns
+func fakeClaudeJSONContainer(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	containerFile := filepath.Join(dir, "container.json")
+	script := `#!/bin/sh
+case "$1" in
+exec) case "$*" in *" cat "*) cat "` + containerFile + `" ;; esac ;;
+cp) cp "$2" "` + containerFile + `" ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("DOCKER_CMD", "docker")
+	t.Setenv("IS_SANDBOX", "")
+	t.Setenv("DOCKER_HOST", "unix://"+filepath.Join(dir, "no-daemon.sock"))
+	return containerFile
+}
+
+func TestSyncClaudeJSONWithMappingsSettles(t *testing.T) {
+	containerFile := fakeClaudeJSONContainer(t)
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	dockerClient, err := docker.NewClient(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	home := t.TempDir()
+	hostPath := filepath.Join(home, ".claude.json")
+	writeFile(t, hostPath, `{"numStartups": 1, "mcpServers": {"db": {"type": "http", "url": "http://localhost:5050"}}}`)
+	mappings := map[string]config.MCPServerMapping{"*": {ForwardHostPorts: true}}
+
+	// The container gets the rewritten copy, then changes it
+	sync := func() {
+		t.Helper()
+		if err := syncClaudeJSON(dockerClient, "abc123", "packnplay-app-main", home, "vscode", config.ClaudeJSONSyncHost, mappings, false); err != nil {
+			t.Fatalf("syncClaudeJSON() error = %v", err)
+		}
+	}
+	sync()
+	data, _ := os.ReadFile(containerFile)
+	writeFile(t, containerFile, strings.Replace(string(data), `"numStartups": 1`, `"numStartups": 2`, 1))
+
+	// Its change reaches the host with the host's own servers, and then both sides agree
+	for i := 0; i < 2; i++ {
+		sync()
+		host, _ := os.ReadFile(hostPath)
+		if !strings.Contains(string(host), `"numStartups": 2`) || !strings.Contains(string(host), "http://localhost:5050") {
+			t.Fatalf("sync %d: host .claude.json = %s, want the container's change and the host's server", i+1, host)
+		}
+		container, _ := os.ReadFile(containerFile)
+		if !strings.Contains(string(container), "host.docker.internal:5050") {
+			t.Fatalf("sync %d: container .claude.json = %s, want the rewritten server", i+1, container)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/obra/packnplay/pkg/config"
+)
+
+// claudeSharedSettings lists the ~/.claude entries shared read-only in selective mode
+var claudeSharedSettings = []string{"settings.json", "CLAUDE.md", "agents", "commands", "hooks", "plugins", "skills"}
+
+// generateClaudeMounts returns the mount arguments that expose ~/.claude to the container
+// according to the sync mode:
+//   - full: host ~/.claude mounted read-write (changes affect the host)
+//   - copy: ~/.claude copied into a per-container directory on every start
+//   - selective: per-container directory with host settings and credentials mounted read-only
+func generateClaudeMounts(mode, homeDir, remoteUser, containerName string, hostHasCredentials, verbose bool) ([]string, error) {
+	hostClaudeDir := filepath.Join(homeDir, ".claude")
+	containerClaudeDir := fmt.Sprintf("/home/%s/.claude", remoteUser)
+
+	switch mode {
+	case "", config.ClaudeSyncFull:
+		return []string{"-v", fmt.Sprintf("%s:%s", hostClaudeDir, containerClaudeDir)}, nil
+
+	case config.ClaudeSyncCopy:
+		sandboxDir, err := claudeSandboxDir(containerName)
+		if err != nil {
+			return nil, err
+		}
+		// Start from a fresh copy so earlier sandbox changes don't leak into this run
+		if err := os.RemoveAll(sandboxDir); err != nil {
+			return nil, fmt.Errorf("failed to reset %s: %w", sandboxDir, err)
+		}
+		if fileExists(hostClaudeDir) {
+			if verbose {
+				fmt.Fprintf(os.Stderr, "Copying ~/.claude to %s\n", sandboxDir)
+			}
+			if err := copyDir(hostClaudeDir, sandboxDir); err != nil {
+				return nil, fmt.Errorf("failed to copy ~/.claude: %w", err)
+			}
+		} else if err := os.MkdirAll(sandboxDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", sandboxDir, err)
+		}
+		return []string{"-v", fmt.Sprintf("%s:%s", sandboxDir, containerClaudeDir)}, nil
+
+	case config.ClaudeSyncSelective:
+		sandboxDir, err := claudeSandboxDir(containerName)
+		if err != nil {
+			return nil, err
+		}
+		// Keep projects, history and todos between runs of the same sandbox
+		if err := os.MkdirAll(sandboxDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", sandboxDir, err)
+		}
+		args := []string{"-v", fmt.Sprintf("%s:%s", sandboxDir, containerClaudeDir)}
+		for _, name := range claudeSharedSettings {
+			hostPath := filepath.Join(hostClaudeDir, name)
+			if !fileExists(hostPath) {
+				continue
+			}
+			args = append(args, "-v", fmt.Sprintf("%s:%s/%s:ro", hostPath, containerClaudeDir, name))
+			if verbose {
+				fmt.Fprintf(os.Stderr, "Sharing ~/.claude/%s read-only\n", name)
+			}
+		}
+		if hostHasCredentials {
+			args = append(args, "-v", fmt.Sprintf("%s:%s/.credentials.json:ro", filepath.Join(hostClaudeDir, ".credentials.json"), containerClaudeDir))
+		}
+		return args, nil
+	}
+
+	return nil, fmt.Errorf("unknown claude sync mode '%s' (valid: full, copy, selective)", mode)
+}
+
+// claudeSandboxDir returns the per-container .claude directory used by copy and selective modes
+func claudeSandboxDir(containerName string) (string, error) {
+	dataDir, err := packnplayDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "claude", containerName), nil
+}
+
+// packnplayDataDir returns packnplay's data directory: $XDG_DATA_HOME/packnplay, by
+// default ~/.local/share/packnplay
+func packnplayDataDir() (string, error) {
+	xdgDataHome := os.Getenv("XDG_DATA_HOME")
+	if xdgDataHome == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		xdgDataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	return filepath.Join(xdgDataHome, "packnplay"), nil
+}
+
+// copyDir recursively copies src to dst, preserving file modes and symlinks
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return copyRegularFile(path, target, info.Mode().Perm())
+		}
+
+		// Skip sockets, pipes and devices
+		return nil
+	})
+}
+
+// copyRegularFile copies a single file with the given permissions
+func copyRegularFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,97 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateClaudeMountsFull(t *testing.T) {
+	args, err := generateClaudeMounts("", "/home/host", "vscode", "packnplay-proj-main", true, false)
+	if err != nil {
+		t.Fatalf("generateClaudeMounts() error = %v", err)
+	}
+
+	want := []string{"-v", "/home/host/.claude:/home/vscode/.claude"}
+	if strings.Join(args, " ") != strings.Join(want, " ") {
+		t.Errorf("generateClaudeMounts() = %v, want %v", args, want)
+	}
+}
+
+func TestGenerateClaudeMountsCopy(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	hostClaude := filepath.Join(homeDir, ".claude")
+	_ = os.MkdirAll(filepath.Join(hostClaude, "commands"), 0755)
+	_ = os.WriteFile(filepath.Join(hostClaude, "settings.json"), []byte(`{"a":1}`), 0644)
+	_ = os.WriteFile(filepath.Join(hostClaude, "commands", "review.md"), []byte("review"), 0644)
+
+	args, err := generateClaudeMounts("copy", homeDir, "vscode", "packnplay-proj-main", true, false)
+	if err != nil {
+		t.Fatalf("generateClaudeMounts() error = %v", err)
+	}
+
+	sandboxDir, _ := claudeSandboxDir("packnplay-proj-main")
+	if len(args) != 2 || args[1] != sandboxDir+":/home/vscode/.claude" {
+		t.Fatalf("generateClaudeMounts() = %v, want mount of %s", args, sandboxDir)
+	}
+
+	// Copy should contain host files
+	if data, err := os.ReadFile(filepath.Join(sandboxDir, "commands", "review.md")); err != nil || string(data) != "review" {
+		t.Errorf("copied commands/review.md = %q, %v", data, err)
+	}
+
+	// Changes in the sandbox must not reach the host
+	_ = os.WriteFile(filepath.Join(sandboxDir, "settings.json"), []byte(`{"a":2}`), 0644)
+	if data, _ := os.ReadFile(filepath.Join(hostClaude, "settings.json")); string(data) != `{"a":1}` {
+		t.Errorf("host settings.json modified: %s", data)
+	}
+
+	// A second start resets the copy
+	_ = os.WriteFile(filepath.Join(sandboxDir, "stale.txt"), []byte("x"), 0644)
+	if _, err := generateClaudeMounts("copy", homeDir, "vscode", "packnplay-proj-main", true, false); err != nil {
+		t.Fatalf("second generateClaudeMounts() error = %v", err)
+	}
+	if fileExists(filepath.Join(sandboxDir, "stale.txt")) {
+		t.Error("copy mode should start from a fresh copy")
+	}
+}
+
+func TestGenerateClaudeMountsSelective(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	hostClaude := filepath.Join(homeDir, ".claude")
+	_ = os.MkdirAll(filepath.Join(hostClaude, "projects"), 0755)
+	_ = os.WriteFile(filepath.Join(hostClaude, "settings.json"), []byte(`{}`), 0644)
+	_ = os.WriteFile(filepath.Join(hostClaude, ".credentials.json"), []byte(`{"token":"abcdefghijklmnopqrstuvwxyz"}`), 0600)
+
+	args, err := generateClaudeMounts("selective", homeDir, "vscode", "packnplay-proj-main", true, false)
+	if err != nil {
+		t.Fatalf("generateClaudeMounts() error = %v", err)
+	}
+
+	joined := strings.Join(args, " ")
+	sandboxDir, _ := claudeSandboxDir("packnplay-proj-main")
+
+	if !strings.Contains(joined, sandboxDir+":/home/vscode/.claude") {
+		t.Errorf("selective mode should mount per-container dir, got %v", args)
+	}
+	if !strings.Contains(joined, filepath.Join(hostClaude, "settings.json")+":/home/vscode/.claude/settings.json:ro") {
+		t.Errorf("selective mode should share settings.json read-only, got %v", args)
+	}
+	if !strings.Contains(joined, "/home/vscode/.claude/.credentials.json:ro") {
+		t.Errorf("selective mode should share credentials read-only, got %v", args)
+	}
+	if strings.Contains(joined, "projects") {
+		t.Errorf("selective mode should not share projects dir, got %v", args)
+	}
+}
+
+func TestGenerateClaudeMountsInvalidMode(t *testing.T) {
+	if _, err := generateClaudeMounts("bogus", "/home/host", "vscode", "c", false, false); err == nil {
+		t.Error("generateClaudeMounts() should reject unknown modes")
+	}
+}
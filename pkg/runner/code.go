@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// CodeConfig describes the container to open in VS Code
+type CodeConfig struct {
+	Path       string
+	Worktree   string
+	NoWorktree bool
+	Runtime    string
+	Insiders   bool // launch code-insiders
+	PrintOnly  bool // print the folder URI instead of launching VS Code
+	Verbose    bool
+}
+
+// Code opens the running container that Run started for the same directory and worktree
+// in VS Code, with the Dev Containers extension attached to it and the project folder
+// open. It returns the folder URI.
+func Code(config *CodeConfig) (string, error) {
+	dockerClient, containerID, mountPath, err := findRunningContainer(config.Path, config.Worktree, config.NoWorktree, config.Runtime, config.Verbose)
+	if err != nil {
+		return "", err
+	}
+	if dockerClient.Command() == "container" {
+		return "", fmt.Errorf("VS Code can't attach to Apple Container containers")
+	}
+	containerName := containerNameOf(dockerClient, containerID)
+	if containerName == "" {
+		return "", fmt.Errorf("failed to get container name")
+	}
+
+	// Run mounts the project at its host path
+	uri := attachedContainerURI(containerName, mountPath)
+	if config.PrintOnly {
+		return uri, nil
+	}
+
+	binary := "code"
+	if config.Insiders {
+		binary = "code-insiders"
+	}
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return uri, fmt.Errorf("%s not found in PATH; in VS Code run \"Shell Command: Install '%s' command in PATH\", or open the URI with --print", binary, binary)
+	}
+	if config.Verbose {
+		fmt.Fprintf(os.Stderr, "Running: %s --folder-uri %s\n", path, uri)
+	}
+	cmd := exec.Command(path, "--folder-uri", uri)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return uri, fmt.Errorf("failed to launch %s: %w", binary, err)
+	}
+	return uri, nil
+}
+
+// attachedContainerURI returns the Dev Containers "attach to running container" URI for
+// folder in containerName: the container, as hex-encoded JSON, in the authority
+func attachedContainerURI(containerName, folder string) string {
+	target, _ := json.Marshal(struct {
+		ContainerName string `json:"containerName"`
+	}{"/" + containerName})
+	return "vscode-remote://attached-container+" + hex.EncodeToString(target) + folder
+}
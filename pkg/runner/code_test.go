@@ -0,0 +1,27 @@
+package runner
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestAttachedContainerURI(t *testing.T) {
+	uri := attachedContainerURI("packnplay-app-main", "/home/me/src/app")
+
+	authority, folder, ok := strings.Cut(strings.TrimPrefix(uri, "vscode-remote://"), "/")
+	if !ok || "/"+folder != "/home/me/src/app" {
+		t.Fatalf("attachedContainerURI() = %q, want the folder as the path", uri)
+	}
+	encoded, ok := strings.CutPrefix(authority, "attached-container+")
+	if !ok {
+		t.Fatalf("attachedContainerURI() authority = %q", authority)
+	}
+	target, err := hex.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("authority isn't hex: %v", err)
+	}
+	if want := `{"containerName":"/packnplay-app-main"}`; string(target) != want {
+		t.Errorf("attached container = %s, want %s", target, want)
+	}
+}
@@ -0,0 +1,102 @@
+package runner
+
+import (
+	"os"
+	"strings"
+
+	"github.com/obra/packnplay/pkg/cache"
+	"github.com/obra/packnplay/pkg/credentials"
+	"github.com/obra/packnplay/pkg/devcontainer"
+)
+
+// safeEnvVars are the only host variables passed through unasked: terminal and locale
+// settings, nothing that could leak host state
+var safeEnvVars = []string{"TERM", "LANG", "LC_ALL", "LC_CTYPE", "LC_MESSAGES", "COLORTERM"}
+
+// localEnv returns the lookup for devcontainer.json ${localEnv:VAR} references: nil for
+// an untrusted workspace, whose references get only their defaults
+func (c *RunConfig) localEnv() devcontainer.LookupFunc {
+	if c.UntrustedWorkspace {
+		return nil
+	}
+	return os.LookupEnv
+}
+
+// runEnv lists where a container's environment comes from, in increasing precedence:
+// docker keeps the last value given for a name
+type runEnv struct {
+	RemoteUser   string
+	PassThrough  []string        // Host variables passed when set (API keys for AI agents)
+	SharedCache  bool            // Point download-heavy tools at the shared cache
+	ContainerEnv []string        // devcontainer.json containerEnv (KEY=value)
+	HostEnv      map[string]bool // containerEnv names whose values were read from the host
+	Credentials  []*credentials.Result
+	User         []string        // --env: KEY=value, or KEY to pass the host's value through
+	UserSecrets  map[string]bool // --env names whose values were fetched from a secret manager; names that look secret count too
+	Getenv       func(string) string
+}
+
+// apply adds the environment to spec. API keys, credentials, fetched secrets and values
+// read from host variables are added as secret env, which is kept off the docker command
+// line.
+func (e runEnv) apply(spec *ContainerSpec) {
+	for _, key := range safeEnvVars {
+		if value := e.Getenv(key); value != "" {
+			spec.AddEnv(key, value)
+		}
+	}
+
+	// Set HOME to container user's home directory (don't use host HOME)
+	spec.AddEnv("HOME", "/home/"+e.RemoteUser)
+
+	// Add IS_SANDBOX marker so tools know they're in a sandbox
+	spec.AddEnv("IS_SANDBOX", "1")
+
+	// Don't set PATH - use container's default PATH to avoid host pollution
+
+	for _, key := range e.PassThrough {
+		if value := e.Getenv(key); value != "" {
+			spec.AddSecretEnv(key, value)
+		}
+	}
+
+	// Overridable with --env
+	if e.SharedCache {
+		for _, env := range cache.EnvArgs() {
+			key, value, _ := strings.Cut(env, "=")
+			spec.AddEnv(key, value)
+		}
+	}
+
+	// Overrides the defaults above, not credentials or --env
+	for _, env := range e.ContainerEnv {
+		key, value, _ := strings.Cut(env, "=")
+		if e.HostEnv[key] {
+			spec.AddSecretEnv(key, value)
+		} else {
+			spec.AddEnv(key, value)
+		}
+	}
+
+	// Before user-specified env vars, so users can override credentials with --env
+	for _, result := range e.Credentials {
+		for _, env := range result.EnvArgs() {
+			key, value, _ := strings.Cut(env, "=")
+			spec.AddSecretEnv(key, value)
+		}
+	}
+
+	for _, env := range e.User {
+		key, value, ok := strings.Cut(env, "=")
+		if !ok {
+			if value = e.Getenv(key); value == "" {
+				continue
+			}
+		}
+		if e.UserSecrets[key] || secretNamePattern.MatchString(key) {
+			spec.AddSecretEnv(key, value)
+		} else {
+			spec.AddEnv(key, value)
+		}
+	}
+}
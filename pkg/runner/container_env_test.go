@@ -0,0 +1,49 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/credentials"
+)
+
+func TestRunEnvApply(t *testing.T) {
+	host := map[string]string{
+		"TERM":              "xterm-256color",
+		"ANTHROPIC_API_KEY": "sk-host",
+		"EDITOR":            "vim",
+	}
+	env := runEnv{
+		RemoteUser:   "vscode",
+		PassThrough:  []string{"ANTHROPIC_API_KEY", "OPENAI_API_KEY"},
+		ContainerEnv: []string{"NODE_ENV=development", "REGION=us-east-1"},
+		HostEnv:      map[string]bool{"REGION": true},
+		Credentials:  []*credentials.Result{{Env: map[string]string{"GH_TOKEN": "gho_x"}}},
+		User:         []string{"GH_TOKEN=override", "EDITOR", "UNSET_ON_HOST", "DATABASE_URL=fetched", "VAULT_ADDR=https://vault"},
+		UserSecrets:  map[string]bool{"DATABASE_URL": true},
+		Getenv:       func(key string) string { return host[key] },
+	}
+
+	spec := NewContainerSpec("c")
+	env.apply(spec)
+	want := []string{
+		"TERM=xterm-256color",
+		"HOME=/home/vscode",
+		"IS_SANDBOX=1",
+		"ANTHROPIC_API_KEY=sk-host",
+		"NODE_ENV=development",
+		"REGION=us-east-1",
+		"GH_TOKEN=gho_x",
+		"GH_TOKEN=override",
+		"EDITOR=vim",
+		"DATABASE_URL=fetched",
+		"VAULT_ADDR=https://vault",
+	}
+	if !reflect.DeepEqual(spec.Env, want) {
+		t.Errorf("Env = %v, want %v", spec.Env, want)
+	}
+	wantSecret := map[string]bool{"ANTHROPIC_API_KEY": true, "REGION": true, "GH_TOKEN": true, "DATABASE_URL": true}
+	if !reflect.DeepEqual(spec.SecretEnv, wantSecret) {
+		t.Errorf("SecretEnv = %v, want %v", spec.SecretEnv, wantSecret)
+	}
+}
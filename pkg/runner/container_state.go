@@ -0,0 +1,32 @@
+package runner
+
+import (
+	"github.com/obra/packnplay/pkg/dind"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/egress"
+	"github.com/obra/packnplay/pkg/services"
+	"github.com/obra/packnplay/pkg/sshserver"
+)
+
+// RemoveContainerState removes what packnplay keeps for a container besides the
+// container itself. Each step does nothing when there is nothing to remove.
+func RemoveContainerState(dockerClient *docker.Client, containerName string) {
+	RemoveContainerSidecars(dockerClient, containerName)
+	// The volumes holding its copy of the workspace, on a remote daemon or with --copy-workspace
+	RemoveSyncVolumes(dockerClient, containerName)
+}
+
+// RemoveContainerSidecars removes what RemoveContainerState does except the volumes
+// holding the container's copy of the workspace, which may have changes not exported yet
+func RemoveContainerSidecars(dockerClient *docker.Client, containerName string) {
+	// The egress proxy and internal network of an allowlist-mode container
+	egress.Stop(dockerClient, containerName)
+	// The dind sidecar of a --docker=dind container
+	dind.Stop(dockerClient, containerName)
+	// The project's services, keeping their named volumes
+	services.Stop(dockerClient, containerName)
+	// The forwarder and keys of packnplay ssh-server
+	sshserver.Stop(dockerClient, containerName, true)
+	// The links its forwarded agent sockets were mounted through
+	RemoveAgentLinks(containerName)
+}
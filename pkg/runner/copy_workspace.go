@@ -0,0 +1,182 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/git"
+)
+
+// copyWorkspaceLabel records where the workspace of a --copy-workspace container, or of one
+// on a remote daemon, was copied from, so packnplay diff and export know what its changes
+// apply to
+const copyWorkspaceLabel = "packnplay-copy-workspace"
+
+// copyBaseRef names the tree the copy's changes are taken against: the workspace as it
+// was copied, then as it was last exported
+const copyBaseRef = "refs/packnplay/copy-base"
+
+// copyDiffedRef names the tree the last diff was taken of, which becomes copyBaseRef once
+// that diff is exported
+const copyDiffedRef = "refs/packnplay/copy-diffed"
+
+// copyScriptSetup starts the scripts run on the copy. They use a scratch index, so the
+// one the agent works with is left alone, and only print git's errors when a step fails,
+// so the output of one that succeeds is nothing but the patch. Hooks and fsmonitor are
+// off, since the agent controls the copy's git config.
+const copyScriptSetup = `set -e
+cd "$1"
+dir=$(mktemp -d)
+trap 'status=$?; [ $status -eq 0 ] || cat "$dir/errors"; rm -rf "$dir"; exit $status' EXIT
+exec 2>"$dir/errors"
+export GIT_INDEX_FILE="$dir/index"
+g() { git -c core.hooksPath=/dev/null -c core.fsmonitor=false "$@"; }
+`
+
+// snapshotScript records the copy's working tree, untracked files included, as copyBaseRef
+const snapshotScript = copyScriptSetup + `g read-tree HEAD || true
+g add -A
+g update-ref ` + copyBaseRef + ` "$(g write-tree)"
+`
+
+// diffScript prints the changes to the copy's working tree since copyBaseRef, committed or
+// not, as a binary patch, recording the tree as copyDiffedRef. External diff drivers and
+// textconv would rewrite the patch, so they are off.
+const diffScript = copyScriptSetup + `g read-tree ` + copyBaseRef + `
+g add -A
+g update-ref ` + copyDiffedRef + ` "$(g write-tree)"
+g diff --cached --binary --no-ext-diff --no-textconv --no-color ` + copyBaseRef + `
+`
+
+// exportedScript moves copyBaseRef to the tree the exported diff was taken of, so changes
+// made since are still exported next time
+const exportedScript = copyScriptSetup + `g update-ref ` + copyBaseRef + ` ` + copyDiffedRef + `
+`
+
+// copyWorkspaceArgs returns the run arguments that record copied on the container
+func copyWorkspaceArgs(copied workspaceSync) []string {
+	data, err := json.Marshal(copied)
+	if err != nil {
+		return nil
+	}
+	return []string{"--label", copyWorkspaceLabel + "=" + string(data)}
+}
+
+// snapshotCopiedWorkspace records the copy as just seeded, for packnplay diff to compare with
+func snapshotCopiedWorkspace(dockerClient *docker.Client, containerID string, copied workspaceSync) error {
+	if output, err := dockerClient.Run("exec", "-u", copied.User, containerID, "sh", "-c", snapshotScript, "sh", copied.Target); err != nil {
+		return fmt.Errorf("failed to snapshot the copied workspace: %w\n%s", err, output)
+	}
+	return nil
+}
+
+// CopiedWorkspace is a container started with --copy-workspace or on a remote daemon: its
+// workspace is a copy of Source in a volume, whose changes are brought back with Diff and
+// Export
+type CopiedWorkspace struct {
+	Client *docker.Client
+	Name   string
+	User   string // container user the copy belongs to
+	Source string // host directory the workspace was copied from
+	Target string // the copy, in the container
+}
+
+// FindCopiedWorkspace returns the --copy-workspace container for a directory and worktree,
+// found as packnplay run would
+func FindCopiedWorkspace(path, worktree string, skipWorktree bool, runtime string, verbose bool) (*CopiedWorkspace, error) {
+	existing, err := FindContainer(path, worktree, skipWorktree, runtime, verbose)
+	if err != nil {
+		return nil, err
+	}
+	return CopiedWorkspaceOf(existing.Client, existing.Name)
+}
+
+// CopiedWorkspaceOf reads where the workspace of containerName came from off its label.
+// The copy is only reachable while the container runs.
+func CopiedWorkspaceOf(dockerClient *docker.Client, containerName string) (*CopiedWorkspace, error) {
+	info, err := dockerClient.InspectContainer(containerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container %s: %w", containerName, err)
+	}
+	data, ok := info.Config.Labels[copyWorkspaceLabel]
+	if !ok {
+		return nil, fmt.Errorf("%s wasn't started with --copy-workspace; its changes are already in the workspace", containerName)
+	}
+	var copied workspaceSync
+	if err := json.Unmarshal([]byte(data), &copied); err != nil {
+		return nil, fmt.Errorf("failed to read %s label: %w", copyWorkspaceLabel, err)
+	}
+	if !info.State.Running {
+		return nil, fmt.Errorf("container %s is not running; start it with %s start %s", containerName, dockerClient.Command(), containerName)
+	}
+	return &CopiedWorkspace{Client: dockerClient, Name: containerName, User: copied.User, Source: copied.Source, Target: copied.Target}, nil
+}
+
+// Diff returns the changes made to the copy since it was made or last exported, as a patch
+// for Source. Empty when there are none.
+func (w *CopiedWorkspace) Diff() ([]byte, error) {
+	output, err := w.Client.Run("exec", "-u", w.User, w.Name, "sh", "-c", diffScript, "sh", w.Target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff the workspace in %s: %w\n%s", w.Name, err, output)
+	}
+	return []byte(output), nil
+}
+
+// ErrNothingToExport is returned by Export when the copy has no changes
+var ErrNothingToExport = errors.New("no changes to export")
+
+// Export applies the copy's changes to Source, then records the copy as exported, so they
+// aren't applied again. Nothing is applied when any of them doesn't fit Source as it is
+// now. Returns the diffstat of what was applied.
+func (w *CopiedWorkspace) Export() (string, error) {
+	patch, err := w.Diff()
+	if err != nil {
+		return "", err
+	}
+	if len(patch) == 0 {
+		return "", ErrNothingToExport
+	}
+	stat, err := git.PatchStat(w.Source, patch)
+	if err != nil {
+		return "", err
+	}
+	if err := git.ApplyPatch(w.Source, patch); err != nil {
+		return "", fmt.Errorf("%w\nThe changes don't apply to %s as it is now; save them with packnplay diff > changes.patch and apply them with git apply --3way", err, w.Source)
+	}
+	if output, err := w.Client.Run("exec", "-u", w.User, w.Name, "sh", "-c", exportedScript, "sh", w.Target); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record the export: %v\n%s\nThe same changes will be exported again next time\n", err, output)
+	}
+	return stat, nil
+}
+
+// ErrUnexportedChanges is returned by CheckExported when removing a container would lose
+// changes made to its copy of the workspace
+var ErrUnexportedChanges = errors.New("unexported changes")
+
+// CheckExported returns an error wrapping ErrUnexportedChanges when the copy of the
+// workspace in containerName has changes that weren't exported, or can't be diffed to
+// tell. Containers working on the workspace itself have nothing to lose.
+func CheckExported(dockerClient *docker.Client, containerName string) error {
+	info, err := dockerClient.InspectContainer(containerName)
+	if err != nil {
+		// Whatever removes it next reports why it can't be found
+		return nil
+	}
+	if _, ok := info.Config.Labels[copyWorkspaceLabel]; !ok {
+		return nil
+	}
+	copied, err := CopiedWorkspaceOf(dockerClient, containerName)
+	if err == nil {
+		var patch []byte
+		if patch, err = copied.Diff(); err == nil {
+			if len(patch) == 0 {
+				return nil
+			}
+			return fmt.Errorf("%s has %w for %s; apply them with packnplay export %s first", containerName, ErrUnexportedChanges, copied.Source, containerName)
+		}
+	}
+	return fmt.Errorf("%w may be lost: can't diff %s: %v", ErrUnexportedChanges, containerName, err)
+}
@@ -0,0 +1,180 @@
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/git"
+)
+
+// runCopyScript runs one of the scripts on the copy at dir, as docker exec would
+func runCopyScript(t *testing.T, script, dir string) string {
+	t.Helper()
+	out, err := exec.Command("sh", "-c", script, "sh", dir).CombinedOutput()
+	if err != nil {
+		t.Fatalf("script failed: %v\n%s", err, out)
+	}
+	return string(out)
+}
+
+func gitIn(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+	return string(out)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCopyWorkspaceScripts(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	// The host workspace, with uncommitted and untracked work when it is copied
+	host := t.TempDir()
+	gitIn(t, host, "init", "-q")
+	writeFile(t, filepath.Join(host, "main.go"), "package main\n")
+	writeFile(t, filepath.Join(host, ".gitignore"), "build/\n")
+	gitIn(t, host, "add", "-A")
+	gitIn(t, host, "commit", "-qm", "initial")
+	writeFile(t, filepath.Join(host, "main.go"), "package main\n\n// host edit\n")
+	writeFile(t, filepath.Join(host, "notes.txt"), "todo\n")
+
+	copyDir := filepath.Join(t.TempDir(), "copy")
+	if out, err := exec.Command("cp", "-a", host, copyDir).CombinedOutput(); err != nil {
+		t.Fatalf("cp failed: %v\n%s", err, out)
+	}
+	runCopyScript(t, snapshotScript, copyDir)
+	if out := runCopyScript(t, diffScript, copyDir); out != "" {
+		t.Fatalf("diff right after the copy = %q, want none", out)
+	}
+
+	// The agent edits, commits some of it, and builds
+	writeFile(t, filepath.Join(copyDir, "main.go"), "package main\n\n// host edit\n// agent edit\n")
+	writeFile(t, filepath.Join(copyDir, "feature.go"), "package main\n")
+	gitIn(t, copyDir, "add", "feature.go")
+	gitIn(t, copyDir, "commit", "-qm", "feature")
+	writeFile(t, filepath.Join(copyDir, "extra.txt"), "new\n")
+	if err := os.Remove(filepath.Join(copyDir, "notes.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(copyDir, "build"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(copyDir, "build", "out"), "binary\n")
+	status := gitIn(t, copyDir, "status", "--porcelain")
+
+	patch := runCopyScript(t, diffScript, copyDir)
+	for _, want := range []string{"b/main.go", "+// agent edit", "b/feature.go", "b/extra.txt", "deleted file mode"} {
+		if !strings.Contains(patch, want) {
+			t.Errorf("patch is missing %q:\n%s", want, patch)
+		}
+	}
+	if strings.Contains(patch, "+// host edit") || strings.Contains(patch, "build/out") {
+		t.Errorf("patch has changes from before the copy or ignored files:\n%s", patch)
+	}
+	if got := gitIn(t, copyDir, "status", "--porcelain"); got != status {
+		t.Errorf("diff changed the copy's index: status %q, was %q", got, status)
+	}
+
+	if err := git.ApplyPatch(host, []byte(patch)); err != nil {
+		t.Fatalf("ApplyPatch() error = %v", err)
+	}
+	for name, want := range map[string]string{"main.go": "package main\n\n// host edit\n// agent edit\n", "feature.go": "package main\n", "extra.txt": "new\n"} {
+		if data, _ := os.ReadFile(filepath.Join(host, name)); string(data) != want {
+			t.Errorf("%s on the host = %q, want %q", name, data, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(host, "notes.txt")); !os.IsNotExist(err) {
+		t.Errorf("notes.txt is still on the host")
+	}
+
+	// Once exported, only changes made since are diffed
+	writeFile(t, filepath.Join(copyDir, "later.txt"), "later\n")
+	runCopyScript(t, exportedScript, copyDir)
+	patch = runCopyScript(t, diffScript, copyDir)
+	if !strings.Contains(patch, "b/later.txt") || strings.Contains(patch, "feature.go") {
+		t.Errorf("diff after export =\n%s\nwant only later.txt", patch)
+	}
+}
+
+func TestSyncedFilesScripts(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	// The copy on a remote daemon, as seeded
+	copyDir := t.TempDir()
+	gitIn(t, copyDir, "init", "-q")
+	writeFile(t, filepath.Join(copyDir, ".gitignore"), "build/\n")
+	writeFile(t, filepath.Join(copyDir, "a.go"), "a\n")
+	writeFile(t, filepath.Join(copyDir, "b.go"), "b\n")
+	writeFile(t, filepath.Join(copyDir, "c.go"), "c\n")
+	gitIn(t, copyDir, "add", "-A")
+	gitIn(t, copyDir, "commit", "-qm", "initial")
+	runCopyScript(t, snapshotScript, copyDir)
+
+	// The agent edits a.go and deletes c.go, while b.go, c.go, a new file and a build
+	// output change on the host
+	writeFile(t, filepath.Join(copyDir, "a.go"), "agent\n")
+	if err := os.Remove(filepath.Join(copyDir, "c.go")); err != nil {
+		t.Fatal(err)
+	}
+	files := []string{"a.go", "b.go", "c.go", "new.go", "build/out", "*.go"}
+	out, err := exec.Command("sh", append([]string{"-c", changedFilesScript, "sh", copyDir}, files...)...).Output()
+	if err != nil {
+		t.Fatalf("changedFilesScript failed: %v", err)
+	}
+	if got := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00"); strings.Join(got, " ") != "a.go c.go" {
+		t.Errorf("changed in the copy = %q, want a.go and c.go", got)
+	}
+
+	// What is pushed isn't the container's change
+	writeFile(t, filepath.Join(copyDir, "b.go"), "host\n")
+	writeFile(t, filepath.Join(copyDir, "new.go"), "new\n")
+	if err := os.MkdirAll(filepath.Join(copyDir, "build"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(copyDir, "build", "out"), "binary\n")
+	if out, err := exec.Command("sh", "-c", syncedFilesScript, "sh", copyDir, "b.go", "new.go", "build/out").CombinedOutput(); err != nil {
+		t.Fatalf("syncedFilesScript failed: %v\n%s", err, out)
+	}
+	patch := runCopyScript(t, diffScript, copyDir)
+	if !strings.Contains(patch, "+agent") || !strings.Contains(patch, "b/c.go") {
+		t.Errorf("patch lost the container's changes:\n%s", patch)
+	}
+	if strings.Contains(patch, "b.go") || strings.Contains(patch, "new.go") || strings.Contains(patch, "build/out") {
+		t.Errorf("patch has files synced from the host:\n%s", patch)
+	}
+}
+
+func TestCopyScriptErrors(t *testing.T) {
+	// No snapshot was ever taken: git's error is the output
+	dir := t.TempDir()
+	gitIn(t, dir, "init", "-q")
+	out, err := exec.Command("sh", "-c", diffScript, "sh", dir).CombinedOutput()
+	if err == nil || !strings.Contains(string(out), copyBaseRef) {
+		t.Errorf("diff without a snapshot = %q, %v, want git's error", out, err)
+	}
+}
+
+func TestCopyWorkspaceArgs(t *testing.T) {
+	args := copyWorkspaceArgs(workspaceSync{User: "vscode", Source: "/src/app", Target: "/src/app"})
+	want := `packnplay-copy-workspace={"user":"vscode","source":"/src/app","target":"/src/app"}`
+	if len(args) != 2 || args[0] != "--label" || args[1] != want {
+		t.Errorf("copyWorkspaceArgs() = %q, want --label %s", args, want)
+	}
+}
@@ -0,0 +1,180 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/credentials"
+	"github.com/obra/packnplay/pkg/git"
+)
+
+// credentialProviders returns the providers enabled for a run, in a stable order:
+// built-ins first, then user-defined scripts sorted by name
+func credentialProviders(cfg *RunConfig, isLinux bool) ([]credentials.Provider, error) {
+	var providers []credentials.Provider
+	scoped := cfg.ScopedCreds
+
+	// With --scoped-creds, gh and AWS get short-lived tokens minted on the host instead
+	if cfg.Credentials.GH && scoped != nil {
+		providers = append(providers, &credentials.GHAppProvider{
+			AppID:          scoped.GH.AppID,
+			InstallationID: scoped.GH.InstallationID,
+			PrivateKey:     scoped.GH.PrivateKey,
+			Permissions:    scoped.GH.Permissions,
+			Repositories:   scoped.GH.Repositories,
+		})
+	} else if cfg.Credentials.GH && isLinux {
+		// On macOS, gh credentials from Keychain are copied in after container starts
+		providers = append(providers, &credentials.GHProvider{})
+	}
+	if cfg.Credentials.AWS && scoped != nil {
+		duration, err := scoped.AWS.SessionDuration()
+		if err != nil {
+			return nil, fmt.Errorf("invalid scoped_credentials.aws: %w", err)
+		}
+		providers = append(providers, &credentials.AWSSessionProvider{
+			Profile:     cfg.AWSProfile,
+			RoleARN:     scoped.AWS.RoleARN,
+			Policy:      scoped.AWS.Policy,
+			Duration:    duration,
+			SessionName: cfg.CredentialSession,
+		})
+	} else if cfg.Credentials.AWS {
+		providers = append(providers, &credentials.AWSProvider{Profile: cfg.AWSProfile})
+	}
+	if cfg.Credentials.GCP {
+		providers = append(providers, &credentials.GCPProvider{})
+	}
+	if cfg.Credentials.Azure {
+		providers = append(providers, &credentials.AzureProvider{Token: cfg.AzureToken})
+	}
+	if cfg.Credentials.Kube {
+		providers = append(providers, &credentials.KubeProvider{})
+	}
+
+	names := make([]string, 0, len(cfg.CredentialProviders))
+	for name := range cfg.CredentialProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		provider := cfg.CredentialProviders[name]
+		var ttl time.Duration
+		if provider.RefreshTTL != "" {
+			var err error
+			ttl, err = time.ParseDuration(provider.RefreshTTL)
+			if err != nil {
+				return nil, fmt.Errorf("invalid refresh_ttl for credential provider '%s': %w", name, err)
+			}
+		}
+		providers = append(providers, &credentials.ScriptProvider{
+			ProviderName: name,
+			Command:      provider.Command,
+			TTL:          ttl,
+		})
+	}
+
+	return providers, nil
+}
+
+// scopedCredentials returns scoped, with the gh token limited to the project's GitHub
+// origin repository when no repositories are configured
+func scopedCredentials(scoped *config.ScopedCredentialsConfig, workDir string) *config.ScopedCredentialsConfig {
+	if scoped == nil || len(scoped.GH.Repositories) > 0 {
+		return scoped
+	}
+	resolved := *scoped
+	if url, err := git.GetRemoteURL(workDir, "origin"); err == nil {
+		if name := githubRepoName(url); name != "" {
+			resolved.GH.Repositories = []string{name}
+		}
+	}
+	return &resolved
+}
+
+// githubRepoName returns the repository name in a GitHub remote URL (ssh or https), or
+// "" for other hosts
+func githubRepoName(url string) string {
+	if !strings.Contains(url, "github.com") {
+		return ""
+	}
+	name := strings.TrimSuffix(path.Base(strings.TrimSuffix(url, "/")), ".git")
+	if name == "." || name == "/" || strings.Contains(name, ":") {
+		return ""
+	}
+	return name
+}
+
+// timeSensitiveCredentials reports whether the run injects credentials that stop working
+// when the container's clock is off: cloud tokens and signed requests
+func timeSensitiveCredentials(cfg *RunConfig) bool {
+	creds := cfg.Credentials
+	return creds.AWS || creds.GCP || creds.Azure || creds.Kube || len(cfg.CredentialProviders) > 0
+}
+
+// credentialCheck collects problems with credentials a run asked for. They are warnings,
+// unless --strict-creds makes them an error that stops the run.
+type credentialCheck struct {
+	strict   bool
+	problems []string
+}
+
+// problem reports a requested credential that can't be provided: a warning, or in strict
+// mode a problem for err
+func (c *credentialCheck) problem(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	c.problems = append(c.problems, message)
+	if !c.strict {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", message)
+	}
+}
+
+// problemIfStrict reports a problem that is silently skipped outside strict mode, such as
+// a credential file that doesn't exist on the host
+func (c *credentialCheck) problemIfStrict(format string, args ...interface{}) {
+	if c.strict {
+		c.problem(format, args...)
+	}
+}
+
+// add takes the problems of a strict credentials.Collect
+func (c *credentialCheck) add(err error) error {
+	var strictErr *credentials.StrictError
+	if !errors.As(err, &strictErr) {
+		return err
+	}
+	c.problems = append(c.problems, strictErr.Problems...)
+	return nil
+}
+
+// err returns the problems reported since the last call in strict mode, nil otherwise
+func (c *credentialCheck) err() error {
+	problems := c.problems
+	c.problems = nil
+	if !c.strict || len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w\n\nFix them or run without --strict-creds", &credentials.StrictError{Problems: problems})
+}
+
+// collectCredentials fetches what providers provide. A dry run only notes on w which of
+// them would, since fetching mints tokens, runs user scripts and calls cloud CLIs.
+func collectCredentials(w io.Writer, providers []credentials.Provider, env credentials.Environment, dryRun bool) ([]*credentials.Result, error) {
+	if !dryRun {
+		return credentials.Collect(providers, env)
+	}
+	for _, provider := range providers {
+		if provider.Detect(env) {
+			dryRunNote(w, "provides %s credentials", provider.Name())
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,187 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/credentials"
+)
+
+func TestCredentialProviders(t *testing.T) {
+	cfg := &RunConfig{
+		Credentials: config.Credentials{GH: true, AWS: true},
+		CredentialProviders: map[string]config.CredentialProviderConfig{
+			"vault": {Command: "vault-creds", RefreshTTL: "1h"},
+			"azure": {Command: "az-creds"},
+		},
+	}
+
+	providers, err := credentialProviders(cfg, true)
+	if err != nil {
+		t.Fatalf("credentialProviders() error = %v", err)
+	}
+
+	var names []string
+	for _, p := range providers {
+		names = append(names, p.Name())
+	}
+	want := []string{"gh", "aws", "azure", "vault"}
+	if len(names) != len(want) {
+		t.Fatalf("providers = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("providers = %v, want %v", names, want)
+			break
+		}
+	}
+	if ttl := providers[3].RefreshTTL(); ttl.Hours() != 1 {
+		t.Errorf("vault RefreshTTL() = %v, want 1h", ttl)
+	}
+
+	// gh is mounted only on Linux
+	providers, _ = credentialProviders(&RunConfig{Credentials: config.Credentials{GH: true}}, false)
+	if len(providers) != 0 {
+		t.Errorf("expected no gh provider off Linux, got %d providers", len(providers))
+	}
+}
+
+func TestCredentialProvidersInvalidTTL(t *testing.T) {
+	cfg := &RunConfig{
+		CredentialProviders: map[string]config.CredentialProviderConfig{
+			"vault": {Command: "vault-creds", RefreshTTL: "soon"},
+		},
+	}
+	if _, err := credentialProviders(cfg, true); err == nil {
+		t.Error("expected error for invalid refresh_ttl")
+	}
+}
+
+func TestTimeSensitiveCredentials(t *testing.T) {
+	tests := []struct {
+		cfg  *RunConfig
+		want bool
+	}{
+		{&RunConfig{Credentials: config.Credentials{Git: true, SSH: true, GH: true}}, false},
+		{&RunConfig{Credentials: config.Credentials{AWS: true}}, true},
+		{&RunConfig{Credentials: config.Credentials{Kube: true}}, true},
+		{&RunConfig{CredentialProviders: map[string]config.CredentialProviderConfig{"vault": {Command: "vault-creds"}}}, true},
+	}
+	for _, tt := range tests {
+		if got := timeSensitiveCredentials(tt.cfg); got != tt.want {
+			t.Errorf("timeSensitiveCredentials(%+v) = %v, want %v", tt.cfg.Credentials, got, tt.want)
+		}
+	}
+}
+
+func TestCredentialCheck(t *testing.T) {
+	lenient := &credentialCheck{}
+	lenient.problemIfStrict("~/.gitconfig does not exist")
+	lenient.problem("ssh-agent socket missing")
+	if err := lenient.err(); err != nil {
+		t.Errorf("err() = %v outside strict mode", err)
+	}
+
+	strict := &credentialCheck{strict: true}
+	strict.problemIfStrict("~/.gitconfig does not exist")
+	if err := strict.add(&credentials.StrictError{Problems: []string{"credential_process failed"}}); err != nil {
+		t.Fatalf("add() = %v", err)
+	}
+	err := strict.err()
+	if err == nil {
+		t.Fatal("err() = nil in strict mode with problems")
+	}
+	for _, want := range []string{"~/.gitconfig does not exist", "credential_process failed", "--strict-creds"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("err() = %q, missing %q", err, want)
+		}
+	}
+	if err := strict.err(); err != nil {
+		t.Errorf("err() reported problems twice: %v", err)
+	}
+
+	other := errors.New("invalid provider")
+	if err := strict.add(other); err != other {
+		t.Errorf("add() = %v, want other errors passed through", err)
+	}
+}
+
+func TestCredentialProvidersScoped(t *testing.T) {
+	cfg := &RunConfig{
+		Credentials: config.Credentials{GH: true, AWS: true},
+		ScopedCreds: &config.ScopedCredentialsConfig{
+			GH:  config.GHAppConfig{AppID: "1234", InstallationID: "5678", PrivateKey: "~/app.pem", Repositories: []string{"packnplay"}},
+			AWS: config.AWSSessionConfig{RoleARN: "arn:aws:iam::123456789012:role/agent", Duration: "30m"},
+		},
+		AWSProfile:        "dev",
+		CredentialSession: "packnplay-app-f227ecb4-main",
+	}
+
+	// Minted tokens are passed in env vars, so gh is scoped off Linux too
+	providers, err := credentialProviders(cfg, false)
+	if err != nil {
+		t.Fatalf("credentialProviders() error = %v", err)
+	}
+	if len(providers) != 2 {
+		t.Fatalf("providers = %v, want gh and aws", providers)
+	}
+	gh, ok := providers[0].(*credentials.GHAppProvider)
+	if !ok || gh.InstallationID != "5678" || gh.Repositories[0] != "packnplay" {
+		t.Errorf("providers[0] = %+v, want the GitHub App provider", providers[0])
+	}
+	aws, ok := providers[1].(*credentials.AWSSessionProvider)
+	if !ok || aws.Profile != "dev" || aws.Duration.Minutes() != 30 || aws.SessionName != "packnplay-app-f227ecb4-main" {
+		t.Errorf("providers[1] = %+v, want the STS session provider", providers[1])
+	}
+
+	// Reconnecting mints new tokens the same way
+	spec := &credentialRefresh{GH: true, AWS: true, AWSProfile: "dev", Scoped: cfg.ScopedCreds, Session: cfg.CredentialSession}
+	providers, err = spec.providers()
+	if err != nil || len(providers) != 2 {
+		t.Fatalf("spec.providers() = %v, %v", providers, err)
+	}
+	if _, ok := providers[1].(*credentials.AWSSessionProvider); !ok {
+		t.Errorf("refreshed providers[1] = %T, want the STS session provider", providers[1])
+	}
+}
+
+func TestGitHubRepoName(t *testing.T) {
+	tests := map[string]string{
+		"git@github.com:obra/packnplay.git":        "packnplay",
+		"https://github.com/obra/packnplay":        "packnplay",
+		"ssh://git@github.com/obra/packnplay.git/": "packnplay",
+		"https://gitlab.com/obra/packnplay.git":    "",
+		"git@github.com:packnplay":                 "",
+	}
+	for url, want := range tests {
+		if got := githubRepoName(url); got != want {
+			t.Errorf("githubRepoName(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+// fetchFailProvider is detected, and fails the test when fetched
+type fetchFailProvider struct{ t *testing.T }
+
+func (p fetchFailProvider) Name() string                            { return "vault" }
+func (p fetchFailProvider) Detect(env credentials.Environment) bool { return true }
+func (p fetchFailProvider) RefreshTTL() time.Duration               { return 0 }
+func (p fetchFailProvider) Fetch(env credentials.Environment) (*credentials.Result, error) {
+	p.t.Error("a dry run fetched credentials")
+	return &credentials.Result{}, nil
+}
+
+func TestCollectCredentialsDryRun(t *testing.T) {
+	var out bytes.Buffer
+	results, err := collectCredentials(&out, []credentials.Provider{fetchFailProvider{t}}, credentials.Environment{}, true)
+	if err != nil || results != nil {
+		t.Errorf("collectCredentials() = %v, %v, want nothing", results, err)
+	}
+	if !strings.Contains(out.String(), "provides vault credentials") {
+		t.Errorf("dry run output = %q, want the provider noted", out.String())
+	}
+}
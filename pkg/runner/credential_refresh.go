@@ -0,0 +1,158 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/credentials"
+	"github.com/obra/packnplay/pkg/docker"
+)
+
+// credentialRefreshLabel records which short-lived credentials a container was started
+// with, so later sessions in it can fetch fresh ones
+const credentialRefreshLabel = "packnplay-credential-refresh"
+
+// credentialRefresh is the JSON in credentialRefreshLabel: the providers whose credentials
+// expire, with the settings needed to run them again. Only env vars are refreshed, since a
+// running container's mounts can't change; kube tokens, which are mounted, aren't covered.
+type credentialRefresh struct {
+	AWS        bool                                       `json:"aws,omitempty"`
+	AWSProfile string                                     `json:"aws_profile,omitempty"`
+	AzureToken bool                                       `json:"azure_token,omitempty"`
+	GH         bool                                       `json:"gh,omitempty"` // only with scoped credentials; gh logins don't expire
+	Scoped     *config.ScopedCredentialsConfig            `json:"scoped,omitempty"`
+	Session    string                                     `json:"session,omitempty"`
+	Scripts    map[string]config.CredentialProviderConfig `json:"scripts,omitempty"`
+	Skip       []string                                   `json:"skip,omitempty"` // set with --env, which keeps the final say
+}
+
+// credentialRefreshSpec returns the credentialRefresh for providers that were just
+// collected, or nil when none of them hand out credentials that expire
+func credentialRefreshSpec(providers []credentials.Provider, cfg *RunConfig) *credentialRefresh {
+	spec := &credentialRefresh{}
+	found := false
+	for _, provider := range providers {
+		if provider.RefreshTTL() <= 0 {
+			continue
+		}
+		switch p := provider.(type) {
+		case *credentials.AWSProvider:
+			spec.AWS, spec.AWSProfile = true, p.Profile
+		case *credentials.AWSSessionProvider:
+			spec.AWS, spec.AWSProfile = true, p.Profile
+			spec.Scoped, spec.Session = cfg.ScopedCreds, p.SessionName
+		case *credentials.GHAppProvider:
+			spec.GH, spec.Scoped = true, cfg.ScopedCreds
+		case *credentials.AzureProvider:
+			if !p.Token {
+				continue
+			}
+			spec.AzureToken = true
+		case *credentials.ScriptProvider:
+			if spec.Scripts == nil {
+				spec.Scripts = make(map[string]config.CredentialProviderConfig)
+			}
+			spec.Scripts[p.ProviderName] = cfg.CredentialProviders[p.ProviderName]
+		default:
+			continue
+		}
+		found = true
+	}
+	if !found {
+		return nil
+	}
+	for _, entry := range cfg.Env {
+		key, _, _ := strings.Cut(entry, "=")
+		spec.Skip = append(spec.Skip, key)
+	}
+	return spec
+}
+
+// label returns the run arguments that record spec on the container
+func (spec *credentialRefresh) label() []string {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil
+	}
+	return []string{"--label", credentialRefreshLabel + "=" + string(data)}
+}
+
+// providers rebuilds the credential providers spec records
+func (spec *credentialRefresh) providers() ([]credentials.Provider, error) {
+	cfg := &RunConfig{
+		AWSProfile:          spec.AWSProfile,
+		AzureToken:          spec.AzureToken,
+		CredentialProviders: spec.Scripts,
+		ScopedCreds:         spec.Scoped,
+		CredentialSession:   spec.Session,
+	}
+	cfg.Credentials.GH = spec.GH && spec.Scoped != nil
+	cfg.Credentials.AWS = spec.AWS
+	cfg.Credentials.Azure = spec.AzureToken
+	return credentialProviders(cfg, true)
+}
+
+// refreshCredentialEnv fetches fresh short-lived credentials for a container from the
+// providers recorded in its labels and returns them as KEY=value pairs for docker exec.
+// Variables in skip, and those set with --env when the container started, are left out.
+func refreshCredentialEnv(labels, containerEnv map[string]string, skip []string, verbose bool) []string {
+	data, ok := labels[credentialRefreshLabel]
+	if !ok {
+		return nil
+	}
+	var spec credentialRefresh
+	if err := json.Unmarshal([]byte(data), &spec); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to read %s label: %v\n", credentialRefreshLabel, err)
+		return nil
+	}
+	providers, err := spec.providers()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not refreshing credentials: %v\n", err)
+		return nil
+	}
+
+	skipped := make(map[string]bool)
+	for _, entry := range append(spec.Skip, skip...) {
+		key, _, _ := strings.Cut(entry, "=")
+		skipped[key] = true
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	var env []string
+	// Never strict: the session goes on with the credentials it started with
+	results, _ := credentials.Collect(providers, credentials.Environment{
+		HomeDir:       homeDir,
+		ContainerHome: containerEnv["HOME"],
+		Verbose:       verbose,
+		Log:           os.Stderr,
+	})
+	for _, result := range results {
+		for _, entry := range result.EnvArgs() {
+			key, _, _ := strings.Cut(entry, "=")
+			if !skipped[key] {
+				env = append(env, entry)
+			}
+		}
+	}
+	if verbose && len(env) > 0 {
+		fmt.Fprintf(os.Stderr, "Refreshed short-lived credentials for this session\n")
+	}
+	return env
+}
+
+// refreshSession prepares a new session in a running container: it re-forwards agent
+// sockets that changed since the container started, and fetches fresh short-lived
+// credentials into config.SessionEnv
+func refreshSession(dockerClient *docker.Client, containerID string, config *RunConfig) {
+	info, err := dockerClient.InspectContainer(containerID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to inspect container, not refreshing agents or credentials: %v\n", err)
+		return
+	}
+	refreshAgentSockets(info.Name, config.Verbose)
+	resumeWorkspaceSync(dockerClient.Command(), info.Name, info.Config.Labels, config.Verbose)
+	config.SessionEnv = refreshCredentialEnv(info.Config.Labels, envMap(info.Config.Env), config.Env, config.Verbose)
+}
@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/credentials"
+)
+
+func TestCredentialRefreshSpec(t *testing.T) {
+	scripts := map[string]config.CredentialProviderConfig{
+		"vault":  {Command: "vault-creds", RefreshTTL: "1h"},
+		"static": {Command: "static-creds"},
+	}
+
+	tests := []struct {
+		name      string
+		providers []credentials.Provider
+		env       []string
+		want      *credentialRefresh
+	}{
+		{
+			"nothing expires",
+			[]credentials.Provider{&credentials.GHProvider{}, &credentials.ScriptProvider{ProviderName: "static", Command: "static-creds"}},
+			nil,
+			nil,
+		},
+		{
+			"scripts with a ttl are recorded",
+			[]credentials.Provider{
+				&credentials.ScriptProvider{ProviderName: "static", Command: "static-creds"},
+				&credentials.ScriptProvider{ProviderName: "vault", Command: "vault-creds", TTL: time.Hour},
+			},
+			[]string{"VAULT_TOKEN=mine", "DEBUG"},
+			&credentialRefresh{
+				Scripts: map[string]config.CredentialProviderConfig{"vault": scripts["vault"]},
+				Skip:    []string{"VAULT_TOKEN", "DEBUG"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := credentialRefreshSpec(tt.providers, &RunConfig{Env: tt.env, CredentialProviders: scripts})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("credentialRefreshSpec() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefreshCredentialEnv(t *testing.T) {
+	spec := &credentialRefresh{
+		Scripts: map[string]config.CredentialProviderConfig{
+			"vault": {Command: `echo '{"env": {"VAULT_TOKEN": "fresh", "VAULT_ADDR": "https://vault", "REGION": "eu"}}'`, RefreshTTL: "1h"},
+		},
+		Skip: []string{"VAULT_ADDR"},
+	}
+	label := spec.label()
+	if len(label) != 2 || !strings.HasPrefix(label[1], credentialRefreshLabel+"=") {
+		t.Fatalf("label() = %q", label)
+	}
+	labels := map[string]string{credentialRefreshLabel: strings.TrimPrefix(label[1], credentialRefreshLabel+"=")}
+
+	got := refreshCredentialEnv(labels, map[string]string{"HOME": "/home/user"}, []string{"REGION=us"}, false)
+	want := []string{"VAULT_TOKEN=fresh"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("refreshCredentialEnv() = %q, want %q", got, want)
+	}
+
+	if got := refreshCredentialEnv(map[string]string{}, nil, nil, false); got != nil {
+		t.Errorf("refreshCredentialEnv() without the label = %q, want nil", got)
+	}
+}
@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/obra/packnplay/pkg/cache"
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/devcontainer"
+	"github.com/obra/packnplay/pkg/docker"
+)
+
+// dependencyCacheInitDir is where the volume setup container mounts the new volumes
+const dependencyCacheInitDir = "/packnplay-cache"
+
+// addDependencyCaches mounts a volume over each package manager cache the run uses:
+// dependency_caches.caches, else the devcontainer's packnplay.dependencyCaches, else all
+// the built-in ones. Volumes that don't exist yet are created for the container user.
+func addDependencyCaches(spec *ContainerSpec, dockerClient *docker.Client, settings config.DependencyCachesConfig, devConfig *devcontainer.Config, workDir string, dryRun, verbose bool) ([]cache.DependencyCache, error) {
+	entries := settings.Caches
+	if len(entries) == 0 {
+		entries = devConfig.Customizations.Packnplay.DependencyCaches
+		for _, entry := range entries {
+			if err := config.ValidateDependencyCache(entry); err != nil {
+				return nil, fmt.Errorf("devcontainer.json customizations.packnplay.dependencyCaches: %w", err)
+			}
+		}
+	}
+
+	home := "/home/" + devConfig.RemoteUser
+	prefix := cache.DependencyVolumePrefix(settings.Scope == config.DependencyCacheGlobal, workDir)
+	caches, err := cache.ResolveDependencyCaches(entries, home, prefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range caches {
+		spec.AddMount(c.Mount())
+	}
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Mounting %d dependency caches (%s-*)\n", len(caches), prefix)
+	}
+
+	if !dryRun {
+		if err := createDependencyVolumes(dockerClient, caches, spec.Image, devConfig.RemoteUser); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	return caches, nil
+}
+
+// createDependencyVolumes creates the cache volumes that don't exist yet and hands them
+// to user. A new volume mounted where the image has nothing belongs to root, so one short
+// container from image chowns them all as they are created.
+func createDependencyVolumes(dockerClient *docker.Client, caches []cache.DependencyCache, image, user string) error {
+	var created []cache.DependencyCache
+	for _, c := range caches {
+		if _, err := dockerClient.Run("volume", "inspect", c.Volume); err == nil {
+			continue
+		}
+		if output, err := dockerClient.Run("volume", "create", "--label", cache.DependencyCacheLabel+"="+c.Name, c.Volume); err != nil {
+			return fmt.Errorf("failed to create dependency cache volume %s: %w\nDocker output:\n%s", c.Volume, err, output)
+		}
+		created = append(created, c)
+	}
+	if len(created) == 0 || user == "root" {
+		return nil
+	}
+
+	// Under Podman's keep-id, commands run with the host user's IDs
+	owner := user
+	args := []string{"run", "--rm", "-u", "0", "--entrypoint", "chown"}
+	if dockerClient.IsPodman() && dockerClient.Rootless() {
+		owner = fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())
+		args = append(args, podmanKeepID)
+	}
+	var dirs []string
+	for i, c := range created {
+		dir := path.Join(dependencyCacheInitDir, strconv.Itoa(i))
+		args = append(args, "-v", c.Volume+":"+dir)
+		dirs = append(dirs, dir)
+	}
+	args = append(append(args, image, owner), dirs...)
+	if output, err := dockerClient.Run(args...); err != nil {
+		return fmt.Errorf("failed to hand dependency caches to %s: %w\nDocker output:\n%s", user, err, output)
+	}
+	return nil
+}
+
+// chownCacheParents hands the directories the runtime created above the caches, such as
+// ~/.cache, to the container user, who could otherwise not write anything else in them
+func chownCacheParents(dockerClient *docker.Client, containerID, user string, caches []cache.DependencyCache, verbose bool) {
+	if user == "root" {
+		return
+	}
+	dirs := cache.CreatedParents(caches, "/home/"+user)
+	if len(dirs) == 0 {
+		return
+	}
+	args := append([]string{"exec", "-u", "0", containerID, "chown", fileOwner(dockerClient, containerID, user)}, dirs...)
+	if output, err := dockerClient.Run(args...); err != nil && verbose {
+		fmt.Fprintf(os.Stderr, "Warning: failed to chown %v: %v\n%s", dirs, err, output)
+	}
+}
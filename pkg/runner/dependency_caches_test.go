@@ -0,0 +1,39 @@
+package runner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/devcontainer"
+)
+
+func TestAddDependencyCaches(t *testing.T) {
+	devConfig := &devcontainer.Config{RemoteUser: "vscode"}
+	devConfig.Customizations.Packnplay.DependencyCaches = []string{"cargo"}
+
+	// The devcontainer's caches, unless the config names some
+	spec := NewContainerSpec("packnplay-app-main")
+	caches, err := addDependencyCaches(spec, nil, config.DependencyCachesConfig{Enabled: true}, devConfig, "/src/app", true, false)
+	if err != nil {
+		t.Fatalf("addDependencyCaches() error = %v", err)
+	}
+	if len(caches) != 1 || len(spec.Mounts) != 1 || !strings.HasSuffix(spec.Mounts[0], "-cargo:/home/vscode/.cargo/registry") {
+		t.Errorf("addDependencyCaches() mounts = %q", spec.Mounts)
+	}
+
+	spec = NewContainerSpec("packnplay-app-main")
+	settings := config.DependencyCachesConfig{Enabled: true, Scope: config.DependencyCacheGlobal, Caches: []string{"npm"}}
+	if _, err := addDependencyCaches(spec, nil, settings, devConfig, "/src/app", true, false); err != nil {
+		t.Fatalf("addDependencyCaches() error = %v", err)
+	}
+	if want := []string{"packnplay-cache-npm:/home/vscode/.npm"}; !reflect.DeepEqual(spec.Mounts, want) {
+		t.Errorf("addDependencyCaches() mounts = %q, want %q", spec.Mounts, want)
+	}
+
+	devConfig.Customizations.Packnplay.DependencyCaches = []string{"Bad Name"}
+	if _, err := addDependencyCaches(NewContainerSpec("x"), nil, config.DependencyCachesConfig{Enabled: true}, devConfig, "/src/app", true, false); err == nil {
+		t.Error("addDependencyCaches() accepted an invalid devcontainer cache")
+	}
+}
@@ -0,0 +1,102 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/devcontainer"
+	"github.com/obra/packnplay/pkg/dind"
+	"github.com/obra/packnplay/pkg/docker"
+)
+
+// Docker access modes, for Run, whose config parameter shadows the config package
+const (
+	dockerOffMode    = config.DockerOff
+	dockerSocketMode = config.DockerSocket
+	dockerDindMode   = config.DockerDind
+)
+
+// dockerAccessMode returns how the container gets docker: the mode from the flag or
+// config, else the one the devcontainer's docker features ask for, else off
+func dockerAccessMode(mode string, devConfig *devcontainer.Config) string {
+	if mode != "" {
+		return mode
+	}
+	switch devConfig.DockerFeature() {
+	case "docker-in-docker":
+		return config.DockerDind
+	case "docker-outside-of-docker", "docker-from-docker":
+		return config.DockerSocket
+	}
+	return config.DockerOff
+}
+
+// checkDockerAccess reports modes the run can't provide, before anything is started
+func checkDockerAccess(mode, runtime, remoteHost string, network config.NetworkConfig) error {
+	if mode == config.DockerOff {
+		return nil
+	}
+	if runtime == "container" {
+		return fmt.Errorf("--docker=%s is not supported with Apple Container", mode)
+	}
+	if mode == config.DockerSocket && remoteHost != "" {
+		return fmt.Errorf("--docker=socket can't mount a local socket on remote host %s; use --docker=dind", remoteHost)
+	}
+	if mode == config.DockerDind && network.Isolated() {
+		return fmt.Errorf("--docker=dind can't be combined with network mode %s", network.Mode)
+	}
+	return nil
+}
+
+// hostDockerSocket returns the daemon socket to mount in socket mode: DOCKER_HOST when
+// it is a unix socket, else the runtime's usual socket
+func hostDockerSocket(getenv func(string) string, podman bool) (string, error) {
+	if host := getenv("DOCKER_HOST"); host != "" {
+		path, ok := strings.CutPrefix(host, "unix://")
+		if !ok {
+			return "", fmt.Errorf("--docker=socket needs a local socket, but DOCKER_HOST is %s", host)
+		}
+		return path, nil
+	}
+	if podman {
+		if runtimeDir := getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+			if path := filepath.Join(runtimeDir, "podman", "podman.sock"); fileExists(path) {
+				return path, nil
+			}
+		}
+		return "/run/podman/podman.sock", nil
+	}
+	return docker.DefaultSocketPath, nil
+}
+
+// dockerSocketMount returns the bind mount that puts the host's daemon socket where
+// docker clients in the container look for it
+func dockerSocketMount(podman bool) (string, error) {
+	socket, err := hostDockerSocket(os.Getenv, podman)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(socket); err != nil {
+		return "", fmt.Errorf("--docker=socket: no daemon socket at %s", socket)
+	}
+	return socket + ":" + docker.DefaultSocketPath, nil
+}
+
+// startDind starts the dind sidecar and returns the docker run flags that join the
+// container to its network and point the docker CLI at it. A dry run starts nothing.
+func startDind(dockerClient *docker.Client, containerName, image string, dryRun, verbose bool) ([]string, error) {
+	network := dind.Name(containerName)
+	if !dryRun {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Starting docker-in-docker sidecar %s\n", network)
+		}
+		var err error
+		if network, err = dind.Start(dockerClient, containerName, image); err != nil {
+			return nil, err
+		}
+	}
+	return []string{"--network", network, "-e", "DOCKER_HOST=" + dind.Host()}, nil
+}
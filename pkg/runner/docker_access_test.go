@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/devcontainer"
+)
+
+func TestDockerAccessMode(t *testing.T) {
+	dindFeature := &devcontainer.Config{Features: map[string]any{"ghcr.io/devcontainers/features/docker-in-docker:2": map[string]any{}}}
+	outsideFeature := &devcontainer.Config{Features: map[string]any{"ghcr.io/devcontainers/features/docker-outside-of-docker:1": map[string]any{}}}
+
+	tests := []struct {
+		name      string
+		mode      string
+		devConfig *devcontainer.Config
+		want      string
+	}{
+		{"nothing asked", "", &devcontainer.Config{}, config.DockerOff},
+		{"docker-in-docker feature", "", dindFeature, config.DockerDind},
+		{"docker-outside-of-docker feature", "", outsideFeature, config.DockerSocket},
+		{"legacy docker-from-docker feature", "", &devcontainer.Config{Features: map[string]any{"docker-from-docker": "latest"}}, config.DockerSocket},
+		{"flag beats feature", config.DockerSocket, dindFeature, config.DockerSocket},
+		{"off beats feature", config.DockerOff, dindFeature, config.DockerOff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dockerAccessMode(tt.mode, tt.devConfig); got != tt.want {
+				t.Errorf("dockerAccessMode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckDockerAccess(t *testing.T) {
+	allowlist := config.NetworkConfig{Mode: config.NetworkAllowlist, Allow: []string{"github.com"}}
+	tests := []struct {
+		name    string
+		mode    string
+		runtime string
+		remote  string
+		network config.NetworkConfig
+		wantErr bool
+	}{
+		{"off with anything", config.DockerOff, "container", "build-box", allowlist, false},
+		{"socket", config.DockerSocket, "docker", "", config.NetworkConfig{}, false},
+		{"dind on podman", config.DockerDind, "podman", "", config.NetworkConfig{}, false},
+		{"dind on a remote host", config.DockerDind, "docker", "build-box", config.NetworkConfig{}, false},
+		{"apple container", config.DockerDind, "container", "", config.NetworkConfig{}, true},
+		{"socket on a remote host", config.DockerSocket, "docker", "build-box", config.NetworkConfig{}, true},
+		{"dind with an allowlist", config.DockerDind, "docker", "", allowlist, true},
+		{"socket with an allowlist", config.DockerSocket, "docker", "", allowlist, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDockerAccess(tt.mode, tt.runtime, tt.remote, tt.network)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkDockerAccess() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHostDockerSocket(t *testing.T) {
+	runtimeDir := t.TempDir()
+	podmanSocket := filepath.Join(runtimeDir, "podman", "podman.sock")
+	if err := os.MkdirAll(filepath.Dir(podmanSocket), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(podmanSocket, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		podman  bool
+		want    string
+		wantErr bool
+	}{
+		{"default", nil, false, "/var/run/docker.sock", false},
+		{"unix DOCKER_HOST", map[string]string{"DOCKER_HOST": "unix:///run/user/1000/docker.sock"}, false, "/run/user/1000/docker.sock", false},
+		{"tcp DOCKER_HOST", map[string]string{"DOCKER_HOST": "tcp://10.0.0.5:2375"}, false, "", true},
+		{"rootless podman", map[string]string{"XDG_RUNTIME_DIR": runtimeDir}, true, podmanSocket, false},
+		{"rootful podman", nil, true, "/run/podman/podman.sock", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := hostDockerSocket(func(key string) string { return tt.env[key] }, tt.podman)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("hostDockerSocket() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("hostDockerSocket() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
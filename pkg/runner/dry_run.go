@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/obra/packnplay/pkg/devcontainer"
+	"github.com/obra/packnplay/pkg/docker"
+)
+
+// redacted replaces secret values in dry run output
+const redacted = "<redacted>"
+
+// secretNamePattern matches variable names that usually hold secrets, for --env values
+// that packnplay can't otherwise tell apart
+var secretNamePattern = regexp.MustCompile(`(?i)(TOKEN|SECRET|PASSW(OR)?D|API_?KEY|PRIVATE_KEY|ACCESS_KEY|CREDENTIAL)`)
+
+// redactEnv returns args with the values of -e KEY=value pairs replaced when secret lists
+// the name or it looks like it holds a secret
+func redactEnv(args []string, secret map[string]bool) []string {
+	out := append([]string{}, args...)
+	for i := 0; i+1 < len(out); i++ {
+		if out[i] != "-e" {
+			continue
+		}
+		key, _, ok := strings.Cut(out[i+1], "=")
+		if ok && (secret[key] || secretNamePattern.MatchString(key)) {
+			out[i+1] = key + "=" + redacted
+		}
+	}
+	return out
+}
+
+// formatCommand renders a runtime command line to paste into a shell: the subcommand,
+// then each flag with its values on a continuation line, then the positional arguments
+// (image or container, and command) on the last one
+func formatCommand(runtime string, args, positional []string) string {
+	var lines []string
+	line := runtime
+	for i, arg := range args {
+		if i > 0 && strings.HasPrefix(arg, "-") {
+			lines = append(lines, line)
+			line = "  " + quoteIfNeeded(arg)
+			continue
+		}
+		line += " " + quoteIfNeeded(arg)
+	}
+	lines = append(lines, line)
+
+	if len(positional) > 0 {
+		quoted := make([]string, len(positional))
+		for i, arg := range positional {
+			quoted[i] = quoteIfNeeded(arg)
+		}
+		lines = append(lines, "  "+strings.Join(quoted, " "))
+	}
+	return strings.Join(lines, " \\\n") + "\n"
+}
+
+// dryRunNote prints a shell comment about what a dry run leaves out
+func dryRunNote(w io.Writer, format string, args ...interface{}) {
+	fmt.Fprintf(w, "# "+format+"\n", args...)
+}
+
+// printDryRun prints the commands that would start the container spec describes and run
+// the command in it, leaving out the values of its secret env vars
+func printDryRun(w io.Writer, dockerClient *docker.Client, spec *ContainerSpec, devConfig *devcontainer.Config, config *RunConfig, claudeJSONSrc string) error {
+	runtime := dockerClient.Command()
+	caps := dockerClient.Capabilities()
+	if config.Ephemeral {
+		args, positional, _ := ephemeralCreateArgs(dockerClient, spec, devConfig, config, claudeJSONSrc)
+		fmt.Fprint(w, formatCommand(runtime, redactEnv(args, spec.SecretEnv), positional))
+		fmt.Fprint(w, formatCommand(runtime, []string{"start", "-a", "-i"}, []string{spec.Name}))
+		return nil
+	}
+
+	args := append(startCommand(false, caps.DetachedTTY, false), spec.Args()...)
+	fmt.Fprint(w, formatCommand(runtime, redactEnv(args, spec.SecretEnv), spec.Positional()))
+	// The container doesn't exist yet, so ${containerEnv:VAR} is looked up in the image
+	remoteEnv, unset := ephemeralRemoteEnv(dockerClient, spec.Image, devConfig, config)
+	remoteEnv, _ = hostEnvByName(remoteEnv, devConfig)
+	printDryRunExec(w, runtime, spec.Name, spec.WorkingDir, remoteEnv, unset, config.Command)
+	return nil
+}
+
+// printDryRunExec prints the docker exec command that runs command in containerName
+func printDryRunExec(w io.Writer, runtime, containerName, workingDir string, remoteEnv, unset, command []string) {
+	args := sessionExecArgs(workingDir, remoteEnv, containerName)
+	args = redactEnv(args[:len(args)-1], nil)
+	fmt.Fprint(w, formatCommand(runtime, args, append([]string{containerName}, sessionCommand(command, unset)...)))
+}
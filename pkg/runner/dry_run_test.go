@@ -0,0 +1,35 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRedactEnv(t *testing.T) {
+	args := []string{"run", "-e", "GH_TOKEN=gho_x", "-e", "ANTHROPIC_BASE=x", "-e", "DB_PASSWORD=hunter2", "-e", "TERM=xterm", "-v", "/a:/b", "-e", "SSH_AUTH_SOCK=/ssh-agent"}
+	got := redactEnv(args, map[string]bool{"ANTHROPIC_BASE": true})
+	want := []string{"run", "-e", "GH_TOKEN=<redacted>", "-e", "ANTHROPIC_BASE=<redacted>", "-e", "DB_PASSWORD=<redacted>", "-e", "TERM=xterm", "-v", "/a:/b", "-e", "SSH_AUTH_SOCK=/ssh-agent"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("redactEnv() = %v, want %v", got, want)
+	}
+	if args[2] != "GH_TOKEN=gho_x" {
+		t.Errorf("redactEnv() changed its input")
+	}
+}
+
+func TestFormatCommand(t *testing.T) {
+	got := formatCommand("docker", []string{"run", "-d", "-it", "--name", "packnplay-app", "-e", "GREETING=hello world"}, []string{"ubuntu", "sleep", "infinity"})
+	want := "docker run \\\n" +
+		"  -d \\\n" +
+		"  -it \\\n" +
+		"  --name packnplay-app \\\n" +
+		"  -e 'GREETING=hello world' \\\n" +
+		"  ubuntu sleep infinity\n"
+	if got != want {
+		t.Errorf("formatCommand() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := formatCommand("podman", []string{"start", "-a"}, nil); got != "podman start \\\n  -a\n" {
+		t.Errorf("formatCommand() without positional args = %q", got)
+	}
+}
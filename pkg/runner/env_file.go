@@ -0,0 +1,42 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// writeEnvFile writes spec's secret env vars to a file only the user can read and points
+// spec.EnvFile at it. Their values then stay off the docker command line, which every
+// process on the host can read, and out of verbose output. The runtime reads the file when
+// it creates the container; the returned func removes it, and can be called more than once.
+func writeEnvFile(spec *ContainerSpec) (func(), error) {
+	entries := spec.SecretEnvEntries()
+	if len(entries) == 0 {
+		return func() {}, nil
+	}
+	for _, entry := range entries {
+		if strings.ContainsAny(entry, "\r\n") {
+			key, _, _ := strings.Cut(entry, "=")
+			return nil, fmt.Errorf("the value of %s has a line break, which can't be passed in an env file", key)
+		}
+	}
+
+	// CreateTemp makes the file readable by the user only
+	file, err := os.CreateTemp("", "packnplay-env-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create env file: %w", err)
+	}
+	path := file.Name()
+	remove := func() { _ = os.Remove(path) }
+	_, err = file.WriteString(strings.Join(entries, "\n") + "\n")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		remove()
+		return nil, fmt.Errorf("failed to write env file: %w", err)
+	}
+	spec.EnvFile = path
+	return remove, nil
+}
@@ -0,0 +1,228 @@
+package runner
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/devcontainer"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/events"
+	"github.com/obra/packnplay/pkg/mcp"
+	"github.com/obra/packnplay/pkg/ptyexec"
+)
+
+// ephemeralClaudeJSON is where .claude.json is staged in a --rm container until the
+// command copies it into place as the container user
+const ephemeralClaudeJSON = "/tmp/.packnplay-claude.json"
+
+// ephemeralName returns a unique name for a --rm container, so one-shot runs never
+// collide with the worktree's regular container or with each other
+func ephemeralName(containerName string) string {
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return fmt.Sprintf("%s-rm-%s", containerName, hex.EncodeToString(suffix))
+}
+
+// ephemeralCommand wraps the user's command for a --rm container: variables remoteEnv
+// sets to null are removed, and the staged .claude.json is copied to claudeJSONPath
+// first (when set) so it belongs to the container user
+func ephemeralCommand(command, unset []string, claudeJSONPath string) []string {
+	if len(unset) > 0 {
+		command = append(append([]string{"env"}, unsetArgs(unset)...), command...)
+	}
+	if claudeJSONPath != "" {
+		command = append([]string{"sh", "-c", `cp "$0" "$1" && shift && exec "$@"`, ephemeralClaudeJSON, claudeJSONPath}, command...)
+	}
+	return command
+}
+
+// ephemeralRemoteEnv resolves remoteEnv for a container that doesn't exist yet:
+// ${containerEnv:VAR} is looked up in the image's environment, overlaid with
+// devcontainer.json containerEnv and --env values
+func ephemeralRemoteEnv(dockerClient *docker.Client, image string, devConfig *devcontainer.Config, config *RunConfig) (env []string, unset []string) {
+	if len(devConfig.RemoteEnv) == 0 {
+		return nil, nil
+	}
+
+	var lookup devcontainer.LookupFunc
+	if devConfig.NeedsContainerEnv() {
+		containerEnv, err := inspectContainerEnv(dockerClient, image)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to read image environment for remoteEnv: %v\n", err)
+			containerEnv = make(map[string]string)
+		}
+		for _, entry := range append(devConfig.ContainerEnvArgs(config.localEnv()), config.Env...) {
+			if key, value, ok := strings.Cut(entry, "="); ok {
+				containerEnv[key] = value
+			}
+		}
+		lookup = func(name string) (string, bool) {
+			value, ok := containerEnv[name]
+			return value, ok
+		}
+	}
+	return resolveRemoteEnv(devConfig, config, lookup)
+}
+
+// runEphemeral runs the command as the main process of a --rm container, described by
+// spec, instead of exec'ing into a long-lived one.
+// The container is created, .claude.json is staged in it, and it is started attached;
+// docker removes it when the command exits. packnplay waits for it (rather than
+// replacing itself) so it can clean up the run's host-side state afterwards.
+func runEphemeral(dockerClient *docker.Client, spec *ContainerSpec, devConfig *devcontainer.Config, config *RunConfig, claudeJSONSrc string, rewrite *mcp.RewriteResult) error {
+	cmdPath, err := exec.LookPath(dockerClient.Command())
+	if err != nil {
+		return fmt.Errorf("failed to find docker command: %w", err)
+	}
+
+	containerName := spec.Name
+	defer removeClaudeSandbox(config.ClaudeSync, containerName)
+
+	removeEnvFile, err := writeEnvFile(spec)
+	if err != nil {
+		return err
+	}
+	defer removeEnvFile()
+	args, positional, hostEnv := ephemeralCreateArgs(dockerClient, spec, devConfig, config, claudeJSONSrc)
+	args = append(args, positional...)
+	claudeJSONPath := ""
+	if fileExists(claudeJSONSrc) {
+		claudeJSONPath = fmt.Sprintf("/home/%s/.claude.json", devConfig.RemoteUser)
+	}
+
+	if config.Verbose {
+		fmt.Fprintf(os.Stderr, "Creating container %s (removed on exit)\n", containerName)
+		fmt.Fprintf(os.Stderr, "Full command: docker %v\n", redactEnv(args, spec.SecretEnv))
+	}
+	containerID, err := dockerClient.RunWithEnv(hostEnv, args...)
+	removeEnvFile()
+	if err != nil {
+		return fmt.Errorf("failed to create container: %w\nDocker output:\n%s", err, containerID)
+	}
+	containerID = strings.TrimSpace(containerID)
+	config.Events.Emit(events.Event{Event: events.Created, Container: containerName, ContainerID: containerID})
+
+	if claudeJSONPath != "" {
+		if err := stageClaudeJSON(dockerClient, containerID, claudeJSONSrc, rewrite); err != nil {
+			// --rm only applies once the container has run
+			_, _ = dockerClient.Run("rm", "-f", containerID)
+			return err
+		}
+	}
+	// Like .claude.json, container-managed credentials go in before the command starts
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		copyContainerCredentials(dockerClient, containerID, containerName, homeDir, devConfig.RemoteUser, config.Verbose)
+	}
+
+	var opts ptyexec.Options
+	var timedOut atomic.Bool
+	if config.Timeout > 0 {
+		opts.Timeout = config.Timeout
+		opts.OnTimeout = func() {
+			timedOut.Store(true)
+			fmt.Fprintf(os.Stderr, "\r\npacknplay: command exceeded timeout of %s, stopping it\r\n", config.Timeout)
+			// The command is the container's main process, so stopping the container stops it
+			if _, err := dockerClient.Run("stop", "-t", strconv.Itoa(int(timeoutGrace.Seconds())), containerID); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to stop container: %v\r\n", err)
+			}
+		}
+	}
+	stopRecording, err := startRecording(config, &opts)
+	if err != nil {
+		_, _ = dockerClient.Run("rm", "-f", containerID)
+		return err
+	}
+	defer stopRecording()
+	defer startLogCapture(config, containerName, &opts)()
+
+	cmd := exec.Command(cmdPath, "start", "-a", "-i", containerID)
+	config.Events.Emit(events.Event{Event: events.ExecStarted, ContainerID: containerID, Command: config.Command})
+	start := time.Now()
+	code, err := ptyexec.Run(cmd, opts)
+	if err != nil {
+		_, _ = dockerClient.Run("rm", "-f", containerID)
+		return fmt.Errorf("failed to start container: %w", err)
+	}
+
+	if timedOut.Load() {
+		code = timeoutExitCode
+	}
+	config.Events.Emit(events.Event{Event: events.Exited, ContainerID: containerID, Code: events.ExitCode(code), TimedOut: timedOut.Load()})
+	// The container is gone, so there is nothing to stop
+	finishRun("", spec.WorkingDir, config, code, time.Since(start))
+	if code != 0 {
+		return &ExitError{Code: code}
+	}
+	return nil
+}
+
+// ephemeralCreateArgs returns the docker create args of a --rm container, with remoteEnv
+// added to spec's, along with what follows: the image and the wrapped command. remoteEnv
+// read from host variables is passed by name, with the values returned in hostEnv for
+// docker's own environment.
+func ephemeralCreateArgs(dockerClient *docker.Client, spec *ContainerSpec, devConfig *devcontainer.Config, config *RunConfig, claudeJSONSrc string) (args, positional, hostEnv []string) {
+	remoteEnv, unset := ephemeralRemoteEnv(dockerClient, spec.Image, devConfig, config)
+	remoteEnv, hostEnv = hostEnvByName(remoteEnv, devConfig)
+	args = append(startCommand(true, false, ptyexec.Interactive()), spec.Args()...)
+	for _, env := range remoteEnv {
+		args = append(args, "-e", env)
+	}
+
+	claudeJSONPath := ""
+	if fileExists(claudeJSONSrc) {
+		claudeJSONPath = fmt.Sprintf("/home/%s/.claude.json", devConfig.RemoteUser)
+	}
+	command := ephemeralCommand(config.Command, unset, claudeJSONPath)
+	if config.Credentials.GPGAgent {
+		command = gpgAgentCommand(command)
+	}
+	return args, append([]string{spec.Image}, command...), hostEnv
+}
+
+// stageClaudeJSON copies .claude.json, with MCP rewrites applied, to ephemeralClaudeJSON
+// in a created container. docker cp works before the container starts, unlike exec.
+func stageClaudeJSON(dockerClient *docker.Client, containerID, hostPath string, rewrite *mcp.RewriteResult) error {
+	data, err := os.ReadFile(hostPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", hostPath, err)
+	}
+	if rewrite != nil {
+		data = rewrite.Data
+	}
+
+	tempDir, err := os.MkdirTemp("", "packnplay-claude-json-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tempDir) }()
+
+	// Readable by whichever user the container runs as; the copy it makes is its own
+	tempPath := filepath.Join(tempDir, ".claude.json")
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write .claude.json: %w", err)
+	}
+	if output, err := dockerClient.Run("cp", tempPath, containerID+":"+ephemeralClaudeJSON); err != nil {
+		return fmt.Errorf("failed to copy .claude.json: %w\nDocker output:\n%s", err, output)
+	}
+	return nil
+}
+
+// removeClaudeSandbox deletes the per-container ~/.claude directory that copy and
+// selective sync modes create, once a --rm container is gone
+func removeClaudeSandbox(mode, containerName string) {
+	if mode == "" || mode == config.ClaudeSyncFull {
+		return
+	}
+	if dir, err := claudeSandboxDir(containerName); err == nil {
+		_ = os.RemoveAll(dir)
+	}
+}
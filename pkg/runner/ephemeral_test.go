@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/docker"
+)
+
+func TestEphemeralName(t *testing.T) {
+	first := ephemeralName("packnplay-app-main")
+	second := ephemeralName("packnplay-app-main")
+
+	if !regexp.MustCompile(`^packnplay-app-main-rm-[0-9a-f]{8}$`).MatchString(first) {
+		t.Errorf("ephemeralName() = %q", first)
+	}
+	if first == second {
+		t.Errorf("ephemeralName() returned %q twice", first)
+	}
+}
+
+func TestEphemeralCommand(t *testing.T) {
+	tests := []struct {
+		name       string
+		unset      []string
+		claudeJSON string
+		want       []string
+	}{
+		{
+			name: "command alone",
+			want: []string{"make", "test"},
+		},
+		{
+			name:  "unset variables",
+			unset: []string{"DEBUG"},
+			want:  []string{"env", "-u", "DEBUG", "make", "test"},
+		},
+		{
+			name:       "stage .claude.json",
+			unset:      []string{"DEBUG"},
+			claudeJSON: "/home/vscode/.claude.json",
+			want: []string{"sh", "-c", `cp "$0" "$1" && shift && exec "$@"`, ephemeralClaudeJSON, "/home/vscode/.claude.json",
+				"env", "-u", "DEBUG", "make", "test"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ephemeralCommand([]string{"make", "test"}, tt.unset, tt.claudeJSON)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ephemeralCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveClaudeSandbox(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	for _, mode := range []string{"full", "copy", "selective"} {
+		dir, err := claudeSandboxDir("c-" + mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Join(dir, "projects"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		removeClaudeSandbox(mode, "c-"+mode)
+
+		_, err = os.Stat(dir)
+		if kept := err == nil; kept != (mode == "full") {
+			t.Errorf("mode %s: sandbox kept = %v", mode, kept)
+		}
+	}
+}
+
+func TestCopyContainerCredentials(t *testing.T) {
+	logPath := fakeDockerCLI(t, "", "")
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	dockerClient, err := docker.NewClient(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The host has no usable credentials (macOS keeps them in the keychain)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	copyContainerCredentials(dockerClient, "abc123", "packnplay-app-main-rm-1", home, "vscode", false)
+	calls, _ := os.ReadFile(logPath)
+	if !strings.Contains(string(calls), "cp ") || !strings.Contains(string(calls), "abc123:/home/vscode/.claude/.credentials.json") {
+		t.Errorf("docker calls = %q, want the credentials copied in", calls)
+	}
+
+	// A host file is mounted instead
+	_ = os.Remove(logPath)
+	if err := os.MkdirAll(filepath.Join(home, ".claude"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".claude", ".credentials.json"), []byte(`{"claudeAiOauth":{"accessToken":"x"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	copyContainerCredentials(dockerClient, "abc123", "packnplay-app-main-rm-1", home, "vscode", false)
+	if calls, _ := os.ReadFile(logPath); len(calls) != 0 {
+		t.Errorf("docker calls = %q, want none with host credentials", calls)
+	}
+}
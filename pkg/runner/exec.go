@@ -0,0 +1,449 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/obra/packnplay/pkg/devcontainer"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/events"
+	"github.com/obra/packnplay/pkg/git"
+	"github.com/obra/packnplay/pkg/logcapture"
+	"github.com/obra/packnplay/pkg/ptyexec"
+	"github.com/obra/packnplay/pkg/recording"
+)
+
+// ExitError reports a non-zero exit from the command run in the container, so the
+// caller can exit with the same status
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("command exited with status %d", e.Code)
+}
+
+// timeoutExitCode is the exit status after --timeout stops the command, matching timeout(1)
+const timeoutExitCode = 124
+
+// timeoutGrace is how long a timed-out command has to exit after SIGTERM before SIGKILL
+const timeoutGrace = 10 * time.Second
+
+// managed reports whether docker exec must run as a child of packnplay
+func (c *RunConfig) managed() bool {
+	return c.ManagedExec || c.RecordFile != "" || c.Timeout > 0 || c.Events != nil || len(c.Then) > 0 || c.AutoCommit || c.LogCapture.Enabled
+}
+
+// execTTYFlags returns the docker exec flags for the session. stdin is always attached so
+// input can be piped in; a TTY is only allocated on an interactive terminal, since without
+// one -t fails ("the input device is not a TTY") and with redirected output it would mix
+// stderr into stdout and turn newlines into \r\n.
+func execTTYFlags(interactive bool) string {
+	if interactive {
+		return "-it"
+	}
+	return "-i"
+}
+
+// execInContainer runs the configured command in the container. By default packnplay
+// replaces itself with docker exec; in managed mode (when recording, enforcing a timeout,
+// emitting events, running --then steps or auto-committing) docker exec runs as a child
+// so packnplay can observe the session, wrap it up when it exits, and return its exit
+// status as an *ExitError. devcontainer.json remoteEnv is applied here.
+func execInContainer(dockerClient *docker.Client, containerID, workingDir string, devConfig *devcontainer.Config, config *RunConfig) error {
+	if len(config.Then) > 0 {
+		return runSteps(dockerClient, containerID, workingDir, devConfig, config, os.Stderr)
+	}
+
+	cmdPath, err := exec.LookPath(dockerClient.Command())
+	if err != nil {
+		return fmt.Errorf("failed to find docker command: %w", err)
+	}
+
+	remoteEnv, unset := remoteEnvArgs(dockerClient, containerID, devConfig, config)
+	remoteEnv, hostEnv := hostEnvByName(remoteEnv, devConfig)
+	// Session credentials are passed by name, with their values in docker's own
+	// environment, so they don't show up in its command line
+	env := append(envNames(config.SessionEnv), remoteEnv...)
+	execArgs := append([]string{filepath.Base(cmdPath)}, sessionExecArgs(workingDir, env, containerID)...)
+	command := sessionCommand(config.Command, unset)
+	processEnv := append(append(os.Environ(), config.SessionEnv...), hostEnv...)
+
+	if !config.managed() {
+		execArgs = append(execArgs, command...)
+		// Use syscall.Exec to replace current process
+		return syscall.Exec(cmdPath, execArgs, processEnv)
+	}
+
+	var opts ptyexec.Options
+	var timedOut atomic.Bool
+	done := make(chan struct{})
+	defer close(done)
+
+	if config.Timeout > 0 {
+		pidFile := fmt.Sprintf("/tmp/.packnplay-exec-%d.pid", os.Getpid())
+		command = withPIDFile(pidFile, command)
+		defer func() {
+			_, _ = dockerClient.Run("exec", containerID, "rm", "-f", pidFile)
+		}()
+
+		opts.Timeout = config.Timeout
+		opts.OnTimeout = func() {
+			timedOut.Store(true)
+			// The terminal may be in raw mode, so end lines with \r\n
+			fmt.Fprintf(os.Stderr, "\r\npacknplay: command exceeded timeout of %s, stopping it\r\n", config.Timeout)
+			stopTimedOutCommand(dockerClient, containerID, pidFile, config.StopOnTimeout, done)
+		}
+	}
+	execArgs = append(execArgs, command...)
+
+	stopRecording, err := startRecording(config, &opts)
+	if err != nil {
+		return err
+	}
+	defer stopRecording()
+	defer startLogCapture(config, containerNameOf(dockerClient, containerID), &opts)()
+
+	cmd := exec.Command(cmdPath, execArgs[1:]...)
+	cmd.Env = processEnv
+	config.Events.Emit(events.Event{Event: events.ExecStarted, ContainerID: containerID, Command: config.Command})
+	start := time.Now()
+	code, err := ptyexec.Run(cmd, opts)
+	if err != nil {
+		return fmt.Errorf("failed to exec in container: %w", err)
+	}
+	if timedOut.Load() {
+		code = timeoutExitCode
+	}
+	config.Events.Emit(events.Event{Event: events.Exited, ContainerID: containerID, Code: events.ExitCode(code), TimedOut: timedOut.Load()})
+	finishRun(containerNameOf(dockerClient, containerID), workingDir, config, code, time.Since(start))
+	if code != 0 {
+		return &ExitError{Code: code}
+	}
+	return nil
+}
+
+// sessionExecArgs returns the docker exec arguments up to the container for a session
+// in workingDir (the resolved host path) with env (KEY=value) set
+func sessionExecArgs(workingDir string, env []string, containerID string) []string {
+	args := []string{"exec", execTTYFlags(ptyexec.Interactive()), "-w", workingDir}
+	for _, entry := range env {
+		args = append(args, "-e", entry)
+	}
+	return append(args, containerID)
+}
+
+// envNames returns the names of env (KEY=value), which docker exec -e reads the values
+// of from its own environment
+func envNames(env []string) []string {
+	names := make([]string, 0, len(env))
+	for _, entry := range env {
+		name, _, _ := strings.Cut(entry, "=")
+		names = append(names, name)
+	}
+	return names
+}
+
+// hostEnvByName replaces the remoteEnv entries whose values were read from host variables
+// with their names, which docker reads the values of from its own environment. Returns
+// the entries to add to docker's environment.
+func hostEnvByName(remoteEnv []string, devConfig *devcontainer.Config) (args, hostEnv []string) {
+	if devConfig == nil {
+		return remoteEnv, nil
+	}
+	fromHost := devConfig.RemoteEnvFromHost()
+	for _, entry := range remoteEnv {
+		if key, _, _ := strings.Cut(entry, "="); fromHost[key] {
+			args = append(args, key)
+			hostEnv = append(hostEnv, entry)
+		} else {
+			args = append(args, entry)
+		}
+	}
+	return args, hostEnv
+}
+
+// sessionCommand wraps command so the variables in unset are removed first: docker exec
+// can't remove a variable, so env(1) drops it
+func sessionCommand(command, unset []string) []string {
+	if len(unset) == 0 {
+		return command
+	}
+	return append(append([]string{"env"}, unsetArgs(unset)...), command...)
+}
+
+// ErrContainerNotRunning is returned by Exec when there is no container to run in
+var ErrContainerNotRunning = errors.New("no running container")
+
+// DefaultShellCommand starts the container user's login shell from /etc/passwd, falling
+// back to bash and then sh when it isn't set or installed
+var DefaultShellCommand = []string{"sh", "-c", `shell=$(getent passwd "$(id -un)" 2>/dev/null | cut -d: -f7); [ -x "$shell" ] || shell=$(command -v bash || echo /bin/sh); exec "$shell"`}
+
+// ExecConfig describes a one-off command for an existing container
+type ExecConfig struct {
+	Path       string
+	Worktree   string
+	NoWorktree bool
+	Runtime    string
+	Command    []string
+	Verbose    bool
+	// The repo isn't trusted: devcontainer.json remoteEnv can't read host variables
+	UntrustedWorkspace bool
+}
+
+// Exec runs a command in the container that Run started for the same directory and
+// worktree. It never creates a container or worktree. A non-zero exit status from the
+// command is returned as an *ExitError.
+func Exec(config *ExecConfig) error {
+	dockerClient, containerID, mountPath, err := findRunningContainer(config.Path, config.Worktree, config.NoWorktree, config.Runtime, config.Verbose)
+	if err != nil {
+		return err
+	}
+
+	devConfig, err := devcontainer.LoadConfig(mountPath)
+	if err != nil {
+		return fmt.Errorf("failed to load devcontainer config: %w", err)
+	}
+
+	runConfig := &RunConfig{
+		Command:            config.Command,
+		Verbose:            config.Verbose,
+		UntrustedWorkspace: config.UntrustedWorkspace,
+	}
+	refreshSession(dockerClient, containerID, runConfig)
+	return execInContainer(dockerClient, containerID, mountPath, devConfig, runConfig)
+}
+
+// findRunningContainer finds the container Run started for path and worktree, and the
+// directory it mounted: the worktree, or path itself
+func findRunningContainer(path, worktree string, skipWorktree bool, runtime string, verbose bool) (*docker.Client, string, string, error) {
+	workDir := path
+	if workDir == "" {
+		var err error
+		workDir, err = os.Getwd()
+		if err != nil {
+			return nil, "", "", fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+	workDir, err := filepath.Abs(workDir)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	worktreeName, err := resolveWorktreeName(workDir, worktree, skipWorktree)
+	if err != nil {
+		return nil, "", "", err
+	}
+	dockerClient, err := docker.NewClientWithRuntime(runtime, verbose)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to initialize container runtime: %w", err)
+	}
+	containerName := ContainerName(dockerClient, workDir, worktreeName)
+
+	running, err := containerIsRunning(dockerClient, containerName)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to check container status: %w", err)
+	}
+	if !running {
+		startFlag := "--worktree=" + worktreeName
+		if worktreeName == noWorktree {
+			startFlag = "--no-worktree"
+		}
+		return nil, "", "", fmt.Errorf("%w for worktree '%s'\n\nStart one with: packnplay run %s <command>", ErrContainerNotRunning, worktreeName, startFlag)
+	}
+
+	containerID, err := getContainerID(dockerClient, containerName)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to get container ID: %w", err)
+	}
+
+	// Run from the directory Run mounted: the worktree, or workDir itself
+	mountPath := workDir
+	if worktreeName != noWorktree {
+		if exists, err := git.WorktreeExists(worktreeName); err == nil && exists {
+			if path, err := git.GetWorktreePath(worktreeName); err == nil {
+				mountPath = path
+			}
+		}
+	}
+	return dockerClient, containerID, mountPath, nil
+}
+
+// startLogCapture sets opts up to append the session's output to the container's log file
+// when log capture is on. Failing to open the log only warns, since the session can run
+// without it. The returned function closes the log.
+func startLogCapture(config *RunConfig, containerName string, opts *ptyexec.Options) func() {
+	if !config.LogCapture.Enabled {
+		return func() {}
+	}
+	maxSize, maxFiles, err := config.LogCapture.Limits()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not capturing logs: %v\n", err)
+		return func() {}
+	}
+	log, err := logcapture.Open(logcapture.Path(containerName), maxSize, maxFiles)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not capturing logs: %v\n", err)
+		return func() {}
+	}
+	log.StartSession(config.Command)
+
+	if opts.Output != nil {
+		opts.Output = io.MultiWriter(opts.Output, log)
+	} else {
+		opts.Output = log
+	}
+	if config.Verbose {
+		fmt.Fprintf(os.Stderr, "Capturing output to %s\n", logcapture.Path(containerName))
+	}
+	return func() {
+		if err := log.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: captured log may be incomplete: %v\n", err)
+		}
+	}
+}
+
+// startRecording sets opts up to record the session to config.RecordFile, if set. The
+// returned function finishes the recording.
+func startRecording(config *RunConfig, opts *ptyexec.Options) (func(), error) {
+	if config.RecordFile == "" {
+		return func() {}, nil
+	}
+
+	width, height, err := term.GetSize(os.Stdout.Fd())
+	if err != nil {
+		width, height = 80, 24
+	}
+	recorder, err := recording.Create(config.RecordFile, width, height, strings.Join(config.Command, " "))
+	if err != nil {
+		return nil, err
+	}
+	opts.Output = recorder
+	opts.OnResize = recorder.Resize
+	if config.Verbose {
+		fmt.Fprintf(os.Stderr, "Recording session to %s\n", config.RecordFile)
+	}
+
+	return func() {
+		if err := recorder.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: recording may be incomplete: %v\n", err)
+		}
+	}, nil
+}
+
+// stopTimedOutCommand stops a command that ran past its timeout: the whole container when
+// stopContainer is set, otherwise the command's process group (SIGTERM, then SIGKILL if it
+// is still running after timeoutGrace). done is closed once the command has exited.
+func stopTimedOutCommand(dockerClient *docker.Client, containerID, pidFile string, stopContainer bool, done <-chan struct{}) {
+	if stopContainer {
+		if _, err := dockerClient.Run("stop", containerID); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to stop container: %v\r\n", err)
+		}
+		return
+	}
+
+	_, _ = dockerClient.Run(append([]string{"exec", containerID}, signalCommand(pidFile, "TERM")...)...)
+	select {
+	case <-done:
+	case <-time.After(timeoutGrace):
+		_, _ = dockerClient.Run(append([]string{"exec", containerID}, signalCommand(pidFile, "KILL")...)...)
+	}
+}
+
+// withPIDFile wraps command so it records its PID inside the container in pidFile. Killing
+// the docker exec client leaves the command running, so timeouts signal it directly.
+func withPIDFile(pidFile string, command []string) []string {
+	return append([]string{"sh", "-c", `echo $$ > "$0" && exec "$@"`, pidFile}, command...)
+}
+
+// signalCommand returns a command that sends signal to the process group recorded in
+// pidFile, or to the process alone when it doesn't lead a group
+func signalCommand(pidFile, signal string) []string {
+	script := fmt.Sprintf(`pid=$(cat "$0" 2>/dev/null) || exit 0; kill -%[1]s -"$pid" 2>/dev/null || kill -%[1]s "$pid" 2>/dev/null; true`, signal)
+	return []string{"sh", "-c", script, pidFile}
+}
+
+// remoteEnvArgs resolves devcontainer.json remoteEnv for docker exec. Variables also set
+// with --env are left out so flags keep the final say. Returns KEY=value pairs and the
+// names remoteEnv sets to null.
+func remoteEnvArgs(dockerClient *docker.Client, containerID string, devConfig *devcontainer.Config, config *RunConfig) (env []string, unset []string) {
+	if devConfig == nil || len(devConfig.RemoteEnv) == 0 {
+		return nil, nil
+	}
+
+	var lookup devcontainer.LookupFunc
+	if devConfig.NeedsContainerEnv() {
+		containerEnv, err := inspectContainerEnv(dockerClient, containerID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to read container environment for remoteEnv: %v\n", err)
+		}
+		lookup = func(name string) (string, bool) {
+			value, ok := containerEnv[name]
+			return value, ok
+		}
+	}
+	return resolveRemoteEnv(devConfig, config, lookup)
+}
+
+// resolveRemoteEnv expands remoteEnv with lookup for ${containerEnv:VAR}, leaving out
+// variables set with --env
+func resolveRemoteEnv(devConfig *devcontainer.Config, config *RunConfig, lookup devcontainer.LookupFunc) (env []string, unset []string) {
+	userSet := make(map[string]bool)
+	for _, entry := range config.Env {
+		key, _, _ := strings.Cut(entry, "=")
+		userSet[key] = true
+	}
+
+	resolved, nulls := devConfig.RemoteEnvArgs(config.localEnv(), lookup)
+	for _, entry := range resolved {
+		key, _, _ := strings.Cut(entry, "=")
+		if userSet[key] {
+			continue
+		}
+		env = append(env, entry)
+	}
+	for _, key := range nulls {
+		if !userSet[key] {
+			unset = append(unset, key)
+		}
+	}
+	return env, unset
+}
+
+// inspectContainerEnv returns the environment the container was created with
+func inspectContainerEnv(dockerClient *docker.Client, containerID string) (map[string]string, error) {
+	info, err := dockerClient.InspectContainer(containerID)
+	if err != nil {
+		return nil, err
+	}
+	return envMap(info.Config.Env), nil
+}
+
+// envMap turns a list of KEY=value pairs into a map
+func envMap(entries []string) map[string]string {
+	env := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			env[key] = value
+		}
+	}
+	return env
+}
+
+// unsetArgs turns variable names into env(1) -u arguments
+func unsetArgs(names []string) []string {
+	var args []string
+	for _, name := range names {
+		args = append(args, "-u", name)
+	}
+	return args
+}
@@ -0,0 +1,255 @@
+package runner
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/devcontainer"
+	"github.com/obra/packnplay/pkg/events"
+	"github.com/obra/packnplay/pkg/logcapture"
+	"github.com/obra/packnplay/pkg/ptyexec"
+)
+
+func TestRunConfigManaged(t *testing.T) {
+	tests := []struct {
+		name   string
+		config RunConfig
+		want   bool
+	}{
+		{"default", RunConfig{}, false},
+		{"managed exec", RunConfig{ManagedExec: true}, true},
+		{"recording", RunConfig{RecordFile: "session.cast"}, true},
+		{"timeout", RunConfig{Timeout: time.Minute}, true},
+		{"json events", RunConfig{Events: events.New(io.Discard)}, true},
+		{"auto commit", RunConfig{AutoCommit: true}, true},
+		{"log capture", RunConfig{LogCapture: config.LogCaptureConfig{Enabled: true}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.managed(); got != tt.want {
+				t.Errorf("managed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartLogCapture(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	var recorded strings.Builder
+	opts := ptyexec.Options{Output: &recorded}
+	cfg := &RunConfig{Command: []string{"claude"}, LogCapture: config.LogCaptureConfig{Enabled: true}}
+	stop := startLogCapture(cfg, "packnplay-app-main", &opts)
+	_, _ = opts.Output.Write([]byte("hello\n"))
+	stop()
+
+	if recorded.String() != "hello\n" {
+		t.Errorf("recording got %q, want the output too", recorded.String())
+	}
+	data, err := os.ReadFile(logcapture.Path("packnplay-app-main"))
+	if err != nil {
+		t.Fatalf("no captured log: %v", err)
+	}
+	if !strings.HasSuffix(string(data), "packnplay: claude ===\nhello\n") {
+		t.Errorf("captured log = %q", data)
+	}
+
+	opts = ptyexec.Options{}
+	startLogCapture(&RunConfig{}, "packnplay-app-main", &opts)()
+	if opts.Output != nil {
+		t.Error("startLogCapture() set an output with log capture off")
+	}
+}
+
+func TestExecTTYFlags(t *testing.T) {
+	if got := execTTYFlags(true); got != "-it" {
+		t.Errorf("execTTYFlags(true) = %q, want -it", got)
+	}
+	// Piped stdin and redirected output still get stdin attached, but no TTY
+	if got := execTTYFlags(false); got != "-i" {
+		t.Errorf("execTTYFlags(false) = %q, want -i", got)
+	}
+}
+
+func TestTimeoutSignalsCommand(t *testing.T) {
+	for _, groupLeader := range []bool{true, false} {
+		name := "process"
+		if groupLeader {
+			name = "process group"
+		}
+		t.Run(name, func(t *testing.T) {
+			pidFile := filepath.Join(t.TempDir(), "exec.pid")
+
+			args := withPIDFile(pidFile, []string{"sleep", "30"})
+			cmd := exec.Command(args[0], args[1:]...)
+			cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: groupLeader}
+			if err := cmd.Start(); err != nil {
+				t.Fatalf("failed to start command: %v", err)
+			}
+			defer func() { _ = cmd.Process.Kill() }()
+
+			// The wrapper execs the command, so the recorded PID is the command itself
+			var data []byte
+			for i := 0; i < 100 && len(data) == 0; i++ {
+				data, _ = os.ReadFile(pidFile)
+				time.Sleep(10 * time.Millisecond)
+			}
+			if strings.TrimSpace(string(data)) != strconv.Itoa(cmd.Process.Pid) {
+				t.Fatalf("pid file = %q, want %d", data, cmd.Process.Pid)
+			}
+
+			signal := signalCommand(pidFile, "TERM")
+			if out, err := exec.Command(signal[0], signal[1:]...).CombinedOutput(); err != nil {
+				t.Fatalf("signal command failed: %v: %s", err, out)
+			}
+
+			err := cmd.Wait()
+			exitErr, ok := err.(*exec.ExitError)
+			if !ok {
+				t.Fatalf("Wait() error = %v, want the command to be killed", err)
+			}
+			status := exitErr.Sys().(syscall.WaitStatus)
+			if !status.Signaled() || status.Signal() != syscall.SIGTERM {
+				t.Errorf("command status = %v, want killed by SIGTERM", status)
+			}
+		})
+	}
+}
+
+func TestSignalCommandMissingPIDFile(t *testing.T) {
+	signal := signalCommand(filepath.Join(t.TempDir(), "missing.pid"), "KILL")
+	if out, err := exec.Command(signal[0], signal[1:]...).CombinedOutput(); err != nil {
+		t.Errorf("signal command failed without a pid file: %v: %s", err, out)
+	}
+}
+
+func TestRemoteEnvArgs(t *testing.T) {
+	debug, region := "1", "us-east-1"
+	devConfig := &devcontainer.Config{RemoteEnv: map[string]*string{
+		"DEBUG":      &debug,
+		"AWS_REGION": &region,
+		"HTTP_PROXY": nil,
+		"NO_PROXY":   nil,
+	}}
+	config := &RunConfig{Env: []string{"AWS_REGION=eu-west-1", "NO_PROXY"}}
+
+	// No ${containerEnv:...} references, so the container is never inspected
+	env, unset := remoteEnvArgs(nil, "container", devConfig, config)
+	if !reflect.DeepEqual(env, []string{"DEBUG=1"}) {
+		t.Errorf("remoteEnvArgs() env = %v, want --env to win for AWS_REGION", env)
+	}
+	if !reflect.DeepEqual(unset, []string{"HTTP_PROXY"}) {
+		t.Errorf("remoteEnvArgs() unset = %v, want --env to win for NO_PROXY", unset)
+	}
+	if got := unsetArgs(unset); !reflect.DeepEqual(got, []string{"-u", "HTTP_PROXY"}) {
+		t.Errorf("unsetArgs() = %v", got)
+	}
+
+	if env, unset := remoteEnvArgs(nil, "container", &devcontainer.Config{}, config); env != nil || unset != nil {
+		t.Errorf("remoteEnvArgs() without remoteEnv = %v, %v; want nothing", env, unset)
+	}
+}
+
+func TestRemoteEnvArgsUntrusted(t *testing.T) {
+	t.Setenv("PACKNPLAY_TEST_TOKEN", "host-secret")
+	token := "${localEnv:PACKNPLAY_TEST_TOKEN}"
+	fallback := "${localEnv:PACKNPLAY_TEST_TOKEN:none}"
+	devConfig := &devcontainer.Config{RemoteEnv: map[string]*string{"TOKEN": &token, "FALLBACK": &fallback}}
+
+	env, _ := remoteEnvArgs(nil, "container", devConfig, &RunConfig{})
+	if want := []string{"FALLBACK=host-secret", "TOKEN=host-secret"}; !reflect.DeepEqual(env, want) {
+		t.Errorf("remoteEnvArgs() trusted = %v, want %v", env, want)
+	}
+	env, _ = remoteEnvArgs(nil, "container", devConfig, &RunConfig{UntrustedWorkspace: true})
+	if want := []string{"FALLBACK=none", "TOKEN="}; !reflect.DeepEqual(env, want) {
+		t.Errorf("remoteEnvArgs() untrusted = %v, want %v", env, want)
+	}
+}
+
+func TestHostEnvByName(t *testing.T) {
+	token := "Bearer ${localEnv:API_TOKEN}"
+	debug := "1"
+	devConfig := &devcontainer.Config{RemoteEnv: map[string]*string{"AUTH": &token, "DEBUG": &debug}}
+
+	args, hostEnv := hostEnvByName([]string{"AUTH=Bearer abc", "DEBUG=1"}, devConfig)
+	if want := []string{"AUTH", "DEBUG=1"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("hostEnvByName() args = %v, want %v", args, want)
+	}
+	if want := []string{"AUTH=Bearer abc"}; !reflect.DeepEqual(hostEnv, want) {
+		t.Errorf("hostEnvByName() hostEnv = %v, want %v", hostEnv, want)
+	}
+	if args, hostEnv := hostEnvByName([]string{"DEBUG=1"}, nil); !reflect.DeepEqual(args, []string{"DEBUG=1"}) || hostEnv != nil {
+		t.Errorf("hostEnvByName() without devcontainer.json = %v, %v", args, hostEnv)
+	}
+}
+
+func TestEnvMap(t *testing.T) {
+	env := envMap([]string{"PATH=/usr/local/bin:/usr/bin", "EMPTY=", "OPTS=a=b", "MALFORMED"})
+	want := map[string]string{"PATH": "/usr/local/bin:/usr/bin", "EMPTY": "", "OPTS": "a=b"}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("envMap() = %v, want %v", env, want)
+	}
+}
+
+func TestEnvNames(t *testing.T) {
+	if got, want := envNames([]string{"GH_TOKEN=ghs_x", "EMPTY=", "OPTS=a=b"}), []string{"GH_TOKEN", "EMPTY", "OPTS"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("envNames() = %v, want %v", got, want)
+	}
+}
+
+func TestResolveWorktreeName(t *testing.T) {
+	plainDir := t.TempDir()
+	repoDir := t.TempDir()
+	if out, err := exec.Command("git", "-C", repoDir, "init", "-q", "-b", "feature-x").CombinedOutput(); err != nil {
+		t.Skipf("git init failed: %v: %s", err, out)
+	}
+
+	tests := []struct {
+		name         string
+		dir          string
+		worktree     string
+		skipWorktree bool
+		want         string
+		wantErr      bool
+	}{
+		{"no-worktree flag", repoDir, "", true, noWorktree, false},
+		{"not a git repo", plainDir, "", false, noWorktree, false},
+		{"worktree outside git repo", plainDir, "feature", false, "", true},
+		{"explicit worktree", repoDir, "bugfix", false, "bugfix", false},
+		{"current branch", repoDir, "", false, "feature-x", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveWorktreeName(tt.dir, tt.worktree, tt.skipWorktree)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveWorktreeName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveWorktreeName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultShellCommand(t *testing.T) {
+	cmd := exec.Command(DefaultShellCommand[0], DefaultShellCommand[1:]...)
+	cmd.Stdin = strings.NewReader("echo shell-ok\n")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("shell command failed: %v: %s", err, out)
+	}
+	if !strings.Contains(string(out), "shell-ok") {
+		t.Errorf("shell output = %q, want it to run commands from stdin", out)
+	}
+}
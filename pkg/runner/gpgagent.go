@@ -0,0 +1,144 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/obra/packnplay/pkg/docker"
+)
+
+// containerGPGAgentSocket is where the host gpg-agent's extra socket appears in the
+// container, and containerGPGPublicKeys the host's exported public keys, which gpg needs
+// to know which key the agent should sign with
+const (
+	containerGPGAgentSocket = "/tmp/packnplay-agent/gpg/S.gpg-agent.extra"
+	containerGPGPublicKeys  = "/tmp/packnplay-gpg-pubkeys.gpg"
+)
+
+// gpgAgentSetup points the container user's gpg at the forwarded agent. S.gpg-agent is
+// written as an Assuan redirect file rather than mounted, since ~/.gnupg has to belong to
+// the user, and no-autostart stops gpg from starting an agent of its own in its place.
+// It does nothing when no agent was forwarded.
+var gpgAgentSetup = strings.Join([]string{
+	`if [ -S ` + containerGPGAgentSocket + ` ]; then`,
+	`mkdir -p "$HOME/.gnupg" && chmod 700 "$HOME/.gnupg" &&`,
+	`printf '%%Assuan%%\nsocket=` + containerGPGAgentSocket + `\n' > "$HOME/.gnupg/S.gpg-agent" &&`,
+	`{ grep -qsx no-autostart "$HOME/.gnupg/gpg.conf" || echo no-autostart >> "$HOME/.gnupg/gpg.conf"; } &&`,
+	`gpg --batch --quiet --import ` + containerGPGPublicKeys + ` 2>/dev/null ||`,
+	`echo "packnplay: failed to set up gpg-agent forwarding" >&2;`,
+	`fi`,
+}, "\n")
+
+// gpgAgentArgs returns the run arguments that forward the host gpg-agent into the
+// container, so commits can be signed without the secret keys ever leaving the host.
+// prepare finds the agent's extra socket and exports the public keys; it is only called
+// where the socket can be mounted. When the agent can't be forwarded it returns a warning.
+func gpgAgentArgs(runtime string, isLinux, remoteHost bool, prepare func() (socket, publicKeys string, err error)) ([]string, string) {
+	switch {
+	case remoteHost:
+		return nil, "Warning: the gpg-agent can't be forwarded to a remote host, skipping it"
+	case runtime == "container":
+		return nil, "Warning: Apple Container can't forward the gpg-agent, skipping it"
+	case !isLinux:
+		return nil, "Warning: the gpg-agent can only be forwarded from a Linux host, since host sockets can't be mounted through Docker Desktop's VM; skipping it"
+	}
+
+	socket, publicKeys, err := prepare()
+	if err != nil {
+		return nil, fmt.Sprintf("Warning: not forwarding the gpg-agent: %v", err)
+	}
+	return []string{
+		"-v", fmt.Sprintf("%s:%s", socket, containerGPGAgentSocket),
+		"-v", fmt.Sprintf("%s:%s:ro", publicKeys, containerGPGPublicKeys),
+	}, ""
+}
+
+// prepareGPGAgent makes sure the host gpg-agent is running and returns its extra socket,
+// which offers signing but not key management, along with a file of the host's exported
+// public keys
+func prepareGPGAgent() (string, string, error) {
+	socket, err := gpgExtraSocket()
+	if err != nil {
+		return "", "", err
+	}
+
+	keys, err := exec.Command("gpg", "--batch", "--export").Output()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to export public keys: %w", err)
+	}
+	if len(keys) == 0 {
+		return "", "", fmt.Errorf("gpg has no keys to sign with")
+	}
+	publicKeys, err := writeGPGPublicKeys(keys)
+	if err != nil {
+		return "", "", err
+	}
+	return socket, publicKeys, nil
+}
+
+// gpgExtraSocket starts the host gpg-agent if it isn't running and returns its extra socket
+func gpgExtraSocket() (string, error) {
+	if _, err := exec.LookPath("gpgconf"); err != nil {
+		return "", fmt.Errorf("gpgconf not found; is GnuPG installed?")
+	}
+	output, err := exec.Command("gpgconf", "--list-dirs", "agent-extra-socket").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the gpg-agent extra socket: %w", err)
+	}
+	socket := strings.TrimSpace(string(output))
+	_ = exec.Command("gpgconf", "--launch", "gpg-agent").Run()
+	if !fileExists(socket) {
+		return "", fmt.Errorf("gpg-agent extra socket %s does not exist; check extra-socket in gpg-agent.conf", socket)
+	}
+	return socket, nil
+}
+
+// writeGPGPublicKeys saves exported public keys under the packnplay data directory. The
+// file is replaced rather than rewritten, so running containers keep the copy they mounted.
+func writeGPGPublicKeys(keys []byte) (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	dir := filepath.Join(dataHome, "packnplay", "gpg")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	// Readable by whichever user the container runs as
+	path := filepath.Join(dir, "pubkeys.gpg")
+	tmp := filepath.Join(dir, ".pubkeys.gpg.tmp")
+	if err := os.WriteFile(tmp, keys, 0644); err != nil {
+		return "", fmt.Errorf("failed to write public keys: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return "", fmt.Errorf("failed to write public keys: %w", err)
+	}
+	return path, nil
+}
+
+// setupGPGAgent runs gpgAgentSetup as user in a started container
+func setupGPGAgent(dockerClient *docker.Client, containerID, user string, verbose bool) {
+	output, err := dockerClient.Run("exec", "-u", user, containerID, "sh", "-c", gpgAgentSetup)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to set up gpg-agent forwarding: %v\n%s", err, output)
+	} else if strings.Contains(output, "packnplay: ") {
+		fmt.Fprint(os.Stderr, "Warning: "+strings.TrimPrefix(output, "packnplay: "))
+	} else if verbose {
+		fmt.Fprintf(os.Stderr, "Forwarded gpg-agent into container\n")
+	}
+}
+
+// gpgAgentCommand wraps the command of a --rm container so gpgAgentSetup runs first,
+// since nothing can be exec'd in it before it starts
+func gpgAgentCommand(command []string) []string {
+	return append([]string{"sh", "-c", gpgAgentSetup + "\nexec \"$@\"", "sh"}, command...)
+}
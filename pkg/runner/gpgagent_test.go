@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGPGAgentArgs(t *testing.T) {
+	prepared := func() (string, string, error) {
+		return "/run/user/1000/gnupg/S.gpg-agent.extra", "/data/pubkeys.gpg", nil
+	}
+	failed := func() (string, string, error) { return "", "", errors.New("gpgconf not found") }
+	forward := []string{
+		"-v", "/run/user/1000/gnupg/S.gpg-agent.extra:" + containerGPGAgentSocket,
+		"-v", "/data/pubkeys.gpg:" + containerGPGPublicKeys + ":ro",
+	}
+
+	tests := []struct {
+		name        string
+		runtime     string
+		isLinux     bool
+		remoteHost  bool
+		prepare     func() (string, string, error)
+		wantArgs    []string
+		wantWarning string
+	}{
+		{"linux mounts the extra socket", "docker", true, false, prepared, forward, ""},
+		{"linux podman", "podman", true, false, prepared, forward, ""},
+		{"no agent", "docker", true, false, failed, nil, "gpgconf not found"},
+		{"docker desktop", "docker", false, false, prepared, nil, "Linux host"},
+		{"apple container", "container", false, false, prepared, nil, "Apple Container"},
+		{"remote host", "docker", true, true, prepared, nil, "remote host"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, warning := gpgAgentArgs(tt.runtime, tt.isLinux, tt.remoteHost, tt.prepare)
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("gpgAgentArgs() args = %q, want %q", args, tt.wantArgs)
+			}
+			if (tt.wantWarning == "") != (warning == "") || !strings.Contains(warning, tt.wantWarning) {
+				t.Errorf("gpgAgentArgs() warning = %q, want one mentioning %q", warning, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestWriteGPGPublicKeys(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	path, err := writeGPGPublicKeys([]byte("old keys"))
+	if err != nil {
+		t.Fatalf("writeGPGPublicKeys() error = %v", err)
+	}
+	mounted, _ := os.Open(path)
+	defer func() { _ = mounted.Close() }()
+
+	if _, err := writeGPGPublicKeys([]byte("new keys")); err != nil {
+		t.Fatalf("writeGPGPublicKeys() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new keys" {
+		t.Errorf("public keys = %q", data)
+	}
+	// A container that mounted the old file keeps seeing it whole
+	old := make([]byte, 16)
+	n, _ := mounted.Read(old)
+	if string(old[:n]) != "old keys" {
+		t.Errorf("replaced file reads %q", old[:n])
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0644 {
+		t.Errorf("public keys mode = %v", info.Mode().Perm())
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("left %d files behind", len(entries))
+	}
+}
+
+func TestGPGAgentCommand(t *testing.T) {
+	if _, err := os.Stat(containerGPGAgentSocket); err == nil {
+		t.Skip(containerGPGAgentSocket + " exists on this host")
+	}
+	home := t.TempDir()
+	command := gpgAgentCommand([]string{"echo", "hello world"})
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Env = append(os.Environ(), "HOME="+home)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("command failed: %v\n%s", err, output)
+	}
+	if string(output) != "hello world\n" {
+		t.Errorf("output = %q, want the command's own", output)
+	}
+	// Without a forwarded agent the setup leaves gpg alone
+	if _, err := os.Stat(filepath.Join(home, ".gnupg")); !os.IsNotExist(err) {
+		t.Errorf("setup touched ~/.gnupg without an agent: %v", err)
+	}
+}
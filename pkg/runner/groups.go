@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// dockerSocketName is the file name of the Docker daemon socket
+const dockerSocketName = "docker.sock"
+
+// supplementalGroups returns the --group-add values for a run: the configured groups
+// plus the owning group of any mounted Docker socket, so the container user can use it
+// without being root. Under rootless Docker the socket belongs to the host user, whose
+// group is root's group (0) inside the container. Duplicates are dropped, order is
+// preserved.
+func supplementalGroups(groups []string, mounts []string, rootlessDocker bool) []string {
+	var result []string
+	seen := make(map[string]bool)
+	add := func(group string) {
+		group = strings.TrimSpace(group)
+		if group != "" && !seen[group] {
+			seen[group] = true
+			result = append(result, group)
+		}
+	}
+
+	for _, group := range groups {
+		add(group)
+	}
+
+	for _, mount := range mounts {
+		hostPath, _, _ := strings.Cut(mount, ":")
+		if filepath.Base(hostPath) != dockerSocketName {
+			continue
+		}
+		// Group names differ between host and image, so use the socket's numeric GID
+		if gid, ok := fileGroupID(hostPath); ok {
+			if rootlessDocker {
+				add("0")
+			} else {
+				add(strconv.FormatUint(uint64(gid), 10))
+			}
+		}
+	}
+
+	return result
+}
+
+// fileGroupID returns the owning group ID of path
+func fileGroupID(path string) (uint32, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return stat.Gid, true
+}
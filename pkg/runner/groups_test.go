@@ -0,0 +1,57 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestSupplementalGroups(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "docker.sock")
+	if err := os.WriteFile(socketPath, nil, 0660); err != nil {
+		t.Fatal(err)
+	}
+	gid := strconv.Itoa(os.Getgid())
+
+	tests := []struct {
+		name     string
+		groups   []string
+		mounts   []string
+		rootless bool
+		want     []string
+	}{
+		{
+			name:   "configured groups are deduplicated",
+			groups: []string{"video", " render ", "video", ""},
+			want:   []string{"video", "render"},
+		},
+		{
+			name:   "docker socket mount adds its GID",
+			groups: []string{"video"},
+			mounts: []string{socketPath + ":/var/run/docker.sock", "/tmp/data:/data:ro"},
+			want:   []string{"video", gid},
+		},
+		{
+			name:     "rootless docker socket is root's group",
+			mounts:   []string{socketPath + ":/var/run/docker.sock"},
+			rootless: true,
+			want:     []string{"0"},
+		},
+		{
+			name:   "missing socket is ignored",
+			mounts: []string{"/nonexistent/docker.sock:/var/run/docker.sock"},
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := supplementalGroups(tt.groups, tt.mounts, tt.rootless)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("supplementalGroups() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/logcapture"
+)
+
+// LogsConfig selects the container output Logs shows
+type LogsConfig struct {
+	Container  string // a container by name, instead of the one for Path and Worktree
+	Path       string
+	Worktree   string
+	NoWorktree bool
+	Runtime    string
+	All        bool
+	Follow     bool
+	Since      string
+	Captured   bool // show the log saved by log capture instead of the runtime's logs
+	Verbose    bool
+}
+
+// Logs shows the stdout and stderr of the container for a directory and worktree, or with
+// All of every packnplay-managed container, each line prefixed with its container name.
+// With Captured, or when the container is gone but its output was captured, the captured
+// log is shown instead. An interrupt while following ends the stream without an error.
+func Logs(config *LogsConfig) error {
+	dockerClient, err := docker.NewClientWithRuntime(config.Runtime, config.Verbose)
+	if err != nil {
+		return fmt.Errorf("failed to initialize container runtime: %w", err)
+	}
+
+	var names []string
+	if config.All {
+		output, err := dockerClient.Run("ps", "--filter", "label=managed-by=packnplay", "--format", "{{.Names}}")
+		if err != nil {
+			return fmt.Errorf("failed to list containers: %w", err)
+		}
+		for _, name := range strings.Split(output, "\n") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			return fmt.Errorf("no packnplay-managed containers running")
+		}
+	} else {
+		name, err := logsContainerName(dockerClient, config)
+		if config.Captured && name != "" {
+			return printCapturedLog(name, os.Stdout)
+		}
+		if err != nil {
+			// The output of a removed container may still have been captured
+			if name != "" && fileExists(logcapture.Path(name)) {
+				fmt.Fprintf(os.Stderr, "%v; showing its captured log %s\n", err, logcapture.Path(name))
+				return printCapturedLog(name, os.Stdout)
+			}
+			return err
+		}
+		names = []string{name}
+	}
+
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+	defer signal.Stop(interrupted)
+
+	if !config.All {
+		err = streamLogs(dockerClient, names[0], config, os.Stdout, os.Stderr)
+	} else {
+		err = streamPrefixedLogs(dockerClient, names, config)
+	}
+
+	select {
+	case <-interrupted:
+		return nil
+	default:
+		return err
+	}
+}
+
+// logsContainerName resolves the container Run started for the configured directory and
+// worktree. Stopped containers that weren't removed still have logs, so it only has to exist.
+// When it doesn't, its name is returned along with the error.
+func logsContainerName(dockerClient *docker.Client, config *LogsConfig) (string, error) {
+	if config.Container != "" {
+		if _, err := dockerClient.Run("inspect", "--format", "{{.Name}}", config.Container); err != nil {
+			return config.Container, fmt.Errorf("failed to inspect container %s: %w", config.Container, err)
+		}
+		return config.Container, nil
+	}
+
+	workDir := config.Path
+	if workDir == "" {
+		var err error
+		workDir, err = os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+	workDir, err := filepath.Abs(workDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	worktreeName, err := resolveWorktreeName(workDir, config.Worktree, config.NoWorktree)
+	if err != nil {
+		return "", err
+	}
+	containerName := ContainerName(dockerClient, workDir, worktreeName)
+
+	if _, err := dockerClient.Run("inspect", "--format", "{{.Name}}", containerName); errors.Is(err, docker.ErrNoSuchContainer) {
+		return containerName, fmt.Errorf("no container found for worktree '%s'", worktreeName)
+	} else if err != nil {
+		return containerName, fmt.Errorf("failed to inspect container %s: %w", containerName, err)
+	}
+	return containerName, nil
+}
+
+// printCapturedLog writes the captured log of containerName to w, rotated logs first so
+// the output is in order
+func printCapturedLog(containerName string, w io.Writer) error {
+	path := logcapture.Path(containerName)
+	if !fileExists(path) {
+		return fmt.Errorf("no captured log for %s (enable log_capture in the config, or run with --capture-logs)", containerName)
+	}
+
+	rotated, _ := filepath.Glob(path + ".*")
+	sort.Slice(rotated, func(i, j int) bool { return rotationIndex(rotated[i]) > rotationIndex(rotated[j]) })
+	for _, file := range append(rotated, path) {
+		f, err := os.Open(file)
+		if err != nil {
+			return fmt.Errorf("failed to read captured log: %w", err)
+		}
+		_, err = io.Copy(w, f)
+		_ = f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rotationIndex returns the number a rotated log ends with, 0 if it has none
+func rotationIndex(path string) int {
+	n, _ := strconv.Atoi(path[strings.LastIndex(path, ".")+1:])
+	return n
+}
+
+// logsArgs builds the docker logs invocation for one container
+func logsArgs(containerName string, follow bool, since string) []string {
+	args := []string{"logs"}
+	if follow {
+		args = append(args, "--follow")
+	}
+	if since != "" {
+		args = append(args, "--since", since)
+	}
+	return append(args, containerName)
+}
+
+// streamLogs runs docker logs for one container, keeping stdout and stderr separate
+func streamLogs(dockerClient *docker.Client, containerName string, config *LogsConfig, stdout, stderr io.Writer) error {
+	args := logsArgs(containerName, config.Follow, config.Since)
+	if config.Verbose {
+		fmt.Fprintf(os.Stderr, "+ %s %v\n", dockerClient.Command(), args)
+	}
+
+	cmd := exec.Command(dockerClient.Command(), args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to read logs for %s: %w", containerName, err)
+	}
+	return nil
+}
+
+// streamPrefixedLogs interleaves the logs of several containers line by line, prefixing
+// each line with its container name padded to a common width
+func streamPrefixedLogs(dockerClient *docker.Client, names []string, config *LogsConfig) error {
+	width := 0
+	for _, name := range names {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := make([]error, len(names))
+	for i, name := range names {
+		prefix := fmt.Sprintf("%-*s | ", width, name)
+		stdout := newPrefixWriter(&mu, os.Stdout, prefix)
+		stderr := newPrefixWriter(&mu, os.Stderr, prefix)
+
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			errs[i] = streamLogs(dockerClient, name, config, stdout, stderr)
+			stdout.Flush()
+			stderr.Flush()
+		}(i, name)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prefixWriter writes complete lines with a prefix. Writers sharing a mutex never
+// interleave within a line.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+	buf    []byte
+}
+
+func newPrefixWriter(mu *sync.Mutex, out io.Writer, prefix string) *prefixWriter {
+	return &prefixWriter{mu: mu, out: out, prefix: prefix}
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := w.writeLine(w.buf[:i+1]); err != nil {
+			return len(p), err
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes a trailing line that has no newline yet
+func (w *prefixWriter) Flush() {
+	if len(w.buf) > 0 {
+		_ = w.writeLine(append(w.buf, '\n'))
+		w.buf = nil
+	}
+}
+
+func (w *prefixWriter) writeLine(line []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := fmt.Fprintf(w.out, "%s%s", w.prefix, line)
+	return err
+}
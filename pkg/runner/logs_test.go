@@ -0,0 +1,96 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/logcapture"
+)
+
+func TestLogsArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		follow bool
+		since  string
+		want   []string
+	}{
+		{
+			name: "plain",
+			want: []string{"logs", "packnplay-app-main"},
+		},
+		{
+			name:   "follow",
+			follow: true,
+			want:   []string{"logs", "--follow", "packnplay-app-main"},
+		},
+		{
+			name:   "follow since",
+			follow: true,
+			since:  "10m",
+			want:   []string{"logs", "--follow", "--since", "10m", "packnplay-app-main"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := logsArgs("packnplay-app-main", tt.follow, tt.since)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("logsArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var mu sync.Mutex
+	var out bytes.Buffer
+	a := newPrefixWriter(&mu, &out, "a | ")
+	b := newPrefixWriter(&mu, &out, "b | ")
+
+	// Partial lines are held until they are complete
+	_, _ = a.Write([]byte("hel"))
+	_, _ = b.Write([]byte("one\ntwo\n"))
+	_, _ = a.Write([]byte("lo\nworld"))
+	a.Flush()
+	b.Flush()
+
+	want := "b | one\nb | two\na | hello\na | world\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestPrintCapturedLog(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	path := logcapture.Path("packnplay-app-main")
+
+	var out bytes.Buffer
+	if err := printCapturedLog("packnplay-app-main", &out); err == nil || !strings.Contains(err.Error(), "no captured log") {
+		t.Fatalf("printCapturedLog() without a log error = %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	for i, content := range []string{"newest\n", "older\n", "oldest\n"} {
+		file := path
+		if i > 0 {
+			file = fmt.Sprintf("%s.%d", path, i)
+		}
+		if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := printCapturedLog("packnplay-app-main", &out); err != nil {
+		t.Fatalf("printCapturedLog() error = %v", err)
+	}
+	if out.String() != "oldest\nolder\nnewest\n" {
+		t.Errorf("printCapturedLog() = %q", out.String())
+	}
+}
@@ -0,0 +1,163 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/container"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/git"
+	"github.com/obra/packnplay/pkg/logcapture"
+)
+
+// ContainerMigrations finds packnplay containers still named the way older versions
+// named them, without the project hash. Run keeps using such a name for as long as a
+// container has it, so stopped ones are renamed, along with the ~/.claude sandbox and
+// captured logs kept under their name. Running ones have to be stopped first.
+func ContainerMigrations(dockerClient *docker.Client) ([]config.Migration, error) {
+	infos, err := dockerClient.ListContainers(map[string][]string{"label": {"managed-by=packnplay"}}, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	return containerMigrations(infos, func(from, to string) error {
+		if output, err := dockerClient.Run("rename", from, to); err != nil {
+			return fmt.Errorf("failed to rename %s: %w\nDocker output:\n%s", from, err, output)
+		}
+		return moveNamedState(from, to)
+	}), nil
+}
+
+// containerMigrations plans the migrations of infos, all packnplay containers. rename
+// renames a stopped container and what is kept under its name.
+func containerMigrations(infos []docker.ContainerInfo, rename func(from, to string) error) []config.Migration {
+	names := make(map[string]bool)
+	for _, info := range infos {
+		names[info.Name] = true
+	}
+
+	var migrations []config.Migration
+	for _, info := range infos {
+		labels := info.Config.Labels
+		hostPath := labels["packnplay-host-path"]
+		worktree := labels["packnplay-worktree"]
+		if labels[container.ProjectHashLabel] != "" || worktree == "" {
+			continue
+		}
+
+		// Containers from before the host path was recorded can't be given the hash
+		if hostPath == "" {
+			if info.Name == container.LegacyContainerName(labels["packnplay-project"], worktree) {
+				migrations = append(migrations, config.Migration{
+					Subject: info.Name,
+					Problem: "was started by a version that didn't record the project path, so it can't be renamed",
+					Fix:     fmt.Sprintf("remove it with: docker rm -f %s (the next packnplay run starts a new one)", info.Name),
+				})
+			}
+			continue
+		}
+		if info.Name != container.LegacyContainerName(hostPath, worktree) {
+			continue
+		}
+
+		name := info.Name
+		target := container.GenerateContainerName(hostPath, worktree)
+		m := config.Migration{
+			Subject: name,
+			Problem: "has the name older versions used, without the project hash, so it can be mistaken for another project with the same directory name",
+		}
+		switch {
+		case info.State.Running:
+			m.Fix = fmt.Sprintf("stop it (packnplay stop --worktree=%s in %s), then run packnplay config doctor --fix again", worktree, hostPath)
+		case names[target]:
+			m.Fix = fmt.Sprintf("remove it with: docker rm %s (%s replaced it)", name, target)
+		default:
+			m.Fix = fmt.Sprintf("rename it to %s", target)
+			m.Apply = func() error { return rename(name, target) }
+		}
+		migrations = append(migrations, m)
+	}
+	return migrations
+}
+
+// moveNamedState moves the host state kept under a container's name, which outlives
+// it, to a new name: the ~/.claude sandbox and captured logs. What a running container
+// keeps (agent links, ssh keys) isn't there for a stopped one.
+func moveNamedState(from, to string) error {
+	var moves [][2]string
+	if fromDir, err := claudeSandboxDir(from); err == nil {
+		toDir, _ := claudeSandboxDir(to)
+		moves = append(moves, [2]string{fromDir, toDir})
+	}
+	logs, _ := filepath.Glob(logcapture.Path(from) + ".*")
+	for _, log := range append([]string{logcapture.Path(from)}, logs...) {
+		moves = append(moves, [2]string{log, logcapture.Path(to) + strings.TrimPrefix(log, logcapture.Path(from))})
+	}
+
+	for _, move := range moves {
+		if !fileExists(move[0]) || fileExists(move[1]) {
+			continue
+		}
+		if err := os.Rename(move[0], move[1]); err != nil {
+			return fmt.Errorf("failed to move %s: %w", move[0], err)
+		}
+	}
+	return nil
+}
+
+// WorktreeMigrations finds worktrees of the repository at repoDir that older versions
+// created next to it instead of in the worktrees directory. Worktrees no running
+// container has mounted are moved there; git keeps track of the move.
+func WorktreeMigrations(dockerClient *docker.Client, repoDir string) ([]config.Migration, error) {
+	worktrees, err := git.ListWorktrees(repoDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	root, err := git.WorktreesRoot()
+	if err != nil {
+		return nil, err
+	}
+	running, err := ProjectContainers(dockerClient, repoDir)
+	if err != nil {
+		return nil, err
+	}
+	busy := make(map[string]bool)
+	for _, c := range running {
+		busy[c.Config.Labels["packnplay-worktree"]] = true
+	}
+
+	return worktreeMigrations(repoDir, root, worktrees, busy, func(from, to string) error {
+		return git.MoveWorktree(repoDir, from, to)
+	}), nil
+}
+
+// worktreeMigrations plans moving the worktrees at their legacy path to root. busy has
+// the branches of worktrees a running container uses.
+func worktreeMigrations(repoDir, root string, worktrees []git.Worktree, busy map[string]bool, move func(from, to string) error) []config.Migration {
+	var migrations []config.Migration
+	for _, worktree := range worktrees {
+		if worktree.Branch == "" || filepath.Clean(worktree.Path) != git.LegacyWorktreePath(repoDir, worktree.Branch) {
+			continue
+		}
+
+		from := worktree.Path
+		target := filepath.Join(root, filepath.Base(repoDir), git.WorktreeDirName(worktree.Branch))
+		m := config.Migration{
+			Subject: from,
+			Problem: "is where older versions put worktrees, next to the project",
+		}
+		switch {
+		case busy[worktree.Branch]:
+			m.Fix = fmt.Sprintf("stop its container (packnplay stop --worktree=%s), then run packnplay config doctor --fix again", worktree.Branch)
+		case fileExists(target):
+			m.Fix = fmt.Sprintf("%s already exists; move the worktree by hand with git worktree move", target)
+		default:
+			m.Fix = fmt.Sprintf("move it to %s", target)
+			m.Apply = func() error { return move(from, target) }
+		}
+		migrations = append(migrations, m)
+	}
+	return migrations
+}
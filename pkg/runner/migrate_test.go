@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/container"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/git"
+	"github.com/obra/packnplay/pkg/logcapture"
+)
+
+func TestContainerMigrations(t *testing.T) {
+	info := func(name string, running bool, labels map[string]string) docker.ContainerInfo {
+		c := docker.ContainerInfo{Name: name}
+		c.State.Running = running
+		c.Config.Labels = labels
+		return c
+	}
+	legacyLabels := func(hostPath, worktree string) map[string]string {
+		return map[string]string{"managed-by": "packnplay", "packnplay-project": filepath.Base(hostPath), "packnplay-worktree": worktree, "packnplay-host-path": hostPath}
+	}
+	current := legacyLabels("/src/app", "main")
+	current[container.ProjectHashLabel] = container.ProjectHash("/src/app")
+
+	infos := []docker.ContainerInfo{
+		info(container.GenerateContainerName("/src/app", "main"), true, current),
+		info("packnplay-app-feature", false, legacyLabels("/src/app", "feature")),
+		info("packnplay-app-fix", true, legacyLabels("/src/app", "fix")),
+		info("packnplay-app-main", false, legacyLabels("/src/app", "main")),
+		info("packnplay-old-main", false, map[string]string{"managed-by": "packnplay", "packnplay-project": "old", "packnplay-worktree": "main"}),
+	}
+
+	var renamed []string
+	migrations := containerMigrations(infos, func(from, to string) error {
+		renamed = append(renamed, from+" -> "+to)
+		return nil
+	})
+
+	want := []struct {
+		subject string
+		fix     string
+		fixable bool
+	}{
+		{"packnplay-app-feature", "rename it to " + container.GenerateContainerName("/src/app", "feature"), true},
+		{"packnplay-app-fix", "stop it", false},
+		{"packnplay-app-main", "remove it", false},
+		{"packnplay-old-main", "remove it", false},
+	}
+	if len(migrations) != len(want) {
+		t.Fatalf("containerMigrations() = %+v, want %d", migrations, len(want))
+	}
+	for i, m := range migrations {
+		if m.Subject != want[i].subject || !strings.HasPrefix(m.Fix, want[i].fix) || (m.Apply != nil) != want[i].fixable {
+			t.Errorf("migration %d = %s: %s (fixable %v), want %s: %s (fixable %v)", i, m.Subject, m.Fix, m.Apply != nil, want[i].subject, want[i].fix, want[i].fixable)
+		}
+	}
+
+	if err := migrations[0].Apply(); err != nil {
+		t.Fatal(err)
+	}
+	if len(renamed) != 1 || renamed[0] != "packnplay-app-feature -> "+container.GenerateContainerName("/src/app", "feature") {
+		t.Errorf("renamed %v", renamed)
+	}
+}
+
+func TestMoveNamedState(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	sandbox, _ := claudeSandboxDir("packnplay-app-main")
+	if err := os.MkdirAll(sandbox, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(logcapture.Dir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, log := range []string{"packnplay-app-main.log", "packnplay-app-main.log.1", "packnplay-app-main-2.log"} {
+		if err := os.WriteFile(filepath.Join(logcapture.Dir(), log), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := moveNamedState("packnplay-app-main", "packnplay-app-f227ecb4-main"); err != nil {
+		t.Fatal(err)
+	}
+	moved, _ := claudeSandboxDir("packnplay-app-f227ecb4-main")
+	for _, path := range []string{moved, logcapture.Path("packnplay-app-f227ecb4-main"), logcapture.Path("packnplay-app-f227ecb4-main") + ".1", filepath.Join(logcapture.Dir(), "packnplay-app-main-2.log")} {
+		if !fileExists(path) {
+			t.Errorf("%s missing after the move", path)
+		}
+	}
+	if fileExists(sandbox) {
+		t.Errorf("%s left behind", sandbox)
+	}
+}
+
+func TestWorktreeMigrations(t *testing.T) {
+	root := t.TempDir()
+	taken := filepath.Join(root, "app", "taken")
+	if err := os.MkdirAll(taken, 0755); err != nil {
+		t.Fatal(err)
+	}
+	worktrees := []git.Worktree{
+		{Path: "/src/app", Branch: "main"},
+		{Path: "/src/app-feature-x", Branch: "feature/x"},
+		{Path: "/src/app-busy", Branch: "busy"},
+		{Path: "/src/app-taken", Branch: "taken"},
+		{Path: filepath.Join(root, "app", "current"), Branch: "current"},
+		{Path: "/src/app-detached"},
+	}
+
+	var moved []string
+	migrations := worktreeMigrations("/src/app", root, worktrees, map[string]bool{"busy": true}, func(from, to string) error {
+		moved = append(moved, from+" -> "+to)
+		return nil
+	})
+	if len(migrations) != 3 {
+		t.Fatalf("worktreeMigrations() = %+v, want 3", migrations)
+	}
+	fixable := []bool{true, false, false}
+	for i, m := range migrations {
+		if (m.Apply != nil) != fixable[i] {
+			t.Errorf("%s fixable = %v, want %v (%s)", m.Subject, m.Apply != nil, fixable[i], m.Fix)
+		}
+	}
+	if err := migrations[0].Apply(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "/src/app-feature-x -> " + filepath.Join(root, "app", "feature-x"); len(moved) != 1 || moved[0] != want {
+		t.Errorf("moved %v, want %s", moved, want)
+	}
+}
@@ -0,0 +1,41 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/egress"
+)
+
+// isolateNetwork returns the docker run flags for an isolated network mode. In allowlist
+// mode it starts the egress proxy first, so the container joins the proxy's internal
+// network and reaches the allowed domains through it. A dry run starts nothing.
+func isolateNetwork(dockerClient *docker.Client, containerName string, network config.NetworkConfig, dryRun, verbose bool) ([]string, error) {
+	if !dockerClient.Capabilities().Networks {
+		return nil, fmt.Errorf("network mode %s is not supported with %s", network.Mode, dockerClient.RuntimeName())
+	}
+
+	if network.Mode == config.NetworkNone {
+		return []string{"--network", "none"}, nil
+	}
+
+	internal := egress.Name(containerName)
+	if !dryRun {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Starting egress proxy allowing %s\n", strings.Join(network.Allow, ", "))
+		}
+		var err error
+		if internal, err = egress.Start(dockerClient, containerName, network.ProxyImage, network.Allow); err != nil {
+			return nil, err
+		}
+	}
+
+	args := []string{"--network", internal}
+	for _, env := range egress.EnvArgs() {
+		args = append(args, "-e", env)
+	}
+	return args, nil
+}
@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/obra/packnplay/pkg/container"
+	"github.com/obra/packnplay/pkg/docker"
+)
+
+// ProjectContainers lists the running containers Run started for the project at workDir,
+// in any worktree. Legacy containers without the project hash label are matched by
+// project name and host path.
+func ProjectContainers(dockerClient *docker.Client, workDir string) ([]docker.ContainerInfo, error) {
+	containers, err := dockerClient.ListContainers(map[string][]string{
+		"label": {"managed-by=packnplay", "packnplay-project=" + filepath.Base(workDir)},
+	}, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	hash := container.ProjectHash(workDir)
+	var matched []docker.ContainerInfo
+	for _, c := range containers {
+		labels := c.Config.Labels
+		if projectHash := labels[container.ProjectHashLabel]; projectHash != "" {
+			if projectHash == hash {
+				matched = append(matched, c)
+			}
+			continue
+		}
+		if hostPath := labels["packnplay-host-path"]; hostPath == "" || filepath.Clean(hostPath) == filepath.Clean(workDir) {
+			matched = append(matched, c)
+		}
+	}
+	return matched, nil
+}
+
+// PickContainer returns which of names to use, as picked on a terminal. Even a single
+// name is listed and has to be picked, since none of them was asked for by name or
+// branch. Without a terminal, names are an error listing them.
+func PickContainer(names []string) (string, error) {
+	return pickContainer(names, os.Stdin, os.Stderr, term.IsTerminal(os.Stdin.Fd()))
+}
+
+// pickContainer asks on out which of names to use, reading a number from in
+func pickContainer(names []string, in io.Reader, out io.Writer, interactive bool) (string, error) {
+	switch {
+	case len(names) == 0:
+		return "", ErrNoContainer
+	case !interactive:
+		return "", fmt.Errorf("the current branch has no container; pass one of these or --worktree:\n  %s", strings.Join(names, "\n  "))
+	}
+
+	for i, name := range names {
+		_, _ = fmt.Fprintf(out, "  %d) %s\n", i+1, name)
+	}
+	_, _ = fmt.Fprintf(out, "Which container [1-%d]? ", len(names))
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	n, err := strconv.Atoi(strings.TrimSpace(answer))
+	if err != nil || n < 1 || n > len(names) {
+		return "", fmt.Errorf("no container picked")
+	}
+	return names[n-1], nil
+}
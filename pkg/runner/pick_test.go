@@ -0,0 +1,47 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPickContainer(t *testing.T) {
+	names := []string{"packnplay-app-f227ecb4-main", "packnplay-app-f227ecb4-feature"}
+
+	tests := []struct {
+		name        string
+		names       []string
+		answer      string
+		interactive bool
+		want        string
+		wantErr     string
+	}{
+		{name: "only one is still confirmed", names: names[:1], answer: "1\n", interactive: true, want: "packnplay-app-f227ecb4-main"},
+		{name: "only one, not confirmed", names: names[:1], answer: "\n", interactive: true, wantErr: "no container picked"},
+		{name: "only one without a terminal", names: names[:1], wantErr: "pass one of these"},
+		{name: "picked by number", names: names, answer: "2\n", interactive: true, want: "packnplay-app-f227ecb4-feature"},
+		{name: "out of range", names: names, answer: "3\n", interactive: true, wantErr: "no container picked"},
+		{name: "no terminal", names: names, wantErr: "pass one of these"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := pickContainer(tt.names, strings.NewReader(tt.answer), &out, tt.interactive)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("pickContainer() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("pickContainer() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := pickContainer(nil, strings.NewReader(""), &bytes.Buffer{}, true); !errors.Is(err, ErrNoContainer) {
+		t.Errorf("pickContainer(nil) error = %v, want ErrNoContainer", err)
+	}
+}
@@ -0,0 +1,222 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/obra/packnplay/pkg/devcontainer"
+)
+
+// forwardPortMappings translates devcontainer forwardPorts into -p mappings bound to
+// localhost. Ports on other services, ports already published with -p, and ports
+// whose host side is taken are skipped with a reason.
+func forwardPortMappings(forwardPorts []devcontainer.ForwardPort, published []string, available func(port int) bool) (mappings []string, skipped []string) {
+	publishedPorts := make(map[int]bool)
+	for _, mapping := range published {
+		if port, ok := publishedContainerPort(mapping); ok {
+			publishedPorts[port] = true
+		}
+	}
+
+	for _, fp := range forwardPorts {
+		switch {
+		case fp.Host != "":
+			skipped = append(skipped, fmt.Sprintf("%s:%d (port on another service)", fp.Host, fp.Port))
+		case fp.Port <= 0 || fp.Port > 65535:
+			skipped = append(skipped, fmt.Sprintf("%d (invalid port)", fp.Port))
+		case publishedPorts[fp.Port]:
+			// Explicit -p for the same container port wins
+		case !available(fp.Port):
+			skipped = append(skipped, fmt.Sprintf("%d (host port already in use)", fp.Port))
+		default:
+			publishedPorts[fp.Port] = true
+			mappings = append(mappings, fmt.Sprintf("127.0.0.1:%d:%d", fp.Port, fp.Port))
+		}
+	}
+
+	return mappings, skipped
+}
+
+// publishedContainerPort extracts the container port from a -p mapping
+// ([hostIP:]hostPort:containerPort[/protocol] or just containerPort)
+func publishedContainerPort(mapping string) (int, bool) {
+	spec, _, _ := strings.Cut(mapping, "/")
+	if i := strings.LastIndex(spec, ":"); i >= 0 {
+		spec = spec[i+1:]
+	}
+	port, err := strconv.Atoi(spec)
+	return port, err == nil
+}
+
+// hostPortAvailable reports whether nothing is listening on the host's localhost port
+func hostPortAvailable(port int) bool {
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return false
+	}
+	_ = listener.Close()
+	return true
+}
+
+// portMapping is a -p mapping split into its parts. HostPort is 0 when the runtime
+// should pick one.
+type portMapping struct {
+	HostIP        string
+	HostPort      int
+	ContainerPort string
+	Protocol      string
+}
+
+// parsePortMapping parses [hostIP:][hostPort:]containerPort[/protocol]. Port ranges
+// aren't parsed and report false.
+func parsePortMapping(mapping string) (portMapping, bool) {
+	spec, protocol, _ := strings.Cut(mapping, "/")
+	m := portMapping{Protocol: protocol}
+	host := ""
+	if i := strings.LastIndex(spec, ":"); i >= 0 {
+		host, m.ContainerPort = spec[:i], spec[i+1:]
+	} else {
+		m.ContainerPort = spec
+	}
+	if port, err := strconv.Atoi(m.ContainerPort); err != nil || port <= 0 || port > 65535 {
+		return portMapping{}, false
+	}
+
+	hostPort := host
+	if i := strings.LastIndex(host, ":"); i >= 0 {
+		m.HostIP, hostPort = host[:i], host[i+1:]
+	}
+	if hostPort != "" {
+		port, err := strconv.Atoi(hostPort)
+		if err != nil || port < 0 || port > 65535 {
+			return portMapping{}, false
+		}
+		m.HostPort = port
+	}
+	return m, true
+}
+
+func (m portMapping) String() string {
+	s := fmt.Sprintf("%d:%s", m.HostPort, m.ContainerPort)
+	if m.HostIP != "" {
+		s = m.HostIP + ":" + s
+	}
+	if m.Protocol != "" {
+		s += "/" + m.Protocol
+	}
+	return s
+}
+
+// portChecker looks at host ports for resolvePublishPorts
+type portChecker struct {
+	available func(m portMapping) bool
+	free      func(m portMapping) (int, error)
+	// reassign asks whether a mapping whose host port is taken should move to a free one
+	reassign func(m portMapping) bool
+}
+
+// resolvePublishPorts checks that the host ports of -p mappings are free before the
+// container is started. Mappings without a host port, or with host port 0, get a free
+// one picked here so it can be printed and recorded; a taken host port is moved to a free
+// one when reassign agrees, and is an error otherwise. Mappings it can't parse, such as
+// port ranges, are passed through for the runtime to handle.
+func resolvePublishPorts(mappings []string, checker portChecker) (resolved []string, notes []string, err error) {
+	for _, mapping := range mappings {
+		m, ok := parsePortMapping(mapping)
+		if !ok {
+			resolved = append(resolved, mapping)
+			continue
+		}
+
+		reason := ""
+		switch {
+		case m.HostPort == 0:
+		case checker.available(m):
+			resolved = append(resolved, m.String())
+			continue
+		case checker.reassign(m):
+			reason = fmt.Sprintf(" (%d is in use)", m.HostPort)
+		default:
+			return nil, nil, fmt.Errorf("host port %d for %s is already in use; free it, publish on another host port, or use -p 0:%s to pick a free one", m.HostPort, mapping, m.ContainerPort)
+		}
+
+		port, err := checker.free(m)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to find a free host port for %s: %w", mapping, err)
+		}
+		m.HostPort = port
+		resolved = append(resolved, m.String())
+		notes = append(notes, fmt.Sprintf("Publishing container port %s on host port %d%s", m.ContainerPort, port, reason))
+	}
+	return resolved, notes, nil
+}
+
+// listenAddr is the host address a mapping binds, all interfaces when it names none
+func (m portMapping) listenAddr(port int) string {
+	return net.JoinHostPort(strings.Trim(m.HostIP, "[]"), strconv.Itoa(port))
+}
+
+// hostPortFree reports whether the mapping's host port can be bound on the host
+func hostPortFree(m portMapping) bool {
+	if m.Protocol == "udp" {
+		conn, err := net.ListenPacket("udp", m.listenAddr(m.HostPort))
+		if err != nil {
+			return false
+		}
+		_ = conn.Close()
+		return true
+	}
+	listener, err := net.Listen("tcp", m.listenAddr(m.HostPort))
+	if err != nil {
+		return false
+	}
+	_ = listener.Close()
+	return true
+}
+
+// freeHostPort asks the host for a port that is free for the mapping
+func freeHostPort(m portMapping) (int, error) {
+	if m.Protocol == "udp" {
+		conn, err := net.ListenPacket("udp", m.listenAddr(0))
+		if err != nil {
+			return 0, err
+		}
+		defer conn.Close()
+		return conn.LocalAddr().(*net.UDPAddr).Port, nil
+	}
+	listener, err := net.Listen("tcp", m.listenAddr(0))
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// confirmPortReassign asks on the terminal whether to move a mapping off a taken host
+// port. Without a terminal it doesn't.
+func confirmPortReassign(in io.Reader, out io.Writer, interactive bool) func(m portMapping) bool {
+	reader := bufio.NewReader(in)
+	return func(m portMapping) bool {
+		if !interactive {
+			return false
+		}
+		_, _ = fmt.Fprintf(out, "Host port %d is already in use. Publish container port %s on a free host port instead? [Y/n]: ", m.HostPort, m.ContainerPort)
+		answer, _ := reader.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "", "y", "yes":
+			return true
+		}
+		return false
+	}
+}
+
+// confirmPortReassignOnTerminal asks about taken host ports using the terminal
+func confirmPortReassignOnTerminal() func(m portMapping) bool {
+	return confirmPortReassign(os.Stdin, os.Stderr, term.IsTerminal(os.Stdin.Fd()))
+}
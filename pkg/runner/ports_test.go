@@ -0,0 +1,181 @@
+package runner
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/devcontainer"
+)
+
+func TestForwardPortMappings(t *testing.T) {
+	inUse := map[int]bool{5000: true}
+	available := func(port int) bool { return !inUse[port] }
+
+	forward := []devcontainer.ForwardPort{
+		{Port: 3000},
+		{Port: 8080},
+		{Port: 5000},
+		{Host: "db", Port: 5432},
+		{Port: 3000}, // duplicate
+		{Port: 70000},
+	}
+	published := []string{"127.0.0.1:18080:8080/tcp"}
+
+	mappings, skipped := forwardPortMappings(forward, published, available)
+
+	wantMappings := []string{"127.0.0.1:3000:3000"}
+	if !reflect.DeepEqual(mappings, wantMappings) {
+		t.Errorf("mappings = %v, want %v", mappings, wantMappings)
+	}
+	wantSkipped := []string{
+		"5000 (host port already in use)",
+		"db:5432 (port on another service)",
+		"70000 (invalid port)",
+	}
+	if !reflect.DeepEqual(skipped, wantSkipped) {
+		t.Errorf("skipped = %v, want %v", skipped, wantSkipped)
+	}
+}
+
+func TestPublishedContainerPort(t *testing.T) {
+	tests := []struct {
+		mapping string
+		want    int
+		ok      bool
+	}{
+		{"8080:3000", 3000, true},
+		{"127.0.0.1:8080:3000/tcp", 3000, true},
+		{"5353:53/udp", 53, true},
+		{"3000", 3000, true},
+		{"8000-8010:8000-8010", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := publishedContainerPort(tt.mapping)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("publishedContainerPort(%q) = %d, %v, want %d, %v", tt.mapping, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestHostPortAvailable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on localhost: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	if hostPortAvailable(port) {
+		t.Errorf("hostPortAvailable(%d) = true while a listener holds it", port)
+	}
+}
+
+func TestParsePortMapping(t *testing.T) {
+	tests := []struct {
+		mapping string
+		want    portMapping
+		ok      bool
+	}{
+		{"8080:3000", portMapping{HostPort: 8080, ContainerPort: "3000"}, true},
+		{"127.0.0.1:8080:3000/tcp", portMapping{HostIP: "127.0.0.1", HostPort: 8080, ContainerPort: "3000", Protocol: "tcp"}, true},
+		{"[::1]:5353:53/udp", portMapping{HostIP: "[::1]", HostPort: 5353, ContainerPort: "53", Protocol: "udp"}, true},
+		{"0:3000", portMapping{ContainerPort: "3000"}, true},
+		{"3000", portMapping{ContainerPort: "3000"}, true},
+		{"127.0.0.1::3000", portMapping{HostIP: "127.0.0.1", ContainerPort: "3000"}, true},
+		{"8000-8010:8000-8010", portMapping{}, false},
+		{"8080:http", portMapping{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parsePortMapping(tt.mapping)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parsePortMapping(%q) = %+v, %v, want %+v, %v", tt.mapping, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestResolvePublishPorts(t *testing.T) {
+	inUse := map[int]bool{8080: true}
+	newChecker := func(reassign bool) portChecker {
+		next := 49152
+		return portChecker{
+			available: func(m portMapping) bool { return !inUse[m.HostPort] },
+			free: func(m portMapping) (int, error) {
+				next++
+				return next, nil
+			},
+			reassign: func(m portMapping) bool { return reassign },
+		}
+	}
+
+	mappings := []string{"9000:9001", "0:3000", "127.0.0.1:8080:8080/tcp", "5432", "8000-8010:8000-8010"}
+	resolved, notes, err := resolvePublishPorts(mappings, newChecker(true))
+	if err != nil {
+		t.Fatalf("resolvePublishPorts() error = %v", err)
+	}
+	wantResolved := []string{"9000:9001", "49153:3000", "127.0.0.1:49154:8080/tcp", "49155:5432", "8000-8010:8000-8010"}
+	if !reflect.DeepEqual(resolved, wantResolved) {
+		t.Errorf("resolved = %v, want %v", resolved, wantResolved)
+	}
+	wantNotes := []string{
+		"Publishing container port 3000 on host port 49153",
+		"Publishing container port 8080 on host port 49154 (8080 is in use)",
+		"Publishing container port 5432 on host port 49155",
+	}
+	if !reflect.DeepEqual(notes, wantNotes) {
+		t.Errorf("notes = %v, want %v", notes, wantNotes)
+	}
+
+	_, _, err = resolvePublishPorts(mappings, newChecker(false))
+	if err == nil || !strings.Contains(err.Error(), "-p 0:8080") {
+		t.Errorf("declined reassignment should fail with a hint, got %v", err)
+	}
+}
+
+func TestConfirmPortReassign(t *testing.T) {
+	m := portMapping{HostPort: 8080, ContainerPort: "3000"}
+	tests := []struct {
+		name        string
+		input       string
+		interactive bool
+		want        bool
+	}{
+		{"default yes", "\n", true, true},
+		{"yes", "y\n", true, true},
+		{"no", "n\n", true, false},
+		{"no terminal", "y\n", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if got := confirmPortReassign(strings.NewReader(tt.input), &out, tt.interactive)(m); got != tt.want {
+				t.Errorf("confirmPortReassign() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFreeHostPort(t *testing.T) {
+	m := portMapping{HostIP: "127.0.0.1", ContainerPort: "3000"}
+	port, err := freeHostPort(m)
+	if err != nil {
+		t.Skipf("cannot listen on localhost: %v", err)
+	}
+	m.HostPort = port
+	if port == 0 || !hostPortFree(m) {
+		t.Errorf("freeHostPort() = %d, which should be free", port)
+	}
+
+	listener, err := net.Listen("tcp", m.listenAddr(port))
+	if err != nil {
+		t.Skipf("cannot listen on localhost: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+	if hostPortFree(m) {
+		t.Errorf("hostPortFree() = true while a listener holds port %d", port)
+	}
+}
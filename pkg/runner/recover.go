@@ -0,0 +1,119 @@
+package runner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/git"
+	"github.com/obra/packnplay/pkg/inflight"
+)
+
+// beginOperation records op as in progress. Failing to record it only costs crash
+// recovery, so it is a warning in verbose mode rather than a reason not to run.
+func beginOperation(op inflight.Operation, verbose bool) *inflight.Operation {
+	recorded, err := inflight.Begin(op)
+	if err != nil && verbose {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record %s: %v\n", op.Describe(), err)
+	}
+	return recorded
+}
+
+// CleanUpOperation removes what an interrupted operation left half set up, and its record.
+// A worktree is removed along with anything in it; its branch is kept.
+func CleanUpOperation(op *inflight.Operation, verbose bool) error {
+	switch op.Kind {
+	case inflight.Worktree:
+		if err := git.RemoveWorktree(op.Repo, op.Target); err != nil {
+			return fmt.Errorf("failed to remove worktree %s: %w", op.Target, err)
+		}
+	case inflight.Container:
+		dockerClient, err := docker.NewClientWithRuntime(op.Runtime, verbose)
+		if err != nil {
+			return fmt.Errorf("failed to initialize container runtime: %w", err)
+		}
+		if output, err := dockerClient.Run("rm", "-f", op.Target); err != nil && !errors.Is(err, docker.ErrNoSuchContainer) {
+			return fmt.Errorf("failed to remove container %s: %w\n%s", op.Target, err, output)
+		}
+	}
+	op.Done()
+	return nil
+}
+
+// ResumeOperation keeps what an interrupted operation set up for the next run to pick up,
+// and drops its record. Only a worktree git finished adding can be resumed; a container
+// that was never fully set up has to be started again.
+func ResumeOperation(op *inflight.Operation) error {
+	if !canResume(*op) {
+		return fmt.Errorf("%s can't be resumed, clean it up instead", op.Target)
+	}
+	op.Done()
+	return nil
+}
+
+func canResume(op inflight.Operation) bool {
+	return op.Kind == inflight.Worktree && git.IsGitRepo(op.Target)
+}
+
+// RecoverInterrupted looks for operations an earlier packnplay left half finished and,
+// on a terminal, offers to clean up or resume each one. Without a terminal it only
+// warns, pointing at packnplay recover.
+func RecoverInterrupted(verbose bool) {
+	ops, err := inflight.Interrupted()
+	if err != nil {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Warning: failed to check for interrupted operations: %v\n", err)
+		}
+		return
+	}
+	recoverInterrupted(ops, os.Stdin, os.Stderr, term.IsTerminal(os.Stdin.Fd()), func(op *inflight.Operation) error {
+		return CleanUpOperation(op, verbose)
+	})
+}
+
+// recoverInterrupted asks about each of ops on out, cleaning up with cleanUp. Skipped
+// operations are asked about again next time.
+func recoverInterrupted(ops []inflight.Operation, in io.Reader, out io.Writer, interactive bool, cleanUp func(*inflight.Operation) error) {
+	if len(ops) == 0 {
+		return
+	}
+	if !interactive {
+		for _, op := range ops {
+			_, _ = fmt.Fprintf(out, "Warning: an earlier packnplay was interrupted while %s\n", op.Describe())
+		}
+		_, _ = fmt.Fprintln(out, "  Run 'packnplay recover' to clean up or resume")
+		return
+	}
+
+	reader := bufio.NewReader(in)
+	for i := range ops {
+		op := &ops[i]
+		resumable := canResume(*op)
+		choices := "[c]lean up, [s]kip"
+		if resumable {
+			choices = "[c]lean up, [r]esume, [s]kip"
+		}
+		_, _ = fmt.Fprintf(out, "An earlier packnplay was interrupted while %s (%s).\n%s? ", op.Describe(), op.Started.Format("Jan 2 15:04"), choices)
+		answer, _ := reader.ReadString('\n')
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "c", "clean", "clean up":
+			if err := cleanUp(op); err != nil {
+				_, _ = fmt.Fprintf(out, "Warning: %v\n", err)
+			} else {
+				_, _ = fmt.Fprintf(out, "Cleaned up %s\n", op.Target)
+			}
+		case "r", "resume":
+			if err := ResumeOperation(op); err != nil {
+				_, _ = fmt.Fprintf(out, "%v; skipping it\n", err)
+				continue
+			}
+			_, _ = fmt.Fprintf(out, "Keeping %s\n", op.Target)
+		}
+	}
+}
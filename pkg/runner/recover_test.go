@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/obra/packnplay/pkg/inflight"
+)
+
+func TestRecoverInterrupted(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	repoDir := t.TempDir()
+	worktreeDir := filepath.Join(t.TempDir(), "feature")
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main", repoDir},
+		{"-C", repoDir, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "initial"},
+		{"-C", repoDir, "worktree", "add", "-q", "-b", "feature", worktreeDir},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	record := func(op inflight.Operation) inflight.Operation {
+		t.Helper()
+		recorded, err := inflight.Begin(op)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *recorded
+	}
+	finished := record(inflight.Operation{Kind: inflight.Worktree, Target: worktreeDir, Repo: repoDir})
+	half := record(inflight.Operation{Kind: inflight.Worktree, Target: filepath.Join(t.TempDir(), "half"), Repo: repoDir})
+	started := record(inflight.Operation{Kind: inflight.Container, Target: "packnplay-app-main", Runtime: "docker"})
+	broken := record(inflight.Operation{Kind: inflight.Container, Target: "packnplay-app-broken", Runtime: "docker"})
+	ops := []inflight.Operation{finished, half, started, broken}
+	for i := range ops {
+		ops[i].Started = time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC)
+	}
+
+	t.Run("without a terminal only warns", func(t *testing.T) {
+		var out bytes.Buffer
+		recoverInterrupted(ops, strings.NewReader(""), &out, false, func(*inflight.Operation) error {
+			t.Error("nothing should be cleaned up without asking")
+			return nil
+		})
+		if !strings.Contains(out.String(), "interrupted while starting container packnplay-app-main") || !strings.Contains(out.String(), "packnplay recover") {
+			t.Errorf("output = %q", out.String())
+		}
+	})
+
+	t.Run("asks about each operation", func(t *testing.T) {
+		var out bytes.Buffer
+		var cleaned []string
+		cleanUp := func(op *inflight.Operation) error {
+			cleaned = append(cleaned, op.Target)
+			if op.Target == "packnplay-app-broken" {
+				return errors.New("docker is not running")
+			}
+			op.Done()
+			return nil
+		}
+		// finished worktree: resume, half-created worktree: resume (not possible), container: clean, broken: clean
+		input := "r\nr\nc\nc\n"
+		recoverInterrupted(ops, strings.NewReader(input), &out, true, cleanUp)
+
+		if want := []string{"packnplay-app-main", "packnplay-app-broken"}; strings.Join(cleaned, ",") != strings.Join(want, ",") {
+			t.Errorf("cleaned up %q, want %q", cleaned, want)
+		}
+		for _, want := range []string{"[c]lean up, [r]esume, [s]kip", "Keeping " + worktreeDir, "can't be resumed", "Warning: docker is not running"} {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("output missing %q:\n%s", want, out.String())
+			}
+		}
+
+		// The skipped and failed operations are asked about again next time
+		if entries, _ := os.ReadDir(inflight.Dir()); len(entries) != 2 {
+			t.Errorf("%d records left, want 2", len(entries))
+		}
+	})
+}
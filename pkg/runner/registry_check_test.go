@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/obra/packnplay/pkg/config"
 	"github.com/obra/packnplay/pkg/docker"
 )
 
@@ -101,6 +102,28 @@ func TestNotificationMessage(t *testing.T) {
 	}
 }
 
+func TestCheckForNewVersionRemembersNotifications(t *testing.T) {
+	localInfo := &ImageVersionInfo{Digest: "sha256:old123"}
+	remoteInfo := &ImageVersionInfo{Digest: "sha256:new456", Created: timeNow()}
+	tracking := &config.VersionTrackingData{
+		Notifications: map[string]config.VersionNotification{
+			"test:latest": {Digest: "sha256:new456", NotifiedAt: timeNow().Add(-2 * time.Hour)},
+		},
+	}
+
+	if checkForNewVersion("test:latest", localInfo, remoteInfo, newVersionTrackerFrom(tracking, 24)).shouldNotify {
+		t.Error("Should not notify again within the frequency")
+	}
+	if !checkForNewVersion("test:latest", localInfo, remoteInfo, newVersionTrackerFrom(tracking, 1)).shouldNotify {
+		t.Error("Should notify again once the image's frequency has passed")
+	}
+
+	newer := &ImageVersionInfo{Digest: "sha256:newer789", Created: timeNow()}
+	if !checkForNewVersion("test:latest", localInfo, newer, newVersionTrackerFrom(tracking, 24)).shouldNotify {
+		t.Error("Should notify about a version it hasn't notified about")
+	}
+}
+
 // Helper functions for testing
 func NewTestDockerClient() (*docker.Client, error) {
 	return docker.NewClient(false)
@@ -118,4 +141,4 @@ func containsString(s, substr string) bool {
 	return strings.Contains(s, substr)
 }
 
-// Types are implemented in runner.go
\ No newline at end of file
+// Types are implemented in runner.go
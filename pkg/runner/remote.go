@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/git"
+	"github.com/obra/packnplay/pkg/remote"
+)
+
+// prepareRemoteWorktree checks out the worktree's branch on the remote host from the
+// project's origin and returns its path there. Unpushed local commits aren't included.
+func prepareRemoteWorktree(target, workDir, worktreeName string, verbose bool) (string, error) {
+	if worktreeName == noWorktree {
+		return "", fmt.Errorf("--host needs a git repository to clone on %s (--no-worktree is not supported)", target)
+	}
+	repoURL, err := git.GetRemoteURL(workDir, "origin")
+	if err != nil {
+		return "", fmt.Errorf("--host needs an 'origin' remote to clone %s from", workDir)
+	}
+
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Preparing worktree %s on %s from %s\n", worktreeName, target, repoURL)
+	}
+	return remote.Host{Target: target}.PrepareWorktree(repoURL, filepath.Base(workDir), worktreeName)
+}
+
+// remoteName returns the remote host or daemon the container runs on, "" when it's local
+func (c *RunConfig) remoteName() string {
+	if c.RemoteHost != "" {
+		return c.RemoteHost
+	}
+	return c.RemoteDaemon
+}
+
+// splitRemoteMounts removes bind mounts of local paths from docker run args, keeping the
+// remote worktree. Single files (credentials, .gitconfig) are returned to be copied in once
+// the container is running; local directories can't be provided and are reported.
+func splitRemoteMounts(args []string, workspace string) (remoteArgs []string, copies []remote.BindMount) {
+	remoteArgs, dropped := remote.LocalMounts(args, workspace)
+
+	var skipped []string
+	for _, mount := range dropped {
+		if info, err := os.Stat(mount.Source); err == nil && info.Mode().IsRegular() {
+			copies = append(copies, mount)
+			continue
+		}
+		skipped = append(skipped, mount.Target)
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: local directories can't be mounted on a remote host, skipping: %s\n", strings.Join(skipped, ", "))
+	}
+	return remoteArgs, copies
+}
+
+// copyRemoteFiles copies local files into a container on a remote host in place of bind
+// mounts, owned by the container user
+func copyRemoteFiles(dockerClient *docker.Client, containerID, containerUser string, copies []remote.BindMount, creds *credentialCheck, verbose bool) {
+	for _, file := range copies {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Copying %s to %s in the remote container\n", file.Source, file.Target)
+		}
+		_, _ = dockerClient.Run("exec", "-u", containerUser, containerID, "mkdir", "-p", filepath.Dir(file.Target))
+		if output, err := dockerClient.Run("cp", file.Source, containerID+":"+file.Target); err != nil {
+			creds.problem("failed to copy %s into the container: %v\n%s", file.Source, err, strings.TrimSpace(output))
+			continue
+		}
+		owner := fileOwner(dockerClient, containerID, containerUser)
+		if output, err := dockerClient.Run("exec", "-u", "0", containerID, "chown", owner, file.Target); err != nil {
+			creds.problemIfStrict("failed to chown %s to %s: %v\n%s", file.Target, owner, err, strings.TrimSpace(output))
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/remote"
+)
+
+// workspaceSyncLabel records the workspace a container on a remote daemon is kept in sync
+// with, so the sync can be resumed when reconnecting
+const workspaceSyncLabel = "packnplay-workspace-sync"
+
+// SyncVolumeLabel marks the volumes holding a container's copy of local directories
+const SyncVolumeLabel = "packnplay-sync-volume"
+
+// workspaceSyncInterval is how often local changes are looked for
+const workspaceSyncInterval = 2 * time.Second
+
+// syncedMount is a local directory whose contents are copied into a volume, because a
+// remote daemon can't bind mount it
+type syncedMount struct {
+	Source string
+	Target string
+	Volume string
+}
+
+// workspaceSync is the JSON in workspaceSyncLabel
+type workspaceSync struct {
+	User   string `json:"user"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// volumeMounts replaces bind mounts of the directories in dirs (local path to volume
+// name suffix) with volumes named for the container, returning the directories to copy
+// into them
+func volumeMounts(args []string, containerName string, dirs map[string]string) ([]string, []syncedMount) {
+	rewritten := make([]string, len(args))
+	copy(rewritten, args)
+	var mounts []syncedMount
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] != "-v" {
+			continue
+		}
+		source, rest, _ := strings.Cut(args[i+1], ":")
+		suffix, ok := dirs[source]
+		if !ok || source == "" {
+			continue
+		}
+		mount := syncedMount{Source: source, Volume: containerName + "-" + suffix}
+		mount.Target, _, _ = strings.Cut(rest, ":")
+		rewritten[i+1] = mount.Volume + ":" + rest
+		mounts = append(mounts, mount)
+	}
+	return rewritten, mounts
+}
+
+// createSyncVolumes creates the volumes for mounts, labeled so packnplay stop removes them
+func createSyncVolumes(dockerClient *docker.Client, containerName string, mounts []syncedMount) error {
+	for _, mount := range mounts {
+		output, err := dockerClient.Run("volume", "create",
+			"--label", "managed-by=packnplay",
+			"--label", SyncVolumeLabel+"="+containerName,
+			mount.Volume)
+		if err != nil {
+			return fmt.Errorf("failed to create volume %s: %w\n%s", mount.Volume, err, output)
+		}
+	}
+	return nil
+}
+
+// seedSyncedMounts copies each local directory into its volume in a started container,
+// owned by the container user
+func seedSyncedMounts(dockerClient *docker.Client, containerID, user string, mounts []syncedMount, verbose bool) error {
+	for _, mount := range mounts {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Copying %s into volume %s on the remote daemon\n", mount.Source, mount.Volume)
+		}
+		if err := dockerClient.CopyToContainer(mount.Source+"/.", containerID, mount.Target); err != nil {
+			return err
+		}
+		_, _ = dockerClient.Run("exec", "-u", "0", containerID, "chown", "-R", fileOwner(dockerClient, containerID, user), mount.Target)
+	}
+	return nil
+}
+
+// label returns the run arguments that record sync on the container
+func (sync workspaceSync) label() []string {
+	data, err := json.Marshal(sync)
+	if err != nil {
+		return nil
+	}
+	return []string{"--label", workspaceSyncLabel + "=" + string(data)}
+}
+
+// startWorkspaceSync starts a background packnplay process pushing local changes into the
+// container, unless one is already running for it
+func startWorkspaceSync(runtime, containerName string, sync workspaceSync, verbose bool) {
+	if workspaceSyncRunning(containerName) {
+		return
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not syncing workspace changes: %v\n", err)
+		return
+	}
+	cmd := exec.Command(executable, "sync-workspace", "--runtime", runtime, "--user", sync.User, containerName, sync.Source, sync.Target)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not syncing workspace changes: %v\n", err)
+		return
+	}
+	_ = cmd.Process.Release()
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Syncing changes in %s into the container every %s\n", sync.Source, workspaceSyncInterval)
+	}
+}
+
+// resumeWorkspaceSync restarts the workspace sync of a container that was started with
+// one, e.g. after the host slept or rebooted
+func resumeWorkspaceSync(runtime, containerName string, labels map[string]string, verbose bool) {
+	data, ok := labels[workspaceSyncLabel]
+	if !ok {
+		return
+	}
+	var sync workspaceSync
+	if err := json.Unmarshal([]byte(data), &sync); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to read %s label: %v\n", workspaceSyncLabel, err)
+		return
+	}
+	startWorkspaceSync(runtime, containerName, sync, verbose)
+}
+
+// stageFilesScript stages the files given after the copy's path, as they are in the copy,
+// on copyBaseRef in the scratch index. Ignored files the base doesn't have are left out,
+// like git add would, and names are taken literally.
+const stageFilesScript = copyScriptSetup + `shift
+export GIT_LITERAL_PATHSPECS=1
+g read-tree ` + copyBaseRef + `
+g ls-files -z --cached --others --exclude-standard -- "$@" | g update-index --add --remove -z --stdin
+`
+
+// changedFilesScript prints, NUL-separated, which of the files given after the copy's path
+// were changed in the copy since copyBaseRef
+const changedFilesScript = stageFilesScript + `g diff --cached --name-only --no-renames -z ` + copyBaseRef + ` -- "$@"
+`
+
+// syncedFilesScript records the files given after the copy's path on copyBaseRef as they
+// are now, so that what was synced in from the host isn't taken for the container's changes
+const syncedFilesScript = stageFilesScript + `g update-ref ` + copyBaseRef + ` "$(g write-tree)"
+`
+
+// SyncWorkspace copies files that change under source into target in the container,
+// until the container stops. Files are only added and updated, never deleted, and git's
+// own directory is left alone, so work done in the container isn't lost. A file the
+// container changed too keeps the container's version, which packnplay diff and export
+// bring back.
+func SyncWorkspace(runtime, containerName, user, source, target string) error {
+	dockerClient, err := docker.NewClientWithRuntime(runtime, false)
+	if err != nil {
+		return err
+	}
+
+	pidPath, err := workspaceSyncPIDPath(containerName)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(pidPath), 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(pidPath), err)
+	}
+	if err := os.WriteFile(pidPath, []byte(strconv.Itoa(os.Getpid())), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", pidPath, err)
+	}
+	defer func() { _ = os.Remove(pidPath) }()
+
+	previous, err := remote.Scan(source)
+	if err != nil {
+		return fmt.Errorf("failed to scan %s: %w", source, err)
+	}
+	for {
+		time.Sleep(workspaceSyncInterval)
+		running, err := containerIsRunning(dockerClient, containerName)
+		if err != nil || !running {
+			return err
+		}
+		current, err := remote.Scan(source)
+		if err != nil {
+			continue
+		}
+		if changed := current.Changed(previous); len(changed) > 0 {
+			if err := syncFiles(dockerClient, containerName, user, source, target, changed); err != nil {
+				// Tried again next time, since previous isn't updated
+				fmt.Fprintf(os.Stderr, "Warning: failed to sync %d files: %v\n", len(changed), err)
+				continue
+			}
+		}
+		previous = current
+	}
+}
+
+// syncFiles pushes files, relative to source, into target in the container, except those
+// changed in the container since they were last synced, and records what was pushed
+func syncFiles(dockerClient *docker.Client, containerName, user, source, target string, files []string) error {
+	output, err := dockerClient.Output(append([]string{"exec", "-u", user, containerName, "sh", "-c", changedFilesScript, "sh", target}, files...)...)
+	if err != nil {
+		return fmt.Errorf("failed to find the container's changes: %w: %s", err, strings.TrimSpace(output))
+	}
+	ownChanges := make(map[string]bool)
+	for _, name := range strings.Split(output, "\x00") {
+		ownChanges[name] = name != ""
+	}
+	var push []string
+	for _, file := range files {
+		if ownChanges[file] {
+			fmt.Fprintf(os.Stderr, "Warning: not syncing %s, which was changed in the container too\n", file)
+			continue
+		}
+		push = append(push, file)
+	}
+	if len(push) == 0 {
+		return nil
+	}
+	if err := pushFiles(dockerClient, containerName, user, source, target, push); err != nil {
+		return err
+	}
+	if output, err := dockerClient.Run(append([]string{"exec", "-u", user, containerName, "sh", "-c", syncedFilesScript, "sh", target}, push...)...); err != nil {
+		return fmt.Errorf("failed to record the synced files: %w: %s", err, strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// pushFiles streams files, relative to source, into target in the container as a tar
+func pushFiles(dockerClient *docker.Client, containerName, user, source, target string, files []string) error {
+	var archive bytes.Buffer
+	if err := remote.WriteTar(&archive, source, files); err != nil {
+		return err
+	}
+	output, err := dockerClient.RunWithInput(io.Reader(&archive), "exec", "-i", "-u", user, containerName, "tar", "-x", "-C", target)
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// workspaceSyncPIDPath is where the sync process for containerName records its PID
+func workspaceSyncPIDPath(containerName string) (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	return filepath.Join(dataHome, "packnplay", "sync", containerName+".pid"), nil
+}
+
+// workspaceSyncRunning reports whether a sync process for containerName is alive
+func workspaceSyncRunning(containerName string) bool {
+	pidPath, err := workspaceSyncPIDPath(containerName)
+	if err != nil {
+		return false
+	}
+	data, err := os.ReadFile(pidPath)
+	if err != nil {
+		return false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return false
+	}
+	return syscall.Kill(pid, 0) == nil
+}
+
+// RemoveSyncVolumes removes the volumes holding a container's copy of local directories.
+// The sync process exits by itself once the container is gone.
+func RemoveSyncVolumes(dockerClient *docker.Client, containerName string) {
+	output, err := dockerClient.Run("volume", "ls", "-q", "--filter", "label="+SyncVolumeLabel+"="+containerName)
+	if err != nil {
+		return
+	}
+	if volumes := strings.Fields(output); len(volumes) > 0 {
+		_, _ = dockerClient.Run(append([]string{"volume", "rm", "-f"}, volumes...)...)
+	}
+}
@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestVolumeMounts(t *testing.T) {
+	args := []string{
+		"run", "-d",
+		"-v", "/home/dev/app:/home/dev/app",
+		"-v", "/home/dev/.claude:/home/vscode/.claude",
+		"-v", "/home/dev/app-main/.git:/home/dev/app-main/.git",
+		"-v", "/home/dev/.gitconfig:/home/vscode/.gitconfig:ro",
+	}
+	dirs := map[string]string{
+		"/home/dev/app":           "workspace",
+		"/home/dev/app-main/.git": "git",
+	}
+	got, mounts := volumeMounts(args, "packnplay-app-main", dirs)
+
+	wantArgs := []string{
+		"run", "-d",
+		"-v", "packnplay-app-main-workspace:/home/dev/app",
+		"-v", "/home/dev/.claude:/home/vscode/.claude",
+		"-v", "packnplay-app-main-git:/home/dev/app-main/.git",
+		"-v", "/home/dev/.gitconfig:/home/vscode/.gitconfig:ro",
+	}
+	if !reflect.DeepEqual(got, wantArgs) {
+		t.Errorf("volumeMounts() args = %v, want %v", got, wantArgs)
+	}
+	wantMounts := []syncedMount{
+		{Source: "/home/dev/app", Target: "/home/dev/app", Volume: "packnplay-app-main-workspace"},
+		{Source: "/home/dev/app-main/.git", Target: "/home/dev/app-main/.git", Volume: "packnplay-app-main-git"},
+	}
+	if !reflect.DeepEqual(mounts, wantMounts) {
+		t.Errorf("volumeMounts() mounts = %v, want %v", mounts, wantMounts)
+	}
+	if args[3] != "/home/dev/app:/home/dev/app" {
+		t.Errorf("volumeMounts() modified its input: %v", args)
+	}
+}
+
+func TestWorkspaceSyncLabel(t *testing.T) {
+	sync := workspaceSync{User: "vscode", Source: "/home/dev/app", Target: "/home/dev/app"}
+	args := sync.label()
+	if len(args) != 2 || args[0] != "--label" {
+		t.Fatalf("label() = %v", args)
+	}
+	name, value, _ := strings.Cut(args[1], "=")
+	if name != workspaceSyncLabel {
+		t.Errorf("label name = %q, want %q", name, workspaceSyncLabel)
+	}
+	var got workspaceSync
+	if err := json.Unmarshal([]byte(value), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != sync {
+		t.Errorf("label round trip = %+v, want %+v", got, sync)
+	}
+}
+
+func TestWorkspaceSyncRunning(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	if workspaceSyncRunning("packnplay-app-main") {
+		t.Error("workspaceSyncRunning() without a pidfile = true")
+	}
+	path, err := workspaceSyncPIDPath("packnplay-app-main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(path, "/packnplay/sync/packnplay-app-main.pid") {
+		t.Errorf("workspaceSyncPIDPath() = %q", path)
+	}
+}
@@ -0,0 +1,48 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/remote"
+)
+
+func TestSplitRemoteMounts(t *testing.T) {
+	home := t.TempDir()
+	gitconfig := filepath.Join(home, ".gitconfig")
+	if err := os.WriteFile(gitconfig, []byte("[user]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	claudeDir := filepath.Join(home, ".claude")
+	if err := os.Mkdir(claudeDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{
+		"run", "-d",
+		"-v", claudeDir + ":/home/vscode/.claude",
+		"-v", "/srv/worktrees/app/main:/srv/worktrees/app/main",
+		"-v", gitconfig + ":/home/vscode/.gitconfig:ro",
+	}
+	got, copies := splitRemoteMounts(args, "/srv/worktrees/app/main")
+
+	wantArgs := []string{"run", "-d", "-v", "/srv/worktrees/app/main:/srv/worktrees/app/main"}
+	if !reflect.DeepEqual(got, wantArgs) {
+		t.Errorf("splitRemoteMounts() args = %v, want %v", got, wantArgs)
+	}
+	wantCopies := []remote.BindMount{{Source: gitconfig, Target: "/home/vscode/.gitconfig"}}
+	if !reflect.DeepEqual(copies, wantCopies) {
+		t.Errorf("splitRemoteMounts() copies = %v, want %v", copies, wantCopies)
+	}
+}
+
+func TestPrepareRemoteWorktreeNeedsRepo(t *testing.T) {
+	if _, err := prepareRemoteWorktree("dev@build-01", t.TempDir(), noWorktree, false); err == nil {
+		t.Error("prepareRemoteWorktree() accepted a directory without a worktree")
+	}
+	if _, err := prepareRemoteWorktree("dev@build-01", t.TempDir(), "main", false); err == nil {
+		t.Error("prepareRemoteWorktree() accepted a directory without an origin remote")
+	}
+}
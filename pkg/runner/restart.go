@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/obra/packnplay/pkg/devcontainer"
+	"github.com/obra/packnplay/pkg/docker"
+)
+
+// ErrNoContainer is returned by FindContainer when a worktree has no container
+var ErrNoContainer = errors.New("no container")
+
+// WorktreeContainer is the container Run started for a directory and worktree
+type WorktreeContainer struct {
+	Client   *docker.Client
+	Name     string
+	Worktree string
+	Image    string
+}
+
+// FindContainer finds the container Run started for path and worktree, running or not
+func FindContainer(path, worktree string, skipWorktree bool, runtime string, verbose bool) (*WorktreeContainer, error) {
+	workDir := path
+	if workDir == "" {
+		var err error
+		workDir, err = os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+	workDir, err := filepath.Abs(workDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	worktreeName, err := resolveWorktreeName(workDir, worktree, skipWorktree)
+	if err != nil {
+		return nil, err
+	}
+
+	dockerClient, err := docker.NewClientWithRuntime(runtime, verbose)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize container runtime: %w", err)
+	}
+	containerName := ContainerName(dockerClient, workDir, worktreeName)
+
+	info, err := dockerClient.InspectContainer(containerName)
+	if errors.Is(err, docker.ErrNoSuchContainer) {
+		return nil, fmt.Errorf("%w for worktree '%s'", ErrNoContainer, worktreeName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container %s: %w", containerName, err)
+	}
+	return &WorktreeContainer{Client: dockerClient, Name: containerName, Worktree: worktreeName, Image: info.Config.Image}, nil
+}
+
+// PullImage pulls the image the container runs, so it is recreated from the latest one.
+// Images built from a devcontainer Dockerfile aren't in a registry; Run rebuilds them
+// when the Dockerfile changes.
+func (c *WorktreeContainer) PullImage(verbose bool) error {
+	output, err := c.Client.Run("image", "inspect", "--format", fmt.Sprintf("{{index .Config.Labels %q}}", devcontainer.BuildHashLabel), c.Image)
+	if err == nil && strings.TrimSpace(output) != "" && strings.TrimSpace(output) != "<no value>" {
+		fmt.Fprintf(os.Stderr, "Warning: %s is built from the project's Dockerfile, not pulled; it is rebuilt when the Dockerfile changes\n", c.Image)
+		return nil
+	}
+
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Pulling image %s\n", c.Image)
+	}
+	if output, err := c.Client.Run("pull", c.Image); err != nil {
+		return fmt.Errorf("failed to pull image %s: %w\nDocker output:\n%s", c.Image, err, output)
+	}
+	return nil
+}
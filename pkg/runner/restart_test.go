@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeDockerCLI puts a docker script on PATH that answers inspect with inspectOutput
+// (failing when it is empty), prints image labels as imageLabel, and logs its arguments
+func fakeDockerCLI(t *testing.T, inspectOutput, imageLabel string) string {
+	t.Helper()
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "calls")
+	script := `#!/bin/sh
+echo "$*" >> "` + logPath + `"
+case "$1" in
+inspect)
+	if [ -z '` + inspectOutput + `' ]; then echo "Error: No such object: $4" >&2; exit 1; fi
+	echo '` + inspectOutput + `' ;;
+image) echo '` + imageLabel + `' ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("DOCKER_CMD", "docker")
+	t.Setenv("IS_SANDBOX", "")
+	t.Setenv("DOCKER_HOST", "unix://"+filepath.Join(dir, "no-daemon.sock"))
+	return logPath
+}
+
+func TestFindContainerMissing(t *testing.T) {
+	fakeDockerCLI(t, "", "")
+
+	_, err := FindContainer(t.TempDir(), "", true, "docker", false)
+	if !errors.Is(err, ErrNoContainer) {
+		t.Errorf("FindContainer() error = %v, want ErrNoContainer", err)
+	}
+}
+
+func TestFindContainerAndPull(t *testing.T) {
+	tests := []struct {
+		name       string
+		imageLabel string
+		wantPull   bool
+	}{
+		{"registry image", "", true},
+		{"built from a Dockerfile", "3f2a9c", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logPath := fakeDockerCLI(t, `[{"Name":"/packnplay-app-main","Config":{"Image":"ghcr.io/obra/packnplay-default:latest"}}]`, tt.imageLabel)
+
+			found, err := FindContainer(t.TempDir(), "", true, "docker", false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if found.Image != "ghcr.io/obra/packnplay-default:latest" || found.Worktree != noWorktree {
+				t.Errorf("FindContainer() = %+v", found)
+			}
+
+			if err := found.PullImage(false); err != nil {
+				t.Fatal(err)
+			}
+			calls, err := os.ReadFile(logPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pulled := strings.Contains(string(calls), "pull ghcr.io/obra/packnplay-default:latest")
+			if pulled != tt.wantPull {
+				t.Errorf("pulled = %v, want %v; calls:\n%s", pulled, tt.wantPull, calls)
+			}
+		})
+	}
+}
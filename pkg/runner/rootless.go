@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/obra/packnplay/pkg/docker"
+)
+
+// podmanKeepID maps the host user to the same user ID in the container, so files in the
+// mounted project stay owned by whoever runs the command
+const podmanKeepID = "--userns=keep-id"
+
+// rootlessArgs returns the run arguments a rootless runtime needs for the container user
+// to work in the mounted project, and a warning when there's no way to arrange it.
+// Rootless Podman keeps the host user's ID with keep-id. Rootless Docker maps the host user
+// to root, so mounted files look root-owned and only a root container user can write them.
+func rootlessArgs(podman, rootless bool, remoteUser string) ([]string, string) {
+	switch {
+	case !rootless:
+		return nil, ""
+	case podman:
+		return []string{podmanKeepID}, ""
+	case remoteUser != "root":
+		return nil, fmt.Sprintf("Warning: rootless Docker maps your user to root in the container, so %s may not be able to write the mounted project. "+
+			"Set \"remoteUser\": \"root\" in devcontainer.json (root in the container is your user on the host), or use rootless Podman", remoteUser)
+	}
+	return nil, ""
+}
+
+// fileOwner returns the owner to chown files copied into the container to. docker cp
+// creates them as root; they should belong to the user commands run as. That is user and
+// its primary group, which needn't share its name, except under Podman's keep-id, where
+// commands run with the host user's IDs, which the image may not have a name for.
+func fileOwner(dockerClient *docker.Client, containerID, user string) string {
+	if !dockerClient.IsPodman() || !dockerClient.Rootless() {
+		gid, err := dockerClient.Run("exec", containerID, "id", "-g", user)
+		if err != nil {
+			return user + ":" + user
+		}
+		return user + ":" + strings.TrimSpace(gid)
+	}
+	uid, err := dockerClient.Run("exec", containerID, "id", "-u")
+	if err != nil {
+		return user + ":" + user
+	}
+	gid, err := dockerClient.Run("exec", containerID, "id", "-g")
+	if err != nil {
+		return user + ":" + user
+	}
+	return strings.TrimSpace(uid) + ":" + strings.TrimSpace(gid)
+}
@@ -0,0 +1,35 @@
+package runner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRootlessArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		podman      bool
+		rootless    bool
+		remoteUser  string
+		wantArgs    []string
+		wantWarning string
+	}{
+		{"rootful docker", false, false, "vscode", nil, ""},
+		{"rootful podman", true, false, "vscode", nil, ""},
+		{"rootless podman keeps the host user ID", true, true, "vscode", []string{"--userns=keep-id"}, ""},
+		{"rootless docker warns about a non-root user", false, true, "vscode", nil, "vscode may not be able to write"},
+		{"rootless docker as root", false, true, "root", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, warning := rootlessArgs(tt.podman, tt.rootless, tt.remoteUser)
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("rootlessArgs() args = %q, want %q", args, tt.wantArgs)
+			}
+			if (tt.wantWarning == "") != (warning == "") || !strings.Contains(warning, tt.wantWarning) {
+				t.Errorf("rootlessArgs() warning = %q, want one mentioning %q", warning, tt.wantWarning)
+			}
+		})
+	}
+}
@@ -2,38 +2,87 @@ package runner
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"os/user"
 	"path/filepath"
 	"strings"
-	"syscall"
 	"time"
 
-	"github.com/obra/packnplay/pkg/aws"
 	"github.com/obra/packnplay/pkg/config"
 	"github.com/obra/packnplay/pkg/container"
+	"github.com/obra/packnplay/pkg/credentials"
 	"github.com/obra/packnplay/pkg/devcontainer"
 	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/events"
 	"github.com/obra/packnplay/pkg/git"
+	"github.com/obra/packnplay/pkg/inflight"
+	"github.com/obra/packnplay/pkg/toolchain"
+	"github.com/obra/packnplay/pkg/ui"
 )
 
 type RunConfig struct {
-	Path           string
-	Worktree       string
-	NoWorktree     bool
-	Env            []string
-	Verbose        bool
-	Runtime        string // docker, podman, or container
-	Reconnect      bool   // Allow reconnecting to existing containers
-	DefaultImage   string // default container image to use
-	Command        []string
-	Credentials    config.Credentials
-	DefaultEnvVars []string // API keys to proxy from host
-	PublishPorts   []string // Port mappings to publish to host
-	HostPath       string   // Host directory path for the container
-	LaunchCommand  string   // Original command line used to launch
+	Path                 string
+	Worktree             string
+	NoWorktree           bool
+	Env                  []string
+	Verbose              bool
+	Runtime              string // docker, podman, or container
+	Reconnect            bool   // Allow reconnecting to existing containers
+	DefaultImage         string // default container image to use
+	Command              []string
+	Then                 [][]string // Commands run after Command in the same container, stopping at the first failure
+	Credentials          config.Credentials
+	DefaultEnvVars       []string                                   // API keys to proxy from host
+	PublishPorts         []string                                   // Port mappings to publish to host
+	HostPath             string                                     // Host directory path for the container
+	LaunchCommand        string                                     // Original command line used to launch
+	ClaudeSync           string                                     // How ~/.claude is exposed: full, copy, or selective
+	ClaudeJSONSync       string                                     // .claude.json re-sync strategy: off, host, or container
+	MCPServers           map[string]config.MCPServerMapping         // MCP server rewrites for the container's .claude.json
+	Mounts               []string                                   // Extra bind mounts (host:container[:ro]) from project config
+	AWSProfile           string                                     // Overrides AWS_PROFILE for credential_process and inside the container
+	AzureToken           bool                                       // Inject an access token from the host's az login
+	CredentialProviders  map[string]config.CredentialProviderConfig // user-defined script providers enabled for this run
+	GroupAdd             []string                                   // Supplemental groups for the container user (--group-add)
+	Labels               map[string]string                          // Extra container labels from config
+	SharedCache          bool                                       // Mount the cross-project shared cache
+	SharedCacheMax       int64                                      // Prune the shared cache to this many bytes before starting (0 = no cap)
+	DependencyCaches     config.DependencyCachesConfig              // Named volumes over package manager caches (~/.npm, ~/.cargo/registry)
+	NoForwardPorts       bool                                       // Don't publish devcontainer forwardPorts
+	Ulimits              []string                                   // docker --ulimit values (name=soft[:hard])
+	Resources            config.ResourcesConfig                     // CPU, memory, swap and pids limits
+	SkipPreflight        bool                                       // Skip host resource checks before starting
+	ManagedExec          bool                                       // Run docker exec as a child process instead of replacing packnplay
+	RecordFile           string                                     // Record the session as an asciicast file (implies ManagedExec)
+	Timeout              time.Duration                              // Stop the command after this long (implies ManagedExec, 0 = no limit)
+	StopOnTimeout        bool                                       // Also stop the container when Timeout is exceeded
+	Events               *events.Writer                             // Lifecycle events for --json-events (nil = off, implies ManagedExec)
+	RemoteHost           string                                     // Run on this ssh host (user@server); the docker CLI must already point at it
+	StrictCreds          bool                                       // Abort when a requested credential can't be provided instead of warning
+	SELinuxLabelDisable  bool                                       // Turn off SELinux label separation for mounts packnplay won't relabel
+	ScopedCreds          *config.ScopedCredentialsConfig            // Mint short-lived gh and AWS tokens on the host instead of passing long-lived ones (nil = off)
+	CredentialSession    string                                     // Names minted credentials in audit logs (the container name)
+	RemoteDaemon         string                                     // Address of a remote daemon that isn't reached over ssh; the workspace is copied into volumes
+	CopyWorkspace        bool                                       // Copy the workspace into volumes instead of bind mounting it; packnplay export applies the changes
+	TrustedImages        []string                                   // Image patterns trusted with secret credentials
+	UntrustedImagePolicy string                                     // warn, prompt, or refuse when the image isn't trusted
+	Ephemeral            bool                                       // --rm: the command is the container's main process and the container is removed when it exits
+	Summary              string                                     // off, short, or full: what to print when a managed run exits (default: short)
+	Network              config.NetworkConfig                       // open, none, or allowlist (egress proxy for the allowed domains only)
+	Docker               config.DockerConfig                        // off, socket (host daemon), or dind (sidecar); no mode defers to devcontainer features
+	Services             map[string]config.ServiceConfig            // Sidecar services (databases, caches) on a network shared with the container
+	UntrustedWorkspace   bool                                       // The repo isn't trusted: its devcontainer features don't grant docker access
+	AutoCommit           bool                                       // Commit the worktree's changes when the command exits (implies ManagedExec)
+	AutoCommitMessage    string                                     // text/template for the auto-commit message ("" = config.DefaultAutoCommitMessage)
+	BuildSecrets         []string                                   // docker build --secret values (id=name,src=path or id=name,env=VAR) for devcontainer builds
+	BuildSSH             []string                                   // docker build --ssh values (default, or id=socket-or-key) for devcontainer builds
+	BuildCache           config.BuildCacheConfig                    // registry cache (--cache-from, --cache-to) for devcontainer builds
+	LogCapture           config.LogCaptureConfig                    // Save session output to a rotated log file per container (implies ManagedExec when enabled)
+	SessionEnv           []string                                   // Set for this exec session only: credentials refreshed when reconnecting (KEY=value)
+	DryRun               bool                                       // Print the runtime commands with secrets redacted instead of running them
 }
 
 // ContainerDetails holds detailed information about a running container
@@ -46,6 +95,36 @@ type ContainerDetails struct {
 	LaunchCommand string
 }
 
+// noWorktree is the worktree name used when the directory is mounted directly
+const noWorktree = "no-worktree"
+
+// resolveWorktreeName returns the worktree a run in workDir uses: noWorktree when asked
+// or outside a git repo, otherwise the requested worktree or the current branch
+func resolveWorktreeName(workDir, worktree string, skipWorktree bool) (string, error) {
+	if skipWorktree {
+		return noWorktree, nil
+	}
+
+	if !git.IsGitRepo(workDir) {
+		if worktree != "" {
+			return "", fmt.Errorf("--worktree specified but %s is not a git repository", workDir)
+		}
+		// Not a git repo and no worktree flag: use directly
+		return noWorktree, nil
+	}
+
+	if worktree != "" {
+		return worktree, nil
+	}
+
+	// Auto-detect from current branch
+	branch, err := git.GetCurrentBranch(workDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to get current branch: %w", err)
+	}
+	return branch, nil
+}
+
 func Run(config *RunConfig) error {
 	// Step 1: Determine working directory
 	workDir := config.Path
@@ -64,77 +143,34 @@ func Run(config *RunConfig) error {
 	}
 
 	// Step 2: Handle worktree logic
-	var mountPath string
-	var worktreeName string
-	var mainRepoGitDir string // Path to main repo's .git directory for mounting
-
-	if config.NoWorktree {
-		// Use directory directly
-		mountPath = workDir
-		worktreeName = "no-worktree"
-	} else {
-		// Check if git repo
-		if !git.IsGitRepo(workDir) {
-			if config.Worktree != "" {
-				return fmt.Errorf("--worktree specified but %s is not a git repository", workDir)
-			}
-			// Not a git repo and no worktree flag: use directly
-			mountPath = workDir
-			worktreeName = "no-worktree"
-		} else {
-			// Is a git repo
-			explicitWorktree := config.Worktree != ""
-			if explicitWorktree {
-				worktreeName = config.Worktree
-			} else {
-				// Auto-detect from current branch
-				branch, err := git.GetCurrentBranch(workDir)
-				if err != nil {
-					return fmt.Errorf("failed to get current branch: %w", err)
-				}
-				worktreeName = branch
-			}
-
-			// Check if worktree exists
-			exists, err := git.WorktreeExists(worktreeName)
-			if err != nil {
-				return fmt.Errorf("failed to check worktree: %w", err)
-			}
+	worktreeName, err := resolveWorktreeName(workDir, config.Worktree, config.NoWorktree)
+	if err != nil {
+		return err
+	}
 
-			if exists {
-				// Worktree already exists - just use it
-				actualPath, err := git.GetWorktreePath(worktreeName)
-				if err != nil {
-					return fmt.Errorf("failed to get worktree path: %w", err)
-				}
-				mountPath = actualPath
-				if config.Verbose {
-					fmt.Fprintf(os.Stderr, "Using existing worktree at %s\n", mountPath)
-				}
-			} else {
-				// Create worktree
-				mountPath = git.DetermineWorktreePath(workDir, worktreeName)
-				if config.Verbose {
-					fmt.Fprintf(os.Stderr, "Creating worktree at %s\n", mountPath)
-				}
+	// Remote runs clone and copy the workspace before there is a command to print
+	if config.DryRun && config.remoteName() != "" {
+		return fmt.Errorf("--dry-run doesn't work with a remote host or daemon")
+	}
 
-				if err := git.CreateWorktree(mountPath, worktreeName, config.Verbose); err != nil {
-					return fmt.Errorf("failed to create worktree: %w", err)
-				}
-			}
+	// Changes to the copy are brought back as a git patch
+	if config.CopyWorkspace && !git.IsGitRepo(workDir) {
+		return fmt.Errorf("--copy-workspace needs a git repository, since changes are brought back with git")
+	}
 
-			// Get main repo's .git directory for mounting
-			// Resolve the real path (follow symlinks) to ensure .git paths match
-			realWorkDir, err := filepath.EvalSymlinks(workDir)
-			if err != nil {
-				realWorkDir = workDir // Fallback if can't resolve
-			}
-			mainRepoGitDir = filepath.Join(realWorkDir, ".git")
-		}
+	mountPath, mainRepoGitDir, err := prepareWorkspace(config, workDir, worktreeName)
+	if err != nil {
+		return err
+	}
+	// Directory holding .devcontainer; on a remote host the mounted checkout isn't local,
+	// and a dry run reads it from workDir for a worktree it didn't create
+	configDir := workDir
+	if config.RemoteHost == "" && fileExists(mountPath) {
+		configDir = mountPath
 	}
 
 	// Step 3: Load devcontainer config
-	devConfig, err := devcontainer.LoadConfig(mountPath)
+	devConfig, err := devcontainer.LoadConfig(configDir)
 	if err != nil {
 		return fmt.Errorf("failed to load devcontainer config: %w", err)
 	}
@@ -142,6 +178,7 @@ func Run(config *RunConfig) error {
 		// Use configured default image (supports custom default containers)
 		defaultImage := getConfiguredDefaultImage(config)
 		devConfig = devcontainer.GetDefaultConfig(defaultImage)
+		warnMissingToolchains(configDir, devConfig.Image)
 	}
 
 	// Step 4: Initialize container client
@@ -150,484 +187,205 @@ func Run(config *RunConfig) error {
 		return fmt.Errorf("failed to initialize container runtime: %w", err)
 	}
 
+	// Docker access for the container (flag or config, else devcontainer features)
+	dockerMode := config.Docker.Mode
+	if dockerMode == "" && config.UntrustedWorkspace {
+		if feature := devConfig.DockerFeature(); feature != "" {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring the devcontainer %s feature of an untrusted workspace (trust it with: packnplay trust)\n", feature)
+			dockerMode = dockerOffMode
+		}
+	}
+	dockerMode = dockerAccessMode(dockerMode, devConfig)
+	if names := devConfig.LocalEnvRefs(); len(names) > 0 && config.UntrustedWorkspace {
+		fmt.Fprintf(os.Stderr, "Warning: not passing host variables %s to an untrusted workspace's devcontainer (trust it with: packnplay trust)\n", strings.Join(names, ", "))
+	}
+	if err := checkDockerAccess(dockerMode, dockerClient.Command(), config.remoteName(), config.Network); err != nil {
+		return err
+	}
+
 	// Step 5: Ensure image available
-	if err := ensureImage(dockerClient, devConfig, mountPath, config.Verbose); err != nil {
+	if config.UntrustedWorkspace {
+		if err := checkBuildPaths(devConfig, configDir); err != nil {
+			return err
+		}
+	}
+	if config.DryRun {
+		dryRunNote(os.Stdout, "pulls or builds %s first", containerImageName(devConfig, filepath.Base(workDir)))
+	} else if err := ensureImage(dockerClient, devConfig, configDir, buildOptions{Secrets: config.BuildSecrets, SSH: config.BuildSSH, Cache: config.BuildCache, LocalEnv: config.localEnv()}, config.Verbose); err != nil {
 		return err
 	}
 
-	// Step 6: Generate container name and labels
+	// Step 6: Generate container name
 	projectName := filepath.Base(workDir)
-	containerName := container.GenerateContainerName(workDir, worktreeName)
-
-	// Use enhanced labels if launch info is available
-	var labels map[string]string
-	if config.HostPath != "" && config.LaunchCommand != "" {
-		labels = container.GenerateLabelsWithLaunchInfo(projectName, worktreeName, config.HostPath, config.LaunchCommand)
-	} else {
-		labels = container.GenerateLabels(projectName, worktreeName)
+	containerName := ContainerName(dockerClient, workDir, worktreeName)
+	if config.Ephemeral {
+		containerName = ephemeralName(container.GenerateContainerName(workDir, worktreeName))
 	}
 
 	// Step 7: Check if container already running
 	if isRunning, err := containerIsRunning(dockerClient, containerName); err != nil {
 		return fmt.Errorf("failed to check container status: %w", err)
 	} else if isRunning {
-		// Container is running - check if user wants to reconnect
-		if !config.Reconnect {
-			// Get detailed container information
-			details, err := getContainerDetails(dockerClient, containerName)
-			if err != nil {
-				// Fallback to basic error if we can't get details
-				return fmt.Errorf("container already running for this worktree (unable to get details: %v)", err)
-			}
-
-			// Build command string
-			var cmdStr strings.Builder
-			for i, arg := range config.Command {
-				if i > 0 {
-					cmdStr.WriteString(" ")
-				}
-				if strings.Contains(arg, " ") {
-					cmdStr.WriteString(fmt.Sprintf("'%s'", arg))
-				} else {
-					cmdStr.WriteString(arg)
-				}
-			}
-
-			// Determine current working directory
-			currentDir, err := os.Getwd()
-			if err != nil {
-				currentDir = ""
-			} else {
-				// Make absolute for comparison
-				currentDir, _ = filepath.Abs(currentDir)
-			}
-
-			// Determine if we need worktree flag (if current dir doesn't match container's host path)
-			needWorktreeFlag := true
-			if currentDir != "" && details.HostPath != "" {
-				// If current directory matches container's host path, we don't need --worktree
-				needWorktreeFlag = currentDir != details.HostPath
-			}
-
-			worktreeFlag := ""
-			if needWorktreeFlag && worktreeName != "no-worktree" {
-				worktreeFlag = fmt.Sprintf(" --worktree=%s", worktreeName)
-			}
-
-			// Build detailed error message
-			errorMsg := fmt.Sprintf("container already running for this worktree\n\n")
-			errorMsg += fmt.Sprintf("Container Details:\n")
-			errorMsg += fmt.Sprintf("  Name: %s\n", details.Names)
-			errorMsg += fmt.Sprintf("  Status: %s\n", details.Status)
-			errorMsg += fmt.Sprintf("  Project: %s\n", details.Project)
-			errorMsg += fmt.Sprintf("  Worktree: %s\n", details.Worktree)
-			if details.HostPath != "" {
-				errorMsg += fmt.Sprintf("  Host Path: %s\n", details.HostPath)
-			}
-			if details.LaunchCommand != "" {
-				errorMsg += fmt.Sprintf("  Original Command: %s\n", details.LaunchCommand)
-			}
-
-			errorMsg += fmt.Sprintf("\nTo run your command in the existing container:\n")
-			errorMsg += fmt.Sprintf("  packnplay run%s --reconnect %s\n", worktreeFlag, cmdStr.String())
-			errorMsg += fmt.Sprintf("\nTo stop the existing container:\n")
-			errorMsg += fmt.Sprintf("  packnplay stop %s", details.Names)
-
-			return fmt.Errorf(errorMsg)
-		}
-
-		// User explicitly wants to reconnect
-		if config.Verbose {
-			fmt.Fprintf(os.Stderr, "Reconnecting to existing container %s\n", containerName)
-		}
-
-		// Get container ID
-		containerID, err := getContainerID(dockerClient, containerName)
-		if err != nil {
-			return fmt.Errorf("failed to get container ID: %w", err)
-		}
-
-		// Exec into existing container
-		cmdPath, err := exec.LookPath(dockerClient.Command())
-		if err != nil {
-			return fmt.Errorf("failed to find docker command: %w", err)
-		}
+		return reconnect(dockerClient, containerName, workDir, worktreeName, devConfig, config)
+	}
 
-		// Use host path as working directory
-		execArgs := []string{
-			filepath.Base(cmdPath),
-			"exec",
-			"-it",
-			"-w", workDir, // Use resolved host path
-			containerID,
+	// Secret credentials only go into trusted images (typo-squatted or repo-provided ones may be malicious)
+	if config.DryRun {
+		if err := dryRunImageTrust(containerImageName(devConfig, projectName), config, os.Stdout); err != nil {
+			return err
 		}
-		execArgs = append(execArgs, config.Command...)
-
-		return syscall.Exec(cmdPath, execArgs, os.Environ())
+	} else if err := confirmImageTrust(containerImageName(devConfig, projectName), config); err != nil {
+		return err
 	}
 
 	// Remove any stopped containers with same name (required for clean start)
-	if config.Verbose {
-		fmt.Fprintf(os.Stderr, "Checking for stopped container with same name...\n")
+	if !config.Ephemeral && !config.DryRun {
+		if config.Verbose {
+			fmt.Fprintf(os.Stderr, "Checking for stopped container with same name...\n")
+		}
+		// Try to remove - ignore errors if container doesn't exist
+		_, _ = dockerClient.Run("rm", containerName)
 	}
-	// Try to remove - ignore errors if container doesn't exist
-	_, _ = dockerClient.Run("rm", containerName)
 
-	// Step 8: Get current user and detect OS
+	// Step 8: Gather what the container is started with
 	currentUser, err := user.Current()
 	if err != nil {
 		return fmt.Errorf("failed to get current user: %w", err)
 	}
-
-	// Check if we're on Linux (idmap only supported on Linux)
-	isLinux := os.Getenv("OSTYPE") == "linux-gnu" || fileExists("/proc/version")
-
-	// Note: Credentials are now managed by separate per-container files and watcher daemon
-	// No need for Keychain extraction during container startup
-
-	// Build docker run command for background container
-	// Apple Container doesn't support -it with -d (detached mode)
-	isApple := currentUser.HomeDir != "" && !isLinux && dockerClient.Command() == "container"
-	var args []string
-	if isApple {
-		args = []string{"run", "-d"}
-	} else {
-		args = []string{"run", "-d", "-it"} // -d for detached, keep -it for interactive
-	}
-
-	// Add labels
-	args = append(args, container.LabelsToArgs(labels)...)
-
-	// Add name
-	args = append(args, "--name", containerName)
-
-	// Add mounts with or without idmap based on OS
-	homeDir := currentUser.HomeDir
-
-	// Mount .claude directory, workspace, and git directory (if worktree)
-	// Note: idmap support is kernel/Docker version dependent, so we don't use it for now
-	// Just use simple volume mounts and run as container's default user
-
-	// Check if we need container-managed credentials
-	hostCredFile := filepath.Join(homeDir, ".claude", ".credentials.json")
-	var needsCredentialOverlay bool
-	var credentialFile string
-
-	// Check if host has meaningful credentials (not just empty file)
-	hostHasCredentials := false
-	if fileExists(hostCredFile) {
-		if stat, err := os.Stat(hostCredFile); err == nil && stat.Size() >= 20 {
-			hostHasCredentials = true
-		}
+	l, err := buildSpec(dockerClient, devConfig, config, runTarget{
+		containerName:  containerName,
+		workDir:        workDir,
+		worktreeName:   worktreeName,
+		mountPath:      mountPath,
+		mainRepoGitDir: mainRepoGitDir,
+		homeDir:        currentUser.HomeDir,
+		dockerMode:     dockerMode,
+	})
+	if err != nil {
+		return err
 	}
 
-	if !hostHasCredentials {
-		needsCredentialOverlay = true
-		if config.Verbose {
-			if !fileExists(hostCredFile) {
-				fmt.Fprintf(os.Stderr, "Host has no .credentials.json, using container-managed credentials\n")
-			} else {
-				fmt.Fprintf(os.Stderr, "Host .credentials.json is too small (%d bytes), using container-managed credentials\n", getFileSize(hostCredFile))
-			}
-		}
-
-		var err error
-		credentialFile, err = getOrCreateContainerCredentialFile(containerName)
-		if err != nil {
-			return fmt.Errorf("failed to get credential file: %w", err)
-		}
-	} else {
-		if config.Verbose {
-			fmt.Fprintf(os.Stderr, "Using host .credentials.json (%d bytes)\n", getFileSize(hostCredFile))
-		}
+	// Step 9: Start the sidecars the container joins; a --rm container takes them along
+	stopSidecars, err := startSidecars(dockerClient, l.spec, dockerMode, l.services, config)
+	if config.Ephemeral {
+		defer stopSidecars()
 	}
-
-	// Mount .claude directory
-	args = append(args, "-v", fmt.Sprintf("%s/.claude:/home/%s/.claude", homeDir, devConfig.RemoteUser))
-
-	// Overlay mount credential file after .claude directory mount
-	if needsCredentialOverlay {
-		args = append(args, "-v", fmt.Sprintf("%s:/home/%s/.claude/.credentials.json", credentialFile, devConfig.RemoteUser))
+	if err != nil {
+		return err
 	}
-
-	// Ensure parent directory exists in container by creating it on first run
-	// We'll create it after container starts but before exec
-
-	// Mount workspace at host path (preserving absolute paths)
-	args = append(args, "-v", fmt.Sprintf("%s:%s", mountPath, mountPath))
-
-	// Mount AI agent config directories if they exist
-	agentConfigDirs := []string{".codex", ".gemini", ".copilot", ".qwen", ".cursor", ".deepseek"}
-	for _, configDir := range agentConfigDirs {
-		agentPath := filepath.Join(homeDir, configDir)
-		if fileExists(agentPath) {
-			args = append(args, "-v", fmt.Sprintf("%s:/home/%s/%s", agentPath, devConfig.RemoteUser, configDir))
-			if config.Verbose {
-				fmt.Fprintf(os.Stderr, "Mounting %s config directory\n", configDir)
-			}
+	if len(l.syncedMounts) > 0 && !config.DryRun {
+		if err := createSyncVolumes(dockerClient, containerName, l.syncedMounts); err != nil {
+			RemoveSyncVolumes(dockerClient, containerName)
+			return err
 		}
 	}
 
-	// Mount .config/amp directory for Sourcegraph Amp CLI if it exists
-	ampConfigPath := filepath.Join(homeDir, ".config", "amp")
-	if fileExists(ampConfigPath) {
-		args = append(args, "-v", fmt.Sprintf("%s:/home/%s/.config/amp", ampConfigPath, devConfig.RemoteUser))
-		if config.Verbose {
-			fmt.Fprintf(os.Stderr, "Mounting amp config directory\n")
-		}
+	if config.DryRun {
+		return printDryRun(os.Stdout, dockerClient, l.spec, devConfig, config, l.claudeJSONSrc)
 	}
 
-	// If using a worktree, also mount the main repo's .git directory at its real path
-	// This allows the worktree's .git file (which contains gitdir: <path>) to resolve correctly
-	if mainRepoGitDir != "" {
-		args = append(args, "-v", fmt.Sprintf("%s:%s", mainRepoGitDir, mainRepoGitDir))
+	// With --rm the command keeps the container alive instead, and takes it along when it exits
+	if config.Ephemeral {
+		return runEphemeral(dockerClient, l.spec, devConfig, config, l.claudeJSONSrc, l.mcpRewrite)
 	}
 
-	// Mount git config
-	if config.Credentials.Git {
-		gitconfigPath := filepath.Join(homeDir, ".gitconfig")
-		if fileExists(gitconfigPath) {
-			// Resolve symlinks to get the actual file path
-			resolvedPath, err := resolveMountPath(gitconfigPath)
-			if err != nil {
-				if config.Verbose {
-					fmt.Fprintf(os.Stderr, "Warning: failed to resolve .gitconfig symlink: %v\n", err)
-				}
-				// Fall back to original path if symlink resolution fails
-				resolvedPath = gitconfigPath
-			}
-			args = append(args, "-v", fmt.Sprintf("%s:/home/%s/.gitconfig:ro", resolvedPath, devConfig.RemoteUser))
-		}
+	// Step 10: Start container in background and set it up
+	containerID, err := startContainer(dockerClient, devConfig, config, l)
+	if err != nil {
+		return err
 	}
 
-	// Mount SSH keys
-	if config.Credentials.SSH {
-		sshPath := filepath.Join(homeDir, ".ssh")
-		if fileExists(sshPath) {
-			args = append(args, "-v", fmt.Sprintf("%s:/home/%s/.ssh:ro", sshPath, devConfig.RemoteUser))
-		}
-	}
+	// Step 11: Exec into container with user's command
+	return execInContainer(dockerClient, containerID, l.spec.WorkingDir, devConfig, config)
+}
 
-	// Note: On macOS, gh credentials from Keychain are copied in after container starts
-	// On Linux, mount the gh config directory if it exists
-	if config.Credentials.GH && isLinux {
-		ghConfigPath := filepath.Join(homeDir, ".config", "gh")
-		if fileExists(ghConfigPath) {
-			args = append(args, "-v", fmt.Sprintf("%s:/home/%s/.config/gh", ghConfigPath, devConfig.RemoteUser))
+// reconnect runs the command in the worktree's container that is already running, when
+// --reconnect asks for it; otherwise it explains what is running there
+func reconnect(dockerClient *docker.Client, containerName, workDir, worktreeName string, devConfig *devcontainer.Config, config *RunConfig) error {
+	if !config.Reconnect {
+		details, err := getContainerDetails(dockerClient, containerName)
+		if err != nil {
+			// Fallback to basic error if we can't get details
+			return fmt.Errorf("container already running for this worktree (unable to get details: %v)", err)
 		}
-	}
-
-	if config.Credentials.GPG {
-		// Mount .gnupg directory (read-only for security)
-		gnupgPath := filepath.Join(homeDir, ".gnupg")
-		if fileExists(gnupgPath) {
-			args = append(args, "-v", fmt.Sprintf("%s:/home/%s/.gnupg:ro", gnupgPath, devConfig.RemoteUser))
+		currentDir, err := os.Getwd()
+		if err != nil {
+			currentDir = ""
+		} else {
+			currentDir, _ = filepath.Abs(currentDir)
 		}
+		return alreadyRunningError(details, config.Command, worktreeName, currentDir)
 	}
 
-	if config.Credentials.NPM {
-		// Mount .npmrc file
-		npmrcPath := filepath.Join(homeDir, ".npmrc")
-		if fileExists(npmrcPath) {
-			// Resolve symlinks to get the actual file path
-			resolvedPath, err := resolveMountPath(npmrcPath)
-			if err != nil {
-				if config.Verbose {
-					fmt.Fprintf(os.Stderr, "Warning: failed to resolve .npmrc symlink: %v\n", err)
-				}
-				// Fall back to original path if symlink resolution fails
-				resolvedPath = npmrcPath
-			}
-			args = append(args, "-v", fmt.Sprintf("%s:/home/%s/.npmrc:ro", resolvedPath, devConfig.RemoteUser))
-		}
+	// User explicitly wants to reconnect
+	if config.DryRun {
+		remoteEnv, unset := remoteEnvArgs(dockerClient, containerName, devConfig, config)
+		remoteEnv, _ = hostEnvByName(remoteEnv, devConfig)
+		printDryRunExec(os.Stdout, dockerClient.Command(), containerName, workDir, remoteEnv, unset, config.Command)
+		return nil
 	}
-
-	// AWS credentials handling
-	// Track which credentials we obtained and from where to enforce priority order
-	var awsCredentials map[string]string
-	var awsCredSource string
-
-	if config.Credentials.AWS {
-		awsCredentials = make(map[string]string)
-
-		// Priority 1: Check if static credentials are already set in environment
-		if aws.HasStaticCredentials() {
-			if config.Verbose {
-				fmt.Fprintf(os.Stderr, "Using existing AWS credentials from environment variables\n")
-			}
-			// Get all AWS_* env vars from host, these will be added later
-			for key, value := range aws.GetAWSEnvVars() {
-				awsCredentials[key] = value
-			}
-		} else {
-			// Priority 2: Try credential_process if AWS_PROFILE is set
-			awsProfile := os.Getenv("AWS_PROFILE")
-			if awsProfile != "" {
-				credentialProcess, err := aws.ParseAWSConfig(awsProfile)
-				if err != nil {
-					// Always warn, not just in verbose mode
-					fmt.Fprintf(os.Stderr, "Warning: failed to get credential_process for profile '%s': %v\n", awsProfile, err)
-				} else {
-					if config.Verbose {
-						fmt.Fprintf(os.Stderr, "Executing credential_process for profile '%s'\n", awsProfile)
-					}
-					creds, err := aws.GetCredentialsFromProcess(credentialProcess)
-					if err != nil {
-						// Always warn, not just in verbose mode
-						fmt.Fprintf(os.Stderr, "Warning: credential_process failed: %v\n", err)
-					} else {
-						awsCredSource = "credential_process"
-						if config.Verbose {
-							fmt.Fprintf(os.Stderr, "Successfully obtained AWS credentials from credential_process\n")
-						}
-						// Add credentials from credential_process
-						awsCredentials["AWS_ACCESS_KEY_ID"] = creds.AccessKeyID
-						awsCredentials["AWS_SECRET_ACCESS_KEY"] = creds.SecretAccessKey
-						if creds.SessionToken != "" {
-							awsCredentials["AWS_SESSION_TOKEN"] = creds.SessionToken
-						}
-						// Also include other AWS_* env vars (region, profile, etc.) but not credentials
-						for key, value := range aws.GetAWSEnvVars() {
-							if key != "AWS_ACCESS_KEY_ID" && key != "AWS_SECRET_ACCESS_KEY" && key != "AWS_SESSION_TOKEN" {
-								awsCredentials[key] = value
-							}
-						}
-					}
-				}
-			} else if config.Verbose {
-				fmt.Fprintf(os.Stderr, "No AWS_PROFILE set, skipping credential_process lookup\n")
-			}
-
-			// If credential_process didn't work, try getting from environment anyway
-			if awsCredSource == "" {
-				for key, value := range aws.GetAWSEnvVars() {
-					awsCredentials[key] = value
-				}
-				if len(awsCredentials) > 0 {
-					if config.Verbose {
-						fmt.Fprintf(os.Stderr, "Using AWS environment variables from host\n")
-					}
-				}
-			}
-		}
-
-		// Mount ~/.aws directory if it exists (read-write for SSO token refresh)
-		awsPath := filepath.Join(homeDir, ".aws")
-		if fileExists(awsPath) {
-			// Use read-write mount to allow SSO token refresh and CLI caching
-			args = append(args, "-v", fmt.Sprintf("%s:/home/%s/.aws", awsPath, devConfig.RemoteUser))
-			if config.Verbose {
-				fmt.Fprintf(os.Stderr, "Mounting AWS config directory (read-write for token refresh)\n")
-			}
-		} else {
-			// Always warn if ~/.aws is missing, not just in verbose
-			fmt.Fprintf(os.Stderr, "Warning: ~/.aws directory not found, AWS CLI config and SSO cache unavailable\n")
-		}
+	if config.Verbose {
+		fmt.Fprintf(os.Stderr, "Reconnecting to existing container %s\n", containerName)
 	}
 
-	workingDir := mountPath
-
-	// Set working directory to host path
-	args = append(args, "-w", workingDir)
-
-	// Add environment variables
-	// Only pass safe terminal/locale variables - nothing else from host
-	safeEnvVars := []string{"TERM", "LANG", "LC_ALL", "LC_CTYPE", "LC_MESSAGES", "COLORTERM"}
-	for _, key := range safeEnvVars {
-		if value := os.Getenv(key); value != "" {
-			args = append(args, "-e", fmt.Sprintf("%s=%s", key, value))
-		}
+	// Get container ID
+	containerID, err := getContainerID(dockerClient, containerName)
+	if err != nil {
+		return fmt.Errorf("failed to get container ID: %w", err)
 	}
+	config.Events.Emit(events.Event{Event: events.Reconnected, Container: containerName, ContainerID: containerID})
 
-	// Set HOME to container user's home directory (don't use host HOME)
-	args = append(args, "-e", fmt.Sprintf("HOME=/home/%s", devConfig.RemoteUser))
-
-	// Add IS_SANDBOX marker so tools know they're in a sandbox
-	args = append(args, "-e", "IS_SANDBOX=1")
-
-	// Don't set PATH - use container's default PATH to avoid host pollution
-
-	// Add default environment variables (API keys for AI agents)
-	for _, envVar := range config.DefaultEnvVars {
-		if value := os.Getenv(envVar); value != "" {
-			args = append(args, "-e", fmt.Sprintf("%s=%s", envVar, value))
+	// Bring .claude.json up to date in both directions before handing over
+	if claudeJSONSyncEnabled(config.ClaudeJSONSync) {
+		hostHome, _ := os.UserHomeDir()
+		if err := syncClaudeJSON(dockerClient, containerID, containerName, hostHome, devConfig.RemoteUser, config.ClaudeJSONSync, config.MCPServers, config.Verbose); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to sync .claude.json: %v\n", err)
 		}
 	}
 
-	// Add AWS environment variables BEFORE user-specified env vars
-	// This allows users to override AWS credentials if needed with --env flags
-	if config.Credentials.AWS && len(awsCredentials) > 0 {
-		// Add in deterministic order to avoid randomness from map iteration
-		// Priority order: credentials first, then config vars
-		credentialKeys := []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN"}
-		for _, key := range credentialKeys {
-			if value, exists := awsCredentials[key]; exists {
-				args = append(args, "-e", fmt.Sprintf("%s=%s", key, value))
-			}
-		}
-		// Then add other AWS vars (region, profile, etc.) in sorted order
-		var otherKeys []string
-		for key := range awsCredentials {
-			isCredKey := false
-			for _, credKey := range credentialKeys {
-				if key == credKey {
-					isCredKey = true
-					break
-				}
-			}
-			if !isCredKey {
-				otherKeys = append(otherKeys, key)
-			}
-		}
-		// Sort for deterministic output
-		for _, key := range otherKeys {
-			args = append(args, "-e", fmt.Sprintf("%s=%s", key, awsCredentials[key]))
-		}
-	}
+	// Agents restarted and credentials expired while the container kept running
+	refreshSession(dockerClient, containerID, config)
 
-	// Add user-specified env vars from --env flags (these can override defaults and AWS)
-	for _, env := range config.Env {
-		// Support both --env KEY=value and --env KEY (pass through from host)
-		if strings.Contains(env, "=") {
-			// KEY=value format - set specific value
-			args = append(args, "-e", env)
-		} else {
-			// KEY format - pass through current value from host
-			if value := os.Getenv(env); value != "" {
-				args = append(args, "-e", fmt.Sprintf("%s=%s", env, value))
-			}
-		}
-	}
+	// Exec into existing container, using host path as working directory
+	return execInContainer(dockerClient, containerID, workDir, devConfig, config)
+}
 
-	// Add port mappings
-	for _, port := range config.PublishPorts {
-		args = append(args, "-p", port)
-	}
+// startContainer starts the container l describes in the background and sets it up:
+// the workspace's directories, copies of files that can't be mounted, .claude.json and
+// credentials. The container is removed again when that fails.
+func startContainer(dockerClient *docker.Client, devConfig *devcontainer.Config, config *RunConfig, l *launch) (string, error) {
+	caps := dockerClient.Capabilities()
+	containerName := l.spec.Name
 
-	// Add image
-	imageName := devConfig.Image
-	if devConfig.DockerFile != "" {
-		imageName = fmt.Sprintf("packnplay-%s-devcontainer:latest", projectName)
+	removeEnvFile, err := writeEnvFile(l.spec)
+	if err != nil {
+		return "", err
 	}
-	args = append(args, imageName)
-
-	// Add a command that keeps container alive
-	args = append(args, "sleep", "infinity")
+	defer removeEnvFile()
+	args := append(startCommand(false, caps.DetachedTTY, false), l.spec.Args()...)
+	args = append(args, l.spec.Positional()...)
 
-	// Step 9: Start container in background
 	if config.Verbose {
 		fmt.Fprintf(os.Stderr, "Starting container %s\n", containerName)
-		fmt.Fprintf(os.Stderr, "Full command: docker %v\n", args)
+		fmt.Fprintf(os.Stderr, "Full command: docker %v\n", redactEnv(args, l.spec.SecretEnv))
 	}
 
+	// Recorded until the container is set up, so one left half set up by a crash is found next time
+	startOp := beginOperation(inflight.Operation{Kind: inflight.Container, Target: containerName, Runtime: dockerClient.Command()}, config.Verbose)
+	defer startOp.Done()
+
 	containerID, err := dockerClient.Run(args...)
+	// The runtime has read the secrets; they don't stay on disk for the session
+	removeEnvFile()
 	if err != nil {
-		return fmt.Errorf("failed to start container: %w\nDocker output:\n%s", err, containerID)
+		RemoveContainerState(dockerClient, containerName)
+		return "", fmt.Errorf("failed to start container: %w\nDocker output:\n%s", err, containerID)
 	}
 	containerID = strings.TrimSpace(containerID)
+	config.Events.Emit(events.Event{Event: events.Created, Container: containerName, ContainerID: containerID})
 
-	// Step 10: Ensure host directory structure exists in container
-	dirCommands := generateDirectoryCreationCommands(mountPath)
+	// Ensure host directory structure exists in container
+	dirCommands := generateDirectoryCreationCommands(l.spec.WorkingDir)
 	for _, dirCmd := range dirCommands {
 		if config.Verbose {
 			fmt.Fprintf(os.Stderr, "Creating directory structure: %v\n", dirCmd)
@@ -635,54 +393,97 @@ func Run(config *RunConfig) error {
 		_, err := dockerClient.Run(append([]string{"exec", containerID}, dirCmd...)...)
 		if err != nil {
 			_, _ = dockerClient.Run("rm", "-f", containerID)
-			return fmt.Errorf("failed to create directory structure: %w", err)
+			RemoveContainerState(dockerClient, containerName)
+			return "", fmt.Errorf("failed to create directory structure: %w", err)
 		}
 	}
+	chownCacheParents(dockerClient, containerID, devConfig.RemoteUser, l.dependencyCaches, config.Verbose)
 
-	// Step 11: Copy config files into container
-
-	// Copy ~/.claude.json
-	claudeConfigSrc := filepath.Join(homeDir, ".claude.json")
-	if _, err := os.Stat(claudeConfigSrc); err == nil {
-		if err := copyFileToContainer(dockerClient, containerID, claudeConfigSrc, fmt.Sprintf("/home/%s/.claude.json", devConfig.RemoteUser), devConfig.RemoteUser, config.Verbose); err != nil {
+	// Copy config files into container
+	copyRemoteFiles(dockerClient, containerID, devConfig.RemoteUser, l.remoteCopies, l.creds, config.Verbose)
+	if len(l.syncedMounts) > 0 {
+		if err := seedSyncedMounts(dockerClient, containerID, devConfig.RemoteUser, l.syncedMounts, config.Verbose); err != nil {
+			_, _ = dockerClient.Run("rm", "-f", containerID)
+			RemoveContainerState(dockerClient, containerName)
+			return "", fmt.Errorf("failed to copy the workspace into volumes: %w", err)
+		}
+		if err := snapshotCopiedWorkspace(dockerClient, containerID, l.sync); err != nil {
 			_, _ = dockerClient.Run("rm", "-f", containerID)
-			return fmt.Errorf("failed to copy .claude.json: %w", err)
+			RemoveContainerState(dockerClient, containerName)
+			return "", err
+		}
+		if !config.CopyWorkspace {
+			startWorkspaceSync(dockerClient.Command(), containerName, l.sync, config.Verbose)
 		}
 	}
 
-	// Copy container-managed credentials into place if needed (host has no .credentials.json)
-	hostCredFile2 := filepath.Join(homeDir, ".claude", ".credentials.json")
-	if !fileExists(hostCredFile2) {
-		if config.Verbose {
-			fmt.Fprintf(os.Stderr, "Copying container credentials into .claude directory...\n")
+	// Copy ~/.claude.json (with MCP server rewrites applied)
+	if _, err := os.Stat(l.claudeJSONSrc); err == nil {
+		if err := copyClaudeJSONToContainer(dockerClient, containerID, l.claudeJSONSrc, fmt.Sprintf("/home/%s/.claude.json", devConfig.RemoteUser), devConfig.RemoteUser, l.mcpRewrite, config.Verbose); err != nil {
+			_, _ = dockerClient.Run("rm", "-f", containerID)
+			RemoveContainerState(dockerClient, containerName)
+			return "", fmt.Errorf("failed to copy .claude.json: %w", err)
 		}
-		// Copy from mounted temp location to .claude directory
-		_, err = dockerClient.Run("exec", containerID, "cp", "/tmp/packnplay-credentials.json", fmt.Sprintf("/home/%s/.claude/.credentials.json", devConfig.RemoteUser))
-		if err != nil && config.Verbose {
-			fmt.Fprintf(os.Stderr, "Warning: failed to copy credentials: %v\n", err)
+		if claudeJSONSyncEnabled(config.ClaudeJSONSync) {
+			if err := recordClaudeJSONSync(containerName, l.claudeJSONSrc, l.mcpRewrite); err != nil && config.Verbose {
+				fmt.Fprintf(os.Stderr, "Warning: failed to record .claude.json l.sync state: %v\n", err)
+			}
 		}
 	}
 
-	// Step 11: Exec into container with user's command
-	cmdPath, err := exec.LookPath(dockerClient.Command())
-	if err != nil {
-		return fmt.Errorf("failed to find docker command: %w", err)
+	// Copy container-managed credentials into place if needed (host has no .credentials.json)
+	copyContainerCredentials(dockerClient, containerID, containerName, l.homeDir, devConfig.RemoteUser, config.Verbose)
+	if err := l.creds.err(); err != nil {
+		_, _ = dockerClient.Run("rm", "-f", containerID)
+		RemoveContainerState(dockerClient, containerName)
+		return "", err
 	}
 
-	execArgs := []string{
-		filepath.Base(cmdPath),
-		"exec",
-		"-it",
-		"-w", workingDir, // Now uses host path
-		containerID,
+	if l.gpgAgentForwarded {
+		setupGPGAgent(dockerClient, containerID, devConfig.RemoteUser, config.Verbose)
 	}
-	execArgs = append(execArgs, config.Command...)
+	return containerID, nil
+}
 
-	// Use syscall.Exec to replace current process
-	return syscall.Exec(cmdPath, execArgs, os.Environ())
+// warnMissingToolchains points out toolchains the project's manifests call for that the
+// default image variant in use doesn't include. Custom images aren't second-guessed.
+func warnMissingToolchains(projectDir, image string) {
+	variant, ok := toolchain.VariantOf(image)
+	if !ok {
+		return
+	}
+	detected := toolchain.Detect(projectDir)
+	missing := toolchain.Missing(variant, detected)
+	if len(missing) == 0 {
+		return
+	}
+	var found []string
+	for _, tc := range missing {
+		found = append(found, tc.Manifest)
+	}
+	fmt.Fprintf(os.Stderr, "Warning: the %s default image has no %s toolchain (found %s); use --image-variant=%s, or pin an image with: packnplay init --auto\n",
+		variant, toolchain.Names(missing), strings.Join(found, ", "), toolchain.BestVariant(detected))
 }
 
-func ensureImage(dockerClient *docker.Client, config *devcontainer.Config, projectPath string, verbose bool) error {
+// containerImageName returns the image the container runs: the configured image, or the
+// one built from the devcontainer's Dockerfile
+func containerImageName(devConfig *devcontainer.Config, projectName string) string {
+	if devConfig.DockerFile != "" {
+		return fmt.Sprintf("packnplay-%s-devcontainer:latest", projectName)
+	}
+	return devConfig.Image
+}
+
+// buildArgs returns the docker build command for a devcontainer Dockerfile, labelling the
+// image with buildHash so later runs can tell when it is out of date. Build args are
+// expanded with localEnv.
+func buildArgs(config *devcontainer.Config, projectPath, imageName, buildHash string, localEnv devcontainer.LookupFunc) []string {
+	args := []string{"build", "-f", config.DockerfilePath(projectPath), "-t", imageName, "--label", devcontainer.BuildHashLabel + "=" + buildHash}
+	args = append(args, config.BuildArgs(localEnv)...)
+	return append(args, config.BuildContext(projectPath))
+}
+
+func ensureImage(dockerClient *docker.Client, config *devcontainer.Config, projectPath string, opts buildOptions, verbose bool) error {
 	var imageName string
 
 	if config.DockerFile != "" {
@@ -690,18 +491,44 @@ func ensureImage(dockerClient *docker.Client, config *devcontainer.Config, proje
 		projectName := filepath.Base(projectPath)
 		imageName = fmt.Sprintf("packnplay-%s-devcontainer:latest", projectName)
 
-		// Check if already built
-		_, err := dockerClient.Run("image", "inspect", imageName)
+		buildHash, err := config.BuildHash(projectPath, opts.LocalEnv)
 		if err != nil {
-			// Need to build
-			if verbose {
+			return err
+		}
+
+		// Build when the image is missing or was built from a different Dockerfile or args
+		builtHash, err := dockerClient.Run("image", "inspect", "--format", fmt.Sprintf("{{index .Config.Labels %q}}", devcontainer.BuildHashLabel), imageName)
+		if err != nil || strings.TrimSpace(builtHash) != buildHash {
+			if err == nil {
+				fmt.Fprintf(os.Stderr, "Dockerfile or build args changed, rebuilding %s\n", imageName)
+			} else if verbose {
 				fmt.Fprintf(os.Stderr, "Building image from %s\n", config.DockerFile)
 			}
 
-			dockerfilePath := filepath.Join(projectPath, ".devcontainer", config.DockerFile)
-			contextPath := filepath.Join(projectPath, ".devcontainer")
+			args := buildArgs(config, projectPath, imageName, buildHash, opts.LocalEnv)
+			if args, err = opts.apply(args, dockerClient.Capabilities().BuildKit); err != nil {
+				return err
+			}
+			var buildEnv []string
+			if dockerClient.Command() == "docker" && os.Getenv("DOCKER_BUILDKIT") == "" {
+				// The legacy builder of older Docker releases has no --secret, --ssh or
+				// Dockerfile.dockerignore
+				buildEnv = append(buildEnv, "DOCKER_BUILDKIT=1")
+			}
+			patterns, extra, err := config.IgnorePatterns(projectPath)
+			if err != nil {
+				return fmt.Errorf("failed to read build context ignore files: %w", err)
+			}
+			warnLargeBuildContext(config.BuildContext(projectPath), patterns, verbose)
+			if extra {
+				var cleanup func()
+				if args, cleanup, err = withIgnorePatterns(args, dockerClient.IsPodman(), patterns); err != nil {
+					return fmt.Errorf("failed to apply %s: %w", devcontainer.IgnoreFile, err)
+				}
+				defer cleanup()
+			}
 
-			output, err := dockerClient.Run("build", "-f", dockerfilePath, "-t", imageName, contextPath)
+			output, err := dockerClient.RunWithEnv(buildEnv, args...)
 			if err != nil {
 				return fmt.Errorf("failed to build image from %s: %w\nDocker output:\n%s", config.DockerFile, err, output)
 			}
@@ -734,48 +561,28 @@ func ensureImage(dockerClient *docker.Client, config *devcontainer.Config, proje
 	return nil
 }
 
-func containerIsRunning(dockerClient *docker.Client, name string) (bool, error) {
-	// Apple Container doesn't support --filter, so get all and filter client-side
-	isApple := dockerClient.Command() == "container"
-
-	var output string
-	var err error
+// ContainerName returns the name of the container for a project directory and worktree,
+// which is the legacy name without the project hash for containers started before it
+func ContainerName(dockerClient *docker.Client, workDir, worktreeName string) string {
+	return container.ResolveContainerName(workDir, worktreeName, func(name string) (map[string]string, bool) {
+		info, err := dockerClient.InspectContainer(name)
+		if err != nil {
+			return nil, false
+		}
+		return info.Config.Labels, true
+	})
+}
 
-	if isApple {
-		output, err = dockerClient.Run("ps", "--format", "json")
-	} else {
-		output, err = dockerClient.Run("ps", "--filter", fmt.Sprintf("name=%s", name), "--format", "{{.Names}}")
+func containerIsRunning(dockerClient *docker.Client, name string) (bool, error) {
+	// Inspect by exact name
+	info, err := dockerClient.InspectContainer(name)
+	if errors.Is(err, docker.ErrNoSuchContainer) {
+		return false, nil
 	}
-
 	if err != nil {
 		return false, err
 	}
-
-	// For Apple Container, output is JSON array
-	if isApple {
-		// Check if container exists AND is running
-		// Look for: "id":"<name>" followed by "status":"running"
-		idMatch := fmt.Sprintf(`"id":"%s"`, name)
-		if !strings.Contains(output, idMatch) {
-			return false, nil
-		}
-
-		// Find the container object and check if status is running
-		// Simple check: find the id, then check if "status":"running" appears before next "id"
-		idIdx := strings.Index(output, idMatch)
-		nextIdIdx := strings.Index(output[idIdx+len(idMatch):], `"id":"`)
-		var searchRegion string
-		if nextIdIdx == -1 {
-			searchRegion = output[idIdx:]
-		} else {
-			searchRegion = output[idIdx : idIdx+len(idMatch)+nextIdIdx]
-		}
-
-		return strings.Contains(searchRegion, `"status":"running"`), nil
-	}
-
-	// Docker/Podman - simple name matching
-	return strings.TrimSpace(output) == name, nil
+	return info.State.Running, nil
 }
 
 // getContainerDetails gets detailed information about a container
@@ -801,11 +608,7 @@ func getContainerDetails(dockerClient *docker.Client, name string) (*ContainerDe
 	}
 
 	// Parse the first (and should be only) line
-	var containerInfo struct {
-		Names  string `json:"Names"`
-		Status string `json:"Status"`
-		Labels string `json:"Labels"`
-	}
+	var containerInfo docker.PSEntry
 
 	if err := json.Unmarshal([]byte(lines[0]), &containerInfo); err != nil {
 		return nil, fmt.Errorf("failed to parse container info: %w", err)
@@ -849,33 +652,12 @@ func parseLabelsFromString(labels string) (project, worktree, hostPath, launchCo
 
 // getContainerID gets the container ID by name
 func getContainerID(dockerClient *docker.Client, name string) (string, error) {
-	isApple := dockerClient.Command() == "container"
-
-	var output string
-	var err error
-
-	if isApple {
-		output, err = dockerClient.Run("ps", "--format", "json")
-	} else {
-		output, err = dockerClient.Run("ps", "--filter", fmt.Sprintf("name=%s", name), "--format", "{{.ID}}")
-	}
-
+	// Inspect by exact name; Apple Container uses the name as the ID
+	info, err := dockerClient.InspectContainer(name)
 	if err != nil {
 		return "", err
 	}
-
-	// For Apple Container, search for container with matching ID in JSON
-	if isApple {
-		idPrefix := fmt.Sprintf(`"id":"%s"`, name)
-		if !strings.Contains(output, idPrefix) {
-			return "", fmt.Errorf("container not found")
-		}
-		// Container name IS the ID in Apple Container
-		return name, nil
-	}
-
-	// Docker/Podman - ID in output
-	return strings.TrimSpace(output), nil
+	return info.ID, nil
 }
 
 func fileExists(path string) bool {
@@ -1011,15 +793,28 @@ func (i *ImageVersionInfo) ShortDigest() string {
 // VersionTracker tracks which image versions have been seen and notified
 type VersionTracker struct {
 	notifications map[string]time.Time // image:digest -> when notified
+	frequency     time.Duration        // how long before notifying about the same version again
 }
 
 // NewVersionTracker creates a new version tracker
 func NewVersionTracker() *VersionTracker {
 	return &VersionTracker{
 		notifications: make(map[string]time.Time),
+		frequency:     24 * time.Hour,
 	}
 }
 
+// newVersionTrackerFrom creates a version tracker holding the notifications recorded in
+// tracking, repeated every frequencyHours
+func newVersionTrackerFrom(tracking *config.VersionTrackingData, frequencyHours int) *VersionTracker {
+	vt := NewVersionTracker()
+	vt.frequency = time.Duration(frequencyHours) * time.Hour
+	for image, notification := range tracking.Notifications {
+		vt.notifications[image+":"+notification.Digest] = notification.NotifiedAt
+	}
+	return vt
+}
+
 // HasNotified returns true if we've notified about this image:digest combination
 func (vt *VersionTracker) HasNotified(image, digest string) bool {
 	key := image + ":" + digest
@@ -1033,6 +828,12 @@ func (vt *VersionTracker) MarkNotified(image, digest string) {
 	vt.notifications[key] = time.Now()
 }
 
+// NotifiedAt returns when we notified about this image:digest combination, or the zero
+// time if we haven't
+func (vt *VersionTracker) NotifiedAt(image, digest string) time.Time {
+	return vt.notifications[image+":"+digest]
+}
+
 // getConfiguredDefaultImage returns the user's configured default image or fallback
 func getConfiguredDefaultImage(runConfig *RunConfig) string {
 	// For now, use the existing DefaultImage field
@@ -1069,7 +870,7 @@ type VersionCheckResult struct {
 
 // checkForNewVersion compares local and remote versions and determines if notification needed
 func checkForNewVersion(imageName string, localInfo, remoteInfo *ImageVersionInfo, tracker *VersionTracker) VersionCheckResult {
-	decision := shouldNotifyAboutVersion(localInfo.Digest, remoteInfo.Digest, time.Time{}, 24*time.Hour)
+	decision := shouldNotifyAboutVersion(localInfo.Digest, remoteInfo.Digest, tracker.NotifiedAt(imageName, remoteInfo.Digest), tracker.frequency)
 
 	return VersionCheckResult{
 		shouldNotify: decision.shouldNotify,
@@ -1080,12 +881,12 @@ func checkForNewVersion(imageName string, localInfo, remoteInfo *ImageVersionInf
 
 // formatVersionNotification creates a user-friendly notification message
 func formatVersionNotification(imageName string, localInfo, remoteInfo *ImageVersionInfo) string {
-	return fmt.Sprintf(`ℹ️  New version available: %s
+	return fmt.Sprintf(`%sNew version available: %s
    Current: %s (%s)
    Latest:  %s (%s)
 
    To update: packnplay refresh-container`,
-		imageName,
+		ui.Sym().Info, imageName,
 		localInfo.ShortDigest(), localInfo.AgeString(),
 		remoteInfo.ShortDigest(), remoteInfo.AgeString())
 }
@@ -1098,9 +899,17 @@ func checkAndNotifyAboutUpdates(dockerClient *docker.Client, imageName string, v
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	// Check if update checking is enabled
-	if !config.ShouldCheckForUpdates(cfg.DefaultContainer, time.Time{}) {
-		return nil // Checking disabled or too recent
+	// Check remote registry for new versions (only for default image)
+	if !cfg.DefaultContainer.CheckForUpdates || imageName != cfg.GetDefaultImage() {
+		return nil
+	}
+
+	// Quiet settings skip the check too, so it happens on the next run that may notify
+	if quiet, reason := cfg.Notifications.Silenced(time.Now(), os.Getenv); quiet {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Not checking for updates to %s: %s\n", imageName, reason)
+		}
+		return nil
 	}
 
 	// Load version tracking data
@@ -1110,16 +919,13 @@ func checkAndNotifyAboutUpdates(dockerClient *docker.Client, imageName string, v
 		return fmt.Errorf("failed to load version tracking: %w", err)
 	}
 
-	// Only check for updates if it's time to do so
-	if !config.ShouldCheckForUpdates(cfg.DefaultContainer, tracking.LastCheck) {
+	// Only check for updates if it's time to do so, at the image's own frequency if it has one
+	updates := cfg.DefaultContainer
+	updates.CheckFrequencyHours = cfg.Notifications.CheckFrequencyHours(imageName, updates.CheckFrequencyHours)
+	if !config.ShouldCheckForUpdates(updates, tracking.LastCheck) {
 		return nil
 	}
 
-	// Check remote registry for new versions (only for default image)
-	if imageName != cfg.GetDefaultImage() {
-		return nil // Only check updates for default image
-	}
-
 	// Get local image info
 	localInfo, err := getLocalImageInfo(dockerClient, imageName)
 	if err != nil {
@@ -1133,25 +939,29 @@ func checkAndNotifyAboutUpdates(dockerClient *docker.Client, imageName string, v
 	}
 
 	// Check if we should notify
-	result := checkForNewVersion(imageName, localInfo, remoteInfo, NewVersionTracker())
+	result := checkForNewVersion(imageName, localInfo, remoteInfo, newVersionTrackerFrom(tracking, updates.CheckFrequencyHours))
 
 	if result.shouldNotify {
 		// Show notification with specific version info
 		message := formatVersionNotification(imageName, result.localInfo, result.remoteInfo)
-		fmt.Println(message)
-
-		// Mark as notified and update tracking
-		tracking.Notifications[imageName] = config.VersionNotification{
-			Digest:     remoteInfo.Digest,
-			NotifiedAt: time.Now(),
-			ImageName:  imageName,
+		// stderr, so it never ends up in the command's piped output
+		fmt.Fprintln(os.Stderr, message)
+	}
+
+	// Record the check, and the notification, merged with what other runs recorded since
+	// it was loaded
+	err = config.UpdateVersionTracking(trackingPath, func(tracking *config.VersionTrackingData) {
+		if result.shouldNotify {
+			tracking.Notifications[imageName] = config.VersionNotification{
+				Digest:     remoteInfo.Digest,
+				NotifiedAt: time.Now(),
+				ImageName:  imageName,
+			}
 		}
 		tracking.LastCheck = time.Now()
-
-		// Save tracking data
-		if err := config.SaveVersionTracking(tracking, trackingPath); err != nil && verbose {
-			fmt.Fprintf(os.Stderr, "Warning: failed to save tracking data: %v\n", err)
-		}
+	})
+	if err != nil && verbose {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save tracking data: %v\n", err)
 	}
 
 	return nil
@@ -1194,7 +1004,6 @@ func getLocalImageInfo(dockerClient *docker.Client, imageName string) (*ImageVer
 	}, nil
 }
 
-
 // getOrCreateContainerCredentialFile manages shared credential file for all containers
 func getOrCreateContainerCredentialFile(containerName string) (string, error) {
 	// Get credentials directory
@@ -1213,7 +1022,7 @@ func getOrCreateContainerCredentialFile(containerName string) (string, error) {
 	if err := os.MkdirAll(credentialsDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create credentials dir: %w", err)
 	}
-	credentialFile := filepath.Join(credentialsDir, "claude-credentials.json")
+	credentialFile := filepath.Join(credentialsDir, credentials.SharedClaudeCredentials)
 
 	// If file doesn't exist, initialize it
 	if !fileExists(credentialFile) {
@@ -1268,15 +1077,6 @@ func copyFileToContainer(dockerClient *docker.Client, containerID, srcPath, dstP
 		fmt.Fprintf(os.Stderr, "Copying %s to container at %s\n", srcPath, dstPath)
 	}
 
-	// Check if this is Apple Container (no cp command)
-	isApple := dockerClient.Command() == "container"
-
-	if isApple {
-		// Apple Container: use exec with base64 to write file
-		return copyFileViaExec(dockerClient, containerID, srcPath, dstPath, user, verbose)
-	}
-
-	// Docker/Podman: use cp command
 	// Ensure parent directory exists in container
 	dstDir := filepath.Dir(dstPath)
 	output, err := dockerClient.Run("exec", containerID, "mkdir", "-p", dstDir)
@@ -1284,46 +1084,17 @@ func copyFileToContainer(dockerClient *docker.Client, containerID, srcPath, dstP
 		return fmt.Errorf("failed to create parent directory %s: %w\nDocker output:\n%s", dstDir, err, output)
 	}
 
-	// Copy file
-	containerDst := fmt.Sprintf("%s:%s", containerID, dstPath)
-	output, err = dockerClient.Run("cp", srcPath, containerDst)
-	if err != nil {
-		return fmt.Errorf("failed to copy file %s to %s: %w\nDocker output:\n%s", srcPath, dstPath, err, output)
+	// Copy file (Apple Container has no cp; its adapter streams the file through exec)
+	if err := dockerClient.CopyToContainer(srcPath, containerID, dstPath); err != nil {
+		return err
 	}
 
 	// Fix ownership (docker cp creates as root)
 	// Only chown the specific file, not the entire directory (might contain read-only mounts)
-	_, err = dockerClient.Run("exec", "-u", "root", containerID, "chown", fmt.Sprintf("%s:%s", user, user), dstPath)
+	_, err = dockerClient.Run("exec", "-u", "root", containerID, "chown", fileOwner(dockerClient, containerID, user), dstPath)
 	if err != nil && verbose {
 		fmt.Fprintf(os.Stderr, "Warning: failed to fix ownership: %v\n", err)
 	}
 
 	return nil
 }
-
-// copyFileViaExec copies a file using a temp directory mount (for Apple Container)
-func copyFileViaExec(dockerClient *docker.Client, containerID, srcPath, dstPath, user string, verbose bool) error {
-	// Create temp directory for file transfer
-	tempDir, err := os.MkdirTemp("", "packnplay-transfer-*")
-	if err != nil {
-		return fmt.Errorf("failed to create temp dir: %w", err)
-	}
-	defer func() { _ = os.RemoveAll(tempDir) }()
-
-	// Copy file to temp directory
-	tempFileName := filepath.Base(srcPath)
-	tempFilePath := filepath.Join(tempDir, tempFileName)
-
-	content, err := os.ReadFile(srcPath)
-	if err != nil {
-		return fmt.Errorf("failed to read source file: %w", err)
-	}
-
-	if err := os.WriteFile(tempFilePath, content, 0644); err != nil {
-		return fmt.Errorf("failed to write to temp file: %w", err)
-	}
-
-	// This function is no longer used for Apple Container
-	// Just return error for now
-	return fmt.Errorf("file copying not supported for Apple Container")
-}
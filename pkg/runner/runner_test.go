@@ -3,9 +3,14 @@ package runner
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/devcontainer"
+	"github.com/obra/packnplay/pkg/docker"
 )
 
 func TestGetOrCreateContainerCredentialFile(t *testing.T) {
@@ -120,4 +125,86 @@ func TestRunConfig(t *testing.T) {
 	if len(cfg.DefaultEnvVars) != 1 || cfg.DefaultEnvVars[0] != "ANTHROPIC_API_KEY" {
 		t.Errorf("RunConfig.DefaultEnvVars = %v, want [ANTHROPIC_API_KEY]", cfg.DefaultEnvVars)
 	}
-}
\ No newline at end of file
+}
+func TestBuildArgs(t *testing.T) {
+	devConfig := &devcontainer.Config{
+		DockerFile: "Dockerfile",
+		Build: &devcontainer.BuildConfig{
+			Dockerfile: "Dockerfile",
+			Context:    "..",
+			Args:       map[string]string{"VARIANT": "3.12"},
+			Target:     "dev",
+		},
+	}
+
+	got := buildArgs(devConfig, "/src/app", "packnplay-app-devcontainer:latest", "abc123", os.LookupEnv)
+	want := []string{
+		"build", "-f", "/src/app/.devcontainer/Dockerfile",
+		"-t", "packnplay-app-devcontainer:latest",
+		"--label", "packnplay-build-hash=abc123",
+		"--build-arg", "VARIANT=3.12",
+		"--target", "dev",
+		"/src/app",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestStartContainer(t *testing.T) {
+	tests := []struct {
+		name       string
+		execStatus int
+		wantErr    bool
+	}{
+		{"started", 0, false},
+		{"directories fail", 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logPath := fakeDockerCLI(t, "", "")
+			t.Setenv("XDG_DATA_HOME", t.TempDir())
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			// Answer run with a container ID, and exec as the test asks
+			script := `#!/bin/sh
+echo "$*" >> "` + logPath + `"
+case "$1" in
+run) echo abc123 ;;
+exec) exit ` + strconv.Itoa(tt.execStatus) + ` ;;
+esac
+`
+			if err := os.WriteFile(filepath.Join(filepath.Dir(logPath), "docker"), []byte(script), 0755); err != nil {
+				t.Fatal(err)
+			}
+			dockerClient, err := docker.NewClient(false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			spec := NewContainerSpec("packnplay-app-main")
+			spec.Image = "ubuntu:24.04"
+			spec.WorkingDir = "/work/app"
+			l := &launch{spec: spec, homeDir: home, claudeJSONSrc: filepath.Join(home, ".claude.json"), creds: &credentialCheck{}}
+			containerID, err := startContainer(dockerClient, &devcontainer.Config{RemoteUser: "vscode"}, &RunConfig{}, l)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("startContainer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && containerID != "abc123" {
+				t.Errorf("startContainer() = %q, want abc123", containerID)
+			}
+
+			calls, _ := os.ReadFile(logPath)
+			if !strings.Contains(string(calls), "run -d") || !strings.Contains(string(calls), "--name packnplay-app-main") {
+				t.Errorf("docker calls = %q, want the container started in the background", calls)
+			}
+			if !strings.Contains(string(calls), "exec abc123 mkdir -p /work") {
+				t.Errorf("docker calls = %q, want the workspace directories created", calls)
+			}
+			if removed := strings.Contains(string(calls), "rm -f abc123"); removed != tt.wantErr {
+				t.Errorf("docker calls = %q, container removed = %v", calls, removed)
+			}
+		})
+	}
+}
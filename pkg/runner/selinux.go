@@ -0,0 +1,135 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// selinuxEnforcePath holds "1" when SELinux is enforcing
+const selinuxEnforcePath = "/sys/fs/selinux/enforce"
+
+// selinuxEnforcing reports whether SELinux is enforcing on the host, where containers
+// can't read bind-mounted files unless they are labeled for containers
+func selinuxEnforcing(enforcePath string) bool {
+	data, err := os.ReadFile(enforcePath)
+	return err == nil && strings.TrimSpace(string(data)) == "1"
+}
+
+// selinuxSystemDirs are host directories that must keep their own labels, along with
+// everything under them
+var selinuxSystemDirs = []string{"/usr", "/etc", "/var", "/run", "/proc", "/sys", "/dev", "/boot"}
+
+// selinuxRelabelDirs returns the host directories whose mounts may be relabeled: the
+// project and worktree in projectDirs, and packnplay's own data and cache directories
+func selinuxRelabelDirs(homeDir string, projectDirs ...string) []string {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	if cacheHome == "" {
+		cacheHome = filepath.Join(homeDir, ".cache")
+	}
+	return append(projectDirs, filepath.Join(dataHome, "packnplay"), filepath.Join(cacheHome, "packnplay"))
+}
+
+// selinuxMountArgs labels the bind mounts in args for an SELinux-enforcing host. Mounts
+// under relabelDirs get the z option, which relabels the host files so any container may
+// share them. Nothing else is relabeled: credential directories in the home directory,
+// system directories and sockets keep their labels, so containers can't read them. For
+// those, labelDisable turns off label separation for the container, with a warning;
+// without it each one is reported.
+func selinuxMountArgs(args []string, homeDir string, relabelDirs []string, labelDisable bool) []string {
+	rewritten := make([]string, len(args))
+	copy(rewritten, args)
+	var unlabeled []string
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] != "-v" {
+			continue
+		}
+		parts := strings.SplitN(args[i+1], ":", 3)
+		if len(parts) < 2 || !filepath.IsAbs(parts[0]) {
+			// Named volumes are labeled by the runtime
+			continue
+		}
+		options := ""
+		if len(parts) == 3 {
+			options = parts[2]
+		}
+		if hasSELinuxOption(options) {
+			continue
+		}
+		if reason := selinuxKeepLabel(parts[0], homeDir, relabelDirs); reason != "" {
+			unlabeled = append(unlabeled, fmt.Sprintf("%s (%s)", parts[0], reason))
+			continue
+		}
+		if options == "" {
+			options = "z"
+		} else {
+			options += ",z"
+		}
+		rewritten[i+1] = parts[0] + ":" + parts[1] + ":" + options
+	}
+	if len(unlabeled) == 0 {
+		return rewritten
+	}
+	if labelDisable {
+		fmt.Fprintf(os.Stderr, "Warning: SELinux label separation is off for this container (--selinux-label-disable), so it isn't confined by SELinux; needed for %s\n", strings.Join(unlabeled, ", "))
+		return append(rewritten, "--security-opt", "label=disable")
+	}
+	for _, mount := range unlabeled {
+		fmt.Fprintf(os.Stderr, "Warning: SELinux keeps the container from reading %s; pass --selinux-label-disable to turn off label separation for this container\n", mount)
+	}
+	return rewritten
+}
+
+// pathWithin reports whether path is dir or under it
+func pathWithin(path, dir string) bool {
+	dir = filepath.Clean(dir)
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// hasSELinuxOption reports whether mount options already choose a label
+func hasSELinuxOption(options string) bool {
+	for _, option := range strings.Split(options, ",") {
+		if option == "z" || option == "Z" {
+			return true
+		}
+	}
+	return false
+}
+
+// selinuxKeepLabel returns why path must keep its label, or "" when it may be relabeled
+func selinuxKeepLabel(path, homeDir string, relabelDirs []string) string {
+	path = filepath.Clean(path)
+	if !slices.ContainsFunc(relabelDirs, func(dir string) bool { return pathWithin(path, dir) }) {
+		return "not the project's or packnplay's"
+	}
+	switch path {
+	case "/", "/home", "/root", "/tmp", filepath.Clean(homeDir):
+		return "a top-level directory"
+	}
+	for _, dir := range selinuxSystemDirs {
+		if path == dir || strings.HasPrefix(path, dir+"/") {
+			return "a system directory"
+		}
+	}
+	if homeDir != "" {
+		for _, name := range []string{".ssh", ".gnupg"} {
+			dir := filepath.Join(homeDir, name)
+			if path == dir || strings.HasPrefix(path, dir+"/") {
+				return "checked by " + strings.TrimPrefix(name, ".")
+			}
+		}
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		return "a socket"
+	}
+	if strings.HasPrefix(path, agentsRoot()+"/") {
+		return "a forwarded agent socket"
+	}
+	return ""
+}
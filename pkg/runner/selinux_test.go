@@ -0,0 +1,92 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSELinuxEnforcing(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"enforcing", "1\n", true},
+		{"permissive", "0\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if got := selinuxEnforcing(path); got != tt.want {
+				t.Errorf("selinuxEnforcing() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if selinuxEnforcing(filepath.Join(dir, "missing")) {
+		t.Error("selinuxEnforcing() without SELinux = true, want false")
+	}
+}
+
+func TestSELinuxMountArgs(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+	home := "/home/dev"
+	relabel := selinuxRelabelDirs(home, "/home/dev/src/app")
+
+	tests := []struct {
+		name         string
+		args         []string
+		labelDisable bool
+		want         []string
+	}{
+		{
+			"project and packnplay mounts are relabeled",
+			[]string{"run", "-v", "/home/dev/src/app:/home/dev/src/app", "-v", "/home/dev/.local/share/packnplay/credentials/claude.json:/home/user/.claude/.credentials.json:ro", "-e", "A=b"},
+			false,
+			[]string{"run", "-v", "/home/dev/src/app:/home/dev/src/app:z", "-v", "/home/dev/.local/share/packnplay/credentials/claude.json:/home/user/.claude/.credentials.json:ro,z", "-e", "A=b"},
+		},
+		{
+			"named volumes and explicit labels are left alone",
+			[]string{"-v", "cache:/cache", "-v", "/home/dev/data:/data:Z"},
+			false,
+			[]string{"-v", "cache:/cache", "-v", "/home/dev/data:/data:Z"},
+		},
+		{
+			"home credentials keep their labels",
+			[]string{"-v", "/home/dev/src/app:/app", "-v", "/home/dev/.aws:/home/user/.aws:ro", "-v", "/home/dev/.gitconfig:/home/user/.gitconfig:ro"},
+			false,
+			[]string{"-v", "/home/dev/src/app:/app:z", "-v", "/home/dev/.aws:/home/user/.aws:ro", "-v", "/home/dev/.gitconfig:/home/user/.gitconfig:ro"},
+		},
+		{
+			"label separation is only turned off when asked",
+			[]string{"-v", "/home/dev/src/app:/app", "-v", "/home/dev/.ssh:/home/user/.ssh:ro"},
+			true,
+			[]string{"-v", "/home/dev/src/app:/app:z", "-v", "/home/dev/.ssh:/home/user/.ssh:ro", "--security-opt", "label=disable"},
+		},
+		{
+			"system sockets aren't relabeled",
+			[]string{"-v", "/var/run/docker.sock:/var/run/docker.sock"},
+			false,
+			[]string{"-v", "/var/run/docker.sock:/var/run/docker.sock"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selinuxMountArgs(tt.args, home, relabel, tt.labelDisable); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selinuxMountArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// A project that is the home directory itself still isn't relabeled
+	if got := selinuxMountArgs([]string{"-v", "/home/dev:/home/dev"}, home, selinuxRelabelDirs(home, home), false); got[1] != "/home/dev:/home/dev" {
+		t.Errorf("selinuxMountArgs() relabeled the home directory: %q", got)
+	}
+}
@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/services"
+)
+
+// resolveServices fills in the project's services and sets the env vars that point the
+// container at them. Without a network there is nothing to reach them over, so none are
+// started then.
+func resolveServices(spec *ContainerSpec, declared map[string]config.ServiceConfig, volumePrefix string, network config.NetworkConfig) ([]services.Service, error) {
+	if len(declared) == 0 {
+		return nil, nil
+	}
+	if network.Mode == config.NetworkNone {
+		fmt.Fprintf(os.Stderr, "Warning: not starting the project's services with network mode none\n")
+		return nil, nil
+	}
+	resolved, err := services.Resolve(declared, volumePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	env, conflicts := services.ConnectEnv(resolved)
+	for _, conflict := range conflicts {
+		fmt.Fprintf(os.Stderr, "Warning: %s; set connect_env to tell them apart\n", conflict)
+	}
+	spec.Env = append(spec.Env, env...)
+	return resolved, nil
+}
+
+// startServices starts the services on the container's network: the one the egress
+// proxy or dind sidecar put it on, else one of their own, which is added to spec. They
+// are waited for, but one that is slow to come up only gets a warning.
+func startServices(dockerClient *docker.Client, containerName string, spec *ContainerSpec, resolved []services.Service, network config.NetworkConfig, dryRun, verbose bool) error {
+	if !dockerClient.Capabilities().Networks {
+		return fmt.Errorf("project services are not supported with %s", dockerClient.RuntimeName())
+	}
+	// The egress proxy's network is internal: nothing on it can be reached from the host
+	internal := network.Mode == config.NetworkAllowlist
+	name, createNetwork := specNetwork(spec), false
+	if name == "" {
+		name, createNetwork = services.NetworkName(containerName), true
+		spec.AddOption("--network", name)
+	}
+	if internal {
+		for _, service := range resolved {
+			if len(service.Ports) > 0 {
+				fmt.Fprintf(os.Stderr, "Warning: not publishing the ports of service %s on the isolated network\n", service.Name)
+			}
+		}
+	}
+	if dryRun {
+		return nil
+	}
+
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Starting %d services on network %s\n", len(resolved), name)
+	}
+	if err := services.Start(dockerClient, containerName, name, createNetwork, !internal, resolved); err != nil {
+		return err
+	}
+	if err := services.WaitReady(dockerClient, containerName, resolved); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return nil
+}
+
+// specNetwork returns the network spec already joins, if any
+func specNetwork(spec *ContainerSpec) string {
+	for i := 0; i+1 < len(spec.Options); i++ {
+		if spec.Options[i] == "--network" {
+			return spec.Options[i+1]
+		}
+	}
+	return ""
+}
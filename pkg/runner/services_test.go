@@ -0,0 +1,39 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/config"
+)
+
+func TestResolveServices(t *testing.T) {
+	declared := map[string]config.ServiceConfig{"cache": {Type: "redis"}}
+
+	spec := NewContainerSpec("packnplay-app-main")
+	resolved, err := resolveServices(spec, declared, "packnplay-app-main", config.NetworkConfig{})
+	if err != nil {
+		t.Fatalf("resolveServices() error = %v", err)
+	}
+	if len(resolved) != 1 || !reflect.DeepEqual(spec.Env, []string{"REDIS_URL=redis://cache:6379"}) {
+		t.Errorf("resolveServices() = %+v, env %q", resolved, spec.Env)
+	}
+
+	// Nothing could reach them without a network
+	spec = NewContainerSpec("packnplay-app-main")
+	resolved, err = resolveServices(spec, declared, "packnplay-app-main", config.NetworkConfig{Mode: config.NetworkNone})
+	if err != nil || resolved != nil || len(spec.Env) != 0 {
+		t.Errorf("resolveServices() with network none = %+v, %v, env %q", resolved, err, spec.Env)
+	}
+}
+
+func TestSpecNetwork(t *testing.T) {
+	spec := NewContainerSpec("packnplay-app-main")
+	if got := specNetwork(spec); got != "" {
+		t.Errorf("specNetwork() = %q, want none", got)
+	}
+	spec.AddArgs([]string{"--network", "packnplay-app-main-egress", "-e", "HTTP_PROXY=http://proxy:3128"})
+	if got := specNetwork(spec); got != "packnplay-app-main-egress" {
+		t.Errorf("specNetwork() = %q", got)
+	}
+}
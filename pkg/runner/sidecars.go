@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"os"
+
+	"github.com/obra/packnplay/pkg/dind"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/egress"
+	"github.com/obra/packnplay/pkg/services"
+)
+
+// startSidecars starts what the container joins: the egress proxy of an isolated network,
+// a docker-in-docker sidecar and the project's services, and adds the flags that join
+// them to spec. A dry run starts nothing and says what it would start. stop removes the
+// sidecars started so far, also when starting the rest failed.
+func startSidecars(dockerClient *docker.Client, spec *ContainerSpec, dockerMode string, projectServices []services.Service, config *RunConfig) (stop func(), err error) {
+	containerName := spec.Name
+	var started []func(docker.Runner, string)
+	stop = func() {
+		for i := len(started) - 1; i >= 0; i-- {
+			started[i](dockerClient, containerName)
+		}
+	}
+
+	// Network isolation: no network at all, or only the allowed domains through a proxy
+	if config.Network.Isolated() {
+		networkArgs, err := isolateNetwork(dockerClient, containerName, config.Network, config.DryRun, config.Verbose)
+		if err != nil {
+			return stop, err
+		}
+		spec.AddArgs(networkArgs)
+		if config.DryRun {
+			dryRunNote(os.Stdout, "starts the egress proxy %s", egress.Name(containerName))
+		} else {
+			started = append(started, egress.Stop)
+		}
+	}
+
+	// A docker-in-docker sidecar on a network shared with the container
+	if dockerMode == dockerDindMode {
+		dindArgs, err := startDind(dockerClient, containerName, config.Docker.DindImage, config.DryRun, config.Verbose)
+		if err != nil {
+			return stop, err
+		}
+		spec.AddArgs(dindArgs)
+		if config.DryRun {
+			dryRunNote(os.Stdout, "starts the docker-in-docker sidecar %s", dind.Name(containerName))
+		} else {
+			started = append(started, dind.Stop)
+		}
+	}
+
+	// The project's services, on the network the container joins
+	if len(projectServices) > 0 {
+		if err := startServices(dockerClient, containerName, spec, projectServices, config.Network, config.DryRun, config.Verbose); err != nil {
+			return stop, err
+		}
+		if config.DryRun {
+			for _, service := range projectServices {
+				dryRunNote(os.Stdout, "starts service %s (%s) as %s", service.Name, service.Image, services.ContainerName(containerName, service.Name))
+			}
+		} else {
+			started = append(started, services.Stop)
+		}
+	}
+	return stop, nil
+}
@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/dind"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/services"
+)
+
+func TestStartSidecarsDryRun(t *testing.T) {
+	redis := []services.Service{{Name: "cache", Image: "redis:7"}}
+	tests := []struct {
+		name       string
+		network    config.NetworkConfig
+		dockerMode string
+		services   []services.Service
+		want       string
+	}{
+		{"network none", config.NetworkConfig{Mode: config.NetworkNone}, dockerOffMode, nil, "--network none"},
+		{"dind", config.NetworkConfig{}, dockerDindMode, nil, "--network packnplay-app-main-dind -e DOCKER_HOST=" + dind.Host()},
+		{"services", config.NetworkConfig{}, dockerOffMode, redis, "--network " + services.NetworkName("packnplay-app-main")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logPath := fakeDockerCLI(t, "", "")
+			dockerClient, err := docker.NewClient(false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			spec := NewContainerSpec("packnplay-app-main")
+			stop, err := startSidecars(dockerClient, spec, tt.dockerMode, tt.services, &RunConfig{DryRun: true, Network: tt.network})
+			if err != nil {
+				t.Fatalf("startSidecars() error = %v", err)
+			}
+			stop()
+			if got := strings.Join(spec.Args(), " "); !strings.Contains(got, tt.want) {
+				t.Errorf("spec args = %q, want %q", got, tt.want)
+			}
+			// A dry run starts nothing, so there is nothing to stop either
+			calls, _ := os.ReadFile(logPath)
+			for _, call := range strings.Split(string(calls), "\n") {
+				if strings.HasPrefix(call, "run ") || strings.HasPrefix(call, "rm ") || strings.HasPrefix(call, "network ") {
+					t.Errorf("dry run called docker %s", call)
+				}
+			}
+		})
+	}
+}
+
+func TestStartSidecarsStop(t *testing.T) {
+	logPath := fakeDockerCLI(t, "", "")
+	dockerClient, err := docker.NewClient(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec := NewContainerSpec("packnplay-app-main")
+	stop, err := startSidecars(dockerClient, spec, dockerDindMode, nil, &RunConfig{})
+	if err != nil {
+		t.Fatalf("startSidecars() error = %v", err)
+	}
+	calls, _ := os.ReadFile(logPath)
+	if !strings.Contains(string(calls), "run -d --name "+dind.Name("packnplay-app-main")) {
+		t.Errorf("docker calls = %q, want the dind sidecar started", calls)
+	}
+
+	_ = os.Remove(logPath)
+	stop()
+	calls, _ = os.ReadFile(logPath)
+	if !strings.Contains(string(calls), "rm -f -v "+dind.Name("packnplay-app-main")) {
+		t.Errorf("docker calls = %q, want the dind sidecar removed", calls)
+	}
+}
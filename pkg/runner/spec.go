@@ -0,0 +1,176 @@
+package runner
+
+import (
+	"sort"
+	"strings"
+)
+
+// ContainerSpec is what a container is started with, gathered concern by concern
+// (workspace, credentials, limits, ports, network) before Args turns it into docker
+// run or create flags. Nothing in it touches the runtime, so it can be printed,
+// exported or checked in tests.
+type ContainerSpec struct {
+	Name       string
+	Hostname   string
+	Image      string
+	Command    []string // what keeps the container alive, or with --rm the command itself
+	WorkingDir string
+	Labels     map[string]string
+	Mounts     []string        // -v values; later ones mount over earlier ones
+	Env        []string        // KEY=value; later ones override earlier ones
+	SecretEnv  map[string]bool // names in Env whose values stay off the command line
+	EnvFile    string          // --env-file holding the SecretEnv values, once written
+	Ports      []string        // -p values
+	Options    []string        // other flags, each followed by its value
+}
+
+// NewContainerSpec returns an empty spec for the container name
+func NewContainerSpec(name string) *ContainerSpec {
+	return &ContainerSpec{
+		Name:      name,
+		Labels:    make(map[string]string),
+		SecretEnv: make(map[string]bool),
+	}
+}
+
+// AddMount adds a bind mount or volume in docker -v syntax
+func (s *ContainerSpec) AddMount(mount string) {
+	s.Mounts = append(s.Mounts, mount)
+}
+
+// AddEnv sets the environment variable key in the container
+func (s *ContainerSpec) AddEnv(key, value string) {
+	s.Env = append(s.Env, key+"="+value)
+}
+
+// AddSecretEnv sets key like AddEnv, keeping its value off the docker command line and
+// out of dry run output
+func (s *ContainerSpec) AddSecretEnv(key, value string) {
+	s.AddEnv(key, value)
+	s.SecretEnv[key] = true
+}
+
+// AddLabel sets a label on the container
+func (s *ContainerSpec) AddLabel(key, value string) {
+	s.Labels[key] = value
+}
+
+// AddPort publishes a port in docker -p syntax
+func (s *ContainerSpec) AddPort(port string) {
+	s.Ports = append(s.Ports, port)
+}
+
+// AddOption adds a flag along with its values, such as --ulimit nofile=1024
+func (s *ContainerSpec) AddOption(flag string, values ...string) {
+	s.Options = append(append(s.Options, flag), values...)
+}
+
+// AddArgs adds run arguments the way helpers that predate the spec return them:
+// -v, -e, -p and --label pairs go where they belong, everything else is an option
+func (s *ContainerSpec) AddArgs(args []string) {
+	for i := 0; i < len(args); i++ {
+		if i+1 < len(args) {
+			switch args[i] {
+			case "-v":
+				s.AddMount(args[i+1])
+				i++
+				continue
+			case "-e":
+				s.Env = append(s.Env, args[i+1])
+				i++
+				continue
+			case "-p":
+				s.AddPort(args[i+1])
+				i++
+				continue
+			case "--label":
+				key, value, _ := strings.Cut(args[i+1], "=")
+				s.AddLabel(key, value)
+				i++
+				continue
+			}
+		}
+		s.Options = append(s.Options, args[i])
+	}
+}
+
+// MountArgs returns the mounts as -v pairs
+func (s *ContainerSpec) MountArgs() []string {
+	args := make([]string, 0, len(s.Mounts)*2)
+	for _, mount := range s.Mounts {
+		args = append(args, "-v", mount)
+	}
+	return args
+}
+
+// RewriteMounts replaces the mounts with what rewrite makes of their -v pairs. Anything
+// else rewrite returns, such as a --security-opt, is added as with AddArgs.
+func (s *ContainerSpec) RewriteMounts(rewrite func(args []string) []string) {
+	args := rewrite(s.MountArgs())
+	s.Mounts = nil
+	s.AddArgs(args)
+}
+
+// Args returns the flags to start the container with, without the subcommand, image and
+// command. Labels are sorted so the same spec always gives the same arguments. Once
+// EnvFile is set, the secret env vars are read from it instead of given with -e.
+func (s *ContainerSpec) Args() []string {
+	keys := make([]string, 0, len(s.Labels))
+	for key := range s.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var args []string
+	for _, key := range keys {
+		args = append(args, "--label", key+"="+s.Labels[key])
+	}
+	args = append(args, "--name", s.Name)
+	if s.Hostname != "" {
+		args = append(args, "--hostname", s.Hostname)
+	}
+	args = append(args, s.MountArgs()...)
+	args = append(args, s.Options...)
+	if s.WorkingDir != "" {
+		args = append(args, "-w", s.WorkingDir)
+	}
+	for _, env := range s.Env {
+		if key, _, _ := strings.Cut(env, "="); s.EnvFile == "" || !s.SecretEnv[key] {
+			args = append(args, "-e", env)
+		}
+	}
+	if s.EnvFile != "" {
+		args = append(args, "--env-file", s.EnvFile)
+	}
+	for _, port := range s.Ports {
+		args = append(args, "-p", port)
+	}
+	return args
+}
+
+// SecretEnvEntries returns the final KEY=value of each secret env var, in the order they
+// were first set
+func (s *ContainerSpec) SecretEnvEntries() []string {
+	var keys []string
+	values := make(map[string]string)
+	for _, env := range s.Env {
+		key, value, _ := strings.Cut(env, "=")
+		if !s.SecretEnv[key] {
+			continue
+		}
+		if _, seen := values[key]; !seen {
+			keys = append(keys, key)
+		}
+		values[key] = value
+	}
+	entries := make([]string, len(keys))
+	for i, key := range keys {
+		entries[i] = key + "=" + values[key]
+	}
+	return entries
+}
+
+// Positional returns the image and command that follow the flags
+func (s *ContainerSpec) Positional() []string {
+	return append([]string{s.Image}, s.Command...)
+}
@@ -0,0 +1,125 @@
+package runner
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestContainerSpecAddArgs(t *testing.T) {
+	spec := NewContainerSpec("c")
+	spec.AddArgs([]string{"-v", "/a:/a", "--network", "n", "-e", "K=v", "-p", "80:80", "--label", "x=y=z", "--init"})
+
+	if !reflect.DeepEqual(spec.Mounts, []string{"/a:/a"}) {
+		t.Errorf("Mounts = %v", spec.Mounts)
+	}
+	if !reflect.DeepEqual(spec.Env, []string{"K=v"}) {
+		t.Errorf("Env = %v", spec.Env)
+	}
+	if !reflect.DeepEqual(spec.Ports, []string{"80:80"}) {
+		t.Errorf("Ports = %v", spec.Ports)
+	}
+	if spec.Labels["x"] != "y=z" {
+		t.Errorf("Labels = %v", spec.Labels)
+	}
+	if !reflect.DeepEqual(spec.Options, []string{"--network", "n", "--init"}) {
+		t.Errorf("Options = %v", spec.Options)
+	}
+}
+
+func TestContainerSpecArgs(t *testing.T) {
+	spec := NewContainerSpec("packnplay-app")
+	spec.Hostname = "app"
+	spec.WorkingDir = "/src/app"
+	spec.AddLabel("b", "2")
+	spec.AddLabel("a", "1")
+	spec.AddMount("/src/app:/src/app")
+	spec.AddOption("--ulimit", "nofile=1024")
+	spec.AddEnv("TERM", "xterm")
+	spec.AddSecretEnv("GH_TOKEN", "secret")
+	spec.AddPort("8080:80")
+
+	want := []string{
+		"--label", "a=1", "--label", "b=2",
+		"--name", "packnplay-app", "--hostname", "app",
+		"-v", "/src/app:/src/app",
+		"--ulimit", "nofile=1024",
+		"-w", "/src/app",
+		"-e", "TERM=xterm", "-e", "GH_TOKEN=secret",
+		"-p", "8080:80",
+	}
+	if got := spec.Args(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Args() = %v, want %v", got, want)
+	}
+	if !spec.SecretEnv["GH_TOKEN"] || spec.SecretEnv["TERM"] {
+		t.Errorf("SecretEnv = %v, want only GH_TOKEN", spec.SecretEnv)
+	}
+}
+
+func TestContainerSpecRewriteMounts(t *testing.T) {
+	spec := NewContainerSpec("c")
+	spec.AddMount("/a:/a")
+	spec.AddMount("/b:/b")
+	spec.AddOption("--init")
+
+	spec.RewriteMounts(func(args []string) []string {
+		var out []string
+		for i := 0; i+1 < len(args); i += 2 {
+			if !strings.HasPrefix(args[i+1], "/b") {
+				out = append(out, args[i], args[i+1]+":z")
+			}
+		}
+		return append(out, "--security-opt", "label=disable")
+	})
+
+	if !reflect.DeepEqual(spec.Mounts, []string{"/a:/a:z"}) {
+		t.Errorf("Mounts = %v", spec.Mounts)
+	}
+	if !reflect.DeepEqual(spec.Options, []string{"--init", "--security-opt", "label=disable"}) {
+		t.Errorf("Options = %v", spec.Options)
+	}
+}
+
+func TestContainerSpecEnvFile(t *testing.T) {
+	spec := NewContainerSpec("c")
+	spec.AddEnv("TERM", "xterm")
+	spec.AddSecretEnv("GH_TOKEN", "first")
+	spec.AddSecretEnv("AWS_SECRET_ACCESS_KEY", "aws")
+	spec.AddEnv("GH_TOKEN", "override") // --env after the credential
+
+	if got, want := spec.SecretEnvEntries(), []string{"GH_TOKEN=override", "AWS_SECRET_ACCESS_KEY=aws"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SecretEnvEntries() = %v, want %v", got, want)
+	}
+
+	remove, err := writeEnvFile(spec)
+	if err != nil {
+		t.Fatalf("writeEnvFile() error = %v", err)
+	}
+	info, err := os.Stat(spec.EnvFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("env file mode = %v, want 0600", info.Mode().Perm())
+	}
+	if data, _ := os.ReadFile(spec.EnvFile); string(data) != "GH_TOKEN=override\nAWS_SECRET_ACCESS_KEY=aws\n" {
+		t.Errorf("env file = %q", data)
+	}
+
+	args := strings.Join(spec.Args(), " ")
+	if strings.Contains(args, "override") || strings.Contains(args, "aws") || !strings.Contains(args, "-e TERM=xterm --env-file "+spec.EnvFile) {
+		t.Errorf("Args() = %s, want secrets only in the env file", args)
+	}
+
+	remove()
+	if _, err := os.Stat(spec.EnvFile); !os.IsNotExist(err) {
+		t.Error("the env file wasn't removed")
+	}
+
+	multiline := NewContainerSpec("c")
+	multiline.AddSecretEnv("KEY", "-----BEGIN\nEND-----")
+	if _, err := writeEnvFile(multiline); err == nil || !strings.Contains(err.Error(), "KEY") {
+		t.Errorf("writeEnvFile() error = %v, want the multi-line KEY refused", err)
+	}
+}
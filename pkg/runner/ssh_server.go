@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"fmt"
+
+	"github.com/obra/packnplay/pkg/devcontainer"
+	"github.com/obra/packnplay/pkg/sshserver"
+)
+
+// SSHServerConfig describes an SSH server to start in, or stop in, an existing container
+type SSHServerConfig struct {
+	Path       string
+	Worktree   string
+	NoWorktree bool
+	Runtime    string
+	Port       int // host port; 0 picks a free one
+	Stop       bool
+	Verbose    bool
+}
+
+// SSHServer starts an SSH server in the container that Run started for the same
+// directory and worktree, with keys made for it, and returns where to reach it. With
+// config.Stop it stops the server instead and returns nil.
+func SSHServer(config *SSHServerConfig) (*sshserver.Endpoint, error) {
+	dockerClient, containerID, mountPath, err := findRunningContainer(config.Path, config.Worktree, config.NoWorktree, config.Runtime, config.Verbose)
+	if err != nil {
+		return nil, err
+	}
+	if !dockerClient.Capabilities().Networks {
+		return nil, fmt.Errorf("ssh-server is not supported with %s", dockerClient.RuntimeName())
+	}
+	containerName := containerNameOf(dockerClient, containerID)
+	if containerName == "" {
+		return nil, fmt.Errorf("failed to get container name")
+	}
+
+	if config.Stop {
+		sshserver.Stop(dockerClient, containerName, true)
+		return nil, nil
+	}
+
+	devConfig, err := devcontainer.LoadConfig(mountPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load devcontainer config: %w", err)
+	}
+
+	port := config.Port
+	if port == 0 {
+		port, err = freeHostPort(portMapping{HostIP: "127.0.0.1"})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find a free port: %w", err)
+		}
+	}
+
+	keys, err := sshserver.GenerateKeys(sshserver.KeyDir(containerName), containerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate SSH keys: %w", err)
+	}
+	endpoint, err := sshserver.Start(dockerClient, containerName, devConfig.RemoteUser, keys, port)
+	if err != nil {
+		return nil, err
+	}
+	// Run mounts the project at its host path
+	endpoint.Dir = mountPath
+	return endpoint, nil
+}
@@ -0,0 +1,40 @@
+package runner
+
+import "fmt"
+
+// containerAgentSocket is where the forwarded ssh-agent socket appears in the container.
+// It has a directory of its own, so linkAgentMounts can mount a link directory there.
+const containerAgentSocket = "/tmp/packnplay-agent/ssh/agent.sock"
+
+// dockerDesktopAgentSocket is Docker Desktop's relay to the macOS host's ssh-agent. Host
+// unix sockets can't be bind mounted through its VM, so it forwards this one itself.
+const dockerDesktopAgentSocket = "/run/host-services/ssh-auth.sock"
+
+// sshAgentArgs returns the run arguments that forward the host ssh-agent into the
+// container, so it can authenticate with the user's keys without the key files ever
+// being mounted. authSock is the host's SSH_AUTH_SOCK. When the agent can't be forwarded
+// it returns a warning instead.
+func sshAgentArgs(runtime, authSock string, isLinux, remoteHost bool) ([]string, string) {
+	var source string
+	switch {
+	case remoteHost:
+		return nil, "Warning: the ssh-agent can't be forwarded to a remote host, skipping it"
+	case runtime == "container":
+		return nil, "Warning: Apple Container can't forward the ssh-agent, skipping it"
+	case !isLinux && runtime != "docker":
+		return nil, fmt.Sprintf("Warning: %s can't forward the ssh-agent on this host, only Docker Desktop can; skipping it", runtime)
+	case !isLinux:
+		source = dockerDesktopAgentSocket
+	case authSock == "":
+		return nil, "Warning: SSH_AUTH_SOCK is not set, so there is no ssh-agent to forward; start one with: eval $(ssh-agent) && ssh-add"
+	case !fileExists(authSock):
+		return nil, fmt.Sprintf("Warning: ssh-agent socket %s does not exist, skipping it", authSock)
+	default:
+		source = authSock
+	}
+
+	return []string{
+		"-v", fmt.Sprintf("%s:%s", source, containerAgentSocket),
+		"-e", "SSH_AUTH_SOCK=" + containerAgentSocket,
+	}, ""
+}
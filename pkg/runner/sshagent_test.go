@@ -0,0 +1,50 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSSHAgentArgs(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	if err := os.WriteFile(socket, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(t.TempDir(), "agent.sock")
+	forward := func(source string) []string {
+		return []string{"-v", source + ":" + containerAgentSocket, "-e", "SSH_AUTH_SOCK=" + containerAgentSocket}
+	}
+
+	tests := []struct {
+		name        string
+		runtime     string
+		authSock    string
+		isLinux     bool
+		remoteHost  bool
+		wantArgs    []string
+		wantWarning string
+	}{
+		{"linux mounts SSH_AUTH_SOCK", "docker", socket, true, false, forward(socket), ""},
+		{"linux podman mounts SSH_AUTH_SOCK", "podman", socket, true, false, forward(socket), ""},
+		{"linux without an agent", "docker", "", true, false, nil, "SSH_AUTH_SOCK is not set"},
+		{"linux with a stale socket", "docker", missing, true, false, nil, "does not exist"},
+		{"docker desktop relays the agent", "docker", "/private/tmp/launchd/Listeners", false, false, forward(dockerDesktopAgentSocket), ""},
+		{"podman on macOS", "podman", socket, false, false, nil, "only Docker Desktop"},
+		{"apple container", "container", socket, false, false, nil, "Apple Container"},
+		{"remote host", "docker", socket, true, true, nil, "remote host"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, warning := sshAgentArgs(tt.runtime, tt.authSock, tt.isLinux, tt.remoteHost)
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("sshAgentArgs() args = %q, want %q", args, tt.wantArgs)
+			}
+			if (tt.wantWarning == "") != (warning == "") || !strings.Contains(warning, tt.wantWarning) {
+				t.Errorf("sshAgentArgs() warning = %q, want one mentioning %q", warning, tt.wantWarning)
+			}
+		})
+	}
+}
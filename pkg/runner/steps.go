@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/obra/packnplay/pkg/devcontainer"
+	"github.com/obra/packnplay/pkg/docker"
+)
+
+// runSteps runs config.Command and then each command in config.Then in the container,
+// one docker exec per step, stopping at the first failure. A single exit summary (and
+// auto-commit) covers all the steps.
+func runSteps(dockerClient *docker.Client, containerID, workingDir string, devConfig *devcontainer.Config, config *RunConfig, out io.Writer) error {
+	steps := append([][]string{config.Command}, config.Then...)
+	start := time.Now()
+	err := runStepSequence(steps, out, func(command []string) error {
+		step := *config
+		step.Command, step.Then = command, nil
+		step.Summary, step.AutoCommit = stepSummary, false
+		return execInContainer(dockerClient, containerID, workingDir, devConfig, &step)
+	})
+
+	code := 0
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		code = exitErr.Code
+	} else if err != nil {
+		return err
+	}
+	finishRun(containerNameOf(dockerClient, containerID), workingDir, config, code, time.Since(start))
+	return err
+}
+
+// runStepSequence runs steps in order, announcing each on out and reporting how long it
+// took. The first failing step's error is returned and the remaining steps are skipped.
+func runStepSequence(steps [][]string, out io.Writer, run func([]string) error) error {
+	start := time.Now()
+	for i, command := range steps {
+		_, _ = fmt.Fprintf(out, "==> [%d/%d] %s\n", i+1, len(steps), strings.Join(command, " "))
+		stepStart := time.Now()
+		err := run(command)
+		elapsed := formatStepDuration(time.Since(stepStart))
+
+		if err != nil {
+			_, _ = fmt.Fprintf(out, "==> [%d/%d] failed after %s: %v\n", i+1, len(steps), elapsed, err)
+			if skipped := len(steps) - i - 1; skipped > 0 {
+				_, _ = fmt.Fprintf(out, "==> skipped %d remaining steps\n", skipped)
+			}
+			return err
+		}
+		_, _ = fmt.Fprintf(out, "==> [%d/%d] done in %s\n", i+1, len(steps), elapsed)
+	}
+	_, _ = fmt.Fprintf(out, "==> all %d steps done in %s\n", len(steps), formatStepDuration(time.Since(start)))
+	return nil
+}
+
+// formatStepDuration rounds a step's duration to tenths of a second, or whole seconds
+// past a minute
+func formatStepDuration(d time.Duration) string {
+	if d >= time.Minute {
+		return d.Round(time.Second).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
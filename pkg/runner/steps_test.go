@@ -0,0 +1,85 @@
+package runner
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunStepSequence(t *testing.T) {
+	steps := [][]string{{"npm", "ci"}, {"npm", "test"}, {"claude"}}
+
+	tests := []struct {
+		name    string
+		failAt  int // index of the failing step, -1 for none
+		wantRun []string
+		wantOut string
+	}{
+		{
+			name:    "all succeed",
+			failAt:  -1,
+			wantRun: []string{"npm ci", "npm test", "claude"},
+			wantOut: `==> \[1/3\] npm ci
+==> \[1/3\] done in \S+
+==> \[2/3\] npm test
+==> \[2/3\] done in \S+
+==> \[3/3\] claude
+==> \[3/3\] done in \S+
+==> all 3 steps done in \S+
+`,
+		},
+		{
+			name:    "fail fast",
+			failAt:  1,
+			wantRun: []string{"npm ci", "npm test"},
+			wantOut: `==> \[1/3\] npm ci
+==> \[1/3\] done in \S+
+==> \[2/3\] npm test
+==> \[2/3\] failed after \S+: command exited with status 2
+==> skipped 1 remaining steps
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			var ran []string
+			err := runStepSequence(steps, &out, func(command []string) error {
+				ran = append(ran, strings.Join(command, " "))
+				if len(ran)-1 == tt.failAt {
+					return &ExitError{Code: 2}
+				}
+				return nil
+			})
+
+			if (err != nil) != (tt.failAt >= 0) {
+				t.Fatalf("runStepSequence() error = %v", err)
+			}
+			if exitErr, ok := err.(*ExitError); err != nil && (!ok || exitErr.Code != 2) {
+				t.Errorf("runStepSequence() error = %v, want the step's *ExitError", err)
+			}
+			if strings.Join(ran, "|") != strings.Join(tt.wantRun, "|") {
+				t.Errorf("ran %q, want %q", ran, tt.wantRun)
+			}
+			if !regexp.MustCompile(`^` + tt.wantOut + `$`).MatchString(out.String()) {
+				t.Errorf("output =\n%s\nwant to match\n%s", out.String(), tt.wantOut)
+			}
+		})
+	}
+}
+
+func TestFormatStepDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		1234 * time.Millisecond:               "1.2s",
+		40 * time.Millisecond:                 "0s",
+		2*time.Minute + 3400*time.Millisecond: "2m3s",
+	}
+	for d, want := range tests {
+		if got := formatStepDuration(d); got != want {
+			t.Errorf("formatStepDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/docker"
+	"github.com/obra/packnplay/pkg/git"
+)
+
+// exitSummary describes a finished managed run for printExitSummary
+type exitSummary struct {
+	Code      int
+	Duration  time.Duration
+	Dir       string   // host directory checked for changes; "" when it couldn't be checked
+	Changed   []string // uncommitted paths in Dir
+	Container string   // container left running, for the stop hint; "" when there is none
+	Copied    bool     // the changes are in the container's copy of the workspace
+}
+
+// stepSummary is the summary mode for each --then step; one summary covers the whole run
+const stepSummary = config.SummaryOff
+
+// reportExit prints the summary of a managed run to stderr, unless config.Summary turns it
+// off. Changes are only counted when the working directory is a git checkout on this host.
+func reportExit(containerName, workingDir string, config *RunConfig, code int, duration time.Duration) {
+	if !summaryEnabled(config.Summary) {
+		return
+	}
+
+	summary := exitSummary{Code: code, Duration: duration, Container: containerName, Copied: config.CopyWorkspace}
+	if config.remoteName() == "" && !config.CopyWorkspace {
+		if changed, err := git.ChangedFiles(workingDir); err == nil {
+			summary.Dir, summary.Changed = workingDir, changed
+		}
+	}
+	printExitSummary(os.Stderr, config.Summary, summary)
+}
+
+// summaryEnabled reports whether mode prints an exit summary
+func summaryEnabled(mode string) bool {
+	return mode != config.SummaryOff
+}
+
+// printExitSummary writes the summary in mode (short when empty): exit status, duration
+// and changed-file count, the changed files themselves in full mode, then hints for what
+// to do next
+func printExitSummary(w io.Writer, mode string, s exitSummary) {
+	if mode == config.SummaryOff {
+		return
+	}
+
+	line := fmt.Sprintf("packnplay: command exited with status %d after %s", s.Code, formatStepDuration(s.Duration))
+	if s.Dir != "" {
+		switch len(s.Changed) {
+		case 0:
+			line += ", no files changed"
+		case 1:
+			line += ", 1 file changed"
+		default:
+			line += fmt.Sprintf(", %d files changed", len(s.Changed))
+		}
+	}
+	_, _ = fmt.Fprintln(w, line)
+
+	if mode == config.SummaryFull {
+		for _, path := range s.Changed {
+			_, _ = fmt.Fprintf(w, "    %s\n", path)
+		}
+	}
+
+	if s.Dir != "" && len(s.Changed) > 0 {
+		dir := quoteIfNeeded(s.Dir)
+		_, _ = fmt.Fprintf(w, "  review: git -C %s diff\n", dir)
+		_, _ = fmt.Fprintf(w, "  commit: git -C %s add -A && git -C %s commit\n", dir, dir)
+	}
+	if s.Copied && s.Container != "" {
+		_, _ = fmt.Fprintf(w, "  review: packnplay diff %s\n", s.Container)
+		_, _ = fmt.Fprintf(w, "  apply:  packnplay export %s\n", s.Container)
+	}
+	if s.Container != "" {
+		_, _ = fmt.Fprintf(w, "  stop:   packnplay stop %s\n", s.Container)
+	}
+}
+
+// containerNameOf returns the name of the container with containerID, or "" if it can't
+// be looked up
+func containerNameOf(dockerClient *docker.Client, containerID string) string {
+	info, err := dockerClient.InspectContainer(containerID)
+	if err != nil {
+		return ""
+	}
+	return info.Name
+}
+
+// shellSafe matches strings that need no quoting in a POSIX shell
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_./:@%+=,-]+$`)
+
+// quoteIfNeeded single-quotes s for a shell command line when it contains anything unsafe
+func quoteIfNeeded(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,96 @@
+package runner
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestPrintExitSummary(t *testing.T) {
+	changed := exitSummary{
+		Code:      1,
+		Duration:  83 * time.Second,
+		Dir:       "/home/me/project",
+		Changed:   []string{"main.go", "notes.txt"},
+		Container: "packnplay-project-main",
+	}
+
+	tests := []struct {
+		name    string
+		mode    string
+		summary exitSummary
+		want    string
+	}{
+		{
+			name:    "off",
+			mode:    "off",
+			summary: changed,
+			want:    "",
+		},
+		{
+			name:    "short",
+			mode:    "short",
+			summary: changed,
+			want: `packnplay: command exited with status 1 after 1m23s, 2 files changed
+  review: git -C /home/me/project diff
+  commit: git -C /home/me/project add -A && git -C /home/me/project commit
+  stop:   packnplay stop packnplay-project-main
+`,
+		},
+		{
+			name:    "full lists files",
+			mode:    "full",
+			summary: changed,
+			want: `packnplay: command exited with status 1 after 1m23s, 2 files changed
+    main.go
+    notes.txt
+  review: git -C /home/me/project diff
+  commit: git -C /home/me/project add -A && git -C /home/me/project commit
+  stop:   packnplay stop packnplay-project-main
+`,
+		},
+		{
+			name:    "default is short",
+			mode:    "",
+			summary: exitSummary{Duration: 1500 * time.Millisecond, Dir: "/src/my app", Changed: []string{"a"}},
+			want: `packnplay: command exited with status 0 after 1.5s, 1 file changed
+  review: git -C '/src/my app' diff
+  commit: git -C '/src/my app' add -A && git -C '/src/my app' commit
+`,
+		},
+		{
+			name:    "nothing changed",
+			mode:    "short",
+			summary: exitSummary{Duration: time.Second, Dir: "/src", Container: "c"},
+			want: `packnplay: command exited with status 0 after 1s, no files changed
+  stop:   packnplay stop c
+`,
+		},
+		{
+			name:    "copied workspace",
+			mode:    "short",
+			summary: exitSummary{Duration: time.Second, Container: "c", Copied: true},
+			want: `packnplay: command exited with status 0 after 1s
+  review: packnplay diff c
+  apply:  packnplay export c
+  stop:   packnplay stop c
+`,
+		},
+		{
+			name:    "not a git checkout",
+			mode:    "full",
+			summary: exitSummary{Code: 124, Duration: time.Hour},
+			want:    "packnplay: command exited with status 124 after 1h0m0s\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			printExitSummary(&buf, tt.mode, tt.summary)
+			if buf.String() != tt.want {
+				t.Errorf("printExitSummary() =\n%s\nwant:\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/obra/packnplay/pkg/config"
+)
+
+// secretCredentials lists the enabled credentials that would let an image act as the
+// user: keys, tokens and cloud credentials. Git config alone is not a secret.
+func secretCredentials(runConfig *RunConfig) []string {
+	var names []string
+	creds := runConfig.Credentials
+	if creds.SSH {
+		names = append(names, "ssh")
+	}
+	if creds.SSHAgent {
+		names = append(names, "ssh-agent")
+	}
+	if creds.GH {
+		names = append(names, "gh")
+	}
+	if creds.GPG {
+		names = append(names, "gpg")
+	}
+	if creds.GPGAgent {
+		names = append(names, "gpg-agent")
+	}
+	if creds.NPM {
+		names = append(names, "npm")
+	}
+	if creds.AWS {
+		names = append(names, "aws")
+	}
+	if creds.GCP {
+		names = append(names, "gcp")
+	}
+	if creds.Azure {
+		names = append(names, "azure")
+	}
+	if creds.Kube {
+		names = append(names, "kube")
+	}
+
+	providers := make([]string, 0, len(runConfig.CredentialProviders))
+	for name := range runConfig.CredentialProviders {
+		providers = append(providers, name)
+	}
+	sort.Strings(providers)
+	return append(names, providers...)
+}
+
+// checkImageTrust applies the untrusted image policy before secret credentials are
+// mounted into imageName. It returns an error when the container must not start.
+func checkImageTrust(imageName string, runConfig *RunConfig, in io.Reader, out io.Writer, interactive bool) error {
+	secrets := secretCredentials(runConfig)
+	if len(secrets) == 0 || config.ImageTrusted(imageName, runConfig.TrustedImages) {
+		return nil
+	}
+
+	what := strings.Join(secrets, ", ")
+	hint := fmt.Sprintf("Add a pattern matching %s to trusted_images in the config file to trust it.", imageName)
+
+	switch config.EffectiveUntrustedImagePolicy(runConfig.UntrustedImagePolicy, interactive) {
+	case config.UntrustedImageRefuse:
+		return fmt.Errorf("refusing to mount %s credentials into untrusted image %s\n%s", what, imageName, hint)
+
+	case config.UntrustedImagePrompt:
+		if !interactive {
+			return fmt.Errorf("refusing to mount %s credentials into untrusted image %s (no terminal to confirm)\n%s", what, imageName, hint)
+		}
+		_, _ = fmt.Fprintf(out, "Image %s is not in trusted_images.\nMount %s credentials into it? [y/N]: ", imageName, what)
+		answer, _ := bufio.NewReader(in).ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return nil
+		}
+		return fmt.Errorf("not starting %s without confirmation\n%s", imageName, hint)
+
+	default:
+		_, _ = fmt.Fprintf(out, "Warning: mounting %s credentials into untrusted image %s\n  %s\n", what, imageName, hint)
+		return nil
+	}
+}
+
+// confirmImageTrust checks imageName against the policy using the terminal
+func confirmImageTrust(imageName string, runConfig *RunConfig) error {
+	return checkImageTrust(imageName, runConfig, os.Stdin, os.Stderr, term.IsTerminal(os.Stdin.Fd()))
+}
+
+// dryRunImageTrust checks imageName like confirmImageTrust, noting the question instead of
+// asking it
+func dryRunImageTrust(imageName string, runConfig *RunConfig, out io.Writer) error {
+	secrets := secretCredentials(runConfig)
+	policy := config.EffectiveUntrustedImagePolicy(runConfig.UntrustedImagePolicy, term.IsTerminal(os.Stdin.Fd()))
+	if policy == config.UntrustedImagePrompt && len(secrets) > 0 && !config.ImageTrusted(imageName, runConfig.TrustedImages) {
+		dryRunNote(out, "asks before mounting %s credentials into untrusted image %s", strings.Join(secrets, ", "), imageName)
+		return nil
+	}
+	return checkImageTrust(imageName, runConfig, nil, os.Stderr, false)
+}
@@ -0,0 +1,127 @@
+package runner
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/config"
+)
+
+func TestSecretCredentials(t *testing.T) {
+	runConfig := &RunConfig{
+		Credentials: config.Credentials{Git: true, SSH: true, SSHAgent: true, GPGAgent: true, AWS: true, GCP: true, Azure: true, Kube: true},
+		CredentialProviders: map[string]config.CredentialProviderConfig{
+			"vault":   {Command: "vault-creds"},
+			"doppler": {Command: "doppler-creds"},
+		},
+	}
+	want := []string{"ssh", "ssh-agent", "gpg-agent", "aws", "gcp", "azure", "kube", "doppler", "vault"}
+	if got := secretCredentials(runConfig); !reflect.DeepEqual(got, want) {
+		t.Errorf("secretCredentials() = %v, want %v", got, want)
+	}
+
+	if got := secretCredentials(&RunConfig{Credentials: config.Credentials{Git: true}}); len(got) != 0 {
+		t.Errorf("git config alone should not count as a secret, got %v", got)
+	}
+}
+
+func TestCheckImageTrust(t *testing.T) {
+	withSSH := config.Credentials{SSH: true}
+
+	tests := []struct {
+		name        string
+		image       string
+		creds       config.Credentials
+		trusted     []string
+		policy      string
+		input       string
+		interactive bool
+		wantErr     string
+		wantOut     string
+	}{
+		{
+			name:   "no secrets",
+			image:  "evil/image",
+			creds:  config.Credentials{Git: true},
+			policy: config.UntrustedImageRefuse,
+		},
+		{
+			name:    "trusted image",
+			image:   "ghcr.io/my-company/dev:1",
+			creds:   withSSH,
+			trusted: []string{"ghcr.io/my-company/*"},
+			policy:  config.UntrustedImageRefuse,
+		},
+		{
+			name:    "default policy warns without a terminal",
+			image:   "evil/image",
+			creds:   withSSH,
+			wantOut: "Warning: mounting ssh credentials into untrusted image evil/image",
+		},
+		{
+			name:        "default policy prompts on a terminal",
+			image:       "evil/image",
+			creds:       withSSH,
+			input:       "\n",
+			interactive: true,
+			wantErr:     "not starting evil/image without confirmation",
+			wantOut:     "Mount ssh credentials into it? [y/N]",
+		},
+		{
+			name:    "refuse",
+			image:   "evil/image",
+			creds:   withSSH,
+			policy:  config.UntrustedImageRefuse,
+			wantErr: "refusing to mount ssh credentials into untrusted image evil/image",
+		},
+		{
+			name:    "prompt without terminal refuses",
+			image:   "evil/image",
+			creds:   withSSH,
+			policy:  config.UntrustedImagePrompt,
+			wantErr: "no terminal to confirm",
+		},
+		{
+			name:        "prompt accepted",
+			image:       "evil/image",
+			creds:       withSSH,
+			policy:      config.UntrustedImagePrompt,
+			input:       "y\n",
+			interactive: true,
+			wantOut:     "Mount ssh credentials into it? [y/N]",
+		},
+		{
+			name:        "prompt declined by default",
+			image:       "evil/image",
+			creds:       withSSH,
+			policy:      config.UntrustedImagePrompt,
+			input:       "\n",
+			interactive: true,
+			wantErr:     "not starting evil/image without confirmation",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runConfig := &RunConfig{
+				Credentials:          tt.creds,
+				TrustedImages:        tt.trusted,
+				UntrustedImagePolicy: tt.policy,
+			}
+			var out bytes.Buffer
+			err := checkImageTrust(tt.image, runConfig, strings.NewReader(tt.input), &out, tt.interactive)
+
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("checkImageTrust() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("checkImageTrust() error = %v, want %q", err, tt.wantErr)
+			}
+			if !strings.Contains(out.String(), tt.wantOut) {
+				t.Errorf("output = %q, want it to contain %q", out.String(), tt.wantOut)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package runner
+
+import "strings"
+
+// ttySession names the tmux or screen session the keep-alive process starts in containers
+// whose runtime can't give it a TTY
+const ttySession = "packnplay"
+
+// ttySessionKeepAlive starts a detached tmux or screen session, whichever the image has,
+// before sleeping forever. The multiplexer gives the session a terminal of its own, so it
+// stands in for the TTY that run -d -it gives the keep-alive process on other runtimes.
+var ttySessionKeepAlive = strings.Join([]string{
+	`if command -v tmux >/dev/null 2>&1; then tmux new-session -d -s ` + ttySession + `;`,
+	`elif command -v screen >/dev/null 2>&1; then screen -dmS ` + ttySession + `;`,
+	`fi`,
+	`exec sleep infinity`,
+}, "\n")
+
+// ttySessionAttach attaches to the keep-alive session, starting a new one if it has
+// ended, and falls back to a plain shell in images without tmux or screen
+var ttySessionAttach = strings.Join([]string{
+	`if command -v tmux >/dev/null 2>&1; then exec tmux new-session -A -s ` + ttySession + `;`,
+	`elif command -v screen >/dev/null 2>&1; then screen -x ` + ttySession + ` 2>/dev/null || exec screen -S ` + ttySession + `;`,
+	`fi`,
+	`exec /bin/bash`,
+}, "\n")
+
+// keepAliveCommand returns the command that keeps a background container running. Without
+// a detached TTY it runs under a tmux or screen session that attaches can join.
+func keepAliveCommand(detachedTTY bool) []string {
+	if detachedTTY {
+		return []string{"sleep", "infinity"}
+	}
+	return []string{"sh", "-c", ttySessionKeepAlive}
+}
+
+// AttachCommand returns the command packnplay attach runs in a container: a shell, or on
+// runtimes without a detached TTY, the keep-alive tmux or screen session
+func AttachCommand(detachedTTY bool) []string {
+	if detachedTTY {
+		return []string{"/bin/bash"}
+	}
+	return []string{"sh", "-c", ttySessionAttach}
+}
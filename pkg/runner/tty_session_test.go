@@ -0,0 +1,51 @@
+package runner
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestKeepAliveCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		detachedTTY bool
+		want        []string
+	}{
+		{"runtime with a detached TTY sleeps", true, []string{"sleep", "infinity"}},
+		{"runtime without one starts a session", false, []string{"sh", "-c", ttySessionKeepAlive}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keepAliveCommand(tt.detachedTTY); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("keepAliveCommand(%v) = %q, want %q", tt.detachedTTY, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttachCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		detachedTTY bool
+		want        []string
+	}{
+		{"runtime with a detached TTY opens bash", true, []string{"/bin/bash"}},
+		{"runtime without one joins the session", false, []string{"sh", "-c", ttySessionAttach}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AttachCommand(tt.detachedTTY); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AttachCommand(%v) = %q, want %q", tt.detachedTTY, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTTYSessionScriptsParse(t *testing.T) {
+	for name, script := range map[string]string{"keep-alive": ttySessionKeepAlive, "attach": ttySessionAttach} {
+		if output, err := exec.Command("sh", "-n", "-c", script).CombinedOutput(); err != nil {
+			t.Errorf("%s script doesn't parse: %v\n%s", name, err, output)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// OnePassword reads op://vault/item/field references with the 1Password CLI, which
+// understands them natively and asks to unlock the app when needed
+type OnePassword struct{}
+
+func (OnePassword) Scheme() string { return "op" }
+
+func (OnePassword) Resolve(ctx context.Context, ref string) (string, error) {
+	return fetch(ctx, "op", "read", "--no-newline", ref)
+}
+
+// Vault reads vault://path#field references from a HashiCorp Vault KV engine with the
+// vault CLI, e.g. vault://secret/anthropic#api_key. VAULT_ADDR and the token come from
+// the host as usual.
+type Vault struct{}
+
+func (Vault) Scheme() string { return "vault" }
+
+func (Vault) Resolve(ctx context.Context, ref string) (string, error) {
+	path, field := splitField(ref, "vault")
+	if path == "" || field == "" {
+		return "", fmt.Errorf("invalid reference '%s' (use vault://path#field)", ref)
+	}
+	return fetch(ctx, "vault", "kv", "get", "-field="+field, path)
+}
+
+// AWSSecretsManager reads aws-sm://secret-id references with the AWS CLI, using the
+// host's AWS profile and region. A #key picks one key out of a JSON secret, e.g.
+// aws-sm://prod/api-keys#anthropic.
+type AWSSecretsManager struct{}
+
+func (AWSSecretsManager) Scheme() string { return "aws-sm" }
+
+func (AWSSecretsManager) Resolve(ctx context.Context, ref string) (string, error) {
+	secretID, key := splitField(ref, "aws-sm")
+	if secretID == "" {
+		return "", fmt.Errorf("invalid reference '%s' (use aws-sm://secret-id or aws-sm://secret-id#key)", ref)
+	}
+	secret, err := fetch(ctx, "aws", "secretsmanager", "get-secret-value", "--secret-id", secretID, "--query", "SecretString", "--output", "text")
+	if err != nil || key == "" {
+		return secret, err
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object, so it has no key '%s'", secretID, key)
+	}
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no key '%s'", secretID, key)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	data, _ := json.Marshal(value)
+	return strings.TrimSpace(string(data)), nil
+}
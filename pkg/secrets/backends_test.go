@@ -0,0 +1,49 @@
+package secrets
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestBackends(t *testing.T) {
+	fakeCommands(t, map[string]string{
+		"op read --no-newline op://Work/GitHub/token":                                                   "ghp_123",
+		"vault kv get -field=api_key secret/anthropic":                                                  "sk-vault\n",
+		"aws secretsmanager get-secret-value --secret-id prod/plain --query SecretString --output text": "plain-secret\n",
+		"aws secretsmanager get-secret-value --secret-id prod/keys --query SecretString --output text":  `{"anthropic": "sk-aws", "port": 8080}` + "\n",
+	})
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{"op://Work/GitHub/token", "ghp_123", ""},
+		{"vault://secret/anthropic#api_key", "sk-vault", ""},
+		{"vault://secret/anthropic", "", "use vault://path#field"},
+		{"aws-sm://prod/plain", "plain-secret", ""},
+		{"aws-sm://prod/keys#anthropic", "sk-aws", ""},
+		{"aws-sm://prod/keys#port", "8080", ""},
+		{"aws-sm://prod/keys#openai", "", "has no key 'openai'"},
+		{"aws-sm://prod/plain#key", "", "not a JSON object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			backend, ok := backendFor(tt.ref)
+			if !ok {
+				t.Fatalf("no backend for %s", tt.ref)
+			}
+			got, err := backend.Resolve(context.Background(), tt.ref)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Resolve() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Resolve() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
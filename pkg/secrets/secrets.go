@@ -0,0 +1,120 @@
+// Package secrets resolves env var values that refer to a secret manager, such as
+// ANTHROPIC_API_KEY=op://Private/Anthropic/credential, so secrets are fetched from the
+// manager's CLI when a container starts instead of having to be in the host environment.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// resolveTimeout bounds how long a secret manager CLI may take, including any unlock
+// prompt it shows
+const resolveTimeout = 60 * time.Second
+
+// Backend fetches secrets from one secret manager
+type Backend interface {
+	// Scheme is the reference prefix the backend handles, without "://"
+	Scheme() string
+	// Resolve fetches the secret that ref, a whole reference, points to
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// Backends are the secret managers references can point to
+var Backends = []Backend{OnePassword{}, Vault{}, AWSSecretsManager{}}
+
+// backendFor returns the backend for value's scheme, if value is a reference
+func backendFor(value string) (Backend, bool) {
+	scheme, _, ok := strings.Cut(value, "://")
+	if !ok {
+		return nil, false
+	}
+	for _, backend := range Backends {
+		if backend.Scheme() == scheme {
+			return backend, true
+		}
+	}
+	return nil, false
+}
+
+// IsReference reports whether value points to a secret manager
+func IsReference(value string) bool {
+	_, ok := backendFor(value)
+	return ok
+}
+
+// Resolve returns the secret value points to, or value itself when it isn't a reference
+func Resolve(value string) (string, error) {
+	backend, ok := backendFor(value)
+	if !ok {
+		return value, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+	return backend.Resolve(ctx, value)
+}
+
+// ResolveEnv resolves the references among KEY=value entries. Entries without a value,
+// which pass a host variable through, are kept as they are. Each reference is fetched
+// once, however many entries use it.
+func ResolveEnv(entries []string) ([]string, error) {
+	resolved := make([]string, 0, len(entries))
+	cache := make(map[string]string)
+	for _, entry := range entries {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || !IsReference(value) {
+			resolved = append(resolved, entry)
+			continue
+		}
+		secret, cached := cache[value]
+		if !cached {
+			var err error
+			if secret, err = Resolve(value); err != nil {
+				return nil, fmt.Errorf("failed to resolve %s: %w", key, err)
+			}
+			cache[value] = secret
+		}
+		resolved = append(resolved, key+"="+secret)
+	}
+	return resolved, nil
+}
+
+// runCommand runs a secret manager CLI and returns what it printed. Replaced in tests.
+var runCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, fmt.Errorf("%s not found in PATH", name)
+	}
+	output, err := exec.CommandContext(ctx, name, args...).Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%s timed out after %s", name, resolveTimeout)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return nil, fmt.Errorf("%s failed: %s", name, strings.TrimSpace(string(exitErr.Stderr)))
+	} else if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", name, err)
+	}
+	return output, nil
+}
+
+// fetch runs a CLI for a secret, dropping the newline it ends its output with
+func fetch(ctx context.Context, name string, args ...string) (string, error) {
+	output, err := runCommand(ctx, name, args...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(string(output), "\n"), "\r"), nil
+}
+
+// splitField splits a reference's path from the #field after it
+func splitField(ref, scheme string) (path, field string) {
+	path = strings.TrimPrefix(ref, scheme+"://")
+	if i := strings.LastIndex(path, "#"); i >= 0 {
+		return path[:i], path[i+1:]
+	}
+	return path, ""
+}
@@ -0,0 +1,80 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeCommands replaces runCommand with one answering from outputs, keyed by the whole
+// command line, and returns the command lines run
+func fakeCommands(t *testing.T, outputs map[string]string) *[]string {
+	t.Helper()
+	var calls []string
+	original := runCommand
+	runCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		line := strings.Join(append([]string{name}, args...), " ")
+		calls = append(calls, line)
+		output, ok := outputs[line]
+		if !ok {
+			return nil, errors.New(name + " failed: no such secret")
+		}
+		return []byte(output), nil
+	}
+	t.Cleanup(func() { runCommand = original })
+	return &calls
+}
+
+func TestIsReference(t *testing.T) {
+	tests := map[string]bool{
+		"op://Private/Anthropic/credential": true,
+		"vault://secret/app#token":          true,
+		"aws-sm://prod/keys":                true,
+		"https://api.z.ai":                  false,
+		"sk-ant-123":                        false,
+		"op:/missing-slash":                 false,
+	}
+	for value, want := range tests {
+		if got := IsReference(value); got != want {
+			t.Errorf("IsReference(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestResolveEnv(t *testing.T) {
+	calls := fakeCommands(t, map[string]string{
+		"op read --no-newline op://Private/Anthropic/credential": "sk-ant-123",
+		"vault kv get -field=token secret/app":                   "hvs.abc\n",
+	})
+
+	got, err := ResolveEnv([]string{
+		"ANTHROPIC_API_KEY=op://Private/Anthropic/credential",
+		"CLAUDE_KEY=op://Private/Anthropic/credential",
+		"VAULT_TOKEN=vault://secret/app#token",
+		"ANTHROPIC_BASE_URL=https://api.z.ai",
+		"HOME_PASSTHROUGH",
+	})
+	if err != nil {
+		t.Fatalf("ResolveEnv() error = %v", err)
+	}
+	want := []string{
+		"ANTHROPIC_API_KEY=sk-ant-123",
+		"CLAUDE_KEY=sk-ant-123",
+		"VAULT_TOKEN=hvs.abc",
+		"ANTHROPIC_BASE_URL=https://api.z.ai",
+		"HOME_PASSTHROUGH",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolveEnv() = %q, want %q", got, want)
+	}
+	if len(*calls) != 2 {
+		t.Errorf("ran %q, want each reference fetched once", *calls)
+	}
+
+	_, err = ResolveEnv([]string{"TOKEN=op://Private/missing/credential"})
+	if err == nil || !strings.Contains(err.Error(), "failed to resolve TOKEN") || !strings.Contains(err.Error(), "no such secret") {
+		t.Errorf("ResolveEnv() of a missing secret error = %v", err)
+	}
+}
@@ -0,0 +1,286 @@
+// Package services runs the sidecar services a project declares in .packnplay.json, such
+// as a database or a cache. They share a network with the container, where each is
+// reachable by its name, and the container gets env vars like DATABASE_URL pointing at
+// them. Stopping the container removes them; their named volumes stay.
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/obra/packnplay/pkg/config"
+	"github.com/obra/packnplay/pkg/docker"
+)
+
+// ForLabel marks the services and their network with the container they serve
+const ForLabel = "packnplay-service-for"
+
+// Builtin holds the defaults of a service type packnplay knows
+type Builtin struct {
+	Image string
+	Port  int
+	Env   map[string]string
+	// Connect returns the env vars that point the container at the service on host,
+	// given the service's env
+	Connect func(host string, port int, env map[string]string) map[string]string
+	// Ready returns a command that succeeds inside the service once it takes connections
+	Ready func(env map[string]string) []string
+}
+
+// Builtins are the service types that need no image or env of their own
+var Builtins = map[string]Builtin{
+	"postgres": {
+		Image: "postgres:16",
+		Port:  5432,
+		Env:   map[string]string{"POSTGRES_USER": "packnplay", "POSTGRES_PASSWORD": "packnplay", "POSTGRES_DB": "packnplay"},
+		Connect: func(host string, port int, env map[string]string) map[string]string {
+			user, password, db := env["POSTGRES_USER"], env["POSTGRES_PASSWORD"], env["POSTGRES_DB"]
+			return map[string]string{
+				"DATABASE_URL": fmt.Sprintf("postgres://%s:%s@%s:%d/%s", user, password, host, port, db),
+				"PGHOST":       host,
+				"PGPORT":       fmt.Sprint(port),
+				"PGUSER":       user,
+				"PGPASSWORD":   password,
+				"PGDATABASE":   db,
+			}
+		},
+		Ready: func(env map[string]string) []string {
+			return []string{"pg_isready", "-h", "127.0.0.1", "-U", env["POSTGRES_USER"]}
+		},
+	},
+	"mysql": {
+		Image: "mysql:8.4",
+		Port:  3306,
+		Env:   map[string]string{"MYSQL_ROOT_PASSWORD": "packnplay", "MYSQL_USER": "packnplay", "MYSQL_PASSWORD": "packnplay", "MYSQL_DATABASE": "packnplay"},
+		Connect: func(host string, port int, env map[string]string) map[string]string {
+			user, password, db := env["MYSQL_USER"], env["MYSQL_PASSWORD"], env["MYSQL_DATABASE"]
+			return map[string]string{
+				"DATABASE_URL": fmt.Sprintf("mysql://%s:%s@%s:%d/%s", user, password, host, port, db),
+				"MYSQL_HOST":   host,
+			}
+		},
+		Ready: func(env map[string]string) []string {
+			return []string{"mysqladmin", "ping", "-h", "127.0.0.1", "--silent"}
+		},
+	},
+	"redis": {
+		Image: "redis:7",
+		Port:  6379,
+		Connect: func(host string, port int, env map[string]string) map[string]string {
+			return map[string]string{"REDIS_URL": fmt.Sprintf("redis://%s:%d", host, port)}
+		},
+		Ready: func(env map[string]string) []string {
+			return []string{"redis-cli", "ping"}
+		},
+	},
+}
+
+// Service is a declared service with its type's defaults filled in
+type Service struct {
+	Name       string // the network alias the container reaches it by
+	Image      string
+	Env        map[string]string
+	Ports      []string
+	Volumes    []string          // named volumes already prefixed for the project
+	ConnectEnv map[string]string // set in the container
+	Ready      []string          // nil when there is nothing to wait for
+}
+
+// validName matches service names that work as network aliases and container name suffixes
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Resolve fills in the declared services from their types, sorted by name. Named volumes
+// get volumePrefix so projects don't share them.
+func Resolve(declared map[string]config.ServiceConfig, volumePrefix string) ([]Service, error) {
+	names := make([]string, 0, len(declared))
+	for name := range declared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var resolved []Service
+	for _, name := range names {
+		declaredService := declared[name]
+		if !validName.MatchString(name) {
+			return nil, fmt.Errorf("invalid service name '%s' (use lowercase letters, digits, - and _)", name)
+		}
+		service := Service{Name: name, Image: declaredService.Image, Ports: declaredService.Ports, Env: map[string]string{}, ConnectEnv: map[string]string{}}
+
+		if declaredService.Type != "" {
+			builtin, ok := Builtins[declaredService.Type]
+			if !ok {
+				return nil, fmt.Errorf("service %s: unknown type '%s' (use %s, or an image)", name, declaredService.Type, strings.Join(Types(), ", "))
+			}
+			if service.Image == "" {
+				service.Image = builtin.Image
+			}
+			for key, value := range builtin.Env {
+				service.Env[key] = value
+			}
+			for key, value := range declaredService.Env {
+				service.Env[key] = value
+			}
+			// Computed from the merged env, so a changed password ends up in DATABASE_URL too
+			for key, value := range builtin.Connect(name, builtin.Port, service.Env) {
+				service.ConnectEnv[key] = value
+			}
+			service.Ready = builtin.Ready(service.Env)
+		} else {
+			for key, value := range declaredService.Env {
+				service.Env[key] = value
+			}
+		}
+		if service.Image == "" {
+			return nil, fmt.Errorf("service %s needs a type or an image", name)
+		}
+		for key, value := range declaredService.ConnectEnv {
+			service.ConnectEnv[key] = value
+		}
+
+		for _, volume := range declaredService.Volumes {
+			source, target, ok := strings.Cut(volume, ":")
+			if !ok || source == "" || target == "" {
+				return nil, fmt.Errorf("service %s: invalid volume '%s' (use name:/path or ./dir:/path)", name, volume)
+			}
+			if !strings.ContainsRune(source, '/') && !strings.HasPrefix(source, ".") && !strings.HasPrefix(source, "~") {
+				volume = volumePrefix + "-" + volume
+			}
+			service.Volumes = append(service.Volumes, volume)
+		}
+		resolved = append(resolved, service)
+	}
+	return resolved, nil
+}
+
+// Types returns the built-in service types, sorted
+func Types() []string {
+	types := make([]string, 0, len(Builtins))
+	for name := range Builtins {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// ConnectEnv returns the env vars for the container as sorted KEY=value pairs. Where two
+// services set the same one, the later one wins and the conflict is returned so it can
+// be reported.
+func ConnectEnv(services []Service) (env []string, conflicts []string) {
+	values := map[string]string{}
+	setBy := map[string]string{}
+	for _, service := range services {
+		for key, value := range service.ConnectEnv {
+			if previous, ok := setBy[key]; ok {
+				conflicts = append(conflicts, fmt.Sprintf("%s is set by both %s and %s", key, previous, service.Name))
+			}
+			values[key] = value
+			setBy[key] = service.Name
+		}
+	}
+	for key, value := range values {
+		env = append(env, key+"="+value)
+	}
+	sort.Strings(env)
+	sort.Strings(conflicts)
+	return env, conflicts
+}
+
+// ContainerName returns the name of service's container for containerName
+func ContainerName(containerName, service string) string {
+	return containerName + "-svc-" + service
+}
+
+// NetworkName returns the network Start creates when the container has none of its own
+func NetworkName(containerName string) string {
+	return containerName + "-services"
+}
+
+// readyTimeout bounds how long WaitReady waits for the services
+var readyTimeout = 60 * time.Second
+
+// pollInterval is how often WaitReady checks a service. Replaced in tests.
+var pollInterval = 500 * time.Millisecond
+
+// Start starts the services for containerName on network, replacing any left over from
+// an earlier run. With createNetwork it creates the network first. Ports aren't
+// published with publish off, as on an internal network where they can't be.
+func Start(d docker.Runner, containerName, network string, createNetwork, publish bool, services []Service) error {
+	label := ForLabel + "=" + containerName
+	Stop(d, containerName)
+
+	if createNetwork {
+		if output, err := d.Run("network", "create", "--label", label, network); err != nil {
+			return fmt.Errorf("failed to create services network: %w\nDocker output:\n%s", err, output)
+		}
+	}
+
+	for _, service := range services {
+		args := []string{"run", "-d", "--name", ContainerName(containerName, service.Name), "--label", label,
+			"--restart", "unless-stopped", "--network", network, "--network-alias", service.Name}
+		keys := make([]string, 0, len(service.Env))
+		for key := range service.Env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			args = append(args, "-e", key+"="+service.Env[key])
+		}
+		for _, volume := range service.Volumes {
+			args = append(args, "-v", volume)
+		}
+		if publish {
+			for _, port := range service.Ports {
+				args = append(args, "-p", port)
+			}
+		}
+		args = append(args, service.Image)
+
+		if output, err := d.Run(args...); err != nil {
+			Stop(d, containerName)
+			return fmt.Errorf("failed to start service %s from %s: %w\nDocker output:\n%s", service.Name, service.Image, err, output)
+		}
+	}
+	return nil
+}
+
+// WaitReady waits for each service with a readiness check to take connections. It
+// returns an error naming the ones that didn't within readyTimeout; they keep running.
+func WaitReady(d docker.Runner, containerName string, services []Service) error {
+	deadline := time.Now().Add(readyTimeout)
+	var late []string
+	for _, service := range services {
+		if service.Ready == nil {
+			continue
+		}
+		name := ContainerName(containerName, service.Name)
+		for {
+			if _, err := d.Run(append([]string{"exec", name}, service.Ready...)...); err == nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				late = append(late, fmt.Sprintf("%s (see docker logs %s)", service.Name, name))
+				break
+			}
+			time.Sleep(pollInterval)
+		}
+	}
+	if len(late) > 0 {
+		return fmt.Errorf("services not ready within %s: %s", readyTimeout, strings.Join(late, ", "))
+	}
+	return nil
+}
+
+// Stop removes the services and the services network for containerName, if there are
+// any. Anonymous volumes go with them; named volumes stay, so data survives a restart.
+func Stop(d docker.Runner, containerName string) {
+	output, err := d.Run("ps", "-aq", "--filter", "label="+ForLabel+"="+containerName)
+	if err == nil {
+		if ids := strings.Fields(output); len(ids) > 0 {
+			_, _ = d.Run(append([]string{"rm", "-f", "-v"}, ids...)...)
+		}
+	}
+	_, _ = d.Run("network", "rm", NetworkName(containerName))
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/obra/packnplay/pkg/config"
+)
+
+type fakeDocker struct {
+	calls    [][]string
+	failOn   string // first argument of the command that fails
+	notReady int    // number of readiness checks that fail before a service answers
+	running  string // what ps lists
+}
+
+func (f *fakeDocker) Command() string {
+	return "docker"
+}
+
+func (f *fakeDocker) Run(args ...string) (string, error) {
+	f.calls = append(f.calls, args)
+	if args[0] == f.failOn {
+		return "boom", errors.New("exit status 1")
+	}
+	switch args[0] {
+	case "exec":
+		if f.notReady > 0 {
+			f.notReady--
+			return "no response", errors.New("exit status 1")
+		}
+	case "ps":
+		return f.running, nil
+	}
+	return "", nil
+}
+
+func commands(calls [][]string) []string {
+	var result []string
+	for _, call := range calls {
+		command := call[0]
+		if command == "network" {
+			command += " " + call[1]
+		}
+		result = append(result, command)
+	}
+	return result
+}
+
+func contains(args []string, arg string) bool {
+	for _, a := range args {
+		if a == arg {
+			return true
+		}
+	}
+	return false
+}
+
+func TestResolve(t *testing.T) {
+	declared := map[string]config.ServiceConfig{
+		"db":    {Type: "postgres", Env: map[string]string{"POSTGRES_PASSWORD": "secret"}, Volumes: []string{"pgdata:/var/lib/postgresql/data", "/repo/sql:/docker-entrypoint-initdb.d"}},
+		"cache": {Type: "redis", ConnectEnv: map[string]string{"CACHE_URL": "redis://cache:6379/1"}},
+		"mail":  {Image: "axllent/mailpit", Ports: []string{"8025:8025"}},
+	}
+	resolved, err := Resolve(declared, "packnplay-app-main")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	var names []string
+	for _, service := range resolved {
+		names = append(names, service.Name)
+	}
+	if want := []string{"cache", "db", "mail"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("Resolve() names = %q, want %q", names, want)
+	}
+
+	cache, db, mail := resolved[0], resolved[1], resolved[2]
+	if cache.Image != "redis:7" || cache.ConnectEnv["REDIS_URL"] != "redis://cache:6379" || cache.ConnectEnv["CACHE_URL"] == "" {
+		t.Errorf("cache = %+v", cache)
+	}
+	// The changed password carries through to the connection URL
+	if got := db.ConnectEnv["DATABASE_URL"]; got != "postgres://packnplay:secret@db:5432/packnplay" {
+		t.Errorf("DATABASE_URL = %q", got)
+	}
+	if db.Env["POSTGRES_USER"] != "packnplay" || db.Ready == nil {
+		t.Errorf("db = %+v, want the postgres defaults", db)
+	}
+	wantVolumes := []string{"packnplay-app-main-pgdata:/var/lib/postgresql/data", "/repo/sql:/docker-entrypoint-initdb.d"}
+	if !reflect.DeepEqual(db.Volumes, wantVolumes) {
+		t.Errorf("db volumes = %q, want %q", db.Volumes, wantVolumes)
+	}
+	if mail.Ready != nil || len(mail.ConnectEnv) != 0 || mail.Ports[0] != "8025:8025" {
+		t.Errorf("mail = %+v, want no readiness check or connection env", mail)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	for name, declared := range map[string]map[string]config.ServiceConfig{
+		"unknown type": {"db": {Type: "oracle"}},
+		"no image":     {"db": {}},
+		"bad name":     {"My DB": {Type: "postgres"}},
+		"bad volume":   {"db": {Type: "postgres", Volumes: []string{"pgdata"}}},
+		"empty volume": {"db": {Type: "postgres", Volumes: []string{":/data"}}},
+	} {
+		if _, err := Resolve(declared, "prefix"); err == nil {
+			t.Errorf("%s: Resolve() succeeded, want an error", name)
+		}
+	}
+}
+
+func TestConnectEnv(t *testing.T) {
+	services := []Service{
+		{Name: "a", ConnectEnv: map[string]string{"DATABASE_URL": "postgres://a", "PGHOST": "a"}},
+		{Name: "b", ConnectEnv: map[string]string{"DATABASE_URL": "mysql://b"}},
+	}
+	env, conflicts := ConnectEnv(services)
+	if want := []string{"DATABASE_URL=mysql://b", "PGHOST=a"}; !reflect.DeepEqual(env, want) {
+		t.Errorf("ConnectEnv() env = %q, want %q", env, want)
+	}
+	if len(conflicts) != 1 || !strings.Contains(conflicts[0], "DATABASE_URL") {
+		t.Errorf("ConnectEnv() conflicts = %q", conflicts)
+	}
+}
+
+func TestStart(t *testing.T) {
+	resolved, err := Resolve(map[string]config.ServiceConfig{"db": {Type: "postgres", Ports: []string{"5432:5432"}}}, "packnplay-app-main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &fakeDocker{}
+	if err := Start(d, "packnplay-app-main", "packnplay-app-main-services", true, true, resolved); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	want := []string{"ps", "network rm", "network create", "run"}
+	if got := commands(d.calls); !reflect.DeepEqual(got, want) {
+		t.Errorf("Start() ran %q, want %q", got, want)
+	}
+	run := d.calls[3]
+	if got := run[len(run)-1]; got != "postgres:16" {
+		t.Errorf("service image = %q", got)
+	}
+	for _, arg := range []string{"packnplay-app-main-svc-db", "packnplay-service-for=packnplay-app-main", "--network-alias", "5432:5432", "POSTGRES_DB=packnplay"} {
+		if !contains(run, arg) {
+			t.Errorf("service run %q is missing %q", run, arg)
+		}
+	}
+
+	// On a network that isn't theirs, and without publishing ports
+	d = &fakeDocker{}
+	if err := Start(d, "packnplay-app-main", "packnplay-app-main-egress", false, false, resolved); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if got := commands(d.calls); contains(got, "network create") || contains(d.calls[2], "5432:5432") {
+		t.Errorf("Start() ran %q", d.calls)
+	}
+}
+
+func TestStartFailureCleansUp(t *testing.T) {
+	d := &fakeDocker{failOn: "run", running: "abc123\n"}
+	if err := Start(d, "packnplay-app-main", "net", true, true, []Service{{Name: "db", Image: "postgres:16"}}); err == nil {
+		t.Fatal("Start() succeeded, want an error")
+	}
+	got := commands(d.calls)
+	if want := []string{"ps", "rm", "network rm"}; !reflect.DeepEqual(got[len(got)-3:], want) {
+		t.Errorf("Start() ran %q, want it to end with %q", got, want)
+	}
+}
+
+func TestWaitReady(t *testing.T) {
+	pollInterval = 0
+	services := []Service{{Name: "mail"}, {Name: "db", Ready: []string{"pg_isready"}}}
+	d := &fakeDocker{notReady: 2}
+	if err := WaitReady(d, "packnplay-app-main", services); err != nil {
+		t.Fatalf("WaitReady() error = %v", err)
+	}
+	if len(d.calls) != 3 || !reflect.DeepEqual(d.calls[0], []string{"exec", "packnplay-app-main-svc-db", "pg_isready"}) {
+		t.Errorf("WaitReady() ran %q", d.calls)
+	}
+
+	saved := readyTimeout
+	readyTimeout = 0
+	defer func() { readyTimeout = saved }()
+	if err := WaitReady(&fakeDocker{notReady: 1}, "packnplay-app-main", services); err == nil || !strings.Contains(err.Error(), "db") {
+		t.Errorf("WaitReady() error = %v, want one naming db", err)
+	}
+}
+
+func TestStop(t *testing.T) {
+	d := &fakeDocker{running: "abc123\ndef456\n"}
+	Stop(d, "packnplay-app-main")
+	want := [][]string{
+		{"ps", "-aq", "--filter", "label=packnplay-service-for=packnplay-app-main"},
+		{"rm", "-f", "-v", "abc123", "def456"},
+		{"network", "rm", "packnplay-app-main-services"},
+	}
+	if !reflect.DeepEqual(d.calls, want) {
+		t.Errorf("Stop() ran %q, want %q", d.calls, want)
+	}
+}
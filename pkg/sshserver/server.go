@@ -0,0 +1,376 @@
+// Package sshserver runs an SSH server in a packnplay container, so editors that work
+// over SSH (VS Code Remote-SSH, JetBrains Gateway) can attach to the sandbox. The server
+// uses keys generated for the container alone, and a small socat sidecar publishes it on
+// a loopback port of the host, since the container's own ports are fixed at creation.
+package sshserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/obra/packnplay/pkg/docker"
+)
+
+// DefaultForwarderImage is the image of the sidecar that publishes the server's port
+const DefaultForwarderImage = "alpine/socat:latest"
+
+// ContainerPort is the port the server listens on inside the container
+const ContainerPort = 2222
+
+// ForLabel marks the forwarder sidecar with the container it serves
+const ForLabel = "packnplay-ssh-for"
+
+// configDir holds the server's keys and config inside the container
+const configDir = "/etc/packnplay-ssh"
+
+// Name returns the name of the forwarder sidecar for containerName
+func Name(containerName string) string {
+	return containerName + "-ssh"
+}
+
+// KeyDir is where the keys for containerName's server are kept on the host
+func KeyDir(containerName string) string {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		homeDir, _ := os.UserHomeDir()
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	return filepath.Join(dataHome, "packnplay", "ssh", containerName)
+}
+
+// Keys are the key pairs generated for one container: the client key editors log in
+// with, and the server's host key
+type Keys struct {
+	ClientKey string // private key file
+	ClientPub string // public key, authorized in the container
+	HostKey   string // private key file, copied into the container
+	HostPub   string // public key, written to the known hosts file
+}
+
+// Keygen creates an ed25519 key pair without a passphrase at path and path.pub.
+// Replaced in tests.
+var Keygen = defaultKeygen
+
+func defaultKeygen(path, comment string) error {
+	output, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", comment, "-f", path).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ssh-keygen failed: %w\n%s", err, output)
+	}
+	return nil
+}
+
+// GenerateKeys replaces the keys in dir with new ones, so each server start gets keys of
+// its own
+func GenerateKeys(dir, comment string) (*Keys, error) {
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to remove old keys: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	keys := &Keys{ClientKey: filepath.Join(dir, "id_ed25519"), HostKey: filepath.Join(dir, "host_ed25519")}
+	for _, key := range []struct {
+		path string
+		pub  *string
+	}{{keys.ClientKey, &keys.ClientPub}, {keys.HostKey, &keys.HostPub}} {
+		if err := Keygen(key.path, comment); err != nil {
+			return nil, err
+		}
+		pub, err := os.ReadFile(key.path + ".pub")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s.pub: %w", key.path, err)
+		}
+		*key.pub = strings.TrimSpace(string(pub))
+	}
+	return keys, nil
+}
+
+// Endpoint is where a started server can be reached from the host
+type Endpoint struct {
+	Alias        string // Host name in the ssh config block
+	Port         int    // loopback port on the host
+	User         string
+	IdentityFile string
+	KnownHosts   string
+	Server       string // openssh or dropbear
+	Dir          string // project directory in the container, set by the caller
+}
+
+// HostBlock renders the ~/.ssh/config entry for the endpoint
+func (e Endpoint) HostBlock() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Host %s\n", e.Alias)
+	fmt.Fprintf(&b, "  HostName 127.0.0.1\n")
+	fmt.Fprintf(&b, "  Port %d\n", e.Port)
+	fmt.Fprintf(&b, "  User %s\n", e.User)
+	fmt.Fprintf(&b, "  IdentityFile %s\n", quoteConfig(e.IdentityFile))
+	fmt.Fprintf(&b, "  IdentitiesOnly yes\n")
+	fmt.Fprintf(&b, "  HostKeyAlias %s\n", e.Alias)
+	fmt.Fprintf(&b, "  UserKnownHostsFile %s\n", quoteConfig(e.KnownHosts))
+	fmt.Fprintf(&b, "  StrictHostKeyChecking yes\n")
+	return b.String()
+}
+
+// quoteConfig quotes an ssh config value with spaces
+func quoteConfig(value string) string {
+	if strings.ContainsAny(value, " \t") {
+		return `"` + value + `"`
+	}
+	return value
+}
+
+// setupScript makes sure the container has an SSH server, installing OpenSSH with the
+// image's package manager when there is none, and prints which one it found
+const setupScript = `set -e
+if command -v sshd >/dev/null 2>&1 || [ -x /usr/sbin/sshd ]; then echo openssh; exit 0; fi
+if command -v dropbear >/dev/null 2>&1 && command -v dropbearconvert >/dev/null 2>&1; then echo dropbear; exit 0; fi
+if command -v apt-get >/dev/null 2>&1; then
+  apt-get update -qq >&2 && DEBIAN_FRONTEND=noninteractive apt-get install -y -qq --no-install-recommends openssh-server >&2
+elif command -v apk >/dev/null 2>&1; then
+  apk add --no-cache openssh-server >&2
+elif command -v dnf >/dev/null 2>&1; then
+  dnf install -y -q openssh-server >&2
+elif command -v yum >/dev/null 2>&1; then
+  yum install -y -q openssh-server >&2
+else
+  echo "no SSH server installed and no known package manager to install one" >&2; exit 1
+fi
+echo openssh`
+
+// sshdConfig renders the OpenSSH server config. env is set in sessions, which sshd
+// otherwise starts with an environment of its own rather than the container's.
+func sshdConfig(user string, env []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Port %d\n", ContainerPort)
+	fmt.Fprintf(&b, "HostKey %s/host_ed25519\n", configDir)
+	fmt.Fprintf(&b, "AuthorizedKeysFile %s/authorized_keys\n", configDir)
+	fmt.Fprintf(&b, "PidFile %s/sshd.pid\n", configDir)
+	fmt.Fprintf(&b, "AllowUsers %s\n", user)
+	b.WriteString("PubkeyAuthentication yes\n")
+	b.WriteString("PasswordAuthentication no\n")
+	b.WriteString("ChallengeResponseAuthentication no\n")
+	b.WriteString("PermitRootLogin prohibit-password\n")
+	// Container home directories are often group-writable, which StrictModes rejects
+	b.WriteString("StrictModes no\n")
+	b.WriteString("X11Forwarding no\n")
+	b.WriteString("AllowTcpForwarding yes\n")
+	b.WriteString("Subsystem sftp internal-sftp\n")
+	if len(env) > 0 {
+		var quoted []string
+		for _, entry := range env {
+			quoted = append(quoted, `"`+entry+`"`)
+		}
+		fmt.Fprintf(&b, "SetEnv %s\n", strings.Join(quoted, " "))
+	}
+	return b.String()
+}
+
+// sessionEnv picks the container environment entries to set in SSH sessions: those that
+// sshd config can quote and that don't describe the session itself
+func sessionEnv(env []string) []string {
+	var kept []string
+	for _, entry := range env {
+		name, _, _ := strings.Cut(entry, "=")
+		switch name {
+		case "", "HOME", "USER", "LOGNAME", "SHELL", "HOSTNAME", "TERM", "PWD", "SHLVL":
+			continue
+		}
+		if strings.ContainsAny(entry, "\"\\\n") {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept
+}
+
+// Start installs and starts an SSH server in containerName that lets user in with keys,
+// replacing one started earlier, and publishes it on 127.0.0.1:port of the host
+func Start(d docker.Runner, containerName, user string, keys *Keys, port int) (*Endpoint, error) {
+	Stop(d, containerName, false)
+
+	output, err := d.Run("exec", "-u", "root", containerName, "sh", "-c", setupScript)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up an SSH server in the container: %w\nDocker output:\n%s", err, output)
+	}
+	server := lastLine(output)
+	if server != "openssh" && server != "dropbear" {
+		return nil, fmt.Errorf("failed to set up an SSH server in the container: unexpected output:\n%s", output)
+	}
+
+	if err := copyConfig(d, containerName, user, keys); err != nil {
+		return nil, err
+	}
+	if server == "openssh" {
+		err = startOpenSSH(d, containerName, user)
+	} else {
+		err = startDropbear(d, containerName, user, keys.ClientPub)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := startForwarder(d, containerName, port); err != nil {
+		Stop(d, containerName, false)
+		return nil, err
+	}
+
+	knownHosts := filepath.Join(filepath.Dir(keys.ClientKey), "known_hosts")
+	if err := os.WriteFile(knownHosts, []byte(knownHostsLine(containerName, keys.HostPub)), 0600); err != nil {
+		Stop(d, containerName, false)
+		return nil, fmt.Errorf("failed to write %s: %w", knownHosts, err)
+	}
+
+	return &Endpoint{
+		Alias:        containerName,
+		Port:         port,
+		User:         user,
+		IdentityFile: keys.ClientKey,
+		KnownHosts:   knownHosts,
+		Server:       server,
+	}, nil
+}
+
+// copyConfig copies the host key, the authorized key and the sshd config into configDir.
+// The client's private key stays on the host.
+func copyConfig(d docker.Runner, containerName, user string, keys *Keys) error {
+	var env []string
+	if output, err := d.Run("inspect", "--format", "{{json .Config.Env}}", containerName); err == nil {
+		_ = json.Unmarshal([]byte(strings.TrimSpace(output)), &env)
+	}
+
+	staging, err := os.MkdirTemp("", "packnplay-ssh-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	hostKey, err := os.ReadFile(keys.HostKey)
+	if err != nil {
+		return fmt.Errorf("failed to read host key: %w", err)
+	}
+	files := map[string]string{
+		"host_ed25519":     string(hostKey),
+		"host_ed25519.pub": keys.HostPub + "\n",
+		"authorized_keys":  keys.ClientPub + "\n",
+		"sshd_config":      sshdConfig(user, sessionEnv(env)),
+		"sshd_config.base": sshdConfig(user, nil),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(staging, name), []byte(content), 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	if output, err := d.Run("exec", "-u", "root", containerName, "sh", "-c", `rm -rf "$0" && mkdir -p "$0"`, configDir); err != nil {
+		return fmt.Errorf("failed to create %s in the container: %w\nDocker output:\n%s", configDir, err, output)
+	}
+	if output, err := d.Run("cp", staging+"/.", containerName+":"+configDir); err != nil {
+		return fmt.Errorf("failed to copy SSH server config into the container: %w\nDocker output:\n%s", err, output)
+	}
+	if output, err := d.Run("exec", "-u", "root", containerName, "sh", "-c", `chown -R 0:0 "$0" && chmod 700 "$0" && chmod 600 "$0"/*`, configDir); err != nil {
+		return fmt.Errorf("failed to secure %s in the container: %w\nDocker output:\n%s", configDir, err, output)
+	}
+	return nil
+}
+
+// openSSHScript starts sshd with the copied config. A locked account ("!" in /etc/shadow)
+// can't log in even with a key, so the user's is unlocked with "*", which allows no
+// password either. Images whose sshd doesn't know SetEnv fall back to the config without it.
+const openSSHScript = `dir=$0 user=$1
+sed -i "s/^$user:!*:/$user:*:/" /etc/shadow 2>/dev/null
+mkdir -p /run/sshd /var/run/sshd
+sshd=$(command -v sshd || echo /usr/sbin/sshd)
+if ! "$sshd" -t -f "$dir/sshd_config" 2>/dev/null; then
+  echo "sshd rejected the container environment (SetEnv); sessions start with sshd's default environment" >&2
+  mv "$dir/sshd_config.base" "$dir/sshd_config"
+fi
+exec "$sshd" -f "$dir/sshd_config"`
+
+func startOpenSSH(d docker.Runner, containerName, user string) error {
+	output, err := d.Run("exec", "-u", "root", containerName, "sh", "-c", openSSHScript, configDir, user)
+	if err != nil {
+		return fmt.Errorf("failed to start sshd in the container: %w\nDocker output:\n%s", err, output)
+	}
+	return nil
+}
+
+// dropbearScript starts dropbear with the host key converted to its format. Dropbear only
+// reads ~/.ssh/authorized_keys, so the client key is added there.
+const dropbearScript = `set -e
+dir=$0 user=$1 key=$2
+dropbearconvert openssh dropbear "$dir/host_ed25519" "$dir/dropbear_ed25519" >/dev/null 2>&1
+home=$(getent passwd "$user" | cut -d: -f6)
+mkdir -p "$home/.ssh"
+touch "$home/.ssh/authorized_keys"
+grep -qxF "$key" "$home/.ssh/authorized_keys" || echo "$key" >> "$home/.ssh/authorized_keys"
+chown -R "$user" "$home/.ssh"
+chmod 700 "$home/.ssh" && chmod 600 "$home/.ssh/authorized_keys"
+exec dropbear -r "$dir/dropbear_ed25519" -p 2222 -s -P "$dir/server.pid"`
+
+func startDropbear(d docker.Runner, containerName, user, clientPub string) error {
+	output, err := d.Run("exec", "-u", "root", containerName, "sh", "-c", dropbearScript, configDir, user, clientPub)
+	if err != nil {
+		return fmt.Errorf("failed to start dropbear in the container: %w\nDocker output:\n%s", err, output)
+	}
+	return nil
+}
+
+// startForwarder starts the sidecar that relays 127.0.0.1:port on the host to the
+// server, on the container's network
+func startForwarder(d docker.Runner, containerName string, port int) error {
+	output, err := d.Run("inspect", "--format", `{{range $name, $net := .NetworkSettings.Networks}}{{$name}} {{$net.IPAddress}}{{"\n"}}{{end}}`, containerName)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container network: %w\nDocker output:\n%s", err, output)
+	}
+	network, ip := "", ""
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			network, ip = fields[0], fields[1]
+			break
+		}
+	}
+	if ip == "" {
+		return fmt.Errorf("container has no network address to reach the SSH server on (started with --network=none?)")
+	}
+
+	output, err = d.Run("run", "-d", "--name", Name(containerName), "--label", ForLabel+"="+containerName,
+		"--network", network, "-p", fmt.Sprintf("127.0.0.1:%d:%d", port, ContainerPort),
+		DefaultForwarderImage,
+		fmt.Sprintf("TCP-LISTEN:%d,fork,reuseaddr", ContainerPort), fmt.Sprintf("TCP:%s:%d", ip, ContainerPort))
+	if err != nil {
+		return fmt.Errorf("failed to start SSH forwarder: %w\nDocker output:\n%s", err, output)
+	}
+	return nil
+}
+
+// knownHostsLine pins the host key under alias, the HostKeyAlias of the Host block
+func knownHostsLine(alias, hostPub string) string {
+	fields := strings.Fields(hostPub)
+	if len(fields) > 2 {
+		fields = fields[:2]
+	}
+	return alias + " " + strings.Join(fields, " ") + "\n"
+}
+
+// lastLine returns the last non-empty line of output
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// Stop stops the SSH server in containerName and removes its forwarder. removeKeys also
+// deletes the keys on the host, which is done once the container is gone.
+func Stop(d docker.Runner, containerName string, removeKeys bool) {
+	_, _ = d.Run("exec", "-u", "root", containerName, "sh", "-c",
+		`for f in "$0"/sshd.pid "$0"/server.pid; do [ -f "$f" ] && kill "$(cat "$f")" 2>/dev/null; done; true`, configDir)
+	_, _ = d.Run("rm", "-f", Name(containerName))
+	if removeKeys {
+		_ = os.RemoveAll(KeyDir(containerName))
+	}
+}
@@ -0,0 +1,184 @@
+package sshserver
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type fakeDocker struct {
+	calls    [][]string
+	server   string // what the setup script reports
+	networks string // inspect output for the container's networks
+	failOn   string // first argument of the command that fails
+}
+
+func (f *fakeDocker) Command() string {
+	return "docker"
+}
+
+func (f *fakeDocker) Run(args ...string) (string, error) {
+	f.calls = append(f.calls, args)
+	if args[0] == f.failOn {
+		return "boom", errors.New("exit status 1")
+	}
+	switch {
+	case args[0] == "exec" && len(args) > 6 && args[6] == setupScript:
+		return "Reading package lists...\n" + f.server + "\n", nil
+	case args[0] == "inspect" && strings.Contains(args[2], "Config.Env"):
+		return `["PATH=/usr/bin","HOME=/home/vscode","NODE_ENV=test","QUOTED=a\"b"]`, nil
+	case args[0] == "inspect":
+		return f.networks, nil
+	}
+	return "", nil
+}
+
+// fakeKeygen writes placeholder keys in place of ssh-keygen
+func fakeKeygen(path, comment string) error {
+	if err := os.WriteFile(path, []byte("private "+filepath.Base(path)), 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(path+".pub", []byte("ssh-ed25519 AAAA"+filepath.Base(path)+" "+comment+"\n"), 0644)
+}
+
+func testKeys(t *testing.T) *Keys {
+	t.Helper()
+	Keygen = fakeKeygen
+	t.Cleanup(func() { Keygen = defaultKeygen })
+	keys, err := GenerateKeys(filepath.Join(t.TempDir(), "keys"), "packnplay-app-main")
+	if err != nil {
+		t.Fatalf("GenerateKeys() error = %v", err)
+	}
+	return keys
+}
+
+func TestGenerateKeys(t *testing.T) {
+	keys := testKeys(t)
+	if keys.ClientPub != "ssh-ed25519 AAAAid_ed25519 packnplay-app-main" || keys.HostPub != "ssh-ed25519 AAAAhost_ed25519 packnplay-app-main" {
+		t.Errorf("GenerateKeys() = %+v", keys)
+	}
+	info, err := os.Stat(filepath.Dir(keys.ClientKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0700 {
+		t.Errorf("key dir mode = %v, want 0700", info.Mode().Perm())
+	}
+}
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		name      string
+		server    string
+		networks  string
+		failOn    string
+		wantErr   string
+		wantStart string // script of the exec that starts the server
+	}{
+		{name: "openssh", server: "openssh", networks: "bridge 172.17.0.2\n", wantStart: openSSHScript},
+		{name: "dropbear", server: "dropbear", networks: "bridge 172.17.0.2\n", wantStart: dropbearScript},
+		{name: "no network", server: "openssh", networks: "none \n", wantErr: "no network address"},
+		{name: "cp fails", server: "openssh", networks: "bridge 172.17.0.2\n", failOn: "cp", wantErr: "failed to copy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := testKeys(t)
+			d := &fakeDocker{server: tt.server, networks: tt.networks, failOn: tt.failOn}
+			endpoint, err := Start(d, "packnplay-app-main", "vscode", keys, 40022)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Start() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			var started, forwarder []string
+			for _, call := range d.calls {
+				if call[0] == "exec" && len(call) > 6 && call[6] == tt.wantStart {
+					started = call
+				}
+				if call[0] == "run" {
+					forwarder = call
+				}
+			}
+			if started == nil {
+				t.Errorf("Start() didn't start %s: %q", tt.server, d.calls)
+			}
+			want := []string{"run", "-d", "--name", "packnplay-app-main-ssh", "--label", "packnplay-ssh-for=packnplay-app-main",
+				"--network", "bridge", "-p", "127.0.0.1:40022:2222", DefaultForwarderImage,
+				"TCP-LISTEN:2222,fork,reuseaddr", "TCP:172.17.0.2:2222"}
+			if strings.Join(forwarder, " ") != strings.Join(want, " ") {
+				t.Errorf("forwarder = %q, want %q", forwarder, want)
+			}
+
+			if endpoint.Alias != "packnplay-app-main" || endpoint.Port != 40022 || endpoint.User != "vscode" || endpoint.Server != tt.server {
+				t.Errorf("Start() = %+v", endpoint)
+			}
+			knownHosts, err := os.ReadFile(endpoint.KnownHosts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(knownHosts) != "packnplay-app-main ssh-ed25519 AAAAhost_ed25519\n" {
+				t.Errorf("known_hosts = %q", knownHosts)
+			}
+		})
+	}
+}
+
+func TestSSHDConfig(t *testing.T) {
+	env := sessionEnv([]string{"PATH=/usr/bin", "HOME=/home/vscode", "NODE_ENV=test", `QUOTED=a"b`, "TERM=xterm"})
+	got := sshdConfig("vscode", env)
+	for _, line := range []string{
+		"Port 2222\n",
+		"AllowUsers vscode\n",
+		"PasswordAuthentication no\n",
+		"AuthorizedKeysFile /etc/packnplay-ssh/authorized_keys\n",
+		`SetEnv "PATH=/usr/bin" "NODE_ENV=test"` + "\n",
+	} {
+		if !strings.Contains(got, line) {
+			t.Errorf("sshdConfig() missing %q:\n%s", line, got)
+		}
+	}
+	if strings.Contains(sshdConfig("vscode", nil), "SetEnv") {
+		t.Error("sshdConfig() without env has a SetEnv line")
+	}
+}
+
+func TestHostBlock(t *testing.T) {
+	e := Endpoint{Alias: "packnplay-app-main", Port: 40022, User: "vscode", IdentityFile: "/home/me/My Data/id_ed25519", KnownHosts: "/data/known_hosts"}
+	want := `Host packnplay-app-main
+  HostName 127.0.0.1
+  Port 40022
+  User vscode
+  IdentityFile "/home/me/My Data/id_ed25519"
+  IdentitiesOnly yes
+  HostKeyAlias packnplay-app-main
+  UserKnownHostsFile /data/known_hosts
+  StrictHostKeyChecking yes
+`
+	if got := e.HostBlock(); got != want {
+		t.Errorf("HostBlock() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestStop(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	dir := KeyDir("packnplay-app-main")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &fakeDocker{failOn: "exec"}
+	Stop(d, "packnplay-app-main", true)
+	if len(d.calls) != 2 || strings.Join(d.calls[1], " ") != "rm -f packnplay-app-main-ssh" {
+		t.Errorf("Stop() ran %q", d.calls)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Stop() left the key dir: %v", err)
+	}
+}
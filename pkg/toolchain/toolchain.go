@@ -0,0 +1,102 @@
+// Package toolchain detects the language toolchains a project needs from its manifest
+// files, and which variant of the packnplay default image provides them.
+package toolchain
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/obra/packnplay/pkg/config"
+)
+
+// Toolchain is a language toolchain a project needs
+type Toolchain struct {
+	Name     string // go, node, python, or rust
+	Manifest string // the file it was detected from
+}
+
+// manifests maps each toolchain to the files that signal it, most specific first
+var manifests = []struct {
+	name  string
+	files []string
+}{
+	{"go", []string{"go.mod"}},
+	{"node", []string{"package.json"}},
+	{"python", []string{"pyproject.toml", "requirements.txt", "setup.py", "Pipfile"}},
+	{"rust", []string{"Cargo.toml"}},
+}
+
+// variantToolchains lists what each default image variant provides. Every variant
+// has Node, which the AI CLIs run on.
+var variantToolchains = map[string][]string{
+	"full":   {"go", "node", "python", "rust"},
+	"slim":   {"node"},
+	"python": {"node", "python"},
+	"go":     {"go", "node"},
+}
+
+// variantPreference is the order variants are considered in, smallest first
+var variantPreference = []string{"slim", "python", "go", "full"}
+
+// Detect returns the toolchains whose manifests are at the top of dir
+func Detect(dir string) []Toolchain {
+	var found []Toolchain
+	for _, m := range manifests {
+		for _, file := range m.files {
+			if _, err := os.Stat(filepath.Join(dir, file)); err == nil {
+				found = append(found, Toolchain{Name: m.name, Manifest: file})
+				break
+			}
+		}
+	}
+	return found
+}
+
+// Missing returns the toolchains the default image variant doesn't provide
+func Missing(variant string, toolchains []Toolchain) []Toolchain {
+	provided := make(map[string]bool)
+	for _, name := range variantToolchains[variant] {
+		provided[name] = true
+	}
+
+	var missing []Toolchain
+	for _, tc := range toolchains {
+		if !provided[tc.Name] {
+			missing = append(missing, tc)
+		}
+	}
+	return missing
+}
+
+// BestVariant returns the smallest default image variant providing all toolchains
+func BestVariant(toolchains []Toolchain) string {
+	for _, variant := range variantPreference {
+		if len(Missing(variant, toolchains)) == 0 {
+			return variant
+		}
+	}
+	return "full"
+}
+
+// VariantOf returns the default image variant image refers to, or false for other images
+func VariantOf(image string) (string, bool) {
+	for _, variant := range config.DefaultImageVariants {
+		if image == config.ImageForVariant(variant) {
+			return variant, true
+		}
+	}
+	if image == config.DefaultImageRepository {
+		return "full", true
+	}
+	return "", false
+}
+
+// Names returns the toolchain names, comma separated
+func Names(toolchains []Toolchain) string {
+	names := make([]string, len(toolchains))
+	for i, tc := range toolchains {
+		names[i] = tc.Name
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,80 @@
+package toolchain
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{"go.mod", "requirements.txt", "pyproject.toml", "README.md"} {
+		if err := os.WriteFile(filepath.Join(dir, file), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Manifests in subdirectories don't count
+	if err := os.MkdirAll(filepath.Join(dir, "web"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "web", "package.json"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Toolchain{{Name: "go", Manifest: "go.mod"}, {Name: "python", Manifest: "pyproject.toml"}}
+	if got := Detect(dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("Detect() = %+v, want %+v", got, want)
+	}
+}
+
+func TestBestVariant(t *testing.T) {
+	tc := func(names ...string) []Toolchain {
+		var toolchains []Toolchain
+		for _, name := range names {
+			toolchains = append(toolchains, Toolchain{Name: name})
+		}
+		return toolchains
+	}
+
+	tests := []struct {
+		toolchains []Toolchain
+		want       string
+	}{
+		{nil, "slim"},
+		{tc("node"), "slim"},
+		{tc("python", "node"), "python"},
+		{tc("go"), "go"},
+		{tc("go", "python"), "full"},
+		{tc("rust"), "full"},
+	}
+	for _, tt := range tests {
+		if got := BestVariant(tt.toolchains); got != tt.want {
+			t.Errorf("BestVariant(%s) = %q, want %q", Names(tt.toolchains), got, tt.want)
+		}
+	}
+
+	if missing := Missing("go", tc("go", "rust", "python")); Names(missing) != "rust, python" {
+		t.Errorf("Missing() = %s", Names(missing))
+	}
+}
+
+func TestVariantOf(t *testing.T) {
+	tests := []struct {
+		image  string
+		want   string
+		wantOK bool
+	}{
+		{"ghcr.io/obra/packnplay-default:latest", "full", true},
+		{"ghcr.io/obra/packnplay-default", "full", true},
+		{"ghcr.io/obra/packnplay-default:slim", "slim", true},
+		{"ghcr.io/obra/packnplay-default:v1.2", "", false},
+		{"ubuntu:24.04", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := VariantOf(tt.image)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("VariantOf(%q) = %q, %v, want %q, %v", tt.image, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Package ui decides how packnplay draws its output: in color or not, and with Unicode
+// symbols or ASCII stand-ins. NO_COLOR turns color off, a locale that isn't UTF-8 turns
+// the symbols into ASCII, and --plain does both, for logs and terminals that garble box
+// drawing and emoji.
+package ui
+
+import (
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Symbols are the non-letter glyphs packnplay draws with
+type Symbols struct {
+	Bullet    string // separates items in help lines
+	Arrow     string // old -> new
+	Ellipsis  string // marks truncated text
+	Rule      string // repeated to draw separator lines
+	Chevron   string // marks an entry that opens another screen
+	UpDown    string // the up and down keys
+	LeftRight string // the left and right keys
+	Up        string // the up key
+	Done      string // prefixes a success message, with its trailing space
+	Info      string // prefixes a notice, with its trailing space
+	Border    lipgloss.Border
+}
+
+// Unicode is the default symbol set
+var Unicode = Symbols{
+	Bullet:    "•",
+	Arrow:     "→",
+	Ellipsis:  "…",
+	Rule:      "─",
+	Chevron:   "›",
+	UpDown:    "↑/↓",
+	LeftRight: "←/→",
+	Up:        "↑",
+	Done:      "✅ ",
+	Info:      "ℹ️  ",
+	Border:    lipgloss.RoundedBorder(),
+}
+
+// ASCII is the symbol set for plain output
+var ASCII = Symbols{
+	Bullet:    "|",
+	Arrow:     "->",
+	Ellipsis:  "...",
+	Rule:      "-",
+	Chevron:   ">",
+	UpDown:    "up/down",
+	LeftRight: "left/right",
+	Up:        "up",
+	Done:      "",
+	Info:      "",
+	Border:    lipgloss.Border{Top: "-", Bottom: "-", Left: "|", Right: "|", TopLeft: "+", TopRight: "+", BottomLeft: "+", BottomRight: "+"},
+}
+
+var (
+	color = true
+	ascii = false
+
+	// detectedProfile is the color support lipgloss found, restored when color is back on
+	detectedProfile = lipgloss.ColorProfile()
+)
+
+// Setup applies the output mode for this process: plain is --plain, getenv reads NO_COLOR
+// and the locale
+func Setup(plain bool, getenv func(string) string) {
+	color = !plain && getenv("NO_COLOR") == ""
+	ascii = plain || !utf8Locale(getenv)
+	if color {
+		lipgloss.SetColorProfile(detectedProfile)
+	} else {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+}
+
+// Color reports whether output may use color and text styles
+func Color() bool {
+	return color
+}
+
+// Plain reports whether output sticks to ASCII
+func Plain() bool {
+	return ascii
+}
+
+// Sym returns the symbols for the current output mode
+func Sym() Symbols {
+	if ascii {
+		return ASCII
+	}
+	return Unicode
+}
+
+// utf8Locale reports whether the terminal can be assumed to show UTF-8. Only a locale
+// that names another encoding, or the C/POSIX locale, rules it out; an unset locale is
+// taken as UTF-8, as it is on Windows, which doesn't use these variables.
+func utf8Locale(getenv func(string) string) bool {
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	locale := ""
+	for _, key := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale = getenv(key); locale != "" {
+			break
+		}
+	}
+	if locale == "" {
+		return true
+	}
+	if locale == "C" || locale == "POSIX" {
+		return false
+	}
+	_, encoding, ok := strings.Cut(locale, ".")
+	if !ok {
+		return true
+	}
+	encoding, _, _ = strings.Cut(strings.ToLower(encoding), "@")
+	return encoding == "utf-8" || encoding == "utf8"
+}
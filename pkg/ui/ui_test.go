@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestSetup(t *testing.T) {
+	defer Setup(false, func(string) string { return "" })
+
+	tests := []struct {
+		name      string
+		plain     bool
+		env       map[string]string
+		wantColor bool
+		wantPlain bool
+	}{
+		{"defaults", false, nil, true, false},
+		{"NO_COLOR", false, map[string]string{"NO_COLOR": "1"}, false, false},
+		{"--plain", true, map[string]string{"LANG": "en_US.UTF-8"}, false, true},
+		{"C locale", false, map[string]string{"LANG": "C"}, true, runtime.GOOS != "windows"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Setup(tt.plain, func(key string) string { return tt.env[key] })
+			if Color() != tt.wantColor || Plain() != tt.wantPlain {
+				t.Errorf("Color(), Plain() = %v, %v, want %v, %v", Color(), Plain(), tt.wantColor, tt.wantPlain)
+			}
+			wantArrow := Unicode.Arrow
+			if tt.wantPlain {
+				wantArrow = ASCII.Arrow
+			}
+			if Sym().Arrow != wantArrow {
+				t.Errorf("Sym().Arrow = %q, want %q", Sym().Arrow, wantArrow)
+			}
+		})
+	}
+}
+
+func TestUTF8Locale(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows doesn't use locale variables")
+	}
+	tests := []struct {
+		env  map[string]string
+		want bool
+	}{
+		{nil, true},
+		{map[string]string{"LANG": "en_US.UTF-8"}, true},
+		{map[string]string{"LANG": "de_DE.utf8@euro"}, true},
+		{map[string]string{"LANG": "en_US"}, true},
+		{map[string]string{"LANG": "C"}, false},
+		{map[string]string{"LANG": "POSIX"}, false},
+		{map[string]string{"LANG": "en_US.ISO-8859-1"}, false},
+		{map[string]string{"LANG": "en_US.UTF-8", "LC_ALL": "C"}, false},
+		{map[string]string{"LANG": "C", "LC_CTYPE": "C.UTF-8"}, true},
+	}
+	for _, tt := range tests {
+		if got := utf8Locale(func(key string) string { return tt.env[key] }); got != tt.want {
+			t.Errorf("utf8Locale(%v) = %v, want %v", tt.env, got, tt.want)
+		}
+	}
+}